@@ -0,0 +1,62 @@
+// Package main contains the migration of the binary content to the blob store.
+// It works with the running server: every record is uploaded like the client does it and the upload
+// is completed only if the record was not changed concurrently.
+//
+// The content kept in the rows of the binary records is moved to the configured blob store.
+// To switch the backend, e.g. from postgres to S3, run blobmigrate -from postgres with the configuration
+// of the new backend before the server is restarted with it, the blobs of the chunks are copied
+// from the old backend. Run it once more after the restart to copy the chunks uploaded meanwhile.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/ncyellow/GophKeeper/internal/server/config"
+	"github.com/ncyellow/GophKeeper/internal/server/storage"
+	"github.com/ncyellow/GophKeeper/internal/server/storage/keyring"
+)
+
+func main() {
+	from := flag.String("from", "", "copy the blobs of the chunks from the backend: postgres, fs or s3")
+
+	conf, err := config.ParseConfig()
+	if err != nil {
+		log.Fatal().Err(err).Msg("cant parse config")
+	}
+
+	pg, err := storage.NewPgStorage(conf)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cant connect to storage")
+	}
+	defer pg.Close()
+
+	ctx := context.Background()
+
+	if *from != "" && *from != conf.BlobStore {
+		copied, missing, err := pg.CopyBlobs(ctx, *from)
+		if err != nil {
+			log.Fatal().Err(err).Msg("cant copy blobs")
+		}
+		fmt.Printf("Blobs copied from %s: %d, missing or damaged: %d\n", *from, copied, missing)
+	}
+
+	// The content is uploaded through the same chain as by the server, so it is encrypted at rest if configured
+	var store storage.Storage = pg
+	if conf.MasterKey != "" || conf.MasterKeyFile != "" {
+		ring, err := keyring.Load(conf.MasterKey, conf.MasterKeyFile)
+		if err != nil {
+			log.Fatal().Err(err).Msg("cant load master keys")
+		}
+		store = storage.NewEncryptedStorage(pg, pg, ring)
+	}
+
+	moved, skipped, err := storage.MoveInlineBinaries(ctx, storage.NewValidatedStorage(store), pg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cant move binaries")
+	}
+	fmt.Printf("Binaries moved to the blob store: %d, changed concurrently and skipped: %d\n", moved, skipped)
+}
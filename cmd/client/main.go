@@ -24,9 +24,10 @@ func main() {
 		log.Fatal().Err(err)
 	}
 
+	// All records are encrypted on the client side before sending
 	terminal := console.Console{
 		Conf:   conf,
-		Client: sender,
+		Client: api.NewCryptoSender(sender),
 	}
 	terminal.Run()
 }
@@ -0,0 +1,64 @@
+// Package main contains the rotation of the encryption keys of the records at rest.
+// It works with the running server: keys and rows are updated one at a time and only if they were not changed concurrently.
+//
+// Master key rotation: add the new version to the master keys, restart the server and run keyrotate,
+// after that the old version can be removed.
+// Data key rotation: run keyrotate -data-keys, every user gets a new data key and all records, the secrets
+// of the second factor and the chunks of the uploaded content are re-encrypted with it.
+// Run it once more after 5 minutes to re-encrypt the records saved by the server with the old cached keys.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/ncyellow/GophKeeper/internal/server/config"
+	"github.com/ncyellow/GophKeeper/internal/server/storage"
+	"github.com/ncyellow/GophKeeper/internal/server/storage/keyring"
+)
+
+func main() {
+	dataKeys := flag.Bool("data-keys", false, "create new data keys for all users before the re-encryption")
+
+	conf, err := config.ParseConfig()
+	if err != nil {
+		log.Fatal().Err(err).Msg("cant parse config")
+	}
+
+	ring, err := keyring.Load(conf.MasterKey, conf.MasterKeyFile)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cant load master keys")
+	}
+
+	pg, err := storage.NewPgStorage(conf)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cant connect to storage")
+	}
+	defer pg.Close()
+
+	ctx := context.Background()
+	store := storage.NewEncryptedStorage(pg, pg, ring)
+
+	rewrapped, err := store.RewrapKeys(ctx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cant re-wrap data keys")
+	}
+	fmt.Printf("Data keys re-wrapped with master key %d: %d\n", ring.ActiveVersion(), rewrapped)
+
+	if *dataKeys {
+		created, err := store.RotateDataKeys(ctx)
+		if err != nil {
+			log.Fatal().Err(err).Msg("cant rotate data keys")
+		}
+		fmt.Printf("New data keys: %d\n", created)
+	}
+
+	updated, skipped, err := store.Reencrypt(ctx, pg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cant re-encrypt records")
+	}
+	fmt.Printf("Values re-encrypted: %d, changed concurrently and skipped: %d\n", updated, skipped)
+}
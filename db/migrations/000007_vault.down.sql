@@ -0,0 +1,2 @@
+ALTER TABLE "users" DROP COLUMN IF EXISTS "vault_check";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "vault_salt";
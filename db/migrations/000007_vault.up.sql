@@ -0,0 +1,2 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "vault_salt" bytea;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "vault_check" text;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS "user_keys"(
+    "@user_keys" bigserial NOT NULL UNIQUE,
+    "user" bigint REFERENCES users ("@users") ON DELETE CASCADE,
+    "version" integer NOT NULL,
+    "kek_version" integer NOT NULL,
+    "wrapped_key" bytea NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "iuser_keys-user-version" ON "user_keys" USING btree ("user", "version");
+CREATE INDEX IF NOT EXISTS "iuser_keys-kek_version" ON "user_keys" USING btree ("kek_version");
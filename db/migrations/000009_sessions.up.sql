@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS "sessions"(
+    "@sessions" bigserial NOT NULL UNIQUE,
+    "id" text NOT NULL,
+    "user" bigint REFERENCES users ("@users") ON DELETE CASCADE,
+    "refresh_hash" text NOT NULL,
+    "previous_hash" text,
+    "user_agent" text NOT NULL DEFAULT '',
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "refreshed_at" timestamptz NOT NULL DEFAULT now(),
+    "expires_at" timestamptz NOT NULL,
+    "revoked_at" timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "isessions-id" ON "sessions" USING btree ("id");
+CREATE INDEX IF NOT EXISTS "isessions-user" ON "sessions" USING btree ("user");
@@ -0,0 +1,10 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "totp_secret" text;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "totp_enabled" boolean NOT NULL DEFAULT false;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "totp_last_counter" bigint NOT NULL DEFAULT 0;
+CREATE TABLE IF NOT EXISTS "recovery_codes"(
+    "@recovery_codes" bigserial NOT NULL UNIQUE,
+    "user" bigint REFERENCES users ("@users") ON DELETE CASCADE,
+    "code_hash" text NOT NULL,
+    "used_at" timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "irecovery_codes-user-code" ON "recovery_codes" USING btree ("user", "code_hash");
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "lockouts";
+DROP TABLE IF EXISTS "login_attempts";
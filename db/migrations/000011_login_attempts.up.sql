@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "login_attempts"(
+    "key" text NOT NULL PRIMARY KEY,
+    "failures" integer NOT NULL DEFAULT 0,
+    "last_failure_at" timestamptz NOT NULL DEFAULT now(),
+    "locked_until" timestamptz
+);
+CREATE TABLE IF NOT EXISTS "lockouts"(
+    "@lockouts" bigserial NOT NULL UNIQUE,
+    "user" bigint REFERENCES users ("@users") ON DELETE CASCADE,
+    "ip" text NOT NULL DEFAULT '',
+    "failures" integer NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "locked_until" timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "ilockouts-user" ON "lockouts" USING btree ("user", "created_at");
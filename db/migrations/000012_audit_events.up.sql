@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS "audit_events"(
+    "@audit_events" bigserial NOT NULL UNIQUE,
+    "user" bigint REFERENCES users ("@users") ON DELETE CASCADE,
+    "actor" text NOT NULL,
+    "action" text NOT NULL,
+    "kind" text NOT NULL DEFAULT '',
+    "record" text NOT NULL DEFAULT '',
+    "transport" text NOT NULL DEFAULT '',
+    "ip" text NOT NULL DEFAULT '',
+    "created_at" timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS "iaudit_events-user" ON "audit_events" USING btree ("user", "@audit_events");
+-- the audit log is append-only, the rows are removed only together with the user
+CREATE OR REPLACE FUNCTION "audit_events_append_only"() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER "audit_events-append-only" BEFORE UPDATE ON "audit_events"
+    FOR EACH ROW EXECUTE FUNCTION "audit_events_append_only"();
@@ -0,0 +1,4 @@
+ALTER TABLE "cards" DROP COLUMN IF EXISTS "version";
+ALTER TABLE "logins" DROP COLUMN IF EXISTS "version";
+ALTER TABLE "text_data" DROP COLUMN IF EXISTS "version";
+ALTER TABLE "bin_data" DROP COLUMN IF EXISTS "version";
@@ -0,0 +1,4 @@
+ALTER TABLE "cards" ADD COLUMN IF NOT EXISTS "version" bigint NOT NULL DEFAULT 1;
+ALTER TABLE "logins" ADD COLUMN IF NOT EXISTS "version" bigint NOT NULL DEFAULT 1;
+ALTER TABLE "text_data" ADD COLUMN IF NOT EXISTS "version" bigint NOT NULL DEFAULT 1;
+ALTER TABLE "bin_data" ADD COLUMN IF NOT EXISTS "version" bigint NOT NULL DEFAULT 1;
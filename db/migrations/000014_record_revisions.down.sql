@@ -0,0 +1,11 @@
+DROP TRIGGER IF EXISTS "cards-revision" ON "cards";
+DROP TRIGGER IF EXISTS "cards-revision-update" ON "cards";
+DROP TRIGGER IF EXISTS "logins-revision" ON "logins";
+DROP TRIGGER IF EXISTS "logins-revision-update" ON "logins";
+DROP TRIGGER IF EXISTS "text_data-revision" ON "text_data";
+DROP TRIGGER IF EXISTS "text_data-revision-update" ON "text_data";
+DROP TRIGGER IF EXISTS "bin_data-revision" ON "bin_data";
+DROP TRIGGER IF EXISTS "bin_data-revision-update" ON "bin_data";
+DROP FUNCTION IF EXISTS "record_revision";
+DROP TABLE IF EXISTS "record_revisions";
+DROP FUNCTION IF EXISTS "record_revisions_immutable";
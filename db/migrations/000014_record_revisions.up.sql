@@ -0,0 +1,54 @@
+CREATE TABLE IF NOT EXISTS "record_revisions"(
+    "@record_revisions" bigserial NOT NULL UNIQUE,
+    "user" bigint REFERENCES users ("@users") ON DELETE CASCADE,
+    "kind" text NOT NULL,
+    "id" text NOT NULL,
+    "version" bigint NOT NULL,
+    "action" text NOT NULL,
+    "data" jsonb NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS "irecord_revisions-user-kind-id" ON "record_revisions" USING btree ("user", "kind", "id");
+CREATE INDEX IF NOT EXISTS "irecord_revisions-created_at" ON "record_revisions" USING btree ("created_at");
+-- every change of a record is kept with the values of the columns as they are stored, the encrypted ones stay encrypted.
+-- The re-encryption by the key rotation does not change the version and is not a revision
+CREATE OR REPLACE FUNCTION "record_revision"() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        -- the records of the deleted user are removed by the cascade, nothing is kept for them
+        IF NOT EXISTS (SELECT 1 FROM "users" WHERE "@users" = OLD."user") THEN
+            RETURN OLD;
+        END IF;
+        INSERT INTO "record_revisions"("user", "kind", "id", "version", "action", "data")
+        VALUES (OLD."user", TG_ARGV[0], OLD."id", OLD."version", 'delete', to_jsonb(OLD));
+        RETURN OLD;
+    END IF;
+    INSERT INTO "record_revisions"("user", "kind", "id", "version", "action", "data")
+    VALUES (NEW."user", TG_ARGV[0], NEW."id", NEW."version", CASE TG_OP WHEN 'INSERT' THEN 'add' ELSE 'update' END, to_jsonb(NEW));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER "cards-revision" AFTER INSERT OR DELETE ON "cards"
+    FOR EACH ROW EXECUTE FUNCTION "record_revision"('card');
+CREATE TRIGGER "cards-revision-update" AFTER UPDATE ON "cards"
+    FOR EACH ROW WHEN (OLD."version" IS DISTINCT FROM NEW."version") EXECUTE FUNCTION "record_revision"('card');
+CREATE TRIGGER "logins-revision" AFTER INSERT OR DELETE ON "logins"
+    FOR EACH ROW EXECUTE FUNCTION "record_revision"('login');
+CREATE TRIGGER "logins-revision-update" AFTER UPDATE ON "logins"
+    FOR EACH ROW WHEN (OLD."version" IS DISTINCT FROM NEW."version") EXECUTE FUNCTION "record_revision"('login');
+CREATE TRIGGER "text_data-revision" AFTER INSERT OR DELETE ON "text_data"
+    FOR EACH ROW EXECUTE FUNCTION "record_revision"('text');
+CREATE TRIGGER "text_data-revision-update" AFTER UPDATE ON "text_data"
+    FOR EACH ROW WHEN (OLD."version" IS DISTINCT FROM NEW."version") EXECUTE FUNCTION "record_revision"('text');
+CREATE TRIGGER "bin_data-revision" AFTER INSERT OR DELETE ON "bin_data"
+    FOR EACH ROW EXECUTE FUNCTION "record_revision"('bin');
+CREATE TRIGGER "bin_data-revision-update" AFTER UPDATE ON "bin_data"
+    FOR EACH ROW WHEN (OLD."version" IS DISTINCT FROM NEW."version") EXECUTE FUNCTION "record_revision"('bin');
+-- the revisions are immutable, they are removed only by the retention or together with the user
+CREATE OR REPLACE FUNCTION "record_revisions_immutable"() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'record_revisions are immutable';
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER "record_revisions-immutable" BEFORE UPDATE ON "record_revisions"
+    FOR EACH ROW EXECUTE FUNCTION "record_revisions_immutable"();
@@ -0,0 +1,36 @@
+DROP TRIGGER IF EXISTS "cards-revision-update" ON "cards";
+CREATE TRIGGER "cards-revision-update" AFTER UPDATE ON "cards"
+    FOR EACH ROW WHEN (OLD."version" IS DISTINCT FROM NEW."version") EXECUTE FUNCTION "record_revision"('card');
+DROP TRIGGER IF EXISTS "logins-revision-update" ON "logins";
+CREATE TRIGGER "logins-revision-update" AFTER UPDATE ON "logins"
+    FOR EACH ROW WHEN (OLD."version" IS DISTINCT FROM NEW."version") EXECUTE FUNCTION "record_revision"('login');
+DROP TRIGGER IF EXISTS "text_data-revision-update" ON "text_data";
+CREATE TRIGGER "text_data-revision-update" AFTER UPDATE ON "text_data"
+    FOR EACH ROW WHEN (OLD."version" IS DISTINCT FROM NEW."version") EXECUTE FUNCTION "record_revision"('text');
+DROP TRIGGER IF EXISTS "bin_data-revision-update" ON "bin_data";
+CREATE TRIGGER "bin_data-revision-update" AFTER UPDATE ON "bin_data"
+    FOR EACH ROW WHEN (OLD."version" IS DISTINCT FROM NEW."version") EXECUTE FUNCTION "record_revision"('bin');
+-- the trashed records were deleted by the user, they must not come back
+DELETE FROM "cards" WHERE "deleted_at" IS NOT NULL;
+DELETE FROM "logins" WHERE "deleted_at" IS NOT NULL;
+DELETE FROM "text_data" WHERE "deleted_at" IS NOT NULL;
+DELETE FROM "bin_data" WHERE "deleted_at" IS NOT NULL;
+CREATE OR REPLACE FUNCTION "record_revision"() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        IF NOT EXISTS (SELECT 1 FROM "users" WHERE "@users" = OLD."user") THEN
+            RETURN OLD;
+        END IF;
+        INSERT INTO "record_revisions"("user", "kind", "id", "version", "action", "data")
+        VALUES (OLD."user", TG_ARGV[0], OLD."id", OLD."version", 'delete', to_jsonb(OLD));
+        RETURN OLD;
+    END IF;
+    INSERT INTO "record_revisions"("user", "kind", "id", "version", "action", "data")
+    VALUES (NEW."user", TG_ARGV[0], NEW."id", NEW."version", CASE TG_OP WHEN 'INSERT' THEN 'add' ELSE 'update' END, to_jsonb(NEW));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+ALTER TABLE "cards" DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE "logins" DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE "text_data" DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE "bin_data" DROP COLUMN IF EXISTS "deleted_at";
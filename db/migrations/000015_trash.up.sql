@@ -0,0 +1,46 @@
+ALTER TABLE "cards" ADD COLUMN IF NOT EXISTS "deleted_at" timestamptz;
+ALTER TABLE "logins" ADD COLUMN IF NOT EXISTS "deleted_at" timestamptz;
+ALTER TABLE "text_data" ADD COLUMN IF NOT EXISTS "deleted_at" timestamptz;
+ALTER TABLE "bin_data" ADD COLUMN IF NOT EXISTS "deleted_at" timestamptz;
+CREATE INDEX IF NOT EXISTS "icards-deleted_at" ON "cards" USING btree ("deleted_at") WHERE "deleted_at" IS NOT NULL;
+CREATE INDEX IF NOT EXISTS "ilogins-deleted_at" ON "logins" USING btree ("deleted_at") WHERE "deleted_at" IS NOT NULL;
+CREATE INDEX IF NOT EXISTS "itext_data-deleted_at" ON "text_data" USING btree ("deleted_at") WHERE "deleted_at" IS NOT NULL;
+CREATE INDEX IF NOT EXISTS "ibin_data-deleted_at" ON "bin_data" USING btree ("deleted_at") WHERE "deleted_at" IS NOT NULL;
+-- the record moved to the trash is the delete revision, its removal from the trash is not a revision any more
+CREATE OR REPLACE FUNCTION "record_revision"() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        -- the records of the deleted user are removed by the cascade, nothing is kept for them
+        IF OLD."deleted_at" IS NOT NULL OR NOT EXISTS (SELECT 1 FROM "users" WHERE "@users" = OLD."user") THEN
+            RETURN OLD;
+        END IF;
+        INSERT INTO "record_revisions"("user", "kind", "id", "version", "action", "data")
+        VALUES (OLD."user", TG_ARGV[0], OLD."id", OLD."version", 'delete', to_jsonb(OLD));
+        RETURN OLD;
+    END IF;
+    INSERT INTO "record_revisions"("user", "kind", "id", "version", "action", "data")
+    VALUES (NEW."user", TG_ARGV[0], NEW."id", NEW."version", CASE
+        WHEN TG_OP = 'INSERT' THEN 'add'
+        WHEN OLD."deleted_at" IS NULL AND NEW."deleted_at" IS NOT NULL THEN 'delete'
+        WHEN OLD."deleted_at" IS NOT NULL AND NEW."deleted_at" IS NULL THEN 'restore'
+        ELSE 'update'
+    END, to_jsonb(NEW));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS "cards-revision-update" ON "cards";
+CREATE TRIGGER "cards-revision-update" AFTER UPDATE ON "cards"
+    FOR EACH ROW WHEN (OLD."version" IS DISTINCT FROM NEW."version" OR OLD."deleted_at" IS DISTINCT FROM NEW."deleted_at")
+    EXECUTE FUNCTION "record_revision"('card');
+DROP TRIGGER IF EXISTS "logins-revision-update" ON "logins";
+CREATE TRIGGER "logins-revision-update" AFTER UPDATE ON "logins"
+    FOR EACH ROW WHEN (OLD."version" IS DISTINCT FROM NEW."version" OR OLD."deleted_at" IS DISTINCT FROM NEW."deleted_at")
+    EXECUTE FUNCTION "record_revision"('login');
+DROP TRIGGER IF EXISTS "text_data-revision-update" ON "text_data";
+CREATE TRIGGER "text_data-revision-update" AFTER UPDATE ON "text_data"
+    FOR EACH ROW WHEN (OLD."version" IS DISTINCT FROM NEW."version" OR OLD."deleted_at" IS DISTINCT FROM NEW."deleted_at")
+    EXECUTE FUNCTION "record_revision"('text');
+DROP TRIGGER IF EXISTS "bin_data-revision-update" ON "bin_data";
+CREATE TRIGGER "bin_data-revision-update" AFTER UPDATE ON "bin_data"
+    FOR EACH ROW WHEN (OLD."version" IS DISTINCT FROM NEW."version" OR OLD."deleted_at" IS DISTINCT FROM NEW."deleted_at")
+    EXECUTE FUNCTION "record_revision"('bin');
@@ -0,0 +1,19 @@
+DROP TRIGGER IF EXISTS "cards-meta" ON "cards";
+DROP TRIGGER IF EXISTS "logins-meta" ON "logins";
+DROP TRIGGER IF EXISTS "text_data-meta" ON "text_data";
+DROP TRIGGER IF EXISTS "bin_data-meta" ON "bin_data";
+DROP FUNCTION IF EXISTS "remove_record_meta";
+DROP FUNCTION IF EXISTS "set_record_meta";
+DROP FUNCTION IF EXISTS "record_fields_of";
+DROP FUNCTION IF EXISTS "record_tags_of";
+DROP TABLE IF EXISTS "record_fields";
+DROP TABLE IF EXISTS "record_tags";
+
+ALTER TABLE "record_revisions" DISABLE TRIGGER "record_revisions-immutable";
+UPDATE "record_revisions" SET "data" = ("data" - 'note') || jsonb_build_object('metainfo', "data"->'note')
+WHERE "data" ? 'note';
+ALTER TABLE "record_revisions" ENABLE TRIGGER "record_revisions-immutable";
+ALTER TABLE "cards" RENAME COLUMN "note" TO "metainfo";
+ALTER TABLE "logins" RENAME COLUMN "note" TO "metainfo";
+ALTER TABLE "text_data" RENAME COLUMN "note" TO "metainfo";
+ALTER TABLE "bin_data" RENAME COLUMN "note" TO "metainfo";
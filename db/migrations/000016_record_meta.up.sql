@@ -0,0 +1,66 @@
+-- the free-form metainfo becomes the note, the structured metadata is kept in the tags and the custom fields
+ALTER TABLE "cards" RENAME COLUMN "metainfo" TO "note";
+ALTER TABLE "logins" RENAME COLUMN "metainfo" TO "note";
+ALTER TABLE "text_data" RENAME COLUMN "metainfo" TO "note";
+ALTER TABLE "bin_data" RENAME COLUMN "metainfo" TO "note";
+-- the revisions keep the rows as they were stored, the old ones are renamed too to be restored
+ALTER TABLE "record_revisions" DISABLE TRIGGER "record_revisions-immutable";
+UPDATE "record_revisions" SET "data" = ("data" - 'metainfo') || jsonb_build_object('note', "data"->'metainfo')
+WHERE "data" ? 'metainfo';
+ALTER TABLE "record_revisions" ENABLE TRIGGER "record_revisions-immutable";
+
+CREATE TABLE IF NOT EXISTS "record_tags"(
+    "user" bigint NOT NULL REFERENCES users ("@users") ON DELETE CASCADE,
+    "kind" text NOT NULL,
+    "id" text NOT NULL,
+    "tag" text NOT NULL CHECK ("tag" <> ''),
+    PRIMARY KEY ("user", "kind", "id", "tag")
+);
+CREATE INDEX IF NOT EXISTS "irecord_tags-user-tag" ON "record_tags" USING btree ("user", "tag");
+CREATE TABLE IF NOT EXISTS "record_fields"(
+    "user" bigint NOT NULL REFERENCES users ("@users") ON DELETE CASCADE,
+    "kind" text NOT NULL,
+    "id" text NOT NULL,
+    "name" text NOT NULL CHECK ("name" <> ''),
+    "value" text NOT NULL,
+    PRIMARY KEY ("user", "kind", "id", "name")
+);
+CREATE INDEX IF NOT EXISTS "irecord_fields-user-name-value" ON "record_fields" USING btree ("user", "name", "value");
+
+-- record_tags_of and record_fields_of read the metadata of the record together with its row
+CREATE OR REPLACE FUNCTION "record_tags_of"(bigint, text, text) RETURNS text[] AS $$
+    SELECT COALESCE(array_agg("tag" ORDER BY "tag"), '{}') FROM "record_tags"
+    WHERE "user" = $1 AND "kind" = $2 AND "id" = $3
+$$ LANGUAGE sql STABLE;
+CREATE OR REPLACE FUNCTION "record_fields_of"(bigint, text, text) RETURNS jsonb AS $$
+    SELECT COALESCE(jsonb_object_agg("name", "value"), '{}') FROM "record_fields"
+    WHERE "user" = $1 AND "kind" = $2 AND "id" = $3
+$$ LANGUAGE sql STABLE;
+-- set_record_meta replaces the metadata of the record, it is called in the statement writing the record
+CREATE OR REPLACE FUNCTION "set_record_meta"(bigint, text, text, text[], text[], text[]) RETURNS void AS $$
+BEGIN
+    DELETE FROM "record_tags" WHERE "user" = $1 AND "kind" = $2 AND "id" = $3;
+    INSERT INTO "record_tags"("user", "kind", "id", "tag")
+    SELECT DISTINCT $1, $2, $3, "tag" FROM unnest($4) AS t("tag");
+    DELETE FROM "record_fields" WHERE "user" = $1 AND "kind" = $2 AND "id" = $3;
+    INSERT INTO "record_fields"("user", "kind", "id", "name", "value")
+    SELECT $1, $2, $3, "name", "value" FROM unnest($5, $6) AS f("name", "value");
+END;
+$$ LANGUAGE plpgsql;
+
+-- the metadata is removed together with the record, the revisions do not keep it
+CREATE OR REPLACE FUNCTION "remove_record_meta"() RETURNS trigger AS $$
+BEGIN
+    DELETE FROM "record_tags" WHERE "user" = OLD."user" AND "kind" = TG_ARGV[0] AND "id" = OLD."id";
+    DELETE FROM "record_fields" WHERE "user" = OLD."user" AND "kind" = TG_ARGV[0] AND "id" = OLD."id";
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER "cards-meta" AFTER DELETE ON "cards"
+    FOR EACH ROW EXECUTE FUNCTION "remove_record_meta"('card');
+CREATE TRIGGER "logins-meta" AFTER DELETE ON "logins"
+    FOR EACH ROW EXECUTE FUNCTION "remove_record_meta"('login');
+CREATE TRIGGER "text_data-meta" AFTER DELETE ON "text_data"
+    FOR EACH ROW EXECUTE FUNCTION "remove_record_meta"('text');
+CREATE TRIGGER "bin_data-meta" AFTER DELETE ON "bin_data"
+    FOR EACH ROW EXECUTE FUNCTION "remove_record_meta"('bin');
@@ -0,0 +1,13 @@
+CREATE OR REPLACE FUNCTION "remove_record_meta"() RETURNS trigger AS $$
+BEGIN
+    DELETE FROM "record_tags" WHERE "user" = OLD."user" AND "kind" = TG_ARGV[0] AND "id" = OLD."id";
+    DELETE FROM "record_fields" WHERE "user" = OLD."user" AND "kind" = TG_ARGV[0] AND "id" = OLD."id";
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+DROP FUNCTION IF EXISTS "set_record_location";
+DROP FUNCTION IF EXISTS "record_folder_of";
+DROP FUNCTION IF EXISTS "record_vault_of";
+DROP TABLE IF EXISTS "record_locations";
+DROP TABLE IF EXISTS "folders";
+DROP TABLE IF EXISTS "vaults";
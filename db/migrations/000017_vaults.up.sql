@@ -0,0 +1,62 @@
+-- the named vaults and the nested folders of the records, the records not in a vault are on the top level
+CREATE TABLE IF NOT EXISTS "vaults"(
+    "@vaults" bigserial PRIMARY KEY,
+    "user" bigint NOT NULL REFERENCES users ("@users") ON DELETE CASCADE,
+    "name" text NOT NULL CHECK ("name" <> ''),
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    UNIQUE ("user", "name")
+);
+CREATE TABLE IF NOT EXISTS "folders"(
+    "@folders" bigserial PRIMARY KEY,
+    "user" bigint NOT NULL REFERENCES users ("@users") ON DELETE CASCADE,
+    "vault" bigint NOT NULL REFERENCES vaults ("@vaults") ON DELETE CASCADE,
+    -- the folders without the parent are in the root of the vault
+    "parent" bigint REFERENCES folders ("@folders") ON DELETE CASCADE,
+    "name" text NOT NULL CHECK ("name" <> ''),
+    "created_at" timestamptz NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "ifolders-vault-parent-name" ON "folders" USING btree ("vault", COALESCE("parent", 0), "name");
+-- the removed folder moves its records to the root of the vault, the removed vault moves them to the top level
+CREATE TABLE IF NOT EXISTS "record_locations"(
+    "user" bigint NOT NULL REFERENCES users ("@users") ON DELETE CASCADE,
+    "kind" text NOT NULL,
+    "id" text NOT NULL,
+    "vault" bigint NOT NULL REFERENCES vaults ("@vaults") ON DELETE CASCADE,
+    "folder" bigint REFERENCES folders ("@folders") ON DELETE SET NULL,
+    PRIMARY KEY ("user", "kind", "id")
+);
+CREATE INDEX IF NOT EXISTS "irecord_locations-vault-folder" ON "record_locations" USING btree ("vault", "folder");
+
+CREATE OR REPLACE FUNCTION "record_vault_of"(bigint, text, text) RETURNS bigint AS $$
+    SELECT COALESCE((SELECT "vault" FROM "record_locations" WHERE "user" = $1 AND "kind" = $2 AND "id" = $3), 0)
+$$ LANGUAGE sql STABLE;
+CREATE OR REPLACE FUNCTION "record_folder_of"(bigint, text, text) RETURNS bigint AS $$
+    SELECT COALESCE((SELECT "folder" FROM "record_locations" WHERE "user" = $1 AND "kind" = $2 AND "id" = $3), 0)
+$$ LANGUAGE sql STABLE;
+-- set_record_location moves the record to the vault and the folder of the user, the zero vault is the top level
+-- and the zero folder is the root of the vault
+CREATE OR REPLACE FUNCTION "set_record_location"(bigint, text, text, bigint, bigint) RETURNS void AS $$
+BEGIN
+    IF $4 = 0 THEN
+        DELETE FROM "record_locations" WHERE "user" = $1 AND "kind" = $2 AND "id" = $3;
+        RETURN;
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM "vaults" WHERE "@vaults" = $4 AND "user" = $1)
+        OR ($5 <> 0 AND NOT EXISTS (SELECT 1 FROM "folders" WHERE "@folders" = $5 AND "vault" = $4)) THEN
+        RAISE foreign_key_violation USING MESSAGE = 'no such vault or folder';
+    END IF;
+    INSERT INTO "record_locations"("user", "kind", "id", "vault", "folder")
+    VALUES ($1, $2, $3, $4, NULLIF($5, 0))
+    ON CONFLICT ("user", "kind", "id") DO UPDATE SET "vault" = EXCLUDED."vault", "folder" = EXCLUDED."folder";
+END;
+$$ LANGUAGE plpgsql;
+
+-- the location is removed together with the record like the tags and the fields
+CREATE OR REPLACE FUNCTION "remove_record_meta"() RETURNS trigger AS $$
+BEGIN
+    DELETE FROM "record_tags" WHERE "user" = OLD."user" AND "kind" = TG_ARGV[0] AND "id" = OLD."id";
+    DELETE FROM "record_fields" WHERE "user" = OLD."user" AND "kind" = TG_ARGV[0] AND "id" = OLD."id";
+    DELETE FROM "record_locations" WHERE "user" = OLD."user" AND "kind" = TG_ARGV[0] AND "id" = OLD."id";
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,2 @@
+-- the otpauth:// uri of the second factor of the login, it is encrypted like the password
+ALTER TABLE "logins" ADD COLUMN IF NOT EXISTS "totp" text NOT NULL DEFAULT '';
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "ssh_keys";
+DELETE FROM "record_tags" WHERE "kind" = 'ssh';
+DELETE FROM "record_fields" WHERE "kind" = 'ssh';
+DELETE FROM "record_locations" WHERE "kind" = 'ssh';
+ALTER TABLE "record_revisions" DISABLE TRIGGER "record_revisions-immutable";
+DELETE FROM "record_revisions" WHERE "kind" = 'ssh';
+ALTER TABLE "record_revisions" ENABLE TRIGGER "record_revisions-immutable";
@@ -0,0 +1,22 @@
+-- the ssh key pairs, only the private key is a secret. The fingerprint is computed from the public key on save
+CREATE TABLE IF NOT EXISTS "ssh_keys"(
+    "@ssh_keys" bigserial NOT NULL UNIQUE,
+    "id" text NOT NULL,
+    "user" bigint REFERENCES users ("@users") ON DELETE CASCADE,
+    "private_key" text NOT NULL,
+    "public_key" text NOT NULL,
+    "comment" text NOT NULL DEFAULT '',
+    "fingerprint" text NOT NULL,
+    "note" text,
+    "version" bigint NOT NULL DEFAULT 1,
+    "deleted_at" timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "issh_keys-user-id" ON "ssh_keys" USING btree ("id", "user");
+CREATE INDEX IF NOT EXISTS "issh_keys-deleted_at" ON "ssh_keys" USING btree ("deleted_at") WHERE "deleted_at" IS NOT NULL;
+CREATE TRIGGER "ssh_keys-revision" AFTER INSERT OR DELETE ON "ssh_keys"
+    FOR EACH ROW EXECUTE FUNCTION "record_revision"('ssh');
+CREATE TRIGGER "ssh_keys-revision-update" AFTER UPDATE ON "ssh_keys"
+    FOR EACH ROW WHEN (OLD."version" IS DISTINCT FROM NEW."version" OR OLD."deleted_at" IS DISTINCT FROM NEW."deleted_at")
+    EXECUTE FUNCTION "record_revision"('ssh');
+CREATE TRIGGER "ssh_keys-meta" AFTER DELETE ON "ssh_keys"
+    FOR EACH ROW EXECUTE FUNCTION "remove_record_meta"('ssh');
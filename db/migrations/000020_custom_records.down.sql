@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS "custom_records";
+DROP TABLE IF EXISTS "record_types";
+DELETE FROM "record_tags" WHERE "kind" = 'custom';
+DELETE FROM "record_fields" WHERE "kind" = 'custom';
+DELETE FROM "record_locations" WHERE "kind" = 'custom';
+ALTER TABLE "record_revisions" DISABLE TRIGGER "record_revisions-immutable";
+DELETE FROM "record_revisions" WHERE "kind" = 'custom';
+ALTER TABLE "record_revisions" ENABLE TRIGGER "record_revisions-immutable";
@@ -0,0 +1,34 @@
+-- the record types defined by the users, the fields are the schema of the records of the type
+CREATE TABLE IF NOT EXISTS "record_types"(
+    "@record_types" bigserial PRIMARY KEY,
+    "user" bigint NOT NULL REFERENCES users ("@users") ON DELETE CASCADE,
+    "name" text NOT NULL CHECK ("name" <> ''),
+    "fields" jsonb NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    UNIQUE ("user", "name")
+);
+-- the records of the user types. The values of the secret fields are kept in one column encrypted at rest,
+-- both columns hold the json objects of the values by the field name. The type can not be removed while it has
+-- records, the deleted ones in the trash too
+CREATE TABLE IF NOT EXISTS "custom_records"(
+    "@custom_records" bigserial NOT NULL UNIQUE,
+    "id" text NOT NULL,
+    "user" bigint REFERENCES users ("@users") ON DELETE CASCADE,
+    "type" text NOT NULL,
+    "values" text NOT NULL DEFAULT '{}',
+    "secrets" text NOT NULL DEFAULT '{}',
+    "note" text,
+    "version" bigint NOT NULL DEFAULT 1,
+    "deleted_at" timestamptz,
+    FOREIGN KEY ("user", "type") REFERENCES record_types ("user", "name") ON DELETE RESTRICT
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "icustom_records-user-id" ON "custom_records" USING btree ("id", "user");
+CREATE INDEX IF NOT EXISTS "icustom_records-user-type" ON "custom_records" USING btree ("user", "type");
+CREATE INDEX IF NOT EXISTS "icustom_records-deleted_at" ON "custom_records" USING btree ("deleted_at") WHERE "deleted_at" IS NOT NULL;
+CREATE TRIGGER "custom_records-revision" AFTER INSERT OR DELETE ON "custom_records"
+    FOR EACH ROW EXECUTE FUNCTION "record_revision"('custom');
+CREATE TRIGGER "custom_records-revision-update" AFTER UPDATE ON "custom_records"
+    FOR EACH ROW WHEN (OLD."version" IS DISTINCT FROM NEW."version" OR OLD."deleted_at" IS DISTINCT FROM NEW."deleted_at")
+    EXECUTE FUNCTION "record_revision"('custom');
+CREATE TRIGGER "custom_records-meta" AFTER DELETE ON "custom_records"
+    FOR EACH ROW EXECUTE FUNCTION "remove_record_meta"('custom');
@@ -0,0 +1,4 @@
+ALTER TABLE "bin_data" DROP COLUMN IF EXISTS "size";
+ALTER TABLE "bin_data" DROP COLUMN IF EXISTS "blob";
+DROP TABLE IF EXISTS "bin_chunks";
+DROP TABLE IF EXISTS "bin_uploads";
@@ -0,0 +1,26 @@
+-- the large binary content is uploaded in chunks. The completed upload becomes the content of the record,
+-- the previous content is removed. The abandoned uploads are removed by the server after a day
+CREATE TABLE IF NOT EXISTS "bin_uploads"(
+    "id" text NOT NULL PRIMARY KEY,
+    "user" bigint NOT NULL REFERENCES users ("@users") ON DELETE CASCADE,
+    "@bin" bigint NOT NULL REFERENCES "bin_data" ("@bin") ON DELETE CASCADE,
+    "size" bigint NOT NULL DEFAULT 0,
+    "chunks" bigint NOT NULL DEFAULT 0,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "completed_at" timestamptz
+);
+CREATE INDEX IF NOT EXISTS "ibin_uploads-bin" ON "bin_uploads" USING btree ("@bin");
+CREATE INDEX IF NOT EXISTS "ibin_uploads-created_at" ON "bin_uploads" USING btree ("created_at") WHERE "completed_at" IS NULL;
+
+-- the chunks are encrypted at rest one by one, so the compression is useless
+CREATE TABLE IF NOT EXISTS "bin_chunks"(
+    "upload" text NOT NULL REFERENCES "bin_uploads" ("id") ON DELETE CASCADE,
+    "seq" bigint NOT NULL,
+    "data" bytea NOT NULL,
+    PRIMARY KEY ("upload", "seq")
+);
+ALTER TABLE "bin_chunks" ALTER COLUMN "data" SET STORAGE EXTERNAL;
+
+-- blob is the id of the completed upload, NULL if the data is in the content column
+ALTER TABLE "bin_data" ADD COLUMN IF NOT EXISTS "blob" text;
+ALTER TABLE "bin_data" ADD COLUMN IF NOT EXISTS "size" bigint NOT NULL DEFAULT 0;
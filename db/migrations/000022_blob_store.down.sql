@@ -0,0 +1,14 @@
+DROP TRIGGER IF EXISTS "bin_uploads-blobs" ON "bin_uploads";
+DROP FUNCTION IF EXISTS "delete_upload_blobs"();
+DROP TABLE IF EXISTS "blob_deletions";
+
+-- only the blobs of the postgres backend are moved back, the ones of the other backends have to be copied by blobmigrate first
+ALTER TABLE "bin_chunks" ADD COLUMN IF NOT EXISTS "data" bytea;
+UPDATE "bin_chunks" c SET "data" = b."data"
+FROM "blobs" b
+WHERE b."key" = c."upload" || '/' || c."seq" || '/' || c."sha256";
+DELETE FROM "bin_chunks" WHERE "data" IS NULL;
+ALTER TABLE "bin_chunks" ALTER COLUMN "data" SET NOT NULL;
+ALTER TABLE "bin_chunks" ALTER COLUMN "data" SET STORAGE EXTERNAL;
+ALTER TABLE "bin_chunks" DROP COLUMN IF EXISTS "sha256";
+DROP TABLE IF EXISTS "blobs";
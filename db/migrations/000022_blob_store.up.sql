@@ -0,0 +1,29 @@
+-- the payloads of the chunks are kept in the blob store, postgres keeps only their checksums.
+-- The blobs table is the blob store of the postgres backend, the key is "<upload>/<seq>/<sha256>"
+CREATE TABLE IF NOT EXISTS "blobs"(
+    "key" text NOT NULL PRIMARY KEY,
+    "data" bytea NOT NULL
+);
+ALTER TABLE "blobs" ALTER COLUMN "data" SET STORAGE EXTERNAL;
+
+ALTER TABLE "bin_chunks" ADD COLUMN IF NOT EXISTS "sha256" text;
+INSERT INTO "blobs"("key", "data")
+SELECT "upload" || '/' || "seq" || '/' || encode(sha256("data"), 'hex'), "data" FROM "bin_chunks"
+ON CONFLICT ("key") DO NOTHING;
+UPDATE "bin_chunks" SET "sha256" = encode(sha256("data"), 'hex');
+ALTER TABLE "bin_chunks" ALTER COLUMN "sha256" SET NOT NULL;
+ALTER TABLE "bin_chunks" DROP COLUMN IF EXISTS "data";
+
+-- the blobs of the removed uploads are removed from the blob store by the server, see PurgeBlobs
+CREATE TABLE IF NOT EXISTS "blob_deletions"(
+    "prefix" text NOT NULL PRIMARY KEY,
+    "created_at" timestamptz NOT NULL DEFAULT now()
+);
+CREATE OR REPLACE FUNCTION "delete_upload_blobs"() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO "blob_deletions"("prefix") VALUES (OLD."id" || '/') ON CONFLICT ("prefix") DO NOTHING;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER "bin_uploads-blobs" AFTER DELETE ON "bin_uploads"
+    FOR EACH ROW EXECUTE FUNCTION "delete_upload_blobs"();
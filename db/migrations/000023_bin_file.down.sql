@@ -0,0 +1,4 @@
+ALTER TABLE "bin_data" DROP COLUMN IF EXISTS "mod_time";
+ALTER TABLE "bin_data" DROP COLUMN IF EXISTS "mime_type";
+ALTER TABLE "bin_data" DROP COLUMN IF EXISTS "file_size";
+ALTER TABLE "bin_data" DROP COLUMN IF EXISTS "file_name";
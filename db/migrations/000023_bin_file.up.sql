@@ -0,0 +1,6 @@
+-- the file the binary was imported from. The file name is encrypted at rest like the content,
+-- the size is the size of the file before the encryption
+ALTER TABLE "bin_data" ADD COLUMN IF NOT EXISTS "file_name" text NOT NULL DEFAULT '';
+ALTER TABLE "bin_data" ADD COLUMN IF NOT EXISTS "file_size" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "bin_data" ADD COLUMN IF NOT EXISTS "mime_type" text NOT NULL DEFAULT '';
+ALTER TABLE "bin_data" ADD COLUMN IF NOT EXISTS "mod_time" timestamptz;
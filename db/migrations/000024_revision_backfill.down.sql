@@ -0,0 +1,2 @@
+-- the backfilled revisions can not be told from the others, they are kept
+SELECT 1;
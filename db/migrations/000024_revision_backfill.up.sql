@@ -0,0 +1,20 @@
+-- the records saved before the revisions were kept have no revision, so their first update would lose the value.
+-- Every record without a revision gets one with its current value, the trashed ones get the delete revision
+INSERT INTO "record_revisions"("user", "kind", "id", "version", "action", "data")
+SELECT r."user", r."kind", r."id", r."version", CASE WHEN r."deleted_at" IS NULL THEN 'add' ELSE 'delete' END, r."data"
+FROM (
+    SELECT "user", 'card' AS "kind", "id", "version", "deleted_at", to_jsonb(t) AS "data" FROM "cards" t
+    UNION ALL
+    SELECT "user", 'login', "id", "version", "deleted_at", to_jsonb(t) FROM "logins" t
+    UNION ALL
+    SELECT "user", 'text', "id", "version", "deleted_at", to_jsonb(t) FROM "text_data" t
+    UNION ALL
+    SELECT "user", 'bin', "id", "version", "deleted_at", to_jsonb(t) FROM "bin_data" t
+    UNION ALL
+    SELECT "user", 'ssh', "id", "version", "deleted_at", to_jsonb(t) FROM "ssh_keys" t
+    UNION ALL
+    SELECT "user", 'custom', "id", "version", "deleted_at", to_jsonb(t) FROM "custom_records" t
+) r
+WHERE NOT EXISTS (
+    SELECT 1 FROM "record_revisions" v WHERE v."user" = r."user" AND v."kind" = r."kind" AND v."id" = r."id"
+);
@@ -0,0 +1 @@
+DROP FUNCTION IF EXISTS "update_folder";
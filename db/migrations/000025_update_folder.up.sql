@@ -0,0 +1,23 @@
+-- update_folder renames the folder of the user and moves it under the parent of the same vault, the zero parent
+-- is the root of the vault. The folders of the user are locked first, so the concurrent moves are checked one after
+-- another and can not make a cycle together. Returns false if there is no such folder, no such parent or the parent
+-- is the folder itself or one of its nested folders
+CREATE OR REPLACE FUNCTION "update_folder"(bigint, bigint, text, bigint) RETURNS boolean AS $$
+BEGIN
+    PERFORM 1 FROM "folders" WHERE "user" = $1 FOR UPDATE;
+    UPDATE "folders" SET "name" = $3, "parent" = NULLIF($4, 0)
+    WHERE "user" = $1 AND "@folders" = $2
+    AND ($4 = 0 OR EXISTS (
+        SELECT 1 FROM "folders" p WHERE p."user" = $1 AND p."vault" = "folders"."vault" AND p."@folders" = $4
+    ))
+    AND $4 NOT IN (
+        WITH RECURSIVE "nested"("id") AS (
+            SELECT $2
+            UNION ALL
+            SELECT f."@folders" FROM "folders" f JOIN "nested" n ON f."parent" = n."id"
+        )
+        SELECT "id" FROM "nested"
+    );
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,3 @@
+-- only the reuse of the last used token is detected again, the hash of the earlier ones is lost
+ALTER TABLE "sessions" ADD COLUMN IF NOT EXISTS "previous_hash" text;
+DROP TABLE IF EXISTS "session_used_hashes";
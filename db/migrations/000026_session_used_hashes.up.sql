@@ -0,0 +1,10 @@
+-- the hashes of all refresh tokens already used by the session, any of them presented again revokes the session
+CREATE TABLE IF NOT EXISTS "session_used_hashes"(
+    "session" text NOT NULL REFERENCES sessions ("id") ON DELETE CASCADE,
+    "hash" text NOT NULL,
+    PRIMARY KEY ("session", "hash")
+);
+INSERT INTO "session_used_hashes"("session", "hash")
+SELECT "id", "previous_hash" FROM "sessions" WHERE "previous_hash" IS NOT NULL
+ON CONFLICT DO NOTHING;
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "previous_hash";
@@ -0,0 +1,338 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ncyellow/GophKeeper/internal/client/vault"
+	"github.com/ncyellow/GophKeeper/internal/models"
+)
+
+var (
+	// errChunkOrder the server expects another chunk, the upload is resumed from its state
+	errChunkOrder = errors.New("unexpected chunk")
+	// errRequestDone the streamed body is not sent again, the upload is resumed from the state on the server
+	errRequestDone = errors.New("request is done")
+)
+
+// transferAttempts how many times the upload or the download is tried before the error is returned
+const transferAttempts = 5
+
+// uploadTransport the requests of the upload, the http and the grpc senders implement them
+type uploadTransport interface {
+	startUpload(binID string) (*models.BinaryUpload, error)
+	uploadState(binID string, uploadID string) (*models.BinaryUpload, error)
+	// sendChunks sends the chunks returned by next until io.EOF, the first one is the chunk upload.Chunks.
+	// Returns the state of the upload after the last chunk
+	sendChunks(upload *models.BinaryUpload, next func() ([]byte, error)) (*models.BinaryUpload, error)
+	completeUpload(binary *models.Binary, uploadID string) (int64, error)
+}
+
+// downloadTransport the request of the download
+type downloadTransport interface {
+	// readContent writes the uploaded content of the binary from the offset to w
+	readContent(binary *models.Binary, offset int64, w io.Writer) error
+}
+
+// uploadContent sends the content in chunks of models.BinaryChunkSize and replaces the content of the binary with it.
+// If the chunks are not sent, the upload is resumed from the chunks received by the server
+func uploadContent(transport uploadTransport, binary *models.Binary, content io.ReadSeeker) error {
+	upload, err := transport.startUpload(binary.ID)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, models.BinaryChunkSize)
+	for attempt := 1; ; attempt++ {
+		// All chunks but the last one are full, so the received ones end at the offset
+		if _, err := content.Seek(upload.Offset, io.SeekStart); err != nil {
+			return err
+		}
+		var readErr error
+		next := func() ([]byte, error) {
+			n, err := io.ReadFull(content, buf)
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				return buf[:n], nil
+			}
+			if err != nil && !errors.Is(err, io.EOF) {
+				readErr = err
+			}
+			return buf[:n], err
+		}
+
+		state, err := transport.sendChunks(upload, next)
+		if err == nil {
+			upload = state
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+		if attempt == transferAttempts || errors.Is(err, models.ErrInvalidChunk) {
+			return err
+		}
+		if upload, err = transport.uploadState(binary.ID, upload.ID); err != nil {
+			return err
+		}
+	}
+
+	version, err := transport.completeUpload(binary, upload.ID)
+	if err != nil {
+		return err
+	}
+	binary.Version, binary.ContentID, binary.Size = version, upload.ID, upload.Offset
+	binary.Data = nil
+	return nil
+}
+
+// downloadContent writes the content of the binary to w. If the connection is broken,
+// the download is resumed from the received bytes unless the content was replaced since the binary was read
+func downloadContent(transport downloadTransport, binary *models.Binary, w io.Writer) error {
+	if binary.ContentID == "" {
+		_, err := w.Write(binary.Data)
+		return err
+	}
+
+	counter := &countingWriter{w: w}
+	for attempt := 1; ; attempt++ {
+		err := transport.readContent(binary, counter.written, counter)
+		if err == nil && counter.written != binary.Size {
+			err = fmt.Errorf(FmtErrInternalServer, io.ErrUnexpectedEOF)
+		}
+		switch {
+		case err == nil:
+			return nil
+		case counter.err != nil:
+			return counter.err
+		case counter.written == binary.Size && !errors.Is(err, ErrContentReplaced):
+			// All the content is written, only the end of the response is lost
+			return nil
+		case attempt == transferAttempts || errors.Is(err, ErrContentReplaced) || errors.Is(err, ErrNotFound):
+			return err
+		}
+	}
+}
+
+// countingWriter counts the written bytes, the download is resumed from them.
+// The error of the writer is kept, it is not a reason to resume
+type countingWriter struct {
+	w       io.Writer
+	written int64
+	err     error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.written += int64(n)
+	if err != nil {
+		c.err = err
+	}
+	return n, err
+}
+
+// sealedContent encrypts the content for the upload. Every encrypted chunk is exactly one chunk of the upload,
+// so the upload is resumed by encrypting the chunks from the offset again. The ad binds the chunk to the record
+// and its position, and the last chunk is marked, so the server can not reorder or cut the chunks
+type sealedContent struct {
+	vault  *vault.Vault
+	binID  string
+	plain  io.ReadSeeker
+	frame  int64
+	chunks int64
+	offset int64
+	seq    int64
+	chunk  []byte
+}
+
+// newSealedContent reads the size of the content, the last chunk is known beforehand.
+// The empty content is one empty chunk, so it can not be cut too
+func newSealedContent(v *vault.Vault, binID string, plain io.ReadSeeker) (*sealedContent, error) {
+	size, err := plain.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	frame := int64(models.BinaryChunkSize - v.ChunkOverhead())
+	chunks := (size + frame - 1) / frame
+	if chunks == 0 {
+		chunks = 1
+	}
+	return &sealedContent{vault: v, binID: binID, plain: plain, frame: frame, chunks: chunks, seq: -1}, nil
+}
+
+func (s *sealedContent) Read(p []byte) (int, error) {
+	seq := s.offset / models.BinaryChunkSize
+	if seq >= s.chunks {
+		return 0, io.EOF
+	}
+	if seq != s.seq {
+		if _, err := s.plain.Seek(seq*s.frame, io.SeekStart); err != nil {
+			return 0, err
+		}
+		plain := make([]byte, s.frame)
+		n, err := io.ReadFull(s.plain, plain)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+			return 0, err
+		}
+		chunk, err := s.vault.SealChunk(chunkAD(s.binID, seq, seq == s.chunks-1), plain[:n])
+		if err != nil {
+			return 0, err
+		}
+		s.seq, s.chunk = seq, chunk
+	}
+	start := s.offset - seq*models.BinaryChunkSize
+	if start >= int64(len(s.chunk)) {
+		return 0, io.EOF
+	}
+	n := copy(p, s.chunk[start:])
+	s.offset += int64(n)
+	return n, nil
+}
+
+// Seek only from the start, the upload is resumed from the start of the chunk
+func (s *sealedContent) Seek(offset int64, whence int) (int64, error) {
+	if whence != io.SeekStart || offset < 0 {
+		return 0, errors.New("unsupported seek")
+	}
+	s.offset = offset
+	return offset, nil
+}
+
+// openedContent decrypts the downloaded content chunk by chunk. The content uploaded without the encryption
+// is written as is. The content moved by the server from the record to the blob store is one envelope
+// of SealBytes, it is buffered and decrypted as a whole
+type openedContent struct {
+	vault   *vault.Vault
+	binID   string
+	w       io.Writer
+	chunks  int64
+	seq     int64
+	buf     []byte
+	checked bool
+	plain   bool
+	// legacy the content is the envelope of SealBytes
+	legacy bool
+}
+
+func newOpenedContent(v *vault.Vault, binary *models.Binary, w io.Writer) *openedContent {
+	return &openedContent{
+		vault:  v,
+		binID:  binary.ID,
+		w:      w,
+		chunks: (binary.Size + models.BinaryChunkSize - 1) / models.BinaryChunkSize,
+		buf:    make([]byte, 0, models.BinaryChunkSize),
+	}
+}
+
+func (o *openedContent) Write(p []byte) (int, error) {
+	if o.plain {
+		return o.w.Write(p)
+	}
+	if o.legacy {
+		o.buf = append(o.buf, p...)
+		return len(p), nil
+	}
+	written := len(p)
+	for len(p) > 0 {
+		n := copy(o.buf[len(o.buf):cap(o.buf)], p)
+		o.buf, p = o.buf[:len(o.buf)+n], p[n:]
+
+		if !o.checked && len(o.buf) >= len(models.EncryptedPrefix) {
+			o.checked = true
+			if !bytes.HasPrefix(o.buf, []byte(models.EncryptedPrefix)) {
+				o.plain = true
+				if _, err := o.w.Write(o.buf); err != nil {
+					return 0, err
+				}
+				_, err := o.w.Write(p)
+				return written, err
+			}
+		}
+		if len(o.buf) == cap(o.buf) {
+			if o.seq == 0 && isEnvelope(o.buf) {
+				o.legacy = true
+				o.buf = append(o.buf, p...)
+				return written, nil
+			}
+			if err := o.open(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// Close decrypts the last chunk, the content is checked to have all chunks
+func (o *openedContent) Close() error {
+	if o.plain || !o.checked {
+		_, err := o.w.Write(o.buf)
+		return err
+	}
+	if o.legacy || o.seq == 0 && isEnvelope(o.buf) {
+		return o.openEnvelope()
+	}
+	if len(o.buf) > 0 {
+		if err := o.open(); err != nil {
+			return err
+		}
+	}
+	if o.seq != o.chunks {
+		return vault.ErrDecrypt
+	}
+	return nil
+}
+
+// open decrypts the buffered chunk and writes it
+func (o *openedContent) open() error {
+	if o.vault == nil {
+		return vault.ErrLocked
+	}
+	plain, err := o.vault.OpenChunk(chunkAD(o.binID, o.seq, o.seq == o.chunks-1), o.buf)
+	if err != nil {
+		return err
+	}
+	if _, err := o.w.Write(plain); err != nil {
+		return err
+	}
+	o.seq++
+	o.buf = o.buf[:0]
+	return nil
+}
+
+// openEnvelope decrypts the buffered content encrypted by SealBytes like the data of the record
+func (o *openedContent) openEnvelope() error {
+	if o.vault == nil {
+		return vault.ErrLocked
+	}
+	plain, err := o.vault.OpenBytes(vault.AD(models.KindBinary, o.binID, "data"), o.buf)
+	if err != nil {
+		return err
+	}
+	_, err = o.w.Write(plain)
+	return err
+}
+
+// isEnvelope checks whether the data is the envelope of SealBytes. The envelope is base64 after the prefix,
+// the chunk of SealChunk is random bytes after the prefix, which are almost never all base64
+func isEnvelope(data []byte) bool {
+	encoded := data[len(models.EncryptedPrefix):]
+	if len(encoded) == 0 {
+		return false
+	}
+	for _, c := range encoded {
+		if !('A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '+' || c == '/') {
+			return false
+		}
+	}
+	return true
+}
+
+// chunkAD associated data of the chunk of the content, the last chunk has its own
+func chunkAD(binID string, seq int64, last bool) string {
+	field := fmt.Sprintf("chunk/%d", seq)
+	if last {
+		field += "/last"
+	}
+	return vault.AD(models.KindBinary, binID, field)
+}
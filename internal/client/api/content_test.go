@@ -0,0 +1,301 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ncyellow/GophKeeper/internal/client/vault"
+	"github.com/ncyellow/GophKeeper/internal/models"
+)
+
+// fakeTransport in-memory server of the uploaded content
+type fakeTransport struct {
+	upload *models.BinaryUpload
+	chunks [][]byte
+	// offsets the offsets the chunks were sent from, one per attempt
+	offsets []int64
+	// breakAfter the connection is broken once after the number of chunks
+	breakAfter int
+	// reject the server rejects the chunks as invalid
+	reject  bool
+	content []byte
+	// reads the offsets the content was read from, one per attempt
+	reads []int64
+	// cut the download is broken once after the number of bytes
+	cut      int64
+	replaced bool
+}
+
+func (f *fakeTransport) startUpload(binID string) (*models.BinaryUpload, error) {
+	if f.upload == nil {
+		f.upload = &models.BinaryUpload{ID: "upload", BinaryID: binID}
+	}
+	upload := *f.upload
+	return &upload, nil
+}
+
+func (f *fakeTransport) uploadState(_ string, _ string) (*models.BinaryUpload, error) {
+	upload := *f.upload
+	return &upload, nil
+}
+
+func (f *fakeTransport) sendChunks(upload *models.BinaryUpload, next func() ([]byte, error)) (*models.BinaryUpload, error) {
+	f.offsets = append(f.offsets, upload.Offset)
+	for sent := 0; ; sent++ {
+		if f.breakAfter > 0 && sent == f.breakAfter {
+			f.breakAfter = 0
+			return nil, fmt.Errorf(FmtErrServerTimout, io.ErrUnexpectedEOF)
+		}
+		data, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if f.reject {
+			return nil, fmt.Errorf("%w: the chunk must be from 1 to %d bytes", models.ErrInvalidChunk, models.BinaryChunkSize)
+		}
+		f.chunks = append(f.chunks, append([]byte(nil), data...))
+		f.upload.Chunks++
+		f.upload.Offset += int64(len(data))
+	}
+	state := *f.upload
+	return &state, nil
+}
+
+func (f *fakeTransport) completeUpload(binary *models.Binary, _ string) (int64, error) {
+	f.content = bytes.Join(f.chunks, nil)
+	return binary.Version + 1, nil
+}
+
+// readContent writes the content in chunks like the server streams it
+func (f *fakeTransport) readContent(_ *models.Binary, offset int64, w io.Writer) error {
+	f.reads = append(f.reads, offset)
+	if f.replaced {
+		return ErrContentReplaced
+	}
+	data := f.content[offset:]
+	if f.cut > 0 && f.cut < int64(len(data)) {
+		data, f.cut = data[:f.cut], 0
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		return fmt.Errorf(FmtErrServerTimout, io.ErrUnexpectedEOF)
+	}
+	for len(data) > 0 {
+		n := min(len(data), models.BinaryChunkSize)
+		if _, err := w.Write(data[:n]); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// contentSender the sender of the records with the uploaded content, like the http and the grpc senders
+type contentSender struct {
+	*fakeSender
+	transport *fakeTransport
+}
+
+func (c *contentSender) UploadBin(binary *models.Binary, content io.ReadSeeker) error {
+	return uploadContent(c.transport, binary, content)
+}
+
+func (c *contentSender) DownloadBin(binary *models.Binary, w io.Writer) error {
+	return downloadContent(c.transport, binary, w)
+}
+
+// testContent the content of the size, its bytes depend on the position
+func testContent(size int) []byte {
+	content := make([]byte, size)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	return content
+}
+
+func TestUploadContentChunks(t *testing.T) {
+	tests := []struct {
+		name  string
+		size  int
+		sizes []int
+	}{
+		{name: "empty", size: 0},
+		{name: "one byte", size: 1, sizes: []int{1}},
+		{name: "one chunk", size: models.BinaryChunkSize, sizes: []int{models.BinaryChunkSize}},
+		{name: "after the boundary", size: models.BinaryChunkSize + 1, sizes: []int{models.BinaryChunkSize, 1}},
+		{name: "several chunks", size: 2*models.BinaryChunkSize + 10,
+			sizes: []int{models.BinaryChunkSize, models.BinaryChunkSize, 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &fakeTransport{}
+			content := testContent(tt.size)
+			binary := models.Binary{ID: "bin", Data: []byte("inline"), Version: 3}
+			require.NoError(t, uploadContent(transport, &binary, bytes.NewReader(content)))
+
+			var sizes []int
+			for _, chunk := range transport.chunks {
+				sizes = append(sizes, len(chunk))
+			}
+			assert.Equal(t, tt.sizes, sizes)
+			assert.Equal(t, content, transport.content)
+			assert.Equal(t, models.Binary{ID: "bin", ContentID: "upload", Size: int64(tt.size), Version: 4}, binary)
+		})
+	}
+}
+
+func TestUploadContentResume(t *testing.T) {
+	content := testContent(3*models.BinaryChunkSize + 10)
+
+	// The server has the first chunk of the interrupted upload, the connection is broken after the next one
+	transport := &fakeTransport{
+		upload:     &models.BinaryUpload{ID: "upload", BinaryID: "bin", Chunks: 1, Offset: models.BinaryChunkSize},
+		chunks:     [][]byte{content[:models.BinaryChunkSize]},
+		breakAfter: 1,
+	}
+	binary := models.Binary{ID: "bin"}
+	require.NoError(t, uploadContent(transport, &binary, bytes.NewReader(content)))
+	assert.Equal(t, []int64{models.BinaryChunkSize, 2 * models.BinaryChunkSize}, transport.offsets)
+	assert.Equal(t, content, transport.content)
+	assert.Equal(t, int64(len(content)), binary.Size)
+
+	// The rejected chunk is not sent again
+	transport = &fakeTransport{reject: true}
+	binary = models.Binary{ID: "bin", Version: 1}
+	err := uploadContent(transport, &binary, bytes.NewReader(content))
+	assert.ErrorIs(t, err, models.ErrInvalidChunk)
+	assert.Len(t, transport.offsets, 1)
+	assert.Equal(t, models.Binary{ID: "bin", Version: 1}, binary)
+
+	// The broken connection is tried again a limited number of times
+	broken := &brokenTransport{fakeTransport: &fakeTransport{}}
+	err = uploadContent(broken, &binary, bytes.NewReader(content))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, transferAttempts, broken.attempts)
+}
+
+// brokenTransport breaks the connection on every attempt
+type brokenTransport struct {
+	*fakeTransport
+	attempts int
+}
+
+func (b *brokenTransport) sendChunks(_ *models.BinaryUpload, _ func() ([]byte, error)) (*models.BinaryUpload, error) {
+	b.attempts++
+	return nil, fmt.Errorf(FmtErrServerTimout, io.ErrUnexpectedEOF)
+}
+
+func TestDownloadContent(t *testing.T) {
+	content := testContent(2*models.BinaryChunkSize + 10)
+	binary := models.Binary{ID: "bin", ContentID: "upload", Size: int64(len(content))}
+
+	// The broken download is resumed from the written bytes
+	transport := &fakeTransport{content: content, cut: models.BinaryChunkSize + 5}
+	var buf bytes.Buffer
+	require.NoError(t, downloadContent(transport, &binary, &buf))
+	assert.Equal(t, content, buf.Bytes())
+	assert.Equal(t, []int64{0, models.BinaryChunkSize + 5}, transport.reads)
+
+	// The content shorter than the size of the record is not accepted
+	transport = &fakeTransport{content: content[:models.BinaryChunkSize]}
+	err := downloadContent(transport, &binary, io.Discard)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Len(t, transport.reads, transferAttempts)
+
+	// The replaced content is not resumed
+	transport = &fakeTransport{content: content, replaced: true}
+	assert.ErrorIs(t, downloadContent(transport, &binary, io.Discard), ErrContentReplaced)
+	assert.Len(t, transport.reads, 1)
+
+	// The inline data is written as is
+	buf.Reset()
+	require.NoError(t, downloadContent(transport, &models.Binary{ID: "bin", Data: []byte("inline")}, &buf))
+	assert.Equal(t, "inline", buf.String())
+}
+
+func TestSealedContent(t *testing.T) {
+	server := &contentSender{fakeSender: newFakeSender(), transport: &fakeTransport{}}
+	crypto := unlocked(t, server)
+	content := testContent(2*models.BinaryChunkSize + 10)
+
+	binary := models.Binary{ID: "bin"}
+	require.NoError(t, crypto.UploadBin(&binary, bytes.NewReader(content)))
+	// Every chunk is encrypted on its own, the server gets only the ciphertext
+	require.Len(t, server.transport.chunks, 3)
+	for _, chunk := range server.transport.chunks {
+		assert.True(t, bytes.HasPrefix(chunk, []byte(models.EncryptedPrefix)))
+		assert.False(t, bytes.Contains(chunk, content[:64]))
+	}
+	assert.Equal(t, int64(len(server.transport.content)), binary.Size)
+
+	// The download spread over several chunks is decrypted and resumed
+	server.transport.cut = models.BinaryChunkSize + 5
+	var buf bytes.Buffer
+	require.NoError(t, crypto.DownloadBin(&binary, &buf))
+	assert.Equal(t, content, buf.Bytes())
+
+	// The changed chunk does not match its authentication tag
+	sealed := server.transport.content
+	server.transport.content = append([]byte(nil), sealed...)
+	server.transport.content[models.BinaryChunkSize+100] ^= 1
+	assert.ErrorIs(t, crypto.DownloadBin(&binary, io.Discard), vault.ErrDecrypt)
+
+	// The content cut by the server at the chunk boundary lacks the last chunk
+	server.transport.content = sealed[:2*models.BinaryChunkSize]
+	cut := binary
+	cut.Size = 2 * models.BinaryChunkSize
+	assert.ErrorIs(t, crypto.DownloadBin(&cut, io.Discard), vault.ErrDecrypt)
+
+	// The chunks can not be reordered
+	server.transport.content = bytes.Join([][]byte{sealed[models.BinaryChunkSize : 2*models.BinaryChunkSize],
+		sealed[:models.BinaryChunkSize], sealed[2*models.BinaryChunkSize:]}, nil)
+	assert.ErrorIs(t, crypto.DownloadBin(&binary, io.Discard), vault.ErrDecrypt)
+}
+
+func TestMigrateContent(t *testing.T) {
+	content := testContent(models.BinaryChunkSize + 10)
+	server := &contentSender{fakeSender: newFakeSender(), transport: &fakeTransport{content: content}}
+	require.NoError(t, server.AddBin(&models.Binary{
+		ID: "bin", FileName: "report.pdf", ContentID: "plain", Size: int64(len(content)),
+	}))
+	crypto := unlocked(t, server)
+
+	// The content readable by the server is uploaded again encrypted, the file name is encrypted before
+	migrated, err := crypto.Migrate(models.KindBinary, "bin")
+	require.NoError(t, err)
+	assert.True(t, migrated)
+	assert.NotContains(t, string(server.stored(models.KindBinary, "bin")), "report.pdf")
+	require.Len(t, server.transport.chunks, 2)
+	for _, chunk := range server.transport.chunks {
+		assert.True(t, bytes.HasPrefix(chunk, []byte(models.EncryptedPrefix)))
+	}
+	binary := models.Binary{ID: "bin", ContentID: "upload", Size: int64(len(server.transport.content))}
+	var buf bytes.Buffer
+	require.NoError(t, crypto.DownloadBin(&binary, &buf))
+	assert.Equal(t, content, buf.Bytes())
+
+	// The encrypted content is recognised by its beginning, it is not downloaded as a whole and not uploaded again
+	server.transport.reads = nil
+	migrated, err = crypto.Migrate(models.KindBinary, "bin")
+	require.NoError(t, err)
+	assert.False(t, migrated)
+	assert.Equal(t, []int64{0}, server.transport.reads)
+	assert.Len(t, server.transport.chunks, 2)
+
+	// The content shorter than the prefix of the ciphertext is plaintext too
+	server = &contentSender{fakeSender: newFakeSender(), transport: &fakeTransport{content: []byte("hi")}}
+	require.NoError(t, server.AddBin(&models.Binary{ID: "bin", ContentID: "plain", Size: 2}))
+	migrated, err = unlocked(t, server).Migrate(models.KindBinary, "bin")
+	require.NoError(t, err)
+	assert.True(t, migrated)
+	require.Len(t, server.transport.chunks, 1)
+	assert.True(t, bytes.HasPrefix(server.transport.chunks[0], []byte(models.EncryptedPrefix)))
+}
@@ -0,0 +1,624 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ncyellow/GophKeeper/internal/client/vault"
+	"github.com/ncyellow/GophKeeper/internal/models"
+)
+
+// VaultSender is a Sender that encrypts the records on the client side with a key derived from the master password
+type VaultSender interface {
+	Sender
+	// Unlock derives the vault key from the master password. On the first call the check value of the master password is saved on the server
+	Unlock(masterPassword string) error
+	// Lock forgets the vault key
+	Lock()
+	// Migrate re-saves the record created before the encryption was enabled in the encrypted form,
+	// the plaintext uploaded content of the binary is uploaded again. Returns false if the record is already encrypted
+	Migrate(kind models.Kind, id string) (bool, error)
+}
+
+// CryptoSender decorator over any Sender. Secret fields of the records are encrypted before sending
+// and decrypted after reading, the server receives only the ciphertext. The record ids and Note are not encrypted.
+type CryptoSender struct {
+	Sender
+	vault *vault.Vault
+}
+
+// NewCryptoSender constructor, the vault is locked until Unlock is called
+func NewCryptoSender(sender Sender) *CryptoSender {
+	return &CryptoSender{Sender: sender}
+}
+
+func (c *CryptoSender) Register(login string, pwd string) error {
+	// The key of the previous user must not be used for the new one
+	c.Lock()
+	return c.Sender.Register(login, pwd)
+}
+
+func (c *CryptoSender) SignIn(login string, pwd string, otp string) error {
+	c.Lock()
+	return c.Sender.SignIn(login, pwd, otp)
+}
+
+func (c *CryptoSender) Logout() error {
+	c.Lock()
+	return c.Sender.Logout()
+}
+
+func (c *CryptoSender) Unlock(masterPassword string) error {
+	params, err := c.Sender.Vault()
+	if err != nil {
+		return err
+	}
+	v, err := vault.Derive(masterPassword, params.Salt)
+	if err != nil {
+		return err
+	}
+
+	if params.Check != "" {
+		if err := v.VerifyCheck(params.Check); err != nil {
+			return err
+		}
+	} else {
+		// The first unlock of the vault, remember the master password check value
+		check, err := v.NewCheck()
+		if err != nil {
+			return err
+		}
+		if err := c.Sender.SetVaultCheck(check); err != nil {
+			return err
+		}
+	}
+	c.vault = v
+	return nil
+}
+
+func (c *CryptoSender) Lock() {
+	c.vault = nil
+}
+
+func (c *CryptoSender) AddCard(card *models.Card) error {
+	sealed := *card
+	if err := c.sealStrings(models.KindCard, card.ID, cardFields(&sealed)); err != nil {
+		return err
+	}
+	return c.Sender.AddCard(&sealed)
+}
+
+func (c *CryptoSender) Card(cardID string) (*models.Card, error) {
+	card, err := c.Sender.Card(cardID)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.openStrings(models.KindCard, card.ID, cardFields(card)); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+func (c *CryptoSender) UpdateCard(card *models.Card) error {
+	sealed := *card
+	if err := c.sealStrings(models.KindCard, card.ID, cardFields(&sealed)); err != nil {
+		return err
+	}
+	if err := c.Sender.UpdateCard(&sealed); err != nil {
+		return err
+	}
+	card.Version = sealed.Version
+	return nil
+}
+
+func (c *CryptoSender) AddLogin(login *models.Login) error {
+	sealed := *login
+	if err := c.sealStrings(models.KindLogin, login.ID, loginFields(&sealed)); err != nil {
+		return err
+	}
+	return c.Sender.AddLogin(&sealed)
+}
+
+func (c *CryptoSender) Login(loginID string) (*models.Login, error) {
+	login, err := c.Sender.Login(loginID)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.openStrings(models.KindLogin, login.ID, loginFields(login)); err != nil {
+		return nil, err
+	}
+	return login, nil
+}
+
+func (c *CryptoSender) UpdateLogin(login *models.Login) error {
+	sealed := *login
+	if err := c.sealStrings(models.KindLogin, login.ID, loginFields(&sealed)); err != nil {
+		return err
+	}
+	if err := c.Sender.UpdateLogin(&sealed); err != nil {
+		return err
+	}
+	login.Version = sealed.Version
+	return nil
+}
+
+func (c *CryptoSender) AddText(text *models.Text) error {
+	sealed := *text
+	if err := c.sealStrings(models.KindText, text.ID, textFields(&sealed)); err != nil {
+		return err
+	}
+	return c.Sender.AddText(&sealed)
+}
+
+func (c *CryptoSender) Text(textID string) (*models.Text, error) {
+	text, err := c.Sender.Text(textID)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.openStrings(models.KindText, text.ID, textFields(text)); err != nil {
+		return nil, err
+	}
+	return text, nil
+}
+
+func (c *CryptoSender) UpdateText(text *models.Text) error {
+	sealed := *text
+	if err := c.sealStrings(models.KindText, text.ID, textFields(&sealed)); err != nil {
+		return err
+	}
+	if err := c.Sender.UpdateText(&sealed); err != nil {
+		return err
+	}
+	text.Version = sealed.Version
+	return nil
+}
+
+func (c *CryptoSender) AddBin(binary *models.Binary) error {
+	if c.vault == nil {
+		return vault.ErrLocked
+	}
+	sealed := *binary
+	data, err := c.vault.SealBytes(vault.AD(models.KindBinary, binary.ID, "data"), binary.Data)
+	if err != nil {
+		return err
+	}
+	sealed.Data = data
+	if err := c.sealStrings(models.KindBinary, binary.ID, binaryFields(&sealed)); err != nil {
+		return err
+	}
+	return c.Sender.AddBin(&sealed)
+}
+
+func (c *CryptoSender) Bin(binID string) (*models.Binary, error) {
+	binary, err := c.Sender.Bin(binID)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.openStrings(models.KindBinary, binary.ID, binaryFields(binary)); err != nil {
+		return nil, err
+	}
+	if !vault.IsEncrypted(string(binary.Data)) {
+		return binary, nil
+	}
+	if c.vault == nil {
+		return nil, vault.ErrLocked
+	}
+	binary.Data, err = c.vault.OpenBytes(vault.AD(models.KindBinary, binary.ID, "data"), binary.Data)
+	if err != nil {
+		return nil, err
+	}
+	return binary, nil
+}
+
+func (c *CryptoSender) UpdateBin(binary *models.Binary) error {
+	if c.vault == nil {
+		return vault.ErrLocked
+	}
+	sealed := *binary
+	data, err := c.vault.SealBytes(vault.AD(models.KindBinary, binary.ID, "data"), binary.Data)
+	if err != nil {
+		return err
+	}
+	sealed.Data = data
+	if err := c.sealStrings(models.KindBinary, binary.ID, binaryFields(&sealed)); err != nil {
+		return err
+	}
+	if err := c.Sender.UpdateBin(&sealed); err != nil {
+		return err
+	}
+	binary.Version = sealed.Version
+	return nil
+}
+
+// UploadBin encrypts the content chunk by chunk while it is uploaded, see sealedContent
+func (c *CryptoSender) UploadBin(binary *models.Binary, content io.ReadSeeker) error {
+	if c.vault == nil {
+		return vault.ErrLocked
+	}
+	sealed, err := newSealedContent(c.vault, binary.ID, content)
+	if err != nil {
+		return err
+	}
+	return c.Sender.UploadBin(binary, sealed)
+}
+
+// DownloadBin decrypts the content chunk by chunk while it is downloaded, the content uploaded
+// without the encryption is written as is
+func (c *CryptoSender) DownloadBin(binary *models.Binary, w io.Writer) error {
+	if binary.ContentID == "" {
+		return c.Sender.DownloadBin(binary, w)
+	}
+	opened := newOpenedContent(c.vault, binary, w)
+	if err := c.Sender.DownloadBin(binary, opened); err != nil {
+		return err
+	}
+	return opened.Close()
+}
+
+// AddSSHKey derives the public key and the fingerprint before the private key is encrypted,
+// the server can not read the encrypted private key to do it
+func (c *CryptoSender) AddSSHKey(key *models.SSHKey) error {
+	if err := key.Validate(); err != nil {
+		return err
+	}
+	sealed := *key
+	if err := c.sealStrings(models.KindSSH, key.ID, sshKeyFields(&sealed)); err != nil {
+		return err
+	}
+	return c.Sender.AddSSHKey(&sealed)
+}
+
+func (c *CryptoSender) SSHKey(keyID string) (*models.SSHKey, error) {
+	key, err := c.Sender.SSHKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.openStrings(models.KindSSH, key.ID, sshKeyFields(key)); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (c *CryptoSender) UpdateSSHKey(key *models.SSHKey) error {
+	if err := key.Validate(); err != nil {
+		return err
+	}
+	sealed := *key
+	if err := c.sealStrings(models.KindSSH, key.ID, sshKeyFields(&sealed)); err != nil {
+		return err
+	}
+	if err := c.Sender.UpdateSSHKey(&sealed); err != nil {
+		return err
+	}
+	key.Version = sealed.Version
+	return nil
+}
+
+// AddCustomRecord encrypts the values of the secret fields one by one, the server checks the public ones by the schema
+func (c *CryptoSender) AddCustomRecord(record *models.CustomRecord) error {
+	sealed := *record
+	fields := secretFields(record.Secrets)
+	if err := c.sealStrings(models.KindCustom, record.ID, fields); err != nil {
+		return err
+	}
+	sealed.Secrets = secretValues(fields)
+	return c.Sender.AddCustomRecord(&sealed)
+}
+
+func (c *CryptoSender) CustomRecord(recordID string) (*models.CustomRecord, error) {
+	record, err := c.Sender.CustomRecord(recordID)
+	if err != nil {
+		return nil, err
+	}
+	fields := secretFields(record.Secrets)
+	if err := c.openStrings(models.KindCustom, record.ID, fields); err != nil {
+		return nil, err
+	}
+	record.Secrets = secretValues(fields)
+	return record, nil
+}
+
+func (c *CryptoSender) UpdateCustomRecord(record *models.CustomRecord) error {
+	sealed := *record
+	fields := secretFields(record.Secrets)
+	if err := c.sealStrings(models.KindCustom, record.ID, fields); err != nil {
+		return err
+	}
+	sealed.Secrets = secretValues(fields)
+	if err := c.Sender.UpdateCustomRecord(&sealed); err != nil {
+		return err
+	}
+	record.Version = sealed.Version
+	return nil
+}
+
+// Revision decrypts the fields of the revision, they are encrypted the same way as the fields of the record
+func (c *CryptoSender) Revision(kind models.Kind, id string, revisionID int64) (*models.Revision, error) {
+	revision, err := c.Sender.Revision(kind, id, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]*string, len(revision.Fields))
+	for name, value := range revision.Fields {
+		value := value
+		fields[name] = &value
+	}
+	if err := c.openStrings(kind, id, fields); err != nil {
+		return nil, err
+	}
+	for name, value := range fields {
+		revision.Fields[name] = *value
+	}
+	// The secrets of the custom record are kept as one json object of the values encrypted one by one
+	if secrets, ok := revision.Fields["secrets"]; ok && kind == models.KindCustom {
+		var values map[string]string
+		if err := json.Unmarshal([]byte(secrets), &values); err != nil {
+			return nil, err
+		}
+		secretFields := secretFields(values)
+		if err := c.openStrings(kind, id, secretFields); err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(secretValues(secretFields))
+		if err != nil {
+			return nil, err
+		}
+		revision.Fields["secrets"] = string(data)
+	}
+	return revision, nil
+}
+
+func (c *CryptoSender) Migrate(kind models.Kind, id string) (bool, error) {
+	if c.vault == nil {
+		return false, vault.ErrLocked
+	}
+
+	switch kind {
+	case models.KindCard:
+		card, err := c.Sender.Card(id)
+		if err != nil {
+			return false, err
+		}
+		plain := *card
+		if !hasPlaintext(cardFields(&plain)) {
+			return false, nil
+		}
+		if err := c.openStrings(kind, id, cardFields(&plain)); err != nil {
+			return false, err
+		}
+		return true, c.UpdateCard(&plain)
+	case models.KindLogin:
+		login, err := c.Sender.Login(id)
+		if err != nil {
+			return false, err
+		}
+		plain := *login
+		if !hasPlaintext(loginFields(&plain)) {
+			return false, nil
+		}
+		if err := c.openStrings(kind, id, loginFields(&plain)); err != nil {
+			return false, err
+		}
+		return true, c.UpdateLogin(&plain)
+	case models.KindText:
+		text, err := c.Sender.Text(id)
+		if err != nil {
+			return false, err
+		}
+		plain := *text
+		if !hasPlaintext(textFields(&plain)) {
+			return false, nil
+		}
+		if err := c.openStrings(kind, id, textFields(&plain)); err != nil {
+			return false, err
+		}
+		return true, c.UpdateText(&plain)
+	case models.KindBinary:
+		binary, err := c.Sender.Bin(id)
+		if err != nil {
+			return false, err
+		}
+		// The uploaded content is migrated after the file name, the upload keeps the fields of the record
+		migrated := hasPlaintext(binaryFields(binary)) || binary.ContentID == "" && !vault.IsEncrypted(string(binary.Data))
+		if migrated {
+			if err := c.openStrings(kind, id, binaryFields(binary)); err != nil {
+				return false, err
+			}
+			data, err := c.vault.OpenBytes(vault.AD(models.KindBinary, binary.ID, "data"), binary.Data)
+			if err != nil {
+				return false, err
+			}
+			binary.Data = data
+			if err := c.UpdateBin(binary); err != nil {
+				return false, err
+			}
+		}
+		if binary.ContentID == "" {
+			return migrated, nil
+		}
+		uploaded, err := c.migrateContent(binary)
+		return migrated || uploaded, err
+	case models.KindSSH:
+		key, err := c.Sender.SSHKey(id)
+		if err != nil {
+			return false, err
+		}
+		plain := *key
+		if !hasPlaintext(sshKeyFields(&plain)) {
+			return false, nil
+		}
+		return true, c.UpdateSSHKey(&plain)
+	case models.KindCustom:
+		record, err := c.Sender.CustomRecord(id)
+		if err != nil {
+			return false, err
+		}
+		fields := secretFields(record.Secrets)
+		if !hasPlaintext(fields) {
+			return false, nil
+		}
+		if err := c.openStrings(kind, id, fields); err != nil {
+			return false, err
+		}
+		record.Secrets = secretValues(fields)
+		return true, c.UpdateCustomRecord(record)
+	}
+	return false, ErrNotFound
+}
+
+// errProbed stops the download of the content after its beginning is read
+var errProbed = errors.New("the beginning of the content is read")
+
+// contentProbe keeps the beginning of the content, enough to tell the encrypted content from the plaintext
+type contentProbe struct {
+	buf []byte
+}
+
+func (p *contentProbe) Write(b []byte) (int, error) {
+	n := min(len(b), len(models.EncryptedPrefix)-len(p.buf))
+	p.buf = append(p.buf, b[:n]...)
+	if len(p.buf) == len(models.EncryptedPrefix) {
+		return n, errProbed
+	}
+	return n, nil
+}
+
+// migrateContent uploads the content again encrypted chunk by chunk if it is readable by the server:
+// it was uploaded before the encryption was enabled or moved to the blob store from the plaintext data.
+// The content encrypted by UploadBin or moved from the encrypted data is left as is
+func (c *CryptoSender) migrateContent(binary *models.Binary) (bool, error) {
+	if binary.Size == 0 {
+		return false, nil
+	}
+	probe := &contentProbe{}
+	if err := c.Sender.DownloadBin(binary, probe); err != nil && !errors.Is(err, errProbed) {
+		return false, err
+	}
+	if bytes.HasPrefix(probe.buf, []byte(models.EncryptedPrefix)) {
+		return false, nil
+	}
+
+	// The content can be larger than the memory, it is kept in a temporary file between the transfers
+	file, err := os.CreateTemp("", "gophkeeper-content-*")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if err := c.Sender.DownloadBin(binary, file); err != nil {
+		return false, err
+	}
+	return true, c.UploadBin(binary, file)
+}
+
+// sealStrings encrypts the fields in place
+func (c *CryptoSender) sealStrings(kind models.Kind, id string, fields map[string]*string) error {
+	if c.vault == nil {
+		return vault.ErrLocked
+	}
+	for name, value := range fields {
+		sealed, err := c.vault.SealString(vault.AD(kind, id, name), *value)
+		if err != nil {
+			return err
+		}
+		*value = sealed
+	}
+	return nil
+}
+
+// openStrings decrypts the fields in place. Plain values of old records are left as is
+func (c *CryptoSender) openStrings(kind models.Kind, id string, fields map[string]*string) error {
+	for name, value := range fields {
+		if !vault.IsEncrypted(*value) {
+			continue
+		}
+		if c.vault == nil {
+			return vault.ErrLocked
+		}
+		plain, err := c.vault.OpenString(vault.AD(kind, id, name), *value)
+		if err != nil {
+			return err
+		}
+		*value = plain
+	}
+	return nil
+}
+
+// hasPlaintext checks whether at least one of the fields is not encrypted.
+// Empty values are skipped, the fields added later are empty in the already encrypted records
+func hasPlaintext(fields map[string]*string) bool {
+	for _, value := range fields {
+		if *value != "" && !vault.IsEncrypted(*value) {
+			return true
+		}
+	}
+	return false
+}
+
+// cardFields secret fields of the card
+func cardFields(card *models.Card) map[string]*string {
+	return map[string]*string{
+		"fio":    &card.FIO,
+		"number": &card.Number,
+		"date":   &card.Date,
+		"cvv":    &card.CVV,
+	}
+}
+
+// loginFields secret fields of the login
+func loginFields(login *models.Login) map[string]*string {
+	return map[string]*string{
+		"login":    &login.Login,
+		"password": &login.Password,
+		"totp":     &login.TOTP,
+	}
+}
+
+// textFields secret fields of the text
+func textFields(text *models.Text) map[string]*string {
+	return map[string]*string{
+		"content": &text.Content,
+	}
+}
+
+// binaryFields secret fields of the binary besides the data, which is sealed as bytes
+func binaryFields(binary *models.Binary) map[string]*string {
+	return map[string]*string{
+		"file_name": &binary.FileName,
+	}
+}
+
+// sshKeyFields secret fields of the ssh key, the public key stays in plain for the fingerprint
+func sshKeyFields(key *models.SSHKey) map[string]*string {
+	return map[string]*string{
+		"private_key": &key.PrivateKey,
+	}
+}
+
+// secretFields values of the secret fields of the custom record, named by the field of the type.
+// The values are copied, secretValues collects them back after the encryption
+func secretFields(secrets map[string]string) map[string]*string {
+	fields := make(map[string]*string, len(secrets))
+	for name, value := range secrets {
+		value := value
+		fields["secrets."+name] = &value
+	}
+	return fields
+}
+
+// secretValues the secrets of the custom record from the fields made by secretFields, nil for no secrets
+func secretValues(fields map[string]*string) map[string]string {
+	if len(fields) == 0 {
+		return nil
+	}
+	secrets := make(map[string]string, len(fields))
+	for name, value := range fields {
+		secrets[strings.TrimPrefix(name, "secrets.")] = *value
+	}
+	return secrets
+}
@@ -0,0 +1,439 @@
+package api
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ncyellow/GophKeeper/internal/client/vault"
+	"github.com/ncyellow/GophKeeper/internal/models"
+)
+
+// fakeSender in-memory server of the records, it keeps them as they are sent
+type fakeSender struct {
+	Sender
+	params   models.VaultParams
+	checks   int
+	records  map[string][]byte
+	versions map[string]int64
+	saves    int
+}
+
+func newFakeSender() *fakeSender {
+	return &fakeSender{
+		params:   models.VaultParams{Salt: []byte("0123456789abcdef")},
+		records:  make(map[string][]byte),
+		versions: make(map[string]int64),
+	}
+}
+
+func (f *fakeSender) Vault() (*models.VaultParams, error) {
+	params := f.params
+	return &params, nil
+}
+
+func (f *fakeSender) SetVaultCheck(check string) error {
+	f.checks++
+	f.params.Check = check
+	return nil
+}
+
+// save adds the record if version is zero, otherwise replaces the record of the version. Returns the new version
+func (f *fakeSender) save(kind models.Kind, id string, version int64, record interface{}) (int64, error) {
+	key := string(kind) + "/" + id
+	current, ok := f.versions[key]
+	switch {
+	case version == 0 && ok:
+		return 0, ErrAlreadyExists
+	case version != 0 && !ok:
+		return 0, ErrNotFound
+	case version != 0 && version != current:
+		return 0, ErrVersionConflict
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return 0, err
+	}
+	f.records[key] = data
+	f.versions[key] = current + 1
+	f.saves++
+	return current + 1, nil
+}
+
+// load reads the record as it was saved, returns its version
+func (f *fakeSender) load(kind models.Kind, id string, record interface{}) (int64, error) {
+	key := string(kind) + "/" + id
+	data, ok := f.records[key]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	return f.versions[key], json.Unmarshal(data, record)
+}
+
+func (f *fakeSender) AddCard(card *models.Card) error {
+	_, err := f.save(models.KindCard, card.ID, 0, card)
+	return err
+}
+
+func (f *fakeSender) Card(cardID string) (*models.Card, error) {
+	var card models.Card
+	version, err := f.load(models.KindCard, cardID, &card)
+	card.Version = version
+	return &card, err
+}
+
+func (f *fakeSender) UpdateCard(card *models.Card) error {
+	version, err := f.save(models.KindCard, card.ID, card.Version, card)
+	if err == nil {
+		card.Version = version
+	}
+	return err
+}
+
+func (f *fakeSender) AddLogin(login *models.Login) error {
+	_, err := f.save(models.KindLogin, login.ID, 0, login)
+	return err
+}
+
+func (f *fakeSender) Login(loginID string) (*models.Login, error) {
+	var login models.Login
+	version, err := f.load(models.KindLogin, loginID, &login)
+	login.Version = version
+	return &login, err
+}
+
+func (f *fakeSender) UpdateLogin(login *models.Login) error {
+	version, err := f.save(models.KindLogin, login.ID, login.Version, login)
+	if err == nil {
+		login.Version = version
+	}
+	return err
+}
+
+func (f *fakeSender) AddText(text *models.Text) error {
+	_, err := f.save(models.KindText, text.ID, 0, text)
+	return err
+}
+
+func (f *fakeSender) Text(textID string) (*models.Text, error) {
+	var text models.Text
+	version, err := f.load(models.KindText, textID, &text)
+	text.Version = version
+	return &text, err
+}
+
+func (f *fakeSender) UpdateText(text *models.Text) error {
+	version, err := f.save(models.KindText, text.ID, text.Version, text)
+	if err == nil {
+		text.Version = version
+	}
+	return err
+}
+
+func (f *fakeSender) AddBin(binary *models.Binary) error {
+	_, err := f.save(models.KindBinary, binary.ID, 0, binary)
+	return err
+}
+
+func (f *fakeSender) Bin(binID string) (*models.Binary, error) {
+	var binary models.Binary
+	version, err := f.load(models.KindBinary, binID, &binary)
+	binary.Version = version
+	return &binary, err
+}
+
+func (f *fakeSender) UpdateBin(binary *models.Binary) error {
+	version, err := f.save(models.KindBinary, binary.ID, binary.Version, binary)
+	if err == nil {
+		binary.Version = version
+	}
+	return err
+}
+
+func (f *fakeSender) AddSSHKey(key *models.SSHKey) error {
+	_, err := f.save(models.KindSSH, key.ID, 0, key)
+	return err
+}
+
+func (f *fakeSender) SSHKey(keyID string) (*models.SSHKey, error) {
+	var key models.SSHKey
+	version, err := f.load(models.KindSSH, keyID, &key)
+	key.Version = version
+	return &key, err
+}
+
+func (f *fakeSender) UpdateSSHKey(key *models.SSHKey) error {
+	version, err := f.save(models.KindSSH, key.ID, key.Version, key)
+	if err == nil {
+		key.Version = version
+	}
+	return err
+}
+
+func (f *fakeSender) AddCustomRecord(record *models.CustomRecord) error {
+	_, err := f.save(models.KindCustom, record.ID, 0, record)
+	return err
+}
+
+func (f *fakeSender) CustomRecord(recordID string) (*models.CustomRecord, error) {
+	var record models.CustomRecord
+	version, err := f.load(models.KindCustom, recordID, &record)
+	record.Version = version
+	return &record, err
+}
+
+func (f *fakeSender) UpdateCustomRecord(record *models.CustomRecord) error {
+	version, err := f.save(models.KindCustom, record.ID, record.Version, record)
+	if err == nil {
+		record.Version = version
+	}
+	return err
+}
+
+// cryptoCase the record of one kind with a secret value, which must not reach the server in plain
+type cryptoCase struct {
+	kind   models.Kind
+	id     string
+	secret string
+	add    func(s Sender) error
+	read   func(s Sender) (interface{}, error)
+	// want the record read back, its version is set by the test
+	want func(version int64) interface{}
+	// update saves the record of the version with the secret, returns the version and the secret after the call
+	update func(s Sender, version int64, secret string) (int64, string, error)
+}
+
+func cryptoCases(t *testing.T) []cryptoCase {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	sshKey := models.SSHKey{ID: "deploy", PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))}
+	require.NoError(t, sshKey.Validate())
+
+	return []cryptoCase{
+		{
+			kind: models.KindCard, id: "card", secret: "4111111111111111",
+			add: func(s Sender) error {
+				return s.AddCard(&models.Card{ID: "card", FIO: "IVAN IVANOV", Number: "4111111111111111", Date: "12/30", CVV: "123", Note: "salary"})
+			},
+			read: func(s Sender) (interface{}, error) { return s.Card("card") },
+			want: func(version int64) interface{} {
+				return &models.Card{ID: "card", FIO: "IVAN IVANOV", Number: "4111111111111111", Date: "12/30", CVV: "123",
+					Note: "salary", Version: version}
+			},
+			update: func(s Sender, version int64, secret string) (int64, string, error) {
+				card := models.Card{ID: "card", Number: secret, Version: version}
+				err := s.UpdateCard(&card)
+				return card.Version, card.Number, err
+			},
+		},
+		{
+			kind: models.KindLogin, id: "login", secret: "p@ssw0rd",
+			add: func(s Sender) error {
+				return s.AddLogin(&models.Login{ID: "login", Login: "user", Password: "p@ssw0rd", TOTP: "otpauth://totp/site"})
+			},
+			read: func(s Sender) (interface{}, error) { return s.Login("login") },
+			want: func(version int64) interface{} {
+				return &models.Login{ID: "login", Login: "user", Password: "p@ssw0rd", TOTP: "otpauth://totp/site", Version: version}
+			},
+			update: func(s Sender, version int64, secret string) (int64, string, error) {
+				login := models.Login{ID: "login", Password: secret, Version: version}
+				err := s.UpdateLogin(&login)
+				return login.Version, login.Password, err
+			},
+		},
+		{
+			kind: models.KindText, id: "text", secret: "the secret text",
+			add:  func(s Sender) error { return s.AddText(&models.Text{ID: "text", Content: "the secret text"}) },
+			read: func(s Sender) (interface{}, error) { return s.Text("text") },
+			want: func(version int64) interface{} {
+				return &models.Text{ID: "text", Content: "the secret text", Version: version}
+			},
+			update: func(s Sender, version int64, secret string) (int64, string, error) {
+				text := models.Text{ID: "text", Content: secret, Version: version}
+				err := s.UpdateText(&text)
+				return text.Version, text.Content, err
+			},
+		},
+		{
+			kind: models.KindBinary, id: "bin", secret: "report.pdf",
+			add: func(s Sender) error {
+				return s.AddBin(&models.Binary{ID: "bin", Data: []byte{0, 1, 2}, FileName: "report.pdf"})
+			},
+			read: func(s Sender) (interface{}, error) { return s.Bin("bin") },
+			want: func(version int64) interface{} {
+				return &models.Binary{ID: "bin", Data: []byte{0, 1, 2}, FileName: "report.pdf", Version: version}
+			},
+			update: func(s Sender, version int64, secret string) (int64, string, error) {
+				binary := models.Binary{ID: "bin", Data: []byte{3}, FileName: secret, Version: version}
+				err := s.UpdateBin(&binary)
+				return binary.Version, binary.FileName, err
+			},
+		},
+		{
+			kind: models.KindSSH, id: "deploy", secret: sshKey.PrivateKey,
+			add: func(s Sender) error {
+				key := sshKey
+				return s.AddSSHKey(&key)
+			},
+			read: func(s Sender) (interface{}, error) { return s.SSHKey("deploy") },
+			want: func(version int64) interface{} {
+				key := sshKey
+				key.Version = version
+				return &key
+			},
+			update: func(s Sender, version int64, secret string) (int64, string, error) {
+				key := models.SSHKey{ID: "deploy", PrivateKey: secret, Version: version}
+				err := s.UpdateSSHKey(&key)
+				return key.Version, key.PrivateKey, err
+			},
+		},
+		{
+			kind: models.KindCustom, id: "bank", secret: "4321",
+			add: func(s Sender) error {
+				return s.AddCustomRecord(&models.CustomRecord{ID: "bank", Type: "bank", Values: map[string]string{"name": "bank"},
+					Secrets: map[string]string{"pin": "4321"}})
+			},
+			read: func(s Sender) (interface{}, error) { return s.CustomRecord("bank") },
+			want: func(version int64) interface{} {
+				return &models.CustomRecord{ID: "bank", Type: "bank", Values: map[string]string{"name": "bank"},
+					Secrets: map[string]string{"pin": "4321"}, Version: version}
+			},
+			update: func(s Sender, version int64, secret string) (int64, string, error) {
+				record := models.CustomRecord{ID: "bank", Type: "bank", Secrets: map[string]string{"pin": secret}, Version: version}
+				err := s.UpdateCustomRecord(&record)
+				return record.Version, record.Secrets["pin"], err
+			},
+		},
+	}
+}
+
+// stored the record as the server keeps it
+func (f *fakeSender) stored(kind models.Kind, id string) []byte {
+	return f.records[string(kind)+"/"+id]
+}
+
+func unlocked(t *testing.T, sender Sender) *CryptoSender {
+	crypto := NewCryptoSender(sender)
+	require.NoError(t, crypto.Unlock("master"))
+	return crypto
+}
+
+func TestCryptoSenderRoundTrip(t *testing.T) {
+	for _, tc := range cryptoCases(t) {
+		t.Run(string(tc.kind), func(t *testing.T) {
+			server := newFakeSender()
+			crypto := unlocked(t, server)
+			require.NoError(t, tc.add(crypto))
+
+			// The server keeps only the ciphertext
+			assert.NotContains(t, string(server.stored(tc.kind, tc.id)), tc.secret)
+			assert.Contains(t, string(server.stored(tc.kind, tc.id)), models.EncryptedPrefix)
+
+			record, err := tc.read(crypto)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want(1), record)
+
+			// The record is opened by the same master password in another client, the locked one can not open it
+			record, err = tc.read(unlocked(t, server))
+			require.NoError(t, err)
+			assert.Equal(t, tc.want(1), record)
+			_, err = tc.read(NewCryptoSender(server))
+			assert.ErrorIs(t, err, vault.ErrLocked)
+		})
+	}
+}
+
+func TestCryptoSenderWrongPassword(t *testing.T) {
+	server := newFakeSender()
+	crypto := unlocked(t, server)
+	check := server.params.Check
+	require.Equal(t, 1, server.checks)
+
+	for _, tc := range cryptoCases(t) {
+		require.NoError(t, tc.add(crypto))
+	}
+	saves := server.saves
+
+	other := NewCryptoSender(server)
+	assert.ErrorIs(t, other.Unlock("wrong"), vault.ErrWrongPassword)
+
+	// The check value is not replaced, the client stays locked and sends nothing
+	assert.Equal(t, check, server.params.Check)
+	assert.Equal(t, 1, server.checks)
+	for _, tc := range cryptoCases(t) {
+		_, err := tc.read(other)
+		assert.ErrorIs(t, err, vault.ErrLocked, tc.kind)
+		_, _, err = tc.update(other, 1, tc.secret)
+		assert.ErrorIs(t, err, vault.ErrLocked, tc.kind)
+	}
+	assert.Equal(t, saves, server.saves)
+	assert.ErrorIs(t, other.UploadBin(&models.Binary{ID: "bin"}, bytes.NewReader([]byte("content"))), vault.ErrLocked)
+}
+
+func TestCryptoSenderMigrate(t *testing.T) {
+	for _, tc := range cryptoCases(t) {
+		t.Run(string(tc.kind), func(t *testing.T) {
+			server := newFakeSender()
+			require.NoError(t, tc.add(server))
+
+			_, err := NewCryptoSender(server).Migrate(tc.kind, tc.id)
+			assert.ErrorIs(t, err, vault.ErrLocked)
+
+			// The record saved before the encryption is re-saved encrypted with the next version
+			crypto := unlocked(t, server)
+			migrated, err := crypto.Migrate(tc.kind, tc.id)
+			require.NoError(t, err)
+			assert.True(t, migrated)
+			assert.NotContains(t, string(server.stored(tc.kind, tc.id)), tc.secret)
+			record, err := tc.read(crypto)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want(2), record)
+
+			// The encrypted values are left alone
+			saves := server.saves
+			migrated, err = crypto.Migrate(tc.kind, tc.id)
+			require.NoError(t, err)
+			assert.False(t, migrated)
+			assert.Equal(t, saves, server.saves)
+		})
+	}
+
+	crypto := unlocked(t, newFakeSender())
+	_, err := crypto.Migrate(models.KindCard, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = crypto.Migrate("unknown", "id")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestCryptoSenderUpdate(t *testing.T) {
+	for _, tc := range cryptoCases(t) {
+		t.Run(string(tc.kind), func(t *testing.T) {
+			server := newFakeSender()
+			crypto := unlocked(t, server)
+			require.NoError(t, tc.add(crypto))
+
+			version, secret, err := tc.update(crypto, 1, tc.secret)
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), version)
+			// The caller keeps the plain values, only the copy sent to the server is encrypted
+			assert.Equal(t, tc.secret, secret)
+			assert.NotContains(t, string(server.stored(tc.kind, tc.id)), tc.secret)
+
+			// The record changed by another client is not replaced, the version of the stale one is kept
+			version, secret, err = tc.update(crypto, 1, tc.secret)
+			assert.ErrorIs(t, err, ErrVersionConflict)
+			assert.Equal(t, int64(1), version)
+			assert.Equal(t, tc.secret, secret)
+			assert.Equal(t, int64(2), server.versions[string(tc.kind)+"/"+tc.id])
+		})
+	}
+}
@@ -1,6 +1,12 @@
 package api
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+)
 
 // List of errors that the client can generate when interacting with the server
 // While some errors in the http client come as StatusCode with no error message.
@@ -15,6 +21,7 @@ var (
 	FmtErrAlreadyExists     = "ID with this identifier is already registered: %w"
 	FmtErrNotFound          = "record with this identifier was not found: %w"
 	FmtErrSerialization     = "serialization error: %w"
+	FmtErrTooManyAttempts   = "%w, retry after %s"
 
 	ErrSerialization     = errors.New("serialization error")
 	ErrAuthRequire       = errors.New("authorization required")
@@ -23,4 +30,38 @@ var (
 	ErrUserNotFound      = errors.New("a user with this login was not found")
 	ErrAlreadyExists     = errors.New("ID with this identifier is already registered")
 	ErrNotFound          = errors.New("record with this identifier was not found")
+	ErrSessionExpired    = errors.New("session is expired or revoked, please sign in again")
+	ErrOTPRequired       = errors.New("two-factor authentication code required")
+	ErrTooManyAttempts   = errors.New("too many sign-in attempts")
+	ErrCertNotBound      = errors.New("the client certificate can not be used for this account")
+	ErrInvalidOTP        = errors.New("invalid two-factor authentication code")
+	ErrTwoFactorState    = errors.New("two-factor authentication is already enabled or is not set up")
+	ErrInvalidPassword   = errors.New("invalid password")
+	ErrEmptyValue        = errors.New("login and password can not be empty")
+	ErrInvalidQuery      = errors.New("invalid cursor or sorting of the list")
+	ErrVersionConflict   = errors.New("the record was changed by another client, read it again")
+	ErrNameTaken         = errors.New("a vault, a folder or a record type with this name already exists")
+	ErrInvalidLocation   = errors.New("no such vault or folder")
+	ErrEmptyName         = errors.New("the name can not be empty")
+	ErrRecordTypeInUse   = errors.New("record type is used by records")
+	ErrContentReplaced   = errors.New("the content was replaced while it was downloaded, read the record again")
+	ErrContentRemoved    = errors.New("the uploaded content of the revision is removed, it can not be restored")
 )
+
+// invalidValues the errors of the values the server checks before saving the record.
+// ErrInvalidRecordType goes before ErrInvalidRecord, the message of the latter is the prefix of the former
+var invalidValues = []error{models.ErrInvalidCard, models.ErrInvalidSSHKey, models.ErrInvalidRecordType,
+	models.ErrInvalidRecord, models.ErrInvalidChunk}
+
+// invalidValue converts the reason of the record rejected by the server into the error wrapping the models error,
+// e.g. models.ErrInvalidCard. Returns nil if the record was rejected for another reason.
+// The server checks only the values that are not encrypted on the client
+func invalidValue(message string) error {
+	for _, invalid := range invalidValues {
+		if strings.HasPrefix(message, invalid.Error()) {
+			reason := strings.TrimPrefix(strings.TrimPrefix(message, invalid.Error()), ": ")
+			return fmt.Errorf("%w: %s", invalid, reason)
+		}
+	}
+	return nil
+}
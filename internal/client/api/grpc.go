@@ -2,16 +2,22 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	"github.com/ncyellow/GophKeeper/internal/client/config"
 	"github.com/ncyellow/GophKeeper/internal/models"
 	proto2 "github.com/ncyellow/GophKeeper/internal/proto"
+	"github.com/ncyellow/GophKeeper/internal/tlsconfig"
 )
 
 // GRPCSender structure of the grpc client. Implements the Sender interface. See the respective methods for all comments.
@@ -19,22 +25,45 @@ type GRPCSender struct {
 	conn   *grpc.ClientConn
 	client proto2.GophKeeperServerClient
 	conf   *config.Config
-	userID *int64
+	// authToken the jwt token received from Register or SignIn, it is sent with every request in the metadata
+	authToken *string
+	// refreshToken is used to get a new authToken when it expires
+	refreshToken string
+}
+
+// publicMethods grpc methods that are called without a token
+var publicMethods = map[string]bool{
+	"/proto.GophKeeperServer/Register": true,
+	"/proto.GophKeeperServer/SignIn":   true,
+	"/proto.GophKeeperServer/Refresh":  true,
 }
 
 // NewGRPCSender constructor
 func NewGRPCSender(conf *config.Config) (*GRPCSender, error) {
+	g := &GRPCSender{
+		conf: conf,
+	}
+	tlsConf, err := tlsconfig.Client(conf.CryptoCrt, conf.CryptoKey, conf.CACertFile)
+	if err != nil {
+		return nil, err
+	}
+	// The address without a host, e.g. ":3200", is the local server
+	if host, _, err := net.SplitHostPort(conf.GRPCAddress); err == nil && host == "" {
+		tlsConf.ServerName = "localhost"
+	}
+
 	// establish a connection to the server
-	conn, err := grpc.Dial(conf.GRPCAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.Dial(conf.GRPCAddress,
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConf)),
+		grpc.WithUnaryInterceptor(g.authInterceptor),
+		grpc.WithStreamInterceptor(g.authStreamInterceptor),
+	)
 	if err != nil {
 		return nil, err
 	}
-	client := proto2.NewGophKeeperServerClient(conn)
-	return &GRPCSender{
-		conf:   conf,
-		conn:   conn,
-		client: client,
-	}, nil
+	g.conn = conn
+	g.client = proto2.NewGophKeeperServerClient(conn)
+	return g, nil
 }
 
 func (g *GRPCSender) Register(login string, pwd string) error {
@@ -44,58 +73,75 @@ func (g *GRPCSender) Register(login string, pwd string) error {
 	})
 	if err != nil {
 		if e, ok := status.FromError(err); ok {
-			if e.Code() == codes.AlreadyExists {
+			switch e.Code() {
+			case codes.AlreadyExists:
 				return fmt.Errorf(FmtErrUserAlreadyExists, err)
+			case codes.PermissionDenied:
+				return ErrCertNotBound
 			}
 		}
 		return fmt.Errorf(FmtErrInternalServer, err)
 	}
-	userID := response.GetUser()
-	g.userID = &userID
-
-	fmt.Printf("userid = %d", userID)
-	fmt.Printf("userid = %d", *g.userID)
+	g.saveTokens(response)
 	return nil
 }
 
-func (g *GRPCSender) SignIn(login string, pwd string) error {
+func (g *GRPCSender) SignIn(login string, pwd string, otp string) error {
+	var header metadata.MD
 	response, err := g.client.SignIn(context.Background(), &proto2.RegisterRequest{
 		Login:    login,
 		Password: pwd,
-	})
+		Otp:      otp,
+	}, grpc.Header(&header))
 	if err != nil {
 		if e, ok := status.FromError(err); ok {
-			if e.Code() == codes.AlreadyExists {
-				return fmt.Errorf(FmtErrUserAlreadyExists, err)
+			switch e.Code() {
+			case codes.Unauthenticated:
+				return fmt.Errorf(FmtErrUserNotFound, err)
+			case codes.FailedPrecondition:
+				return ErrOTPRequired
+			case codes.PermissionDenied:
+				return ErrCertNotBound
+			case codes.ResourceExhausted:
+				return fmt.Errorf(FmtErrTooManyAttempts, ErrTooManyAttempts, retryAfter(header))
 			}
 		}
 		return fmt.Errorf(FmtErrInternalServer, err)
 	}
-	userID := response.GetUser()
-	g.userID = &userID
+	g.saveTokens(response)
 	return nil
 }
 
 func (g *GRPCSender) AddCard(card *models.Card) error {
-	if g.userID == nil {
-		return ErrAuthRequire
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
 	}
-	_, err := g.client.AddCard(context.Background(), &proto2.AddCardRequest{
+	_, err = g.client.AddCard(ctx, &proto2.AddCardRequest{
 		Card: &proto2.Card{
-			Id:       card.ID,
-			Fio:      card.FIO,
-			Number:   card.Number,
-			Date:     card.Date,
-			Cvv:      card.CVV,
-			Metainfo: card.MetaInfo,
+			Id:     card.ID,
+			Fio:    card.FIO,
+			Number: card.Number,
+			Date:   card.Date,
+			Cvv:    card.CVV,
+			Note:   card.Note,
+			Tags:   card.Tags,
+			Fields: card.Fields,
+			Vault:  card.Vault,
+			Folder: card.Folder,
 		},
-		User: *g.userID,
 	})
 	if err != nil {
 		if e, ok := status.FromError(err); ok {
 			if e.Code() == codes.AlreadyExists {
 				return fmt.Errorf(FmtErrAlreadyExists, err)
 			}
+			if err := invalidValue(e.Message()); e.Code() == codes.InvalidArgument && err != nil {
+				return err
+			}
+			if e.Code() == codes.InvalidArgument {
+				return ErrInvalidLocation
+			}
 		}
 		return fmt.Errorf(FmtErrInternalServer, err)
 	}
@@ -103,13 +149,13 @@ func (g *GRPCSender) AddCard(card *models.Card) error {
 }
 
 func (g *GRPCSender) Card(cardID string) (*models.Card, error) {
-	if g.userID == nil {
-		return nil, ErrAuthRequire
+	ctx, err := g.authContext()
+	if err != nil {
+		return nil, err
 	}
 
-	response, err := g.client.Card(context.Background(), &proto2.CardRequest{
-		Id:   cardID,
-		User: *g.userID,
+	response, err := g.client.Card(ctx, &proto2.CardRequest{
+		Id: cardID,
 	})
 	if err != nil {
 		if e, ok := status.FromError(err); ok {
@@ -122,48 +168,86 @@ func (g *GRPCSender) Card(cardID string) (*models.Card, error) {
 
 	respCard := response.GetCard()
 	return &models.Card{
-		ID:       respCard.GetId(),
-		FIO:      respCard.GetFio(),
-		Number:   respCard.GetNumber(),
-		Date:     respCard.GetDate(),
-		CVV:      respCard.GetCvv(),
-		MetaInfo: respCard.GetMetainfo(),
+		ID:      respCard.GetId(),
+		FIO:     respCard.GetFio(),
+		Number:  respCard.GetNumber(),
+		Date:    respCard.GetDate(),
+		CVV:     respCard.GetCvv(),
+		Note:    respCard.GetNote(),
+		Tags:    respCard.GetTags(),
+		Fields:  respCard.GetFields(),
+		Vault:   respCard.GetVault(),
+		Folder:  respCard.GetFolder(),
+		Version: respCard.GetVersion(),
 	}, nil
 }
 
+func (g *GRPCSender) UpdateCard(card *models.Card) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	response, err := g.client.UpdateCard(ctx, &proto2.UpdateCardRequest{
+		Card: &proto2.Card{
+			Id:      card.ID,
+			Fio:     card.FIO,
+			Number:  card.Number,
+			Date:    card.Date,
+			Cvv:     card.CVV,
+			Note:    card.Note,
+			Tags:    card.Tags,
+			Fields:  card.Fields,
+			Version: card.Version,
+		},
+	})
+	if err != nil {
+		return updateError(err)
+	}
+	card.Version = response.GetVersion()
+	return nil
+}
+
 func (g *GRPCSender) DelCard(cardID string) error {
-	if g.userID == nil {
-		return ErrAuthRequire
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
 	}
-	_, err := g.client.DeleteCard(context.Background(), &proto2.DeleteCardRequest{
-		Id:   cardID,
-		User: *g.userID,
+	_, err = g.client.DeleteCard(ctx, &proto2.DeleteCardRequest{
+		Id: cardID,
 	})
 	if err != nil {
-		return fmt.Errorf(FmtErrInternalServer, err)
+		return deleteError(err)
 	}
 	return nil
 }
 
 func (g *GRPCSender) AddLogin(login *models.Login) error {
-	if g.userID == nil {
-		return ErrAuthRequire
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
 	}
 
-	_, err := g.client.AddLogin(context.Background(), &proto2.AddLoginRequest{
+	_, err = g.client.AddLogin(ctx, &proto2.AddLoginRequest{
 		Login: &proto2.Login{
 			Id:       login.ID,
 			Login:    login.Login,
 			Password: login.Password,
-			Metainfo: login.MetaInfo,
+			Totp:     login.TOTP,
+			Note:     login.Note,
+			Tags:     login.Tags,
+			Fields:   login.Fields,
+			Vault:    login.Vault,
+			Folder:   login.Folder,
 		},
-		User: *g.userID,
 	})
 	if err != nil {
 		if e, ok := status.FromError(err); ok {
 			if e.Code() == codes.AlreadyExists {
 				return fmt.Errorf(FmtErrAlreadyExists, err)
 			}
+			if e.Code() == codes.InvalidArgument {
+				return ErrInvalidLocation
+			}
 		}
 		return fmt.Errorf(FmtErrInternalServer, err)
 	}
@@ -171,13 +255,13 @@ func (g *GRPCSender) AddLogin(login *models.Login) error {
 }
 
 func (g *GRPCSender) Login(loginID string) (*models.Login, error) {
-	if g.userID == nil {
-		return nil, ErrAuthRequire
+	ctx, err := g.authContext()
+	if err != nil {
+		return nil, err
 	}
 
-	response, err := g.client.Login(context.Background(), &proto2.LoginRequest{
-		Id:   loginID,
-		User: *g.userID,
+	response, err := g.client.Login(ctx, &proto2.LoginRequest{
+		Id: loginID,
 	})
 	if err != nil {
 		if e, ok := status.FromError(err); ok {
@@ -193,42 +277,79 @@ func (g *GRPCSender) Login(loginID string) (*models.Login, error) {
 		ID:       respLogin.GetId(),
 		Login:    respLogin.GetLogin(),
 		Password: respLogin.GetPassword(),
-		MetaInfo: respLogin.GetMetainfo(),
+		TOTP:     respLogin.GetTotp(),
+		Note:     respLogin.GetNote(),
+		Tags:     respLogin.GetTags(),
+		Fields:   respLogin.GetFields(),
+		Vault:    respLogin.GetVault(),
+		Folder:   respLogin.GetFolder(),
+		Version:  respLogin.GetVersion(),
 	}, nil
 }
 
+func (g *GRPCSender) UpdateLogin(login *models.Login) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	response, err := g.client.UpdateLogin(ctx, &proto2.UpdateLoginRequest{
+		Login: &proto2.Login{
+			Id:       login.ID,
+			Login:    login.Login,
+			Password: login.Password,
+			Totp:     login.TOTP,
+			Note:     login.Note,
+			Tags:     login.Tags,
+			Fields:   login.Fields,
+			Version:  login.Version,
+		},
+	})
+	if err != nil {
+		return updateError(err)
+	}
+	login.Version = response.GetVersion()
+	return nil
+}
+
 func (g *GRPCSender) DelLogin(loginID string) error {
-	if g.userID == nil {
-		return ErrAuthRequire
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
 	}
-	_, err := g.client.DeleteLogin(context.Background(), &proto2.DeleteLoginRequest{
-		Id:   loginID,
-		User: *g.userID,
+	_, err = g.client.DeleteLogin(ctx, &proto2.DeleteLoginRequest{
+		Id: loginID,
 	})
 	if err != nil {
-		return fmt.Errorf(FmtErrInternalServer, err)
+		return deleteError(err)
 	}
 	return nil
 }
 
 func (g *GRPCSender) AddText(text *models.Text) error {
-	if g.userID == nil {
-		return ErrAuthRequire
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
 	}
 
-	_, err := g.client.AddText(context.Background(), &proto2.AddTextRequest{
+	_, err = g.client.AddText(ctx, &proto2.AddTextRequest{
 		Text: &proto2.Text{
-			Id:       text.ID,
-			Content:  text.Content,
-			Metainfo: text.MetaInfo,
+			Id:      text.ID,
+			Content: text.Content,
+			Note:    text.Note,
+			Tags:    text.Tags,
+			Fields:  text.Fields,
+			Vault:   text.Vault,
+			Folder:  text.Folder,
 		},
-		User: *g.userID,
 	})
 	if err != nil {
 		if e, ok := status.FromError(err); ok {
 			if e.Code() == codes.AlreadyExists {
 				return fmt.Errorf(FmtErrAlreadyExists, err)
 			}
+			if e.Code() == codes.InvalidArgument {
+				return ErrInvalidLocation
+			}
 		}
 		return fmt.Errorf(FmtErrInternalServer, err)
 	}
@@ -236,13 +357,13 @@ func (g *GRPCSender) AddText(text *models.Text) error {
 }
 
 func (g *GRPCSender) Text(textID string) (*models.Text, error) {
-	if g.userID == nil {
-		return nil, ErrAuthRequire
+	ctx, err := g.authContext()
+	if err != nil {
+		return nil, err
 	}
 
-	response, err := g.client.Text(context.Background(), &proto2.TextRequest{
-		Id:   textID,
-		User: *g.userID,
+	response, err := g.client.Text(ctx, &proto2.TextRequest{
+		Id: textID,
 	})
 	if err != nil {
 		if e, ok := status.FromError(err); ok {
@@ -255,44 +376,82 @@ func (g *GRPCSender) Text(textID string) (*models.Text, error) {
 
 	respText := response.GetText()
 	return &models.Text{
-		ID:       respText.GetId(),
-		Content:  respText.GetContent(),
-		MetaInfo: respText.GetMetainfo(),
+		ID:      respText.GetId(),
+		Content: respText.GetContent(),
+		Note:    respText.GetNote(),
+		Tags:    respText.GetTags(),
+		Fields:  respText.GetFields(),
+		Vault:   respText.GetVault(),
+		Folder:  respText.GetFolder(),
+		Version: respText.GetVersion(),
 	}, nil
 }
 
+func (g *GRPCSender) UpdateText(text *models.Text) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	response, err := g.client.UpdateText(ctx, &proto2.UpdateTextRequest{
+		Text: &proto2.Text{
+			Id:      text.ID,
+			Content: text.Content,
+			Note:    text.Note,
+			Tags:    text.Tags,
+			Fields:  text.Fields,
+			Version: text.Version,
+		},
+	})
+	if err != nil {
+		return updateError(err)
+	}
+	text.Version = response.GetVersion()
+	return nil
+}
+
 func (g *GRPCSender) DelText(textID string) error {
-	if g.userID == nil {
-		return ErrAuthRequire
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
 	}
-	_, err := g.client.DeleteText(context.Background(), &proto2.DeleteTextRequest{
-		Id:   textID,
-		User: *g.userID,
+	_, err = g.client.DeleteText(ctx, &proto2.DeleteTextRequest{
+		Id: textID,
 	})
 	if err != nil {
-		return fmt.Errorf(FmtErrInternalServer, err)
+		return deleteError(err)
 	}
 	return nil
 }
 
 func (g *GRPCSender) AddBin(binary *models.Binary) error {
-	if g.userID == nil {
-		return ErrAuthRequire
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
 	}
 
-	_, err := g.client.AddBinary(context.Background(), &proto2.AddBinRequest{
+	_, err = g.client.AddBinary(ctx, &proto2.AddBinRequest{
 		Binary: &proto2.Binary{
 			Id:       binary.ID,
 			Data:     binary.Data,
-			Metainfo: binary.MetaInfo,
+			FileName: binary.FileName,
+			FileSize: binary.FileSize,
+			MimeType: binary.MimeType,
+			ModTime:  unixTime(binary.ModTime),
+			Note:     binary.Note,
+			Tags:     binary.Tags,
+			Fields:   binary.Fields,
+			Vault:    binary.Vault,
+			Folder:   binary.Folder,
 		},
-		User: *g.userID,
 	})
 	if err != nil {
 		if e, ok := status.FromError(err); ok {
 			if e.Code() == codes.AlreadyExists {
 				return fmt.Errorf(FmtErrAlreadyExists, err)
 			}
+			if e.Code() == codes.InvalidArgument {
+				return ErrInvalidLocation
+			}
 		}
 		return fmt.Errorf(FmtErrInternalServer, err)
 	}
@@ -300,13 +459,13 @@ func (g *GRPCSender) AddBin(binary *models.Binary) error {
 }
 
 func (g *GRPCSender) Bin(binID string) (*models.Binary, error) {
-	if g.userID == nil {
-		return nil, ErrAuthRequire
+	ctx, err := g.authContext()
+	if err != nil {
+		return nil, err
 	}
 
-	response, err := g.client.Binary(context.Background(), &proto2.BinRequest{
-		Id:   binID,
-		User: *g.userID,
+	response, err := g.client.Binary(ctx, &proto2.BinRequest{
+		Id: binID,
 	})
 	if err != nil {
 		if e, ok := status.FromError(err); ok {
@@ -319,22 +478,1278 @@ func (g *GRPCSender) Bin(binID string) (*models.Binary, error) {
 
 	respText := response.GetBinary()
 	return &models.Binary{
-		ID:       respText.GetId(),
-		Data:     respText.GetData(),
-		MetaInfo: respText.GetMetainfo(),
+		ID:        respText.GetId(),
+		Data:      respText.GetData(),
+		ContentID: respText.GetContentId(),
+		Size:      respText.GetSize(),
+		FileName:  respText.GetFileName(),
+		FileSize:  respText.GetFileSize(),
+		MimeType:  respText.GetMimeType(),
+		ModTime:   timeOfUnix(respText.GetModTime()),
+		Note:      respText.GetNote(),
+		Tags:      respText.GetTags(),
+		Fields:    respText.GetFields(),
+		Vault:     respText.GetVault(),
+		Folder:    respText.GetFolder(),
+		Version:   respText.GetVersion(),
 	}, nil
 }
 
+func (g *GRPCSender) UpdateBin(binary *models.Binary) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	response, err := g.client.UpdateBinary(ctx, &proto2.UpdateBinRequest{
+		Binary: &proto2.Binary{
+			Id:        binary.ID,
+			Data:      binary.Data,
+			ContentId: binary.ContentID,
+			FileName:  binary.FileName,
+			FileSize:  binary.FileSize,
+			MimeType:  binary.MimeType,
+			ModTime:   unixTime(binary.ModTime),
+			Note:      binary.Note,
+			Tags:      binary.Tags,
+			Fields:    binary.Fields,
+			Version:   binary.Version,
+		},
+	})
+	if err != nil {
+		return updateError(err)
+	}
+	binary.Version = response.GetVersion()
+	return nil
+}
+
 func (g *GRPCSender) DelBin(binID string) error {
-	if g.userID == nil {
-		return ErrAuthRequire
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	_, err = g.client.DeleteBinary(ctx, &proto2.DeleteBinRequest{
+		Id: binID,
+	})
+	if err != nil {
+		return deleteError(err)
+	}
+	return nil
+}
+
+func (g *GRPCSender) UploadBin(binary *models.Binary, content io.ReadSeeker) error {
+	return uploadContent(g, binary, content)
+}
+
+func (g *GRPCSender) DownloadBin(binary *models.Binary, w io.Writer) error {
+	return downloadContent(g, binary, w)
+}
+
+func (g *GRPCSender) startUpload(binID string) (*models.BinaryUpload, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return nil, err
+	}
+	response, err := g.client.StartUpload(ctx, &proto2.StartUploadRequest{BinId: binID})
+	if err != nil {
+		return nil, uploadError(err)
+	}
+	return uploadModel(response.GetUpload()), nil
+}
+
+func (g *GRPCSender) uploadState(binID string, uploadID string) (*models.BinaryUpload, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return nil, err
+	}
+	response, err := g.client.UploadState(ctx, &proto2.UploadStateRequest{BinId: binID, UploadId: uploadID})
+	if err != nil {
+		return nil, uploadError(err)
+	}
+	return uploadModel(response.GetUpload()), nil
+}
+
+// sendChunks sends the chunks in one client stream. If the token is expired, a new one is received
+// and the error is returned, the upload is resumed from the chunks the server saved
+func (g *GRPCSender) sendChunks(upload *models.BinaryUpload, next func() ([]byte, error)) (*models.BinaryUpload, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return nil, err
+	}
+	// The stream without chunks is rejected, the empty content has nothing to send
+	data, err := next()
+	if errors.Is(err, io.EOF) {
+		return upload, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := g.client.UploadChunks(ctx)
+	if err != nil {
+		return nil, g.streamError(ctx, err)
+	}
+	for seq := upload.Chunks; ; seq++ {
+		err := stream.Send(&proto2.UploadChunk{BinId: upload.BinaryID, UploadId: upload.ID, Seq: seq, Data: data})
+		if errors.Is(err, io.EOF) {
+			// The server closed the stream, its status is returned by CloseAndRecv
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf(FmtErrServerTimout, err)
+		}
+		if data, err = next(); errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	response, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, g.streamError(ctx, err)
+	}
+	return uploadModel(response.GetUpload()), nil
+}
+
+func (g *GRPCSender) completeUpload(binary *models.Binary, uploadID string) (int64, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return 0, err
+	}
+	response, err := g.client.CompleteUpload(ctx, &proto2.CompleteUploadRequest{
+		BinId:    binary.ID,
+		UploadId: uploadID,
+		Version:  binary.Version,
+	})
+	if err != nil {
+		return 0, updateError(err)
+	}
+	return response.GetVersion(), nil
+}
+
+// readContent writes the content from the server stream. The content id is sent with the request,
+// so the server does not resume the download of the replaced content
+func (g *GRPCSender) readContent(binary *models.Binary, offset int64, w io.Writer) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := g.client.DownloadBinary(ctx, &proto2.DownloadBinaryRequest{
+		Id:        binary.ID,
+		Offset:    offset,
+		ContentId: binary.ContentID,
+	})
+	if err != nil {
+		return g.streamError(ctx, err)
+	}
+	for {
+		message, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return g.streamError(ctx, err)
+		}
+		if message.GetOffset() != offset {
+			return fmt.Errorf(FmtErrInternalServer, io.ErrUnexpectedEOF)
+		}
+		if _, err := w.Write(message.GetData()); err != nil {
+			return err
+		}
+		offset += int64(len(message.GetData()))
+	}
+}
+
+// streamError converts the status of the content streams to the client errors. The expired token comes
+// with the stream, so a new one is received here and the transfer is resumed by the caller
+func (g *GRPCSender) streamError(ctx context.Context, err error) error {
+	if status.Code(err) == codes.Unauthenticated && g.refreshToken != "" {
+		if err := g.refresh(context.WithoutCancel(ctx)); err != nil {
+			return err
+		}
+		return fmt.Errorf(FmtErrServerTimout, err)
+	}
+	return uploadError(err)
+}
+
+// unixTime the optional time as it is sent, 0 if it is unknown
+func unixTime(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.Unix()
+}
+
+// timeOfUnix the optional time of the response, nil for 0
+func timeOfUnix(sec int64) *time.Time {
+	if sec == 0 {
+		return nil
+	}
+	t := time.Unix(sec, 0)
+	return &t
+}
+
+func uploadModel(upload *proto2.BinaryUpload) *models.BinaryUpload {
+	return &models.BinaryUpload{ID: upload.GetId(), BinaryID: upload.GetBinId(), Offset: upload.GetOffset(),
+		Chunks: upload.GetChunks()}
+}
+
+// uploadError converts the grpc status of the binary content requests to the client errors
+func uploadError(err error) error {
+	switch status.Code(err) {
+	case codes.NotFound:
+		// The download is not resumed for the deleted record, so ErrNotFound is kept in the chain
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	case codes.FailedPrecondition:
+		if e, _ := status.FromError(err); e.Message() == "content is replaced" {
+			return ErrContentReplaced
+		}
+		return errChunkOrder
+	case codes.InvalidArgument:
+		if e, _ := status.FromError(err); invalidValue(e.Message()) != nil {
+			return invalidValue(e.Message())
+		}
+	}
+	return fmt.Errorf(FmtErrInternalServer, err)
+}
+
+func (g *GRPCSender) AddSSHKey(key *models.SSHKey) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
 	}
-	_, err := g.client.DeleteBinary(context.Background(), &proto2.DeleteBinRequest{
-		Id:   binID,
-		User: *g.userID,
+
+	_, err = g.client.AddSSHKey(ctx, &proto2.AddSSHKeyRequest{
+		Key: &proto2.SSHKey{
+			Id:         key.ID,
+			PrivateKey: key.PrivateKey,
+			PublicKey:  key.PublicKey,
+			Comment:    key.Comment,
+			Note:       key.Note,
+			Tags:       key.Tags,
+			Fields:     key.Fields,
+			Vault:      key.Vault,
+			Folder:     key.Folder,
+		},
 	})
 	if err != nil {
+		if e, ok := status.FromError(err); ok {
+			if e.Code() == codes.AlreadyExists {
+				return fmt.Errorf(FmtErrAlreadyExists, err)
+			}
+			if err := invalidValue(e.Message()); e.Code() == codes.InvalidArgument && err != nil {
+				return err
+			}
+			if e.Code() == codes.InvalidArgument {
+				return ErrInvalidLocation
+			}
+		}
 		return fmt.Errorf(FmtErrInternalServer, err)
 	}
 	return nil
 }
+
+func (g *GRPCSender) SSHKey(keyID string) (*models.SSHKey, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := g.client.SSHKey(ctx, &proto2.SSHKeyRequest{
+		Id: keyID,
+	})
+	if err != nil {
+		if e, ok := status.FromError(err); ok {
+			if e.Code() == codes.NotFound {
+				return nil, fmt.Errorf(FmtErrNotFound, err)
+			}
+		}
+		return nil, fmt.Errorf(FmtErrInternalServer, err)
+	}
+
+	respKey := response.GetKey()
+	return &models.SSHKey{
+		ID:          respKey.GetId(),
+		PrivateKey:  respKey.GetPrivateKey(),
+		PublicKey:   respKey.GetPublicKey(),
+		Comment:     respKey.GetComment(),
+		Fingerprint: respKey.GetFingerprint(),
+		Note:        respKey.GetNote(),
+		Tags:        respKey.GetTags(),
+		Fields:      respKey.GetFields(),
+		Vault:       respKey.GetVault(),
+		Folder:      respKey.GetFolder(),
+		Version:     respKey.GetVersion(),
+	}, nil
+}
+
+func (g *GRPCSender) UpdateSSHKey(key *models.SSHKey) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	response, err := g.client.UpdateSSHKey(ctx, &proto2.UpdateSSHKeyRequest{
+		Key: &proto2.SSHKey{
+			Id:         key.ID,
+			PrivateKey: key.PrivateKey,
+			PublicKey:  key.PublicKey,
+			Comment:    key.Comment,
+			Note:       key.Note,
+			Tags:       key.Tags,
+			Fields:     key.Fields,
+			Version:    key.Version,
+		},
+	})
+	if err != nil {
+		return updateError(err)
+	}
+	key.Version = response.GetVersion()
+	return nil
+}
+
+func (g *GRPCSender) DelSSHKey(keyID string) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	_, err = g.client.DeleteSSHKey(ctx, &proto2.DeleteSSHKeyRequest{
+		Id: keyID,
+	})
+	if err != nil {
+		return deleteError(err)
+	}
+	return nil
+}
+
+func (g *GRPCSender) RecordTypes() ([]models.RecordType, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return nil, err
+	}
+	response, err := g.client.RecordTypes(ctx, &proto2.RecordTypesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrInternalServer, err)
+	}
+	recordTypes := make([]models.RecordType, 0, len(response.GetTypes()))
+	for _, recordType := range response.GetTypes() {
+		recordTypes = append(recordTypes, recordTypeModel(recordType))
+	}
+	return recordTypes, nil
+}
+
+func (g *GRPCSender) RecordType(name string) (*models.RecordType, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return nil, err
+	}
+	response, err := g.client.RecordType(ctx, &proto2.RecordTypeRequest{Name: name})
+	if err != nil {
+		return nil, recordTypeError(err)
+	}
+	recordType := recordTypeModel(response.GetType())
+	return &recordType, nil
+}
+
+func (g *GRPCSender) AddRecordType(recordType models.RecordType) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	_, err = g.client.AddRecordType(ctx, &proto2.AddRecordTypeRequest{Type: recordTypeProto(recordType)})
+	if err != nil {
+		return recordTypeError(err)
+	}
+	return nil
+}
+
+func (g *GRPCSender) UpdateRecordType(recordType models.RecordType) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	_, err = g.client.UpdateRecordType(ctx, &proto2.UpdateRecordTypeRequest{Type: recordTypeProto(recordType)})
+	if err != nil {
+		return recordTypeError(err)
+	}
+	return nil
+}
+
+func (g *GRPCSender) DeleteRecordType(name string) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	_, err = g.client.DeleteRecordType(ctx, &proto2.DeleteRecordTypeRequest{Name: name})
+	if err != nil {
+		return recordTypeError(err)
+	}
+	return nil
+}
+
+func (g *GRPCSender) AddCustomRecord(record *models.CustomRecord) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+
+	_, err = g.client.AddCustomRecord(ctx, &proto2.AddCustomRecordRequest{
+		Record: &proto2.CustomRecord{
+			Id:      record.ID,
+			Type:    record.Type,
+			Values:  record.Values,
+			Secrets: record.Secrets,
+			Note:    record.Note,
+			Tags:    record.Tags,
+			Fields:  record.Fields,
+			Vault:   record.Vault,
+			Folder:  record.Folder,
+		},
+	})
+	if err != nil {
+		if e, ok := status.FromError(err); ok {
+			if e.Code() == codes.AlreadyExists {
+				return fmt.Errorf(FmtErrAlreadyExists, err)
+			}
+			if err := invalidValue(e.Message()); e.Code() == codes.InvalidArgument && err != nil {
+				return err
+			}
+			if e.Code() == codes.InvalidArgument {
+				return ErrInvalidLocation
+			}
+		}
+		return fmt.Errorf(FmtErrInternalServer, err)
+	}
+	return nil
+}
+
+func (g *GRPCSender) CustomRecord(recordID string) (*models.CustomRecord, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := g.client.CustomRecord(ctx, &proto2.CustomRecordRequest{
+		Id: recordID,
+	})
+	if err != nil {
+		if e, ok := status.FromError(err); ok {
+			if e.Code() == codes.NotFound {
+				return nil, fmt.Errorf(FmtErrNotFound, err)
+			}
+		}
+		return nil, fmt.Errorf(FmtErrInternalServer, err)
+	}
+
+	respRecord := response.GetRecord()
+	return &models.CustomRecord{
+		ID:      respRecord.GetId(),
+		Type:    respRecord.GetType(),
+		Values:  respRecord.GetValues(),
+		Secrets: respRecord.GetSecrets(),
+		Note:    respRecord.GetNote(),
+		Tags:    respRecord.GetTags(),
+		Fields:  respRecord.GetFields(),
+		Vault:   respRecord.GetVault(),
+		Folder:  respRecord.GetFolder(),
+		Version: respRecord.GetVersion(),
+	}, nil
+}
+
+func (g *GRPCSender) UpdateCustomRecord(record *models.CustomRecord) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	response, err := g.client.UpdateCustomRecord(ctx, &proto2.UpdateCustomRecordRequest{
+		Record: &proto2.CustomRecord{
+			Id:      record.ID,
+			Type:    record.Type,
+			Values:  record.Values,
+			Secrets: record.Secrets,
+			Note:    record.Note,
+			Tags:    record.Tags,
+			Fields:  record.Fields,
+			Version: record.Version,
+		},
+	})
+	if err != nil {
+		return updateError(err)
+	}
+	record.Version = response.GetVersion()
+	return nil
+}
+
+func (g *GRPCSender) DelCustomRecord(recordID string) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	_, err = g.client.DeleteCustomRecord(ctx, &proto2.DeleteCustomRecordRequest{
+		Id: recordID,
+	})
+	if err != nil {
+		return deleteError(err)
+	}
+	return nil
+}
+
+// recordTypeModel the record type of the response
+func recordTypeModel(recordType *proto2.RecordType) models.RecordType {
+	result := models.RecordType{Name: recordType.GetName()}
+	for _, field := range recordType.GetFields() {
+		result.Fields = append(result.Fields, models.TypeField{
+			Name:     field.GetName(),
+			Type:     models.FieldType(field.GetType()),
+			Secret:   field.GetSecret(),
+			Required: field.GetRequired(),
+		})
+	}
+	return result
+}
+
+// recordTypeProto the record type of the request
+func recordTypeProto(recordType models.RecordType) *proto2.RecordType {
+	result := &proto2.RecordType{Name: recordType.Name}
+	for _, field := range recordType.Fields {
+		result.Fields = append(result.Fields, &proto2.TypeField{
+			Name:     field.Name,
+			Type:     string(field.Type),
+			Secret:   field.Secret,
+			Required: field.Required,
+		})
+	}
+	return result
+}
+
+// recordTypeError converts the grpc status of the change of the record type, the type with records
+// can not be deleted and the invalid schema is rejected
+func recordTypeError(err error) error {
+	switch status.Code(err) {
+	case codes.FailedPrecondition:
+		return ErrRecordTypeInUse
+	case codes.InvalidArgument:
+		if e, _ := status.FromError(err); invalidValue(e.Message()) != nil {
+			return invalidValue(e.Message())
+		}
+	}
+	return folderError(err)
+}
+
+func (g *GRPCSender) Trash() ([]models.TrashItem, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return nil, err
+	}
+	response, err := g.client.Trash(ctx, &proto2.TrashRequest{})
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrInternalServer, err)
+	}
+	items := make([]models.TrashItem, 0, len(response.GetItems()))
+	for _, item := range response.GetItems() {
+		items = append(items, models.TrashItem{
+			Kind:      models.Kind(item.GetKind()),
+			ID:        item.GetId(),
+			Note:      item.GetNote(),
+			DeletedAt: time.Unix(item.GetDeletedAt(), 0),
+		})
+	}
+	return items, nil
+}
+
+func (g *GRPCSender) RestoreTrash(kind models.Kind, id string) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	_, err = g.client.RestoreTrash(ctx, &proto2.RestoreTrashRequest{Kind: string(kind), Id: id})
+	if err != nil {
+		return deleteError(err)
+	}
+	return nil
+}
+
+func (g *GRPCSender) EmptyTrash() (int64, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return 0, err
+	}
+	response, err := g.client.EmptyTrash(ctx, &proto2.EmptyTrashRequest{})
+	if err != nil {
+		return 0, fmt.Errorf(FmtErrInternalServer, err)
+	}
+	return response.GetRemoved(), nil
+}
+
+func (g *GRPCSender) Vaults() ([]models.Vault, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return nil, err
+	}
+	response, err := g.client.Vaults(ctx, &proto2.VaultsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrInternalServer, err)
+	}
+	vaults := make([]models.Vault, 0, len(response.GetVaults()))
+	for _, vault := range response.GetVaults() {
+		vaults = append(vaults, models.Vault{ID: vault.GetId(), Name: vault.GetName()})
+	}
+	return vaults, nil
+}
+
+func (g *GRPCSender) AddVault(name string) (int64, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return 0, err
+	}
+	response, err := g.client.AddVault(ctx, &proto2.AddVaultRequest{Name: name})
+	if err != nil {
+		return 0, folderError(err)
+	}
+	return response.GetId(), nil
+}
+
+func (g *GRPCSender) RenameVault(vaultID int64, name string) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	_, err = g.client.RenameVault(ctx, &proto2.RenameVaultRequest{Id: vaultID, Name: name})
+	if err != nil {
+		return folderError(err)
+	}
+	return nil
+}
+
+func (g *GRPCSender) DeleteVault(vaultID int64) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	_, err = g.client.DeleteVault(ctx, &proto2.DeleteVaultRequest{Id: vaultID})
+	if err != nil {
+		return folderError(err)
+	}
+	return nil
+}
+
+func (g *GRPCSender) Folders(vaultID int64) ([]models.Folder, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return nil, err
+	}
+	response, err := g.client.Folders(ctx, &proto2.FoldersRequest{Vault: vaultID})
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrInternalServer, err)
+	}
+	folders := make([]models.Folder, 0, len(response.GetFolders()))
+	for _, folder := range response.GetFolders() {
+		folders = append(folders, models.Folder{
+			ID:     folder.GetId(),
+			Vault:  folder.GetVault(),
+			Parent: folder.GetParent(),
+			Name:   folder.GetName(),
+		})
+	}
+	return folders, nil
+}
+
+func (g *GRPCSender) AddFolder(folder models.Folder) (int64, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return 0, err
+	}
+	response, err := g.client.AddFolder(ctx, &proto2.AddFolderRequest{
+		Folder: &proto2.Folder{Vault: folder.Vault, Parent: folder.Parent, Name: folder.Name},
+	})
+	if err != nil {
+		return 0, folderError(err)
+	}
+	return response.GetId(), nil
+}
+
+func (g *GRPCSender) UpdateFolder(folder models.Folder) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	_, err = g.client.UpdateFolder(ctx, &proto2.UpdateFolderRequest{
+		Folder: &proto2.Folder{Id: folder.ID, Parent: folder.Parent, Name: folder.Name},
+	})
+	if err != nil {
+		return folderError(err)
+	}
+	return nil
+}
+
+func (g *GRPCSender) DeleteFolder(folderID int64) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	_, err = g.client.DeleteFolder(ctx, &proto2.DeleteFolderRequest{Id: folderID})
+	if err != nil {
+		return folderError(err)
+	}
+	return nil
+}
+
+func (g *GRPCSender) MoveRecord(kind models.Kind, id string, location models.Location) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	_, err = g.client.MoveRecord(ctx, &proto2.MoveRecordRequest{
+		Kind:   string(kind),
+		Id:     id,
+		Vault:  location.Vault,
+		Folder: location.Folder,
+	})
+	if err != nil {
+		return folderError(err)
+	}
+	return nil
+}
+
+// folderError converts the grpc status of the change of the vaults, the folders or the location of the record
+func folderError(err error) error {
+	switch status.Code(err) {
+	case codes.NotFound:
+		return fmt.Errorf(FmtErrNotFound, err)
+	case codes.AlreadyExists:
+		return ErrNameTaken
+	case codes.InvalidArgument:
+		if status.Convert(err).Message() == ErrInvalidLocation.Error() {
+			return ErrInvalidLocation
+		}
+		return ErrEmptyName
+	}
+	return fmt.Errorf(FmtErrInternalServer, err)
+}
+
+// deleteError converts the error of the deletion or of the restore from the trash
+func deleteError(err error) error {
+	if e, ok := status.FromError(err); ok {
+		if e.Code() == codes.NotFound {
+			return fmt.Errorf(FmtErrNotFound, err)
+		}
+	}
+	return fmt.Errorf(FmtErrInternalServer, err)
+}
+
+func (g *GRPCSender) Revisions(kind models.Kind, id string) ([]models.Revision, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return nil, err
+	}
+	response, err := g.client.Revisions(ctx, &proto2.RevisionsRequest{Kind: string(kind), Id: id})
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrInternalServer, err)
+	}
+	revisions := make([]models.Revision, 0, len(response.GetRevisions()))
+	for _, revision := range response.GetRevisions() {
+		revisions = append(revisions, revisionModel(revision))
+	}
+	return revisions, nil
+}
+
+func (g *GRPCSender) Revision(kind models.Kind, id string, revisionID int64) (*models.Revision, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return nil, err
+	}
+	response, err := g.client.Revision(ctx, &proto2.RevisionRequest{Kind: string(kind), Id: id, Revision: revisionID})
+	if err != nil {
+		if e, ok := status.FromError(err); ok {
+			if e.Code() == codes.NotFound {
+				return nil, fmt.Errorf(FmtErrNotFound, err)
+			}
+		}
+		return nil, fmt.Errorf(FmtErrInternalServer, err)
+	}
+	revision := revisionModel(response.GetRevision())
+	return &revision, nil
+}
+
+func (g *GRPCSender) RestoreRevision(kind models.Kind, id string, revisionID int64) (int64, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return 0, err
+	}
+	response, err := g.client.RestoreRevision(ctx, &proto2.RestoreRevisionRequest{Kind: string(kind), Id: id, Revision: revisionID})
+	if err != nil {
+		return 0, updateError(err)
+	}
+	return response.GetVersion(), nil
+}
+
+// revisionModel converts the revision of the response
+func revisionModel(revision *proto2.Revision) models.Revision {
+	return models.Revision{
+		ID:        revision.GetId(),
+		Kind:      models.Kind(revision.GetKind()),
+		RecordID:  revision.GetRecordId(),
+		Version:   revision.GetVersion(),
+		Action:    revision.GetAction(),
+		CreatedAt: time.Unix(revision.GetCreatedAt(), 0),
+		Fields:    revision.GetFields(),
+	}
+}
+
+func (g *GRPCSender) Vault() (*models.VaultParams, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return nil, err
+	}
+	response, err := g.client.Vault(ctx, &proto2.VaultRequest{})
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrInternalServer, err)
+	}
+	return &models.VaultParams{
+		Salt:  response.GetSalt(),
+		Check: response.GetCheck(),
+	}, nil
+}
+
+func (g *GRPCSender) SetVaultCheck(check string) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	_, err = g.client.SetVaultCheck(ctx, &proto2.SetVaultCheckRequest{
+		Check: check,
+	})
+	if err != nil {
+		if e, ok := status.FromError(err); ok {
+			if e.Code() == codes.AlreadyExists {
+				return fmt.Errorf(FmtErrAlreadyExists, err)
+			}
+		}
+		return fmt.Errorf(FmtErrInternalServer, err)
+	}
+	return nil
+}
+
+func (g *GRPCSender) Logout() error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	_, err = g.client.Logout(ctx, &proto2.LogoutRequest{})
+	// The tokens are forgotten even if the server is unavailable
+	g.authToken = nil
+	g.refreshToken = ""
+	if err != nil {
+		return fmt.Errorf(FmtErrInternalServer, err)
+	}
+	return nil
+}
+
+func (g *GRPCSender) Sessions() ([]models.Session, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return nil, err
+	}
+	response, err := g.client.Sessions(ctx, &proto2.SessionsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrInternalServer, err)
+	}
+
+	sessions := make([]models.Session, 0, len(response.GetSessions()))
+	for _, session := range response.GetSessions() {
+		sessions = append(sessions, models.Session{
+			ID:          session.GetId(),
+			UserAgent:   session.GetUserAgent(),
+			CreatedAt:   time.Unix(session.GetCreatedAt(), 0),
+			RefreshedAt: time.Unix(session.GetRefreshedAt(), 0),
+			ExpiresAt:   time.Unix(session.GetExpiresAt(), 0),
+			Current:     session.GetCurrent(),
+		})
+	}
+	return sessions, nil
+}
+
+func (g *GRPCSender) RevokeSession(sessionID string) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	_, err = g.client.RevokeSession(ctx, &proto2.RevokeSessionRequest{
+		Id: sessionID,
+	})
+	if err != nil {
+		if e, ok := status.FromError(err); ok {
+			if e.Code() == codes.NotFound {
+				return fmt.Errorf(FmtErrNotFound, err)
+			}
+		}
+		return fmt.Errorf(FmtErrInternalServer, err)
+	}
+	return nil
+}
+
+func (g *GRPCSender) SetupTOTP() (*models.TOTPSetup, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return nil, err
+	}
+	response, err := g.client.SetupTOTP(ctx, &proto2.SetupTOTPRequest{})
+	if err != nil {
+		return nil, twoFactorError(err)
+	}
+	return &models.TOTPSetup{
+		Secret: response.GetSecret(),
+		URI:    response.GetUri(),
+	}, nil
+}
+
+func (g *GRPCSender) ConfirmTOTP(code string) ([]string, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return nil, err
+	}
+	response, err := g.client.ConfirmTOTP(ctx, &proto2.ConfirmTOTPRequest{
+		Code: code,
+	})
+	if err != nil {
+		return nil, twoFactorError(err)
+	}
+	return response.GetRecoveryCodes(), nil
+}
+
+func (g *GRPCSender) DisableTOTP(code string) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	_, err = g.client.DisableTOTP(ctx, &proto2.DisableTOTPRequest{
+		Code: code,
+	})
+	if err != nil {
+		return twoFactorError(err)
+	}
+	return nil
+}
+
+func (g *GRPCSender) ChangePassword(current string, newPassword string) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	var header metadata.MD
+	_, err = g.client.ChangePassword(ctx, &proto2.ChangePasswordRequest{
+		Password:    current,
+		NewPassword: newPassword,
+	}, grpc.Header(&header))
+	if err != nil {
+		return accountError(err, header)
+	}
+	return nil
+}
+
+func (g *GRPCSender) ChangeLogin(pwd string, newLogin string) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	var header metadata.MD
+	_, err = g.client.ChangeLogin(ctx, &proto2.ChangeLoginRequest{
+		Password: pwd,
+		NewLogin: newLogin,
+	}, grpc.Header(&header))
+	if err != nil {
+		return accountError(err, header)
+	}
+	return nil
+}
+
+func (g *GRPCSender) DeleteAccount(pwd string) error {
+	ctx, err := g.authContext()
+	if err != nil {
+		return err
+	}
+	var header metadata.MD
+	_, err = g.client.DeleteAccount(ctx, &proto2.DeleteAccountRequest{
+		Password: pwd,
+	}, grpc.Header(&header))
+	if err != nil {
+		return accountError(err, header)
+	}
+	// The sessions of the deleted user are gone
+	g.authToken = nil
+	g.refreshToken = ""
+	return nil
+}
+
+func (g *GRPCSender) Lockouts() ([]models.Lockout, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return nil, err
+	}
+	response, err := g.client.Lockouts(ctx, &proto2.LockoutsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrInternalServer, err)
+	}
+	lockouts := make([]models.Lockout, 0, len(response.GetLockouts()))
+	for _, lockout := range response.GetLockouts() {
+		lockouts = append(lockouts, models.Lockout{
+			IP:          lockout.GetIp(),
+			Failures:    int(lockout.GetFailures()),
+			CreatedAt:   time.Unix(lockout.GetCreatedAt(), 0),
+			LockedUntil: time.Unix(lockout.GetLockedUntil(), 0),
+		})
+	}
+	return lockouts, nil
+}
+
+func (g *GRPCSender) AuditEvents(before int64, limit int) (*models.AuditPage, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return nil, err
+	}
+	response, err := g.client.AuditEvents(ctx, &proto2.AuditRequest{Before: before, Limit: int32(limit)})
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrInternalServer, err)
+	}
+	page := models.AuditPage{
+		Events: make([]models.AuditEvent, 0, len(response.GetEvents())),
+		Next:   response.GetNext(),
+	}
+	for _, event := range response.GetEvents() {
+		page.Events = append(page.Events, models.AuditEvent{
+			ID:        event.GetId(),
+			Actor:     event.GetActor(),
+			Action:    event.GetAction(),
+			Kind:      models.Kind(event.GetKind()),
+			RecordID:  event.GetRecordId(),
+			Transport: event.GetTransport(),
+			IP:        event.GetIp(),
+			CreatedAt: time.Unix(event.GetCreatedAt(), 0),
+		})
+	}
+	return &page, nil
+}
+
+func (g *GRPCSender) Cards(opts models.ListOptions) (*models.RecordPage, error) {
+	return g.list(func(ctx context.Context, req *proto2.ListRequest) (recordClient, error) {
+		return g.client.ListCards(ctx, req)
+	}, opts)
+}
+
+func (g *GRPCSender) Logins(opts models.ListOptions) (*models.RecordPage, error) {
+	return g.list(func(ctx context.Context, req *proto2.ListRequest) (recordClient, error) {
+		return g.client.ListLogins(ctx, req)
+	}, opts)
+}
+
+func (g *GRPCSender) Texts(opts models.ListOptions) (*models.RecordPage, error) {
+	return g.list(func(ctx context.Context, req *proto2.ListRequest) (recordClient, error) {
+		return g.client.ListTexts(ctx, req)
+	}, opts)
+}
+
+func (g *GRPCSender) Bins(opts models.ListOptions) (*models.RecordPage, error) {
+	return g.list(func(ctx context.Context, req *proto2.ListRequest) (recordClient, error) {
+		return g.client.ListBinaries(ctx, req)
+	}, opts)
+}
+
+func (g *GRPCSender) SSHKeys(opts models.ListOptions) (*models.RecordPage, error) {
+	return g.list(func(ctx context.Context, req *proto2.ListRequest) (recordClient, error) {
+		return g.client.ListSSHKeys(ctx, req)
+	}, opts)
+}
+
+func (g *GRPCSender) CustomRecords(opts models.ListOptions) (*models.RecordPage, error) {
+	return g.list(func(ctx context.Context, req *proto2.ListRequest) (recordClient, error) {
+		return g.client.ListCustomRecords(ctx, req)
+	}, opts)
+}
+
+// recordClient the client side of any of the record list streams
+type recordClient interface {
+	Recv() (*proto2.RecordInfo, error)
+}
+
+// list reads the page of the records from the stream. If the token is expired, a new one is received
+// by the refresh token and the page is requested again
+func (g *GRPCSender) list(call func(ctx context.Context, req *proto2.ListRequest) (recordClient, error),
+	opts models.ListOptions) (*models.RecordPage, error) {
+	ctx, err := g.authContext()
+	if err != nil {
+		return nil, err
+	}
+	req := &proto2.ListRequest{
+		After:  opts.After,
+		Limit:  int32(opts.Limit),
+		Sort:   opts.Sort,
+		Desc:   opts.Desc,
+		Tag:    opts.Tag,
+		Field:  opts.Field,
+		Value:  opts.Value,
+		Vault:  opts.Vault,
+		Folder: opts.Folder,
+	}
+
+	page, err := readRecords(ctx, call, req)
+	if status.Code(err) == codes.Unauthenticated && g.refreshToken != "" {
+		if err := g.refresh(ctx); err != nil {
+			return nil, err
+		}
+		page, err = readRecords(ctx, call, req)
+	}
+	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			return nil, ErrInvalidQuery
+		}
+		return nil, fmt.Errorf(FmtErrInternalServer, err)
+	}
+	return page, nil
+}
+
+// readRecords reads all the messages of the record list stream
+func readRecords(ctx context.Context, call func(ctx context.Context, req *proto2.ListRequest) (recordClient, error),
+	req *proto2.ListRequest) (*models.RecordPage, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := call(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	page := models.RecordPage{Records: make([]models.RecordInfo, 0)}
+	for {
+		record, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return &page, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		page.Records = append(page.Records, models.RecordInfo{ID: record.GetId(), Note: record.GetNote(),
+			Tags: record.GetTags(), Fields: record.GetFields()})
+		page.Next = record.GetNext()
+	}
+}
+
+// updateError converts the grpc status of the record update to the client errors
+func updateError(err error) error {
+	switch status.Code(err) {
+	case codes.NotFound:
+		return fmt.Errorf(FmtErrNotFound, err)
+	case codes.Aborted:
+		return ErrVersionConflict
+	case codes.FailedPrecondition:
+		return ErrContentRemoved
+	case codes.InvalidArgument:
+		if e, _ := status.FromError(err); invalidValue(e.Message()) != nil {
+			return invalidValue(e.Message())
+		}
+	}
+	return fmt.Errorf(FmtErrInternalServer, err)
+}
+
+// retryAfter returns the delay of the sign-in from the response header of the server
+func retryAfter(header metadata.MD) string {
+	if values := header.Get("retry-after"); len(values) > 0 {
+		return values[0] + "s"
+	}
+	return "a while"
+}
+
+// accountError converts the grpc status of the account management requests to the client errors
+func accountError(err error, header metadata.MD) error {
+	if e, ok := status.FromError(err); ok {
+		switch e.Code() {
+		case codes.InvalidArgument:
+			return ErrEmptyValue
+		case codes.PermissionDenied:
+			if e.Message() == "invalid password" {
+				return ErrInvalidPassword
+			}
+			return ErrCertNotBound
+		case codes.AlreadyExists:
+			return fmt.Errorf(FmtErrUserAlreadyExists, err)
+		case codes.ResourceExhausted:
+			return fmt.Errorf(FmtErrTooManyAttempts, ErrTooManyAttempts, retryAfter(header))
+		}
+	}
+	return fmt.Errorf(FmtErrInternalServer, err)
+}
+
+// twoFactorError converts the grpc status of the second factor requests to the client errors
+func twoFactorError(err error) error {
+	if e, ok := status.FromError(err); ok {
+		switch e.Code() {
+		case codes.PermissionDenied:
+			return ErrInvalidOTP
+		case codes.AlreadyExists, codes.FailedPrecondition:
+			return ErrTwoFactorState
+		}
+	}
+	return fmt.Errorf(FmtErrInternalServer, err)
+}
+
+// authContext checks that the client is signed in. The token is added to the metadata by authInterceptor
+func (g *GRPCSender) authContext() (context.Context, error) {
+	if g.authToken == nil {
+		return nil, ErrAuthRequire
+	}
+	return context.Background(), nil
+}
+
+// authInterceptor adds the token to the outgoing metadata, the server interceptor takes the user from it.
+// If the token is expired, a new one is received by the refresh token and the call is repeated
+func (g *GRPCSender) authInterceptor(ctx context.Context, method string, req, reply interface{},
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if publicMethods[method] || g.authToken == nil {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	err := invoker(metadata.AppendToOutgoingContext(ctx, "authorization", *g.authToken), method, req, reply, cc, opts...)
+	if status.Code(err) != codes.Unauthenticated || g.refreshToken == "" {
+		return err
+	}
+	if err := g.refresh(ctx); err != nil {
+		return err
+	}
+	return invoker(metadata.AppendToOutgoingContext(ctx, "authorization", *g.authToken), method, req, reply, cc, opts...)
+}
+
+// authStreamInterceptor adds the token to the outgoing metadata of the streams. The error of the expired token
+// comes only with the first message of the stream, so it is handled by the caller, see list
+func (g *GRPCSender) authStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+	method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	if g.authToken != nil {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", *g.authToken)
+	}
+	return streamer(ctx, desc, cc, method, opts...)
+}
+
+// refresh receives a new pair of tokens. If the session is expired or revoked, the tokens are forgotten
+func (g *GRPCSender) refresh(ctx context.Context) error {
+	response, err := g.client.Refresh(ctx, &proto2.RefreshRequest{
+		RefreshToken: g.refreshToken,
+	})
+	if err != nil {
+		if status.Code(err) == codes.Unauthenticated {
+			g.authToken = nil
+			g.refreshToken = ""
+			return ErrSessionExpired
+		}
+		return fmt.Errorf(FmtErrInternalServer, err)
+	}
+	g.saveTokens(response)
+	return nil
+}
+
+// saveTokens remembers the tokens of the session
+func (g *GRPCSender) saveTokens(response *proto2.RegisterResponse) {
+	authToken := response.GetToken()
+	g.authToken = &authToken
+	g.refreshToken = response.GetRefreshToken()
+}
@@ -2,16 +2,18 @@ package api
 
 import (
 	"bytes"
-	"crypto/tls"
-	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
-	"os"
+	"net/url"
+	"strconv"
 
 	"github.com/ncyellow/GophKeeper/internal/client/config"
 	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/tlsconfig"
 )
 
 // HTTPSender structure of the http client. Implements the Sender interface. See the respective methods for all comments.
@@ -19,32 +21,19 @@ type HTTPSender struct {
 	Client    *http.Client
 	Conf      *config.Config
 	AuthToken *string
+	// RefreshToken is used to get a new AuthToken when it expires
+	RefreshToken string
 }
 
 // NewHTTPSender constructor for the http client
 func NewHTTPSender(conf *config.Config) (*HTTPSender, error) {
-	clientCertFile := conf.CryptoCrt
-	clientKeyFile := conf.CryptoKey
-	caCertFile := conf.CACertFile
-
-	cert, err := tls.LoadX509KeyPair(clientCertFile, clientKeyFile)
-	if err != nil {
-		return nil, err
-	}
-
-	caCert, err := os.ReadFile(caCertFile)
+	tlsConf, err := tlsconfig.Client(conf.CryptoCrt, conf.CryptoKey, conf.CACertFile)
 	if err != nil {
 		return nil, err
 	}
 
-	caCertPool := x509.NewCertPool()
-	caCertPool.AppendCertsFromPEM(caCert)
-
 	t := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			Certificates: []tls.Certificate{cert},
-			RootCAs:      caCertPool,
-		},
+		TLSClientConfig: tlsConf,
 	}
 
 	return &HTTPSender{
@@ -81,19 +70,21 @@ func (s *HTTPSender) Register(login string, pwd string) error {
 
 	if resp.StatusCode == http.StatusConflict {
 		return ErrUserAlreadyExists
+	} else if resp.StatusCode == http.StatusForbidden {
+		return ErrCertNotBound
 	} else if resp.StatusCode != http.StatusOK {
 		return ErrInternalServer
 	}
 
-	authToken := resp.Header.Get("Authorization")
-	s.AuthToken = &authToken
+	s.saveTokens(resp)
 	return nil
 }
 
-func (s *HTTPSender) SignIn(login string, pwd string) error {
+func (s *HTTPSender) SignIn(login string, pwd string, otp string) error {
 	user := models.User{
 		Login:    login,
 		Password: pwd,
+		OTP:      otp,
 	}
 
 	result, ok := json.Marshal(user)
@@ -113,12 +104,17 @@ func (s *HTTPSender) SignIn(login string, pwd string) error {
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode == http.StatusPreconditionRequired {
+		return ErrOTPRequired
+	} else if resp.StatusCode == http.StatusForbidden {
+		return ErrCertNotBound
+	} else if resp.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf(FmtErrTooManyAttempts, ErrTooManyAttempts, resp.Header.Get("Retry-After")+"s")
+	} else if resp.StatusCode != http.StatusOK {
 		return ErrUserNotFound
 	}
 
-	authToken := resp.Header.Get("Authorization")
-	s.AuthToken = &authToken
+	s.saveTokens(resp)
 	return nil
 }
 
@@ -145,6 +141,19 @@ func (s *HTTPSender) Card(cardID string) (*models.Card, error) {
 	return &card, nil
 }
 
+func (s *HTTPSender) UpdateCard(card *models.Card) error {
+	data, err := json.Marshal(card)
+	if err != nil {
+		return ErrSerialization
+	}
+	version, err := s.update("PUT", data, fmt.Sprintf("/api/card/%s", card.ID))
+	if err != nil {
+		return err
+	}
+	card.Version = version
+	return nil
+}
+
 func (s *HTTPSender) DelCard(cardID string) error {
 	return s.del(cardID, "api/card")
 }
@@ -172,6 +181,19 @@ func (s *HTTPSender) Login(loginID string) (*models.Login, error) {
 	return &login, nil
 }
 
+func (s *HTTPSender) UpdateLogin(login *models.Login) error {
+	data, err := json.Marshal(login)
+	if err != nil {
+		return ErrSerialization
+	}
+	version, err := s.update("PUT", data, fmt.Sprintf("/api/login/%s", login.ID))
+	if err != nil {
+		return err
+	}
+	login.Version = version
+	return nil
+}
+
 func (s *HTTPSender) DelLogin(loginID string) error {
 	return s.del(loginID, "api/login")
 }
@@ -181,7 +203,7 @@ func (s *HTTPSender) AddText(text *models.Text) error {
 	if ok != nil {
 		return ErrSerialization
 	}
-	return s.add(data, "api/txt")
+	return s.add(data, "/api/txt")
 }
 
 func (s *HTTPSender) Text(textID string) (*models.Text, error) {
@@ -199,6 +221,19 @@ func (s *HTTPSender) Text(textID string) (*models.Text, error) {
 	return &text, nil
 }
 
+func (s *HTTPSender) UpdateText(text *models.Text) error {
+	data, err := json.Marshal(text)
+	if err != nil {
+		return ErrSerialization
+	}
+	version, err := s.update("PUT", data, fmt.Sprintf("/api/txt/%s", text.ID))
+	if err != nil {
+		return err
+	}
+	text.Version = version
+	return nil
+}
+
 func (s *HTTPSender) DelText(textID string) error {
 	return s.del(textID, "api/txt")
 }
@@ -208,7 +243,7 @@ func (s *HTTPSender) AddBin(binary *models.Binary) error {
 	if ok != nil {
 		return ErrSerialization
 	}
-	return s.add(data, "api/bin")
+	return s.add(data, "/api/bin")
 }
 
 func (s *HTTPSender) Bin(binID string) (*models.Binary, error) {
@@ -226,92 +261,1060 @@ func (s *HTTPSender) Bin(binID string) (*models.Binary, error) {
 	return &binary, nil
 }
 
+func (s *HTTPSender) UpdateBin(binary *models.Binary) error {
+	data, err := json.Marshal(binary)
+	if err != nil {
+		return ErrSerialization
+	}
+	version, err := s.update("PUT", data, fmt.Sprintf("/api/bin/%s", binary.ID))
+	if err != nil {
+		return err
+	}
+	binary.Version = version
+	return nil
+}
+
 func (s *HTTPSender) DelBin(binID string) error {
 	return s.del(binID, "api/bin")
 }
 
-// add общий метод по добавлению на сервер. Содержит общую часть для любого типа данных
-func (s *HTTPSender) add(data []byte, urlSuffix string) error {
+func (s *HTTPSender) UploadBin(binary *models.Binary, content io.ReadSeeker) error {
+	return uploadContent(s, binary, content)
+}
+
+func (s *HTTPSender) DownloadBin(binary *models.Binary, w io.Writer) error {
+	return downloadContent(s, binary, w)
+}
+
+func (s *HTTPSender) startUpload(binID string) (*models.BinaryUpload, error) {
+	return s.upload("POST", fmt.Sprintf("/api/bin/%s/uploads", binID))
+}
+
+func (s *HTTPSender) uploadState(binID string, uploadID string) (*models.BinaryUpload, error) {
+	return s.upload("GET", fmt.Sprintf("/api/bin/%s/uploads/%s", binID, uploadID))
+}
+
+// sendChunks отправляет части одним multipart запросом, каждая часть пишется в тело по мере чтения,
+// поэтому в памяти только одна часть. Полученные сервером до обрыва соединения части сохраняются
+func (s *HTTPSender) sendChunks(upload *models.BinaryUpload, next func() ([]byte, error)) (*models.BinaryUpload, error) {
+	if s.AuthToken == nil {
+		return nil, ErrAuthRequire
+	}
+
+	body, pipe := io.Pipe()
+	form := multipart.NewWriter(pipe)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pipe.CloseWithError(writeChunks(form, next))
+	}()
+	// Части читаются из содержимого, пока запрос не завершен. Дожидаемся записи, чтобы содержимое
+	// не читалось одновременно с повтором загрузки
+	defer func() {
+		body.CloseWithError(errRequestDone)
+		<-done
+	}()
+
+	urlPath := fmt.Sprintf("%s/api/bin/%s/uploads/%s?seq=%d", s.Conf.Address, upload.BinaryID, upload.ID, upload.Chunks)
+	req, err := http.NewRequest("PUT", urlPath, body)
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrRequestPrepare, err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	// Тело потоковое и его не повторить после обновления токена, загрузка продолжится с состояния на сервере
+	req.GetBody = func() (io.ReadCloser, error) {
+		return nil, errRequestDone
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	case http.StatusConflict:
+		return nil, errChunkOrder
+	case http.StatusBadRequest:
+		return nil, badRequest(resp)
+	default:
+		return nil, ErrInternalServer
+	}
+
+	var state models.BinaryUpload
+	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
+		return nil, fmt.Errorf(FmtErrDeserialization, err)
+	}
+	return &state, nil
+}
+
+// writeChunks пишет части в multipart тело, каждая часть - отдельный файл chunk
+func writeChunks(form *multipart.Writer, next func() ([]byte, error)) error {
+	for {
+		data, err := next()
+		if errors.Is(err, io.EOF) {
+			return form.Close()
+		}
+		if err != nil {
+			return err
+		}
+		part, err := form.CreateFormFile("chunk", "chunk")
+		if err != nil {
+			return err
+		}
+		if _, err := part.Write(data); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *HTTPSender) completeUpload(binary *models.Binary, uploadID string) (int64, error) {
+	data, err := json.Marshal(models.RecordVersion{ID: binary.ID, Version: binary.Version})
+	if err != nil {
+		return 0, ErrSerialization
+	}
+	return s.update("POST", data, fmt.Sprintf("/api/bin/%s/uploads/%s/complete", binary.ID, uploadID))
+}
+
+// readContent пишет содержимое с offset. Продолжение загрузки запрашивается с If-Range, поэтому если содержимое
+// заменено, сервер отдает его целиком и загрузка не продолжается
+func (s *HTTPSender) readContent(binary *models.Binary, offset int64, w io.Writer) error {
 	if s.AuthToken == nil {
 		return ErrAuthRequire
 	}
 
-	req, err := http.NewRequest("POST", s.Conf.Address+urlSuffix, bytes.NewBuffer(data))
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/bin/%s/content", s.Conf.Address, binary.ID), nil)
 	if err != nil {
 		return fmt.Errorf(FmtErrRequestPrepare, err)
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", *s.AuthToken)
+	etag := strconv.Quote(binary.ContentID)
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", etag)
+	}
 
-	resp, err := s.Client.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
-		return fmt.Errorf(FmtErrServerTimout, err)
+		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusConflict {
-		return ErrAlreadyExists
-	} else if resp.StatusCode != http.StatusOK {
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode == http.StatusOK && offset == 0 && resp.Header.Get("ETag") == etag:
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+	case resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		return ErrContentReplaced
+	default:
 		return ErrInternalServer
 	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf(FmtErrServerTimout, err)
+	}
 	return nil
 }
 
-// read общий метод по чтение с сервера. Содержит общую часть для любого типа данных
-func (s *HTTPSender) read(textID string, urlSuffix string) ([]byte, error) {
-	if s.AuthToken == nil {
-		return nil, ErrAuthRequire
+func (s *HTTPSender) AddSSHKey(key *models.SSHKey) error {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return ErrSerialization
 	}
+	return s.add(data, "/api/ssh")
+}
 
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/%s/%s", s.Conf.Address, urlSuffix, textID), nil)
+func (s *HTTPSender) SSHKey(keyID string) (*models.SSHKey, error) {
+	data, err := s.read(keyID, "api/ssh")
 	if err != nil {
-		return nil, fmt.Errorf(FmtErrRequestPrepare, err)
+		return nil, err
 	}
-	req.Header.Set("Authorization", *s.AuthToken)
+	// разбираем сообщение
+	var key models.SSHKey
+	err = json.Unmarshal(data, &key)
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrDeserialization, err)
+	}
+	return &key, nil
+}
 
-	resp, err := s.Client.Do(req)
+func (s *HTTPSender) UpdateSSHKey(key *models.SSHKey) error {
+	data, err := json.Marshal(key)
 	if err != nil {
-		return nil, fmt.Errorf(FmtErrServerTimout, err)
+		return ErrSerialization
 	}
-	defer resp.Body.Close()
+	version, err := s.update("PUT", data, fmt.Sprintf("/api/ssh/%s", key.ID))
+	if err != nil {
+		return err
+	}
+	key.Version = version
+	return nil
+}
 
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, ErrNotFound
+func (s *HTTPSender) DelSSHKey(keyID string) error {
+	return s.del(keyID, "api/ssh")
+}
+
+func (s *HTTPSender) RecordTypes() ([]models.RecordType, error) {
+	data, err := s.get("api/types")
+	if err != nil {
+		return nil, err
+	}
+	// разбираем сообщение
+	var recordTypes []models.RecordType
+	err = json.Unmarshal(data, &recordTypes)
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrDeserialization, err)
 	}
+	return recordTypes, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, ErrInternalServer
+func (s *HTTPSender) RecordType(name string) (*models.RecordType, error) {
+	data, err := s.read(url.PathEscape(name), "api/types")
+	if err != nil {
+		return nil, err
+	}
+	// разбираем сообщение
+	var recordType models.RecordType
+	err = json.Unmarshal(data, &recordType)
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrDeserialization, err)
 	}
+	return &recordType, nil
+}
 
-	reqBody, err := io.ReadAll(resp.Body)
-	defer resp.Body.Close()
+func (s *HTTPSender) AddRecordType(recordType models.RecordType) error {
+	data, err := json.Marshal(recordType)
 	if err != nil {
-		return nil, fmt.Errorf(FmtErrSerialization, err)
+		return fmt.Errorf(FmtErrSerialization, err)
 	}
-	return reqBody, nil
+	_, err = s.folders("POST", data, "/api/types")
+	return err
 }
 
-// del общий метод по удалению с сервера. Содержит общую часть для любого типа данных
-func (s *HTTPSender) del(binID string, urlSuffix string) error {
-	if s.AuthToken == nil {
-		return ErrAuthRequire
+func (s *HTTPSender) UpdateRecordType(recordType models.RecordType) error {
+	data, err := json.Marshal(recordType)
+	if err != nil {
+		return fmt.Errorf(FmtErrSerialization, err)
 	}
+	_, err = s.folders("PUT", data, "/api/types/"+url.PathEscape(recordType.Name))
+	return err
+}
 
-	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/%s/%s", s.Conf.Address, urlSuffix, binID), nil)
+func (s *HTTPSender) DeleteRecordType(name string) error {
+	_, err := s.folders("DELETE", nil, "/api/types/"+url.PathEscape(name))
+	return err
+}
+
+func (s *HTTPSender) AddCustomRecord(record *models.CustomRecord) error {
+	data, err := json.Marshal(record)
 	if err != nil {
-		return fmt.Errorf(FmtErrRequestPrepare, err)
+		return ErrSerialization
 	}
+	return s.add(data, "/api/custom")
+}
 
-	req.Header.Set("Authorization", *s.AuthToken)
+func (s *HTTPSender) CustomRecord(recordID string) (*models.CustomRecord, error) {
+	data, err := s.read(recordID, "api/custom")
+	if err != nil {
+		return nil, err
+	}
+	// разбираем сообщение
+	var record models.CustomRecord
+	err = json.Unmarshal(data, &record)
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrDeserialization, err)
+	}
+	return &record, nil
+}
 
-	resp, err := s.Client.Do(req)
+func (s *HTTPSender) UpdateCustomRecord(record *models.CustomRecord) error {
+	data, err := json.Marshal(record)
 	if err != nil {
-		return fmt.Errorf(FmtErrServerTimout, err)
+		return ErrSerialization
+	}
+	version, err := s.update("PUT", data, fmt.Sprintf("/api/custom/%s", record.ID))
+	if err != nil {
+		return err
+	}
+	record.Version = version
+	return nil
+}
+
+func (s *HTTPSender) DelCustomRecord(recordID string) error {
+	return s.del(recordID, "api/custom")
+}
+
+func (s *HTTPSender) Trash() ([]models.TrashItem, error) {
+	data, err := s.get("api/trash")
+	if err != nil {
+		return nil, err
+	}
+	// разбираем сообщение
+	var items []models.TrashItem
+	err = json.Unmarshal(data, &items)
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrDeserialization, err)
+	}
+	return items, nil
+}
+
+func (s *HTTPSender) RestoreTrash(kind models.Kind, id string) error {
+	return s.send("POST", nil, fmt.Sprintf("/api/trash/%s/%s/restore", kind, url.PathEscape(id)))
+}
+
+func (s *HTTPSender) EmptyTrash() (int64, error) {
+	if s.AuthToken == nil {
+		return 0, ErrAuthRequire
+	}
+
+	req, err := http.NewRequest("DELETE", s.Conf.Address+"/api/trash", nil)
+	if err != nil {
+		return 0, fmt.Errorf(FmtErrRequestPrepare, err)
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return 0, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return ErrInternalServer
+		return 0, ErrInternalServer
 	}
-	return nil
+	var emptied models.TrashEmptied
+	if err := json.NewDecoder(resp.Body).Decode(&emptied); err != nil {
+		return 0, fmt.Errorf(FmtErrDeserialization, err)
+	}
+	return emptied.Removed, nil
+}
+
+func (s *HTTPSender) Vaults() ([]models.Vault, error) {
+	data, err := s.get("api/vaults")
+	if err != nil {
+		return nil, err
+	}
+	// разбираем сообщение
+	var vaults []models.Vault
+	err = json.Unmarshal(data, &vaults)
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrDeserialization, err)
+	}
+	return vaults, nil
+}
+
+func (s *HTTPSender) AddVault(name string) (int64, error) {
+	data, err := json.Marshal(models.Vault{Name: name})
+	if err != nil {
+		return 0, fmt.Errorf(FmtErrSerialization, err)
+	}
+	respBody, err := s.folders("POST", data, "/api/vaults")
+	if err != nil {
+		return 0, err
+	}
+	var vault models.Vault
+	if err := json.Unmarshal(respBody, &vault); err != nil {
+		return 0, fmt.Errorf(FmtErrDeserialization, err)
+	}
+	return vault.ID, nil
+}
+
+func (s *HTTPSender) RenameVault(vaultID int64, name string) error {
+	data, err := json.Marshal(models.Vault{Name: name})
+	if err != nil {
+		return fmt.Errorf(FmtErrSerialization, err)
+	}
+	_, err = s.folders("PUT", data, fmt.Sprintf("/api/vaults/%d", vaultID))
+	return err
+}
+
+func (s *HTTPSender) DeleteVault(vaultID int64) error {
+	_, err := s.folders("DELETE", nil, fmt.Sprintf("/api/vaults/%d", vaultID))
+	return err
+}
+
+func (s *HTTPSender) Folders(vaultID int64) ([]models.Folder, error) {
+	data, err := s.get(fmt.Sprintf("api/vaults/%d/folders", vaultID))
+	if err != nil {
+		return nil, err
+	}
+	// разбираем сообщение
+	var folders []models.Folder
+	err = json.Unmarshal(data, &folders)
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrDeserialization, err)
+	}
+	return folders, nil
+}
+
+func (s *HTTPSender) AddFolder(folder models.Folder) (int64, error) {
+	data, err := json.Marshal(folder)
+	if err != nil {
+		return 0, fmt.Errorf(FmtErrSerialization, err)
+	}
+	respBody, err := s.folders("POST", data, fmt.Sprintf("/api/vaults/%d/folders", folder.Vault))
+	if err != nil {
+		return 0, err
+	}
+	var added models.Folder
+	if err := json.Unmarshal(respBody, &added); err != nil {
+		return 0, fmt.Errorf(FmtErrDeserialization, err)
+	}
+	return added.ID, nil
+}
+
+func (s *HTTPSender) UpdateFolder(folder models.Folder) error {
+	data, err := json.Marshal(folder)
+	if err != nil {
+		return fmt.Errorf(FmtErrSerialization, err)
+	}
+	_, err = s.folders("PUT", data, fmt.Sprintf("/api/folders/%d", folder.ID))
+	return err
+}
+
+func (s *HTTPSender) DeleteFolder(folderID int64) error {
+	_, err := s.folders("DELETE", nil, fmt.Sprintf("/api/folders/%d", folderID))
+	return err
+}
+
+func (s *HTTPSender) MoveRecord(kind models.Kind, id string, location models.Location) error {
+	data, err := json.Marshal(location)
+	if err != nil {
+		return fmt.Errorf(FmtErrSerialization, err)
+	}
+	_, err = s.folders("PUT", data, fmt.Sprintf("/api/%s/%s/location", kindPath(kind), url.PathEscape(id)))
+	return err
+}
+
+func (s *HTTPSender) Revisions(kind models.Kind, id string) ([]models.Revision, error) {
+	data, err := s.get(fmt.Sprintf("api/%s/%s/revisions", kindPath(kind), url.PathEscape(id)))
+	if err != nil {
+		return nil, err
+	}
+	// разбираем сообщение
+	var revisions []models.Revision
+	err = json.Unmarshal(data, &revisions)
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrDeserialization, err)
+	}
+	return revisions, nil
+}
+
+func (s *HTTPSender) Revision(kind models.Kind, id string, revisionID int64) (*models.Revision, error) {
+	data, err := s.get(fmt.Sprintf("api/%s/%s/revisions/%d", kindPath(kind), url.PathEscape(id), revisionID))
+	if err != nil {
+		return nil, err
+	}
+	// разбираем сообщение
+	var revision models.Revision
+	err = json.Unmarshal(data, &revision)
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrDeserialization, err)
+	}
+	return &revision, nil
+}
+
+func (s *HTTPSender) RestoreRevision(kind models.Kind, id string, revisionID int64) (int64, error) {
+	return s.update("POST", nil, fmt.Sprintf("/api/%s/%s/revisions/%d/restore", kindPath(kind), url.PathEscape(id), revisionID))
+}
+
+func (s *HTTPSender) Vault() (*models.VaultParams, error) {
+	data, err := s.get("api/vault")
+	if err != nil {
+		return nil, err
+	}
+	// разбираем сообщение
+	var params models.VaultParams
+	err = json.Unmarshal(data, &params)
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrDeserialization, err)
+	}
+	return &params, nil
+}
+
+func (s *HTTPSender) SetVaultCheck(check string) error {
+	data, ok := json.Marshal(models.VaultParams{Check: check})
+	if ok != nil {
+		return ErrSerialization
+	}
+	return s.send("PUT", data, "/api/vault")
+}
+
+func (s *HTTPSender) Logout() error {
+	err := s.send("POST", nil, "/api/logout")
+	// Токены забываем даже если сервер недоступен
+	s.AuthToken = nil
+	s.RefreshToken = ""
+	return err
+}
+
+func (s *HTTPSender) Sessions() ([]models.Session, error) {
+	data, err := s.get("api/sessions")
+	if err != nil {
+		return nil, err
+	}
+	// разбираем сообщение
+	var sessions []models.Session
+	err = json.Unmarshal(data, &sessions)
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrDeserialization, err)
+	}
+	return sessions, nil
+}
+
+func (s *HTTPSender) RevokeSession(sessionID string) error {
+	return s.del(sessionID, "api/sessions")
+}
+
+func (s *HTTPSender) SetupTOTP() (*models.TOTPSetup, error) {
+	data, err := s.twoFactor(nil, "/api/2fa/setup")
+	if err != nil {
+		return nil, err
+	}
+	// разбираем сообщение
+	var setup models.TOTPSetup
+	err = json.Unmarshal(data, &setup)
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrDeserialization, err)
+	}
+	return &setup, nil
+}
+
+func (s *HTTPSender) ConfirmTOTP(code string) ([]string, error) {
+	request, err := json.Marshal(models.OTPCode{Code: code})
+	if err != nil {
+		return nil, ErrSerialization
+	}
+	data, err := s.twoFactor(request, "/api/2fa/confirm")
+	if err != nil {
+		return nil, err
+	}
+	// разбираем сообщение
+	var codes models.RecoveryCodes
+	err = json.Unmarshal(data, &codes)
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrDeserialization, err)
+	}
+	return codes.Codes, nil
+}
+
+func (s *HTTPSender) DisableTOTP(code string) error {
+	request, err := json.Marshal(models.OTPCode{Code: code})
+	if err != nil {
+		return ErrSerialization
+	}
+	_, err = s.twoFactor(request, "/api/2fa/disable")
+	return err
+}
+
+func (s *HTTPSender) ChangePassword(current string, newPassword string) error {
+	request, err := json.Marshal(models.PasswordChange{Password: current, NewPassword: newPassword})
+	if err != nil {
+		return ErrSerialization
+	}
+	return s.account("PUT", request, "/api/account/password")
+}
+
+func (s *HTTPSender) ChangeLogin(pwd string, newLogin string) error {
+	request, err := json.Marshal(models.LoginChange{Password: pwd, NewLogin: newLogin})
+	if err != nil {
+		return ErrSerialization
+	}
+	return s.account("PUT", request, "/api/account/login")
+}
+
+func (s *HTTPSender) DeleteAccount(pwd string) error {
+	request, err := json.Marshal(models.AccountDeletion{Password: pwd})
+	if err != nil {
+		return ErrSerialization
+	}
+	err = s.account("DELETE", request, "/api/account")
+	if err != nil {
+		return err
+	}
+	// Сессий удаленного пользователя больше нет
+	s.AuthToken = nil
+	s.RefreshToken = ""
+	return nil
+}
+
+func (s *HTTPSender) Lockouts() ([]models.Lockout, error) {
+	data, err := s.get("api/lockouts")
+	if err != nil {
+		return nil, err
+	}
+	// разбираем сообщение
+	var lockouts []models.Lockout
+	err = json.Unmarshal(data, &lockouts)
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrDeserialization, err)
+	}
+	return lockouts, nil
+}
+
+func (s *HTTPSender) AuditEvents(before int64, limit int) (*models.AuditPage, error) {
+	data, err := s.get(fmt.Sprintf("api/audit?before=%d&limit=%d", before, limit))
+	if err != nil {
+		return nil, err
+	}
+	// разбираем сообщение
+	var page models.AuditPage
+	err = json.Unmarshal(data, &page)
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrDeserialization, err)
+	}
+	return &page, nil
+}
+
+func (s *HTTPSender) Cards(opts models.ListOptions) (*models.RecordPage, error) {
+	return s.list("api/card", opts)
+}
+
+func (s *HTTPSender) Logins(opts models.ListOptions) (*models.RecordPage, error) {
+	return s.list("api/login", opts)
+}
+
+func (s *HTTPSender) Texts(opts models.ListOptions) (*models.RecordPage, error) {
+	return s.list("api/txt", opts)
+}
+
+func (s *HTTPSender) Bins(opts models.ListOptions) (*models.RecordPage, error) {
+	return s.list("api/bin", opts)
+}
+
+func (s *HTTPSender) SSHKeys(opts models.ListOptions) (*models.RecordPage, error) {
+	return s.list("api/ssh", opts)
+}
+
+func (s *HTTPSender) CustomRecords(opts models.ListOptions) (*models.RecordPage, error) {
+	return s.list("api/custom", opts)
+}
+
+// list общий метод чтения страницы списка записей любого типа
+func (s *HTTPSender) list(urlPath string, opts models.ListOptions) (*models.RecordPage, error) {
+	query := url.Values{}
+	if opts.After != "" {
+		query.Set("after", opts.After)
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Sort != "" {
+		query.Set("sort", opts.Sort)
+	}
+	if opts.Desc {
+		query.Set("order", "desc")
+	}
+	if opts.Tag != "" {
+		query.Set("tag", opts.Tag)
+	}
+	if opts.Field != "" {
+		query.Set("field", opts.Field)
+	}
+	if opts.Value != "" {
+		query.Set("value", opts.Value)
+	}
+	if opts.Vault != 0 {
+		query.Set("vault", strconv.FormatInt(opts.Vault, 10))
+	}
+	if opts.Folder != 0 {
+		query.Set("folder", strconv.FormatInt(opts.Folder, 10))
+	}
+	data, err := s.get(urlPath + "?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	// разбираем сообщение
+	var page models.RecordPage
+	err = json.Unmarshal(data, &page)
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrDeserialization, err)
+	}
+	return &page, nil
+}
+
+// kindPath префикс пути api записей вида kind
+func kindPath(kind models.Kind) string {
+	if kind == models.KindText {
+		return "txt"
+	}
+	return string(kind)
+}
+
+// add общий метод по добавлению на сервер. Содержит общую часть для любого типа данных
+func (s *HTTPSender) add(data []byte, urlSuffix string) error {
+	return s.send("POST", data, urlSuffix)
+}
+
+// send общий метод отправки json на сервер, ответ 409 превращается в ErrAlreadyExists, 404 - в ErrNotFound
+func (s *HTTPSender) send(method string, data []byte, urlSuffix string) error {
+	if s.AuthToken == nil {
+		return ErrAuthRequire
+	}
+
+	req, err := http.NewRequest(method, s.Conf.Address+urlSuffix, bytes.NewBuffer(data))
+	if err != nil {
+		return fmt.Errorf(FmtErrRequestPrepare, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		return ErrAlreadyExists
+	} else if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	} else if resp.StatusCode == http.StatusBadRequest {
+		return badRequest(resp)
+	} else if resp.StatusCode != http.StatusOK {
+		return ErrInternalServer
+	}
+	return nil
+}
+
+// folders общий метод изменения хранилищ, папок, типов записей и расположения записей, возвращает тело ответа.
+// Ответ 409 - имя уже занято или у типа есть записи, 400 - нет такого хранилища или папки, неверная схема типа
+func (s *HTTPSender) folders(method string, data []byte, urlSuffix string) ([]byte, error) {
+	if s.AuthToken == nil {
+		return nil, ErrAuthRequire
+	}
+
+	req, err := http.NewRequest(method, s.Conf.Address+urlSuffix, bytes.NewBuffer(data))
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrRequestPrepare, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode == http.StatusConflict:
+		if body, _ := io.ReadAll(resp.Body); string(body) == ErrRecordTypeInUse.Error() {
+			return nil, ErrRecordTypeInUse
+		}
+		return nil, ErrNameTaken
+	case resp.StatusCode == http.StatusBadRequest:
+		if err := badRequest(resp); err != ErrInternalServer {
+			return nil, err
+		}
+		return nil, ErrEmptyName
+	default:
+		return nil, ErrInternalServer
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrSerialization, err)
+	}
+	return respBody, nil
+}
+
+// badRequest превращает ответ 400 в ошибку клиента по тексту ответа: неверная карта, ssh ключ, тип записи
+// или запись пользовательского типа, нет такого хранилища или папки
+func badRequest(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	switch {
+	case invalidValue(string(body)) != nil:
+		return invalidValue(string(body))
+	case string(body) == ErrInvalidLocation.Error():
+		return ErrInvalidLocation
+	default:
+		return ErrInternalServer
+	}
+}
+
+// update общий метод замены записи любого типа, возвращает новую версию записи.
+// Ответ 409 превращается в ErrVersionConflict
+func (s *HTTPSender) update(method string, data []byte, urlSuffix string) (int64, error) {
+	if s.AuthToken == nil {
+		return 0, ErrAuthRequire
+	}
+
+	req, err := http.NewRequest(method, s.Conf.Address+urlSuffix, bytes.NewBuffer(data))
+	if err != nil {
+		return 0, fmt.Errorf(FmtErrRequestPrepare, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return 0, ErrNotFound
+	case http.StatusConflict:
+		return 0, ErrVersionConflict
+	case http.StatusGone:
+		return 0, ErrContentRemoved
+	case http.StatusBadRequest:
+		return 0, badRequest(resp)
+	default:
+		return 0, ErrInternalServer
+	}
+
+	var version models.RecordVersion
+	if err := json.NewDecoder(resp.Body).Decode(&version); err != nil {
+		return 0, fmt.Errorf(FmtErrDeserialization, err)
+	}
+	return version.Version, nil
+}
+
+// upload общий метод запросов загрузки содержимого, возвращает состояние загрузки
+func (s *HTTPSender) upload(method string, urlSuffix string) (*models.BinaryUpload, error) {
+	if s.AuthToken == nil {
+		return nil, ErrAuthRequire
+	}
+
+	req, err := http.NewRequest(method, s.Conf.Address+urlSuffix, nil)
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrRequestPrepare, err)
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, ErrInternalServer
+	}
+
+	var upload models.BinaryUpload
+	if err := json.NewDecoder(resp.Body).Decode(&upload); err != nil {
+		return nil, fmt.Errorf(FmtErrDeserialization, err)
+	}
+	return &upload, nil
+}
+
+// twoFactor общий метод запросов второго фактора, возвращает тело ответа.
+// Ответ 403 - неверный код, 409 - второй фактор уже включен или не настроен
+func (s *HTTPSender) twoFactor(data []byte, urlSuffix string) ([]byte, error) {
+	if s.AuthToken == nil {
+		return nil, ErrAuthRequire
+	}
+
+	req, err := http.NewRequest("POST", s.Conf.Address+urlSuffix, bytes.NewBuffer(data))
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrRequestPrepare, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusForbidden:
+		return nil, ErrInvalidOTP
+	case http.StatusConflict:
+		return nil, ErrTwoFactorState
+	default:
+		return nil, ErrInternalServer
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrSerialization, err)
+	}
+	return respBody, nil
+}
+
+// account общий метод запросов управления аккаунтом, коды ответа превращаются в ошибки клиента
+func (s *HTTPSender) account(method string, data []byte, urlSuffix string) error {
+	if s.AuthToken == nil {
+		return ErrAuthRequire
+	}
+
+	req, err := http.NewRequest(method, s.Conf.Address+urlSuffix, bytes.NewBuffer(data))
+	if err != nil {
+		return fmt.Errorf(FmtErrRequestPrepare, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusBadRequest:
+		return ErrEmptyValue
+	case http.StatusForbidden:
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) == "invalid password" {
+			return ErrInvalidPassword
+		}
+		return ErrCertNotBound
+	case http.StatusConflict:
+		return ErrUserAlreadyExists
+	case http.StatusTooManyRequests:
+		return fmt.Errorf(FmtErrTooManyAttempts, ErrTooManyAttempts, resp.Header.Get("Retry-After")+"s")
+	default:
+		return ErrInternalServer
+	}
+}
+
+// read общий метод по чтение с сервера. Содержит общую часть для любого типа данных
+func (s *HTTPSender) read(textID string, urlSuffix string) ([]byte, error) {
+	return s.get(fmt.Sprintf("%s/%s", urlSuffix, textID))
+}
+
+// get общий метод GET запроса, возвращает тело ответа
+func (s *HTTPSender) get(urlPath string) ([]byte, error) {
+	if s.AuthToken == nil {
+		return nil, ErrAuthRequire
+	}
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/%s", s.Conf.Address, urlPath), nil)
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrRequestPrepare, err)
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	if resp.StatusCode == http.StatusBadRequest {
+		return nil, ErrInvalidQuery
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, ErrInternalServer
+	}
+
+	reqBody, err := io.ReadAll(resp.Body)
+	defer resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrSerialization, err)
+	}
+	return reqBody, nil
+}
+
+// del общий метод по удалению с сервера. Содержит общую часть для любого типа данных
+func (s *HTTPSender) del(binID string, urlSuffix string) error {
+	if s.AuthToken == nil {
+		return ErrAuthRequire
+	}
+
+	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/%s/%s", s.Conf.Address, urlSuffix, binID), nil)
+	if err != nil {
+		return fmt.Errorf(FmtErrRequestPrepare, err)
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return ErrInternalServer
+	}
+	return nil
+}
+
+// do отправляет запрос с токеном авторизации. Если токен истек, получает новый по refresh токену и повторяет запрос
+func (s *HTTPSender) do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("Authorization", *s.AuthToken)
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrServerTimout, err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized || s.RefreshToken == "" {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+
+	// Тело запроса уже прочитано, берем его копию
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		retry.Body, err = req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf(FmtErrRequestPrepare, err)
+		}
+	}
+	retry.Header.Set("Authorization", *s.AuthToken)
+	resp, err = s.Client.Do(retry)
+	if err != nil {
+		return nil, fmt.Errorf(FmtErrServerTimout, err)
+	}
+	return resp, nil
+}
+
+// refresh получает новую пару токенов. Если сессия истекла или отозвана, токены забываются
+func (s *HTTPSender) refresh() error {
+	result, err := json.Marshal(models.Tokens{RefreshToken: s.RefreshToken})
+	if err != nil {
+		return ErrSerialization
+	}
+
+	req, err := http.NewRequest("POST", s.Conf.Address+"/api/token/refresh", bytes.NewBuffer(result))
+	if err != nil {
+		return fmt.Errorf(FmtErrRequestPrepare, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf(FmtErrServerTimout, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		s.AuthToken = nil
+		s.RefreshToken = ""
+		return ErrSessionExpired
+	} else if resp.StatusCode != http.StatusOK {
+		return ErrInternalServer
+	}
+
+	s.saveTokens(resp)
+	return nil
+}
+
+// saveTokens запоминает токены сессии из заголовков ответа
+func (s *HTTPSender) saveTokens(resp *http.Response) {
+	authToken := resp.Header.Get("Authorization")
+	s.AuthToken = &authToken
+	s.RefreshToken = resp.Header.Get("Refresh-Token")
 }
@@ -2,6 +2,8 @@
 package api
 
 import (
+	"io"
+
 	"github.com/ncyellow/GophKeeper/internal/models"
 )
 
@@ -9,20 +11,63 @@ import (
 type Sender interface {
 	// Register request to the server for client registration
 	Register(login string, pwd string) error
-	// SignIn request to the server for client authorization
-	SignIn(login string, pwd string) error
+	// SignIn request to the server for client authorization. otp is the code of the second factor or a recovery code,
+	// if it is empty and the user enabled the second factor, ErrOTPRequired is returned
+	SignIn(login string, pwd string, otp string) error
+	// Logout request to revoke the current session, after it the client has to sign in again
+	Logout() error
+	// Sessions request to read the active sessions of the user
+	Sessions() ([]models.Session, error)
+	// RevokeSession request to revoke the session by id, e.g. of a lost device
+	RevokeSession(sessionID string) error
+
+	// SetupTOTP request to generate a new secret of the second factor, it is enabled only after ConfirmTOTP
+	SetupTOTP() (*models.TOTPSetup, error)
+	// ConfirmTOTP request to enable the second factor by the code from the authenticator app, returns the recovery codes
+	ConfirmTOTP(code string) ([]string, error)
+	// DisableTOTP request to disable the second factor by the current code or a recovery code
+	DisableTOTP(code string) error
+	// ChangePassword request to replace the password, the other sessions of the user are revoked
+	ChangePassword(current string, newPassword string) error
+	// ChangeLogin request to rename the account, the password confirms it
+	ChangeLogin(pwd string, newLogin string) error
+	// DeleteAccount request to delete the account with all the records, the client forgets the tokens
+	DeleteAccount(pwd string) error
+
+	// Lockouts request to read the lockouts of the account after too many failed sign-in attempts
+	Lockouts() ([]models.Lockout, error)
+	// AuditEvents request to read the page of the audit log, before is the cursor of the page, zero for the newest events
+	AuditEvents(before int64, limit int) (*models.AuditPage, error)
+
+	// Cards request to read the page of the cards without the secret fields
+	Cards(opts models.ListOptions) (*models.RecordPage, error)
+	// Logins request to read the page of the logins without the secret fields
+	Logins(opts models.ListOptions) (*models.RecordPage, error)
+	// Texts request to read the page of the text content without the secret fields
+	Texts(opts models.ListOptions) (*models.RecordPage, error)
+	// Bins request to read the page of the binary data without the secret fields
+	Bins(opts models.ListOptions) (*models.RecordPage, error)
+	// SSHKeys request to read the page of the ssh keys without the secret fields
+	SSHKeys(opts models.ListOptions) (*models.RecordPage, error)
+	// CustomRecords request to read the page of the records of the user types without the values
+	CustomRecords(opts models.ListOptions) (*models.RecordPage, error)
 
 	// AddCard request to add a new card
 	AddCard(card *models.Card) error
 	// Card request to read an existing card by id
 	Card(cardID string) (*models.Card, error)
-	// DelCard request to delete an existing card by id
+	// UpdateCard request to replace the card read before, the version of the record is updated on success.
+	// ErrVersionConflict is returned if the record was changed since it was read
+	UpdateCard(card *models.Card) error
+	// DelCard request to move an existing card to the trash by id
 	DelCard(cardID string) error
 
 	// AddLogin request to add a new login
 	AddLogin(login *models.Login) error
 	// Login request to read an existing login by id
 	Login(loginID string) (*models.Login, error)
+	// UpdateLogin request to replace the login read before, see UpdateCard
+	UpdateLogin(login *models.Login) error
 	// DelLogin request to delete an existing login by id
 	DelLogin(loginID string) error
 
@@ -30,6 +75,8 @@ type Sender interface {
 	AddText(text *models.Text) error
 	// Text request to read existing text content by id
 	Text(textID string) (*models.Text, error)
+	// UpdateText request to replace the text content read before, see UpdateCard
+	UpdateText(text *models.Text) error
 	// DelText request to delete existing text content by id
 	DelText(textID string) error
 
@@ -37,6 +84,86 @@ type Sender interface {
 	AddBin(binary *models.Binary) error
 	// Bin request to read existing binary data by id
 	Bin(binID string) (*models.Binary, error)
+	// UpdateBin request to replace the binary data read before, see UpdateCard
+	UpdateBin(binary *models.Binary) error
 	// DelBin request to delete existing binary data by id
 	DelBin(binID string) error
+	// UploadBin request to replace the content of the binary data read before with the content uploaded in chunks,
+	// the memory does not depend on the size of the content. The interrupted upload is resumed from the chunks
+	// received by the server, so the content has to be seekable. Version, ContentID and Size of the binary are updated
+	UploadBin(binary *models.Binary, content io.ReadSeeker) error
+	// DownloadBin request to write the content of the binary data read before to w, the interrupted download
+	// is resumed. ErrContentReplaced is returned if the content was replaced since the binary was read
+	DownloadBin(binary *models.Binary, w io.Writer) error
+
+	// AddSSHKey request to add a new ssh key, the public key is derived from the private one if it is omitted.
+	// The error wrapping models.ErrInvalidSSHKey is returned if the keys can not be parsed or are not a pair
+	AddSSHKey(key *models.SSHKey) error
+	// SSHKey request to read an existing ssh key by id
+	SSHKey(keyID string) (*models.SSHKey, error)
+	// UpdateSSHKey request to replace the ssh key read before, see UpdateCard
+	UpdateSSHKey(key *models.SSHKey) error
+	// DelSSHKey request to delete an existing ssh key by id
+	DelSSHKey(keyID string) error
+
+	// RecordTypes request to read the record types defined by the user
+	RecordTypes() ([]models.RecordType, error)
+	// RecordType request to read the schema of the record type by name
+	RecordType(name string) (*models.RecordType, error)
+	// AddRecordType request to define a new record type. ErrNameTaken is returned if the user has the type with the name,
+	// the error wrapping models.ErrInvalidRecordType if the schema is not valid
+	AddRecordType(recordType models.RecordType) error
+	// UpdateRecordType request to replace the fields of the record type found by name
+	UpdateRecordType(recordType models.RecordType) error
+	// DeleteRecordType request to delete the record type, ErrRecordTypeInUse is returned while the type has records
+	DeleteRecordType(name string) error
+
+	// AddCustomRecord request to add a new record of the user type.
+	// The error wrapping models.ErrInvalidRecord is returned if the values do not match the schema of the type
+	AddCustomRecord(record *models.CustomRecord) error
+	// CustomRecord request to read an existing record of the user type by id
+	CustomRecord(recordID string) (*models.CustomRecord, error)
+	// UpdateCustomRecord request to replace the record of the user type read before, see UpdateCard
+	UpdateCustomRecord(record *models.CustomRecord) error
+	// DelCustomRecord request to delete an existing record of the user type by id
+	DelCustomRecord(recordID string) error
+
+	// Trash request to read the deleted records, they can be restored until the trash is emptied
+	Trash() ([]models.TrashItem, error)
+	// RestoreTrash request to move the deleted record back from the trash
+	RestoreTrash(kind models.Kind, id string) error
+	// EmptyTrash request to remove the deleted records for good, returns the number of the removed ones
+	EmptyTrash() (int64, error)
+
+	// Revisions request to read the history of the record without the values, the newest revision first
+	Revisions(kind models.Kind, id string) ([]models.Revision, error)
+	// Revision request to read the revision of the record with the values of the fields
+	Revision(kind models.Kind, id string, revisionID int64) (*models.Revision, error)
+	// RestoreRevision request to make the revision the current version of the record, returns the new version
+	RestoreRevision(kind models.Kind, id string, revisionID int64) (int64, error)
+
+	// Vaults request to read the named vaults of the user, not to be confused with the client side encryption Vault
+	Vaults() ([]models.Vault, error)
+	// AddVault request to create the vault, returns its id. ErrNameTaken is returned if the name is used by another vault
+	AddVault(name string) (int64, error)
+	// RenameVault request to rename the vault
+	RenameVault(vaultID int64, name string) error
+	// DeleteVault request to delete the vault with its folders, the records of the vault are moved to the top level
+	DeleteVault(vaultID int64) error
+	// Folders request to read all folders of the vault, the tree is built by the parents
+	Folders(vaultID int64) ([]models.Folder, error)
+	// AddFolder request to create the folder in the vault, returns its id. The zero parent is the root of the vault
+	AddFolder(folder models.Folder) (int64, error)
+	// UpdateFolder request to rename the folder and move it to another parent of the same vault
+	UpdateFolder(folder models.Folder) error
+	// DeleteFolder request to delete the folder with the nested ones, the records are moved to the root of the vault
+	DeleteFolder(folderID int64) error
+	// MoveRecord request to move the record to the vault and the folder, the zero vault is the top level.
+	// ErrInvalidLocation is returned if there is no such vault or folder
+	MoveRecord(kind models.Kind, id string, location models.Location) error
+
+	// Vault request to read the parameters of the client side encryption
+	Vault() (*models.VaultParams, error)
+	// SetVaultCheck request to save the master password check value, it can be set only once
+	SetVaultCheck(check string) error
 }
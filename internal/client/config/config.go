@@ -28,7 +28,7 @@ func ParseConfig() (*Config, error) {
 	flag.StringVar(&cfg.Address, "addr", "https://localhost", "address in the format host:port")
 	flag.StringVar(&cfg.CryptoCrt, "crypto-crt", "", "*.crt filepath")
 	flag.StringVar(&cfg.CryptoKey, "crypto-key", "", "*.key filepath")
-	flag.StringVar(&cfg.CACertFile, "crypto-ca", "", "*.key filepath for ca")
+	flag.StringVar(&cfg.CACertFile, "crypto-ca", "", "*.crt filepath of the ca for the server certificate verification")
 	flag.StringVar(&cfg.GRPCAddress, "grp-addr", ":3200", "grpc address in the format host:port")
 
 	// First, we parse the command line arguments
@@ -0,0 +1,146 @@
+package console
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/ncyellow/GophKeeper/internal/client/api"
+	"github.com/ncyellow/GophKeeper/internal/client/sshagent"
+	"github.com/ncyellow/GophKeeper/internal/models"
+)
+
+// sshAgent - the agent serving the ssh keys of the vault, nil until agent-start
+var sshAgent *sshagent.Agent
+
+// startAgent - starts the ssh-agent on the socket and prints the variable for the ssh clients.
+// The default socket is in the temporary directory
+func startAgent(args []string) {
+	path := filepath.Join(os.TempDir(), fmt.Sprintf("gophkeeper-agent-%d.sock", os.Getpid()))
+	if len(args) > 0 && args[0] != "" {
+		path = args[0]
+	}
+	if sshAgent == nil {
+		sshAgent = sshagent.New()
+	}
+	if err := sshAgent.Listen(path); err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	fmt.Println("Agent started, load the keys with agent-add and run in the shell:")
+	fmt.Printf("export SSH_AUTH_SOCK=%s\n", path)
+}
+
+// stopAgent - stops the ssh-agent and forgets the loaded keys, e.g. when the vault is locked
+func stopAgent() {
+	if sshAgent == nil || sshAgent.Path() == "" {
+		return
+	}
+	if err := sshAgent.Close(); err != nil {
+		fmt.Println(err.Error())
+	}
+	fmt.Println("Agent stopped")
+}
+
+// agentAdd - loads the ssh key of the vault into the running agent, the passphrase of the protected key is asked
+func agentAdd(sender api.VaultSender, args []string) {
+	if len(args) != 1 {
+		fmt.Println("Enter ssh key identifier!")
+		return
+	}
+	if sshAgent == nil || sshAgent.Path() == "" {
+		fmt.Println("The agent is not running, start it with agent-start!")
+		return
+	}
+	key, err := sender.SSHKey(args[0])
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	var passphrase []byte
+	var missing *ssh.PassphraseMissingError
+	if _, err := ssh.ParseRawPrivateKey([]byte(key.PrivateKey)); errors.As(err, &missing) {
+		value, err := hiddenInput("Enter Passphrase: ")
+		if err != nil {
+			return
+		}
+		passphrase = []byte(value)
+	}
+	if err := sshAgent.Add(key, passphrase); err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	fmt.Printf("Key %s loaded, %s\n", key.ID, key.Fingerprint)
+}
+
+// agentRemove - unloads the ssh key of the vault from the agent
+func agentRemove(sender api.VaultSender, args []string) {
+	if len(args) != 1 {
+		fmt.Println("Enter ssh key identifier!")
+		return
+	}
+	if sshAgent == nil || sshAgent.Path() == "" {
+		fmt.Println("The agent is not running!")
+		return
+	}
+	key, err := sender.SSHKey(args[0])
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if err := sshAgent.Remove(key.PublicKey); err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	fmt.Printf("Key %s unloaded\n", key.ID)
+}
+
+// printAgent - prints the socket of the agent and the loaded keys
+func printAgent() {
+	if sshAgent == nil || sshAgent.Path() == "" {
+		fmt.Println("The agent is not running")
+		return
+	}
+	fmt.Printf("SSH_AUTH_SOCK=%s\n", sshAgent.Path())
+	keys, err := sshAgent.Keys()
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if len(keys) == 0 {
+		fmt.Println("No keys loaded")
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "TYPE\tFINGERPRINT\tCOMMENT")
+	for _, key := range keys {
+		fmt.Fprintf(table, "%s\t%s\t%s\n", key.Type(), ssh.FingerprintSHA256(key), key.Comment)
+	}
+	table.Flush()
+}
+
+// printSSHKey - prints the ssh key, the private key is masked unless show is set, then it is printed after the table.
+// The public key is printed as is to be copied into authorized_keys
+func printSSHKey(key *models.SSHKey, show bool) {
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "ID\t%s\n", key.ID)
+	fmt.Fprintf(table, "Fingerprint\t%s\n", key.Fingerprint)
+	fmt.Fprintf(table, "Public Key\t%s\n", strings.TrimSpace(key.PublicKey+" "+key.Comment))
+	if !show {
+		fmt.Fprintf(table, "Private Key\t%s\n", maskField(models.KindSSH, "private_key", key.PrivateKey))
+	}
+	fmt.Fprintf(table, "Note\t%s\n", key.Note)
+	fmt.Fprintf(table, "Tags\t%s\n", strings.Join(key.Tags, ", "))
+	fmt.Fprintf(table, "Fields\t%s\n", formatFields(key.Fields))
+	fmt.Fprintf(table, "Version\t%d\n", key.Version)
+	table.Flush()
+	if show {
+		fmt.Println(strings.TrimSpace(key.PrivateKey))
+	}
+}
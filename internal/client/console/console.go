@@ -2,17 +2,24 @@
 package console
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/c-bata/go-prompt"
 
 	"github.com/ncyellow/GophKeeper/internal/client/api"
 	"github.com/ncyellow/GophKeeper/internal/client/config"
+	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/totp"
 )
 
 // LivePrefixState auxiliary structure to create a nice prompt with the name of the authorized user
+// and the path of the current vault and folder
 var LivePrefixState struct {
 	LivePrefix string
 	IsEnable   bool
@@ -26,16 +33,17 @@ func changeLivePrefix() (string, bool) {
 // Console structure for handling keyboard input. Using go-prompt
 type Console struct {
 	Conf   *config.Config
-	Client api.Sender
+	Client api.VaultSender
 }
 
 // CreateExecutor function for processing all commands entered from the keyboard
-func CreateExecutor(sender api.Sender) func(string) {
+func CreateExecutor(sender api.VaultSender) func(string) {
 	return func(t string) {
 		s := strings.TrimSpace(t)
 		commands := strings.Split(s, " ")
 		switch commands[0] {
 		case "exit":
+			stopAgent()
 			fmt.Println("bye!")
 			os.Exit(0)
 		case "version":
@@ -50,26 +58,216 @@ func CreateExecutor(sender api.Sender) func(string) {
 					fmt.Println(err.Error())
 				} else {
 					fmt.Println("")
-					LivePrefixState.LivePrefix = fmt.Sprintf("%s >>>", username)
-					LivePrefixState.IsEnable = true
+					enter(username)
+					unlock(sender)
 				}
 			}
 		case "signin":
 			username, password, err := credentials()
 			if err == nil {
-				err := sender.SignIn(username, password)
+				err := sender.SignIn(username, password, "")
+				if errors.Is(err, api.ErrOTPRequired) {
+					// The password is correct, the second factor is enabled
+					fmt.Println("")
+					code, readErr := otpCode()
+					if readErr != nil {
+						return
+					}
+					err = sender.SignIn(username, password, code)
+				}
 				if err != nil {
 					fmt.Println("")
 					fmt.Println(err.Error())
 				} else {
 					fmt.Println("")
-					LivePrefixState.LivePrefix = fmt.Sprintf("%s >>>", username)
-					LivePrefixState.IsEnable = true
+					enter(username)
+					unlock(sender)
+				}
+			}
+		case "logout":
+			err := sender.Logout()
+			stopAgent()
+			enter("")
+			if err != nil {
+				fmt.Println(err.Error())
+			} else {
+				fmt.Println("Logged out!")
+			}
+		case "password":
+			current, newPassword, err := passwordChange()
+			if err != nil {
+				fmt.Println(err.Error())
+			} else if err := sender.ChangePassword(current, newPassword); err != nil {
+				fmt.Println(err.Error())
+			} else {
+				fmt.Println("Password changed, other sessions are revoked!")
+			}
+		case "rename":
+			login, pwd, err := loginChange()
+			if err != nil {
+				fmt.Println(err.Error())
+			} else if err := sender.ChangeLogin(pwd, login); err != nil {
+				fmt.Println(err.Error())
+			} else {
+				current.user = login
+				updatePrompt()
+				fmt.Println("Login changed!")
+			}
+		case "account-delete":
+			pwd, confirmed, err := accountDeletion()
+			if err != nil {
+				fmt.Println(err.Error())
+			} else if !confirmed {
+				fmt.Println("Canceled")
+			} else if err := sender.DeleteAccount(pwd); err != nil {
+				fmt.Println(err.Error())
+			} else {
+				sender.Lock()
+				enter("")
+				fmt.Println("Account deleted!")
+			}
+		case "sessions":
+			sessions, err := sender.Sessions()
+			if err != nil {
+				fmt.Println(err.Error())
+			} else {
+				for _, session := range sessions {
+					current := ""
+					if session.Current {
+						current = " (current)"
+					}
+					fmt.Printf("%s%s - %s, signed in %s, last used %s\n", session.ID, current, session.UserAgent,
+						session.CreatedAt.Format(time.DateTime), session.RefreshedAt.Format(time.DateTime))
+				}
+			}
+		case "session-revoke":
+			if len(commands) != 2 {
+				fmt.Println("Enter session identifier!")
+			} else {
+				err := sender.RevokeSession(commands[1])
+				if err != nil {
+					fmt.Println(err.Error())
+				} else {
+					fmt.Println("Session revoked!")
+				}
+			}
+		case "2fa-enable":
+			enableTwoFactor(sender)
+		case "2fa-disable":
+			code, err := otpCode()
+			if err == nil {
+				err := sender.DisableTOTP(code)
+				if err != nil {
+					fmt.Println(err.Error())
+				} else {
+					fmt.Println("Two-factor authentication disabled!")
+				}
+			}
+		case "lockouts":
+			lockouts, err := sender.Lockouts()
+			if err != nil {
+				fmt.Println(err.Error())
+			} else if len(lockouts) == 0 {
+				fmt.Println("No lockouts")
+			} else {
+				for _, lockout := range lockouts {
+					fmt.Printf("%s - locked until %s after %d failed attempts, last from %s\n",
+						lockout.CreatedAt.Format(time.DateTime), lockout.LockedUntil.Format(time.DateTime),
+						lockout.Failures, lockout.IP)
+				}
+			}
+		case "audit":
+			printAudit(sender, commands[1:])
+		case "unlock":
+			unlock(sender)
+		case "lock":
+			sender.Lock()
+			stopAgent()
+			fmt.Println("Vault locked!")
+		case "migrate":
+			if len(commands) != 3 {
+				fmt.Println("Enter record kind and identifier!")
+			} else {
+				kind, err := models.ParseKind(commands[1])
+				if err != nil {
+					fmt.Println(err.Error())
+					return
+				}
+				migrated, err := sender.Migrate(kind, commands[2])
+				if err != nil {
+					fmt.Println(err.Error())
+				} else if migrated {
+					fmt.Println("Record encrypted!")
+				} else {
+					fmt.Println("Record is already encrypted")
+				}
+			}
+		case "trash":
+			printTrash(sender)
+		case "trash-restore":
+			if len(commands) != 3 {
+				fmt.Println("Enter record kind and identifier!")
+			} else {
+				kind, err := models.ParseKind(commands[1])
+				if err != nil {
+					fmt.Println(err.Error())
+					return
+				}
+				if err := sender.RestoreTrash(kind, commands[2]); err != nil {
+					fmt.Println(err.Error())
+				} else {
+					fmt.Println("Record restored!")
 				}
 			}
+		case "trash-empty":
+			removed, err := sender.EmptyTrash()
+			if err != nil {
+				fmt.Println(err.Error())
+			} else {
+				fmt.Printf("%d records removed for good\n", removed)
+			}
+		case "history":
+			printHistory(sender, commands[1:])
+		case "revision":
+			printRevision(sender, commands[1:])
+		case "diff":
+			printDiff(sender, commands[1:])
+		case "restore":
+			restoreRevision(sender, commands[1:])
+		case "cd":
+			changeDir(sender, commands[1:])
+		case "pwd":
+			fmt.Println(current.path())
+		case "ls":
+			printDir(sender)
+		case "mkdir":
+			makeDir(sender, commands[1:])
+		case "rmdir":
+			removeDir(sender, commands[1:])
+		case "rendir":
+			renameDir(sender, commands[1:])
+		case "mv":
+			moveRecord(sender, commands[1:])
+		case "cards":
+			printRecords(commands[0], sender.Cards, commands[1:])
+		case "logins":
+			printRecords(commands[0], sender.Logins, commands[1:])
+		case "texts":
+			printRecords(commands[0], sender.Texts, commands[1:])
+		case "bins":
+			printRecords(commands[0], sender.Bins, commands[1:])
+		case "ssh-keys":
+			printRecords(commands[0], sender.SSHKeys, commands[1:])
+		case "customs":
+			printRecords(commands[0], sender.CustomRecords, commands[1:])
 		case "card-add":
 			card, err := readCard()
-			if err == nil {
+			if errors.Is(err, models.ErrInvalidCard) {
+				fmt.Println("")
+				fmt.Println(err.Error())
+			} else if err == nil {
+				target := current.target()
+				card.Vault, card.Folder = target.Vault, target.Folder
 				err := sender.AddCard(card)
 				if err != nil {
 					fmt.Println("")
@@ -80,14 +278,31 @@ func CreateExecutor(sender api.Sender) func(string) {
 				}
 			}
 		case "card":
-			if len(commands) != 2 {
-				fmt.Println("Enter card number!")
+			if len(commands) != 2 && (len(commands) != 3 || commands[2] != "show") {
+				fmt.Println("Enter card identifier!")
 			} else {
 				card, err := sender.Card(commands[1])
 				if err != nil {
 					fmt.Println(err.Error())
 				} else {
-					fmt.Printf("Card data read successfully - %#v!\n", card)
+					printCard(card, len(commands) == 3)
+				}
+			}
+		case "card-edit":
+			if len(commands) != 2 {
+				fmt.Println("Enter card identifier!")
+			} else {
+				card, err := sender.Card(commands[1])
+				if err != nil {
+					fmt.Println(err.Error())
+				} else if err := editCard(card); errors.Is(err, models.ErrInvalidCard) {
+					fmt.Println(err.Error())
+				} else if err == nil {
+					if err := sender.UpdateCard(card); err != nil {
+						fmt.Println(err.Error())
+					} else {
+						fmt.Printf("Card updated, version %d\n", card.Version)
+					}
 				}
 			}
 		case "card-del":
@@ -98,12 +313,14 @@ func CreateExecutor(sender api.Sender) func(string) {
 				if err != nil {
 					fmt.Println(err.Error())
 				} else {
-					fmt.Println("Card deleted!")
+					fmt.Println("Card moved to trash!")
 				}
 			}
 		case "login-add":
 			login, err := readLogin()
 			if err == nil {
+				target := current.target()
+				login.Vault, login.Folder = target.Vault, target.Folder
 				err := sender.AddLogin(login)
 				if err != nil {
 					fmt.Println("")
@@ -114,14 +331,35 @@ func CreateExecutor(sender api.Sender) func(string) {
 				}
 			}
 		case "login":
-			if len(commands) != 2 {
+			if len(commands) != 2 && (len(commands) != 3 || commands[2] != "show") {
 				fmt.Println("Enter login identifier!")
 			} else {
 				login, err := sender.Login(commands[1])
 				if err != nil {
 					fmt.Println(err.Error())
 				} else {
-					fmt.Printf("Login data read successfully - %#v!\n", login)
+					printLogin(login, len(commands) == 3)
+				}
+			}
+		case "totp":
+			if len(commands) != 2 {
+				fmt.Println("Enter login identifier!")
+			} else {
+				printTOTP(sender, commands[1])
+			}
+		case "login-edit":
+			if len(commands) != 2 {
+				fmt.Println("Enter login identifier!")
+			} else {
+				login, err := sender.Login(commands[1])
+				if err != nil {
+					fmt.Println(err.Error())
+				} else if err := editLogin(login); err == nil {
+					if err := sender.UpdateLogin(login); err != nil {
+						fmt.Println(err.Error())
+					} else {
+						fmt.Printf("Login updated, version %d\n", login.Version)
+					}
 				}
 			}
 		case "login-del":
@@ -132,12 +370,14 @@ func CreateExecutor(sender api.Sender) func(string) {
 				if err != nil {
 					fmt.Println(err.Error())
 				} else {
-					fmt.Println("Login deleted!")
+					fmt.Println("Login moved to trash!")
 				}
 			}
 		case "text-add":
 			text, err := readText()
 			if err == nil {
+				target := current.target()
+				text.Vault, text.Folder = target.Vault, target.Folder
 				err := sender.AddText(text)
 				if err != nil {
 					fmt.Println(err.Error())
@@ -156,40 +396,58 @@ func CreateExecutor(sender api.Sender) func(string) {
 					fmt.Printf("Text data read successfully - %#v!\n", text)
 				}
 			}
-		case "text-del":
+		case "text-edit":
 			if len(commands) != 2 {
 				fmt.Println("Enter text identifier!")
 			} else {
-				err := sender.DelText(commands[1])
+				text, err := sender.Text(commands[1])
 				if err != nil {
 					fmt.Println(err.Error())
-				} else {
-					fmt.Println("Text deleted!")
+				} else if err := editText(text); err == nil {
+					if err := sender.UpdateText(text); err != nil {
+						fmt.Println(err.Error())
+					} else {
+						fmt.Printf("Text updated, version %d\n", text.Version)
+					}
 				}
 			}
-		case "bin-add":
-			bin, err := readBinary()
-			if err == nil {
-				err := sender.AddBin(bin)
+		case "text-del":
+			if len(commands) != 2 {
+				fmt.Println("Enter text identifier!")
+			} else {
+				err := sender.DelText(commands[1])
 				if err != nil {
-					fmt.Println("")
 					fmt.Println(err.Error())
 				} else {
-					fmt.Println("")
-					fmt.Printf("Bin with ID - %s successfully added\n", bin.ID)
+					fmt.Println("Text moved to trash!")
 				}
 			}
+		case "bin-add":
+			addBinary(sender, current.target(), commands[1:])
 		case "bin":
+			showBinary(sender, commands[1:])
+		case "bin-edit":
 			if len(commands) != 2 {
 				fmt.Println("Enter file identifier!")
 			} else {
 				bin, err := sender.Bin(commands[1])
 				if err != nil {
 					fmt.Println(err.Error())
+				} else if upload, err := editBinary(bin); err != nil {
+					fmt.Println(err.Error())
+				} else if err := sender.UpdateBin(bin); err != nil {
+					fmt.Println(err.Error())
+				} else if upload != "" {
+					fmt.Printf("Bin updated, version %d, uploading the content\n", bin.Version)
+					uploadBinary(sender, []string{bin.ID, upload})
 				} else {
-					fmt.Printf("Bin data read successfully - %#v!\n", bin)
+					fmt.Printf("Bin updated, version %d\n", bin.Version)
 				}
 			}
+		case "bin-upload":
+			uploadBinary(sender, commands[1:])
+		case "bin-download":
+			downloadBinary(sender, commands[1:])
 		case "bin-del":
 			if len(commands) != 2 {
 				fmt.Println("Enter file identifier!")
@@ -198,10 +456,327 @@ func CreateExecutor(sender api.Sender) func(string) {
 				if err != nil {
 					fmt.Println(err.Error())
 				} else {
-					fmt.Println("Bin deleted!")
+					fmt.Println("Bin moved to trash!")
+				}
+			}
+		case "ssh-add":
+			key, err := readSSHKey()
+			if errors.Is(err, models.ErrInvalidSSHKey) {
+				fmt.Println(err.Error())
+			} else if err == nil {
+				target := current.target()
+				key.Vault, key.Folder = target.Vault, target.Folder
+				err := sender.AddSSHKey(key)
+				if err != nil {
+					fmt.Println(err.Error())
+				} else {
+					fmt.Printf("SSH key with ID - %s successfully added, %s\n", key.ID, key.Fingerprint)
+				}
+			}
+		case "ssh":
+			if len(commands) != 2 && (len(commands) != 3 || commands[2] != "show") {
+				fmt.Println("Enter ssh key identifier!")
+			} else {
+				key, err := sender.SSHKey(commands[1])
+				if err != nil {
+					fmt.Println(err.Error())
+				} else {
+					printSSHKey(key, len(commands) == 3)
 				}
 			}
+		case "ssh-edit":
+			if len(commands) != 2 {
+				fmt.Println("Enter ssh key identifier!")
+			} else {
+				key, err := sender.SSHKey(commands[1])
+				if err != nil {
+					fmt.Println(err.Error())
+				} else if err := editSSHKey(key); errors.Is(err, models.ErrInvalidSSHKey) {
+					fmt.Println(err.Error())
+				} else if err == nil {
+					if err := sender.UpdateSSHKey(key); err != nil {
+						fmt.Println(err.Error())
+					} else {
+						fmt.Printf("SSH key updated, version %d\n", key.Version)
+					}
+				}
+			}
+		case "ssh-del":
+			if len(commands) != 2 {
+				fmt.Println("Enter ssh key identifier!")
+			} else {
+				err := sender.DelSSHKey(commands[1])
+				if err != nil {
+					fmt.Println(err.Error())
+				} else {
+					fmt.Println("SSH key moved to trash!")
+				}
+			}
+		case "types":
+			printRecordTypes(sender)
+		case "type-add":
+			addRecordType(sender, commands[1:])
+		case "type":
+			printRecordType(sender, commands[1:])
+		case "type-edit":
+			editRecordType(sender, commands[1:])
+		case "type-del":
+			deleteRecordType(sender, commands[1:])
+		case "custom-add":
+			addCustomRecord(sender, commands[1:])
+		case "custom":
+			printCustomRecord(sender, commands[1:])
+		case "custom-edit":
+			editCustomRecord(sender, commands[1:])
+		case "custom-del":
+			if len(commands) != 2 {
+				fmt.Println("Enter record identifier!")
+			} else {
+				err := sender.DelCustomRecord(commands[1])
+				if err != nil {
+					fmt.Println(err.Error())
+				} else {
+					fmt.Println("Record moved to trash!")
+				}
+			}
+		case "agent-start":
+			startAgent(commands[1:])
+		case "agent-stop":
+			stopAgent()
+		case "agent-add":
+			agentAdd(sender, commands[1:])
+		case "agent-del":
+			agentRemove(sender, commands[1:])
+		case "agent":
+			printAgent()
+		}
+	}
+}
+
+// unlock - asks the master password and unlocks the vault
+func unlock(sender api.VaultSender) {
+	masterPwd, err := masterPassword()
+	if err != nil {
+		return
+	}
+	fmt.Println("")
+	if err := sender.Unlock(masterPwd); err != nil {
+		fmt.Println(err.Error())
+		fmt.Println("Vault is locked, use unlock to try again")
+		return
+	}
+	fmt.Println("Vault unlocked!")
+}
+
+// auditPageSize - the number of the audit events printed by one command
+const auditPageSize = 20
+
+// printAudit - prints the page of the audit log, the optional argument is the cursor printed with the previous page
+func printAudit(sender api.VaultSender, args []string) {
+	var before int64
+	if len(args) > 0 {
+		var err error
+		before, err = strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			fmt.Println("Enter the cursor of the page!")
+			return
+		}
+	}
+	page, err := sender.AuditEvents(before, auditPageSize)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if len(page.Events) == 0 {
+		fmt.Println("No events")
+		return
+	}
+	for _, event := range page.Events {
+		record := ""
+		if event.RecordID != "" {
+			record = fmt.Sprintf(" %s %s", event.Kind, event.RecordID)
+		}
+		fmt.Printf("%s - %s %s%s over %s from %s\n", event.CreatedAt.Format(time.DateTime),
+			event.Actor, event.Action, record, event.Transport, event.IP)
+	}
+	if page.Next != 0 {
+		fmt.Printf("More: audit %d\n", page.Next)
+	}
+}
+
+// listPageSize - the number of the records printed by one list command
+const listPageSize = 20
+
+// printRecords - prints the page of the records of the current vault or folder as a table. The optional arguments are the sorting (created or id),
+// the order (asc or desc), the filters tag:<tag> and field:<name>[=<value>] and the cursor printed with the previous page,
+// in any order
+func printRecords(command string, list func(opts models.ListOptions) (*models.RecordPage, error), args []string) {
+	// The records of the current vault or folder, on the top level all the records
+	target := current.target()
+	opts := models.ListOptions{Limit: listPageSize, Vault: target.Vault, Folder: target.Folder}
+	var options []string
+	for _, arg := range args {
+		switch arg {
+		case "":
+			continue
+		case models.SortCreated, models.SortID:
+			opts.Sort = arg
+		case "asc":
+			opts.Desc = false
+		case "desc":
+			opts.Desc = true
+		default:
+			if tag, ok := strings.CutPrefix(arg, "tag:"); ok {
+				opts.Tag = tag
+			} else if field, ok := strings.CutPrefix(arg, "field:"); ok {
+				opts.Field, opts.Value, _ = strings.Cut(field, "=")
+			} else {
+				opts.After = arg
+				continue
+			}
 		}
+		options = append(options, arg)
+	}
+
+	page, err := list(opts)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if len(page.Records) == 0 {
+		fmt.Println("No records")
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ID\tNOTE\tTAGS\tFIELDS")
+	for _, record := range page.Records {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", record.ID, record.Note, strings.Join(record.Tags, ", "),
+			formatFields(record.Fields))
+	}
+	table.Flush()
+	if page.Next != "" {
+		fmt.Printf("More: %s\n", strings.Join(append(append([]string{command}, options...), page.Next), " "))
+	}
+}
+
+// printCard - prints the card with the brand and the expiry, the number and the CVV are masked unless show is set
+func printCard(card *models.Card, show bool) {
+	number, cvv := card.Number, card.CVV
+	if !show {
+		number, cvv = maskField(models.KindCard, "number", number), maskField(models.KindCard, "cvv", cvv)
+	}
+	expiry := card.Date
+	if parsed, err := models.ParseCardExpiry(card.Date); err == nil && parsed.Expired(time.Now()) {
+		expiry += " (expired)"
+	}
+	brand := models.CardNumber(card.Number).Brand()
+	if brand == models.BrandUnknown {
+		brand = "unknown"
+	}
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "ID\t%s\n", card.ID)
+	fmt.Fprintf(table, "Brand\t%s\n", brand)
+	fmt.Fprintf(table, "FIO\t%s\n", card.FIO)
+	fmt.Fprintf(table, "Number\t%s\n", number)
+	fmt.Fprintf(table, "Expiry\t%s\n", expiry)
+	fmt.Fprintf(table, "CVV\t%s\n", cvv)
+	fmt.Fprintf(table, "Note\t%s\n", card.Note)
+	fmt.Fprintf(table, "Tags\t%s\n", strings.Join(card.Tags, ", "))
+	fmt.Fprintf(table, "Fields\t%s\n", formatFields(card.Fields))
+	fmt.Fprintf(table, "Version\t%d\n", card.Version)
+	table.Flush()
+}
+
+// printLogin - prints the login, the password and the TOTP secret are masked unless show is set
+func printLogin(login *models.Login, show bool) {
+	password, otp := login.Password, login.TOTP
+	if !show {
+		password, otp = maskField(models.KindLogin, "password", password), maskField(models.KindLogin, "totp", otp)
+	}
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "ID\t%s\n", login.ID)
+	fmt.Fprintf(table, "Login\t%s\n", login.Login)
+	fmt.Fprintf(table, "Password\t%s\n", password)
+	fmt.Fprintf(table, "TOTP\t%s\n", otp)
+	fmt.Fprintf(table, "Note\t%s\n", login.Note)
+	fmt.Fprintf(table, "Tags\t%s\n", strings.Join(login.Tags, ", "))
+	fmt.Fprintf(table, "Fields\t%s\n", formatFields(login.Fields))
+	fmt.Fprintf(table, "Version\t%d\n", login.Version)
+	table.Flush()
+}
+
+// printTOTP - prints the current one-time code of the login and the seconds until it expires.
+// The code is computed on the client, the secret is not shown
+func printTOTP(sender api.VaultSender, loginID string) {
+	login, err := sender.Login(loginID)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if login.TOTP == "" {
+		fmt.Println("The login has no TOTP secret, add it with login-edit!")
+		return
+	}
+	key, err := totp.ParseKey(login.TOTP)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	now := time.Now()
+	code, err := key.Code(now)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	fmt.Printf("Code: %s, expires in %d s\n", code, int(key.Remaining(now).Seconds()))
+}
+
+// printTrash - prints the deleted records as a table, the recently deleted first
+func printTrash(sender api.VaultSender) {
+	items, err := sender.Trash()
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if len(items) == 0 {
+		fmt.Println("Trash is empty")
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "KIND\tID\tNOTE\tDELETED")
+	for _, item := range items {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", item.Kind, item.ID, item.Note, item.DeletedAt.Local().Format(time.DateTime))
+	}
+	table.Flush()
+}
+
+// enableTwoFactor - shows the new secret of the second factor, asks the code from the authenticator app
+// and prints the recovery codes
+func enableTwoFactor(sender api.VaultSender) {
+	setup, err := sender.SetupTOTP()
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	fmt.Println("Add the secret to your authenticator app:")
+	fmt.Printf("Secret: %s\n", setup.Secret)
+	fmt.Printf("URI: %s\n", setup.URI)
+
+	code, err := otpCode()
+	if err != nil {
+		return
+	}
+	codes, err := sender.ConfirmTOTP(code)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	fmt.Println("Two-factor authentication enabled!")
+	fmt.Println("Save the recovery codes, each of them can be used once instead of the code if the app is lost:")
+	for _, code := range codes {
+		fmt.Println(code)
 	}
 }
 
@@ -214,22 +789,85 @@ func completer(d prompt.Document) []prompt.Suggest {
 			s = []prompt.Suggest{
 				{Text: "register", Description: "Create new user"},
 				{Text: "signin", Description: "SignIn user"},
+				{Text: "logout", Description: "Logout, the current session is revoked"},
+				{Text: "password", Description: "Change the password, other sessions are revoked"},
+				{Text: "rename", Description: "Change the login"},
+				{Text: "account-delete", Description: "Delete the account with all records"},
+				{Text: "sessions", Description: "List active sessions"},
+				{Text: "session-revoke", Description: "Revoke session by identifier"},
+				{Text: "2fa-enable", Description: "Enable two-factor authentication with an authenticator app"},
+				{Text: "2fa-disable", Description: "Disable two-factor authentication"},
+				{Text: "lockouts", Description: "List lockouts of the account after failed sign-in attempts"},
+				{Text: "audit", Description: "List the audit log of the account: audit [cursor]"},
+				{Text: "unlock", Description: "Enter the master password to decrypt records"},
+				{Text: "lock", Description: "Forget the master password"},
+				{Text: "migrate", Description: "Encrypt a record saved before the encryption: migrate <card|login|text|bin|ssh|custom> <id>"},
 
-				{Text: "card-add", Description: "Add new card"},
-				{Text: "card", Description: "Get card data"},
-				{Text: "card-del", Description: "Delete card"},
+				{Text: "trash", Description: "List deleted records"},
+				{Text: "trash-restore", Description: "Restore a deleted record: trash-restore <card|login|text|bin|ssh|custom> <id>"},
+				{Text: "trash-empty", Description: "Remove deleted records for good"},
+				{Text: "history", Description: "List revisions of a record: history <card|login|text|bin|ssh|custom> <id>"},
+				{Text: "revision", Description: "Show a revision, secrets are masked: revision <kind> <id> <revision> [show]"},
+				{Text: "diff", Description: "Show changed fields: diff <kind> <id> <revision> <revision>"},
+				{Text: "restore", Description: "Restore a revision as the current version: restore <kind> <id> <revision>"},
 
-				{Text: "login-add", Description: "Add new login"},
-				{Text: "login", Description: "Get login data"},
-				{Text: "login-del", Description: "Delete login"},
+				{Text: "cd", Description: "Enter a vault or a folder, following commands are scoped to it: cd [/vault/folder|folder|..]"},
+				{Text: "pwd", Description: "Show the current vault and folder"},
+				{Text: "ls", Description: "List vaults on the top level or nested folders"},
+				{Text: "mkdir", Description: "Create a vault on the top level or a folder: mkdir <name>"},
+				{Text: "rmdir", Description: "Delete a vault or a folder, records are moved up: rmdir <path>"},
+				{Text: "rendir", Description: "Rename a vault or a folder: rendir <path> <name>"},
+				{Text: "mv", Description: "Move a record to a vault or a folder: mv <card|login|text|bin|ssh|custom> <id> <path>"},
+
+				{Text: "cards", Description: "List cards: cards [created|id] [asc|desc] [tag:<tag>] [field:<name>[=<value>]] [cursor]"},
+				{Text: "logins", Description: "List logins: logins [created|id] [asc|desc] [tag:<tag>] [field:<name>[=<value>]] [cursor]"},
+				{Text: "texts", Description: "List texts: texts [created|id] [asc|desc] [tag:<tag>] [field:<name>[=<value>]] [cursor]"},
+				{Text: "bins", Description: "List binaries: bins [created|id] [asc|desc] [tag:<tag>] [field:<name>[=<value>]] [cursor]"},
+				{Text: "ssh-keys", Description: "List ssh keys: ssh-keys [created|id] [asc|desc] [tag:<tag>] [field:<name>[=<value>]] [cursor]"},
+				{Text: "customs", Description: "List records of own types: customs [created|id] [asc|desc] [tag:<tag>] [field:<name>[=<value>]] [cursor]"},
+
+				{Text: "card-add", Description: "Add new card, the number, the expiry and the CVV are validated"},
+				{Text: "card", Description: "Get card data, the number and the CVV are masked: card <id> [show]"},
+				{Text: "card-edit", Description: "Edit card, empty input keeps the current value"},
+				{Text: "card-del", Description: "Move card to trash"},
+
+				{Text: "login-add", Description: "Add new login, the TOTP secret is optional"},
+				{Text: "login", Description: "Get login data, the password and the TOTP secret are masked: login <id> [show]"},
+				{Text: "totp", Description: "Show the one-time code of a login and the seconds until it expires: totp <id>"},
+				{Text: "login-edit", Description: "Edit login, empty input keeps the current value"},
+				{Text: "login-del", Description: "Move login to trash"},
 
 				{Text: "text-add", Description: "Add new text"},
 				{Text: "text", Description: "Get text data"},
-				{Text: "text-del", Description: "Delete text"},
+				{Text: "text-edit", Description: "Edit text, empty input keeps the current value"},
+				{Text: "text-del", Description: "Move text to trash"},
+
+				{Text: "bin-add", Description: "Add new binary with the content and the metadata of the file: bin-add <file>"},
+				{Text: "bin", Description: "Get binary metadata, or save its content to a new file: bin <id> [path]"},
+				{Text: "bin-edit", Description: "Edit binary, empty input keeps the current value"},
+				{Text: "bin-upload", Description: "Replace binary content with a file uploaded in chunks: bin-upload <id> <file>"},
+				{Text: "bin-download", Description: "Save binary content to a new file: bin-download <id> <file>"},
+				{Text: "bin-del", Description: "Move binary to trash"},
+
+				{Text: "ssh-add", Description: "Add new ssh key from its files, the public key is derived if omitted"},
+				{Text: "ssh", Description: "Get ssh key with the fingerprint, the private key is masked: ssh <id> [show]"},
+				{Text: "ssh-edit", Description: "Edit ssh key, empty input keeps the current value"},
+				{Text: "ssh-del", Description: "Move ssh key to trash"},
+				{Text: "agent-start", Description: "Start the ssh-agent serving the keys from memory: agent-start [socket]"},
+				{Text: "agent-add", Description: "Load ssh key into the agent: agent-add <id>"},
+				{Text: "agent-del", Description: "Unload ssh key from the agent: agent-del <id>"},
+				{Text: "agent", Description: "Show the agent socket and the loaded keys"},
+				{Text: "agent-stop", Description: "Stop the agent, the keys are forgotten"},
 
-				{Text: "bin-add", Description: "Add new binary"},
-				{Text: "bin", Description: "Get binary data"},
-				{Text: "bin-del", Description: "Delete binary"},
+				{Text: "types", Description: "List own record types with their fields"},
+				{Text: "type-add", Description: "Define a record type, fields are asked as name:type[:secret][:required]: type-add <name>"},
+				{Text: "type", Description: "Show the fields of a record type: type <name>"},
+				{Text: "type-edit", Description: "Edit the fields of a record type, - removes a field: type-edit <name>"},
+				{Text: "type-del", Description: "Delete a record type without records: type-del <name>"},
+				{Text: "custom-add", Description: "Add a record of own type, values are asked by its fields: custom-add <type>"},
+				{Text: "custom", Description: "Get a record of own type, secret values are masked: custom <id> [show]"},
+				{Text: "custom-edit", Description: "Edit a record of own type, empty input keeps the current value"},
+				{Text: "custom-del", Description: "Move a record of own type to trash"},
 
 				{Text: "help", Description: "List all available commands"},
 				{Text: "version", Description: "Client version"},
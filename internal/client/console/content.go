@@ -0,0 +1,252 @@
+package console
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ncyellow/GophKeeper/internal/client/api"
+	"github.com/ncyellow/GophKeeper/internal/models"
+)
+
+// sniffLen - how many first bytes of the file are used to detect its MIME type
+const sniffLen = 512
+
+// addBinary - adds the binary with the content and the metadata of the file. The file up to one chunk
+// is sent in the record, the larger one is uploaded in chunks after the record is added
+func addBinary(sender api.VaultSender, target models.Location, args []string) {
+	if len(args) != 1 {
+		fmt.Println("Enter the path of the file!")
+		return
+	}
+	file, err := os.Open(args[0])
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	defer file.Close()
+
+	bin, err := readBinary(filepath.Base(args[0]))
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if err := describeFile(bin, file); err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	bin.Vault, bin.Folder = target.Vault, target.Folder
+	chunked := bin.FileSize > models.BinaryChunkSize
+	if !chunked {
+		if bin.Data, err = io.ReadAll(file); err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+	}
+	if err := sender.AddBin(bin); err != nil {
+		fmt.Println("")
+		fmt.Println(err.Error())
+		return
+	}
+	if chunked {
+		fmt.Printf("Bin with ID - %s added, uploading the content\n", bin.ID)
+		uploadBinary(sender, []string{bin.ID, args[0]})
+		return
+	}
+	fmt.Println("")
+	fmt.Printf("Bin with ID - %s successfully added\n", bin.ID)
+}
+
+// uploadBinary - replaces the content of the binary with the file, the file is uploaded in chunks
+// and is not read into memory. The interrupted upload is resumed from the chunks the server received.
+// The metadata of the binary is replaced with the one of the file after the upload
+func uploadBinary(sender api.VaultSender, args []string) {
+	if len(args) != 2 {
+		fmt.Println("Enter file identifier and the path of the file!")
+		return
+	}
+	file, err := os.Open(args[1])
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	defer file.Close()
+
+	bin, err := sender.Bin(args[0])
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	info := *bin
+	if err := describeFile(&info, file); err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if err := sender.UploadBin(bin, file); err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if bin.FileName != info.FileName || bin.FileSize != info.FileSize || bin.MimeType != info.MimeType ||
+		!sameTime(bin.ModTime, info.ModTime) {
+		bin.FileName, bin.FileSize, bin.MimeType, bin.ModTime = info.FileName, info.FileSize, info.MimeType, info.ModTime
+		if err := sender.UpdateBin(bin); err != nil {
+			fmt.Printf("Bin content uploaded, but the file metadata is not saved: %s\n", err.Error())
+			return
+		}
+	}
+	fmt.Printf("Bin content uploaded, %d bytes, version %d\n", bin.Size, bin.Version)
+}
+
+// downloadBinary - writes the content of the binary to the new file, see saveBinary
+func downloadBinary(sender api.VaultSender, args []string) {
+	if len(args) != 2 {
+		fmt.Println("Enter file identifier and the path of the file!")
+		return
+	}
+	bin, err := sender.Bin(args[0])
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	path, err := saveBinary(sender, bin, args[1])
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	fmt.Printf("Bin content saved to %s\n", path)
+}
+
+// showBinary - prints the metadata of the binary, or saves its content if the path is given: bin <id> [path]
+func showBinary(sender api.VaultSender, args []string) {
+	if len(args) != 1 && len(args) != 2 {
+		fmt.Println("Enter file identifier!")
+		return
+	}
+	if len(args) == 2 {
+		downloadBinary(sender, args)
+		return
+	}
+	bin, err := sender.Bin(args[0])
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	printBinary(bin)
+}
+
+// saveBinary - writes the content of the binary to the new file readable only by the user,
+// the existing file is not overwritten. If the path is a directory, the file gets the original name of the binary.
+// The file of the failed download is removed, the saved one gets the original modification time.
+// Returns the path of the file
+func saveBinary(sender api.VaultSender, bin *models.Binary, path string) (string, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		// Only the base name is taken, the name from the server can not point out of the directory
+		name := filepath.Base(filepath.Clean(bin.FileName))
+		if bin.FileName == "" || name == "." || name == ".." || name == string(filepath.Separator) {
+			return "", errors.New("the binary has no file name, enter the path of the file")
+		}
+		path = filepath.Join(path, name)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", err
+	}
+	err = sender.DownloadBin(bin, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	if bin.ModTime != nil {
+		if err := os.Chtimes(path, *bin.ModTime, *bin.ModTime); err != nil {
+			return "", err
+		}
+	}
+	return path, nil
+}
+
+// printBinary - prints the metadata of the binary, the content is saved by bin <id> <path>
+func printBinary(bin *models.Binary) {
+	size := bin.FileSize
+	if size == 0 && bin.ContentID != "" {
+		size = bin.Size
+	} else if size == 0 {
+		size = int64(len(bin.Data))
+	}
+	modified := ""
+	if bin.ModTime != nil {
+		modified = bin.ModTime.Local().Format(time.DateTime)
+	}
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "ID\t%s\n", bin.ID)
+	fmt.Fprintf(table, "File Name\t%s\n", bin.FileName)
+	fmt.Fprintf(table, "Size\t%d\n", size)
+	fmt.Fprintf(table, "MIME Type\t%s\n", bin.MimeType)
+	fmt.Fprintf(table, "Modified\t%s\n", modified)
+	fmt.Fprintf(table, "Note\t%s\n", bin.Note)
+	fmt.Fprintf(table, "Tags\t%s\n", strings.Join(bin.Tags, ", "))
+	fmt.Fprintf(table, "Fields\t%s\n", formatFields(bin.Fields))
+	fmt.Fprintf(table, "Version\t%d\n", bin.Version)
+	table.Flush()
+}
+
+// readFile - reads the file into the data of the binary and sets its metadata
+func readFile(bin *models.Binary, name string) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := describeFile(bin, file); err != nil {
+		return err
+	}
+	bin.Data, err = io.ReadAll(file)
+	return err
+}
+
+// describeFile - sets the metadata of the binary from the open file and rewinds the file.
+// The MIME type is sniffed from the first bytes, the extension is used if they say nothing
+func describeFile(bin *models.Binary, file *os.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", file.Name())
+	}
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	mimeType := http.DetectContentType(head[:n])
+	if byExt := mime.TypeByExtension(filepath.Ext(file.Name())); mimeType == "application/octet-stream" && byExt != "" {
+		mimeType = byExt
+	}
+	// The time is kept with the precision of seconds, as gRPC sends it
+	modTime := info.ModTime().Truncate(time.Second).UTC()
+	bin.FileName, bin.FileSize, bin.MimeType, bin.ModTime = filepath.Base(file.Name()), info.Size(), mimeType, &modTime
+	return nil
+}
+
+// sameTime - compares the optional times
+func sameTime(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
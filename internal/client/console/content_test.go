@@ -0,0 +1,46 @@
+package console
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+)
+
+func TestDescribeFile(t *testing.T) {
+	dir := t.TempDir()
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 1000)...)
+	name := filepath.Join(dir, "photo.bin")
+	require.NoError(t, os.WriteFile(name, png, 0o600))
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 600, time.Local)
+	require.NoError(t, os.Chtimes(name, modTime, modTime))
+
+	file, err := os.Open(name)
+	require.NoError(t, err)
+	defer file.Close()
+
+	// The type is sniffed from the content whatever the extension is, the file is read from the start after that
+	var bin models.Binary
+	require.NoError(t, describeFile(&bin, file))
+	assert.Equal(t, "photo.bin", bin.FileName)
+	assert.Equal(t, int64(len(png)), bin.FileSize)
+	assert.Equal(t, "image/png", bin.MimeType)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local).UTC(), *bin.ModTime)
+	data, err := io.ReadAll(file)
+	require.NoError(t, err)
+	assert.Equal(t, png, data)
+
+	// The extension is used when the content says nothing
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "doc.pdf"), []byte{0, 1, 2}, 0o600))
+	require.NoError(t, readFile(&bin, filepath.Join(dir, "doc.pdf")))
+	assert.Equal(t, "application/pdf", bin.MimeType)
+	assert.Equal(t, []byte{0, 1, 2}, bin.Data)
+
+	assert.Error(t, readFile(&bin, dir))
+}
@@ -0,0 +1,361 @@
+package console
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ncyellow/GophKeeper/internal/client/api"
+	"github.com/ncyellow/GophKeeper/internal/models"
+)
+
+// printRecordTypes - prints the record types of the user with their fields as name:type[:secret][:required]
+func printRecordTypes(sender api.VaultSender) {
+	recordTypes, err := sender.RecordTypes()
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if len(recordTypes) == 0 {
+		fmt.Println("No record types, define one with type-add")
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "NAME\tFIELDS")
+	for _, recordType := range recordTypes {
+		fields := make([]string, 0, len(recordType.Fields))
+		for _, field := range recordType.Fields {
+			fields = append(fields, field.String())
+		}
+		fmt.Fprintf(table, "%s\t%s\n", recordType.Name, strings.Join(fields, ", "))
+	}
+	table.Flush()
+}
+
+// printRecordType - prints the fields of the record type in the order they are asked
+func printRecordType(sender api.VaultSender, args []string) {
+	name := strings.Join(args, " ")
+	if name == "" {
+		fmt.Println("Enter record type name!")
+		return
+	}
+	recordType, err := sender.RecordType(name)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "FIELD\tTYPE\tSECRET\tREQUIRED")
+	for _, field := range recordType.Fields {
+		fmt.Fprintf(table, "%s\t%s\t%t\t%t\n", field.Name, field.Type, field.Secret, field.Required)
+	}
+	table.Flush()
+}
+
+// addRecordType - defines the record type, the fields are asked one by one until the empty input
+func addRecordType(sender api.VaultSender, args []string) {
+	name := strings.Join(args, " ")
+	if name == "" {
+		fmt.Println("Enter record type name!")
+		return
+	}
+	recordType := models.RecordType{Name: name}
+	if err := readTypeFields(bufio.NewReader(os.Stdin), &recordType); err != nil {
+		return
+	}
+	if err := sender.AddRecordType(recordType); err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	fmt.Printf("Record type %s added\n", recordType.Name)
+}
+
+// editRecordType - asks the new definition of every field, the empty input keeps it and - removes it,
+// then the new fields are asked until the empty input. While the type has records its fields can not be removed
+// or change the secrecy
+func editRecordType(sender api.VaultSender, args []string) {
+	name := strings.Join(args, " ")
+	if name == "" {
+		fmt.Println("Enter record type name!")
+		return
+	}
+	recordType, err := sender.RecordType(name)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	fields := recordType.Fields
+	recordType.Fields = nil
+	for _, field := range fields {
+		for {
+			line := field.String()
+			if err := editLine(reader, "Field", &line); err != nil {
+				return
+			}
+			if line == clearValue {
+				break
+			}
+			edited, err := models.ParseTypeField(line)
+			if err != nil {
+				fmt.Println(err.Error())
+				continue
+			}
+			recordType.Fields = append(recordType.Fields, edited)
+			break
+		}
+	}
+	if err := readTypeFields(reader, recordType); err != nil {
+		return
+	}
+	err = sender.UpdateRecordType(*recordType)
+	if errors.Is(err, api.ErrRecordTypeInUse) {
+		fmt.Println("The type has records, its fields can not be removed or made secret or public")
+		return
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	fmt.Printf("Record type %s updated\n", recordType.Name)
+}
+
+// deleteRecordType - deletes the record type, the type with records is kept
+func deleteRecordType(sender api.VaultSender, args []string) {
+	name := strings.Join(args, " ")
+	if name == "" {
+		fmt.Println("Enter record type name!")
+		return
+	}
+	err := sender.DeleteRecordType(name)
+	if errors.Is(err, api.ErrRecordTypeInUse) {
+		fmt.Println("The type has records, delete them and empty the trash first")
+		return
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	fmt.Printf("Record type %s deleted\n", name)
+}
+
+// readTypeFields - reads the new fields of the record type until the empty input, the invalid field is asked again
+func readTypeFields(reader *bufio.Reader, recordType *models.RecordType) error {
+	fmt.Printf("Field types: %s. Secret fields are encrypted and masked\n", fieldTypeList())
+	for {
+		fmt.Print("Enter Field (name:type[:secret][:required]) [done]: ")
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if line = strings.TrimSpace(line); line == "" {
+			return nil
+		}
+		field, err := models.ParseTypeField(line)
+		if err != nil {
+			fmt.Println(err.Error())
+			continue
+		}
+		recordType.Fields = append(recordType.Fields, field)
+	}
+}
+
+// fieldTypeList - the types of the fields for the prompts
+func fieldTypeList() string {
+	types := make([]string, 0, len(models.FieldTypes))
+	for _, fieldType := range models.FieldTypes {
+		types = append(types, string(fieldType))
+	}
+	return strings.Join(types, ", ")
+}
+
+// fieldLabel - the name of the field with its type for the prompts
+func fieldLabel(field models.TypeField) string {
+	label := fmt.Sprintf("%s (%s", field.Name, field.Type)
+	if field.Required {
+		label += ", required"
+	}
+	return label + ")"
+}
+
+// addCustomRecord - asks the values of the record by the schema of the type, the secret ones without echo.
+// The record is checked by the schema before it is encrypted
+func addCustomRecord(sender api.VaultSender, args []string) {
+	name := strings.Join(args, " ")
+	if name == "" {
+		fmt.Println("Enter record type name!")
+		return
+	}
+	recordType, err := sender.RecordType(name)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Print("Enter ID: ")
+	recordID, err := reader.ReadString('\n')
+	if err != nil {
+		return
+	}
+	record := models.CustomRecord{ID: strings.TrimSpace(recordID), Type: recordType.Name}
+	for _, field := range recordType.Fields {
+		var value string
+		if field.Secret {
+			if value, err = hiddenInput(fmt.Sprintf("Enter %s: ", fieldLabel(field))); err != nil {
+				return
+			}
+		} else {
+			fmt.Printf("Enter %s: ", fieldLabel(field))
+			if value, err = reader.ReadString('\n'); err != nil {
+				return
+			}
+		}
+		record.SetValue(field, strings.TrimSpace(value))
+	}
+	fmt.Print("Enter Note: ")
+	note, err := reader.ReadString('\n')
+	if err != nil {
+		return
+	}
+	record.Note = strings.TrimSpace(note)
+	if record.Tags, record.Fields, err = readMeta(reader); err != nil {
+		return
+	}
+	if err := record.Validate(recordType); err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	target := current.target()
+	record.Vault, record.Folder = target.Vault, target.Folder
+	if err := sender.AddCustomRecord(&record); err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	fmt.Printf("%s with ID - %s successfully added\n", record.Type, record.ID)
+}
+
+// editCustomRecord - asks the new values of the record by the schema of the type, the empty input keeps
+// the current value and - clears it. The secret values are asked without echo. The values of the fields
+// removed from the type are dropped
+func editCustomRecord(sender api.VaultSender, args []string) {
+	if len(args) != 1 {
+		fmt.Println("Enter record identifier!")
+		return
+	}
+	record, err := sender.CustomRecord(args[0])
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	recordType, err := sender.RecordType(record.Type)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	// The values are saved by the current schema, the ones of the removed fields are dropped
+	values, secrets := record.Values, record.Secrets
+	record.Values, record.Secrets = nil, nil
+	reader := bufio.NewReader(os.Stdin)
+	for _, field := range recordType.Fields {
+		value, ok := secrets[field.Name]
+		if !ok {
+			value = values[field.Name]
+		}
+		if field.Secret {
+			line, err := hiddenInput(fmt.Sprintf("Enter %s [keep current, - removes]: ", fieldLabel(field)))
+			if err != nil {
+				return
+			}
+			if line != "" {
+				value = line
+			}
+		} else if err := editLine(reader, fieldLabel(field), &value); err != nil {
+			return
+		}
+		if value == clearValue {
+			value = ""
+		}
+		record.SetValue(field, value)
+	}
+	if err := editLine(reader, "Note", &record.Note); err != nil {
+		return
+	}
+	if err := editMeta(reader, &record.Tags, &record.Fields); err != nil {
+		return
+	}
+	if err := record.Validate(recordType); err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if err := sender.UpdateCustomRecord(record); err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	fmt.Printf("%s updated, version %d\n", record.Type, record.Version)
+}
+
+// printCustomRecord - prints the record with the values in the order of the fields of its type,
+// the secret values are masked unless show is set. The values of the fields removed from the type are printed last
+func printCustomRecord(sender api.VaultSender, args []string) {
+	if len(args) != 1 && (len(args) != 2 || args[1] != "show") {
+		fmt.Println("Enter record identifier!")
+		return
+	}
+	record, err := sender.CustomRecord(args[0])
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	// The type may be changed since the record was saved, the values are printed as they are kept
+	recordType, err := sender.RecordType(record.Type)
+	if err != nil {
+		recordType = &models.RecordType{Name: record.Type}
+	}
+	show := len(args) == 2
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "ID\t%s\n", record.ID)
+	fmt.Fprintf(table, "Type\t%s\n", record.Type)
+	printed := make(map[string]bool)
+	for _, field := range recordType.Fields {
+		value := record.Value(field)
+		if field.Secret && !show && value != "" {
+			value = "********"
+		}
+		fmt.Fprintf(table, "%s\t%s\n", field.Name, value)
+		printed[field.Name] = true
+	}
+	for _, secret := range []bool{false, true} {
+		values := record.Values
+		if secret {
+			values = record.Secrets
+		}
+		names := make([]string, 0, len(values))
+		for name := range values {
+			if !printed[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			value := values[name]
+			if secret && !show {
+				value = "********"
+			}
+			fmt.Fprintf(table, "%s\t%s\n", name, value)
+		}
+	}
+	fmt.Fprintf(table, "Note\t%s\n", record.Note)
+	fmt.Fprintf(table, "Tags\t%s\n", strings.Join(record.Tags, ", "))
+	fmt.Fprintf(table, "Fields\t%s\n", formatFields(record.Fields))
+	fmt.Fprintf(table, "Version\t%d\n", record.Version)
+	table.Flush()
+}
@@ -0,0 +1,189 @@
+package console
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ncyellow/GophKeeper/internal/client/api"
+	"github.com/ncyellow/GophKeeper/internal/models"
+)
+
+// fieldChange - the change of the field between two revisions, the values are already masked
+type fieldChange struct {
+	Field string
+	From  string
+	To    string
+}
+
+// publicFields - the fields of the records shown as is, the others are secrets
+var publicFields = map[string]bool{"note": true, "public_key": true, "comment": true, "fingerprint": true,
+	"type": true, "values": true}
+
+// maskField - hides the value of the secret field, only the last digits of the card number are shown
+func maskField(kind models.Kind, field string, value string) string {
+	if publicFields[field] || value == "" {
+		return value
+	}
+	if kind == models.KindCard && field == "number" && len(value) > 4 {
+		return models.CardNumber(value).Masked()
+	}
+	return "********"
+}
+
+// diffFields - the changed fields of the revisions ordered by name. The values of the secret fields are not shown,
+// only the fact of the change
+func diffFields(kind models.Kind, from map[string]string, to map[string]string) []fieldChange {
+	names := make(map[string]bool)
+	for name := range from {
+		names[name] = true
+	}
+	for name := range to {
+		names[name] = true
+	}
+
+	var changes []fieldChange
+	for name := range names {
+		if from[name] == to[name] {
+			continue
+		}
+		change := fieldChange{Field: name, From: "changed", To: "changed"}
+		if publicFields[name] {
+			change.From, change.To = from[name], to[name]
+		}
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
+
+// parseRevisionArgs - reads the kind, the id and the revision ids of the history commands
+func parseRevisionArgs(args []string, revisions int) (models.Kind, string, []int64, error) {
+	if len(args) != 2+revisions {
+		return "", "", nil, fmt.Errorf("enter record kind, identifier and %d revision(s)", revisions)
+	}
+	kind, err := models.ParseKind(args[0])
+	if err != nil {
+		return "", "", nil, err
+	}
+	ids := make([]int64, 0, revisions)
+	for _, arg := range args[2:] {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return "", "", nil, fmt.Errorf("invalid revision %q", arg)
+		}
+		ids = append(ids, id)
+	}
+	return kind, args[1], ids, nil
+}
+
+// printHistory - prints the revisions of the record, the newest first
+func printHistory(sender api.VaultSender, args []string) {
+	kind, id, _, err := parseRevisionArgs(args, 0)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	revisions, err := sender.Revisions(kind, id)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if len(revisions) == 0 {
+		fmt.Println("No revisions")
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "REVISION\tVERSION\tACTION\tCREATED")
+	for _, revision := range revisions {
+		fmt.Fprintf(table, "%d\t%d\t%s\t%s\n", revision.ID, revision.Version, revision.Action,
+			revision.CreatedAt.Local().Format(time.DateTime))
+	}
+	table.Flush()
+}
+
+// printRevision - prints the fields of the revision, the secrets are masked unless the last argument is show
+func printRevision(sender api.VaultSender, args []string) {
+	show := len(args) == 4 && args[3] == "show"
+	if show {
+		args = args[:3]
+	}
+	kind, id, ids, err := parseRevisionArgs(args, 1)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	revision, err := sender.Revision(kind, id, ids[0])
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	fmt.Printf("Revision %d, version %d, %s at %s\n", revision.ID, revision.Version, revision.Action,
+		revision.CreatedAt.Local().Format(time.DateTime))
+
+	names := make([]string, 0, len(revision.Fields))
+	for name := range revision.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		value := revision.Fields[name]
+		if !show {
+			value = maskField(kind, name, value)
+		}
+		fmt.Fprintf(table, "%s\t%s\n", name, value)
+	}
+	table.Flush()
+}
+
+// printDiff - prints the fields changed between two revisions of the record
+func printDiff(sender api.VaultSender, args []string) {
+	kind, id, ids, err := parseRevisionArgs(args, 2)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	from, err := sender.Revision(kind, id, ids[0])
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	to, err := sender.Revision(kind, id, ids[1])
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	changes := diffFields(kind, from.Fields, to.Fields)
+	if len(changes) == 0 {
+		fmt.Println("No changes")
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "FIELD\tREVISION %d\tREVISION %d\n", from.ID, to.ID)
+	for _, change := range changes {
+		fmt.Fprintf(table, "%s\t%s\t%s\n", change.Field, change.From, change.To)
+	}
+	table.Flush()
+}
+
+// restoreRevision - makes the revision the current version of the record
+func restoreRevision(sender api.VaultSender, args []string) {
+	kind, id, ids, err := parseRevisionArgs(args, 1)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	version, err := sender.RestoreRevision(kind, id, ids[0])
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	fmt.Printf("Revision restored, version %d\n", version)
+}
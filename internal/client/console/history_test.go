@@ -0,0 +1,30 @@
+package console
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+)
+
+func TestMaskField(t *testing.T) {
+	assert.Equal(t, "****1111", maskField(models.KindCard, "number", "4111111111111111"))
+	assert.Equal(t, "********", maskField(models.KindCard, "cvv", "123"))
+	assert.Equal(t, "********", maskField(models.KindLogin, "password", "secret"))
+	assert.Equal(t, "work", maskField(models.KindLogin, "note", "work"))
+	assert.Equal(t, "", maskField(models.KindLogin, "password", ""))
+}
+
+func TestDiffFields(t *testing.T) {
+	from := map[string]string{"login": "user", "password": "old", "note": "work"}
+	to := map[string]string{"login": "user", "password": "new", "note": "home"}
+
+	// The secret values are not shown, only the public ones
+	assert.Equal(t, []fieldChange{
+		{Field: "note", From: "work", To: "home"},
+		{Field: "password", From: "changed", To: "changed"},
+	}, diffFields(models.KindLogin, from, to))
+
+	assert.Empty(t, diffFields(models.KindLogin, from, from))
+}
@@ -3,6 +3,7 @@ package console
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"golang.org/x/term"
 
 	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/totp"
 )
 
 // credentials - reads login and password from the console. If everything is ok, error will be nil
@@ -33,7 +35,98 @@ func credentials() (string, string, error) {
 	return strings.TrimSpace(username), strings.TrimSpace(password), nil
 }
 
-// readCard - reads card data from the console. If everything is ok, error will be nil
+// masterPassword - reads the master password of the vault from the console. It is never sent to the server
+func masterPassword() (string, error) {
+	fmt.Print("Enter Master Password: ")
+	bytePassword, err := term.ReadPassword(int(syscall.Stdin))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(bytePassword)), nil
+}
+
+// ErrPasswordMismatch - the new password and its repetition differ
+var ErrPasswordMismatch = errors.New("passwords do not match")
+
+// hiddenInput - reads a value without echo, e.g. a password
+func hiddenInput(prompt string) (string, error) {
+	fmt.Print(prompt)
+	value, err := term.ReadPassword(int(syscall.Stdin))
+	fmt.Println("")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(value)), nil
+}
+
+// passwordChange - reads the current password and the new one twice
+func passwordChange() (string, string, error) {
+	current, err := hiddenInput("Enter Current Password: ")
+	if err != nil {
+		return "", "", err
+	}
+	newPassword, err := hiddenInput("Enter New Password: ")
+	if err != nil {
+		return "", "", err
+	}
+	repeated, err := hiddenInput("Repeat New Password: ")
+	if err != nil {
+		return "", "", err
+	}
+	if newPassword != repeated {
+		return "", "", ErrPasswordMismatch
+	}
+	return current, newPassword, nil
+}
+
+// loginChange - reads the new login and the password confirming it
+func loginChange() (string, string, error) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Print("Enter New Username: ")
+	login, err := reader.ReadString('\n')
+	if err != nil {
+		return "", "", err
+	}
+	pwd, err := hiddenInput("Enter Password: ")
+	if err != nil {
+		return "", "", err
+	}
+	return strings.TrimSpace(login), pwd, nil
+}
+
+// accountDeletion - reads the password and asks to confirm the deletion. Returns false if it is not confirmed
+func accountDeletion() (string, bool, error) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Print("All records will be deleted, type yes to continue: ")
+	answer, err := reader.ReadString('\n')
+	if err != nil {
+		return "", false, err
+	}
+	if strings.TrimSpace(answer) != "yes" {
+		return "", false, nil
+	}
+	pwd, err := hiddenInput("Enter Password: ")
+	if err != nil {
+		return "", false, err
+	}
+	return pwd, true, nil
+}
+
+// otpCode - reads the code of the second factor or a recovery code from the console
+func otpCode() (string, error) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Print("Enter Code: ")
+	code, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(code), nil
+}
+
+// readCard - reads card data from the console and validates it. If everything is ok, error will be nil
 func readCard() (*models.Card, error) {
 	reader := bufio.NewReader(os.Stdin)
 
@@ -55,7 +148,7 @@ func readCard() (*models.Card, error) {
 		return nil, err
 	}
 
-	fmt.Print("Enter Date: ")
+	fmt.Print("Enter Date (MM/YY): ")
 	cardDate, err := reader.ReadString('\n')
 	if err != nil {
 		return nil, err
@@ -67,20 +160,31 @@ func readCard() (*models.Card, error) {
 		return nil, err
 	}
 
-	fmt.Print("Enter MetaInfo: ")
-	metaInfo, err := reader.ReadString('\n')
+	fmt.Print("Enter Note: ")
+	note, err := reader.ReadString('\n')
 	if err != nil {
 		return nil, err
 	}
 
-	return &models.Card{
-		ID:       strings.TrimSpace(cardID),
-		FIO:      strings.TrimSpace(fio),
-		Number:   strings.TrimSpace(number),
-		Date:     strings.TrimSpace(cardDate),
-		CVV:      strings.TrimSpace(cvv),
-		MetaInfo: strings.TrimSpace(metaInfo),
-	}, nil
+	tags, fields, err := readMeta(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	card := &models.Card{
+		ID:     strings.TrimSpace(cardID),
+		FIO:    strings.TrimSpace(fio),
+		Number: strings.TrimSpace(number),
+		Date:   strings.TrimSpace(cardDate),
+		CVV:    strings.TrimSpace(cvv),
+		Note:   strings.TrimSpace(note),
+		Tags:   tags,
+		Fields: fields,
+	}
+	if err := card.Validate(); err != nil {
+		return nil, err
+	}
+	return card, nil
 }
 
 // readLogin - reads login data from the console. If everything is ok, error will be nil
@@ -105,9 +209,25 @@ func readLogin() (*models.Login, error) {
 		return nil, err
 	}
 	password := string(bytePassword)
+	fmt.Println("")
 
-	fmt.Print("Enter MetaInfo: ")
-	metaInfo, err := reader.ReadString('\n')
+	secret, err := hiddenInput("Enter TOTP Secret or otpauth:// URI [none]: ")
+	if err != nil {
+		return nil, err
+	}
+	otp, err := totpURI(secret, strings.TrimSpace(login))
+	if err != nil {
+		fmt.Println(err.Error())
+		return nil, err
+	}
+
+	fmt.Print("Enter Note: ")
+	note, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	tags, fields, err := readMeta(reader)
 	if err != nil {
 		return nil, err
 	}
@@ -116,7 +236,10 @@ func readLogin() (*models.Login, error) {
 		ID:       strings.TrimSpace(cardID),
 		Login:    strings.TrimSpace(login),
 		Password: strings.TrimSpace(password),
-		MetaInfo: strings.TrimSpace(metaInfo),
+		TOTP:     otp,
+		Note:     strings.TrimSpace(note),
+		Tags:     tags,
+		Fields:   fields,
 	}, nil
 }
 
@@ -136,49 +259,310 @@ func readText() (*models.Text, error) {
 		return nil, err
 	}
 
-	fmt.Print("Enter MetaInfo: ")
-	metaInfo, err := reader.ReadString('\n')
+	fmt.Print("Enter Note: ")
+	note, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	tags, fields, err := readMeta(reader)
 	if err != nil {
 		return nil, err
 	}
 
 	return &models.Text{
-		ID:       strings.TrimSpace(cardID),
-		Content:  strings.TrimSpace(content),
-		MetaInfo: strings.TrimSpace(metaInfo),
+		ID:      strings.TrimSpace(cardID),
+		Content: strings.TrimSpace(content),
+		Note:    strings.TrimSpace(note),
+		Tags:    tags,
+		Fields:  fields,
 	}, nil
 }
 
-// readText - reads binary data from the console. If everything is ok, error will be nil
-func readBinary() (*models.Binary, error) {
+// readBinary - reads the binary record from the console, the content is the file. The empty ID is the name of the file.
+// If everything is ok, error will be nil
+func readBinary(fileName string) (*models.Binary, error) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Printf("Enter ID [%s]: ", fileName)
+	binID, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if binID = strings.TrimSpace(binID); binID == "" {
+		binID = fileName
+	}
+
+	fmt.Print("Enter Note: ")
+	note, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	tags, fields, err := readMeta(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Binary{
+		ID:     binID,
+		Note:   strings.TrimSpace(note),
+		Tags:   tags,
+		Fields: fields,
+	}, nil
+}
+
+// readSSHKey - reads the ssh key from the files of the private and the public keys. The public key is taken
+// from the .pub file next to the private one or derived from the private key if no file is given.
+// The keys are validated, the fingerprint is computed
+func readSSHKey() (*models.SSHKey, error) {
 	reader := bufio.NewReader(os.Stdin)
 
 	fmt.Print("Enter ID: ")
-	cardID, err := reader.ReadString('\n')
+	keyID, err := reader.ReadString('\n')
 	if err != nil {
 		return nil, err
 	}
 
-	fmt.Print("Enter Filename: ")
-	content, err := reader.ReadString('\n')
+	fmt.Print("Enter Private Key Filename: ")
+	filename, err := reader.ReadString('\n')
 	if err != nil {
 		return nil, err
 	}
-	content = strings.TrimSpace(content)
-	data, err := os.ReadFile(content)
+	filename = strings.TrimSpace(filename)
+	private, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
 
-	fmt.Print("Enter MetaInfo: ")
-	metaInfo, err := reader.ReadString('\n')
+	public, err := readPublicKey(reader, filename+".pub")
 	if err != nil {
 		return nil, err
 	}
 
-	return &models.Binary{
-		ID:       strings.TrimSpace(cardID),
-		Data:     data,
-		MetaInfo: strings.TrimSpace(metaInfo),
-	}, nil
+	fmt.Print("Enter Comment [from the public key]: ")
+	comment, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Print("Enter Note: ")
+	note, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	tags, fields, err := readMeta(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &models.SSHKey{
+		ID:         strings.TrimSpace(keyID),
+		PrivateKey: string(private),
+		PublicKey:  public,
+		Comment:    strings.TrimSpace(comment),
+		Note:       strings.TrimSpace(note),
+		Tags:       tags,
+		Fields:     fields,
+	}
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// readPublicKey - asks the file of the public key, the empty input takes the default file if it exists.
+// Returns the empty key if there is no file, then the key is derived from the private one
+func readPublicKey(reader *bufio.Reader, defaultFile string) (string, error) {
+	fmt.Printf("Enter Public Key Filename [%s or derive]: ", defaultFile)
+	filename, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	filename = strings.TrimSpace(filename)
+	if filename == "" {
+		if _, err := os.Stat(defaultFile); err != nil {
+			return "", nil
+		}
+		filename = defaultFile
+	}
+	public, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	return string(public), nil
+}
+
+// editCard - asks the new values of the card, the current values are shown in brackets and kept on empty input.
+// The number and the CVV are masked. The edited card is validated
+func editCard(card *models.Card) error {
+	reader := bufio.NewReader(os.Stdin)
+	fields := []struct {
+		name  string
+		value *string
+		shown string
+	}{
+		{"FIO", &card.FIO, card.FIO},
+		{"Number", &card.Number, models.CardNumber(card.Number).Masked()},
+		{"Date", &card.Date, card.Date},
+		{"CVV", &card.CVV, maskField(models.KindCard, "cvv", card.CVV)},
+		{"Note", &card.Note, card.Note},
+	}
+	for _, field := range fields {
+		if err := editShown(reader, field.name, field.shown, field.value); err != nil {
+			return err
+		}
+	}
+	if err := editMeta(reader, &card.Tags, &card.Fields); err != nil {
+		return err
+	}
+	return card.Validate()
+}
+
+// editLogin - asks the new values of the login, the password and the TOTP secret are hidden and kept on empty input
+func editLogin(login *models.Login) error {
+	reader := bufio.NewReader(os.Stdin)
+	if err := editLine(reader, "Login", &login.Login); err != nil {
+		return err
+	}
+
+	fmt.Print("Enter Password [keep current]: ")
+	bytePassword, err := term.ReadPassword(int(syscall.Stdin))
+	if err != nil {
+		return err
+	}
+	fmt.Println("")
+	if password := strings.TrimSpace(string(bytePassword)); password != "" {
+		login.Password = password
+	}
+
+	secret, err := hiddenInput("Enter TOTP Secret or otpauth:// URI [keep current, - removes]: ")
+	switch {
+	case err != nil:
+		return err
+	case secret == "-":
+		login.TOTP = ""
+	case secret != "":
+		otp, err := totpURI(secret, login.Login)
+		if err != nil {
+			fmt.Println(err.Error())
+			return err
+		}
+		login.TOTP = otp
+	}
+	if err := editLine(reader, "Note", &login.Note); err != nil {
+		return err
+	}
+	return editMeta(reader, &login.Tags, &login.Fields)
+}
+
+// totpURI - checks the TOTP secret of the login and brings it to the otpauth:// URI with all the parameters.
+// The bare secret gets the login as the account, the empty secret means the login has no second factor
+func totpURI(secret string, account string) (string, error) {
+	if secret == "" {
+		return "", nil
+	}
+	key, err := totp.ParseKey(secret)
+	if err != nil {
+		return "", err
+	}
+	if key.Account == "" {
+		key.Account = account
+	}
+	return key.URI(), nil
+}
+
+// editText - asks the new values of the text
+func editText(text *models.Text) error {
+	reader := bufio.NewReader(os.Stdin)
+	if err := editLine(reader, "Content", &text.Content); err != nil {
+		return err
+	}
+	if err := editLine(reader, "Note", &text.Note); err != nil {
+		return err
+	}
+	return editMeta(reader, &text.Tags, &text.Fields)
+}
+
+// editBinary - asks the file with the new data and the new note, the empty filename keeps the current data.
+// The file up to one chunk replaces the data of the binary, the path of the larger one is returned
+// to be uploaded in chunks after the update
+func editBinary(binary *models.Binary) (string, error) {
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Print("Enter Filename [keep current data]: ")
+	filename, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	var upload string
+	if filename = strings.TrimSpace(filename); filename != "" {
+		info, err := os.Stat(filename)
+		if err != nil {
+			return "", err
+		}
+		if info.Size() > models.BinaryChunkSize {
+			upload = filename
+		} else {
+			if err := readFile(binary, filename); err != nil {
+				return "", err
+			}
+			// The data replaces the uploaded content too
+			binary.ContentID, binary.Size = "", 0
+		}
+	}
+	if err := editLine(reader, "Note", &binary.Note); err != nil {
+		return "", err
+	}
+	return upload, editMeta(reader, &binary.Tags, &binary.Fields)
+}
+
+// editSSHKey - asks the files of the new keys, the empty private key filename keeps the current keys.
+// The public key of the new private key is read from the .pub file next to it or derived. The keys are validated
+func editSSHKey(key *models.SSHKey) error {
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Print("Enter Private Key Filename [keep current]: ")
+	filename, err := reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if filename = strings.TrimSpace(filename); filename != "" {
+		private, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		public, err := readPublicKey(reader, filename+".pub")
+		if err != nil {
+			return err
+		}
+		key.PrivateKey, key.PublicKey, key.Comment = string(private), public, ""
+	}
+	if err := editLine(reader, "Comment", &key.Comment); err != nil {
+		return err
+	}
+	if err := editLine(reader, "Note", &key.Note); err != nil {
+		return err
+	}
+	if err := editMeta(reader, &key.Tags, &key.Fields); err != nil {
+		return err
+	}
+	return key.Validate()
+}
+
+// editLine - reads the new value of the field, the empty input keeps the current value
+func editLine(reader *bufio.Reader, name string, value *string) error {
+	return editShown(reader, name, *value, value)
+}
+
+// editShown - reads the new value of the field showing the current one as shown, e.g. masked
+func editShown(reader *bufio.Reader, name string, shown string, value *string) error {
+	fmt.Printf("Enter %s [%s]: ", name, shown)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if line = strings.TrimSpace(line); line != "" {
+		*value = line
+	}
+	return nil
 }
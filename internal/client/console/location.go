@@ -0,0 +1,307 @@
+package console
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ncyellow/GophKeeper/internal/client/api"
+	"github.com/ncyellow/GophKeeper/internal/models"
+)
+
+// errNoSuchPath - the path does not name a vault or a folder of the user
+var errNoSuchPath = errors.New("no such vault or folder")
+
+// location - the vault and the folder the console commands are scoped to
+type location struct {
+	user string
+	// vault - the current vault, the zero ID is the top level with all the records
+	vault models.Vault
+	// folders - the folders from the root of the vault to the current one
+	folders []models.Folder
+}
+
+// current - the location of the signed in user
+var current location
+
+// path - the location as /vault/folder/..., the top level is /
+func (l location) path() string {
+	if l.vault.ID == 0 {
+		return "/"
+	}
+	names := []string{"", l.vault.Name}
+	for _, folder := range l.folders {
+		names = append(names, folder.Name)
+	}
+	return strings.Join(names, "/")
+}
+
+// target - the vault and the folder of the location as the destination of the record
+func (l location) target() models.Location {
+	target := models.Location{Vault: l.vault.ID}
+	if len(l.folders) > 0 {
+		target.Folder = l.folders[len(l.folders)-1].ID
+	}
+	return target
+}
+
+// enter - moves to the top level of the user and shows it in the prompt, the empty user hides the prompt
+func enter(user string) {
+	current = location{user: user}
+	LivePrefixState.IsEnable = user != ""
+	updatePrompt()
+}
+
+// updatePrompt - shows the user and the current path in the prompt
+func updatePrompt() {
+	LivePrefixState.LivePrefix = ""
+	if current.user != "" {
+		LivePrefixState.LivePrefix = fmt.Sprintf("%s:%s >>>", current.user, current.path())
+	}
+}
+
+// resolve - finds the location by the path. The absolute path starts from the top level, the relative one
+// from the location; .. is the parent folder, the vault or the top level. The vaults and the folders are read
+// only when the path names them
+func resolve(from location, path string, vaults func() ([]models.Vault, error),
+	folders func(vaultID int64) ([]models.Folder, error)) (location, error) {
+	to := location{user: from.user, vault: from.vault, folders: append([]models.Folder{}, from.folders...)}
+	if strings.HasPrefix(path, "/") {
+		to = location{user: from.user}
+	}
+
+	trees := make(map[int64][]models.Folder)
+	for _, name := range strings.Split(path, "/") {
+		switch {
+		case name == "" || name == ".":
+			continue
+		case name == "..":
+			if len(to.folders) > 0 {
+				to.folders = to.folders[:len(to.folders)-1]
+			} else {
+				to.vault = models.Vault{}
+			}
+			continue
+		case to.vault.ID == 0:
+			list, err := vaults()
+			if err != nil {
+				return from, err
+			}
+			found := false
+			for _, vault := range list {
+				if vault.Name == name {
+					to.vault, found = vault, true
+					break
+				}
+			}
+			if !found {
+				return from, fmt.Errorf("%w: %s", errNoSuchPath, name)
+			}
+			continue
+		}
+
+		tree, ok := trees[to.vault.ID]
+		if !ok {
+			list, err := folders(to.vault.ID)
+			if err != nil {
+				return from, err
+			}
+			tree, trees[to.vault.ID] = list, list
+		}
+		parent := to.target().Folder
+		found := false
+		for _, folder := range tree {
+			if folder.Parent == parent && folder.Name == name {
+				to.folders, found = append(to.folders, folder), true
+				break
+			}
+		}
+		if !found {
+			return from, fmt.Errorf("%w: %s", errNoSuchPath, name)
+		}
+	}
+	return to, nil
+}
+
+// resolveWith - resolves the path from the current location by the vaults and the folders of the server
+func resolveWith(sender api.VaultSender, path string) (location, error) {
+	return resolve(current, path, sender.Vaults, sender.Folders)
+}
+
+// changeDir - makes the location of the path the current one
+func changeDir(sender api.VaultSender, args []string) {
+	path := "/"
+	if len(args) > 0 {
+		path = args[0]
+	}
+	to, err := resolveWith(sender, path)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	current = to
+	updatePrompt()
+}
+
+// printDir - prints the vaults on the top level or the nested folders of the current folder
+func printDir(sender api.VaultSender) {
+	var names []string
+	if current.vault.ID == 0 {
+		vaults, err := sender.Vaults()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		for _, vault := range vaults {
+			names = append(names, vault.Name)
+		}
+	} else {
+		folders, err := sender.Folders(current.vault.ID)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		parent := current.target().Folder
+		for _, folder := range folders {
+			if folder.Parent == parent {
+				names = append(names, folder.Name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		fmt.Println("Empty")
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(table, "%s/\n", name)
+	}
+	table.Flush()
+}
+
+// makeDir - creates the folder in the current folder or the vault on the top level
+func makeDir(sender api.VaultSender, args []string) {
+	if len(args) != 1 || args[0] == "" || strings.Contains(args[0], "/") {
+		fmt.Println("Enter the name without slashes!")
+		return
+	}
+	var err error
+	if current.vault.ID == 0 {
+		_, err = sender.AddVault(args[0])
+	} else {
+		_, err = sender.AddFolder(models.Folder{Vault: current.vault.ID, Parent: current.target().Folder, Name: args[0]})
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	fmt.Printf("%s created!\n", args[0])
+}
+
+// removeDir - deletes the vault or the folder of the path, the records in them are moved up.
+// If the current location is inside it, the console moves to its parent
+func removeDir(sender api.VaultSender, args []string) {
+	if len(args) != 1 {
+		fmt.Println("Enter the path of the vault or the folder!")
+		return
+	}
+	target, err := resolveWith(sender, args[0])
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if target.vault.ID == 0 {
+		fmt.Println("The top level can not be removed!")
+		return
+	}
+
+	if len(target.folders) == 0 {
+		err = sender.DeleteVault(target.vault.ID)
+	} else {
+		err = sender.DeleteFolder(target.target().Folder)
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if contains(target, current) {
+		current = location{user: current.user}
+		if len(target.folders) > 0 {
+			current.vault, current.folders = target.vault, target.folders[:len(target.folders)-1]
+		}
+		updatePrompt()
+	}
+	fmt.Println("Removed, the records are moved up!")
+}
+
+// contains - checks that the location is the vault or the folder of the parent or is nested in it
+func contains(parent location, l location) bool {
+	if parent.vault.ID != l.vault.ID || len(l.folders) < len(parent.folders) {
+		return false
+	}
+	return len(parent.folders) == 0 || l.folders[len(parent.folders)-1].ID == parent.target().Folder
+}
+
+// renameDir - renames the vault or the folder of the path, the folder stays in its parent
+func renameDir(sender api.VaultSender, args []string) {
+	if len(args) != 2 || args[1] == "" || strings.Contains(args[1], "/") {
+		fmt.Println("Enter the path and the new name without slashes!")
+		return
+	}
+	target, err := resolveWith(sender, args[0])
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if target.vault.ID == 0 {
+		fmt.Println("The top level can not be renamed!")
+		return
+	}
+
+	if len(target.folders) == 0 {
+		err = sender.RenameVault(target.vault.ID, args[1])
+	} else {
+		folder := target.folders[len(target.folders)-1]
+		folder.Name = args[1]
+		err = sender.UpdateFolder(folder)
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	// The renamed vault or folder may be a part of the current path
+	if contains(target, current) {
+		if len(target.folders) == 0 {
+			current.vault.Name = args[1]
+		} else {
+			current.folders[len(target.folders)-1].Name = args[1]
+		}
+		updatePrompt()
+	}
+	fmt.Println("Renamed!")
+}
+
+// moveRecord - moves the record to the vault and the folder of the path
+func moveRecord(sender api.VaultSender, args []string) {
+	if len(args) != 3 {
+		fmt.Println("Enter record kind, identifier and path!")
+		return
+	}
+	kind, err := models.ParseKind(args[0])
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	target, err := resolveWith(sender, args[2])
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if err := sender.MoveRecord(kind, args[1], target.target()); err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	fmt.Printf("Record moved to %s\n", target.path())
+}
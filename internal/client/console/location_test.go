@@ -0,0 +1,70 @@
+package console
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+)
+
+func TestResolve(t *testing.T) {
+	vaults := func() ([]models.Vault, error) {
+		return []models.Vault{{ID: 1, Name: "home"}, {ID: 2, Name: "work"}}, nil
+	}
+	folders := func(vaultID int64) ([]models.Folder, error) {
+		if vaultID != 2 {
+			return nil, nil
+		}
+		return []models.Folder{
+			{ID: 5, Vault: 2, Name: "bank"},
+			{ID: 6, Vault: 2, Parent: 5, Name: "cards"},
+			{ID: 7, Vault: 2, Name: "cards"},
+		}, nil
+	}
+	top := location{user: "user"}
+
+	to, err := resolve(top, "/work/bank/cards", vaults, folders)
+	require.NoError(t, err)
+	assert.Equal(t, "/work/bank/cards", to.path())
+	assert.Equal(t, models.Location{Vault: 2, Folder: 6}, to.target())
+	assert.Equal(t, "user", to.user)
+
+	up, err := resolve(to, "../../cards", vaults, folders)
+	require.NoError(t, err)
+	assert.Equal(t, models.Location{Vault: 2, Folder: 7}, up.target())
+	// The resolved location does not share the folders with the previous one
+	assert.Equal(t, "/work/bank/cards", to.path())
+
+	root, err := resolve(to, "../..", vaults, folders)
+	require.NoError(t, err)
+	assert.Equal(t, "/work", root.path())
+	assert.Equal(t, models.Location{Vault: 2}, root.target())
+
+	home, err := resolve(root, "../home", vaults, folders)
+	require.NoError(t, err)
+	assert.Equal(t, models.Location{Vault: 1}, home.target())
+
+	_, err = resolve(root, "/work/missing", vaults, folders)
+	assert.ErrorIs(t, err, errNoSuchPath)
+	_, err = resolve(top, "bank", vaults, folders)
+	assert.ErrorIs(t, err, errNoSuchPath)
+
+	assert.Equal(t, "/", top.path())
+	assert.Equal(t, models.Location{}, top.target())
+}
+
+func TestContains(t *testing.T) {
+	work := location{vault: models.Vault{ID: 2}}
+	bank := location{vault: work.vault, folders: []models.Folder{{ID: 5}}}
+	cards := location{vault: work.vault, folders: []models.Folder{{ID: 5}, {ID: 6}}}
+	other := location{vault: work.vault, folders: []models.Folder{{ID: 7}}}
+
+	assert.True(t, contains(work, cards))
+	assert.True(t, contains(bank, cards))
+	assert.True(t, contains(bank, bank))
+	assert.False(t, contains(cards, bank))
+	assert.False(t, contains(bank, other))
+	assert.False(t, contains(location{vault: models.Vault{ID: 1}}, cards))
+}
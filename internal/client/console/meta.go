@@ -0,0 +1,90 @@
+package console
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// clearValue - the input clearing the tags or the fields on edit, the empty input keeps them
+const clearValue = "-"
+
+// parseTags - reads the comma separated tags, the empty and the repeated tags are skipped
+func parseTags(line string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, tag := range strings.Split(line, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// parseFields - reads the custom fields entered as name=value pairs separated by semicolons,
+// the name without the value is the field with the empty value
+func parseFields(line string) map[string]string {
+	var fields map[string]string
+	for _, pair := range strings.Split(line, ";") {
+		name, value, _ := strings.Cut(pair, "=")
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if fields == nil {
+			fields = make(map[string]string)
+		}
+		fields[name] = strings.TrimSpace(value)
+	}
+	return fields
+}
+
+// formatFields - the fields in the input format ordered by name
+func formatFields(fields map[string]string) string {
+	pairs := make([]string, 0, len(fields))
+	for name, value := range fields {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "; ")
+}
+
+// readMeta - reads the tags and the custom fields of the new record
+func readMeta(reader *bufio.Reader) ([]string, map[string]string, error) {
+	fmt.Print("Enter Tags (comma separated): ")
+	tags, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, nil, err
+	}
+	fmt.Print("Enter Fields (name=value; ...): ")
+	fields, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, nil, err
+	}
+	return parseTags(tags), parseFields(fields), nil
+}
+
+// editMeta - asks the new tags and fields of the record, the empty input keeps the current ones and - clears them
+func editMeta(reader *bufio.Reader, tags *[]string, fields *map[string]string) error {
+	line := strings.Join(*tags, ", ")
+	if err := editLine(reader, "Tags", &line); err != nil {
+		return err
+	}
+	if line == clearValue {
+		line = ""
+	}
+	*tags = parseTags(line)
+
+	line = formatFields(*fields)
+	if err := editLine(reader, "Fields", &line); err != nil {
+		return err
+	}
+	if line == clearValue {
+		line = ""
+	}
+	*fields = parseFields(line)
+	return nil
+}
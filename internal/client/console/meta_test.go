@@ -0,0 +1,22 @@
+package console
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTags(t *testing.T) {
+	assert.Equal(t, []string{"work", "bank"}, parseTags(" work, bank,,work "))
+	assert.Empty(t, parseTags(""))
+}
+
+func TestParseFields(t *testing.T) {
+	fields := parseFields("bank = test; pin; =skipped;; url=https://a.ru/?q=1")
+	assert.Equal(t, map[string]string{"bank": "test", "pin": "", "url": "https://a.ru/?q=1"}, fields)
+	assert.Nil(t, parseFields(" "))
+
+	// The formatted fields are read back as is
+	assert.Equal(t, fields, parseFields(formatFields(fields)))
+	assert.Equal(t, "bank=test; pin=; url=https://a.ru/?q=1", formatFields(fields))
+}
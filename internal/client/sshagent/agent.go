@@ -0,0 +1,177 @@
+// Package sshagent serves the ssh keys of the vault to the ssh clients over the agent protocol on a Unix socket.
+// The private keys are kept in memory only, they are never written to disk
+package sshagent
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+)
+
+// ErrReadOnly - the keys of the agent are managed by the console, the ssh clients can only list them and sign
+var ErrReadOnly = errors.New("the agent serves the keys of the vault, manage them in the console")
+
+// ErrRunning - the agent already listens on a socket
+var ErrRunning = errors.New("the agent is already running")
+
+// readOnly the keyring that refuses the changes requested by the ssh clients, e.g. ssh-add
+type readOnly struct {
+	agent.ExtendedAgent
+}
+
+func (r readOnly) Add(agent.AddedKey) error {
+	return ErrReadOnly
+}
+
+func (r readOnly) Remove(ssh.PublicKey) error {
+	return ErrReadOnly
+}
+
+func (r readOnly) RemoveAll() error {
+	return ErrReadOnly
+}
+
+func (r readOnly) Lock([]byte) error {
+	return ErrReadOnly
+}
+
+func (r readOnly) Unlock([]byte) error {
+	return ErrReadOnly
+}
+
+// Agent the ssh-agent with the keys loaded from the vault
+type Agent struct {
+	keyring  agent.ExtendedAgent
+	mu       sync.Mutex
+	listener net.Listener
+	path     string
+}
+
+// New constructor, the agent has no keys and does not listen until Listen is called
+func New() *Agent {
+	return &Agent{keyring: agent.NewKeyring().(agent.ExtendedAgent)}
+}
+
+// Add loads the private key of the record into the agent. The passphrase is used only for the protected keys
+func (a *Agent) Add(key *models.SSHKey, passphrase []byte) error {
+	var raw interface{}
+	var err error
+	if len(passphrase) > 0 {
+		raw, err = ssh.ParseRawPrivateKeyWithPassphrase([]byte(key.PrivateKey), passphrase)
+	} else {
+		raw, err = ssh.ParseRawPrivateKey([]byte(key.PrivateKey))
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s", models.ErrInvalidSSHKey, err.Error())
+	}
+	comment := key.Comment
+	if comment == "" {
+		comment = key.ID
+	}
+	return a.keyring.Add(agent.AddedKey{PrivateKey: raw, Comment: comment})
+}
+
+// Remove unloads the key with the public key in the authorized_keys format
+func (a *Agent) Remove(publicKey string) error {
+	public, _, err := models.ParseSSHPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	return a.keyring.Remove(public)
+}
+
+// Keys the loaded keys
+func (a *Agent) Keys() ([]*agent.Key, error) {
+	return a.keyring.List()
+}
+
+// Listen creates the Unix socket at the path and serves the ssh clients connecting to it in the background.
+// The socket is accessible only by the current user
+func (a *Agent) Listen(path string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.listener != nil {
+		return ErrRunning
+	}
+
+	listener, err := listenPrivate(path)
+	if err != nil {
+		return err
+	}
+	a.listener, a.path = listener, path
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = agent.ServeAgent(readOnly{a.keyring}, conn)
+			}()
+		}
+	}()
+	return nil
+}
+
+// listenPrivate creates the socket in a new directory accessible only by the current user and links it
+// to the path when its mode is set, so the socket is never accessible by the others, whatever the umask is.
+// The existing path is not replaced
+func listenPrivate(path string) (net.Listener, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".agent-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: filepath.Join(dir, "s"), Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	// The socket is removed by Close at the path, the temporary one is removed with the directory
+	listener.SetUnlinkOnClose(false)
+	if err := os.Chmod(listener.Addr().String(), 0o600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	if err := os.Link(listener.Addr().String(), path); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// Path the socket the agent listens on, empty if it is stopped
+func (a *Agent) Path() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.path
+}
+
+// Close stops listening, removes the socket and forgets the keys.
+// The connections already accepted are served until the clients close them
+func (a *Agent) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.listener == nil {
+		return nil
+	}
+	err := a.listener.Close()
+	if removeErr := os.Remove(a.path); err == nil && !errors.Is(removeErr, os.ErrNotExist) {
+		err = removeErr
+	}
+	a.listener, a.path = nil, ""
+	if removeErr := a.keyring.RemoveAll(); err == nil {
+		err = removeErr
+	}
+	return err
+}
@@ -0,0 +1,85 @@
+package sshagent
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+)
+
+func TestAgent(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	key := models.SSHKey{ID: "deploy", PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))}
+	require.NoError(t, key.Validate())
+
+	a := New()
+	require.NoError(t, a.Add(&key, nil))
+	dir := t.TempDir()
+	path := filepath.Join(dir, "agent.sock")
+	require.NoError(t, a.Listen(path))
+	defer a.Close()
+	assert.ErrorIs(t, a.Listen(path), ErrRunning)
+	assert.Equal(t, path, a.Path())
+
+	// Only the socket is left in the directory, it is accessible only by the user
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// The existing file is not replaced
+	assert.Error(t, New().Listen(path))
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	defer conn.Close()
+	client := agent.NewClient(conn)
+
+	keys, err := client.List()
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "deploy", keys[0].Comment)
+
+	public, _, err := models.ParseSSHPublicKey(key.PublicKey)
+	require.NoError(t, err)
+	signature, err := client.Sign(public, []byte("challenge"))
+	require.NoError(t, err)
+	assert.NoError(t, public.Verify([]byte("challenge"), signature))
+
+	// The ssh clients can not change the keys of the vault
+	assert.Error(t, client.Add(agent.AddedKey{PrivateKey: private}))
+	assert.Error(t, client.RemoveAll())
+
+	require.NoError(t, a.Remove(key.PublicKey))
+	keys, err = client.List()
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	require.NoError(t, a.Close())
+	assert.Empty(t, a.Path())
+	_, err = net.Dial("unix", path)
+	assert.Error(t, err)
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestAgentInvalidKey(t *testing.T) {
+	a := New()
+	err := a.Add(&models.SSHKey{PrivateKey: "not a key"}, nil)
+	assert.ErrorIs(t, err, models.ErrInvalidSSHKey)
+}
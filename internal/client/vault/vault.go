@@ -0,0 +1,180 @@
+// Package vault implements the client side encryption of the records.
+// The key is derived from the master password with argon2id, the values are encrypted with AES-256-GCM.
+// The master password and the key never leave the client, the server stores only the ciphertext.
+package vault
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+)
+
+var (
+	ErrLocked          = errors.New("vault is locked, enter the master password")
+	ErrWrongPassword   = errors.New("wrong master password")
+	ErrDecrypt         = errors.New("cant decrypt the value, the record is corrupted or was encrypted with another key")
+	ErrInvalidEnvelope = errors.New("invalid encrypted value")
+)
+
+// argon2id parameters of the key derivation. They are part of the format, changing them makes old records unreadable
+const (
+	kdfTime    = 3
+	kdfMemory  = 64 * 1024
+	kdfThreads = 4
+	keyLen     = 32
+)
+
+// checkPlaintext the value encrypted in the check, it is used to verify the master password
+const checkPlaintext = "gophkeeper vault check"
+
+// checkAD associated data of the check value
+const checkAD = "vault/check"
+
+// Vault holds the key derived from the master password
+type Vault struct {
+	aead cipher.AEAD
+}
+
+// Derive derives the vault key from the master password and the salt received from the server
+func Derive(masterPassword string, salt []byte) (*Vault, error) {
+	if len(salt) == 0 {
+		return nil, errors.New("empty vault salt")
+	}
+	key := argon2.IDKey([]byte(masterPassword), salt, kdfTime, kdfMemory, kdfThreads, keyLen)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Vault{aead: aead}, nil
+}
+
+// NewCheck returns a new master password check value to save on the server
+func (v *Vault) NewCheck() (string, error) {
+	return v.SealString(checkAD, checkPlaintext)
+}
+
+// VerifyCheck checks that the vault key is derived from the same master password as the check value
+func (v *Vault) VerifyCheck(check string) error {
+	plain, err := v.OpenString(checkAD, check)
+	if err != nil || plain != checkPlaintext {
+		return ErrWrongPassword
+	}
+	return nil
+}
+
+// SealString encrypts the string. ad binds the ciphertext to its place (record kind, id and field),
+// so the server can not swap encrypted values between records
+func (v *Vault) SealString(ad string, plaintext string) (string, error) {
+	sealed, err := v.seal(ad, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return models.EncryptedPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// OpenString decrypts the string. Values without the encryption prefix were saved before the encryption was enabled
+// and are returned as is
+func (v *Vault) OpenString(ad string, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(value, models.EncryptedPrefix))
+	if err != nil {
+		return "", ErrInvalidEnvelope
+	}
+	plain, err := v.open(ad, sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// SealBytes encrypts binary data. The result is a printable string in bytes,
+// because the server keeps binary data in a text column
+func (v *Vault) SealBytes(ad string, plaintext []byte) ([]byte, error) {
+	sealed, err := v.seal(ad, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(models.EncryptedPrefix + base64.RawStdEncoding.EncodeToString(sealed)), nil
+}
+
+// OpenBytes decrypts binary data, unencrypted data is returned as is
+func (v *Vault) OpenBytes(ad string, value []byte) ([]byte, error) {
+	if !IsEncrypted(string(value)) {
+		return value, nil
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(string(value), models.EncryptedPrefix))
+	if err != nil {
+		return nil, ErrInvalidEnvelope
+	}
+	return v.open(ad, sealed)
+}
+
+// SealChunk encrypts the chunk of the uploaded content. Unlike SealBytes the result is not encoded,
+// so the chunks of the same size have the same encrypted size, see ChunkOverhead
+func (v *Vault) SealChunk(ad string, plaintext []byte) ([]byte, error) {
+	sealed, err := v.seal(ad, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(models.EncryptedPrefix), sealed...), nil
+}
+
+// OpenChunk decrypts the chunk encrypted by SealChunk
+func (v *Vault) OpenChunk(ad string, value []byte) ([]byte, error) {
+	if !bytes.HasPrefix(value, []byte(models.EncryptedPrefix)) {
+		return nil, ErrInvalidEnvelope
+	}
+	return v.open(ad, value[len(models.EncryptedPrefix):])
+}
+
+// ChunkOverhead how much longer the chunk is after SealChunk
+func (v *Vault) ChunkOverhead() int {
+	return len(models.EncryptedPrefix) + v.aead.NonceSize() + v.aead.Overhead()
+}
+
+// IsEncrypted checks whether the value was encrypted by the vault
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, models.EncryptedPrefix)
+}
+
+// AD builds the associated data of the record field
+func AD(kind models.Kind, id string, field string) string {
+	return fmt.Sprintf("%s/%s/%s", kind, id, field)
+}
+
+// seal returns nonce + ciphertext
+func (v *Vault) seal(ad string, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, v.aead.NonceSize(), v.aead.NonceSize()+len(plaintext)+v.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("cant generate nonce: %w", err)
+	}
+	return v.aead.Seal(nonce, nonce, plaintext, []byte(ad)), nil
+}
+
+// open splits nonce + ciphertext and decrypts it
+func (v *Vault) open(ad string, sealed []byte) ([]byte, error) {
+	if len(sealed) < v.aead.NonceSize() {
+		return nil, ErrInvalidEnvelope
+	}
+	nonce, ciphertext := sealed[:v.aead.NonceSize()], sealed[v.aead.NonceSize():]
+	plain, err := v.aead.Open(nil, nonce, ciphertext, []byte(ad))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plain, nil
+}
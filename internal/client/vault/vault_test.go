@@ -0,0 +1,87 @@
+package vault
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+)
+
+func TestSealOpen(t *testing.T) {
+	v, err := Derive("master", []byte("0123456789abcdef"))
+	require.NoError(t, err)
+
+	ad := AD(models.KindCard, "id", "cvv")
+	sealed, err := v.SealString(ad, "123")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(sealed, models.EncryptedPrefix))
+	assert.NotContains(t, sealed, "123")
+
+	plain, err := v.OpenString(ad, sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, "123", plain)
+
+	// The value can not be moved to another field or record
+	_, err = v.OpenString(AD(models.KindCard, "other", "cvv"), sealed)
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	// Values saved before the encryption are returned as is
+	plain, err = v.OpenString(ad, "legacy")
+	assert.NoError(t, err)
+	assert.Equal(t, "legacy", plain)
+
+	data, err := v.SealBytes(ad, []byte{0, 1, 2})
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(string(data)))
+	opened, err := v.OpenBytes(ad, data)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 1, 2}, opened)
+
+	_, err = v.OpenString(ad, models.EncryptedPrefix+"!!!")
+	assert.ErrorIs(t, err, ErrInvalidEnvelope)
+}
+
+func TestCheck(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	v, err := Derive("master", salt)
+	require.NoError(t, err)
+
+	check, err := v.NewCheck()
+	require.NoError(t, err)
+	assert.NoError(t, v.VerifyCheck(check))
+
+	same, err := Derive("master", salt)
+	require.NoError(t, err)
+	assert.NoError(t, same.VerifyCheck(check))
+
+	wrong, err := Derive("wrong", salt)
+	require.NoError(t, err)
+	assert.ErrorIs(t, wrong.VerifyCheck(check), ErrWrongPassword)
+
+	_, err = Derive("master", nil)
+	assert.Error(t, err)
+}
+
+func TestSealChunk(t *testing.T) {
+	v, err := Derive("master", []byte("0123456789abcdef"))
+	require.NoError(t, err)
+
+	ad := AD(models.KindBinary, "photo", "chunk/0")
+	sealed, err := v.SealChunk(ad, []byte("chunk"))
+	require.NoError(t, err)
+	assert.Len(t, sealed, len("chunk")+v.ChunkOverhead())
+
+	opened, err := v.OpenChunk(ad, sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("chunk"), opened)
+
+	// The chunk can not be moved to another position
+	_, err = v.OpenChunk(AD(models.KindBinary, "photo", "chunk/1"), sealed)
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = v.OpenChunk(ad, []byte("chunk"))
+	assert.ErrorIs(t, err, ErrInvalidEnvelope)
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// BinaryChunkSize - the size of the chunks of the uploaded content, only the last chunk may be shorter.
+// So the chunk with the byte at the offset is offset / BinaryChunkSize
+const BinaryChunkSize = 1 << 20
+
+// ErrInvalidChunk - the chunk is empty or longer than BinaryChunkSize
+var ErrInvalidChunk = errors.New("invalid chunk")
+
+// BinaryUpload - the upload session of the binary content. The chunks are sent in order, an interrupted upload
+// is resumed from Chunks. The completed upload replaces the content of the binary
+type BinaryUpload struct {
+	ID       string `json:"id"`
+	BinaryID string `json:"bin_id"`
+	// Offset - how many bytes are received
+	Offset int64 `json:"offset"`
+	// Chunks - how many chunks are received, it is the number of the next chunk
+	Chunks int64 `json:"chunks"`
+}
+
+// BinaryChunk - the chunk of the uploaded content
+type BinaryChunk struct {
+	Upload string
+	Seq    int64
+	// Size - the length of the chunk as it was sent, Data may be encrypted at rest
+	Size int64
+	Data []byte
+}
+
+// Validate checks the length of the chunk and sets Size
+func (c *BinaryChunk) Validate() error {
+	if len(c.Data) == 0 || len(c.Data) > BinaryChunkSize {
+		return fmt.Errorf("%w: the chunk must be from 1 to %d bytes", ErrInvalidChunk, BinaryChunkSize)
+	}
+	if c.Seq < 0 {
+		return fmt.Errorf("%w: negative sequence number", ErrInvalidChunk)
+	}
+	c.Size = int64(len(c.Data))
+	return nil
+}
@@ -0,0 +1,237 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCard - the number, the expiry or the CVV of the card is not valid, the wrapping error tells which one
+var ErrInvalidCard = errors.New("invalid card")
+
+// CardBrand - the payment system of the card, it is detected by the first digits of the number
+type CardBrand string
+
+const (
+	BrandUnknown    CardBrand = ""
+	BrandVisa       CardBrand = "Visa"
+	BrandMastercard CardBrand = "Mastercard"
+	BrandMir        CardBrand = "Mir"
+	BrandAmex       CardBrand = "American Express"
+	BrandDiners     CardBrand = "Diners Club"
+	BrandDiscover   CardBrand = "Discover"
+	BrandJCB        CardBrand = "JCB"
+	BrandUnionPay   CardBrand = "UnionPay"
+	BrandMaestro    CardBrand = "Maestro"
+)
+
+// brandRule - the range of the first digits of the brand and the allowed lengths of its numbers.
+// The bounds have the same count of digits, it is the length of the compared prefix
+type brandRule struct {
+	brand    CardBrand
+	from, to int
+	lengths  [2]int
+}
+
+// brandRules - the ranges of the brands, the narrower ranges go first
+var brandRules = []brandRule{
+	{BrandMir, 2200, 2204, [2]int{16, 19}},
+	{BrandMastercard, 2221, 2720, [2]int{16, 16}},
+	{BrandMastercard, 51, 55, [2]int{16, 16}},
+	{BrandVisa, 4, 4, [2]int{13, 19}},
+	{BrandAmex, 34, 34, [2]int{15, 15}},
+	{BrandAmex, 37, 37, [2]int{15, 15}},
+	{BrandDiners, 300, 305, [2]int{14, 19}},
+	{BrandDiners, 36, 36, [2]int{14, 19}},
+	{BrandDiners, 38, 39, [2]int{14, 19}},
+	{BrandJCB, 3528, 3589, [2]int{16, 19}},
+	{BrandDiscover, 6011, 6011, [2]int{16, 19}},
+	{BrandDiscover, 644, 649, [2]int{16, 19}},
+	{BrandDiscover, 65, 65, [2]int{16, 19}},
+	{BrandUnionPay, 62, 62, [2]int{16, 19}},
+	{BrandMaestro, 50, 50, [2]int{12, 19}},
+	{BrandMaestro, 56, 58, [2]int{12, 19}},
+	{BrandMaestro, 63, 63, [2]int{12, 19}},
+	{BrandMaestro, 67, 67, [2]int{12, 19}},
+}
+
+// CardNumber - the primary account number of the card, digits only
+type CardNumber string
+
+// ParseCardNumber reads the number typed with spaces or dashes between the groups of digits.
+// The number must pass the Luhn check and have the length of its brand, the numbers of unknown brands 12 to 19 digits
+func ParseCardNumber(s string) (CardNumber, error) {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(s))
+	if digits == "" {
+		return "", fmt.Errorf("%w: the number is empty", ErrInvalidCard)
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", fmt.Errorf("%w: the number must contain only digits", ErrInvalidCard)
+		}
+	}
+
+	number := CardNumber(digits)
+	lengths := [2]int{12, 19}
+	if rule, ok := number.rule(); ok {
+		lengths = rule.lengths
+	}
+	if len(digits) < lengths[0] || len(digits) > lengths[1] {
+		return "", fmt.Errorf("%w: the %s number must have %d to %d digits", ErrInvalidCard,
+			brandName(number.Brand()), lengths[0], lengths[1])
+	}
+	if !luhn(digits) {
+		return "", fmt.Errorf("%w: the number fails the checksum, check it for typos", ErrInvalidCard)
+	}
+	return number, nil
+}
+
+// rule - the range of the brand the number belongs to
+func (n CardNumber) rule() (brandRule, bool) {
+	for _, rule := range brandRules {
+		size := len(strconv.Itoa(rule.from))
+		if len(n) < size {
+			continue
+		}
+		prefix, err := strconv.Atoi(string(n[:size]))
+		if err == nil && prefix >= rule.from && prefix <= rule.to {
+			return rule, true
+		}
+	}
+	return brandRule{}, false
+}
+
+// Brand - the payment system of the card, BrandUnknown if the first digits are not known
+func (n CardNumber) Brand() CardBrand {
+	rule, _ := n.rule()
+	return rule.brand
+}
+
+// Masked - the number with only the last 4 digits shown
+func (n CardNumber) Masked() string {
+	if len(n) <= 4 {
+		return "****"
+	}
+	return "****" + string(n[len(n)-4:])
+}
+
+// luhn - the checksum of the card numbers: every second digit from the right is doubled
+func luhn(digits string) bool {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := int(digits[i] - '0')
+		if (len(digits)-i)%2 == 0 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+	return sum%10 == 0
+}
+
+// brandName - the name of the brand for the messages
+func brandName(brand CardBrand) string {
+	if brand == BrandUnknown {
+		return "card"
+	}
+	return string(brand)
+}
+
+// CardExpiry - the month and the year the card is valid through
+type CardExpiry struct {
+	Month time.Month
+	Year  int
+}
+
+// ParseCardExpiry reads the expiry printed on the card as MM/YY, the year may also have 4 digits
+func ParseCardExpiry(s string) (CardExpiry, error) {
+	month, year, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return CardExpiry{}, fmt.Errorf("%w: the expiry must be MM/YY", ErrInvalidCard)
+	}
+	m, err := strconv.Atoi(strings.TrimSpace(month))
+	if err != nil || m < 1 || m > 12 {
+		return CardExpiry{}, fmt.Errorf("%w: the expiry month must be 01 to 12", ErrInvalidCard)
+	}
+	year = strings.TrimSpace(year)
+	y, err := strconv.Atoi(year)
+	switch {
+	case err != nil:
+		return CardExpiry{}, fmt.Errorf("%w: the expiry must be MM/YY", ErrInvalidCard)
+	case len(year) == 2:
+		y += 2000
+	case len(year) != 4 || y < 2000 || y > 2099:
+		return CardExpiry{}, fmt.Errorf("%w: the expiry year must be YY or 20YY", ErrInvalidCard)
+	}
+	return CardExpiry{Month: time.Month(m), Year: y}, nil
+}
+
+// String - the expiry as MM/YY
+func (e CardExpiry) String() string {
+	return fmt.Sprintf("%02d/%02d", int(e.Month), e.Year%100)
+}
+
+// Expired - the card is valid through the last day of the month
+func (e CardExpiry) Expired(now time.Time) bool {
+	return !now.Before(time.Date(e.Year, e.Month+1, 1, 0, 0, 0, 0, now.Location()))
+}
+
+// ValidateCVV checks the security code of the brand: 4 digits for American Express, 3 for the others.
+// Any of them is allowed for the unknown brands
+func ValidateCVV(cvv string, brand CardBrand) error {
+	for _, r := range cvv {
+		if r < '0' || r > '9' {
+			return fmt.Errorf("%w: the CVV must contain only digits", ErrInvalidCard)
+		}
+	}
+	switch {
+	case brand == BrandAmex && len(cvv) != 4:
+		return fmt.Errorf("%w: the %s CVV must have 4 digits", ErrInvalidCard, brand)
+	case brand != BrandAmex && brand != BrandUnknown && len(cvv) != 3:
+		return fmt.Errorf("%w: the %s CVV must have 3 digits", ErrInvalidCard, brand)
+	case len(cvv) != 3 && len(cvv) != 4:
+		return fmt.Errorf("%w: the CVV must have 3 or 4 digits", ErrInvalidCard)
+	}
+	return nil
+}
+
+// Validate checks the number, the expiry and the CVV of the card and brings the number to digits only
+// and the expiry to MM/YY. The CVV is optional. The fields encrypted on the client side are not checked,
+// the client checks them before the encryption, the plain ones are checked anyway.
+// The CVV of the card with the encrypted number is checked without the brand
+func (c *Card) Validate() error {
+	brand := BrandUnknown
+	if !isSealed(c.Number) {
+		number, err := ParseCardNumber(c.Number)
+		if err != nil {
+			return err
+		}
+		c.Number, brand = string(number), number.Brand()
+	}
+	if !isSealed(c.Date) {
+		expiry, err := ParseCardExpiry(c.Date)
+		if err != nil {
+			return err
+		}
+		c.Date = expiry.String()
+	}
+	if !isSealed(c.CVV) {
+		cvv := strings.TrimSpace(c.CVV)
+		if cvv != "" {
+			if err := ValidateCVV(cvv, brand); err != nil {
+				return err
+			}
+		}
+		c.CVV = cvv
+	}
+	return nil
+}
+
+// isSealed checks whether the value was encrypted on the client side
+func isSealed(value string) bool {
+	return strings.HasPrefix(value, EncryptedPrefix)
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCardNumber(t *testing.T) {
+	tests := []struct {
+		name   string
+		number string
+		want   CardNumber
+		brand  CardBrand
+		valid  bool
+	}{
+		{name: "visa with spaces", number: "4111 1111 1111 1111", want: "4111111111111111", brand: BrandVisa, valid: true},
+		{name: "mastercard with dashes", number: "5555-5555-5555-4444", want: "5555555555554444", brand: BrandMastercard, valid: true},
+		{name: "mastercard 2 series", number: "2221000000000009", want: "2221000000000009", brand: BrandMastercard, valid: true},
+		{name: "mir", number: "2200000000000004", want: "2200000000000004", brand: BrandMir, valid: true},
+		{name: "amex", number: "378282246310005", want: "378282246310005", brand: BrandAmex, valid: true},
+		{name: "unknown brand", number: "9999999999999995", want: "9999999999999995", brand: BrandUnknown, valid: true},
+		{name: "luhn", number: "4111111111111112"},
+		{name: "letters", number: "4111a11111111111"},
+		{name: "empty", number: " "},
+		{name: "amex length", number: "3782822463100051"},
+		{name: "too short", number: "42424242426"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			number, err := ParseCardNumber(tt.number)
+			if !tt.valid {
+				assert.ErrorIs(t, err, ErrInvalidCard)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, number)
+			assert.Equal(t, tt.brand, number.Brand())
+		})
+	}
+	assert.Equal(t, "****1111", CardNumber("4111111111111111").Masked())
+}
+
+func TestParseCardExpiry(t *testing.T) {
+	expiry, err := ParseCardExpiry(" 3/2030 ")
+	require.NoError(t, err)
+	assert.Equal(t, CardExpiry{Month: time.March, Year: 2030}, expiry)
+	assert.Equal(t, "03/30", expiry.String())
+
+	expiry, err = ParseCardExpiry("12/24")
+	require.NoError(t, err)
+	assert.False(t, expiry.Expired(time.Date(2024, 12, 31, 23, 0, 0, 0, time.UTC)))
+	assert.True(t, expiry.Expired(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	for _, value := range []string{"13/30", "00/30", "1230", "12/3", "12/1999", "ab/cd"} {
+		_, err := ParseCardExpiry(value)
+		assert.ErrorIs(t, err, ErrInvalidCard, value)
+	}
+}
+
+func TestValidateCVV(t *testing.T) {
+	assert.NoError(t, ValidateCVV("123", BrandVisa))
+	assert.NoError(t, ValidateCVV("1234", BrandAmex))
+	assert.NoError(t, ValidateCVV("1234", BrandUnknown))
+	assert.ErrorIs(t, ValidateCVV("1234", BrandVisa), ErrInvalidCard)
+	assert.ErrorIs(t, ValidateCVV("123", BrandAmex), ErrInvalidCard)
+	assert.ErrorIs(t, ValidateCVV("12a", BrandMir), ErrInvalidCard)
+}
+
+func TestCardValidate(t *testing.T) {
+	card := Card{Number: "4111 1111 1111 1111", Date: "1/2030", CVV: " 123 "}
+	require.NoError(t, card.Validate())
+	assert.Equal(t, Card{Number: "4111111111111111", Date: "01/30", CVV: "123"}, card)
+
+	// The CVV is optional
+	card = Card{Number: "4111111111111111", Date: "01/30"}
+	assert.NoError(t, card.Validate())
+
+	card = Card{Number: "4111111111111111", Date: "01/30", CVV: "1234"}
+	assert.ErrorIs(t, card.Validate(), ErrInvalidCard)
+
+	// The values encrypted on the client can not be checked by the server
+	sealed := Card{Number: EncryptedPrefix + "abc", Date: EncryptedPrefix + "def", CVV: EncryptedPrefix + "ghi"}
+	assert.NoError(t, sealed.Validate())
+	assert.Equal(t, EncryptedPrefix+"abc", sealed.Number)
+
+	// The plain fields are checked even if the others are encrypted
+	partly := Card{Number: "4111111111111112", Date: "01/30", CVV: EncryptedPrefix + "ghi"}
+	assert.ErrorIs(t, partly.Validate(), ErrInvalidCard)
+	partly = Card{Number: EncryptedPrefix + "abc", Date: "13/30"}
+	assert.ErrorIs(t, partly.Validate(), ErrInvalidCard)
+	partly = Card{Number: EncryptedPrefix + "abc", Date: "1/2030", CVV: "12"}
+	assert.ErrorIs(t, partly.Validate(), ErrInvalidCard)
+	partly = Card{Number: EncryptedPrefix + "abc", Date: "1/2030", CVV: " 1234 "}
+	assert.NoError(t, partly.Validate())
+	assert.Equal(t, Card{Number: EncryptedPrefix + "abc", Date: "01/30", CVV: "1234"}, partly)
+}
@@ -0,0 +1,238 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRecordType - the schema of the record type is not valid, the wrapping error tells why
+var ErrInvalidRecordType = errors.New("invalid record type")
+
+// ErrInvalidRecord - the values of the custom record do not match the schema of its type
+var ErrInvalidRecord = errors.New("invalid record")
+
+// FieldType - the type of the value of the field of the record type
+type FieldType string
+
+const (
+	FieldText   FieldType = "text"
+	FieldNumber FieldType = "number"
+	FieldDate   FieldType = "date" // YYYY-MM-DD
+	FieldURL    FieldType = "url"
+	FieldEmail  FieldType = "email"
+)
+
+// FieldTypes all types of the fields
+var FieldTypes = []FieldType{FieldText, FieldNumber, FieldDate, FieldURL, FieldEmail}
+
+// TypeField - the field of the record type. The values of the secret fields are encrypted and masked
+type TypeField struct {
+	Name     string    `json:"name"`
+	Type     FieldType `json:"type"`
+	Secret   bool      `json:"secret,omitempty"`
+	Required bool      `json:"required,omitempty"`
+}
+
+// RecordType - the template of the records defined by the user, e.g. "API key" or "Wi-Fi network".
+// The name is unique for the user, the fields are asked and shown in their order
+type RecordType struct {
+	UserID int64       `json:"-"`
+	Name   string      `json:"name"`
+	Fields []TypeField `json:"fields"`
+}
+
+// ParseTypeField reads the field as name:type[:secret][:required], the type is text if it is omitted
+func ParseTypeField(s string) (TypeField, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	field := TypeField{Name: strings.TrimSpace(parts[0]), Type: FieldText}
+	if len(parts) > 1 && parts[1] != "" {
+		field.Type = FieldType(strings.TrimSpace(parts[1]))
+	}
+	for _, flag := range parts[min(len(parts), 2):] {
+		switch strings.TrimSpace(flag) {
+		case "secret":
+			field.Secret = true
+		case "required":
+			field.Required = true
+		default:
+			return TypeField{}, fmt.Errorf("%w: unknown flag %q of the field %s, secret or required expected",
+				ErrInvalidRecordType, flag, field.Name)
+		}
+	}
+	return field, field.validate()
+}
+
+// String - the field as name:type[:secret][:required]
+func (f TypeField) String() string {
+	s := f.Name + ":" + string(f.Type)
+	if f.Secret {
+		s += ":secret"
+	}
+	if f.Required {
+		s += ":required"
+	}
+	return s
+}
+
+// validate checks the name and the type of the field
+func (f TypeField) validate() error {
+	if f.Name == "" || strings.ContainsAny(f.Name, ":= ") {
+		return fmt.Errorf("%w: the field name can not be empty or contain spaces, colons or equal signs",
+			ErrInvalidRecordType)
+	}
+	for _, fieldType := range FieldTypes {
+		if f.Type == fieldType {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: unknown type %q of the field %s", ErrInvalidRecordType, f.Type, f.Name)
+}
+
+// Validate checks that the type has a name and the fields with unique names and known types
+func (t *RecordType) Validate() error {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" {
+		return fmt.Errorf("%w: the name can not be empty", ErrInvalidRecordType)
+	}
+	if len(t.Fields) == 0 {
+		return fmt.Errorf("%w: the type must have at least one field", ErrInvalidRecordType)
+	}
+	names := make(map[string]bool, len(t.Fields))
+	for _, field := range t.Fields {
+		if err := field.validate(); err != nil {
+			return err
+		}
+		if names[field.Name] {
+			return fmt.Errorf("%w: the field %s is repeated", ErrInvalidRecordType, field.Name)
+		}
+		names[field.Name] = true
+	}
+	return nil
+}
+
+// Field finds the field of the type by the name
+func (t *RecordType) Field(name string) (TypeField, bool) {
+	for _, field := range t.Fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return TypeField{}, false
+}
+
+// Validate checks the values of the record by the schema of its type: every value belongs to a field of the type,
+// the values of the secret fields are in Secrets and the others in Values, the required fields are filled
+// and the values match the types of the fields. The empty values are dropped. The values encrypted
+// on the client side are not checked
+func (r *CustomRecord) Validate(recordType *RecordType) error {
+	if r.Type != recordType.Name {
+		return fmt.Errorf("%w: the record is of the type %s, not %s", ErrInvalidRecord, r.Type, recordType.Name)
+	}
+	for _, values := range []map[string]string{r.Values, r.Secrets} {
+		for _, name := range sortedNames(values) {
+			field, ok := recordType.Field(name)
+			if !ok {
+				return fmt.Errorf("%w: the type %s has no field %s", ErrInvalidRecord, recordType.Name, name)
+			}
+			if _, secret := r.Secrets[name]; secret != field.Secret {
+				return fmt.Errorf("%w: the field %s is kept with the wrong secrecy", ErrInvalidRecord, name)
+			}
+			value := strings.TrimSpace(values[name])
+			if value == "" {
+				delete(values, name)
+				continue
+			}
+			if err := checkValue(field, value); err != nil {
+				return err
+			}
+			values[name] = value
+		}
+	}
+	for _, field := range recordType.Fields {
+		if field.Required && r.Values[field.Name] == "" && r.Secrets[field.Name] == "" {
+			return fmt.Errorf("%w: the field %s is required", ErrInvalidRecord, field.Name)
+		}
+	}
+	return nil
+}
+
+// Value the value of the field of the record, public or secret
+func (r *CustomRecord) Value(field TypeField) string {
+	if field.Secret {
+		return r.Secrets[field.Name]
+	}
+	return r.Values[field.Name]
+}
+
+// SetValue puts the value of the field into Values or Secrets by the schema
+func (r *CustomRecord) SetValue(field TypeField, value string) {
+	values := &r.Values
+	if field.Secret {
+		values = &r.Secrets
+	}
+	if *values == nil {
+		*values = make(map[string]string)
+	}
+	(*values)[field.Name] = value
+}
+
+// checkValue checks that the value matches the type of the field
+func checkValue(field TypeField, value string) error {
+	if strings.HasPrefix(value, EncryptedPrefix) {
+		return nil
+	}
+	var err error
+	switch field.Type {
+	case FieldNumber:
+		_, err = strconv.ParseFloat(value, 64)
+	case FieldDate:
+		_, err = time.Parse(time.DateOnly, value)
+	case FieldURL:
+		var u *url.URL
+		u, err = url.Parse(value)
+		if err == nil && (u.Scheme == "" || u.Host == "") {
+			err = errors.New("no scheme or host")
+		}
+	case FieldEmail:
+		var address *mail.Address
+		address, err = mail.ParseAddress(value)
+		if err == nil && address.Address != value {
+			err = errors.New("not a bare address")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%w: the field %s must be %s", ErrInvalidRecord, field.Name, fieldTypeName(field.Type))
+	}
+	return nil
+}
+
+// fieldTypeName - the type of the field for the messages
+func fieldTypeName(fieldType FieldType) string {
+	switch fieldType {
+	case FieldNumber:
+		return "a number"
+	case FieldDate:
+		return "a date as YYYY-MM-DD"
+	case FieldURL:
+		return "an absolute URL"
+	case FieldEmail:
+		return "an email address"
+	}
+	return "a text"
+}
+
+// sortedNames the keys of the values in the order the errors are reported the same way every time
+func sortedNames(values map[string]string) []string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTypeField(t *testing.T) {
+	field, err := ParseTypeField("password:text:secret:required")
+	require.NoError(t, err)
+	assert.Equal(t, TypeField{Name: "password", Type: FieldText, Secret: true, Required: true}, field)
+	assert.Equal(t, "password:text:secret:required", field.String())
+
+	field, err = ParseTypeField("ssid")
+	require.NoError(t, err)
+	assert.Equal(t, TypeField{Name: "ssid", Type: FieldText}, field)
+
+	for _, s := range []string{"", "port:integer", "port:number:hidden", "wi fi:text"} {
+		_, err := ParseTypeField(s)
+		assert.ErrorIs(t, err, ErrInvalidRecordType, s)
+	}
+}
+
+func TestRecordTypeValidate(t *testing.T) {
+	wifi := RecordType{Name: " Wi-Fi ", Fields: []TypeField{{Name: "ssid", Type: FieldText}}}
+	require.NoError(t, wifi.Validate())
+	assert.Equal(t, "Wi-Fi", wifi.Name)
+
+	for _, recordType := range []RecordType{
+		{Name: "", Fields: wifi.Fields},
+		{Name: "empty"},
+		{Name: "twice", Fields: []TypeField{{Name: "a", Type: FieldText}, {Name: "a", Type: FieldDate}}},
+		{Name: "unknown", Fields: []TypeField{{Name: "a", Type: "color"}}},
+	} {
+		assert.ErrorIs(t, recordType.Validate(), ErrInvalidRecordType, recordType.Name)
+	}
+}
+
+func TestCustomRecordValidate(t *testing.T) {
+	apiKey := &RecordType{Name: "API key", Fields: []TypeField{
+		{Name: "service", Type: FieldURL, Required: true},
+		{Name: "key", Type: FieldText, Secret: true, Required: true},
+		{Name: "expires", Type: FieldDate},
+		{Name: "owner", Type: FieldEmail},
+		{Name: "limit", Type: FieldNumber},
+	}}
+
+	record := CustomRecord{
+		Type:    "API key",
+		Values:  map[string]string{"service": " https://api.example.com ", "expires": "2027-01-31", "limit": "", "owner": "dev@example.com"},
+		Secrets: map[string]string{"key": "abc"},
+	}
+	require.NoError(t, record.Validate(apiKey))
+	assert.Equal(t, map[string]string{"service": "https://api.example.com", "expires": "2027-01-31",
+		"owner": "dev@example.com"}, record.Values)
+
+	// The secret encrypted on the client side is not checked
+	sealed := CustomRecord{Type: "API key", Values: map[string]string{"service": "https://api.example.com"},
+		Secrets: map[string]string{"key": EncryptedPrefix + "abc"}}
+	assert.NoError(t, sealed.Validate(apiKey))
+
+	tests := []CustomRecord{
+		{Type: "Wi-Fi", Values: record.Values, Secrets: record.Secrets},
+		{Type: "API key", Values: map[string]string{"service": "https://api.example.com"}},
+		{Type: "API key", Values: map[string]string{"service": "https://api.example.com", "key": "abc"}},
+		{Type: "API key", Values: map[string]string{"service": "api.example.com"}, Secrets: record.Secrets},
+		{Type: "API key", Values: map[string]string{"service": "https://a.b", "expires": "31.01.2027"}, Secrets: record.Secrets},
+		{Type: "API key", Values: map[string]string{"service": "https://a.b", "owner": "Dev <dev@example.com>"}, Secrets: record.Secrets},
+		{Type: "API key", Values: map[string]string{"service": "https://a.b", "limit": "many"}, Secrets: record.Secrets},
+		{Type: "API key", Values: map[string]string{"service": "https://a.b", "color": "red"}, Secrets: record.Secrets},
+	}
+	for _, tt := range tests {
+		assert.ErrorIs(t, tt.Validate(apiKey), ErrInvalidRecord, tt.Values)
+	}
+}
@@ -0,0 +1,28 @@
+package models
+
+import "fmt"
+
+// Kind - the kind of the stored record
+type Kind string
+
+const (
+	KindCard   Kind = "card"
+	KindLogin  Kind = "login"
+	KindText   Kind = "text"
+	KindBinary Kind = "bin"
+	KindSSH    Kind = "ssh"
+	KindCustom Kind = "custom"
+)
+
+// Kinds all kinds of records in the order they are shown to the user
+var Kinds = []Kind{KindCard, KindLogin, KindText, KindBinary, KindSSH, KindCustom}
+
+// ParseKind converts the user input into the record kind
+func ParseKind(s string) (Kind, error) {
+	for _, kind := range Kinds {
+		if string(kind) == s {
+			return kind, nil
+		}
+	}
+	return "", fmt.Errorf("unknown record kind %q", s)
+}
@@ -1,46 +1,346 @@
-// Package models contains structures that describe our domain entities
-// right now all data is strings, we should replace them with correct types + proper validation
+// Package models contains structures that describe our domain entities.
+// The secret fields of the records are strings because they may hold the ciphertext of the client side encryption,
+// the plain values are checked by the typed parsers, e.g. Card.Validate
 package models
 
+import "time"
+
 // User - user type
 type User struct {
 	UserID   int64  `json:"-"`
 	Login    string `json:"login"`
 	Password string `json:"password"`
+	// OTP - the second factor on sign-in: the code from the authenticator app or a recovery code
+	OTP string `json:"otp,omitempty"`
 }
 
 // Card - bank card
 type Card struct {
-	UserID   int64  `json:"-"`
-	ID       string `json:"id"`
-	FIO      string `json:"fio"` // The name on the card may differ from the actual name
-	Number   string `json:"number"`
-	Date     string `json:"date"`
-	CVV      string `json:"cvv"`
-	MetaInfo string `json:"metainfo"`
+	UserID int64  `json:"-"`
+	ID     string `json:"id"`
+	FIO    string `json:"fio"`    // The name on the card may differ from the actual name
+	Number string `json:"number"` // Digits only after Validate, see ParseCardNumber
+	Date   string `json:"date"`   // The expiry as MM/YY after Validate, see ParseCardExpiry
+	CVV    string `json:"cvv"`
+	Note   string `json:"note"`
+	// Tags and Fields - the labels and the custom "name: value" fields of the record, they are not encrypted
+	// and are used to filter the lists
+	Tags   []string          `json:"tags,omitempty"`
+	Fields map[string]string `json:"fields,omitempty"`
+	// Vault and Folder - the location of the record, it is set on addition and changed only by the move.
+	// The zero vault is the top level, the zero folder is the root of the vault
+	Vault  int64 `json:"vault,omitempty"`
+	Folder int64 `json:"folder,omitempty"`
+	// Version grows with every update, the update of a stale version is rejected
+	Version int64 `json:"version"`
 }
 
 // Text - text content
 type Text struct {
-	UserID   int64  `json:"-"`
-	ID       string `json:"id"`
-	Content  string `json:"content"`
-	MetaInfo string `json:"metainfo"`
+	UserID  int64             `json:"-"`
+	ID      string            `json:"id"`
+	Content string            `json:"content"`
+	Note    string            `json:"note"`
+	Tags    []string          `json:"tags,omitempty"`
+	Fields  map[string]string `json:"fields,omitempty"`
+	Vault   int64             `json:"vault,omitempty"`
+	Folder  int64             `json:"folder,omitempty"`
+	Version int64             `json:"version"`
 }
 
-// Binary - binary data
+// Binary - binary data. Small data is kept in Data, the large one is uploaded in chunks, see BinaryUpload
 type Binary struct {
-	UserID   int64  `json:"-"`
-	ID       string `json:"id"`
-	Data     []byte `json:"data"`
-	MetaInfo string `json:"metainfo"`
+	UserID int64  `json:"-"`
+	ID     string `json:"id"`
+	Data   []byte `json:"data"`
+	// ContentID - the id of the uploaded content, empty if the data is in Data.
+	// The update keeps the uploaded content only if it is still the current one
+	ContentID string `json:"content_id,omitempty"`
+	// Size - the size of the uploaded content
+	Size int64 `json:"size,omitempty"`
+	// FileName, FileSize, MimeType, ModTime - the file the binary was imported from. FileSize is the size
+	// of the file, the data may be encrypted. The file name is a secret like the data
+	FileName string            `json:"file_name,omitempty"`
+	FileSize int64             `json:"file_size,omitempty"`
+	MimeType string            `json:"mime_type,omitempty"`
+	ModTime  *time.Time        `json:"mod_time,omitempty"`
+	Note     string            `json:"note"`
+	Tags     []string          `json:"tags,omitempty"`
+	Fields   map[string]string `json:"fields,omitempty"`
+	Vault    int64             `json:"vault,omitempty"`
+	Folder   int64             `json:"folder,omitempty"`
+	Version  int64             `json:"version"`
 }
 
 // Login - login data
 type Login struct {
-	UserID   int64  `json:"-"`
-	ID       string `json:"id"`
-	Login    string `json:"login"`
+	UserID   int64             `json:"-"`
+	ID       string            `json:"id"`
+	Login    string            `json:"login"`
+	Password string            `json:"password"`
+	TOTP     string            `json:"totp,omitempty"` // The otpauth:// URI of the second factor, a secret like the password
+	Note     string            `json:"note"`
+	Tags     []string          `json:"tags,omitempty"`
+	Fields   map[string]string `json:"fields,omitempty"`
+	Vault    int64             `json:"vault,omitempty"`
+	Folder   int64             `json:"folder,omitempty"`
+	Version  int64             `json:"version"`
+}
+
+// SSHKey - the ssh key pair. Only the private key is a secret, the public key and the fingerprint are shown
+// to find the key and are offered by the ssh-agent of the client
+type SSHKey struct {
+	UserID      int64             `json:"-"`
+	ID          string            `json:"id"`
+	PrivateKey  string            `json:"private_key"` // PEM or OpenSSH format, may be protected by a passphrase
+	PublicKey   string            `json:"public_key"`  // The authorized_keys line without the comment after Validate
+	Comment     string            `json:"comment"`
+	Fingerprint string            `json:"fingerprint"` // SHA256 of the public key, it is set by Validate
+	Note        string            `json:"note"`
+	Tags        []string          `json:"tags,omitempty"`
+	Fields      map[string]string `json:"fields,omitempty"`
+	Vault       int64             `json:"vault,omitempty"`
+	Folder      int64             `json:"folder,omitempty"`
+	Version     int64             `json:"version"`
+}
+
+// CustomRecord - the record of the type defined by the user, see RecordType. The values of the secret fields
+// of the type are kept apart from the others, they are encrypted like the password of the login
+type CustomRecord struct {
+	UserID  int64             `json:"-"`
+	ID      string            `json:"id"`
+	Type    string            `json:"type"` // The name of the RecordType
+	Values  map[string]string `json:"values,omitempty"`
+	Secrets map[string]string `json:"secrets,omitempty"`
+	// Sealed - the secrets encrypted at rest as one value, it is used only by the storage of the server
+	Sealed  string            `json:"-"`
+	Note    string            `json:"note"`
+	Tags    []string          `json:"tags,omitempty"`
+	Fields  map[string]string `json:"fields,omitempty"`
+	Vault   int64             `json:"vault,omitempty"`
+	Folder  int64             `json:"folder,omitempty"`
+	Version int64             `json:"version"`
+}
+
+// EncryptedPrefix - prefix of the values encrypted on the client side with the vault key.
+// The server can not read such values, it only stores them
+const EncryptedPrefix = "$gk1$"
+
+// VaultParams - public parameters of the client side encryption of the user records.
+// Salt is used to derive the vault key from the master password, Check is a value encrypted with this key,
+// the client uses it to verify the master password. The server never sees the master password or the key.
+type VaultParams struct {
+	Salt  []byte `json:"salt"`
+	Check string `json:"check"`
+}
+
+// Session - signed in client of the user. Every session has its own refresh token and can be revoked separately
+type Session struct {
+	ID          string    `json:"id"`
+	UserID      int64     `json:"-"`
+	UserAgent   string    `json:"user_agent"`
+	CreatedAt   time.Time `json:"created_at"`
+	RefreshedAt time.Time `json:"refreshed_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Current     bool      `json:"current"`
+}
+
+// Tokens - the short-lived access token and the refresh token used to get a new pair
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TOTP - the second factor of the user. The secret is saved on setup, but is checked on sign-in only after confirmation
+type TOTP struct {
+	Secret  string
+	Enabled bool
+	// LastCounter time step of the last accepted code, the same code can not be used twice
+	LastCounter int64
+}
+
+// TOTPSetup - the new secret of the second factor, it is added to the authenticator app by the secret or the uri
+type TOTPSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// OTPCode - the code from the authenticator app or a recovery code
+type OTPCode struct {
+	Code string `json:"code"`
+}
+
+// RecoveryCodes - one-time codes to sign in without the authenticator app, they are shown only once
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+// Lockout - the sign-in to the account was blocked because of too many failed attempts
+type Lockout struct {
+	// IP address of the last failed attempt
+	IP          string    `json:"ip"`
+	Failures    int       `json:"failures"`
+	CreatedAt   time.Time `json:"created_at"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+// Actions of the audit events
+const (
+	AuditRead         = "read"
+	AuditAdd          = "add"
+	AuditUpdate       = "update"
+	AuditDelete       = "delete"
+	AuditRestore      = "restore"
+	AuditEmptyTrash   = "empty-trash"
+	AuditMove         = "move"
+	AuditSignIn       = "signin"
+	AuditSignInFailed = "signin-failed"
+	// Actions of the account, the deletion of the account is kept without the user
+	AuditChangePassword = "change-password"
+	AuditChangeLogin    = "change-login"
+	AuditDeleteAccount  = "delete-account"
+	AuditEnableTOTP     = "2fa-enable"
+	AuditDisableTOTP    = "2fa-disable"
+	AuditRevokeSession  = "revoke-session"
+	AuditLockout        = "lockout"
+)
+
+// AuditEvent - the access to the vault or the account event, the audit log is append-only
+type AuditEvent struct {
+	ID int64 `json:"id"`
+	// Actor login of the user, for the failed sign-in the login that was entered
+	Actor    string `json:"actor"`
+	Action   string `json:"action"`
+	Kind     Kind   `json:"kind,omitempty"`
+	RecordID string `json:"record_id,omitempty"`
+	// Transport http or grpc
+	Transport string    `json:"transport"`
+	IP        string    `json:"ip"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AuditPage - the page of the audit log, the newest events first.
+// Next is the cursor of the next page, zero on the last page
+type AuditPage struct {
+	Events []AuditEvent `json:"events"`
+	Next   int64        `json:"next,omitempty"`
+}
+
+// PasswordChange - the request to replace the password, the current one confirms it
+type PasswordChange struct {
+	Password    string `json:"password"`
+	NewPassword string `json:"new_password"`
+}
+
+// LoginChange - the request to rename the account, the password confirms it
+type LoginChange struct {
+	Password string `json:"password"`
+	NewLogin string `json:"new_login"`
+}
+
+// AccountDeletion - the request to delete the account with all the records, the password confirms it
+type AccountDeletion struct {
 	Password string `json:"password"`
-	MetaInfo string `json:"metainfo"`
+}
+
+// RecordVersion - the version of the record after the update
+type RecordVersion struct {
+	ID      string `json:"id"`
+	Version int64  `json:"version"`
+}
+
+// Sorting orders of the record lists
+const (
+	// SortCreated in the order the records were added, the default one
+	SortCreated = "created"
+	// SortID by the id of the record
+	SortID = "id"
+)
+
+// ListOptions - the page of the record list requested by the client.
+// After is the cursor of the page, the Next of the previous page, empty for the first one.
+// Tag and Field filter the records, the Field filter matches any value of the field if Value is empty.
+// The non-zero Vault lists only the records of the Folder of the vault, the zero Folder is the root of the vault
+type ListOptions struct {
+	Sort   string
+	Desc   bool
+	After  string
+	Limit  int
+	Tag    string
+	Field  string
+	Value  string
+	Vault  int64
+	Folder int64
+}
+
+// Vault - the named vault of the records of the user, not to be confused with the client side encryption
+// of the records
+type Vault struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// Folder - the folder of the vault, the folders without the parent are in the root of the vault
+type Folder struct {
+	ID     int64  `json:"id"`
+	Vault  int64  `json:"vault"`
+	Parent int64  `json:"parent,omitempty"`
+	Name   string `json:"name"`
+}
+
+// Location - the vault and the folder the record is moved to, see Card.Vault
+type Location struct {
+	Vault  int64 `json:"vault"`
+	Folder int64 `json:"folder"`
+}
+
+// RecordInfo - the record without the secret fields, the lists show only the id and the metadata
+type RecordInfo struct {
+	ID     string            `json:"id"`
+	Note   string            `json:"note"`
+	Tags   []string          `json:"tags,omitempty"`
+	Fields map[string]string `json:"fields,omitempty"`
+	// Cursor position of the record in the sorting order, the next page starts after it
+	Cursor string `json:"-"`
+}
+
+// RecordPage - the page of the record list. Next is the cursor of the next page, empty on the last page
+type RecordPage struct {
+	Records []RecordInfo `json:"records"`
+	Next    string       `json:"next,omitempty"`
+}
+
+// Actions of the revisions
+const (
+	RevisionAdd    = "add"
+	RevisionUpdate = "update"
+	RevisionDelete = "delete"
+	// RevisionRestore the record was restored from the trash
+	RevisionRestore = "restore"
+)
+
+// Revision - the state of the record after the change, the history of the record is immutable.
+// The revision of the deletion keeps the last values of the record
+type Revision struct {
+	ID        int64     `json:"id"`
+	Kind      Kind      `json:"kind"`
+	RecordID  string    `json:"record_id"`
+	Version   int64     `json:"version"`
+	Action    string    `json:"action"`
+	CreatedAt time.Time `json:"created_at"`
+	// Fields values by the json name of the field of the record, they are read only for a single revision
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// TrashItem - the deleted record, it can be restored until the trash is emptied or the item is expired
+type TrashItem struct {
+	Kind      Kind      `json:"kind"`
+	ID        string    `json:"id"`
+	Note      string    `json:"note"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// TrashEmptied - the result of emptying the trash
+type TrashEmptied struct {
+	Removed int64 `json:"removed"`
 }
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ErrInvalidSSHKey - the private or the public key can not be parsed or they are not a pair
+var ErrInvalidSSHKey = errors.New("invalid ssh key")
+
+// ParseSSHPublicKey reads the public key in the authorized_keys format, the comment after the key is returned too
+func ParseSSHPublicKey(s string) (ssh.PublicKey, string, error) {
+	key, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(s)))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: the public key must be an authorized_keys line", ErrInvalidSSHKey)
+	}
+	return key, comment, nil
+}
+
+// SSHPublicKeyOf derives the public key from the private one in the PEM or the OpenSSH format.
+// The public key of a key protected by a passphrase is read without the passphrase if the format keeps it in plain,
+// otherwise nil is returned without an error
+func SSHPublicKeyOf(privateKey string) (ssh.PublicKey, error) {
+	raw, err := ssh.ParseRawPrivateKey([]byte(privateKey))
+	var missing *ssh.PassphraseMissingError
+	switch {
+	case errors.As(err, &missing):
+		return missing.PublicKey, nil
+	case err != nil:
+		return nil, fmt.Errorf("%w: the private key must be in the PEM or the OpenSSH format", ErrInvalidSSHKey)
+	}
+	signer, err := ssh.NewSignerFromKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSSHKey, err.Error())
+	}
+	return signer.PublicKey(), nil
+}
+
+// Validate checks that the private and the public keys are a pair and fills the public key and the fingerprint.
+// The public key may be omitted if it is derived from the private one, the comment of the public key line becomes
+// the comment of the record if it has none. The private key encrypted on the client side is not checked,
+// the public key is still parsed for the fingerprint
+func (k *SSHKey) Validate() error {
+	var public ssh.PublicKey
+	if strings.TrimSpace(k.PublicKey) != "" {
+		key, comment, err := ParseSSHPublicKey(k.PublicKey)
+		if err != nil {
+			return err
+		}
+		public = key
+		if k.Comment == "" {
+			k.Comment = comment
+		}
+	}
+
+	if !strings.HasPrefix(k.PrivateKey, EncryptedPrefix) {
+		derived, err := SSHPublicKeyOf(k.PrivateKey)
+		if err != nil {
+			return err
+		}
+		if public != nil && derived != nil && string(public.Marshal()) != string(derived.Marshal()) {
+			return fmt.Errorf("%w: the public key does not match the private key", ErrInvalidSSHKey)
+		}
+		if public == nil {
+			public = derived
+		}
+	}
+	if public == nil {
+		return fmt.Errorf("%w: the public key is required for this private key", ErrInvalidSSHKey)
+	}
+
+	k.PublicKey = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(public)))
+	k.Fingerprint = ssh.FingerprintSHA256(public)
+	return nil
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// newSSHKey generates the ed25519 key pair, the private key in the PKCS#8 PEM and the authorized_keys line
+func newSSHKey(t *testing.T) (string, string) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	sshPublic, err := ssh.NewPublicKey(public)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPublic)))
+}
+
+func TestSSHKeyValidate(t *testing.T) {
+	private, public := newSSHKey(t)
+	_, otherPublic := newSSHKey(t)
+
+	// The public key and the fingerprint are derived from the private key
+	key := SSHKey{PrivateKey: private}
+	require.NoError(t, key.Validate())
+	assert.Equal(t, public, key.PublicKey)
+	assert.True(t, strings.HasPrefix(key.Fingerprint, "SHA256:"))
+
+	// The comment of the public key line is kept as the comment of the record
+	key = SSHKey{PrivateKey: private, PublicKey: public + " deploy@ci"}
+	require.NoError(t, key.Validate())
+	assert.Equal(t, public, key.PublicKey)
+	assert.Equal(t, "deploy@ci", key.Comment)
+
+	key = SSHKey{PrivateKey: private, PublicKey: otherPublic}
+	assert.ErrorIs(t, key.Validate(), ErrInvalidSSHKey)
+	key = SSHKey{PrivateKey: "not a key"}
+	assert.ErrorIs(t, key.Validate(), ErrInvalidSSHKey)
+	key = SSHKey{PrivateKey: private, PublicKey: "ssh-ed25519 broken"}
+	assert.ErrorIs(t, key.Validate(), ErrInvalidSSHKey)
+
+	// The private key encrypted on the client needs the public key
+	key = SSHKey{PrivateKey: EncryptedPrefix + "sealed", PublicKey: public}
+	require.NoError(t, key.Validate())
+	assert.NotEmpty(t, key.Fingerprint)
+	key = SSHKey{PrivateKey: EncryptedPrefix + "sealed"}
+	assert.ErrorIs(t, key.Validate(), ErrInvalidSSHKey)
+}
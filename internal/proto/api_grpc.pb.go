@@ -25,18 +25,75 @@ const _ = grpc.SupportPackageIsVersion7
 type GophKeeperServerClient interface {
 	Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error)
 	SignIn(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error)
+	Refresh(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*RegisterResponse, error)
+	Logout(ctx context.Context, in *LogoutRequest, opts ...grpc.CallOption) (*LogoutResponse, error)
+	Sessions(ctx context.Context, in *SessionsRequest, opts ...grpc.CallOption) (*SessionsResponse, error)
+	RevokeSession(ctx context.Context, in *RevokeSessionRequest, opts ...grpc.CallOption) (*RevokeSessionResponse, error)
+	ChangePassword(ctx context.Context, in *ChangePasswordRequest, opts ...grpc.CallOption) (*ChangePasswordResponse, error)
+	ChangeLogin(ctx context.Context, in *ChangeLoginRequest, opts ...grpc.CallOption) (*ChangeLoginResponse, error)
+	DeleteAccount(ctx context.Context, in *DeleteAccountRequest, opts ...grpc.CallOption) (*DeleteAccountResponse, error)
+	SetupTOTP(ctx context.Context, in *SetupTOTPRequest, opts ...grpc.CallOption) (*SetupTOTPResponse, error)
+	ConfirmTOTP(ctx context.Context, in *ConfirmTOTPRequest, opts ...grpc.CallOption) (*ConfirmTOTPResponse, error)
+	DisableTOTP(ctx context.Context, in *DisableTOTPRequest, opts ...grpc.CallOption) (*DisableTOTPResponse, error)
+	Lockouts(ctx context.Context, in *LockoutsRequest, opts ...grpc.CallOption) (*LockoutsResponse, error)
+	AuditEvents(ctx context.Context, in *AuditRequest, opts ...grpc.CallOption) (*AuditResponse, error)
 	AddCard(ctx context.Context, in *AddCardRequest, opts ...grpc.CallOption) (*AddCardResponse, error)
 	AddLogin(ctx context.Context, in *AddLoginRequest, opts ...grpc.CallOption) (*AddLoginResponse, error)
 	AddText(ctx context.Context, in *AddTextRequest, opts ...grpc.CallOption) (*AddTextResponse, error)
 	AddBinary(ctx context.Context, in *AddBinRequest, opts ...grpc.CallOption) (*AddBinResponse, error)
+	AddSSHKey(ctx context.Context, in *AddSSHKeyRequest, opts ...grpc.CallOption) (*AddSSHKeyResponse, error)
+	ListCards(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (GophKeeperServer_ListCardsClient, error)
+	ListLogins(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (GophKeeperServer_ListLoginsClient, error)
+	ListTexts(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (GophKeeperServer_ListTextsClient, error)
+	ListBinaries(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (GophKeeperServer_ListBinariesClient, error)
+	ListSSHKeys(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (GophKeeperServer_ListSSHKeysClient, error)
 	Card(ctx context.Context, in *CardRequest, opts ...grpc.CallOption) (*CardResponse, error)
 	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error)
 	Text(ctx context.Context, in *TextRequest, opts ...grpc.CallOption) (*TextResponse, error)
 	Binary(ctx context.Context, in *BinRequest, opts ...grpc.CallOption) (*BinResponse, error)
+	SSHKey(ctx context.Context, in *SSHKeyRequest, opts ...grpc.CallOption) (*SSHKeyResponse, error)
+	UpdateCard(ctx context.Context, in *UpdateCardRequest, opts ...grpc.CallOption) (*UpdateCardResponse, error)
+	UpdateLogin(ctx context.Context, in *UpdateLoginRequest, opts ...grpc.CallOption) (*UpdateLoginResponse, error)
+	UpdateText(ctx context.Context, in *UpdateTextRequest, opts ...grpc.CallOption) (*UpdateTextResponse, error)
+	UpdateBinary(ctx context.Context, in *UpdateBinRequest, opts ...grpc.CallOption) (*UpdateBinResponse, error)
+	UpdateSSHKey(ctx context.Context, in *UpdateSSHKeyRequest, opts ...grpc.CallOption) (*UpdateSSHKeyResponse, error)
+	Trash(ctx context.Context, in *TrashRequest, opts ...grpc.CallOption) (*TrashResponse, error)
+	RestoreTrash(ctx context.Context, in *RestoreTrashRequest, opts ...grpc.CallOption) (*RestoreTrashResponse, error)
+	EmptyTrash(ctx context.Context, in *EmptyTrashRequest, opts ...grpc.CallOption) (*EmptyTrashResponse, error)
+	Vaults(ctx context.Context, in *VaultsRequest, opts ...grpc.CallOption) (*VaultsResponse, error)
+	AddVault(ctx context.Context, in *AddVaultRequest, opts ...grpc.CallOption) (*AddVaultResponse, error)
+	RenameVault(ctx context.Context, in *RenameVaultRequest, opts ...grpc.CallOption) (*RenameVaultResponse, error)
+	DeleteVault(ctx context.Context, in *DeleteVaultRequest, opts ...grpc.CallOption) (*DeleteVaultResponse, error)
+	Folders(ctx context.Context, in *FoldersRequest, opts ...grpc.CallOption) (*FoldersResponse, error)
+	AddFolder(ctx context.Context, in *AddFolderRequest, opts ...grpc.CallOption) (*AddFolderResponse, error)
+	UpdateFolder(ctx context.Context, in *UpdateFolderRequest, opts ...grpc.CallOption) (*UpdateFolderResponse, error)
+	DeleteFolder(ctx context.Context, in *DeleteFolderRequest, opts ...grpc.CallOption) (*DeleteFolderResponse, error)
+	MoveRecord(ctx context.Context, in *MoveRecordRequest, opts ...grpc.CallOption) (*MoveRecordResponse, error)
+	Revisions(ctx context.Context, in *RevisionsRequest, opts ...grpc.CallOption) (*RevisionsResponse, error)
+	Revision(ctx context.Context, in *RevisionRequest, opts ...grpc.CallOption) (*RevisionResponse, error)
+	RestoreRevision(ctx context.Context, in *RestoreRevisionRequest, opts ...grpc.CallOption) (*RestoreRevisionResponse, error)
 	DeleteCard(ctx context.Context, in *DeleteCardRequest, opts ...grpc.CallOption) (*DeleteCardResponse, error)
 	DeleteLogin(ctx context.Context, in *DeleteLoginRequest, opts ...grpc.CallOption) (*DeleteLoginResponse, error)
 	DeleteText(ctx context.Context, in *DeleteTextRequest, opts ...grpc.CallOption) (*DeleteTextResponse, error)
 	DeleteBinary(ctx context.Context, in *DeleteBinRequest, opts ...grpc.CallOption) (*DeleteBinResponse, error)
+	StartUpload(ctx context.Context, in *StartUploadRequest, opts ...grpc.CallOption) (*StartUploadResponse, error)
+	UploadState(ctx context.Context, in *UploadStateRequest, opts ...grpc.CallOption) (*UploadStateResponse, error)
+	UploadChunks(ctx context.Context, opts ...grpc.CallOption) (GophKeeperServer_UploadChunksClient, error)
+	CompleteUpload(ctx context.Context, in *CompleteUploadRequest, opts ...grpc.CallOption) (*CompleteUploadResponse, error)
+	DownloadBinary(ctx context.Context, in *DownloadBinaryRequest, opts ...grpc.CallOption) (GophKeeperServer_DownloadBinaryClient, error)
+	DeleteSSHKey(ctx context.Context, in *DeleteSSHKeyRequest, opts ...grpc.CallOption) (*DeleteSSHKeyResponse, error)
+	RecordTypes(ctx context.Context, in *RecordTypesRequest, opts ...grpc.CallOption) (*RecordTypesResponse, error)
+	RecordType(ctx context.Context, in *RecordTypeRequest, opts ...grpc.CallOption) (*RecordTypeResponse, error)
+	AddRecordType(ctx context.Context, in *AddRecordTypeRequest, opts ...grpc.CallOption) (*AddRecordTypeResponse, error)
+	UpdateRecordType(ctx context.Context, in *UpdateRecordTypeRequest, opts ...grpc.CallOption) (*UpdateRecordTypeResponse, error)
+	DeleteRecordType(ctx context.Context, in *DeleteRecordTypeRequest, opts ...grpc.CallOption) (*DeleteRecordTypeResponse, error)
+	ListCustomRecords(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (GophKeeperServer_ListCustomRecordsClient, error)
+	AddCustomRecord(ctx context.Context, in *AddCustomRecordRequest, opts ...grpc.CallOption) (*AddCustomRecordResponse, error)
+	CustomRecord(ctx context.Context, in *CustomRecordRequest, opts ...grpc.CallOption) (*CustomRecordResponse, error)
+	UpdateCustomRecord(ctx context.Context, in *UpdateCustomRecordRequest, opts ...grpc.CallOption) (*UpdateCustomRecordResponse, error)
+	DeleteCustomRecord(ctx context.Context, in *DeleteCustomRecordRequest, opts ...grpc.CallOption) (*DeleteCustomRecordResponse, error)
+	Vault(ctx context.Context, in *VaultRequest, opts ...grpc.CallOption) (*VaultResponse, error)
+	SetVaultCheck(ctx context.Context, in *SetVaultCheckRequest, opts ...grpc.CallOption) (*SetVaultCheckResponse, error)
 }
 
 type gophKeeperServerClient struct {
@@ -65,6 +122,114 @@ func (c *gophKeeperServerClient) SignIn(ctx context.Context, in *RegisterRequest
 	return out, nil
 }
 
+func (c *gophKeeperServerClient) Refresh(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*RegisterResponse, error) {
+	out := new(RegisterResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/Refresh", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) Logout(ctx context.Context, in *LogoutRequest, opts ...grpc.CallOption) (*LogoutResponse, error) {
+	out := new(LogoutResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/Logout", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) Sessions(ctx context.Context, in *SessionsRequest, opts ...grpc.CallOption) (*SessionsResponse, error) {
+	out := new(SessionsResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/Sessions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) RevokeSession(ctx context.Context, in *RevokeSessionRequest, opts ...grpc.CallOption) (*RevokeSessionResponse, error) {
+	out := new(RevokeSessionResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/RevokeSession", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) ChangePassword(ctx context.Context, in *ChangePasswordRequest, opts ...grpc.CallOption) (*ChangePasswordResponse, error) {
+	out := new(ChangePasswordResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/ChangePassword", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) ChangeLogin(ctx context.Context, in *ChangeLoginRequest, opts ...grpc.CallOption) (*ChangeLoginResponse, error) {
+	out := new(ChangeLoginResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/ChangeLogin", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) DeleteAccount(ctx context.Context, in *DeleteAccountRequest, opts ...grpc.CallOption) (*DeleteAccountResponse, error) {
+	out := new(DeleteAccountResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/DeleteAccount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) SetupTOTP(ctx context.Context, in *SetupTOTPRequest, opts ...grpc.CallOption) (*SetupTOTPResponse, error) {
+	out := new(SetupTOTPResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/SetupTOTP", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) ConfirmTOTP(ctx context.Context, in *ConfirmTOTPRequest, opts ...grpc.CallOption) (*ConfirmTOTPResponse, error) {
+	out := new(ConfirmTOTPResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/ConfirmTOTP", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) DisableTOTP(ctx context.Context, in *DisableTOTPRequest, opts ...grpc.CallOption) (*DisableTOTPResponse, error) {
+	out := new(DisableTOTPResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/DisableTOTP", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) Lockouts(ctx context.Context, in *LockoutsRequest, opts ...grpc.CallOption) (*LockoutsResponse, error) {
+	out := new(LockoutsResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/Lockouts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) AuditEvents(ctx context.Context, in *AuditRequest, opts ...grpc.CallOption) (*AuditResponse, error) {
+	out := new(AuditResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/AuditEvents", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *gophKeeperServerClient) AddCard(ctx context.Context, in *AddCardRequest, opts ...grpc.CallOption) (*AddCardResponse, error) {
 	out := new(AddCardResponse)
 	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/AddCard", in, out, opts...)
@@ -101,6 +266,175 @@ func (c *gophKeeperServerClient) AddBinary(ctx context.Context, in *AddBinReques
 	return out, nil
 }
 
+func (c *gophKeeperServerClient) AddSSHKey(ctx context.Context, in *AddSSHKeyRequest, opts ...grpc.CallOption) (*AddSSHKeyResponse, error) {
+	out := new(AddSSHKeyResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/AddSSHKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) ListCards(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (GophKeeperServer_ListCardsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &GophKeeperServer_ServiceDesc.Streams[0], "/proto.GophKeeperServer/ListCards", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &gophKeeperServerListCardsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type GophKeeperServer_ListCardsClient interface {
+	Recv() (*RecordInfo, error)
+	grpc.ClientStream
+}
+
+type gophKeeperServerListCardsClient struct {
+	grpc.ClientStream
+}
+
+func (x *gophKeeperServerListCardsClient) Recv() (*RecordInfo, error) {
+	m := new(RecordInfo)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *gophKeeperServerClient) ListLogins(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (GophKeeperServer_ListLoginsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &GophKeeperServer_ServiceDesc.Streams[1], "/proto.GophKeeperServer/ListLogins", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &gophKeeperServerListLoginsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type GophKeeperServer_ListLoginsClient interface {
+	Recv() (*RecordInfo, error)
+	grpc.ClientStream
+}
+
+type gophKeeperServerListLoginsClient struct {
+	grpc.ClientStream
+}
+
+func (x *gophKeeperServerListLoginsClient) Recv() (*RecordInfo, error) {
+	m := new(RecordInfo)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *gophKeeperServerClient) ListTexts(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (GophKeeperServer_ListTextsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &GophKeeperServer_ServiceDesc.Streams[2], "/proto.GophKeeperServer/ListTexts", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &gophKeeperServerListTextsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type GophKeeperServer_ListTextsClient interface {
+	Recv() (*RecordInfo, error)
+	grpc.ClientStream
+}
+
+type gophKeeperServerListTextsClient struct {
+	grpc.ClientStream
+}
+
+func (x *gophKeeperServerListTextsClient) Recv() (*RecordInfo, error) {
+	m := new(RecordInfo)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *gophKeeperServerClient) ListBinaries(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (GophKeeperServer_ListBinariesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &GophKeeperServer_ServiceDesc.Streams[3], "/proto.GophKeeperServer/ListBinaries", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &gophKeeperServerListBinariesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type GophKeeperServer_ListBinariesClient interface {
+	Recv() (*RecordInfo, error)
+	grpc.ClientStream
+}
+
+type gophKeeperServerListBinariesClient struct {
+	grpc.ClientStream
+}
+
+func (x *gophKeeperServerListBinariesClient) Recv() (*RecordInfo, error) {
+	m := new(RecordInfo)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *gophKeeperServerClient) ListSSHKeys(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (GophKeeperServer_ListSSHKeysClient, error) {
+	stream, err := c.cc.NewStream(ctx, &GophKeeperServer_ServiceDesc.Streams[4], "/proto.GophKeeperServer/ListSSHKeys", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &gophKeeperServerListSSHKeysClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type GophKeeperServer_ListSSHKeysClient interface {
+	Recv() (*RecordInfo, error)
+	grpc.ClientStream
+}
+
+type gophKeeperServerListSSHKeysClient struct {
+	grpc.ClientStream
+}
+
+func (x *gophKeeperServerListSSHKeysClient) Recv() (*RecordInfo, error) {
+	m := new(RecordInfo)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *gophKeeperServerClient) Card(ctx context.Context, in *CardRequest, opts ...grpc.CallOption) (*CardResponse, error) {
 	out := new(CardResponse)
 	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/Card", in, out, opts...)
@@ -137,370 +471,2075 @@ func (c *gophKeeperServerClient) Binary(ctx context.Context, in *BinRequest, opt
 	return out, nil
 }
 
-func (c *gophKeeperServerClient) DeleteCard(ctx context.Context, in *DeleteCardRequest, opts ...grpc.CallOption) (*DeleteCardResponse, error) {
-	out := new(DeleteCardResponse)
-	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/DeleteCard", in, out, opts...)
+func (c *gophKeeperServerClient) SSHKey(ctx context.Context, in *SSHKeyRequest, opts ...grpc.CallOption) (*SSHKeyResponse, error) {
+	out := new(SSHKeyResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/SSHKey", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *gophKeeperServerClient) DeleteLogin(ctx context.Context, in *DeleteLoginRequest, opts ...grpc.CallOption) (*DeleteLoginResponse, error) {
-	out := new(DeleteLoginResponse)
-	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/DeleteLogin", in, out, opts...)
+func (c *gophKeeperServerClient) UpdateCard(ctx context.Context, in *UpdateCardRequest, opts ...grpc.CallOption) (*UpdateCardResponse, error) {
+	out := new(UpdateCardResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/UpdateCard", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *gophKeeperServerClient) DeleteText(ctx context.Context, in *DeleteTextRequest, opts ...grpc.CallOption) (*DeleteTextResponse, error) {
-	out := new(DeleteTextResponse)
-	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/DeleteText", in, out, opts...)
+func (c *gophKeeperServerClient) UpdateLogin(ctx context.Context, in *UpdateLoginRequest, opts ...grpc.CallOption) (*UpdateLoginResponse, error) {
+	out := new(UpdateLoginResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/UpdateLogin", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *gophKeeperServerClient) DeleteBinary(ctx context.Context, in *DeleteBinRequest, opts ...grpc.CallOption) (*DeleteBinResponse, error) {
-	out := new(DeleteBinResponse)
-	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/DeleteBinary", in, out, opts...)
+func (c *gophKeeperServerClient) UpdateText(ctx context.Context, in *UpdateTextRequest, opts ...grpc.CallOption) (*UpdateTextResponse, error) {
+	out := new(UpdateTextResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/UpdateText", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-// GophKeeperServerServer is the server API for GophKeeperServer service.
-// All implementations must embed UnimplementedGophKeeperServerServer
-// for forward compatibility
-type GophKeeperServerServer interface {
-	Register(context.Context, *RegisterRequest) (*RegisterResponse, error)
-	SignIn(context.Context, *RegisterRequest) (*RegisterResponse, error)
-	AddCard(context.Context, *AddCardRequest) (*AddCardResponse, error)
-	AddLogin(context.Context, *AddLoginRequest) (*AddLoginResponse, error)
-	AddText(context.Context, *AddTextRequest) (*AddTextResponse, error)
-	AddBinary(context.Context, *AddBinRequest) (*AddBinResponse, error)
-	Card(context.Context, *CardRequest) (*CardResponse, error)
-	Login(context.Context, *LoginRequest) (*LoginResponse, error)
-	Text(context.Context, *TextRequest) (*TextResponse, error)
-	Binary(context.Context, *BinRequest) (*BinResponse, error)
-	DeleteCard(context.Context, *DeleteCardRequest) (*DeleteCardResponse, error)
-	DeleteLogin(context.Context, *DeleteLoginRequest) (*DeleteLoginResponse, error)
-	DeleteText(context.Context, *DeleteTextRequest) (*DeleteTextResponse, error)
-	DeleteBinary(context.Context, *DeleteBinRequest) (*DeleteBinResponse, error)
-	mustEmbedUnimplementedGophKeeperServerServer()
+func (c *gophKeeperServerClient) UpdateBinary(ctx context.Context, in *UpdateBinRequest, opts ...grpc.CallOption) (*UpdateBinResponse, error) {
+	out := new(UpdateBinResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/UpdateBinary", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-// UnimplementedGophKeeperServerServer must be embedded to have forward compatible implementations.
-type UnimplementedGophKeeperServerServer struct {
+func (c *gophKeeperServerClient) UpdateSSHKey(ctx context.Context, in *UpdateSSHKeyRequest, opts ...grpc.CallOption) (*UpdateSSHKeyResponse, error) {
+	out := new(UpdateSSHKeyResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/UpdateSSHKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-func (UnimplementedGophKeeperServerServer) Register(context.Context, *RegisterRequest) (*RegisterResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
-}
-func (UnimplementedGophKeeperServerServer) SignIn(context.Context, *RegisterRequest) (*RegisterResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method SignIn not implemented")
+func (c *gophKeeperServerClient) Trash(ctx context.Context, in *TrashRequest, opts ...grpc.CallOption) (*TrashResponse, error) {
+	out := new(TrashResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/Trash", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedGophKeeperServerServer) AddCard(context.Context, *AddCardRequest) (*AddCardResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method AddCard not implemented")
+
+func (c *gophKeeperServerClient) RestoreTrash(ctx context.Context, in *RestoreTrashRequest, opts ...grpc.CallOption) (*RestoreTrashResponse, error) {
+	out := new(RestoreTrashResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/RestoreTrash", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedGophKeeperServerServer) AddLogin(context.Context, *AddLoginRequest) (*AddLoginResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method AddLogin not implemented")
+
+func (c *gophKeeperServerClient) EmptyTrash(ctx context.Context, in *EmptyTrashRequest, opts ...grpc.CallOption) (*EmptyTrashResponse, error) {
+	out := new(EmptyTrashResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/EmptyTrash", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedGophKeeperServerServer) AddText(context.Context, *AddTextRequest) (*AddTextResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method AddText not implemented")
+
+func (c *gophKeeperServerClient) Vaults(ctx context.Context, in *VaultsRequest, opts ...grpc.CallOption) (*VaultsResponse, error) {
+	out := new(VaultsResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/Vaults", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedGophKeeperServerServer) AddBinary(context.Context, *AddBinRequest) (*AddBinResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method AddBinary not implemented")
+
+func (c *gophKeeperServerClient) AddVault(ctx context.Context, in *AddVaultRequest, opts ...grpc.CallOption) (*AddVaultResponse, error) {
+	out := new(AddVaultResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/AddVault", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedGophKeeperServerServer) Card(context.Context, *CardRequest) (*CardResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Card not implemented")
+
+func (c *gophKeeperServerClient) RenameVault(ctx context.Context, in *RenameVaultRequest, opts ...grpc.CallOption) (*RenameVaultResponse, error) {
+	out := new(RenameVaultResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/RenameVault", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedGophKeeperServerServer) Login(context.Context, *LoginRequest) (*LoginResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+
+func (c *gophKeeperServerClient) DeleteVault(ctx context.Context, in *DeleteVaultRequest, opts ...grpc.CallOption) (*DeleteVaultResponse, error) {
+	out := new(DeleteVaultResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/DeleteVault", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) Folders(ctx context.Context, in *FoldersRequest, opts ...grpc.CallOption) (*FoldersResponse, error) {
+	out := new(FoldersResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/Folders", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) AddFolder(ctx context.Context, in *AddFolderRequest, opts ...grpc.CallOption) (*AddFolderResponse, error) {
+	out := new(AddFolderResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/AddFolder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) UpdateFolder(ctx context.Context, in *UpdateFolderRequest, opts ...grpc.CallOption) (*UpdateFolderResponse, error) {
+	out := new(UpdateFolderResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/UpdateFolder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) DeleteFolder(ctx context.Context, in *DeleteFolderRequest, opts ...grpc.CallOption) (*DeleteFolderResponse, error) {
+	out := new(DeleteFolderResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/DeleteFolder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) MoveRecord(ctx context.Context, in *MoveRecordRequest, opts ...grpc.CallOption) (*MoveRecordResponse, error) {
+	out := new(MoveRecordResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/MoveRecord", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) Revisions(ctx context.Context, in *RevisionsRequest, opts ...grpc.CallOption) (*RevisionsResponse, error) {
+	out := new(RevisionsResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/Revisions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) Revision(ctx context.Context, in *RevisionRequest, opts ...grpc.CallOption) (*RevisionResponse, error) {
+	out := new(RevisionResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/Revision", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) RestoreRevision(ctx context.Context, in *RestoreRevisionRequest, opts ...grpc.CallOption) (*RestoreRevisionResponse, error) {
+	out := new(RestoreRevisionResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/RestoreRevision", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) DeleteCard(ctx context.Context, in *DeleteCardRequest, opts ...grpc.CallOption) (*DeleteCardResponse, error) {
+	out := new(DeleteCardResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/DeleteCard", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) DeleteLogin(ctx context.Context, in *DeleteLoginRequest, opts ...grpc.CallOption) (*DeleteLoginResponse, error) {
+	out := new(DeleteLoginResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/DeleteLogin", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) DeleteText(ctx context.Context, in *DeleteTextRequest, opts ...grpc.CallOption) (*DeleteTextResponse, error) {
+	out := new(DeleteTextResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/DeleteText", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) DeleteBinary(ctx context.Context, in *DeleteBinRequest, opts ...grpc.CallOption) (*DeleteBinResponse, error) {
+	out := new(DeleteBinResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/DeleteBinary", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) StartUpload(ctx context.Context, in *StartUploadRequest, opts ...grpc.CallOption) (*StartUploadResponse, error) {
+	out := new(StartUploadResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/StartUpload", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) UploadState(ctx context.Context, in *UploadStateRequest, opts ...grpc.CallOption) (*UploadStateResponse, error) {
+	out := new(UploadStateResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/UploadState", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) UploadChunks(ctx context.Context, opts ...grpc.CallOption) (GophKeeperServer_UploadChunksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &GophKeeperServer_ServiceDesc.Streams[5], "/proto.GophKeeperServer/UploadChunks", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &gophKeeperServerUploadChunksClient{stream}
+	return x, nil
+}
+
+type GophKeeperServer_UploadChunksClient interface {
+	Send(*UploadChunk) error
+	CloseAndRecv() (*UploadChunksResponse, error)
+	grpc.ClientStream
+}
+
+type gophKeeperServerUploadChunksClient struct {
+	grpc.ClientStream
+}
+
+func (x *gophKeeperServerUploadChunksClient) Send(m *UploadChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *gophKeeperServerUploadChunksClient) CloseAndRecv() (*UploadChunksResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(UploadChunksResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *gophKeeperServerClient) CompleteUpload(ctx context.Context, in *CompleteUploadRequest, opts ...grpc.CallOption) (*CompleteUploadResponse, error) {
+	out := new(CompleteUploadResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/CompleteUpload", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) DownloadBinary(ctx context.Context, in *DownloadBinaryRequest, opts ...grpc.CallOption) (GophKeeperServer_DownloadBinaryClient, error) {
+	stream, err := c.cc.NewStream(ctx, &GophKeeperServer_ServiceDesc.Streams[6], "/proto.GophKeeperServer/DownloadBinary", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &gophKeeperServerDownloadBinaryClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type GophKeeperServer_DownloadBinaryClient interface {
+	Recv() (*BinaryContent, error)
+	grpc.ClientStream
+}
+
+type gophKeeperServerDownloadBinaryClient struct {
+	grpc.ClientStream
+}
+
+func (x *gophKeeperServerDownloadBinaryClient) Recv() (*BinaryContent, error) {
+	m := new(BinaryContent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *gophKeeperServerClient) DeleteSSHKey(ctx context.Context, in *DeleteSSHKeyRequest, opts ...grpc.CallOption) (*DeleteSSHKeyResponse, error) {
+	out := new(DeleteSSHKeyResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/DeleteSSHKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) RecordTypes(ctx context.Context, in *RecordTypesRequest, opts ...grpc.CallOption) (*RecordTypesResponse, error) {
+	out := new(RecordTypesResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/RecordTypes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) RecordType(ctx context.Context, in *RecordTypeRequest, opts ...grpc.CallOption) (*RecordTypeResponse, error) {
+	out := new(RecordTypeResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/RecordType", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) AddRecordType(ctx context.Context, in *AddRecordTypeRequest, opts ...grpc.CallOption) (*AddRecordTypeResponse, error) {
+	out := new(AddRecordTypeResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/AddRecordType", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) UpdateRecordType(ctx context.Context, in *UpdateRecordTypeRequest, opts ...grpc.CallOption) (*UpdateRecordTypeResponse, error) {
+	out := new(UpdateRecordTypeResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/UpdateRecordType", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) DeleteRecordType(ctx context.Context, in *DeleteRecordTypeRequest, opts ...grpc.CallOption) (*DeleteRecordTypeResponse, error) {
+	out := new(DeleteRecordTypeResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/DeleteRecordType", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) ListCustomRecords(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (GophKeeperServer_ListCustomRecordsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &GophKeeperServer_ServiceDesc.Streams[7], "/proto.GophKeeperServer/ListCustomRecords", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &gophKeeperServerListCustomRecordsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type GophKeeperServer_ListCustomRecordsClient interface {
+	Recv() (*RecordInfo, error)
+	grpc.ClientStream
+}
+
+type gophKeeperServerListCustomRecordsClient struct {
+	grpc.ClientStream
+}
+
+func (x *gophKeeperServerListCustomRecordsClient) Recv() (*RecordInfo, error) {
+	m := new(RecordInfo)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *gophKeeperServerClient) AddCustomRecord(ctx context.Context, in *AddCustomRecordRequest, opts ...grpc.CallOption) (*AddCustomRecordResponse, error) {
+	out := new(AddCustomRecordResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/AddCustomRecord", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) CustomRecord(ctx context.Context, in *CustomRecordRequest, opts ...grpc.CallOption) (*CustomRecordResponse, error) {
+	out := new(CustomRecordResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/CustomRecord", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) UpdateCustomRecord(ctx context.Context, in *UpdateCustomRecordRequest, opts ...grpc.CallOption) (*UpdateCustomRecordResponse, error) {
+	out := new(UpdateCustomRecordResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/UpdateCustomRecord", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) DeleteCustomRecord(ctx context.Context, in *DeleteCustomRecordRequest, opts ...grpc.CallOption) (*DeleteCustomRecordResponse, error) {
+	out := new(DeleteCustomRecordResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/DeleteCustomRecord", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) Vault(ctx context.Context, in *VaultRequest, opts ...grpc.CallOption) (*VaultResponse, error) {
+	out := new(VaultResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/Vault", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServerClient) SetVaultCheck(ctx context.Context, in *SetVaultCheckRequest, opts ...grpc.CallOption) (*SetVaultCheckResponse, error) {
+	out := new(SetVaultCheckResponse)
+	err := c.cc.Invoke(ctx, "/proto.GophKeeperServer/SetVaultCheck", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GophKeeperServerServer is the server API for GophKeeperServer service.
+// All implementations must embed UnimplementedGophKeeperServerServer
+// for forward compatibility
+type GophKeeperServerServer interface {
+	Register(context.Context, *RegisterRequest) (*RegisterResponse, error)
+	SignIn(context.Context, *RegisterRequest) (*RegisterResponse, error)
+	Refresh(context.Context, *RefreshRequest) (*RegisterResponse, error)
+	Logout(context.Context, *LogoutRequest) (*LogoutResponse, error)
+	Sessions(context.Context, *SessionsRequest) (*SessionsResponse, error)
+	RevokeSession(context.Context, *RevokeSessionRequest) (*RevokeSessionResponse, error)
+	ChangePassword(context.Context, *ChangePasswordRequest) (*ChangePasswordResponse, error)
+	ChangeLogin(context.Context, *ChangeLoginRequest) (*ChangeLoginResponse, error)
+	DeleteAccount(context.Context, *DeleteAccountRequest) (*DeleteAccountResponse, error)
+	SetupTOTP(context.Context, *SetupTOTPRequest) (*SetupTOTPResponse, error)
+	ConfirmTOTP(context.Context, *ConfirmTOTPRequest) (*ConfirmTOTPResponse, error)
+	DisableTOTP(context.Context, *DisableTOTPRequest) (*DisableTOTPResponse, error)
+	Lockouts(context.Context, *LockoutsRequest) (*LockoutsResponse, error)
+	AuditEvents(context.Context, *AuditRequest) (*AuditResponse, error)
+	AddCard(context.Context, *AddCardRequest) (*AddCardResponse, error)
+	AddLogin(context.Context, *AddLoginRequest) (*AddLoginResponse, error)
+	AddText(context.Context, *AddTextRequest) (*AddTextResponse, error)
+	AddBinary(context.Context, *AddBinRequest) (*AddBinResponse, error)
+	AddSSHKey(context.Context, *AddSSHKeyRequest) (*AddSSHKeyResponse, error)
+	ListCards(*ListRequest, GophKeeperServer_ListCardsServer) error
+	ListLogins(*ListRequest, GophKeeperServer_ListLoginsServer) error
+	ListTexts(*ListRequest, GophKeeperServer_ListTextsServer) error
+	ListBinaries(*ListRequest, GophKeeperServer_ListBinariesServer) error
+	ListSSHKeys(*ListRequest, GophKeeperServer_ListSSHKeysServer) error
+	Card(context.Context, *CardRequest) (*CardResponse, error)
+	Login(context.Context, *LoginRequest) (*LoginResponse, error)
+	Text(context.Context, *TextRequest) (*TextResponse, error)
+	Binary(context.Context, *BinRequest) (*BinResponse, error)
+	SSHKey(context.Context, *SSHKeyRequest) (*SSHKeyResponse, error)
+	UpdateCard(context.Context, *UpdateCardRequest) (*UpdateCardResponse, error)
+	UpdateLogin(context.Context, *UpdateLoginRequest) (*UpdateLoginResponse, error)
+	UpdateText(context.Context, *UpdateTextRequest) (*UpdateTextResponse, error)
+	UpdateBinary(context.Context, *UpdateBinRequest) (*UpdateBinResponse, error)
+	UpdateSSHKey(context.Context, *UpdateSSHKeyRequest) (*UpdateSSHKeyResponse, error)
+	Trash(context.Context, *TrashRequest) (*TrashResponse, error)
+	RestoreTrash(context.Context, *RestoreTrashRequest) (*RestoreTrashResponse, error)
+	EmptyTrash(context.Context, *EmptyTrashRequest) (*EmptyTrashResponse, error)
+	Vaults(context.Context, *VaultsRequest) (*VaultsResponse, error)
+	AddVault(context.Context, *AddVaultRequest) (*AddVaultResponse, error)
+	RenameVault(context.Context, *RenameVaultRequest) (*RenameVaultResponse, error)
+	DeleteVault(context.Context, *DeleteVaultRequest) (*DeleteVaultResponse, error)
+	Folders(context.Context, *FoldersRequest) (*FoldersResponse, error)
+	AddFolder(context.Context, *AddFolderRequest) (*AddFolderResponse, error)
+	UpdateFolder(context.Context, *UpdateFolderRequest) (*UpdateFolderResponse, error)
+	DeleteFolder(context.Context, *DeleteFolderRequest) (*DeleteFolderResponse, error)
+	MoveRecord(context.Context, *MoveRecordRequest) (*MoveRecordResponse, error)
+	Revisions(context.Context, *RevisionsRequest) (*RevisionsResponse, error)
+	Revision(context.Context, *RevisionRequest) (*RevisionResponse, error)
+	RestoreRevision(context.Context, *RestoreRevisionRequest) (*RestoreRevisionResponse, error)
+	DeleteCard(context.Context, *DeleteCardRequest) (*DeleteCardResponse, error)
+	DeleteLogin(context.Context, *DeleteLoginRequest) (*DeleteLoginResponse, error)
+	DeleteText(context.Context, *DeleteTextRequest) (*DeleteTextResponse, error)
+	DeleteBinary(context.Context, *DeleteBinRequest) (*DeleteBinResponse, error)
+	StartUpload(context.Context, *StartUploadRequest) (*StartUploadResponse, error)
+	UploadState(context.Context, *UploadStateRequest) (*UploadStateResponse, error)
+	UploadChunks(GophKeeperServer_UploadChunksServer) error
+	CompleteUpload(context.Context, *CompleteUploadRequest) (*CompleteUploadResponse, error)
+	DownloadBinary(*DownloadBinaryRequest, GophKeeperServer_DownloadBinaryServer) error
+	DeleteSSHKey(context.Context, *DeleteSSHKeyRequest) (*DeleteSSHKeyResponse, error)
+	RecordTypes(context.Context, *RecordTypesRequest) (*RecordTypesResponse, error)
+	RecordType(context.Context, *RecordTypeRequest) (*RecordTypeResponse, error)
+	AddRecordType(context.Context, *AddRecordTypeRequest) (*AddRecordTypeResponse, error)
+	UpdateRecordType(context.Context, *UpdateRecordTypeRequest) (*UpdateRecordTypeResponse, error)
+	DeleteRecordType(context.Context, *DeleteRecordTypeRequest) (*DeleteRecordTypeResponse, error)
+	ListCustomRecords(*ListRequest, GophKeeperServer_ListCustomRecordsServer) error
+	AddCustomRecord(context.Context, *AddCustomRecordRequest) (*AddCustomRecordResponse, error)
+	CustomRecord(context.Context, *CustomRecordRequest) (*CustomRecordResponse, error)
+	UpdateCustomRecord(context.Context, *UpdateCustomRecordRequest) (*UpdateCustomRecordResponse, error)
+	DeleteCustomRecord(context.Context, *DeleteCustomRecordRequest) (*DeleteCustomRecordResponse, error)
+	Vault(context.Context, *VaultRequest) (*VaultResponse, error)
+	SetVaultCheck(context.Context, *SetVaultCheckRequest) (*SetVaultCheckResponse, error)
+	mustEmbedUnimplementedGophKeeperServerServer()
+}
+
+// UnimplementedGophKeeperServerServer must be embedded to have forward compatible implementations.
+type UnimplementedGophKeeperServerServer struct {
+}
+
+func (UnimplementedGophKeeperServerServer) Register(context.Context, *RegisterRequest) (*RegisterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
+func (UnimplementedGophKeeperServerServer) SignIn(context.Context, *RegisterRequest) (*RegisterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SignIn not implemented")
+}
+func (UnimplementedGophKeeperServerServer) Refresh(context.Context, *RefreshRequest) (*RegisterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Refresh not implemented")
+}
+func (UnimplementedGophKeeperServerServer) Logout(context.Context, *LogoutRequest) (*LogoutResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Logout not implemented")
+}
+func (UnimplementedGophKeeperServerServer) Sessions(context.Context, *SessionsRequest) (*SessionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Sessions not implemented")
+}
+func (UnimplementedGophKeeperServerServer) RevokeSession(context.Context, *RevokeSessionRequest) (*RevokeSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeSession not implemented")
+}
+func (UnimplementedGophKeeperServerServer) ChangePassword(context.Context, *ChangePasswordRequest) (*ChangePasswordResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangePassword not implemented")
+}
+func (UnimplementedGophKeeperServerServer) ChangeLogin(context.Context, *ChangeLoginRequest) (*ChangeLoginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangeLogin not implemented")
+}
+func (UnimplementedGophKeeperServerServer) DeleteAccount(context.Context, *DeleteAccountRequest) (*DeleteAccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteAccount not implemented")
+}
+func (UnimplementedGophKeeperServerServer) SetupTOTP(context.Context, *SetupTOTPRequest) (*SetupTOTPResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetupTOTP not implemented")
+}
+func (UnimplementedGophKeeperServerServer) ConfirmTOTP(context.Context, *ConfirmTOTPRequest) (*ConfirmTOTPResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmTOTP not implemented")
+}
+func (UnimplementedGophKeeperServerServer) DisableTOTP(context.Context, *DisableTOTPRequest) (*DisableTOTPResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DisableTOTP not implemented")
+}
+func (UnimplementedGophKeeperServerServer) Lockouts(context.Context, *LockoutsRequest) (*LockoutsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Lockouts not implemented")
+}
+func (UnimplementedGophKeeperServerServer) AuditEvents(context.Context, *AuditRequest) (*AuditResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AuditEvents not implemented")
+}
+func (UnimplementedGophKeeperServerServer) AddCard(context.Context, *AddCardRequest) (*AddCardResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddCard not implemented")
+}
+func (UnimplementedGophKeeperServerServer) AddLogin(context.Context, *AddLoginRequest) (*AddLoginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddLogin not implemented")
+}
+func (UnimplementedGophKeeperServerServer) AddText(context.Context, *AddTextRequest) (*AddTextResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddText not implemented")
+}
+func (UnimplementedGophKeeperServerServer) AddBinary(context.Context, *AddBinRequest) (*AddBinResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddBinary not implemented")
+}
+func (UnimplementedGophKeeperServerServer) AddSSHKey(context.Context, *AddSSHKeyRequest) (*AddSSHKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddSSHKey not implemented")
+}
+func (UnimplementedGophKeeperServerServer) ListCards(*ListRequest, GophKeeperServer_ListCardsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListCards not implemented")
+}
+func (UnimplementedGophKeeperServerServer) ListLogins(*ListRequest, GophKeeperServer_ListLoginsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListLogins not implemented")
+}
+func (UnimplementedGophKeeperServerServer) ListTexts(*ListRequest, GophKeeperServer_ListTextsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListTexts not implemented")
+}
+func (UnimplementedGophKeeperServerServer) ListBinaries(*ListRequest, GophKeeperServer_ListBinariesServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListBinaries not implemented")
+}
+func (UnimplementedGophKeeperServerServer) ListSSHKeys(*ListRequest, GophKeeperServer_ListSSHKeysServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListSSHKeys not implemented")
+}
+func (UnimplementedGophKeeperServerServer) Card(context.Context, *CardRequest) (*CardResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Card not implemented")
+}
+func (UnimplementedGophKeeperServerServer) Login(context.Context, *LoginRequest) (*LoginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
 }
 func (UnimplementedGophKeeperServerServer) Text(context.Context, *TextRequest) (*TextResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Text not implemented")
 }
-func (UnimplementedGophKeeperServerServer) Binary(context.Context, *BinRequest) (*BinResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Binary not implemented")
+func (UnimplementedGophKeeperServerServer) Binary(context.Context, *BinRequest) (*BinResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Binary not implemented")
+}
+func (UnimplementedGophKeeperServerServer) SSHKey(context.Context, *SSHKeyRequest) (*SSHKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SSHKey not implemented")
+}
+func (UnimplementedGophKeeperServerServer) UpdateCard(context.Context, *UpdateCardRequest) (*UpdateCardResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCard not implemented")
+}
+func (UnimplementedGophKeeperServerServer) UpdateLogin(context.Context, *UpdateLoginRequest) (*UpdateLoginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateLogin not implemented")
+}
+func (UnimplementedGophKeeperServerServer) UpdateText(context.Context, *UpdateTextRequest) (*UpdateTextResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateText not implemented")
+}
+func (UnimplementedGophKeeperServerServer) UpdateBinary(context.Context, *UpdateBinRequest) (*UpdateBinResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateBinary not implemented")
+}
+func (UnimplementedGophKeeperServerServer) UpdateSSHKey(context.Context, *UpdateSSHKeyRequest) (*UpdateSSHKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateSSHKey not implemented")
+}
+func (UnimplementedGophKeeperServerServer) Trash(context.Context, *TrashRequest) (*TrashResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Trash not implemented")
+}
+func (UnimplementedGophKeeperServerServer) RestoreTrash(context.Context, *RestoreTrashRequest) (*RestoreTrashResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreTrash not implemented")
+}
+func (UnimplementedGophKeeperServerServer) EmptyTrash(context.Context, *EmptyTrashRequest) (*EmptyTrashResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EmptyTrash not implemented")
+}
+func (UnimplementedGophKeeperServerServer) Vaults(context.Context, *VaultsRequest) (*VaultsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Vaults not implemented")
+}
+func (UnimplementedGophKeeperServerServer) AddVault(context.Context, *AddVaultRequest) (*AddVaultResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddVault not implemented")
+}
+func (UnimplementedGophKeeperServerServer) RenameVault(context.Context, *RenameVaultRequest) (*RenameVaultResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenameVault not implemented")
+}
+func (UnimplementedGophKeeperServerServer) DeleteVault(context.Context, *DeleteVaultRequest) (*DeleteVaultResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteVault not implemented")
+}
+func (UnimplementedGophKeeperServerServer) Folders(context.Context, *FoldersRequest) (*FoldersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Folders not implemented")
+}
+func (UnimplementedGophKeeperServerServer) AddFolder(context.Context, *AddFolderRequest) (*AddFolderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddFolder not implemented")
+}
+func (UnimplementedGophKeeperServerServer) UpdateFolder(context.Context, *UpdateFolderRequest) (*UpdateFolderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateFolder not implemented")
+}
+func (UnimplementedGophKeeperServerServer) DeleteFolder(context.Context, *DeleteFolderRequest) (*DeleteFolderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteFolder not implemented")
+}
+func (UnimplementedGophKeeperServerServer) MoveRecord(context.Context, *MoveRecordRequest) (*MoveRecordResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MoveRecord not implemented")
+}
+func (UnimplementedGophKeeperServerServer) Revisions(context.Context, *RevisionsRequest) (*RevisionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Revisions not implemented")
+}
+func (UnimplementedGophKeeperServerServer) Revision(context.Context, *RevisionRequest) (*RevisionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Revision not implemented")
+}
+func (UnimplementedGophKeeperServerServer) RestoreRevision(context.Context, *RestoreRevisionRequest) (*RestoreRevisionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreRevision not implemented")
+}
+func (UnimplementedGophKeeperServerServer) DeleteCard(context.Context, *DeleteCardRequest) (*DeleteCardResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCard not implemented")
+}
+func (UnimplementedGophKeeperServerServer) DeleteLogin(context.Context, *DeleteLoginRequest) (*DeleteLoginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteLogin not implemented")
+}
+func (UnimplementedGophKeeperServerServer) DeleteText(context.Context, *DeleteTextRequest) (*DeleteTextResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteText not implemented")
+}
+func (UnimplementedGophKeeperServerServer) DeleteBinary(context.Context, *DeleteBinRequest) (*DeleteBinResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteBinary not implemented")
+}
+func (UnimplementedGophKeeperServerServer) StartUpload(context.Context, *StartUploadRequest) (*StartUploadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartUpload not implemented")
+}
+func (UnimplementedGophKeeperServerServer) UploadState(context.Context, *UploadStateRequest) (*UploadStateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UploadState not implemented")
+}
+func (UnimplementedGophKeeperServerServer) UploadChunks(GophKeeperServer_UploadChunksServer) error {
+	return status.Errorf(codes.Unimplemented, "method UploadChunks not implemented")
+}
+func (UnimplementedGophKeeperServerServer) CompleteUpload(context.Context, *CompleteUploadRequest) (*CompleteUploadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompleteUpload not implemented")
+}
+func (UnimplementedGophKeeperServerServer) DownloadBinary(*DownloadBinaryRequest, GophKeeperServer_DownloadBinaryServer) error {
+	return status.Errorf(codes.Unimplemented, "method DownloadBinary not implemented")
+}
+func (UnimplementedGophKeeperServerServer) DeleteSSHKey(context.Context, *DeleteSSHKeyRequest) (*DeleteSSHKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSSHKey not implemented")
+}
+func (UnimplementedGophKeeperServerServer) RecordTypes(context.Context, *RecordTypesRequest) (*RecordTypesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecordTypes not implemented")
+}
+func (UnimplementedGophKeeperServerServer) RecordType(context.Context, *RecordTypeRequest) (*RecordTypeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecordType not implemented")
+}
+func (UnimplementedGophKeeperServerServer) AddRecordType(context.Context, *AddRecordTypeRequest) (*AddRecordTypeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddRecordType not implemented")
+}
+func (UnimplementedGophKeeperServerServer) UpdateRecordType(context.Context, *UpdateRecordTypeRequest) (*UpdateRecordTypeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateRecordType not implemented")
+}
+func (UnimplementedGophKeeperServerServer) DeleteRecordType(context.Context, *DeleteRecordTypeRequest) (*DeleteRecordTypeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteRecordType not implemented")
+}
+func (UnimplementedGophKeeperServerServer) ListCustomRecords(*ListRequest, GophKeeperServer_ListCustomRecordsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListCustomRecords not implemented")
+}
+func (UnimplementedGophKeeperServerServer) AddCustomRecord(context.Context, *AddCustomRecordRequest) (*AddCustomRecordResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddCustomRecord not implemented")
+}
+func (UnimplementedGophKeeperServerServer) CustomRecord(context.Context, *CustomRecordRequest) (*CustomRecordResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CustomRecord not implemented")
+}
+func (UnimplementedGophKeeperServerServer) UpdateCustomRecord(context.Context, *UpdateCustomRecordRequest) (*UpdateCustomRecordResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCustomRecord not implemented")
+}
+func (UnimplementedGophKeeperServerServer) DeleteCustomRecord(context.Context, *DeleteCustomRecordRequest) (*DeleteCustomRecordResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCustomRecord not implemented")
+}
+func (UnimplementedGophKeeperServerServer) Vault(context.Context, *VaultRequest) (*VaultResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Vault not implemented")
+}
+func (UnimplementedGophKeeperServerServer) SetVaultCheck(context.Context, *SetVaultCheckRequest) (*SetVaultCheckResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetVaultCheck not implemented")
+}
+func (UnimplementedGophKeeperServerServer) mustEmbedUnimplementedGophKeeperServerServer() {}
+
+// UnsafeGophKeeperServerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GophKeeperServerServer will
+// result in compilation errors.
+type UnsafeGophKeeperServerServer interface {
+	mustEmbedUnimplementedGophKeeperServerServer()
+}
+
+func RegisterGophKeeperServerServer(s grpc.ServiceRegistrar, srv GophKeeperServerServer) {
+	s.RegisterService(&GophKeeperServer_ServiceDesc, srv)
+}
+
+func _GophKeeperServer_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/Register",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).Register(ctx, req.(*RegisterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_SignIn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).SignIn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/SignIn",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).SignIn(ctx, req.(*RegisterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_Refresh_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefreshRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).Refresh(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/Refresh",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).Refresh(ctx, req.(*RefreshRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_Logout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogoutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).Logout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/Logout",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).Logout(ctx, req.(*LogoutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_Sessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).Sessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/Sessions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).Sessions(ctx, req.(*SessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_RevokeSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).RevokeSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/RevokeSession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).RevokeSession(ctx, req.(*RevokeSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_ChangePassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangePasswordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).ChangePassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/ChangePassword",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).ChangePassword(ctx, req.(*ChangePasswordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_ChangeLogin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangeLoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).ChangeLogin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/ChangeLogin",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).ChangeLogin(ctx, req.(*ChangeLoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_DeleteAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).DeleteAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/DeleteAccount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).DeleteAccount(ctx, req.(*DeleteAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_SetupTOTP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetupTOTPRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).SetupTOTP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/SetupTOTP",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).SetupTOTP(ctx, req.(*SetupTOTPRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_ConfirmTOTP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfirmTOTPRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).ConfirmTOTP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/ConfirmTOTP",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).ConfirmTOTP(ctx, req.(*ConfirmTOTPRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_DisableTOTP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DisableTOTPRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).DisableTOTP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/DisableTOTP",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).DisableTOTP(ctx, req.(*DisableTOTPRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_Lockouts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LockoutsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).Lockouts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/Lockouts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).Lockouts(ctx, req.(*LockoutsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_AuditEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuditRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).AuditEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/AuditEvents",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).AuditEvents(ctx, req.(*AuditRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_AddCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).AddCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/AddCard",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).AddCard(ctx, req.(*AddCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_AddLogin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddLoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).AddLogin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/AddLogin",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).AddLogin(ctx, req.(*AddLoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_AddText_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddTextRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).AddText(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/AddText",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).AddText(ctx, req.(*AddTextRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_AddBinary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddBinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).AddBinary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/AddBinary",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).AddBinary(ctx, req.(*AddBinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_AddSSHKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddSSHKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).AddSSHKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/AddSSHKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).AddSSHKey(ctx, req.(*AddSSHKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_ListCards_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GophKeeperServerServer).ListCards(m, &gophKeeperServerListCardsServer{stream})
+}
+
+type GophKeeperServer_ListCardsServer interface {
+	Send(*RecordInfo) error
+	grpc.ServerStream
+}
+
+type gophKeeperServerListCardsServer struct {
+	grpc.ServerStream
+}
+
+func (x *gophKeeperServerListCardsServer) Send(m *RecordInfo) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _GophKeeperServer_ListLogins_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GophKeeperServerServer).ListLogins(m, &gophKeeperServerListLoginsServer{stream})
+}
+
+type GophKeeperServer_ListLoginsServer interface {
+	Send(*RecordInfo) error
+	grpc.ServerStream
+}
+
+type gophKeeperServerListLoginsServer struct {
+	grpc.ServerStream
+}
+
+func (x *gophKeeperServerListLoginsServer) Send(m *RecordInfo) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _GophKeeperServer_ListTexts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GophKeeperServerServer).ListTexts(m, &gophKeeperServerListTextsServer{stream})
+}
+
+type GophKeeperServer_ListTextsServer interface {
+	Send(*RecordInfo) error
+	grpc.ServerStream
+}
+
+type gophKeeperServerListTextsServer struct {
+	grpc.ServerStream
+}
+
+func (x *gophKeeperServerListTextsServer) Send(m *RecordInfo) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _GophKeeperServer_ListBinaries_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GophKeeperServerServer).ListBinaries(m, &gophKeeperServerListBinariesServer{stream})
+}
+
+type GophKeeperServer_ListBinariesServer interface {
+	Send(*RecordInfo) error
+	grpc.ServerStream
+}
+
+type gophKeeperServerListBinariesServer struct {
+	grpc.ServerStream
+}
+
+func (x *gophKeeperServerListBinariesServer) Send(m *RecordInfo) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _GophKeeperServer_ListSSHKeys_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GophKeeperServerServer).ListSSHKeys(m, &gophKeeperServerListSSHKeysServer{stream})
+}
+
+type GophKeeperServer_ListSSHKeysServer interface {
+	Send(*RecordInfo) error
+	grpc.ServerStream
+}
+
+type gophKeeperServerListSSHKeysServer struct {
+	grpc.ServerStream
+}
+
+func (x *gophKeeperServerListSSHKeysServer) Send(m *RecordInfo) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _GophKeeperServer_Card_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).Card(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/Card",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).Card(ctx, req.(*CardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/Login",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).Login(ctx, req.(*LoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_Text_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TextRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).Text(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/Text",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).Text(ctx, req.(*TextRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_Binary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).Binary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/Binary",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).Binary(ctx, req.(*BinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_SSHKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SSHKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).SSHKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/SSHKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).SSHKey(ctx, req.(*SSHKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_UpdateCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).UpdateCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/UpdateCard",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).UpdateCard(ctx, req.(*UpdateCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_UpdateLogin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateLoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).UpdateLogin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/UpdateLogin",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).UpdateLogin(ctx, req.(*UpdateLoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_UpdateText_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateTextRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).UpdateText(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/UpdateText",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).UpdateText(ctx, req.(*UpdateTextRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_UpdateBinary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateBinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).UpdateBinary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/UpdateBinary",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).UpdateBinary(ctx, req.(*UpdateBinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_UpdateSSHKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateSSHKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).UpdateSSHKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/UpdateSSHKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).UpdateSSHKey(ctx, req.(*UpdateSSHKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_Trash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TrashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).Trash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/Trash",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).Trash(ctx, req.(*TrashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedGophKeeperServerServer) DeleteCard(context.Context, *DeleteCardRequest) (*DeleteCardResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method DeleteCard not implemented")
+
+func _GophKeeperServer_RestoreTrash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreTrashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).RestoreTrash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/RestoreTrash",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).RestoreTrash(ctx, req.(*RestoreTrashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedGophKeeperServerServer) DeleteLogin(context.Context, *DeleteLoginRequest) (*DeleteLoginResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method DeleteLogin not implemented")
+
+func _GophKeeperServer_EmptyTrash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmptyTrashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).EmptyTrash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/EmptyTrash",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).EmptyTrash(ctx, req.(*EmptyTrashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedGophKeeperServerServer) DeleteText(context.Context, *DeleteTextRequest) (*DeleteTextResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method DeleteText not implemented")
+
+func _GophKeeperServer_Vaults_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VaultsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).Vaults(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/Vaults",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).Vaults(ctx, req.(*VaultsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedGophKeeperServerServer) DeleteBinary(context.Context, *DeleteBinRequest) (*DeleteBinResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method DeleteBinary not implemented")
+
+func _GophKeeperServer_AddVault_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddVaultRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).AddVault(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/AddVault",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).AddVault(ctx, req.(*AddVaultRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_RenameVault_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenameVaultRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).RenameVault(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/RenameVault",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).RenameVault(ctx, req.(*RenameVaultRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_DeleteVault_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteVaultRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).DeleteVault(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/DeleteVault",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).DeleteVault(ctx, req.(*DeleteVaultRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_Folders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FoldersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).Folders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/Folders",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).Folders(ctx, req.(*FoldersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_AddFolder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddFolderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).AddFolder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/AddFolder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).AddFolder(ctx, req.(*AddFolderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_UpdateFolder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateFolderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).UpdateFolder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/UpdateFolder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).UpdateFolder(ctx, req.(*UpdateFolderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_DeleteFolder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteFolderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).DeleteFolder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/DeleteFolder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).DeleteFolder(ctx, req.(*DeleteFolderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_MoveRecord_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MoveRecordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).MoveRecord(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/MoveRecord",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).MoveRecord(ctx, req.(*MoveRecordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_Revisions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevisionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).Revisions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/Revisions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).Revisions(ctx, req.(*RevisionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_Revision_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevisionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).Revision(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/Revision",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).Revision(ctx, req.(*RevisionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_RestoreRevision_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreRevisionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).RestoreRevision(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/RestoreRevision",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).RestoreRevision(ctx, req.(*RestoreRevisionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_DeleteCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).DeleteCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/DeleteCard",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).DeleteCard(ctx, req.(*DeleteCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_DeleteLogin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteLoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).DeleteLogin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/DeleteLogin",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).DeleteLogin(ctx, req.(*DeleteLoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperServer_DeleteText_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteTextRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).DeleteText(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/DeleteText",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).DeleteText(ctx, req.(*DeleteTextRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedGophKeeperServerServer) mustEmbedUnimplementedGophKeeperServerServer() {}
 
-// UnsafeGophKeeperServerServer may be embedded to opt out of forward compatibility for this service.
-// Use of this interface is not recommended, as added methods to GophKeeperServerServer will
-// result in compilation errors.
-type UnsafeGophKeeperServerServer interface {
-	mustEmbedUnimplementedGophKeeperServerServer()
+func _GophKeeperServer_DeleteBinary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteBinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).DeleteBinary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/DeleteBinary",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).DeleteBinary(ctx, req.(*DeleteBinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func RegisterGophKeeperServerServer(s grpc.ServiceRegistrar, srv GophKeeperServerServer) {
-	s.RegisterService(&GophKeeperServer_ServiceDesc, srv)
+func _GophKeeperServer_StartUpload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartUploadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServerServer).StartUpload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GophKeeperServer/StartUpload",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServerServer).StartUpload(ctx, req.(*StartUploadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func _GophKeeperServer_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(RegisterRequest)
+func _GophKeeperServer_UploadState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UploadStateRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(GophKeeperServerServer).Register(ctx, in)
+		return srv.(GophKeeperServerServer).UploadState(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/proto.GophKeeperServer/Register",
+		FullMethod: "/proto.GophKeeperServer/UploadState",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(GophKeeperServerServer).Register(ctx, req.(*RegisterRequest))
+		return srv.(GophKeeperServerServer).UploadState(ctx, req.(*UploadStateRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _GophKeeperServer_SignIn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(RegisterRequest)
+func _GophKeeperServer_UploadChunks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(GophKeeperServerServer).UploadChunks(&gophKeeperServerUploadChunksServer{stream})
+}
+
+type GophKeeperServer_UploadChunksServer interface {
+	SendAndClose(*UploadChunksResponse) error
+	Recv() (*UploadChunk, error)
+	grpc.ServerStream
+}
+
+type gophKeeperServerUploadChunksServer struct {
+	grpc.ServerStream
+}
+
+func (x *gophKeeperServerUploadChunksServer) SendAndClose(m *UploadChunksResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *gophKeeperServerUploadChunksServer) Recv() (*UploadChunk, error) {
+	m := new(UploadChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _GophKeeperServer_CompleteUpload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompleteUploadRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(GophKeeperServerServer).SignIn(ctx, in)
+		return srv.(GophKeeperServerServer).CompleteUpload(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/proto.GophKeeperServer/SignIn",
+		FullMethod: "/proto.GophKeeperServer/CompleteUpload",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(GophKeeperServerServer).SignIn(ctx, req.(*RegisterRequest))
+		return srv.(GophKeeperServerServer).CompleteUpload(ctx, req.(*CompleteUploadRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _GophKeeperServer_AddCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(AddCardRequest)
+func _GophKeeperServer_DownloadBinary_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DownloadBinaryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GophKeeperServerServer).DownloadBinary(m, &gophKeeperServerDownloadBinaryServer{stream})
+}
+
+type GophKeeperServer_DownloadBinaryServer interface {
+	Send(*BinaryContent) error
+	grpc.ServerStream
+}
+
+type gophKeeperServerDownloadBinaryServer struct {
+	grpc.ServerStream
+}
+
+func (x *gophKeeperServerDownloadBinaryServer) Send(m *BinaryContent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _GophKeeperServer_DeleteSSHKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSSHKeyRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(GophKeeperServerServer).AddCard(ctx, in)
+		return srv.(GophKeeperServerServer).DeleteSSHKey(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/proto.GophKeeperServer/AddCard",
+		FullMethod: "/proto.GophKeeperServer/DeleteSSHKey",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(GophKeeperServerServer).AddCard(ctx, req.(*AddCardRequest))
+		return srv.(GophKeeperServerServer).DeleteSSHKey(ctx, req.(*DeleteSSHKeyRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _GophKeeperServer_AddLogin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(AddLoginRequest)
+func _GophKeeperServer_RecordTypes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecordTypesRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(GophKeeperServerServer).AddLogin(ctx, in)
+		return srv.(GophKeeperServerServer).RecordTypes(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/proto.GophKeeperServer/AddLogin",
+		FullMethod: "/proto.GophKeeperServer/RecordTypes",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(GophKeeperServerServer).AddLogin(ctx, req.(*AddLoginRequest))
+		return srv.(GophKeeperServerServer).RecordTypes(ctx, req.(*RecordTypesRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _GophKeeperServer_AddText_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(AddTextRequest)
+func _GophKeeperServer_RecordType_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecordTypeRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(GophKeeperServerServer).AddText(ctx, in)
+		return srv.(GophKeeperServerServer).RecordType(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/proto.GophKeeperServer/AddText",
+		FullMethod: "/proto.GophKeeperServer/RecordType",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(GophKeeperServerServer).AddText(ctx, req.(*AddTextRequest))
+		return srv.(GophKeeperServerServer).RecordType(ctx, req.(*RecordTypeRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _GophKeeperServer_AddBinary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(AddBinRequest)
+func _GophKeeperServer_AddRecordType_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddRecordTypeRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(GophKeeperServerServer).AddBinary(ctx, in)
+		return srv.(GophKeeperServerServer).AddRecordType(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/proto.GophKeeperServer/AddBinary",
+		FullMethod: "/proto.GophKeeperServer/AddRecordType",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(GophKeeperServerServer).AddBinary(ctx, req.(*AddBinRequest))
+		return srv.(GophKeeperServerServer).AddRecordType(ctx, req.(*AddRecordTypeRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _GophKeeperServer_Card_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(CardRequest)
+func _GophKeeperServer_UpdateRecordType_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRecordTypeRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(GophKeeperServerServer).Card(ctx, in)
+		return srv.(GophKeeperServerServer).UpdateRecordType(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/proto.GophKeeperServer/Card",
+		FullMethod: "/proto.GophKeeperServer/UpdateRecordType",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(GophKeeperServerServer).Card(ctx, req.(*CardRequest))
+		return srv.(GophKeeperServerServer).UpdateRecordType(ctx, req.(*UpdateRecordTypeRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _GophKeeperServer_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(LoginRequest)
+func _GophKeeperServer_DeleteRecordType_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRecordTypeRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(GophKeeperServerServer).Login(ctx, in)
+		return srv.(GophKeeperServerServer).DeleteRecordType(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/proto.GophKeeperServer/Login",
+		FullMethod: "/proto.GophKeeperServer/DeleteRecordType",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(GophKeeperServerServer).Login(ctx, req.(*LoginRequest))
+		return srv.(GophKeeperServerServer).DeleteRecordType(ctx, req.(*DeleteRecordTypeRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _GophKeeperServer_Text_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(TextRequest)
+func _GophKeeperServer_ListCustomRecords_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GophKeeperServerServer).ListCustomRecords(m, &gophKeeperServerListCustomRecordsServer{stream})
+}
+
+type GophKeeperServer_ListCustomRecordsServer interface {
+	Send(*RecordInfo) error
+	grpc.ServerStream
+}
+
+type gophKeeperServerListCustomRecordsServer struct {
+	grpc.ServerStream
+}
+
+func (x *gophKeeperServerListCustomRecordsServer) Send(m *RecordInfo) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _GophKeeperServer_AddCustomRecord_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddCustomRecordRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(GophKeeperServerServer).Text(ctx, in)
+		return srv.(GophKeeperServerServer).AddCustomRecord(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/proto.GophKeeperServer/Text",
+		FullMethod: "/proto.GophKeeperServer/AddCustomRecord",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(GophKeeperServerServer).Text(ctx, req.(*TextRequest))
+		return srv.(GophKeeperServerServer).AddCustomRecord(ctx, req.(*AddCustomRecordRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _GophKeeperServer_Binary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(BinRequest)
+func _GophKeeperServer_CustomRecord_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CustomRecordRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(GophKeeperServerServer).Binary(ctx, in)
+		return srv.(GophKeeperServerServer).CustomRecord(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/proto.GophKeeperServer/Binary",
+		FullMethod: "/proto.GophKeeperServer/CustomRecord",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(GophKeeperServerServer).Binary(ctx, req.(*BinRequest))
+		return srv.(GophKeeperServerServer).CustomRecord(ctx, req.(*CustomRecordRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _GophKeeperServer_DeleteCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(DeleteCardRequest)
+func _GophKeeperServer_UpdateCustomRecord_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCustomRecordRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(GophKeeperServerServer).DeleteCard(ctx, in)
+		return srv.(GophKeeperServerServer).UpdateCustomRecord(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/proto.GophKeeperServer/DeleteCard",
+		FullMethod: "/proto.GophKeeperServer/UpdateCustomRecord",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(GophKeeperServerServer).DeleteCard(ctx, req.(*DeleteCardRequest))
+		return srv.(GophKeeperServerServer).UpdateCustomRecord(ctx, req.(*UpdateCustomRecordRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _GophKeeperServer_DeleteLogin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(DeleteLoginRequest)
+func _GophKeeperServer_DeleteCustomRecord_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCustomRecordRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(GophKeeperServerServer).DeleteLogin(ctx, in)
+		return srv.(GophKeeperServerServer).DeleteCustomRecord(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/proto.GophKeeperServer/DeleteLogin",
+		FullMethod: "/proto.GophKeeperServer/DeleteCustomRecord",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(GophKeeperServerServer).DeleteLogin(ctx, req.(*DeleteLoginRequest))
+		return srv.(GophKeeperServerServer).DeleteCustomRecord(ctx, req.(*DeleteCustomRecordRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _GophKeeperServer_DeleteText_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(DeleteTextRequest)
+func _GophKeeperServer_Vault_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VaultRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(GophKeeperServerServer).DeleteText(ctx, in)
+		return srv.(GophKeeperServerServer).Vault(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/proto.GophKeeperServer/DeleteText",
+		FullMethod: "/proto.GophKeeperServer/Vault",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(GophKeeperServerServer).DeleteText(ctx, req.(*DeleteTextRequest))
+		return srv.(GophKeeperServerServer).Vault(ctx, req.(*VaultRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _GophKeeperServer_DeleteBinary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(DeleteBinRequest)
+func _GophKeeperServer_SetVaultCheck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetVaultCheckRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(GophKeeperServerServer).DeleteBinary(ctx, in)
+		return srv.(GophKeeperServerServer).SetVaultCheck(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/proto.GophKeeperServer/DeleteBinary",
+		FullMethod: "/proto.GophKeeperServer/SetVaultCheck",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(GophKeeperServerServer).DeleteBinary(ctx, req.(*DeleteBinRequest))
+		return srv.(GophKeeperServerServer).SetVaultCheck(ctx, req.(*SetVaultCheckRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -520,6 +2559,54 @@ var GophKeeperServer_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SignIn",
 			Handler:    _GophKeeperServer_SignIn_Handler,
 		},
+		{
+			MethodName: "Refresh",
+			Handler:    _GophKeeperServer_Refresh_Handler,
+		},
+		{
+			MethodName: "Logout",
+			Handler:    _GophKeeperServer_Logout_Handler,
+		},
+		{
+			MethodName: "Sessions",
+			Handler:    _GophKeeperServer_Sessions_Handler,
+		},
+		{
+			MethodName: "RevokeSession",
+			Handler:    _GophKeeperServer_RevokeSession_Handler,
+		},
+		{
+			MethodName: "ChangePassword",
+			Handler:    _GophKeeperServer_ChangePassword_Handler,
+		},
+		{
+			MethodName: "ChangeLogin",
+			Handler:    _GophKeeperServer_ChangeLogin_Handler,
+		},
+		{
+			MethodName: "DeleteAccount",
+			Handler:    _GophKeeperServer_DeleteAccount_Handler,
+		},
+		{
+			MethodName: "SetupTOTP",
+			Handler:    _GophKeeperServer_SetupTOTP_Handler,
+		},
+		{
+			MethodName: "ConfirmTOTP",
+			Handler:    _GophKeeperServer_ConfirmTOTP_Handler,
+		},
+		{
+			MethodName: "DisableTOTP",
+			Handler:    _GophKeeperServer_DisableTOTP_Handler,
+		},
+		{
+			MethodName: "Lockouts",
+			Handler:    _GophKeeperServer_Lockouts_Handler,
+		},
+		{
+			MethodName: "AuditEvents",
+			Handler:    _GophKeeperServer_AuditEvents_Handler,
+		},
 		{
 			MethodName: "AddCard",
 			Handler:    _GophKeeperServer_AddCard_Handler,
@@ -536,6 +2623,10 @@ var GophKeeperServer_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AddBinary",
 			Handler:    _GophKeeperServer_AddBinary_Handler,
 		},
+		{
+			MethodName: "AddSSHKey",
+			Handler:    _GophKeeperServer_AddSSHKey_Handler,
+		},
 		{
 			MethodName: "Card",
 			Handler:    _GophKeeperServer_Card_Handler,
@@ -552,6 +2643,90 @@ var GophKeeperServer_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Binary",
 			Handler:    _GophKeeperServer_Binary_Handler,
 		},
+		{
+			MethodName: "SSHKey",
+			Handler:    _GophKeeperServer_SSHKey_Handler,
+		},
+		{
+			MethodName: "UpdateCard",
+			Handler:    _GophKeeperServer_UpdateCard_Handler,
+		},
+		{
+			MethodName: "UpdateLogin",
+			Handler:    _GophKeeperServer_UpdateLogin_Handler,
+		},
+		{
+			MethodName: "UpdateText",
+			Handler:    _GophKeeperServer_UpdateText_Handler,
+		},
+		{
+			MethodName: "UpdateBinary",
+			Handler:    _GophKeeperServer_UpdateBinary_Handler,
+		},
+		{
+			MethodName: "UpdateSSHKey",
+			Handler:    _GophKeeperServer_UpdateSSHKey_Handler,
+		},
+		{
+			MethodName: "Trash",
+			Handler:    _GophKeeperServer_Trash_Handler,
+		},
+		{
+			MethodName: "RestoreTrash",
+			Handler:    _GophKeeperServer_RestoreTrash_Handler,
+		},
+		{
+			MethodName: "EmptyTrash",
+			Handler:    _GophKeeperServer_EmptyTrash_Handler,
+		},
+		{
+			MethodName: "Vaults",
+			Handler:    _GophKeeperServer_Vaults_Handler,
+		},
+		{
+			MethodName: "AddVault",
+			Handler:    _GophKeeperServer_AddVault_Handler,
+		},
+		{
+			MethodName: "RenameVault",
+			Handler:    _GophKeeperServer_RenameVault_Handler,
+		},
+		{
+			MethodName: "DeleteVault",
+			Handler:    _GophKeeperServer_DeleteVault_Handler,
+		},
+		{
+			MethodName: "Folders",
+			Handler:    _GophKeeperServer_Folders_Handler,
+		},
+		{
+			MethodName: "AddFolder",
+			Handler:    _GophKeeperServer_AddFolder_Handler,
+		},
+		{
+			MethodName: "UpdateFolder",
+			Handler:    _GophKeeperServer_UpdateFolder_Handler,
+		},
+		{
+			MethodName: "DeleteFolder",
+			Handler:    _GophKeeperServer_DeleteFolder_Handler,
+		},
+		{
+			MethodName: "MoveRecord",
+			Handler:    _GophKeeperServer_MoveRecord_Handler,
+		},
+		{
+			MethodName: "Revisions",
+			Handler:    _GophKeeperServer_Revisions_Handler,
+		},
+		{
+			MethodName: "Revision",
+			Handler:    _GophKeeperServer_Revision_Handler,
+		},
+		{
+			MethodName: "RestoreRevision",
+			Handler:    _GophKeeperServer_RestoreRevision_Handler,
+		},
 		{
 			MethodName: "DeleteCard",
 			Handler:    _GophKeeperServer_DeleteCard_Handler,
@@ -568,7 +2743,108 @@ var GophKeeperServer_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteBinary",
 			Handler:    _GophKeeperServer_DeleteBinary_Handler,
 		},
+		{
+			MethodName: "StartUpload",
+			Handler:    _GophKeeperServer_StartUpload_Handler,
+		},
+		{
+			MethodName: "UploadState",
+			Handler:    _GophKeeperServer_UploadState_Handler,
+		},
+		{
+			MethodName: "CompleteUpload",
+			Handler:    _GophKeeperServer_CompleteUpload_Handler,
+		},
+		{
+			MethodName: "DeleteSSHKey",
+			Handler:    _GophKeeperServer_DeleteSSHKey_Handler,
+		},
+		{
+			MethodName: "RecordTypes",
+			Handler:    _GophKeeperServer_RecordTypes_Handler,
+		},
+		{
+			MethodName: "RecordType",
+			Handler:    _GophKeeperServer_RecordType_Handler,
+		},
+		{
+			MethodName: "AddRecordType",
+			Handler:    _GophKeeperServer_AddRecordType_Handler,
+		},
+		{
+			MethodName: "UpdateRecordType",
+			Handler:    _GophKeeperServer_UpdateRecordType_Handler,
+		},
+		{
+			MethodName: "DeleteRecordType",
+			Handler:    _GophKeeperServer_DeleteRecordType_Handler,
+		},
+		{
+			MethodName: "AddCustomRecord",
+			Handler:    _GophKeeperServer_AddCustomRecord_Handler,
+		},
+		{
+			MethodName: "CustomRecord",
+			Handler:    _GophKeeperServer_CustomRecord_Handler,
+		},
+		{
+			MethodName: "UpdateCustomRecord",
+			Handler:    _GophKeeperServer_UpdateCustomRecord_Handler,
+		},
+		{
+			MethodName: "DeleteCustomRecord",
+			Handler:    _GophKeeperServer_DeleteCustomRecord_Handler,
+		},
+		{
+			MethodName: "Vault",
+			Handler:    _GophKeeperServer_Vault_Handler,
+		},
+		{
+			MethodName: "SetVaultCheck",
+			Handler:    _GophKeeperServer_SetVaultCheck_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListCards",
+			Handler:       _GophKeeperServer_ListCards_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListLogins",
+			Handler:       _GophKeeperServer_ListLogins_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListTexts",
+			Handler:       _GophKeeperServer_ListTexts_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListBinaries",
+			Handler:       _GophKeeperServer_ListBinaries_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListSSHKeys",
+			Handler:       _GophKeeperServer_ListSSHKeys_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "UploadChunks",
+			Handler:       _GophKeeperServer_UploadChunks_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "DownloadBinary",
+			Handler:       _GophKeeperServer_DownloadBinary_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListCustomRecords",
+			Handler:       _GophKeeperServer_ListCustomRecords_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api.proto",
 }
@@ -0,0 +1,108 @@
+// Package audit carries the source of the request to the audit log: the transport and the ip address of the client.
+// The source is put into the context by the HTTP middleware and the gRPC interceptors before the authentication,
+// so the failed sign-in attempts are recorded with it too
+package audit
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/paging"
+)
+
+// Transports of the requests
+const (
+	HTTP = "http"
+	GRPC = "grpc"
+)
+
+// Source where the request came from
+type Source struct {
+	Transport string
+	IP        string
+}
+
+// sourceKey key of the Source in the context
+type sourceKey struct{}
+
+// WithSource returns the context with the source of the request
+func WithSource(ctx context.Context, source Source) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+// SourceFrom returns the source of the request, empty if it is not set
+func SourceFrom(ctx context.Context) Source {
+	source, _ := ctx.Value(sourceKey{}).(Source)
+	return source
+}
+
+// internalKey key of the mark of the internal reads in the context
+type internalKey struct{}
+
+// Internal returns the context of the reads made by the server itself, e.g. the current record read before
+// the partial update. They are not logged, the request is recorded by its own action
+func Internal(ctx context.Context) context.Context {
+	return context.WithValue(ctx, internalKey{}, true)
+}
+
+// IsInternal reports whether the reads of the context are made by the server itself
+func IsInternal(ctx context.Context) bool {
+	internal, _ := ctx.Value(internalKey{}).(bool)
+	return internal
+}
+
+// Middleware puts the source of the HTTP request into the context
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		ctx := WithSource(r.Context(), Source{Transport: HTTP, IP: hostOf(r.RemoteAddr)})
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// UnaryInterceptor puts the source of the gRPC request into the context
+func UnaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(grpcSource(ctx), req)
+}
+
+// StreamInterceptor puts the source of the gRPC stream into the context
+func StreamInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &sourceStream{ServerStream: ss, ctx: grpcSource(ss.Context())})
+}
+
+// sourceStream replaces the context of the stream
+type sourceStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *sourceStream) Context() context.Context {
+	return s.ctx
+}
+
+// NewPage returns the page of the events read with the limit, a full page has the cursor of the next one
+func NewPage(events []models.AuditEvent, limit int) models.AuditPage {
+	next := paging.Next(events, limit, func(event models.AuditEvent) int64 { return event.ID })
+	return models.AuditPage{Events: events, Next: next}
+}
+
+func grpcSource(ctx context.Context) context.Context {
+	source := Source{Transport: GRPC}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		source.IP = hostOf(p.Addr.String())
+	}
+	return WithSource(ctx, source)
+}
+
+// hostOf returns the ip address without the port
+func hostOf(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
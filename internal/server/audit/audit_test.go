@@ -0,0 +1,30 @@
+package audit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+)
+
+func TestMiddleware(t *testing.T) {
+	var source Source
+	handler := Middleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		source = SourceFrom(r.Context())
+	}))
+
+	r := httptest.NewRequest("GET", "/api/audit", nil)
+	r.RemoteAddr = "10.0.0.1:51000"
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, Source{Transport: HTTP, IP: "10.0.0.1"}, source)
+}
+
+func TestPage(t *testing.T) {
+	events := []models.AuditEvent{{ID: 12}, {ID: 10}}
+	assert.Equal(t, int64(10), NewPage(events, 2).Next)
+	assert.Zero(t, NewPage(events, 3).Next)
+	assert.Zero(t, NewPage(nil, 2).Next)
+}
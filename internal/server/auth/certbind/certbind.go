@@ -0,0 +1,98 @@
+// Package certbind binds the client certificates to the accounts. A bound account can be used only
+// with the certificate of its subject, and the bound certificate can be used only for its account,
+// so a stolen password or token is useless without the certificate
+package certbind
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var ErrCertMismatch = errors.New("client certificate is not bound to the account")
+
+// ErrBoundLogin the bound account can not be renamed, the binding is kept by the login
+var ErrBoundLogin = errors.New("account bound to a certificate can not be renamed")
+
+// Bindings pairs of the login and the common name of the certificate subject. The nil Bindings allow everything
+type Bindings struct {
+	byLogin   map[string]string
+	bySubject map[string]string
+}
+
+// Load reads the file with lines "<login> <common name>", empty lines and lines starting with # are skipped.
+// Returns nil if the file is not set
+func Load(file string) (*Bindings, error) {
+	if file == "" {
+		return nil, nil
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("cant read certificate bindings: %w", err)
+	}
+	defer f.Close()
+
+	b := &Bindings{
+		byLogin:   make(map[string]string),
+		bySubject: make(map[string]string),
+	}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		login, subject, found := strings.Cut(text, " ")
+		subject = strings.TrimSpace(subject)
+		if !found || subject == "" {
+			return nil, fmt.Errorf("invalid certificate binding on line %d, expected \"<login> <common name>\"", line)
+		}
+		if _, ok := b.byLogin[login]; ok {
+			return nil, fmt.Errorf("login %s is bound twice on line %d", login, line)
+		}
+		if _, ok := b.bySubject[subject]; ok {
+			return nil, fmt.Errorf("certificate %s is bound twice on line %d", subject, line)
+		}
+		b.byLogin[login] = subject
+		b.bySubject[subject] = login
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cant read certificate bindings: %w", err)
+	}
+	return b, nil
+}
+
+// Check returns ErrCertMismatch if the account or the client certificate of the connection is bound to another one.
+// state is nil for the connections without TLS
+func (b *Bindings) Check(login string, state *tls.ConnectionState) error {
+	if b == nil {
+		return nil
+	}
+	subject := ""
+	if state != nil && len(state.PeerCertificates) > 0 {
+		subject = state.PeerCertificates[0].Subject.CommonName
+	}
+
+	if bound, ok := b.byLogin[login]; ok && bound != subject {
+		return ErrCertMismatch
+	}
+	if bound, ok := b.bySubject[subject]; ok && bound != login {
+		return ErrCertMismatch
+	}
+	return nil
+}
+
+// CheckRename returns ErrBoundLogin if the account is bound, its binding would not follow the new login.
+// The new login is checked like by Check, so the rename can not take over the binding of another login
+func (b *Bindings) CheckRename(login string, newLogin string, state *tls.ConnectionState) error {
+	if b == nil {
+		return nil
+	}
+	if _, ok := b.byLogin[login]; ok {
+		return ErrBoundLogin
+	}
+	return b.Check(newLogin, state)
+}
@@ -0,0 +1,68 @@
+package certbind
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func connection(subject string) *tls.ConnectionState {
+	return &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: subject}}},
+	}
+}
+
+func TestCheck(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "bindings")
+	require.NoError(t, os.WriteFile(file, []byte("# bound accounts\nalice Alice Laptop\n\nbob bob-phone\n"), 0o600))
+
+	bindings, err := Load(file)
+	require.NoError(t, err)
+
+	assert.NoError(t, bindings.Check("alice", connection("Alice Laptop")))
+	// The bound account requires its certificate
+	assert.ErrorIs(t, bindings.Check("alice", connection("bob-phone")), ErrCertMismatch)
+	assert.ErrorIs(t, bindings.Check("alice", connection("other")), ErrCertMismatch)
+	assert.ErrorIs(t, bindings.Check("alice", nil), ErrCertMismatch)
+	// The bound certificate can not be used for another account
+	assert.ErrorIs(t, bindings.Check("carol", connection("bob-phone")), ErrCertMismatch)
+	// Accounts and certificates without bindings are not restricted
+	assert.NoError(t, bindings.Check("carol", connection("other")))
+	assert.NoError(t, bindings.Check("carol", nil))
+
+	var disabled *Bindings
+	assert.NoError(t, disabled.Check("alice", nil))
+	assert.NoError(t, disabled.CheckRename("alice", "alice2", nil))
+}
+
+func TestCheckRename(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "bindings")
+	require.NoError(t, os.WriteFile(file, []byte("alice Alice Laptop\nbob bob-phone\n"), 0o600))
+	bindings, err := Load(file)
+	require.NoError(t, err)
+
+	// The bound account keeps its login even with its certificate
+	assert.ErrorIs(t, bindings.CheckRename("alice", "alice2", connection("Alice Laptop")), ErrBoundLogin)
+	// The free account can not take the bound login
+	assert.ErrorIs(t, bindings.CheckRename("carol", "bob", connection("other")), ErrCertMismatch)
+	assert.NoError(t, bindings.CheckRename("carol", "carol2", connection("other")))
+}
+
+func TestLoad(t *testing.T) {
+	bindings, err := Load("")
+	assert.NoError(t, err)
+	assert.Nil(t, bindings)
+
+	for _, content := range []string{"alice\n", "alice a\nalice b\n", "alice a\nbob a\n"} {
+		file := filepath.Join(t.TempDir(), "bindings")
+		require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+		_, err := Load(file)
+		assert.Error(t, err, content)
+	}
+}
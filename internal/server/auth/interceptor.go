@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/ncyellow/GophKeeper/internal/server/auth/certbind"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/jwt"
+	"github.com/ncyellow/GophKeeper/internal/server/storage"
+)
+
+// MetadataKey the grpc metadata key the client passes its token in. Same meaning as the Authorization http header
+const MetadataKey = "authorization"
+
+// UnaryInterceptor - grpc analogue of the Auth middleware. Checks the token from the metadata and puts the user into the context.
+// Methods listed in publicMethods (full names, e.g. /proto.GophKeeperServer/SignIn) are called without a token.
+func UnaryInterceptor(store storage.Storage, parser jwt.Parser, bindings *certbind.Bindings, publicMethods ...string) grpc.UnaryServerInterceptor {
+	public := methodSet(publicMethods)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if public[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		ctx, err := authContext(ctx, store, parser, bindings)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor - the same as UnaryInterceptor but for streaming methods
+func StreamInterceptor(store storage.Storage, parser jwt.Parser, bindings *certbind.Bindings, publicMethods ...string) grpc.StreamServerInterceptor {
+	public := methodSet(publicMethods)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if public[info.FullMethod] {
+			return handler(srv, ss)
+		}
+		ctx, err := authContext(ss.Context(), store, parser, bindings)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authContext reads the token from the incoming metadata and returns a context with the authorized user
+func authContext(ctx context.Context, store storage.Storage, parser jwt.Parser, bindings *certbind.Bindings) (context.Context, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "")
+	}
+	tokens := md.Get(MetadataKey)
+	if len(tokens) != 1 {
+		return nil, status.Error(codes.Unauthenticated, "")
+	}
+
+	user, sessionID, err := authenticate(ctx, store, parser, tokens[0])
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "")
+	}
+	if err := bindings.Check(user.Login, TLSState(ctx)); err != nil {
+		return nil, status.Error(codes.PermissionDenied, "")
+	}
+	ctx = context.WithValue(ctx, UserContextKey{}, user)
+	return context.WithValue(ctx, SessionContextKey{}, sessionID), nil
+}
+
+// TLSState returns the TLS state of the grpc connection, nil without TLS
+func TLSState(ctx context.Context) *tls.ConnectionState {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil
+	}
+	return &info.State
+}
+
+// authStream replaces the context of the stream with the one that contains the authorized user
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context with the authorized user
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
+
+// methodSet converts the list of methods into a set
+func methodSet(methods []string) map[string]bool {
+	result := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		result[method] = true
+	}
+	return result
+}
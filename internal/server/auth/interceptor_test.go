@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/jwt"
+	mockjwt "github.com/ncyellow/GophKeeper/internal/server/mocks/auth/jwt"
+	mockstorage "github.com/ncyellow/GophKeeper/internal/server/mocks/storage"
+)
+
+// TestUnaryInterceptor checks that the user is taken from the token and not from the request
+func TestUnaryInterceptor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockstorage.NewMockStorage(ctrl)
+	parser := mockjwt.NewMockParser(ctrl)
+	interceptor := UnaryInterceptor(store, parser, nil, "/proto.GophKeeperServer/SignIn")
+
+	var ctxUser *models.User
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		ctxUser, _ = ctx.Value(UserContextKey{}).(*models.User)
+		return "ok", nil
+	}
+	private := &grpc.UnaryServerInfo{FullMethod: "/proto.GophKeeperServer/Card"}
+	public := &grpc.UnaryServerInfo{FullMethod: "/proto.GophKeeperServer/SignIn"}
+
+	// public method without a token
+	resp, err := interceptor(context.Background(), nil, public, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+	assert.Nil(t, ctxUser)
+
+	// private method without metadata
+	_, err = interceptor(context.Background(), nil, private, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// private method with an invalid token
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, "bad"))
+	parser.EXPECT().ParseToken("bad").Return(nil, errors.New("invalid token"))
+	_, err = interceptor(ctx, nil, private, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// private method with a valid token
+	user := &models.User{UserID: 7, Login: "login"}
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, "good"))
+	parser.EXPECT().ParseToken("good").Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+	store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+	resp, err = interceptor(ctx, nil, private, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+	assert.Equal(t, user, ctxUser)
+
+	// valid token of the revoked session
+	ctxUser = nil
+	parser.EXPECT().ParseToken("good").Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+	store.EXPECT().SessionUser(gomock.Any(), "session").Return(nil, pgx.ErrNoRows)
+	_, err = interceptor(ctx, nil, private, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Nil(t, ctxUser)
+}
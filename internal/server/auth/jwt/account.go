@@ -0,0 +1,115 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/password"
+)
+
+// ErrEmptyValue the new login or password is empty
+var ErrEmptyValue = errors.New("empty login or password")
+
+// ChangePassword replaces the password of the signed-in user after checking the current one.
+// The other sessions are revoked, a stolen session does not survive the password change
+func (a *Authorizer) ChangePassword(ctx context.Context, user *models.User, sessionID string, ip string, change models.PasswordChange) error {
+	if change.NewPassword == "" {
+		return ErrEmptyValue
+	}
+	if err := a.confirmPassword(ctx, user, ip, change.Password); err != nil {
+		return err
+	}
+	hash, err := password.Hash(change.NewPassword)
+	if err != nil {
+		return err
+	}
+	if err := a.Store.UpdatePassword(ctx, user.UserID, hash); err != nil {
+		return err
+	}
+	a.audit(ctx, user.UserID, user.Login, ip, models.AuditChangePassword)
+	return a.Store.RevokeOtherSessions(ctx, user.UserID, sessionID)
+}
+
+// ChangeLogin renames the signed-in user after checking the password. Returns storage.ErrLoginTaken if the login is used.
+// The access tokens are issued for the old login, the clients get new ones by the refresh token
+func (a *Authorizer) ChangeLogin(ctx context.Context, user *models.User, ip string, change models.LoginChange) error {
+	if change.NewLogin == "" {
+		return ErrEmptyValue
+	}
+	if err := a.confirmPassword(ctx, user, ip, change.Password); err != nil {
+		return err
+	}
+	if err := a.Store.ChangeLogin(ctx, user.UserID, change.NewLogin); err != nil {
+		return err
+	}
+	a.audit(ctx, user.UserID, user.Login, ip, models.AuditChangeLogin)
+	return nil
+}
+
+// DeleteAccount removes the signed-in user with all the records after checking the password.
+// The audit log of the user is removed with the user, the deletion is recorded without the user
+func (a *Authorizer) DeleteAccount(ctx context.Context, user *models.User, ip string, deletion models.AccountDeletion) error {
+	if err := a.confirmPassword(ctx, user, ip, deletion.Password); err != nil {
+		return err
+	}
+	if err := a.Store.DeleteUser(ctx, user.UserID); err != nil {
+		return err
+	}
+	a.audit(ctx, 0, user.Login, ip, models.AuditDeleteAccount)
+	return nil
+}
+
+// EnableTOTP enables the second factor of the signed-in user by the code of the set up secret,
+// returns the recovery codes. See twofactor.Manager.Confirm
+func (a *Authorizer) EnableTOTP(ctx context.Context, user *models.User, ip string, code string) ([]string, error) {
+	codes, err := a.TwoFactor.Confirm(ctx, user.UserID, code)
+	if err != nil {
+		return nil, err
+	}
+	a.audit(ctx, user.UserID, user.Login, ip, models.AuditEnableTOTP)
+	return codes, nil
+}
+
+// DisableTOTP disables the second factor of the signed-in user by the current code or a recovery code.
+// See twofactor.Manager.Disable
+func (a *Authorizer) DisableTOTP(ctx context.Context, user *models.User, ip string, code string) error {
+	if err := a.TwoFactor.Disable(ctx, user.UserID, code); err != nil {
+		return err
+	}
+	a.audit(ctx, user.UserID, user.Login, ip, models.AuditDisableTOTP)
+	return nil
+}
+
+// RevokeSession revokes the session of the signed-in user, e.g. of a lost device.
+// Returns pgx.ErrNoRows if the user has no such active session
+func (a *Authorizer) RevokeSession(ctx context.Context, user *models.User, ip string, sessionID string) error {
+	if err := a.Store.RevokeSession(ctx, user.UserID, sessionID); err != nil {
+		return err
+	}
+	a.audit(ctx, user.UserID, user.Login, ip, models.AuditRevokeSession)
+	return nil
+}
+
+// confirmPassword checks the current password of the signed-in user. The attempts are limited as on sign-in,
+// otherwise a stolen session could be used to guess the password
+func (a *Authorizer) confirmPassword(ctx context.Context, user *models.User, ip string, pwd string) error {
+	if err := a.Throttle.Check(ctx, user.Login, ip); err != nil {
+		return err
+	}
+	repoUser, err := a.Store.User(ctx, user.Login)
+	if err != nil {
+		return err
+	}
+	ok, _, err := password.Verify(pwd, repoUser.Password)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidCredentials, err)
+	}
+	if !ok {
+		a.failure(ctx, user.Login, ip, user.UserID)
+		return ErrInvalidCredentials
+	}
+	a.Throttle.Success(ctx, user.Login)
+	return nil
+}
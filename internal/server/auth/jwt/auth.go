@@ -3,70 +3,277 @@ package jwt
 
 import (
 	"context"
-	"crypto/sha1"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/jackc/pgx/v4"
+	"github.com/rs/zerolog/log"
 
 	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/password"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/signing"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/throttle"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/twofactor"
+	"github.com/ncyellow/GophKeeper/internal/server/config"
 	"github.com/ncyellow/GophKeeper/internal/server/storage"
 )
 
-var ErrInvalidToken = errors.New(`invalid token`)
+var (
+	ErrInvalidToken       = errors.New(`invalid token`)
+	ErrInvalidCredentials = errors.New(`invalid login or password`)
+	ErrSessionExpired     = errors.New(`session is expired or revoked`)
+)
+
+// dummyHash the hash checked for the unknown login, it has the default parameters so the check costs the same
+var dummyHash = sync.OnceValue(func() string {
+	hash, _ := password.Hash("dummy")
+	return hash
+})
+
+// Default lifetimes of the tokens, used when they are not set in the configuration
+const (
+	DefaultAccessTTL  = 15 * time.Minute
+	DefaultRefreshTTL = 30 * 24 * time.Hour
+)
 
 // Claims used for working with golang-jwt to sign the token and verify it
 type Claims struct {
 	jwt.RegisteredClaims
-	Username string `json:"username"`
+	Username  string `json:"username"`
+	SessionID string `json:"sid"`
 }
 
-// Authorizer structure for verifying the user and issuing the authorization token
+// Authorizer structure for verifying the user and issuing the authorization tokens.
+// Every sign-in creates a session: the access token is short-lived, the refresh token is stored as a hash
+// and is replaced on every refresh. If the user enabled the second factor, the code is required on sign-in.
+// Failed sign-in attempts are limited by Throttle
 type Authorizer struct {
 	Store      storage.Storage
-	SigningKey []byte
+	TwoFactor  *twofactor.Manager
+	Throttle   *throttle.Throttler
+	Keys       *signing.Keystore
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// NewAuthorizer constructor, the token lifetimes are taken from the configuration, the tokens are signed by keys
+func NewAuthorizer(store storage.Storage, conf *config.Config, keys *signing.Keystore) *Authorizer {
+	a := &Authorizer{
+		Store:      store,
+		TwoFactor:  twofactor.NewManager(store),
+		Throttle:   throttle.NewThrottler(store, conf),
+		Keys:       keys,
+		AccessTTL:  conf.AccessTokenTTL,
+		RefreshTTL: conf.RefreshTokenTTL,
+	}
+	if a.AccessTTL <= 0 {
+		a.AccessTTL = DefaultAccessTTL
+	}
+	if a.RefreshTTL <= 0 {
+		a.RefreshTTL = DefaultRefreshTTL
+	}
+	return a
+}
+
+// DefaultParser default parser for jwt tokens, the signature is checked by the key of the kid header
+type DefaultParser struct {
+	Keys *signing.Keystore
+}
+
+// NewParser constructor
+func NewParser(keys *signing.Keystore) *DefaultParser {
+	return &DefaultParser{Keys: keys}
+}
+
+// SignIn - checks the login and password, then the second factor if it is enabled, and creates a new session.
+// Returns twofactor.ErrCodeRequired if the password is correct but the code is missing
+// and *throttle.LockedError if there were too many failed attempts of the login or from the ip address
+func (a *Authorizer) SignIn(ctx context.Context, user *models.User, userAgent string, ip string) (*models.Tokens, error) {
+	if err := a.Throttle.Check(ctx, user.Login, ip); err != nil {
+		a.audit(ctx, 0, user.Login, ip, models.AuditSignInFailed)
+		return nil, err
+	}
+
+	repoUser, err := a.Store.User(ctx, user.Login)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// The unknown login takes as long as the wrong password, otherwise the logins are enumerated by the time
+			password.Verify(user.Password, dummyHash())
+			a.failure(ctx, user.Login, ip, 0)
+			a.audit(ctx, 0, user.Login, ip, models.AuditSignInFailed)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCredentials, err)
+	}
+
+	// The password is checked here and not in the sql query, because the hash contains its own salt
+	ok, needsRehash, err := password.Verify(user.Password, repoUser.Password)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCredentials, err)
+	}
+	if !ok {
+		a.failure(ctx, user.Login, ip, repoUser.UserID)
+		a.audit(ctx, repoUser.UserID, user.Login, ip, models.AuditSignInFailed)
+		return nil, ErrInvalidCredentials
+	}
+	if err := a.TwoFactor.Verify(ctx, repoUser.UserID, user.OTP); err != nil {
+		if errors.Is(err, twofactor.ErrCodeRequired) {
+			return nil, err
+		}
+		// Guessing of the second factor codes is limited the same way as of the passwords
+		if errors.Is(err, twofactor.ErrInvalidCode) {
+			a.failure(ctx, user.Login, ip, repoUser.UserID)
+			a.audit(ctx, repoUser.UserID, user.Login, ip, models.AuditSignInFailed)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCredentials, err)
+	}
+	a.Throttle.Success(ctx, user.Login)
+	if needsRehash {
+		a.rehash(ctx, repoUser.UserID, user.Password)
+	}
+
+	tokens, err := a.CreateSession(ctx, repoUser, userAgent)
+	if err != nil {
+		return nil, err
+	}
+	a.audit(ctx, repoUser.UserID, user.Login, ip, models.AuditSignIn)
+	return tokens, nil
 }
 
-// DefaultParser default parser for jwt tokens
-type DefaultParser struct{}
+// CreateSession creates a new session of the already authenticated user, e.g. right after the registration
+func (a *Authorizer) CreateSession(ctx context.Context, user *models.User, userAgent string) (*models.Tokens, error) {
+	sessionID, err := randomString(sessionIDLen)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomString(refreshSecretLen)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.Store.CreateSession(ctx, models.Session{
+		ID:        sessionID,
+		UserID:    user.UserID,
+		UserAgent: userAgent,
+		ExpiresAt: time.Now().Add(a.RefreshTTL),
+	}, hashSecret(secret))
+	if err != nil {
+		return nil, err
+	}
+	return a.tokens(user.Login, sessionID, secret)
+}
 
-// SignIn - checks if such a user exists in the database and generates a token
-func (a *Authorizer) SignIn(ctx context.Context, user *models.User) (string, error) {
-	expirationDuration := time.Hour * 24
-	pwd := sha1.New()
-	pwd.Write([]byte(user.Password))
-	user.Password = fmt.Sprintf("%x", pwd.Sum(nil))
+// Refresh issues a new pair of tokens by the refresh token, the old refresh token stops working.
+// If an already used refresh token is presented, it was stolen, so the whole session is revoked
+func (a *Authorizer) Refresh(ctx context.Context, refreshToken string) (*models.Tokens, error) {
+	sessionID, secret, found := strings.Cut(refreshToken, ".")
+	if !found || sessionID == "" || secret == "" {
+		return nil, ErrInvalidToken
+	}
+	newSecret, err := randomString(refreshSecretLen)
+	if err != nil {
+		return nil, err
+	}
 
-	repoUser, err := a.Store.User(ctx, user.Login, user.Password)
+	user, err := a.Store.RefreshSession(ctx, sessionID, hashSecret(secret), hashSecret(newSecret), time.Now().Add(a.RefreshTTL))
 	if err != nil {
-		return "", fmt.Errorf("invalid login or password: %w", err)
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		revoked, err := a.Store.RevokeReusedSession(ctx, sessionID, hashSecret(secret))
+		if err != nil {
+			log.Error().Err(err).Msgf("cant revoke session %s", sessionID)
+		} else if revoked {
+			log.Warn().Msgf("refresh token of session %s is used twice, session revoked", sessionID)
+		}
+		return nil, ErrSessionExpired
 	}
+	return a.tokens(user.Login, sessionID, newSecret)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
+// tokens signs the access token and builds the refresh token of the session
+func (a *Authorizer) tokens(login string, sessionID string, secret string) (*models.Tokens, error) {
+	key, err := a.Keys.SigningKey()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	token := jwt.NewWithClaims(key.Method(), &Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expirationDuration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(a.AccessTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
-		Username: repoUser.Login,
+		Username:  login,
+		SessionID: sessionID,
 	})
-	return token.SignedString(a.SigningKey)
+	token.Header["kid"] = key.ID
+	accessToken, err := token.SignedString(key.SignKey())
+	if err != nil {
+		return nil, err
+	}
+	return &models.Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: sessionID + "." + secret,
+	}, nil
+}
+
+// failure counts the failed sign-in attempt, the lockout of the account is audited.
+// Errors do not change the response, the attempt is already failed
+func (a *Authorizer) failure(ctx context.Context, login string, ip string, userID int64) {
+	locked, err := a.Throttle.Failure(ctx, login, ip, userID)
+	if err != nil {
+		log.Error().Err(err).Msgf("cant count failed sign-in attempt of %s", login)
+		return
+	}
+	if locked {
+		a.audit(ctx, userID, login, ip, models.AuditLockout)
+	}
+}
+
+// audit appends the sign-in or the account event to the audit log, errors do not change the result of the action
+func (a *Authorizer) audit(ctx context.Context, userID int64, login string, ip string, action string) {
+	err := a.Store.AddAuditEvent(ctx, userID, models.AuditEvent{Actor: login, Action: action, IP: ip})
+	if err != nil {
+		log.Error().Err(err).Msgf("cant write audit event %s of %s", action, login)
+	}
+}
+
+// rehash replaces a legacy or outdated password hash with the current one.
+// It is done on a successful sign-in, because only then we know the password. Errors do not prevent the sign-in.
+func (a *Authorizer) rehash(ctx context.Context, userID int64, pwd string) {
+	hash, err := password.Hash(pwd)
+	if err != nil {
+		log.Error().Err(err).Msg("cant hash password")
+		return
+	}
+	if err := a.Store.UpdatePassword(ctx, userID, hash); err != nil {
+		log.Error().Err(err).Msgf("cant update password hash of the user %d", userID)
+	}
 }
 
-// ParseToken - checks if the token is valid, if yes returns the login of this user
-func (p *DefaultParser) ParseToken(accessToken string, signingKey []byte) (string, error) {
+// ParseToken - checks if the token is valid, if yes returns its claims: the login of the user and the session
+func (p *DefaultParser) ParseToken(accessToken string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(accessToken, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, err := p.Keys.VerificationKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		// The algorithm is taken from the key and not from the token, otherwise a public key could be used as a hmac secret
+		if token.Method.Alg() != key.Algorithm {
 			return nil, fmt.Errorf("signing error")
 		}
-		return signingKey, nil
+		return key.VerifyKey(), nil
 	})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims.Username, nil
+		return claims, nil
 	}
 
-	return "", ErrInvalidToken
+	return nil, ErrInvalidToken
 }
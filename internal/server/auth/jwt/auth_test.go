@@ -0,0 +1,58 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ncyellow/GophKeeper/internal/server/auth/signing"
+)
+
+func TestParseToken(t *testing.T) {
+	for _, algorithm := range []string{signing.EdDSA, signing.HS256} {
+		keys, err := signing.Open(signing.Options{Algorithm: algorithm})
+		require.NoError(t, err)
+		a := &Authorizer{Keys: keys, AccessTTL: time.Minute}
+		parser := NewParser(keys)
+
+		tokens, err := a.tokens("login", "session", "secret")
+		require.NoError(t, err)
+		claims, err := parser.ParseToken(tokens.AccessToken)
+		require.NoError(t, err, algorithm)
+		assert.Equal(t, "login", claims.Username)
+		assert.Equal(t, "session", claims.SessionID)
+
+		// The token of another keystore
+		other, err := signing.Open(signing.Options{Algorithm: algorithm})
+		require.NoError(t, err)
+		_, err = NewParser(other).ParseToken(tokens.AccessToken)
+		assert.Error(t, err, algorithm)
+	}
+}
+
+// TestParseTokenAlgorithm checks that the algorithm of the token has to match its key
+func TestParseTokenAlgorithm(t *testing.T) {
+	keys, err := signing.Open(signing.Options{Algorithm: signing.EdDSA})
+	require.NoError(t, err)
+	key, err := keys.SigningKey()
+	require.NoError(t, err)
+
+	// The public key is known to everybody, it must not work as a hmac secret
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{Username: "login", SessionID: "session"})
+	token.Header["kid"] = key.ID
+	forged, err := token.SignedString([]byte(key.VerifyKey().(ed25519.PublicKey)))
+	require.NoError(t, err)
+	_, err = NewParser(keys).ParseToken(forged)
+	assert.Error(t, err)
+
+	// The token without kid
+	token = jwt.NewWithClaims(jwt.SigningMethodEdDSA, &Claims{Username: "login", SessionID: "session"})
+	unsigned, err := token.SignedString(key.SignKey())
+	require.NoError(t, err)
+	_, err = NewParser(keys).ParseToken(unsigned)
+	assert.Error(t, err)
+}
@@ -0,0 +1,31 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// Lengths of the random parts of the refresh token "<session id>.<secret>" in bytes
+const (
+	sessionIDLen     = 16
+	refreshSecretLen = 32
+)
+
+// randomString returns n random bytes in base64url
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("cant generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashSecret the refresh token is stored only as a hash, so a database leak does not give access to the sessions.
+// The secret is random and long, a salt and a slow hash are not needed
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -5,34 +5,57 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/certbind"
 	"github.com/ncyellow/GophKeeper/internal/server/auth/jwt"
-	"github.com/ncyellow/GophKeeper/internal/server/config"
 	"github.com/ncyellow/GophKeeper/internal/server/storage"
 )
 
 // UserContextKey for accessing authorized user data in context
 type UserContextKey struct{}
 
+// SessionContextKey for accessing the id of the current session in context
+type SessionContextKey struct{}
+
 // Auth - middleware checks the token and if all is well, verifies its presence in the database.
-func Auth(store storage.Storage, conf *config.Config, parser jwt.Parser) func(http.Handler) http.Handler {
+// If the account or the client certificate is bound, they have to match
+func Auth(store storage.Storage, parser jwt.Parser, bindings *certbind.Bindings) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
-			login, err := parser.ParseToken(authHeader, []byte(conf.SigningKey))
+			user, sessionID, err := authenticate(r.Context(), store, parser, authHeader)
 			if err != nil {
 				w.WriteHeader(http.StatusUnauthorized)
 				return
 			}
-
-			user, err := store.UserByLogin(r.Context(), login)
-			// If all is well, but for some reason the user is not in the database - also not authorized.
-			if err != nil {
-				w.WriteHeader(http.StatusUnauthorized)
+			if err := bindings.Check(user.Login, r.TLS); err != nil {
+				w.WriteHeader(http.StatusForbidden)
 				return
 			}
 
-			r = r.Clone(context.WithValue(r.Context(), UserContextKey{}, user))
+			ctx := context.WithValue(r.Context(), UserContextKey{}, user)
+			r = r.Clone(context.WithValue(ctx, SessionContextKey{}, sessionID))
 			next.ServeHTTP(w, r)
 		})
 	}
 }
+
+// authenticate checks the token and returns the user it was issued to and the session id.
+// It is shared by the http middleware and the grpc interceptors.
+func authenticate(ctx context.Context, store storage.Storage, parser jwt.Parser, token string) (*models.User, string, error) {
+	claims, err := parser.ParseToken(token)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The token is valid, but the session may be revoked. If all is well, but for some reason
+	// the user is not in the database - also not authorized.
+	user, err := store.SessionUser(ctx, claims.SessionID)
+	if err != nil {
+		return nil, "", err
+	}
+	if user.Login != claims.Username {
+		return nil, "", jwt.ErrInvalidToken
+	}
+	return user, claims.SessionID, nil
+}
@@ -0,0 +1,70 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashAndVerify(t *testing.T) {
+	hash, err := Hash("password")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=65536,t=1,p=4$"))
+
+	// The same password gives different hashes because of the salt
+	other, err := Hash("password")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other)
+
+	ok, rehash, err := Verify("password", hash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.False(t, rehash)
+
+	ok, rehash, err = Verify("wrong", hash)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.False(t, rehash)
+}
+
+func TestVerifyLegacy(t *testing.T) {
+	// sha1("password")
+	legacy := "5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8"
+
+	ok, rehash, err := Verify("password", legacy)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, rehash)
+
+	ok, rehash, err = Verify("wrong", legacy)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.False(t, rehash)
+}
+
+func TestVerifyOutdatedParams(t *testing.T) {
+	hash, err := HashWithParams("password", Params{Memory: 1024, Time: 1, Threads: 1, SaltLen: 16, KeyLen: 32})
+	require.NoError(t, err)
+
+	ok, rehash, err := Verify("password", hash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, rehash)
+}
+
+func TestVerifyInvalidHash(t *testing.T) {
+	for _, hash := range []string{
+		"",
+		"plain",
+		"$argon2i$v=19$m=65536,t=1,p=4$c2FsdA$a2V5",
+		"$argon2id$v=18$m=65536,t=1,p=4$c2FsdA$a2V5",
+		"$argon2id$v=19$m=x,t=1,p=4$c2FsdA$a2V5",
+		"$argon2id$v=19$m=65536,t=1,p=4$!!!$a2V5",
+	} {
+		ok, _, err := Verify("password", hash)
+		assert.ErrorIs(t, err, ErrInvalidHash, hash)
+		assert.False(t, ok, hash)
+	}
+}
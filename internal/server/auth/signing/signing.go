@@ -0,0 +1,419 @@
+// Package signing implements the keystore of the keys signing the access tokens.
+// The keys are generated and rotated by the server and persisted in a directory, one pem file per key,
+// every token has the id of its key in the kid header. A retired key is still accepted until the tokens
+// signed by it expire, so the rotation does not log the users out.
+// Ed25519 keys have a separate public key file, verification-only replicas get only these files.
+package signing
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ncyellow/GophKeeper/internal/server/config"
+)
+
+// Supported algorithms, the names of the jwt alg header
+const (
+	HS256 = "HS256"
+	EdDSA = "EdDSA"
+)
+
+// Default values of the options, used when they are not set in the configuration
+const (
+	DefaultDir       = "signing-keys"
+	DefaultAlgorithm = EdDSA
+	DefaultRotation  = 30 * 24 * time.Hour
+	DefaultReload    = time.Minute
+	DefaultTokenTTL  = 15 * time.Minute
+)
+
+// Extensions of the key files
+const (
+	privateExt = ".key"
+	publicExt  = ".pub"
+)
+
+// hmacKeyLen length of the generated HS256 keys in bytes
+const hmacKeyLen = 32
+
+var (
+	ErrNoKey        = errors.New("no signing key, the server can not start without it")
+	ErrUnknownKey   = errors.New("unknown or retired signing key")
+	ErrVerifyOnly   = errors.New("verification-only keystore can not sign tokens")
+	ErrAlgorithm    = errors.New("unsupported signing algorithm, expected HS256 or EdDSA")
+	ErrInvalidKeyID = errors.New("invalid key id")
+)
+
+// Options of the keystore
+type Options struct {
+	// Dir directory of the key files, an empty one keeps the keys only in memory
+	Dir string
+	// Algorithm of the generated keys
+	Algorithm string
+	// Rotation age of the signing key after which a new one is generated
+	Rotation time.Duration
+	// Reload how often the directory is read again to pick up the keys generated by other instances.
+	// A new key signs only after two reload intervals, so every instance accepts it by then
+	Reload time.Duration
+	// TokenTTL lifetime of the access tokens, the retired key is accepted during it
+	TokenTTL time.Duration
+	// VerifyOnly the keystore reads only the public keys and never generates keys
+	VerifyOnly bool
+}
+
+// NewOptions returns the options from the configuration
+func NewOptions(conf *config.Config) Options {
+	opts := Options{
+		Dir:        conf.SigningKeysDir,
+		Algorithm:  conf.SigningAlgorithm,
+		Rotation:   conf.SigningKeyRotation,
+		Reload:     conf.SigningKeysReload,
+		TokenTTL:   conf.AccessTokenTTL,
+		VerifyOnly: conf.SigningVerifyOnly,
+	}
+	if opts.Dir == "" {
+		opts.Dir = DefaultDir
+	}
+	return opts
+}
+
+// Key the signing key, the private part is nil in the verification-only keystore
+type Key struct {
+	ID        string
+	Algorithm string
+	CreatedAt time.Time
+	private   interface{}
+	public    interface{}
+}
+
+// Method returns the jwt signing method of the key
+func (k *Key) Method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+// SignKey returns the key for jwt.Token.SignedString
+func (k *Key) SignKey() interface{} {
+	return k.private
+}
+
+// VerifyKey returns the key for jwt.Keyfunc
+func (k *Key) VerifyKey() interface{} {
+	return k.public
+}
+
+// Keystore holds the signing keys ordered by the creation time
+type Keystore struct {
+	opts Options
+	now  func() time.Time
+
+	mu   sync.RWMutex
+	keys []*Key
+}
+
+// Open loads the keys from the directory and generates the first one if there are none.
+// Returns ErrNoKey if there is no key to sign or, for the verification-only keystore, to verify the tokens
+func Open(opts Options) (*Keystore, error) {
+	if opts.Algorithm == "" {
+		opts.Algorithm = DefaultAlgorithm
+	}
+	if opts.Algorithm != HS256 && opts.Algorithm != EdDSA {
+		return nil, ErrAlgorithm
+	}
+	if opts.Rotation <= 0 {
+		opts.Rotation = DefaultRotation
+	}
+	if opts.Reload <= 0 {
+		opts.Reload = DefaultReload
+	}
+	if opts.TokenTTL <= 0 {
+		opts.TokenTTL = DefaultTokenTTL
+	}
+
+	k := &Keystore{opts: opts, now: time.Now}
+	if err := k.Reload(); err != nil {
+		return nil, err
+	}
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if len(k.keys) == 0 {
+		return nil, ErrNoKey
+	}
+	return k, nil
+}
+
+// Reload reads the directory again and, unless the keystore is verification-only,
+// generates the next key when the newest one is older than the rotation period and removes the retired keys.
+// On error the previous keys stay in use
+func (k *Keystore) Reload() error {
+	keys, err := k.load()
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys = keys
+	if k.opts.VerifyOnly {
+		return nil
+	}
+
+	now := k.now()
+	if len(k.keys) == 0 || now.Sub(k.keys[len(k.keys)-1].CreatedAt) >= k.opts.Rotation {
+		key, err := k.generate(now)
+		if err != nil {
+			return err
+		}
+		log.Info().Msgf("new %s signing key %s is generated", key.Algorithm, key.ID)
+		k.keys = append(k.keys, key)
+	}
+	k.removeRetired(now)
+	return nil
+}
+
+// Watch reloads the keys until the context is done
+func (k *Keystore) Watch(ctx context.Context) {
+	ticker := time.NewTicker(k.opts.Reload)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := k.Reload(); err != nil {
+				log.Error().Err(err).Msgf("cant reload signing keys from %s, the previous ones are used", k.opts.Dir)
+			}
+		}
+	}
+}
+
+// SigningKey returns the key signing new tokens: the newest activated one
+func (k *Keystore) SigningKey() (*Key, error) {
+	if k.opts.VerifyOnly {
+		return nil, ErrVerifyOnly
+	}
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if len(k.keys) == 0 {
+		return nil, ErrNoKey
+	}
+	now := k.now()
+	for i := len(k.keys) - 1; i > 0; i-- {
+		if !now.Before(k.activatedAt(i)) {
+			return k.keys[i], nil
+		}
+	}
+	return k.keys[0], nil
+}
+
+// VerificationKey returns the key by the kid header of the token, ErrUnknownKey if there is no such key or it is retired
+func (k *Keystore) VerificationKey(id string) (*Key, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	now := k.now()
+	for i, key := range k.keys {
+		if key.ID == id {
+			if i+1 < len(k.keys) && !now.Before(k.retiredAt(i)) {
+				return nil, ErrUnknownKey
+			}
+			return key, nil
+		}
+	}
+	return nil, ErrUnknownKey
+}
+
+// activatedAt time since the key signs the tokens, the first key signs at once
+func (k *Keystore) activatedAt(i int) time.Time {
+	if i == 0 {
+		return k.keys[0].CreatedAt
+	}
+	return k.keys[i].CreatedAt.Add(2 * k.opts.Reload)
+}
+
+// retiredAt time since the key is not accepted: the tokens signed by it before the next key was activated have expired
+func (k *Keystore) retiredAt(i int) time.Time {
+	return k.activatedAt(i + 1).Add(k.opts.TokenTTL)
+}
+
+// removeRetired forgets the retired keys and removes their files, the caller holds the lock
+func (k *Keystore) removeRetired(now time.Time) {
+	keep := 0
+	for keep < len(k.keys)-1 && !now.Before(k.retiredAt(keep)) {
+		keep++
+	}
+	for _, key := range k.keys[:keep] {
+		if k.opts.Dir == "" {
+			continue
+		}
+		for _, ext := range []string{privateExt, publicExt} {
+			err := os.Remove(filepath.Join(k.opts.Dir, key.ID+ext))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Error().Err(err).Msgf("cant remove retired signing key %s", key.ID)
+			}
+		}
+		log.Info().Msgf("signing key %s is retired", key.ID)
+	}
+	k.keys = k.keys[keep:]
+}
+
+// generate creates and persists a new key
+func (k *Keystore) generate(now time.Time) (*Key, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("cant generate signing key id: %w", err)
+	}
+	key := &Key{ID: hex.EncodeToString(id), Algorithm: k.opts.Algorithm, CreatedAt: now.UTC().Truncate(time.Second)}
+
+	switch key.Algorithm {
+	case HS256:
+		secret := make([]byte, hmacKeyLen)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("cant generate signing key: %w", err)
+		}
+		key.private, key.public = secret, secret
+	case EdDSA:
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("cant generate signing key: %w", err)
+		}
+		key.private, key.public = private, public
+	}
+
+	if k.opts.Dir == "" {
+		return key, nil
+	}
+	if err := os.MkdirAll(k.opts.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("cant create signing keys directory: %w", err)
+	}
+	private, public, err := encode(key)
+	if err != nil {
+		return nil, err
+	}
+	if public != nil {
+		if err := os.WriteFile(filepath.Join(k.opts.Dir, key.ID+publicExt), public, 0o644); err != nil {
+			return nil, fmt.Errorf("cant save signing key: %w", err)
+		}
+	}
+	// The private key is written last, a key without it is not used for signing
+	if err := os.WriteFile(filepath.Join(k.opts.Dir, key.ID+privateExt), private, 0o600); err != nil {
+		return nil, fmt.Errorf("cant save signing key: %w", err)
+	}
+	return key, nil
+}
+
+// load reads the key files of the directory: the private keys or, for the verification-only keystore, the public ones
+func (k *Keystore) load() ([]*Key, error) {
+	if k.opts.Dir == "" {
+		k.mu.RLock()
+		defer k.mu.RUnlock()
+		return append([]*Key(nil), k.keys...), nil
+	}
+	ext := privateExt
+	if k.opts.VerifyOnly {
+		ext = publicExt
+	}
+	files, err := filepath.Glob(filepath.Join(k.opts.Dir, "*"+ext))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*Key, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("cant read signing key: %w", err)
+		}
+		key, err := decode(strings.TrimSuffix(filepath.Base(file), ext), data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signing key %s: %w", file, err)
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].ID < keys[j].ID
+		}
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+// encode returns the pem files of the private and the public key, the public one is nil for HS256
+func encode(key *Key) ([]byte, []byte, error) {
+	headers := map[string]string{
+		"Algorithm": key.Algorithm,
+		"Created":   key.CreatedAt.Format(time.RFC3339),
+	}
+	if key.Algorithm == HS256 {
+		return pem.EncodeToMemory(&pem.Block{Type: "HMAC KEY", Headers: headers, Bytes: key.private.([]byte)}), nil, nil
+	}
+
+	private, err := x509.MarshalPKCS8PrivateKey(key.private)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cant encode signing key: %w", err)
+	}
+	public, err := x509.MarshalPKIXPublicKey(key.public)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cant encode signing key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Headers: headers, Bytes: private}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Headers: headers, Bytes: public}), nil
+}
+
+// decode parses the pem file of the key
+func decode(id string, data []byte) (*Key, error) {
+	if _, err := hex.DecodeString(id); err != nil || id == "" {
+		return nil, ErrInvalidKeyID
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("expected pem")
+	}
+	created, err := time.Parse(time.RFC3339, block.Headers["Created"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid creation time: %w", err)
+	}
+	key := &Key{ID: id, Algorithm: block.Headers["Algorithm"], CreatedAt: created}
+
+	switch {
+	case key.Algorithm == HS256 && block.Type == "HMAC KEY":
+		key.private, key.public = block.Bytes, block.Bytes
+	case key.Algorithm == EdDSA && block.Type == "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		private, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return nil, ErrAlgorithm
+		}
+		key.private, key.public = private, private.Public()
+	case key.Algorithm == EdDSA && block.Type == "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		public, ok := parsed.(ed25519.PublicKey)
+		if !ok {
+			return nil, ErrAlgorithm
+		}
+		key.public = public
+	default:
+		return nil, ErrAlgorithm
+	}
+	return key, nil
+}
@@ -0,0 +1,108 @@
+package signing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+
+	keys, err := Open(Options{Dir: dir})
+	require.NoError(t, err)
+	key, err := keys.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, EdDSA, key.Algorithm)
+	assert.FileExists(t, filepath.Join(dir, key.ID+privateExt))
+	assert.FileExists(t, filepath.Join(dir, key.ID+publicExt))
+
+	// The key is persisted, the restarted server uses the same one
+	keys, err = Open(Options{Dir: dir})
+	require.NoError(t, err)
+	same, err := keys.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, same.ID)
+
+	_, err = Open(Options{Algorithm: "RS256"})
+	assert.ErrorIs(t, err, ErrAlgorithm)
+
+	// The server does not start with a broken key file
+	broken := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(broken, "0a0b"+privateExt), []byte("not a key"), 0o600))
+	_, err = Open(Options{Dir: broken})
+	assert.Error(t, err)
+}
+
+func TestVerifyOnly(t *testing.T) {
+	dir := t.TempDir()
+	keys, err := Open(Options{Dir: dir})
+	require.NoError(t, err)
+	key, err := keys.SigningKey()
+	require.NoError(t, err)
+
+	// The replica gets only the public key files
+	replicaDir := t.TempDir()
+	data, err := os.ReadFile(filepath.Join(dir, key.ID+publicExt))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(replicaDir, key.ID+publicExt), data, 0o644))
+
+	replica, err := Open(Options{Dir: replicaDir, VerifyOnly: true})
+	require.NoError(t, err)
+	_, err = replica.SigningKey()
+	assert.ErrorIs(t, err, ErrVerifyOnly)
+	public, err := replica.VerificationKey(key.ID)
+	require.NoError(t, err)
+	assert.Nil(t, public.SignKey())
+	assert.Equal(t, key.VerifyKey(), public.VerifyKey())
+
+	// HS256 keys are secret, there is nothing to give to the replica
+	_, err = Open(Options{Dir: t.TempDir(), VerifyOnly: true})
+	assert.ErrorIs(t, err, ErrNoKey)
+}
+
+func TestRotation(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	keys := &Keystore{
+		opts: Options{Dir: dir, Algorithm: HS256, Rotation: time.Hour, Reload: time.Minute, TokenTTL: 15 * time.Minute},
+		now:  func() time.Time { return now },
+	}
+	require.NoError(t, keys.Reload())
+	first, err := keys.SigningKey()
+	require.NoError(t, err)
+
+	// The next key is generated after the rotation period, but signs only after two reload intervals
+	now = now.Add(time.Hour)
+	require.NoError(t, keys.Reload())
+	second := keys.keys[len(keys.keys)-1]
+	assert.NotEqual(t, first.ID, second.ID)
+	key, err := keys.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, key.ID)
+	_, err = keys.VerificationKey(second.ID)
+	assert.NoError(t, err, "the next key is accepted before it signs")
+
+	now = now.Add(2 * time.Minute)
+	key, err = keys.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, key.ID)
+
+	// The tokens of the first key are accepted until they expire
+	now = now.Add(14 * time.Minute)
+	_, err = keys.VerificationKey(first.ID)
+	assert.NoError(t, err)
+	now = now.Add(time.Minute)
+	_, err = keys.VerificationKey(first.ID)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	require.NoError(t, keys.Reload())
+	assert.NoFileExists(t, filepath.Join(dir, first.ID+privateExt))
+	assert.FileExists(t, filepath.Join(dir, second.ID+privateExt))
+	_, err = keys.VerificationKey("unknown")
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
@@ -0,0 +1,173 @@
+// Package throttle limits the sign-in attempts. Failed attempts are counted per login and per ip address,
+// after the free attempts every next failure blocks the sign-in for an exponentially growing delay.
+// The counters are stored in the database, so all server instances enforce the same limits.
+package throttle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/config"
+	"github.com/ncyellow/GophKeeper/internal/server/storage"
+)
+
+var ErrTooManyAttempts = errors.New(`too many sign-in attempts`)
+
+// Default limits, used when they are not set in the configuration
+const (
+	DefaultLoginAttempts = 5
+	DefaultIPAttempts    = 20
+	DefaultBaseDelay     = time.Second
+	DefaultMaxDelay      = 15 * time.Minute
+	DefaultWindow        = 24 * time.Hour
+)
+
+// LockedError the sign-in is blocked, RetryAfter is the time left
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrTooManyAttempts, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LockedError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+// Policy limits of the sign-in attempts
+type Policy struct {
+	// LoginAttempts free failed attempts of one login, IPAttempts of one ip address over all logins
+	LoginAttempts int
+	IPAttempts    int
+	// BaseDelay the delay after the first failure over the free attempts, it doubles with every next failure up to MaxDelay.
+	// Reaching MaxDelay is the lockout of the account, the owner sees it in the list of lockouts
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Window failures older than it are forgotten
+	Window time.Duration
+}
+
+// Delay returns how long the sign-in is blocked after the failures, zero for the free attempts
+func (p Policy) Delay(failures int, free int) time.Duration {
+	over := failures - free
+	if over <= 0 {
+		return 0
+	}
+	// The shift is limited to avoid the overflow, MaxDelay is reached much earlier
+	delay := time.Duration(float64(p.BaseDelay) * math.Pow(2, float64(min(over-1, 32))))
+	if delay > p.MaxDelay || delay <= 0 {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// Throttler checks and counts the sign-in attempts
+type Throttler struct {
+	Store  storage.Storage
+	Policy Policy
+}
+
+// NewThrottler constructor, the limits are taken from the configuration
+func NewThrottler(store storage.Storage, conf *config.Config) *Throttler {
+	policy := Policy{
+		LoginAttempts: conf.LoginAttempts,
+		IPAttempts:    DefaultIPAttempts,
+		BaseDelay:     DefaultBaseDelay,
+		MaxDelay:      conf.LockoutDuration,
+		Window:        DefaultWindow,
+	}
+	if policy.LoginAttempts <= 0 {
+		policy.LoginAttempts = DefaultLoginAttempts
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = DefaultMaxDelay
+	}
+	return &Throttler{Store: store, Policy: policy}
+}
+
+// Check returns *LockedError if the sign-in of the login or from the ip is blocked.
+// Unknown logins are blocked the same way, so the lockout does not reveal whether the account exists
+func (t *Throttler) Check(ctx context.Context, login string, ip string) error {
+	retryAfter, err := t.Store.LoginRetryAfter(ctx, keys(login, ip))
+	if err != nil {
+		return err
+	}
+	if retryAfter > 0 {
+		return &LockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// Failure counts the failed attempt and blocks the sign-in if the free attempts are over.
+// userID is zero if there is no such login, otherwise the lockout event is saved for the owner.
+// Returns true if the attempt locked the account
+func (t *Throttler) Failure(ctx context.Context, login string, ip string, userID int64) (bool, error) {
+	loginKey, ipKey := loginKey(login), ipKey(ip)
+
+	failures, err := t.fail(ctx, loginKey, t.Policy.LoginAttempts)
+	if err != nil {
+		return false, err
+	}
+	if ip != "" {
+		if _, err := t.fail(ctx, ipKey, t.Policy.IPAttempts); err != nil {
+			return false, err
+		}
+	}
+
+	// The lockout event is saved once, when the delay reaches the maximum
+	delay := t.Policy.Delay(failures, t.Policy.LoginAttempts)
+	if userID != 0 && delay == t.Policy.MaxDelay && t.Policy.Delay(failures-1, t.Policy.LoginAttempts) < t.Policy.MaxDelay {
+		log.Warn().Msgf("sign-in of the user %d is locked after %d failed attempts, last from %s", userID, failures, ip)
+		lockout := models.Lockout{IP: ip, Failures: failures}
+		if err := t.Store.AddLockout(ctx, userID, lockout, delay); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// Success forgets the failed attempts of the login. The counter of the ip is kept:
+// a successful sign-in to own account must not allow guessing the passwords of others
+func (t *Throttler) Success(ctx context.Context, login string) {
+	if err := t.Store.ResetLoginFailures(ctx, loginKey(login)); err != nil {
+		log.Error().Err(err).Msgf("cant reset failed sign-in attempts of %s", login)
+	}
+}
+
+// fail increments the counter and blocks it if the free attempts are over
+func (t *Throttler) fail(ctx context.Context, key string, free int) (int, error) {
+	failures, err := t.Store.AddLoginFailure(ctx, key, t.Policy.Window)
+	if err != nil {
+		return 0, err
+	}
+	if delay := t.Policy.Delay(failures, free); delay > 0 {
+		if err := t.Store.LockLogin(ctx, key, delay); err != nil {
+			return 0, err
+		}
+	}
+	return failures, nil
+}
+
+// keys the attempt counters of the sign-in
+func keys(login string, ip string) []string {
+	if ip == "" {
+		return []string{loginKey(login)}
+	}
+	return []string{loginKey(login), ipKey(ip)}
+}
+
+func loginKey(login string) string {
+	return "login:" + login
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
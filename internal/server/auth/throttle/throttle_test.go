@@ -0,0 +1,83 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/config"
+	mockstorage "github.com/ncyellow/GophKeeper/internal/server/mocks/storage"
+)
+
+func TestDelay(t *testing.T) {
+	policy := Policy{BaseDelay: time.Second, MaxDelay: time.Minute}
+
+	assert.Equal(t, time.Duration(0), policy.Delay(0, 5))
+	assert.Equal(t, time.Duration(0), policy.Delay(5, 5))
+	assert.Equal(t, time.Second, policy.Delay(6, 5))
+	assert.Equal(t, 2*time.Second, policy.Delay(7, 5))
+	assert.Equal(t, 32*time.Second, policy.Delay(11, 5))
+	assert.Equal(t, time.Minute, policy.Delay(12, 5))
+	assert.Equal(t, time.Minute, policy.Delay(1000, 5))
+}
+
+func TestCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockstorage.NewMockStorage(ctrl)
+	throttler := NewThrottler(store, &config.Config{})
+
+	store.EXPECT().LoginRetryAfter(gomock.Any(), []string{"login:user", "ip:10.0.0.1"}).Return(time.Duration(0), nil)
+	assert.NoError(t, throttler.Check(context.Background(), "user", "10.0.0.1"))
+
+	store.EXPECT().LoginRetryAfter(gomock.Any(), []string{"login:user", "ip:10.0.0.1"}).Return(30*time.Second, nil)
+	err := throttler.Check(context.Background(), "user", "10.0.0.1")
+	var locked *LockedError
+	assert.True(t, errors.As(err, &locked))
+	assert.Equal(t, 30*time.Second, locked.RetryAfter)
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+}
+
+func TestFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockstorage.NewMockStorage(ctrl)
+	throttler := NewThrottler(store, &config.Config{LoginAttempts: 3, LockoutDuration: 4 * time.Second})
+
+	// A free attempt is only counted
+	store.EXPECT().AddLoginFailure(gomock.Any(), "login:user", DefaultWindow).Return(1, nil)
+	store.EXPECT().AddLoginFailure(gomock.Any(), "ip:10.0.0.1", DefaultWindow).Return(1, nil)
+	_, err := throttler.Failure(context.Background(), "user", "10.0.0.1", 1)
+	assert.NoError(t, err)
+
+	// The free attempts are over, the delay grows
+	store.EXPECT().AddLoginFailure(gomock.Any(), "login:user", DefaultWindow).Return(5, nil)
+	store.EXPECT().LockLogin(gomock.Any(), "login:user", 2*time.Second).Return(nil)
+	store.EXPECT().AddLoginFailure(gomock.Any(), "ip:10.0.0.1", DefaultWindow).Return(5, nil)
+	locked, err := throttler.Failure(context.Background(), "user", "10.0.0.1", 1)
+	assert.NoError(t, err)
+	assert.False(t, locked)
+
+	// The maximum delay is reached, the owner is notified once
+	store.EXPECT().AddLoginFailure(gomock.Any(), "login:user", DefaultWindow).Return(6, nil)
+	store.EXPECT().LockLogin(gomock.Any(), "login:user", 4*time.Second).Return(nil)
+	store.EXPECT().AddLoginFailure(gomock.Any(), "ip:10.0.0.1", DefaultWindow).Return(6, nil)
+	store.EXPECT().AddLockout(gomock.Any(), int64(1), models.Lockout{IP: "10.0.0.1", Failures: 6}, 4*time.Second).Return(nil)
+	locked, err = throttler.Failure(context.Background(), "user", "10.0.0.1", 1)
+	assert.NoError(t, err)
+	assert.True(t, locked)
+
+	store.EXPECT().AddLoginFailure(gomock.Any(), "login:user", DefaultWindow).Return(7, nil)
+	store.EXPECT().LockLogin(gomock.Any(), "login:user", 4*time.Second).Return(nil)
+	store.EXPECT().AddLoginFailure(gomock.Any(), "ip:10.0.0.1", DefaultWindow).Return(7, nil)
+	locked, err = throttler.Failure(context.Background(), "user", "10.0.0.1", 1)
+	assert.NoError(t, err)
+	assert.False(t, locked)
+}
@@ -0,0 +1,194 @@
+// Package twofactor implements the second factor of the sign-in: TOTP codes of an authenticator app
+// and one-time recovery codes for the case the app is lost
+package twofactor
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/storage"
+	"github.com/ncyellow/GophKeeper/internal/totp"
+)
+
+var (
+	ErrCodeRequired   = errors.New(`two-factor code required`)
+	ErrInvalidCode    = errors.New(`invalid two-factor code`)
+	ErrAlreadyEnabled = errors.New(`two-factor authentication is already enabled`)
+	ErrNotEnabled     = errors.New(`two-factor authentication is not enabled`)
+	ErrNotSetUp       = errors.New(`two-factor authentication is not set up`)
+)
+
+const (
+	// DefaultIssuer the name of the service shown by the authenticator app
+	DefaultIssuer = "GophKeeper"
+	// skew the codes of the neighbour time steps are accepted because of the clock drift
+	skew = 1
+	// recoveryCodes count of the recovery codes issued on enabling
+	recoveryCodes = 10
+	// recoveryCodeLen length of the recovery code in bytes, 10 characters in base32
+	recoveryCodeLen = 5
+)
+
+// recoveryEncoding lowercase base32 is easy to read and type, no ambiguous characters like 0/O and 1/l
+var recoveryEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// Manager enables, disables and checks the second factor of the users
+type Manager struct {
+	Store  storage.Storage
+	Issuer string
+	// now is the clock of the codes, replaced in tests
+	now func() time.Time
+}
+
+// NewManager constructor
+func NewManager(store storage.Storage) *Manager {
+	return &Manager{
+		Store:  store,
+		Issuer: DefaultIssuer,
+		now:    time.Now,
+	}
+}
+
+// Setup generates a new secret of the user. The second factor is not enabled until Confirm,
+// so the user is not locked out if the secret was not added to the app
+func (m *Manager) Setup(ctx context.Context, user *models.User) (*models.TOTPSetup, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Store.SetTOTPSecret(ctx, user.UserID, secret); err != nil {
+		if errors.Is(err, storage.ErrTOTPEnabled) {
+			return nil, ErrAlreadyEnabled
+		}
+		return nil, err
+	}
+	return &models.TOTPSetup{
+		Secret: secret,
+		URI:    totp.URI(m.Issuer, user.Login, secret),
+	}, nil
+}
+
+// Confirm enables the second factor if the code of the set up secret is valid.
+// Returns the recovery codes, only their hashes are stored
+func (m *Manager) Confirm(ctx context.Context, userID int64, code string) ([]string, error) {
+	otp, err := m.Store.TOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if otp.Enabled {
+		return nil, ErrAlreadyEnabled
+	}
+	if otp.Secret == "" {
+		return nil, ErrNotSetUp
+	}
+	counter, ok, err := totp.Validate(otp.Secret, code, m.now(), skew)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	codes := make([]string, 0, recoveryCodes)
+	hashes := make([]string, 0, recoveryCodes)
+	for i := 0; i < recoveryCodes; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	if err := m.Store.EnableTOTP(ctx, userID, counter, hashes); err != nil {
+		if errors.Is(err, storage.ErrTOTPEnabled) {
+			return nil, ErrAlreadyEnabled
+		}
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable removes the second factor, the current code or a recovery code is required
+func (m *Manager) Disable(ctx context.Context, userID int64, code string) error {
+	otp, err := m.Store.TOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !otp.Enabled {
+		return ErrNotEnabled
+	}
+	if err := m.check(ctx, userID, otp, code); err != nil {
+		return err
+	}
+	return m.Store.DisableTOTP(ctx, userID)
+}
+
+// Verify checks the second factor on sign-in. Users without the second factor pass without the code
+func (m *Manager) Verify(ctx context.Context, userID int64, code string) error {
+	otp, err := m.Store.TOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !otp.Enabled {
+		return nil
+	}
+	return m.check(ctx, userID, otp, code)
+}
+
+// check accepts the TOTP code once or an unused recovery code
+func (m *Manager) check(ctx context.Context, userID int64, otp *models.TOTP, code string) error {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return ErrCodeRequired
+	}
+
+	counter, ok, err := totp.Validate(otp.Secret, code, m.now(), skew)
+	if err != nil {
+		return err
+	}
+	if ok {
+		// The code is accepted once: an intercepted code can not be replayed during its lifetime
+		used, err := m.Store.UseTOTPCounter(ctx, userID, counter)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidCode
+		}
+		return nil
+	}
+
+	used, err := m.Store.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// newRecoveryCode returns a random code in the form xxxxx-xxxxx
+func newRecoveryCode() (string, error) {
+	buf := make([]byte, recoveryCodeLen)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("cant generate recovery code: %w", err)
+	}
+	code := recoveryEncoding.EncodeToString(buf)
+	return code[:len(code)/2] + "-" + code[len(code)/2:], nil
+}
+
+// hashRecoveryCode hash of the code, the dash, spaces and the case are ignored.
+// Recovery codes are random, so a hash without a salt is enough
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(code)))
+}
@@ -0,0 +1,107 @@
+package twofactor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+	mockstorage "github.com/ncyellow/GophKeeper/internal/server/mocks/storage"
+	"github.com/ncyellow/GophKeeper/internal/totp"
+)
+
+const secret = "JBSWY3DPEHPK3PXP"
+
+func newTestManager(t *testing.T, now time.Time) (*Manager, *mockstorage.MockStorage) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+	store := mockstorage.NewMockStorage(ctrl)
+	manager := NewManager(store)
+	manager.now = func() time.Time { return now }
+	return manager, store
+}
+
+func testCode(t *testing.T, at time.Time) string {
+	code, err := totp.Code(secret, at)
+	require.NoError(t, err)
+	return code
+}
+
+func TestVerifySkew(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 10, 0, time.UTC)
+	manager, store := newTestManager(t, now)
+	store.EXPECT().TOTP(ctx, int64(1)).Return(&models.TOTP{Secret: secret, Enabled: true}, nil).AnyTimes()
+
+	// The codes of the neighbour time steps are accepted with their own counters
+	for _, step := range []int64{-1, 0, 1} {
+		at := now.Add(time.Duration(step) * 30 * time.Second)
+		store.EXPECT().UseTOTPCounter(ctx, int64(1), totp.Counter(at)).Return(true, nil)
+		assert.NoError(t, manager.Verify(ctx, 1, testCode(t, at)))
+	}
+
+	// The older and the newer ones are not TOTP codes, they are checked as the recovery codes
+	for _, step := range []int64{-2, 2} {
+		code := testCode(t, now.Add(time.Duration(step)*30*time.Second))
+		store.EXPECT().UseRecoveryCode(ctx, int64(1), hashRecoveryCode(code)).Return(false, nil)
+		assert.ErrorIs(t, manager.Verify(ctx, 1, code), ErrInvalidCode)
+	}
+
+	assert.ErrorIs(t, manager.Verify(ctx, 1, " "), ErrCodeRequired)
+}
+
+func TestVerifyReplay(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 10, 0, time.UTC)
+	manager, store := newTestManager(t, now)
+	store.EXPECT().TOTP(ctx, int64(1)).Return(&models.TOTP{Secret: secret, Enabled: true}, nil).Times(2)
+
+	// The storage accepts the time step once, the same code is rejected the second time
+	code := testCode(t, now)
+	gomock.InOrder(
+		store.EXPECT().UseTOTPCounter(ctx, int64(1), totp.Counter(now)).Return(true, nil),
+		store.EXPECT().UseTOTPCounter(ctx, int64(1), totp.Counter(now)).Return(false, nil),
+	)
+	assert.NoError(t, manager.Verify(ctx, 1, code))
+	assert.ErrorIs(t, manager.Verify(ctx, 1, code), ErrInvalidCode)
+}
+
+func TestVerifyRecoveryCode(t *testing.T) {
+	ctx := context.Background()
+	manager, store := newTestManager(t, time.Now())
+	store.EXPECT().TOTP(ctx, int64(1)).Return(&models.TOTP{Secret: secret, Enabled: true}, nil)
+
+	// The dash, the spaces and the case are ignored
+	store.EXPECT().UseRecoveryCode(ctx, int64(1), hashRecoveryCode("abcdefghij")).Return(true, nil)
+	assert.NoError(t, manager.Verify(ctx, 1, "ABCDE-FGHIJ "))
+}
+
+func TestConfirm(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 10, 0, time.UTC)
+	manager, store := newTestManager(t, now)
+	store.EXPECT().TOTP(ctx, int64(1)).Return(&models.TOTP{Secret: secret}, nil).Times(2)
+
+	_, err := manager.Confirm(ctx, 1, testCode(t, now.Add(2*time.Minute)))
+	assert.ErrorIs(t, err, ErrInvalidCode)
+
+	// The counter of the code is saved, so the code can not be used for the sign-in again
+	at := now.Add(-30 * time.Second)
+	var hashes []string
+	store.EXPECT().EnableTOTP(ctx, int64(1), totp.Counter(at), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int64, _ int64, recoveryHashes []string) error {
+			hashes = recoveryHashes
+			return nil
+		})
+	codes, err := manager.Confirm(ctx, 1, testCode(t, at))
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodes)
+	for i, code := range codes {
+		assert.Regexp(t, `^[a-z2-7]+-[a-z2-7]+$`, code)
+		assert.Equal(t, hashRecoveryCode(code), hashes[i])
+	}
+}
@@ -0,0 +1,111 @@
+// Package blobstore keeps the payloads of the binary records outside of the rows of the records.
+// The records and the checksums of their blobs stay in postgres, the blobs are kept by one of the backends:
+// the postgres table, the local filesystem or an S3-compatible object storage.
+package blobstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/driftprogramming/pgxpoolmock"
+
+	"github.com/ncyellow/GophKeeper/internal/server/config"
+)
+
+// Backends of the blob store, see config.Config.BlobStore
+const (
+	BackendPostgres = "postgres"
+	BackendFS       = "fs"
+	BackendS3       = "s3"
+)
+
+var (
+	// ErrNotFound there is no blob with the key
+	ErrNotFound = errors.New("blob not found")
+	// ErrChecksum the blob does not match its checksum, it is damaged or replaced
+	ErrChecksum = errors.New("blob checksum mismatch")
+	// ErrInvalidKey the key is empty or is not a relative slash separated path
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// BlobStore keeps the blobs by the keys. The keys are slash separated paths, e.g. "<upload>/<seq>/<sha256>"
+type BlobStore interface {
+	// Put saves the blob, the blob with the same key is replaced
+	Put(ctx context.Context, key string, data []byte) error
+	// Get returns ErrNotFound if there is no blob with the key
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete removes all the blobs with the keys under the prefix, the prefix ends with a slash
+	Delete(ctx context.Context, prefix string) error
+	// Remove removes the blob with the key, the missing blob is not an error
+	Remove(ctx context.Context, key string) error
+}
+
+// New creates the blob store of the backend configured by conf.BlobStore, postgres by default.
+// The postgres backend keeps the blobs in the database of the pool
+func New(conf *config.Config, pool pgxpoolmock.PgxPool) (BlobStore, error) {
+	switch conf.BlobStore {
+	case "", BackendPostgres:
+		return NewPostgres(pool), nil
+	case BackendFS:
+		return NewFS(conf.BlobDir)
+	case BackendS3:
+		return NewS3(S3Config{
+			Endpoint:  conf.S3Endpoint,
+			Region:    conf.S3Region,
+			Bucket:    conf.S3Bucket,
+			AccessKey: conf.S3AccessKey,
+			SecretKey: conf.S3SecretKey,
+		})
+	default:
+		return nil, fmt.Errorf("unknown blob store %q", conf.BlobStore)
+	}
+}
+
+// Checksum returns the hex SHA-256 of the blob
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Write saves the data under dir/<checksum> and returns the checksum. The key changes with the data,
+// so a blob written for a rejected chunk does not replace the accepted one
+func Write(ctx context.Context, store BlobStore, dir string, data []byte) (string, error) {
+	sum := Checksum(data)
+	if err := store.Put(ctx, path.Join(dir, sum), data); err != nil {
+		return "", err
+	}
+	return sum, nil
+}
+
+// Read returns the blob dir/<sum> verified by the checksum, ErrChecksum if it does not match
+func Read(ctx context.Context, store BlobStore, dir string, sum string) ([]byte, error) {
+	data, err := store.Get(ctx, path.Join(dir, sum))
+	if err != nil {
+		return nil, err
+	}
+	if Checksum(data) != sum {
+		return nil, fmt.Errorf("%w: %s/%s", ErrChecksum, dir, sum)
+	}
+	return data, nil
+}
+
+// validKey checks that the key is a clean relative path, so it can not leave the root of the backend
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || key == ".." || strings.HasPrefix(key, "../") {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return nil
+}
+
+// validPrefix checks the prefix of Delete, it is a key ending with a slash
+func validPrefix(prefix string) error {
+	if !strings.HasSuffix(prefix, "/") {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, prefix)
+	}
+	return validKey(strings.TrimSuffix(prefix, "/"))
+}
@@ -0,0 +1,89 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FS keeps the blobs as files under the root directory, the key is the relative path of the file.
+// The files are readable only by the server
+type FS struct {
+	root string
+}
+
+// NewFS constructor, the root directory is created if it does not exist
+func NewFS(root string) (*FS, error) {
+	if root == "" {
+		return nil, errors.New("blob directory is not configured")
+	}
+	if err := os.MkdirAll(root, 0o700); err != nil {
+		return nil, fmt.Errorf("cant create blob directory: %w", err)
+	}
+	return &FS{root: root}, nil
+}
+
+// Put writes the blob to a temporary file and renames it, so the blob is never read half written
+func (f *FS) Put(_ context.Context, key string, data []byte) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+	name := f.path(key)
+	if err := os.MkdirAll(filepath.Dir(name), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".blob-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func (f *FS) Get(_ context.Context, key string) ([]byte, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(f.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Delete removes the directory of the prefix, the keys are grouped by the directories
+func (f *FS) Delete(_ context.Context, prefix string) error {
+	if err := validPrefix(prefix); err != nil {
+		return err
+	}
+	return os.RemoveAll(f.path(strings.TrimSuffix(prefix, "/")))
+}
+
+func (f *FS) Remove(_ context.Context, key string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+	if err := os.Remove(f.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (f *FS) path(key string) string {
+	return filepath.Join(f.root, filepath.FromSlash(key))
+}
@@ -0,0 +1,61 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+
+	"github.com/driftprogramming/pgxpoolmock"
+	"github.com/jackc/pgx/v4"
+)
+
+// Postgres keeps the blobs in the blobs table of the database of the records
+type Postgres struct {
+	pool pgxpoolmock.PgxPool
+}
+
+// NewPostgres constructor, the pool is shared with the storage of the records
+func NewPostgres(pool pgxpoolmock.PgxPool) *Postgres {
+	return &Postgres{pool: pool}
+}
+
+func (p *Postgres) Put(ctx context.Context, key string, data []byte) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+	_, err := p.pool.Exec(ctx, `
+	INSERT INTO "blobs"("key", "data") VALUES ($1, $2)
+	ON CONFLICT ("key") DO UPDATE SET "data" = EXCLUDED."data"
+	`, key, data)
+	return err
+}
+
+func (p *Postgres) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+	var data []byte
+	err := p.pool.QueryRow(ctx, `SELECT "data" FROM "blobs" WHERE "key" = $1`, key).Scan(&data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (p *Postgres) Delete(ctx context.Context, prefix string) error {
+	if err := validPrefix(prefix); err != nil {
+		return err
+	}
+	_, err := p.pool.Exec(ctx, `DELETE FROM "blobs" WHERE starts_with("key", $1)`, prefix)
+	return err
+}
+
+func (p *Postgres) Remove(ctx context.Context, key string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+	_, err := p.pool.Exec(ctx, `DELETE FROM "blobs" WHERE "key" = $1`, key)
+	return err
+}
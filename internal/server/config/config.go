@@ -2,7 +2,9 @@
 package config
 
 import (
+	"errors"
 	"flag"
+	"time"
 
 	"github.com/caarlos0/env/v6"
 )
@@ -12,14 +14,54 @@ var (
 	BuildDate    = "N/A"
 )
 
+// ErrBindingsWithoutCA the bindings are checked against the verified client certificates, without the ca
+// the clients do not present them and the bound accounts can not sign in at all
+var ErrBindingsWithoutCA = errors.New("the certificate bindings require the ca of the client certificates")
+
 // Config structure for working with server configuration
 type Config struct {
 	Address      string `env:"RUN_ADDRESS"`
 	GRPCAddress  string `env:"GRPC_ADDRESS"`
 	DatabaseConn string `env:"DATABASE_URI"`
-	SigningKey   string `env:"SUPER_KEY"`
-	CryptoCrt    string `env:"CRYPTO_CERT"`
-	CryptoKey    string `env:"CRYPTO_KEY"`
+	// SigningKeysDir directory of the keys signing the access tokens, they are generated and rotated by the server.
+	// With SigningVerifyOnly only the public keys are read from it and the tokens are not issued
+	SigningKeysDir     string        `env:"SIGNING_KEYS_DIR"`
+	SigningAlgorithm   string        `env:"SIGNING_ALGORITHM"`
+	SigningKeyRotation time.Duration `env:"SIGNING_KEY_ROTATION"`
+	SigningKeysReload  time.Duration `env:"SIGNING_KEYS_RELOAD"`
+	SigningVerifyOnly  bool          `env:"SIGNING_VERIFY_ONLY"`
+	// AccessTokenTTL lifetime of the jwt token, RefreshTokenTTL lifetime of the session without refreshing
+	AccessTokenTTL  time.Duration `env:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL time.Duration `env:"REFRESH_TOKEN_TTL"`
+	// LoginAttempts free failed sign-in attempts of one login, LockoutDuration the maximum delay after them
+	LoginAttempts   int           `env:"LOGIN_ATTEMPTS"`
+	LockoutDuration time.Duration `env:"LOCKOUT_DURATION"`
+	CryptoCrt       string        `env:"CRYPTO_CERT"`
+	CryptoKey       string        `env:"CRYPTO_KEY"`
+	// CACertFile if it is set, the clients of both transports have to present a certificate signed by it
+	CACertFile string `env:"CA_CERT_FILE"`
+	// CRLFile revoked client certificates, the file is reloaded every CRLReloadInterval
+	CRLFile           string        `env:"CRL_FILE"`
+	CRLReloadInterval time.Duration `env:"CRL_RELOAD_INTERVAL"`
+	// CertBindingsFile lines "<login> <common name>", the bound accounts can be used only with their certificates
+	CertBindingsFile string `env:"CERT_BINDINGS_FILE"`
+	// MasterKey master keys for the encryption of the records at rest, "<version>:<base64 key>" separated by commas
+	MasterKey     string `env:"MASTER_KEY"`
+	MasterKeyFile string `env:"MASTER_KEY_FILE"`
+	// RevisionRetention how long the previous versions of the records are kept, zero keeps them forever
+	RevisionRetention time.Duration `env:"REVISION_RETENTION"`
+	// TrashRetention how long the deleted records can be restored, zero keeps them until the trash is emptied
+	TrashRetention time.Duration `env:"TRASH_RETENTION"`
+	// BlobStore the backend of the payloads of the binary records: postgres, fs or s3
+	BlobStore string `env:"BLOB_STORE"`
+	// BlobDir the root directory of the fs backend
+	BlobDir string `env:"BLOB_DIR"`
+	// S3Endpoint, S3Region, S3Bucket and the keys configure the s3 backend
+	S3Endpoint  string `env:"S3_ENDPOINT"`
+	S3Region    string `env:"S3_REGION"`
+	S3Bucket    string `env:"S3_BUCKET"`
+	S3AccessKey string `env:"S3_ACCESS_KEY"`
+	S3SecretKey string `env:"S3_SECRET_KEY"`
 }
 
 // ParseConfig parsing ENV + command line for reading configuration
@@ -31,6 +73,27 @@ func ParseConfig() (*Config, error) {
 	flag.StringVar(&cfg.DatabaseConn, "dns", "", "connection string to postgresql")
 	flag.StringVar(&cfg.CryptoCrt, "crypto-crt", "", "*.crt filepath for tls")
 	flag.StringVar(&cfg.CryptoKey, "crypto-key", "", "*.key filepath for tls")
+	flag.StringVar(&cfg.CACertFile, "crypto-ca", "", "*.crt filepath of the ca for the client certificates verification")
+	flag.StringVar(&cfg.CRLFile, "crypto-crl", "", "*.crl filepath of the revoked client certificates")
+	flag.DurationVar(&cfg.CRLReloadInterval, "crl-reload", 5*time.Minute, "how often the crl file is reloaded")
+	flag.StringVar(&cfg.CertBindingsFile, "cert-bindings", "", "filepath of the bindings of the client certificates to the accounts")
+	flag.StringVar(&cfg.SigningKeysDir, "signing-keys", "signing-keys", "directory of the keys signing the access tokens")
+	flag.StringVar(&cfg.SigningAlgorithm, "signing-alg", "EdDSA", "algorithm of the new signing keys: EdDSA or HS256")
+	flag.DurationVar(&cfg.SigningKeyRotation, "signing-rotation", 30*24*time.Hour, "how often a new signing key is generated")
+	flag.DurationVar(&cfg.SigningKeysReload, "signing-reload", time.Minute, "how often the signing keys directory is reloaded")
+	flag.BoolVar(&cfg.SigningVerifyOnly, "signing-verify-only", false, "read only the public signing keys, the tokens are not issued")
+	flag.DurationVar(&cfg.AccessTokenTTL, "access-ttl", 15*time.Minute, "lifetime of the access token")
+	flag.DurationVar(&cfg.RefreshTokenTTL, "refresh-ttl", 30*24*time.Hour, "lifetime of the session without refreshing")
+	flag.IntVar(&cfg.LoginAttempts, "login-attempts", 5, "free failed sign-in attempts before the delay")
+	flag.DurationVar(&cfg.LockoutDuration, "lockout", 15*time.Minute, "maximum delay of the sign-in after failed attempts")
+	flag.StringVar(&cfg.MasterKeyFile, "master-key-file", "", "filepath of the master keys for the encryption at rest")
+	flag.DurationVar(&cfg.RevisionRetention, "revision-retention", 90*24*time.Hour, "how long the previous versions of the records are kept")
+	flag.DurationVar(&cfg.TrashRetention, "trash-retention", 30*24*time.Hour, "how long the deleted records are kept in the trash")
+	flag.StringVar(&cfg.BlobStore, "blob-store", "postgres", "backend of the binary payloads: postgres, fs or s3")
+	flag.StringVar(&cfg.BlobDir, "blob-dir", "blobs", "root directory of the fs blob store")
+	flag.StringVar(&cfg.S3Endpoint, "s3-endpoint", "", "url of the s3-compatible storage of the blobs")
+	flag.StringVar(&cfg.S3Region, "s3-region", "us-east-1", "region of the s3 bucket")
+	flag.StringVar(&cfg.S3Bucket, "s3-bucket", "", "bucket of the blobs")
 
 	// First, we parse the command line
 	flag.Parse()
@@ -40,5 +103,16 @@ func ParseConfig() (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
 	return &cfg, nil
 }
+
+// Validate checks the settings that can not work together
+func (c *Config) Validate() error {
+	if c.CertBindingsFile != "" && c.CACertFile == "" {
+		return ErrBindingsWithoutCA
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		conf Config
+		want error
+	}{
+		{name: "no client certificates", conf: Config{}},
+		{name: "ca without bindings", conf: Config{CACertFile: "ca.crt"}},
+		{name: "bindings with ca", conf: Config{CACertFile: "ca.crt", CertBindingsFile: "bindings"}},
+		{name: "bindings without ca", conf: Config{CertBindingsFile: "bindings"}, want: ErrBindingsWithoutCA},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.conf.Validate(), tt.want)
+		})
+	}
+}
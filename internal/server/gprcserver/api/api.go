@@ -4,105 +4,436 @@ package api
 
 import (
 	"context"
-	"crypto/sha1"
 	"errors"
-	"fmt"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"time"
 
 	"github.com/jackc/pgx/v4"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
 	"github.com/ncyellow/GophKeeper/internal/models"
 	proto2 "github.com/ncyellow/GophKeeper/internal/proto"
+	"github.com/ncyellow/GophKeeper/internal/server/audit"
+	"github.com/ncyellow/GophKeeper/internal/server/auth"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/certbind"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/jwt"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/password"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/signing"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/throttle"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/twofactor"
 	"github.com/ncyellow/GophKeeper/internal/server/config"
+	"github.com/ncyellow/GophKeeper/internal/server/listing"
+	"github.com/ncyellow/GophKeeper/internal/server/paging"
 	"github.com/ncyellow/GophKeeper/internal/server/storage"
 )
 
+// PublicMethods grpc methods that are called without a token, all others require authorization
+var PublicMethods = []string{
+	"/proto.GophKeeperServer/Register",
+	"/proto.GophKeeperServer/SignIn",
+	"/proto.GophKeeperServer/Refresh",
+}
+
 // GRPCServer structure for implementing a grpc server. I have done full testing for the https server.
 // Therefore, I will not repeat myself and will not test the same thing a second time.
 type GRPCServer struct {
 	proto2.UnimplementedGophKeeperServerServer
-	conf *config.Config
-	repo storage.Storage
+	conf       *config.Config
+	repo       storage.Storage
+	authorizer *jwt.Authorizer
+	bindings   *certbind.Bindings
 }
 
-// NewServer constructor
-func NewServer(repo storage.Storage, conf *config.Config) *GRPCServer {
+// NewServer constructor, keys sign the tokens, bindings restrict the accounts to the client certificates, may be nil
+func NewServer(repo storage.Storage, conf *config.Config, keys *signing.Keystore, bindings *certbind.Bindings) *GRPCServer {
 	return &GRPCServer{
-		repo: repo,
-		conf: conf,
+		repo:       repo,
+		conf:       conf,
+		authorizer: jwt.NewAuthorizer(repo, conf, keys),
+		bindings:   bindings,
 	}
 }
 
 // Register user registration
 func (s *GRPCServer) Register(ctx context.Context, req *proto2.RegisterRequest) (*proto2.RegisterResponse, error) {
-	login := req.GetLogin()
-	originalPassword := req.GetPassword()
+	if err := s.bindings.Check(req.GetLogin(), auth.TLSState(ctx)); err != nil {
+		return nil, status.Error(codes.PermissionDenied, "certificate is not bound to the account")
+	}
 
-	pwd := sha1.New()
-	pwd.Write([]byte(originalPassword))
-	hashPwd := fmt.Sprintf("%x", pwd.Sum(nil))
+	hashPwd, err := password.Hash(req.GetPassword())
+	if err != nil {
+		return nil, status.Error(codes.Internal, "")
+	}
 
 	user := models.User{
-		Login:    login,
+		Login:    req.GetLogin(),
 		Password: hashPwd,
 	}
 
 	// Performing registration attempt
-	_, err := s.repo.Register(ctx, user)
+	user.UserID, err = s.repo.Register(ctx, user)
 	if err != nil {
 		return nil, status.Error(codes.AlreadyExists, "")
 	}
 
-	repoUser, err := s.repo.User(ctx, user.Login, user.Password)
+	// Creating the session if registration is successful
+	tokens, err := s.authorizer.CreateSession(ctx, &user, userAgent(ctx))
 	if err != nil {
 		return nil, status.Error(codes.Internal, "")
 	}
 
 	return &proto2.RegisterResponse{
-		User: repoUser.UserID,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
 	}, nil
 }
 
 // SignIn authentication
 func (s *GRPCServer) SignIn(ctx context.Context, req *proto2.RegisterRequest) (*proto2.RegisterResponse, error) {
-	login := req.GetLogin()
-	originalPassword := req.GetPassword()
+	user := models.User{
+		Login:    req.GetLogin(),
+		Password: req.GetPassword(),
+		OTP:      req.GetOtp(),
+	}
 
-	pwd := sha1.New()
-	pwd.Write([]byte(originalPassword))
-	hashPwd := fmt.Sprintf("%x", pwd.Sum(nil))
+	// The bound account is not signed in without its certificate
+	if err := s.bindings.Check(user.Login, auth.TLSState(ctx)); err != nil {
+		return nil, status.Error(codes.PermissionDenied, "certificate is not bound to the account")
+	}
 
-	user := models.User{
-		Login:    login,
-		Password: hashPwd,
+	// Attempting authentication, if successful - generate tokens
+	tokens, err := s.authorizer.SignIn(ctx, &user, userAgent(ctx), remoteIP(ctx))
+	if err != nil {
+		var locked *throttle.LockedError
+		if errors.As(err, &locked) {
+			seconds := int(math.Ceil(locked.RetryAfter.Seconds()))
+			grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(seconds)))
+			return nil, status.Errorf(codes.ResourceExhausted, "too many attempts, retry after %d seconds", seconds)
+		}
+		// The password is correct, the client has to repeat the request with the second factor code
+		if errors.Is(err, twofactor.ErrCodeRequired) {
+			return nil, status.Error(codes.FailedPrecondition, "otp required")
+		}
+		return nil, status.Error(codes.Unauthenticated, "")
 	}
 
-	repoUser, err := s.repo.User(ctx, user.Login, user.Password)
+	return &proto2.RegisterResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+// Refresh issues a new pair of tokens by the refresh token
+func (s *GRPCServer) Refresh(ctx context.Context, req *proto2.RefreshRequest) (*proto2.RegisterResponse, error) {
+	tokens, err := s.authorizer.Refresh(ctx, req.GetRefreshToken())
 	if err != nil {
+		if errors.Is(err, jwt.ErrSessionExpired) || errors.Is(err, jwt.ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, "")
+		}
 		return nil, status.Error(codes.Internal, "")
 	}
 
 	return &proto2.RegisterResponse{
-		User: repoUser.UserID,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
 	}, nil
 }
 
-// AddCard register a new card
+// Logout revokes the current session
+func (s *GRPCServer) Logout(ctx context.Context, req *proto2.LogoutRequest) (*proto2.LogoutResponse, error) {
+	var response proto2.LogoutResponse
+	sessionID, _ := ctx.Value(auth.SessionContextKey{}).(string)
+	err := s.repo.RevokeSession(ctx, currentUserID(ctx), sessionID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, status.Error(codes.Internal, "")
+	}
+	return &response, nil
+}
+
+// Sessions returns the active sessions of the user
+func (s *GRPCServer) Sessions(ctx context.Context, req *proto2.SessionsRequest) (*proto2.SessionsResponse, error) {
+	var response proto2.SessionsResponse
+	sessionID, _ := ctx.Value(auth.SessionContextKey{}).(string)
+	sessions, err := s.repo.Sessions(ctx, currentUserID(ctx))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "")
+	}
+	for _, session := range sessions {
+		response.Sessions = append(response.Sessions, &proto2.Session{
+			Id:          session.ID,
+			UserAgent:   session.UserAgent,
+			CreatedAt:   session.CreatedAt.Unix(),
+			RefreshedAt: session.RefreshedAt.Unix(),
+			ExpiresAt:   session.ExpiresAt.Unix(),
+			Current:     session.ID == sessionID,
+		})
+	}
+	return &response, nil
+}
+
+// RevokeSession revokes the session of the user
+func (s *GRPCServer) RevokeSession(ctx context.Context, req *proto2.RevokeSessionRequest) (*proto2.RevokeSessionResponse, error) {
+	var response proto2.RevokeSessionResponse
+	user := ctx.Value(auth.UserContextKey{}).(*models.User)
+	err := s.authorizer.RevokeSession(ctx, user, remoteIP(ctx), req.GetId())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "")
+		}
+		return nil, status.Error(codes.Internal, "")
+	}
+	return &response, nil
+}
+
+// SetupTOTP generates a new secret of the second factor, it is enabled only after ConfirmTOTP
+func (s *GRPCServer) SetupTOTP(ctx context.Context, req *proto2.SetupTOTPRequest) (*proto2.SetupTOTPResponse, error) {
+	user := ctx.Value(auth.UserContextKey{}).(*models.User)
+	setup, err := s.authorizer.TwoFactor.Setup(ctx, user)
+	if err != nil {
+		if errors.Is(err, twofactor.ErrAlreadyEnabled) {
+			return nil, status.Error(codes.AlreadyExists, "")
+		}
+		return nil, status.Error(codes.Internal, "")
+	}
+	return &proto2.SetupTOTPResponse{
+		Secret: setup.Secret,
+		Uri:    setup.URI,
+	}, nil
+}
+
+// ConfirmTOTP enables the second factor and returns the recovery codes
+func (s *GRPCServer) ConfirmTOTP(ctx context.Context, req *proto2.ConfirmTOTPRequest) (*proto2.ConfirmTOTPResponse, error) {
+	user := ctx.Value(auth.UserContextKey{}).(*models.User)
+	recoveryCodes, err := s.authorizer.EnableTOTP(ctx, user, remoteIP(ctx), req.GetCode())
+	if err != nil {
+		switch {
+		case errors.Is(err, twofactor.ErrInvalidCode):
+			return nil, status.Error(codes.PermissionDenied, "invalid code")
+		case errors.Is(err, twofactor.ErrAlreadyEnabled), errors.Is(err, twofactor.ErrNotSetUp):
+			return nil, status.Error(codes.FailedPrecondition, "")
+		}
+		return nil, status.Error(codes.Internal, "")
+	}
+	return &proto2.ConfirmTOTPResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+// DisableTOTP disables the second factor, the current code or a recovery code is required
+func (s *GRPCServer) DisableTOTP(ctx context.Context, req *proto2.DisableTOTPRequest) (*proto2.DisableTOTPResponse, error) {
+	var response proto2.DisableTOTPResponse
+	user := ctx.Value(auth.UserContextKey{}).(*models.User)
+	err := s.authorizer.DisableTOTP(ctx, user, remoteIP(ctx), req.GetCode())
+	if err != nil {
+		switch {
+		case errors.Is(err, twofactor.ErrInvalidCode), errors.Is(err, twofactor.ErrCodeRequired):
+			return nil, status.Error(codes.PermissionDenied, "invalid code")
+		case errors.Is(err, twofactor.ErrNotEnabled):
+			return nil, status.Error(codes.FailedPrecondition, "")
+		}
+		return nil, status.Error(codes.Internal, "")
+	}
+	return &response, nil
+}
+
+// ChangePassword replaces the password of the user, the other sessions are revoked
+func (s *GRPCServer) ChangePassword(ctx context.Context, req *proto2.ChangePasswordRequest) (*proto2.ChangePasswordResponse, error) {
+	user := ctx.Value(auth.UserContextKey{}).(*models.User)
+	sessionID, _ := ctx.Value(auth.SessionContextKey{}).(string)
+	err := s.authorizer.ChangePassword(ctx, user, sessionID, remoteIP(ctx), models.PasswordChange{
+		Password:    req.GetPassword(),
+		NewPassword: req.GetNewPassword(),
+	})
+	if err != nil {
+		return nil, accountError(ctx, err)
+	}
+	return &proto2.ChangePasswordResponse{}, nil
+}
+
+// ChangeLogin renames the user, the current access token stops working and is refreshed by the client
+func (s *GRPCServer) ChangeLogin(ctx context.Context, req *proto2.ChangeLoginRequest) (*proto2.ChangeLoginResponse, error) {
+	user := ctx.Value(auth.UserContextKey{}).(*models.User)
+	// The binding is kept by the login, so the bound account is not renamed and the new login is not bound
+	if err := s.bindings.CheckRename(user.Login, req.GetNewLogin(), auth.TLSState(ctx)); errors.Is(err, certbind.ErrBoundLogin) {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	} else if err != nil {
+		return nil, status.Error(codes.PermissionDenied, "certificate is not bound to the account")
+	}
+	err := s.authorizer.ChangeLogin(ctx, user, remoteIP(ctx), models.LoginChange{
+		Password: req.GetPassword(),
+		NewLogin: req.GetNewLogin(),
+	})
+	if err != nil {
+		return nil, accountError(ctx, err)
+	}
+	return &proto2.ChangeLoginResponse{}, nil
+}
+
+// DeleteAccount removes the user with all the records and sessions
+func (s *GRPCServer) DeleteAccount(ctx context.Context, req *proto2.DeleteAccountRequest) (*proto2.DeleteAccountResponse, error) {
+	user := ctx.Value(auth.UserContextKey{}).(*models.User)
+	err := s.authorizer.DeleteAccount(ctx, user, remoteIP(ctx), models.AccountDeletion{Password: req.GetPassword()})
+	if err != nil {
+		return nil, accountError(ctx, err)
+	}
+	return &proto2.DeleteAccountResponse{}, nil
+}
+
+// accountError converts the errors of the account management requests to the grpc status.
+// The wrong password is PermissionDenied and not Unauthenticated, otherwise the client would refresh the token and repeat the request
+func accountError(ctx context.Context, err error) error {
+	var locked *throttle.LockedError
+	switch {
+	case errors.As(err, &locked):
+		seconds := int(math.Ceil(locked.RetryAfter.Seconds()))
+		grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(seconds)))
+		return status.Errorf(codes.ResourceExhausted, "too many attempts, retry after %d seconds", seconds)
+	case errors.Is(err, jwt.ErrEmptyValue):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, jwt.ErrInvalidCredentials):
+		return status.Error(codes.PermissionDenied, "invalid password")
+	case errors.Is(err, storage.ErrLoginTaken):
+		return status.Error(codes.AlreadyExists, "")
+	default:
+		return status.Error(codes.Internal, "")
+	}
+}
+
+// Lockouts returns the lockouts of the account after too many failed sign-in attempts
+func (s *GRPCServer) Lockouts(ctx context.Context, req *proto2.LockoutsRequest) (*proto2.LockoutsResponse, error) {
+	var response proto2.LockoutsResponse
+	lockouts, err := s.repo.Lockouts(ctx, currentUserID(ctx))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "")
+	}
+	for _, lockout := range lockouts {
+		response.Lockouts = append(response.Lockouts, &proto2.Lockout{
+			Ip:          lockout.IP,
+			Failures:    int32(lockout.Failures),
+			CreatedAt:   lockout.CreatedAt.Unix(),
+			LockedUntil: lockout.LockedUntil.Unix(),
+		})
+	}
+	return &response, nil
+}
+
+// AuditEvents returns the page of the audit log of the user
+func (s *GRPCServer) AuditEvents(ctx context.Context, req *proto2.AuditRequest) (*proto2.AuditResponse, error) {
+	var response proto2.AuditResponse
+	limit := paging.Limit(int(req.GetLimit()))
+	events, err := s.repo.AuditEvents(ctx, currentUserID(ctx), req.GetBefore(), limit)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "")
+	}
+	page := audit.NewPage(events, limit)
+	for _, event := range page.Events {
+		response.Events = append(response.Events, &proto2.AuditEvent{
+			Id:        event.ID,
+			Actor:     event.Actor,
+			Action:    event.Action,
+			Kind:      string(event.Kind),
+			RecordId:  event.RecordID,
+			Transport: event.Transport,
+			Ip:        event.IP,
+			CreatedAt: event.CreatedAt.Unix(),
+		})
+	}
+	response.Next = page.Next
+	return &response, nil
+}
+
+// ListCards streams the page of the cards of the user without the secret fields
+func (s *GRPCServer) ListCards(req *proto2.ListRequest, stream proto2.GophKeeperServer_ListCardsServer) error {
+	return s.listRecords(models.KindCard, req, stream)
+}
+
+// ListLogins streams the page of the logins of the user without the secret fields
+func (s *GRPCServer) ListLogins(req *proto2.ListRequest, stream proto2.GophKeeperServer_ListLoginsServer) error {
+	return s.listRecords(models.KindLogin, req, stream)
+}
+
+// ListTexts streams the page of the text data of the user without the secret fields
+func (s *GRPCServer) ListTexts(req *proto2.ListRequest, stream proto2.GophKeeperServer_ListTextsServer) error {
+	return s.listRecords(models.KindText, req, stream)
+}
+
+// ListBinaries streams the page of the binary data of the user without the secret fields
+func (s *GRPCServer) ListBinaries(req *proto2.ListRequest, stream proto2.GophKeeperServer_ListBinariesServer) error {
+	return s.listRecords(models.KindBinary, req, stream)
+}
+
+// ListSSHKeys streams the page of the ssh keys of the user without the secret fields
+func (s *GRPCServer) ListSSHKeys(req *proto2.ListRequest, stream proto2.GophKeeperServer_ListSSHKeysServer) error {
+	return s.listRecords(models.KindSSH, req, stream)
+}
+
+// recordStream the server side of any of the record list streams
+type recordStream interface {
+	Send(*proto2.RecordInfo) error
+	Context() context.Context
+}
+
+// listRecords sends the page of the records of the kind one by one, the last record of a full page has the next cursor
+func (s *GRPCServer) listRecords(kind models.Kind, req *proto2.ListRequest, stream recordStream) error {
+	order := listing.OrderAsc
+	if req.GetDesc() {
+		order = listing.OrderDesc
+	}
+	opts, err := listing.Options(req.GetSort(), order, req.GetAfter(), int(req.GetLimit()))
+	if err == nil {
+		opts, err = listing.Filter(opts, req.GetTag(), req.GetField(), req.GetValue())
+	}
+	if err == nil {
+		opts, err = listing.Scope(opts, req.GetVault(), req.GetFolder())
+	}
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	ctx := stream.Context()
+	records, err := s.repo.Records(ctx, currentUserID(ctx), kind, opts)
+	if err != nil {
+		return status.Error(codes.Internal, "")
+	}
+	page := listing.NewPage(records, opts.Limit)
+	for i, record := range page.Records {
+		info := &proto2.RecordInfo{Id: record.ID, Note: record.Note, Tags: record.Tags, Fields: record.Fields}
+		if i == len(page.Records)-1 {
+			info.Next = page.Next
+		}
+		if err := stream.Send(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddCard register a new card, the number, the expiry and the CVV are checked first
 func (s *GRPCServer) AddCard(ctx context.Context, req *proto2.AddCardRequest) (*proto2.AddCardResponse, error) {
 	var response proto2.AddCardResponse
-	card := *req.GetCard()
-	userID := req.GetUser()
-	err := s.repo.AddCard(ctx, userID, models.Card{
-		ID:       card.GetId(),
-		FIO:      card.GetFio(),
-		Number:   card.GetFio(),
-		Date:     card.GetDate(),
-		CVV:      card.GetCvv(),
-		MetaInfo: card.GetMetainfo(),
-	})
+	card := models.Card{
+		ID:     req.GetCard().GetId(),
+		FIO:    req.GetCard().GetFio(),
+		Number: req.GetCard().GetNumber(),
+		Date:   req.GetCard().GetDate(),
+		CVV:    req.GetCard().GetCvv(),
+		Note:   req.GetCard().GetNote(),
+		Tags:   req.GetCard().GetTags(),
+		Fields: req.GetCard().GetFields(),
+		Vault:  req.GetCard().GetVault(),
+		Folder: req.GetCard().GetFolder(),
+	}
+	if err := card.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	err := s.repo.AddCard(ctx, currentUserID(ctx), card)
 	if err != nil {
-		return nil, status.Error(codes.AlreadyExists, "")
+		return nil, addError(err)
 	}
 	return &response, nil
 }
@@ -111,15 +442,20 @@ func (s *GRPCServer) AddCard(ctx context.Context, req *proto2.AddCardRequest) (*
 func (s *GRPCServer) AddLogin(ctx context.Context, req *proto2.AddLoginRequest) (*proto2.AddLoginResponse, error) {
 	var response proto2.AddLoginResponse
 	login := *req.GetLogin()
-	userID := req.GetUser()
+	userID := currentUserID(ctx)
 	err := s.repo.AddLogin(ctx, userID, models.Login{
 		ID:       login.GetId(),
 		Login:    login.GetLogin(),
 		Password: login.GetPassword(),
-		MetaInfo: login.GetMetainfo(),
+		TOTP:     login.GetTotp(),
+		Note:     login.GetNote(),
+		Tags:     login.GetTags(),
+		Fields:   login.GetFields(),
+		Vault:    login.GetVault(),
+		Folder:   login.GetFolder(),
 	})
 	if err != nil {
-		return nil, status.Error(codes.AlreadyExists, "")
+		return nil, addError(err)
 	}
 	return &response, nil
 }
@@ -128,14 +464,18 @@ func (s *GRPCServer) AddLogin(ctx context.Context, req *proto2.AddLoginRequest)
 func (s *GRPCServer) AddText(ctx context.Context, req *proto2.AddTextRequest) (*proto2.AddTextResponse, error) {
 	var response proto2.AddTextResponse
 	text := *req.GetText()
-	userID := req.GetUser()
+	userID := currentUserID(ctx)
 	err := s.repo.AddText(ctx, userID, models.Text{
-		ID:       text.GetId(),
-		Content:  text.GetContent(),
-		MetaInfo: text.GetMetainfo(),
+		ID:      text.GetId(),
+		Content: text.GetContent(),
+		Note:    text.GetNote(),
+		Tags:    text.GetTags(),
+		Fields:  text.GetFields(),
+		Vault:   text.GetVault(),
+		Folder:  text.GetFolder(),
 	})
 	if err != nil {
-		return nil, status.Error(codes.AlreadyExists, "")
+		return nil, addError(err)
 	}
 	return &response, nil
 }
@@ -144,14 +484,46 @@ func (s *GRPCServer) AddText(ctx context.Context, req *proto2.AddTextRequest) (*
 func (s *GRPCServer) AddBinary(ctx context.Context, req *proto2.AddBinRequest) (*proto2.AddBinResponse, error) {
 	var response proto2.AddBinResponse
 	text := *req.GetBinary()
-	userID := req.GetUser()
+	userID := currentUserID(ctx)
 	err := s.repo.AddBinary(ctx, userID, models.Binary{
 		ID:       text.GetId(),
 		Data:     text.GetData(),
-		MetaInfo: text.GetMetainfo(),
+		FileName: text.GetFileName(),
+		FileSize: text.GetFileSize(),
+		MimeType: text.GetMimeType(),
+		ModTime:  timeOfUnix(text.GetModTime()),
+		Note:     text.GetNote(),
+		Tags:     text.GetTags(),
+		Fields:   text.GetFields(),
+		Vault:    text.GetVault(),
+		Folder:   text.GetFolder(),
 	})
 	if err != nil {
-		return nil, status.Error(codes.AlreadyExists, "")
+		return nil, addError(err)
+	}
+	return &response, nil
+}
+
+// AddSSHKey register a new ssh key, the public key and the fingerprint are derived from the private one if needed
+func (s *GRPCServer) AddSSHKey(ctx context.Context, req *proto2.AddSSHKeyRequest) (*proto2.AddSSHKeyResponse, error) {
+	var response proto2.AddSSHKeyResponse
+	key := models.SSHKey{
+		ID:         req.GetKey().GetId(),
+		PrivateKey: req.GetKey().GetPrivateKey(),
+		PublicKey:  req.GetKey().GetPublicKey(),
+		Comment:    req.GetKey().GetComment(),
+		Note:       req.GetKey().GetNote(),
+		Tags:       req.GetKey().GetTags(),
+		Fields:     req.GetKey().GetFields(),
+		Vault:      req.GetKey().GetVault(),
+		Folder:     req.GetKey().GetFolder(),
+	}
+	if err := key.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	err := s.repo.AddSSHKey(ctx, currentUserID(ctx), key)
+	if err != nil {
+		return nil, addError(err)
 	}
 	return &response, nil
 }
@@ -159,7 +531,7 @@ func (s *GRPCServer) AddBinary(ctx context.Context, req *proto2.AddBinRequest) (
 // Card return specific card data
 func (s *GRPCServer) Card(ctx context.Context, req *proto2.CardRequest) (*proto2.CardResponse, error) {
 	cardID := req.GetId()
-	userID := req.GetUser()
+	userID := currentUserID(ctx)
 	card, err := s.repo.Card(ctx, userID, cardID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -169,12 +541,17 @@ func (s *GRPCServer) Card(ctx context.Context, req *proto2.CardRequest) (*proto2
 	}
 	return &proto2.CardResponse{
 		Card: &proto2.Card{
-			Id:       card.ID,
-			Fio:      card.FIO,
-			Number:   card.Number,
-			Date:     card.Date,
-			Cvv:      card.CVV,
-			Metainfo: card.MetaInfo,
+			Id:      card.ID,
+			Fio:     card.FIO,
+			Number:  card.Number,
+			Date:    card.Date,
+			Cvv:     card.CVV,
+			Note:    card.Note,
+			Tags:    card.Tags,
+			Fields:  card.Fields,
+			Vault:   card.Vault,
+			Folder:  card.Folder,
+			Version: card.Version,
 		},
 	}, nil
 }
@@ -182,7 +559,7 @@ func (s *GRPCServer) Card(ctx context.Context, req *proto2.CardRequest) (*proto2
 // Login return specific login data
 func (s *GRPCServer) Login(ctx context.Context, req *proto2.LoginRequest) (*proto2.LoginResponse, error) {
 	loginID := req.GetId()
-	userID := req.GetUser()
+	userID := currentUserID(ctx)
 	login, err := s.repo.Login(ctx, userID, loginID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -195,7 +572,13 @@ func (s *GRPCServer) Login(ctx context.Context, req *proto2.LoginRequest) (*prot
 			Id:       login.ID,
 			Login:    login.Login,
 			Password: login.Password,
-			Metainfo: login.MetaInfo,
+			Totp:     login.TOTP,
+			Note:     login.Note,
+			Tags:     login.Tags,
+			Fields:   login.Fields,
+			Vault:    login.Vault,
+			Folder:   login.Folder,
+			Version:  login.Version,
 		},
 	}, nil
 }
@@ -203,7 +586,7 @@ func (s *GRPCServer) Login(ctx context.Context, req *proto2.LoginRequest) (*prot
 // Text return specific text data
 func (s *GRPCServer) Text(ctx context.Context, req *proto2.TextRequest) (*proto2.TextResponse, error) {
 	textID := req.GetId()
-	userID := req.GetUser()
+	userID := currentUserID(ctx)
 	text, err := s.repo.Text(ctx, userID, textID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -213,9 +596,14 @@ func (s *GRPCServer) Text(ctx context.Context, req *proto2.TextRequest) (*proto2
 	}
 	return &proto2.TextResponse{
 		Text: &proto2.Text{
-			Id:       text.ID,
-			Content:  text.Content,
-			Metainfo: text.MetaInfo,
+			Id:      text.ID,
+			Content: text.Content,
+			Note:    text.Note,
+			Tags:    text.Tags,
+			Fields:  text.Fields,
+			Vault:   text.Vault,
+			Folder:  text.Folder,
+			Version: text.Version,
 		},
 	}, nil
 }
@@ -223,7 +611,7 @@ func (s *GRPCServer) Text(ctx context.Context, req *proto2.TextRequest) (*proto2
 // Binary return specific binary data
 func (s *GRPCServer) Binary(ctx context.Context, req *proto2.BinRequest) (*proto2.BinResponse, error) {
 	binID := req.GetId()
-	userID := req.GetUser()
+	userID := currentUserID(ctx)
 	bin, err := s.repo.Binary(ctx, userID, binID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -233,20 +621,420 @@ func (s *GRPCServer) Binary(ctx context.Context, req *proto2.BinRequest) (*proto
 	}
 	return &proto2.BinResponse{
 		Binary: &proto2.Binary{
-			Id:       bin.ID,
-			Data:     bin.Data,
-			Metainfo: bin.MetaInfo,
+			Id:        bin.ID,
+			Data:      bin.Data,
+			Note:      bin.Note,
+			Tags:      bin.Tags,
+			Fields:    bin.Fields,
+			Vault:     bin.Vault,
+			Folder:    bin.Folder,
+			Version:   bin.Version,
+			ContentId: bin.ContentID,
+			Size:      bin.Size,
+			FileName:  bin.FileName,
+			FileSize:  bin.FileSize,
+			MimeType:  bin.MimeType,
+			ModTime:   unixTime(bin.ModTime),
 		},
 	}, nil
 }
 
+// SSHKey return specific ssh key
+func (s *GRPCServer) SSHKey(ctx context.Context, req *proto2.SSHKeyRequest) (*proto2.SSHKeyResponse, error) {
+	key, err := s.repo.SSHKey(ctx, currentUserID(ctx), req.GetId())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "")
+		}
+		return nil, status.Error(codes.Internal, "")
+	}
+	return &proto2.SSHKeyResponse{
+		Key: &proto2.SSHKey{
+			Id:          key.ID,
+			PrivateKey:  key.PrivateKey,
+			PublicKey:   key.PublicKey,
+			Comment:     key.Comment,
+			Fingerprint: key.Fingerprint,
+			Note:        key.Note,
+			Tags:        key.Tags,
+			Fields:      key.Fields,
+			Vault:       key.Vault,
+			Folder:      key.Folder,
+			Version:     key.Version,
+		},
+	}, nil
+}
+
+// UpdateCard replaces the card if its version is still the one read by the client
+func (s *GRPCServer) UpdateCard(ctx context.Context, req *proto2.UpdateCardRequest) (*proto2.UpdateCardResponse, error) {
+	card := models.Card{
+		ID:      req.GetCard().GetId(),
+		FIO:     req.GetCard().GetFio(),
+		Number:  req.GetCard().GetNumber(),
+		Date:    req.GetCard().GetDate(),
+		CVV:     req.GetCard().GetCvv(),
+		Note:    req.GetCard().GetNote(),
+		Tags:    req.GetCard().GetTags(),
+		Fields:  req.GetCard().GetFields(),
+		Version: req.GetCard().GetVersion(),
+	}
+	if err := card.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	version, err := s.repo.UpdateCard(ctx, currentUserID(ctx), card)
+	if err != nil {
+		return nil, updateError(err)
+	}
+	return &proto2.UpdateCardResponse{Version: version}, nil
+}
+
+// UpdateLogin replaces the login if its version is still the one read by the client
+func (s *GRPCServer) UpdateLogin(ctx context.Context, req *proto2.UpdateLoginRequest) (*proto2.UpdateLoginResponse, error) {
+	login := req.GetLogin()
+	version, err := s.repo.UpdateLogin(ctx, currentUserID(ctx), models.Login{
+		ID:       login.GetId(),
+		Login:    login.GetLogin(),
+		Password: login.GetPassword(),
+		TOTP:     login.GetTotp(),
+		Note:     login.GetNote(),
+		Tags:     login.GetTags(),
+		Fields:   login.GetFields(),
+		Version:  login.GetVersion(),
+	})
+	if err != nil {
+		return nil, updateError(err)
+	}
+	return &proto2.UpdateLoginResponse{Version: version}, nil
+}
+
+// UpdateText replaces the text if its version is still the one read by the client
+func (s *GRPCServer) UpdateText(ctx context.Context, req *proto2.UpdateTextRequest) (*proto2.UpdateTextResponse, error) {
+	text := req.GetText()
+	version, err := s.repo.UpdateText(ctx, currentUserID(ctx), models.Text{
+		ID:      text.GetId(),
+		Content: text.GetContent(),
+		Note:    text.GetNote(),
+		Tags:    text.GetTags(),
+		Fields:  text.GetFields(),
+		Version: text.GetVersion(),
+	})
+	if err != nil {
+		return nil, updateError(err)
+	}
+	return &proto2.UpdateTextResponse{Version: version}, nil
+}
+
+// UpdateBinary replaces the binary data if its version is still the one read by the client
+func (s *GRPCServer) UpdateBinary(ctx context.Context, req *proto2.UpdateBinRequest) (*proto2.UpdateBinResponse, error) {
+	bin := req.GetBinary()
+	version, err := s.repo.UpdateBinary(ctx, currentUserID(ctx), models.Binary{
+		ID:        bin.GetId(),
+		Data:      bin.GetData(),
+		Note:      bin.GetNote(),
+		Tags:      bin.GetTags(),
+		Fields:    bin.GetFields(),
+		Version:   bin.GetVersion(),
+		ContentID: bin.GetContentId(),
+		FileName:  bin.GetFileName(),
+		FileSize:  bin.GetFileSize(),
+		MimeType:  bin.GetMimeType(),
+		ModTime:   timeOfUnix(bin.GetModTime()),
+	})
+	if err != nil {
+		return nil, updateError(err)
+	}
+	return &proto2.UpdateBinResponse{Version: version}, nil
+}
+
+// UpdateSSHKey replaces the ssh key if its version is still the one read by the client
+func (s *GRPCServer) UpdateSSHKey(ctx context.Context, req *proto2.UpdateSSHKeyRequest) (*proto2.UpdateSSHKeyResponse, error) {
+	key := models.SSHKey{
+		ID:         req.GetKey().GetId(),
+		PrivateKey: req.GetKey().GetPrivateKey(),
+		PublicKey:  req.GetKey().GetPublicKey(),
+		Comment:    req.GetKey().GetComment(),
+		Note:       req.GetKey().GetNote(),
+		Tags:       req.GetKey().GetTags(),
+		Fields:     req.GetKey().GetFields(),
+		Version:    req.GetKey().GetVersion(),
+	}
+	if err := key.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	version, err := s.repo.UpdateSSHKey(ctx, currentUserID(ctx), key)
+	if err != nil {
+		return nil, updateError(err)
+	}
+	return &proto2.UpdateSSHKeyResponse{Version: version}, nil
+}
+
+// addError converts the error of the new record to the grpc status
+func addError(err error) error {
+	if errors.Is(err, storage.ErrInvalidLocation) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.AlreadyExists, "")
+}
+
+// updateError converts the error of the record update to the grpc status
+func updateError(err error) error {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return status.Error(codes.NotFound, "")
+	case errors.Is(err, storage.ErrVersionConflict):
+		return status.Error(codes.Aborted, "version conflict")
+	case errors.Is(err, storage.ErrContentRemoved):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, "")
+	}
+}
+
+// Revisions returns the previous versions of the record without the values, the newest first
+func (s *GRPCServer) Revisions(ctx context.Context, req *proto2.RevisionsRequest) (*proto2.RevisionsResponse, error) {
+	kind, err := models.ParseKind(req.GetKind())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	revisions, err := s.repo.Revisions(ctx, currentUserID(ctx), kind, req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.Internal, "")
+	}
+	var response proto2.RevisionsResponse
+	for _, revision := range revisions {
+		response.Revisions = append(response.Revisions, revisionProto(revision))
+	}
+	return &response, nil
+}
+
+// Revision returns the revision of the record with the values of the fields
+func (s *GRPCServer) Revision(ctx context.Context, req *proto2.RevisionRequest) (*proto2.RevisionResponse, error) {
+	kind, err := models.ParseKind(req.GetKind())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	revision, err := s.repo.Revision(ctx, currentUserID(ctx), kind, req.GetId(), req.GetRevision())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "")
+		}
+		return nil, status.Error(codes.Internal, "")
+	}
+	return &proto2.RevisionResponse{Revision: revisionProto(*revision)}, nil
+}
+
+// RestoreRevision makes the revision the current version of the record, the deleted record is added again
+func (s *GRPCServer) RestoreRevision(ctx context.Context, req *proto2.RestoreRevisionRequest) (*proto2.RestoreRevisionResponse, error) {
+	kind, err := models.ParseKind(req.GetKind())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	version, err := s.repo.RestoreRevision(ctx, currentUserID(ctx), kind, req.GetId(), req.GetRevision())
+	if err != nil {
+		return nil, updateError(err)
+	}
+	return &proto2.RestoreRevisionResponse{Version: version}, nil
+}
+
+func revisionProto(revision models.Revision) *proto2.Revision {
+	return &proto2.Revision{
+		Id:        revision.ID,
+		Kind:      string(revision.Kind),
+		RecordId:  revision.RecordID,
+		Version:   revision.Version,
+		Action:    revision.Action,
+		CreatedAt: revision.CreatedAt.Unix(),
+		Fields:    revision.Fields,
+	}
+}
+
+// Trash returns the deleted records of the user without the secret fields, the recently deleted first
+func (s *GRPCServer) Trash(ctx context.Context, req *proto2.TrashRequest) (*proto2.TrashResponse, error) {
+	items, err := s.repo.Trash(ctx, currentUserID(ctx))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "")
+	}
+	var response proto2.TrashResponse
+	for _, item := range items {
+		response.Items = append(response.Items, &proto2.TrashItem{
+			Kind:      string(item.Kind),
+			Id:        item.ID,
+			Note:      item.Note,
+			DeletedAt: item.DeletedAt.Unix(),
+		})
+	}
+	return &response, nil
+}
+
+// RestoreTrash moves the deleted record back from the trash
+func (s *GRPCServer) RestoreTrash(ctx context.Context, req *proto2.RestoreTrashRequest) (*proto2.RestoreTrashResponse, error) {
+	kind, err := models.ParseKind(req.GetKind())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	err = s.repo.RestoreTrash(ctx, currentUserID(ctx), kind, req.GetId())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "")
+		}
+		return nil, status.Error(codes.Internal, "")
+	}
+	return &proto2.RestoreTrashResponse{}, nil
+}
+
+// EmptyTrash removes the deleted records of the user for good
+func (s *GRPCServer) EmptyTrash(ctx context.Context, req *proto2.EmptyTrashRequest) (*proto2.EmptyTrashResponse, error) {
+	removed, err := s.repo.EmptyTrash(ctx, currentUserID(ctx))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "")
+	}
+	return &proto2.EmptyTrashResponse{Removed: removed}, nil
+}
+
+// Vaults returns the vaults of the user ordered by name
+func (s *GRPCServer) Vaults(ctx context.Context, req *proto2.VaultsRequest) (*proto2.VaultsResponse, error) {
+	vaults, err := s.repo.Vaults(ctx, currentUserID(ctx))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "")
+	}
+	var response proto2.VaultsResponse
+	for _, vault := range vaults {
+		response.Vaults = append(response.Vaults, &proto2.Vault{Id: vault.ID, Name: vault.Name})
+	}
+	return &response, nil
+}
+
+// AddVault creates the vault with the name unique for the user
+func (s *GRPCServer) AddVault(ctx context.Context, req *proto2.AddVaultRequest) (*proto2.AddVaultResponse, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "empty name")
+	}
+	vaultID, err := s.repo.AddVault(ctx, currentUserID(ctx), req.GetName())
+	if err != nil {
+		return nil, folderError(err)
+	}
+	return &proto2.AddVaultResponse{Id: vaultID}, nil
+}
+
+// RenameVault renames the vault
+func (s *GRPCServer) RenameVault(ctx context.Context, req *proto2.RenameVaultRequest) (*proto2.RenameVaultResponse, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "empty name")
+	}
+	if err := s.repo.RenameVault(ctx, currentUserID(ctx), req.GetId(), req.GetName()); err != nil {
+		return nil, folderError(err)
+	}
+	return &proto2.RenameVaultResponse{}, nil
+}
+
+// DeleteVault removes the vault with its folders, the records are moved to the top level
+func (s *GRPCServer) DeleteVault(ctx context.Context, req *proto2.DeleteVaultRequest) (*proto2.DeleteVaultResponse, error) {
+	if err := s.repo.DeleteVault(ctx, currentUserID(ctx), req.GetId()); err != nil {
+		return nil, folderError(err)
+	}
+	return &proto2.DeleteVaultResponse{}, nil
+}
+
+// Folders returns all folders of the vault ordered by name
+func (s *GRPCServer) Folders(ctx context.Context, req *proto2.FoldersRequest) (*proto2.FoldersResponse, error) {
+	folders, err := s.repo.Folders(ctx, currentUserID(ctx), req.GetVault())
+	if err != nil {
+		return nil, status.Error(codes.Internal, "")
+	}
+	var response proto2.FoldersResponse
+	for _, folder := range folders {
+		response.Folders = append(response.Folders, &proto2.Folder{
+			Id:     folder.ID,
+			Vault:  folder.Vault,
+			Parent: folder.Parent,
+			Name:   folder.Name,
+		})
+	}
+	return &response, nil
+}
+
+// AddFolder creates the folder in the root of the vault or in the parent folder
+func (s *GRPCServer) AddFolder(ctx context.Context, req *proto2.AddFolderRequest) (*proto2.AddFolderResponse, error) {
+	folder := req.GetFolder()
+	if folder.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "empty name")
+	}
+	folderID, err := s.repo.AddFolder(ctx, currentUserID(ctx), models.Folder{
+		Vault:  folder.GetVault(),
+		Parent: folder.GetParent(),
+		Name:   folder.GetName(),
+	})
+	if err != nil {
+		return nil, folderError(err)
+	}
+	return &proto2.AddFolderResponse{Id: folderID}, nil
+}
+
+// UpdateFolder renames the folder and moves it to another parent of the same vault
+func (s *GRPCServer) UpdateFolder(ctx context.Context, req *proto2.UpdateFolderRequest) (*proto2.UpdateFolderResponse, error) {
+	folder := req.GetFolder()
+	if folder.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "empty name")
+	}
+	err := s.repo.UpdateFolder(ctx, currentUserID(ctx), models.Folder{
+		ID:     folder.GetId(),
+		Parent: folder.GetParent(),
+		Name:   folder.GetName(),
+	})
+	if err != nil {
+		return nil, folderError(err)
+	}
+	return &proto2.UpdateFolderResponse{}, nil
+}
+
+// DeleteFolder removes the folder with the nested ones, the records are moved to the root of the vault
+func (s *GRPCServer) DeleteFolder(ctx context.Context, req *proto2.DeleteFolderRequest) (*proto2.DeleteFolderResponse, error) {
+	if err := s.repo.DeleteFolder(ctx, currentUserID(ctx), req.GetId()); err != nil {
+		return nil, folderError(err)
+	}
+	return &proto2.DeleteFolderResponse{}, nil
+}
+
+// MoveRecord moves the record to the vault and the folder, the zero vault is the top level
+func (s *GRPCServer) MoveRecord(ctx context.Context, req *proto2.MoveRecordRequest) (*proto2.MoveRecordResponse, error) {
+	kind, err := models.ParseKind(req.GetKind())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	location := models.Location{Vault: req.GetVault(), Folder: req.GetFolder()}
+	if location.Vault == 0 && location.Folder != 0 {
+		return nil, status.Error(codes.InvalidArgument, storage.ErrInvalidLocation.Error())
+	}
+	if err := s.repo.MoveRecord(ctx, currentUserID(ctx), kind, req.GetId(), location); err != nil {
+		return nil, folderError(err)
+	}
+	return &proto2.MoveRecordResponse{}, nil
+}
+
+// folderError converts the error of the change of the vaults, the folders or the location of the record to the grpc status
+func folderError(err error) error {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return status.Error(codes.NotFound, "")
+	case errors.Is(err, storage.ErrNameTaken):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, storage.ErrInvalidLocation):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, "")
+	}
+}
+
 // DeleteCard delete card by user and ID
 func (s *GRPCServer) DeleteCard(ctx context.Context, req *proto2.DeleteCardRequest) (*proto2.DeleteCardResponse, error) {
 	var response proto2.DeleteCardResponse
 	dataID := req.GetId()
-	userID := req.GetUser()
+	userID := currentUserID(ctx)
 	err := s.repo.DeleteCard(ctx, userID, dataID)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "")
+		}
 		return nil, status.Error(codes.Internal, "")
 	}
 	return &response, nil
@@ -256,9 +1044,12 @@ func (s *GRPCServer) DeleteCard(ctx context.Context, req *proto2.DeleteCardReque
 func (s *GRPCServer) DeleteLogin(ctx context.Context, req *proto2.DeleteLoginRequest) (*proto2.DeleteLoginResponse, error) {
 	var response proto2.DeleteLoginResponse
 	dataID := req.GetId()
-	userID := req.GetUser()
+	userID := currentUserID(ctx)
 	err := s.repo.DeleteLogin(ctx, userID, dataID)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "")
+		}
 		return nil, status.Error(codes.Internal, "")
 	}
 	return &response, nil
@@ -268,9 +1059,12 @@ func (s *GRPCServer) DeleteLogin(ctx context.Context, req *proto2.DeleteLoginReq
 func (s *GRPCServer) DeleteText(ctx context.Context, req *proto2.DeleteTextRequest) (*proto2.DeleteTextResponse, error) {
 	var response proto2.DeleteTextResponse
 	dataID := req.GetId()
-	userID := req.GetUser()
+	userID := currentUserID(ctx)
 	err := s.repo.DeleteText(ctx, userID, dataID)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "")
+		}
 		return nil, status.Error(codes.Internal, "")
 	}
 	return &response, nil
@@ -280,10 +1074,416 @@ func (s *GRPCServer) DeleteText(ctx context.Context, req *proto2.DeleteTextReque
 func (s *GRPCServer) DeleteBinary(ctx context.Context, req *proto2.DeleteBinRequest) (*proto2.DeleteBinResponse, error) {
 	var response proto2.DeleteBinResponse
 	dataID := req.GetId()
-	userID := req.GetUser()
+	userID := currentUserID(ctx)
 	err := s.repo.DeleteBinary(ctx, userID, dataID)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "")
+		}
+		return nil, status.Error(codes.Internal, "")
+	}
+	return &response, nil
+}
+
+// StartUpload creates the upload of the content of the binary, the record has to be added before
+func (s *GRPCServer) StartUpload(ctx context.Context, req *proto2.StartUploadRequest) (*proto2.StartUploadResponse, error) {
+	upload, err := s.repo.StartUpload(ctx, currentUserID(ctx), req.GetBinId())
+	if err != nil {
+		return nil, uploadError(err)
+	}
+	return &proto2.StartUploadResponse{Upload: uploadProto(upload)}, nil
+}
+
+// UploadState returns the state of the upload, the interrupted upload is resumed from its chunks
+func (s *GRPCServer) UploadState(ctx context.Context, req *proto2.UploadStateRequest) (*proto2.UploadStateResponse, error) {
+	upload, err := s.binaryUpload(ctx, req.GetBinId(), req.GetUploadId())
+	if err != nil {
+		return nil, err
+	}
+	return &proto2.UploadStateResponse{Upload: uploadProto(upload)}, nil
+}
+
+// UploadChunks appends the chunks of the stream to the upload of the first message, every message is one chunk.
+// The chunks are saved as they are received, so the chunks received before the stream is broken are kept
+func (s *GRPCServer) UploadChunks(stream proto2.GophKeeperServer_UploadChunksServer) error {
+	ctx := stream.Context()
+	userID := currentUserID(ctx)
+	var upload *models.BinaryUpload
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if upload == nil {
+			if upload, err = s.binaryUpload(ctx, req.GetBinId(), req.GetUploadId()); err != nil {
+				return err
+			}
+		}
+		upload, err = s.repo.AddChunk(ctx, userID, models.BinaryChunk{Upload: upload.ID, Seq: req.GetSeq(), Data: req.GetData()})
+		if err != nil {
+			return uploadError(err)
+		}
+	}
+	if upload == nil {
+		return status.Error(codes.InvalidArgument, "no chunks")
+	}
+	return stream.SendAndClose(&proto2.UploadChunksResponse{Upload: uploadProto(upload)})
+}
+
+// CompleteUpload replaces the content of the binary with the upload if the version is still the one read by the client
+func (s *GRPCServer) CompleteUpload(ctx context.Context, req *proto2.CompleteUploadRequest) (*proto2.CompleteUploadResponse, error) {
+	version, err := s.repo.CompleteUpload(ctx, currentUserID(ctx), req.GetBinId(), req.GetUploadId(), req.GetVersion())
+	if err != nil {
+		return nil, updateError(err)
+	}
+	return &proto2.CompleteUploadResponse{Version: version}, nil
+}
+
+// DownloadBinary streams the content of the binary from the offset in chunks, the first message has the size
+// of the whole content. The download is resumed only if the content was not replaced since it was started
+func (s *GRPCServer) DownloadBinary(req *proto2.DownloadBinaryRequest, stream proto2.GophKeeperServer_DownloadBinaryServer) error {
+	ctx := stream.Context()
+	userID := currentUserID(ctx)
+	binary, err := s.repo.Binary(ctx, userID, req.GetId())
+	if err != nil {
+		return uploadError(err)
+	}
+	if req.GetContentId() != "" && req.GetContentId() != binary.ContentID {
+		return status.Error(codes.FailedPrecondition, "content is replaced")
+	}
+	content := storage.NewContentReader(ctx, s.repo, userID, binary)
+	offset := req.GetOffset()
+	if offset < 0 || offset > content.Size() {
+		return status.Error(codes.OutOfRange, "invalid offset")
+	}
+	if _, err := content.Seek(offset, io.SeekStart); err != nil {
+		return status.Error(codes.Internal, "")
+	}
+
+	buf := make([]byte, models.BinaryChunkSize)
+	for first := true; ; first = false {
+		n, err := io.ReadFull(content, buf)
+		if n > 0 || first {
+			message := &proto2.BinaryContent{Offset: offset, Data: buf[:n]}
+			if first {
+				message.Size, message.ContentId = content.Size(), binary.ContentID
+			}
+			if err := stream.Send(message); err != nil {
+				return err
+			}
+			offset += int64(n)
+		}
+		if errors.Is(err, io.EOF) || (errors.Is(err, io.ErrUnexpectedEOF) && offset == content.Size()) {
+			return nil
+		}
+		if err != nil {
+			return status.Error(codes.Internal, "")
+		}
+	}
+}
+
+// binaryUpload reads the upload, the upload of another binary is not found
+func (s *GRPCServer) binaryUpload(ctx context.Context, binID string, uploadID string) (*models.BinaryUpload, error) {
+	upload, err := s.repo.BinaryUpload(ctx, currentUserID(ctx), uploadID)
+	if err == nil && upload.BinaryID != binID {
+		err = pgx.ErrNoRows
+	}
+	if err != nil {
+		return nil, uploadError(err)
+	}
+	return upload, nil
+}
+
+func uploadProto(upload *models.BinaryUpload) *proto2.BinaryUpload {
+	return &proto2.BinaryUpload{Id: upload.ID, BinId: upload.BinaryID, Offset: upload.Offset, Chunks: upload.Chunks}
+}
+
+// unixTime the optional time as it is sent, 0 if it is unknown
+func unixTime(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.Unix()
+}
+
+// timeOfUnix the optional time of the request, nil for 0
+func timeOfUnix(sec int64) *time.Time {
+	if sec == 0 {
+		return nil
+	}
+	t := time.Unix(sec, 0)
+	return &t
+}
+
+// uploadError converts the error of the binary content requests to the grpc status
+func uploadError(err error) error {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return status.Error(codes.NotFound, "")
+	case errors.Is(err, models.ErrInvalidChunk):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, storage.ErrChunkOrder):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, "")
+	}
+}
+
+// DeleteSSHKey delete ssh key by user and ID
+func (s *GRPCServer) DeleteSSHKey(ctx context.Context, req *proto2.DeleteSSHKeyRequest) (*proto2.DeleteSSHKeyResponse, error) {
+	var response proto2.DeleteSSHKeyResponse
+	err := s.repo.DeleteSSHKey(ctx, currentUserID(ctx), req.GetId())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "")
+		}
+		return nil, status.Error(codes.Internal, "")
+	}
+	return &response, nil
+}
+
+// RecordTypes returns the record types defined by the user ordered by name
+func (s *GRPCServer) RecordTypes(ctx context.Context, req *proto2.RecordTypesRequest) (*proto2.RecordTypesResponse, error) {
+	recordTypes, err := s.repo.RecordTypes(ctx, currentUserID(ctx))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "")
+	}
+	var response proto2.RecordTypesResponse
+	for _, recordType := range recordTypes {
+		response.Types = append(response.Types, recordTypeProto(recordType))
+	}
+	return &response, nil
+}
+
+// RecordType returns the schema of the record type
+func (s *GRPCServer) RecordType(ctx context.Context, req *proto2.RecordTypeRequest) (*proto2.RecordTypeResponse, error) {
+	recordType, err := s.repo.RecordType(ctx, currentUserID(ctx), req.GetName())
+	if err != nil {
+		return nil, recordTypeError(err)
+	}
+	return &proto2.RecordTypeResponse{Type: recordTypeProto(*recordType)}, nil
+}
+
+// AddRecordType creates the record type with the name unique for the user
+func (s *GRPCServer) AddRecordType(ctx context.Context, req *proto2.AddRecordTypeRequest) (*proto2.AddRecordTypeResponse, error) {
+	recordType := recordTypeModel(req.GetType())
+	if err := recordType.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := s.repo.AddRecordType(ctx, currentUserID(ctx), recordType); err != nil {
+		return nil, recordTypeError(err)
+	}
+	return &proto2.AddRecordTypeResponse{}, nil
+}
+
+// UpdateRecordType replaces the fields of the record type, the records keep their values
+func (s *GRPCServer) UpdateRecordType(ctx context.Context, req *proto2.UpdateRecordTypeRequest) (*proto2.UpdateRecordTypeResponse, error) {
+	recordType := recordTypeModel(req.GetType())
+	if err := recordType.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := s.repo.UpdateRecordType(ctx, currentUserID(ctx), recordType); err != nil {
+		return nil, recordTypeError(err)
+	}
+	return &proto2.UpdateRecordTypeResponse{}, nil
+}
+
+// DeleteRecordType removes the record type, the type with records can not be removed
+func (s *GRPCServer) DeleteRecordType(ctx context.Context, req *proto2.DeleteRecordTypeRequest) (*proto2.DeleteRecordTypeResponse, error) {
+	if err := s.repo.DeleteRecordType(ctx, currentUserID(ctx), req.GetName()); err != nil {
+		return nil, recordTypeError(err)
+	}
+	return &proto2.DeleteRecordTypeResponse{}, nil
+}
+
+// ListCustomRecords streams the page of the custom records of the user without the values
+func (s *GRPCServer) ListCustomRecords(req *proto2.ListRequest, stream proto2.GophKeeperServer_ListCustomRecordsServer) error {
+	return s.listRecords(models.KindCustom, req, stream)
+}
+
+// AddCustomRecord register a new custom record, the values are checked by the schema of its type
+func (s *GRPCServer) AddCustomRecord(ctx context.Context, req *proto2.AddCustomRecordRequest) (*proto2.AddCustomRecordResponse, error) {
+	record := customRecordModel(req.GetRecord())
+	record.Vault = req.GetRecord().GetVault()
+	record.Folder = req.GetRecord().GetFolder()
+	err := s.repo.AddCustomRecord(ctx, currentUserID(ctx), record)
+	if errors.Is(err, models.ErrInvalidRecord) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, addError(err)
+	}
+	return &proto2.AddCustomRecordResponse{}, nil
+}
+
+// CustomRecord return specific custom record
+func (s *GRPCServer) CustomRecord(ctx context.Context, req *proto2.CustomRecordRequest) (*proto2.CustomRecordResponse, error) {
+	record, err := s.repo.CustomRecord(ctx, currentUserID(ctx), req.GetId())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "")
+		}
+		return nil, status.Error(codes.Internal, "")
+	}
+	return &proto2.CustomRecordResponse{
+		Record: &proto2.CustomRecord{
+			Id:      record.ID,
+			Type:    record.Type,
+			Values:  record.Values,
+			Secrets: record.Secrets,
+			Note:    record.Note,
+			Tags:    record.Tags,
+			Fields:  record.Fields,
+			Vault:   record.Vault,
+			Folder:  record.Folder,
+			Version: record.Version,
+		},
+	}, nil
+}
+
+// UpdateCustomRecord replaces the custom record if its version is still the one read by the client
+func (s *GRPCServer) UpdateCustomRecord(ctx context.Context, req *proto2.UpdateCustomRecordRequest) (*proto2.UpdateCustomRecordResponse, error) {
+	record := customRecordModel(req.GetRecord())
+	record.Version = req.GetRecord().GetVersion()
+	version, err := s.repo.UpdateCustomRecord(ctx, currentUserID(ctx), record)
+	if errors.Is(err, models.ErrInvalidRecord) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, updateError(err)
+	}
+	return &proto2.UpdateCustomRecordResponse{Version: version}, nil
+}
+
+// DeleteCustomRecord delete custom record by user and ID
+func (s *GRPCServer) DeleteCustomRecord(ctx context.Context, req *proto2.DeleteCustomRecordRequest) (*proto2.DeleteCustomRecordResponse, error) {
+	var response proto2.DeleteCustomRecordResponse
+	err := s.repo.DeleteCustomRecord(ctx, currentUserID(ctx), req.GetId())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "")
+		}
 		return nil, status.Error(codes.Internal, "")
 	}
 	return &response, nil
 }
+
+// customRecordModel the custom record of the request without the version and the location
+func customRecordModel(record *proto2.CustomRecord) models.CustomRecord {
+	return models.CustomRecord{
+		ID:      record.GetId(),
+		Type:    record.GetType(),
+		Values:  record.GetValues(),
+		Secrets: record.GetSecrets(),
+		Note:    record.GetNote(),
+		Tags:    record.GetTags(),
+		Fields:  record.GetFields(),
+	}
+}
+
+// recordTypeModel the record type of the request
+func recordTypeModel(recordType *proto2.RecordType) models.RecordType {
+	result := models.RecordType{Name: recordType.GetName()}
+	for _, field := range recordType.GetFields() {
+		result.Fields = append(result.Fields, models.TypeField{
+			Name:     field.GetName(),
+			Type:     models.FieldType(field.GetType()),
+			Secret:   field.GetSecret(),
+			Required: field.GetRequired(),
+		})
+	}
+	return result
+}
+
+// recordTypeProto the record type of the response
+func recordTypeProto(recordType models.RecordType) *proto2.RecordType {
+	result := &proto2.RecordType{Name: recordType.Name}
+	for _, field := range recordType.Fields {
+		result.Fields = append(result.Fields, &proto2.TypeField{
+			Name:     field.Name,
+			Type:     string(field.Type),
+			Secret:   field.Secret,
+			Required: field.Required,
+		})
+	}
+	return result
+}
+
+// recordTypeError converts the error of the change of the record type to the grpc status
+func recordTypeError(err error) error {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return status.Error(codes.NotFound, "")
+	case errors.Is(err, models.ErrInvalidRecordType):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, storage.ErrNameTaken):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, storage.ErrRecordTypeInUse):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, "")
+	}
+}
+
+// Vault return the parameters of the client side encryption
+func (s *GRPCServer) Vault(ctx context.Context, req *proto2.VaultRequest) (*proto2.VaultResponse, error) {
+	userID := currentUserID(ctx)
+	params, err := s.repo.VaultParams(ctx, userID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "")
+	}
+	return &proto2.VaultResponse{
+		Salt:  params.Salt,
+		Check: params.Check,
+	}, nil
+}
+
+// SetVaultCheck save the master password check value
+func (s *GRPCServer) SetVaultCheck(ctx context.Context, req *proto2.SetVaultCheckRequest) (*proto2.SetVaultCheckResponse, error) {
+	var response proto2.SetVaultCheckResponse
+	userID := currentUserID(ctx)
+	if req.GetCheck() == "" {
+		return nil, status.Error(codes.InvalidArgument, "")
+	}
+	err := s.repo.SetVaultCheck(ctx, userID, req.GetCheck())
+	if err != nil {
+		if errors.Is(err, storage.ErrVaultInitialized) {
+			return nil, status.Error(codes.AlreadyExists, "")
+		}
+		return nil, status.Error(codes.Internal, "")
+	}
+	return &response, nil
+}
+
+// currentUserID returns the id of the user authorized by the auth interceptor
+func currentUserID(ctx context.Context) int64 {
+	return ctx.Value(auth.UserContextKey{}).(*models.User).UserID
+}
+
+// userAgent returns the user agent of the grpc client, it is shown in the list of sessions
+func userAgent(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get("user-agent"); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// remoteIP returns the ip address of the grpc client
+func remoteIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
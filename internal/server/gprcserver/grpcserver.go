@@ -2,6 +2,7 @@
 package gprcserver
 
 import (
+	"context"
 	"net"
 	"os"
 	"os/signal"
@@ -9,11 +10,18 @@ import (
 
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	"github.com/ncyellow/GophKeeper/internal/proto"
+	"github.com/ncyellow/GophKeeper/internal/server/audit"
+	"github.com/ncyellow/GophKeeper/internal/server/auth"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/certbind"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/jwt"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/signing"
 	"github.com/ncyellow/GophKeeper/internal/server/config"
 	"github.com/ncyellow/GophKeeper/internal/server/gprcserver/api"
 	"github.com/ncyellow/GophKeeper/internal/server/storage"
+	"github.com/ncyellow/GophKeeper/internal/tlsconfig"
 )
 
 // GRPCServer server structure. Implements the Server interface
@@ -25,7 +33,34 @@ type GRPCServer struct {
 // After startup, it waits for os.Interrupt, syscall.SIGINT, syscall.SIGTERM
 // The function is very similar to RunServer from the http implementation, but here is a different variant of graceful shutdown.
 func (s *GRPCServer) Run() error {
-	store, err := storage.NewPgStorage(s.Conf)
+	store, err := storage.CreateStorage(s.Conf)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	crl, err := tlsconfig.OpenCRL(ctx, s.Conf.CRLFile, s.Conf.CACertFile, s.Conf.CRLReloadInterval)
+	if err != nil {
+		return err
+	}
+	bindings, err := certbind.Load(s.Conf.CertBindingsFile)
+	if err != nil {
+		return err
+	}
+	keys, err := signing.Open(signing.NewOptions(s.Conf))
+	if err != nil {
+		return err
+	}
+	go keys.Watch(ctx)
+	go storage.PurgeRevisions(ctx, store, s.Conf.RevisionRetention)
+	go storage.PurgeTrash(ctx, store, s.Conf.TrashRetention)
+	go storage.PurgeUploads(ctx, store)
+	go storage.PurgeBlobs(ctx, store)
+
+	// Secrets are never sent in cleartext, TLS is required as for https
+	tlsConf, err := tlsconfig.Server(s.Conf.CryptoCrt, s.Conf.CryptoKey, s.Conf.CACertFile, crl)
 	if err != nil {
 		return err
 	}
@@ -35,9 +70,14 @@ func (s *GRPCServer) Run() error {
 		return err
 	}
 
-	grpcServer := grpc.NewServer()
+	parser := jwt.NewParser(keys)
+	grpcServer := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(tlsConf)),
+		grpc.ChainUnaryInterceptor(audit.UnaryInterceptor, auth.UnaryInterceptor(store, parser, bindings, api.PublicMethods...)),
+		grpc.ChainStreamInterceptor(audit.StreamInterceptor, auth.StreamInterceptor(store, parser, bindings, api.PublicMethods...)),
+	)
 	// register service
-	proto.RegisterGophKeeperServerServer(grpcServer, api.NewServer(store, s.Conf))
+	proto.RegisterGophKeeperServerServer(grpcServer, api.NewServer(store, s.Conf, keys, bindings))
 
 	defer func() {
 		// shutting down the server via GracefulStop
@@ -0,0 +1,268 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v4"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/auth"
+	"github.com/ncyellow/GophKeeper/internal/server/storage"
+)
+
+// chunkPart the name of the multipart part with the chunk of the content
+const chunkPart = "chunk"
+
+// BinaryContent returns the content of the binary, the uploaded one or the data of the record.
+// The Range requests are supported, so an interrupted download is resumed
+// @Tags Read
+// @Summary Downloading the binary content
+// @Description The content is streamed chunk by chunk. The ETag changes when the content is replaced,
+// @Description use it in If-Range to resume the download.
+// @ID readBinaryContent
+// @Produce octet-stream
+// @Param id path string true "Binary ID"
+// @Param Range header string false "bytes=<from>-[<to>]"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Failure 404 {string} string ""
+// @Failure 416 {string} string ""
+// @Failure 500 {string} string ""
+// @Router /api/bin/{id}/content [get]
+func (h *Handler) BinaryContent() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		binary, err := h.store.Binary(r.Context(), user.UserID, chi.URLParam(r, "id"))
+		if err != nil {
+			writeUploadError(rw, err)
+			return
+		}
+
+		etag := binary.ContentID
+		if etag == "" {
+			etag = fmt.Sprintf("v%d", binary.Version)
+		}
+		rw.Header().Set("ETag", strconv.Quote(etag))
+		rw.Header().Set("Content-Type", "application/octet-stream")
+		http.ServeContent(rw, r, "", time.Time{}, storage.NewContentReader(r.Context(), h.store, user.UserID, binary))
+	}
+}
+
+// StartUpload creates the upload of the content of the binary, the record has to be added before
+// @Tags Add
+// @Summary Starting the upload of the binary content
+// @Description The content is sent in chunks of 1 MiB by PUT /api/bin/{id}/uploads/{upload},
+// @Description then the upload is completed and replaces the content of the record.
+// @ID startUpload
+// @Produce json
+// @Param id path string true "Binary ID"
+// @Success 200 {object} models.BinaryUpload
+// @Failure 404 {string} string ""
+// @Failure 500 {string} string ""
+// @Router /api/bin/{id}/uploads [post]
+func (h *Handler) StartUpload() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		upload, err := h.store.StartUpload(r.Context(), user.UserID, chi.URLParam(r, "id"))
+		if err != nil {
+			writeUploadError(rw, err)
+			return
+		}
+		writeJSON(rw, upload)
+	}
+}
+
+// BinaryUpload returns the state of the upload, the interrupted upload is resumed from its chunks
+// @Tags Read
+// @Summary Returns the state of the upload
+// @ID readUpload
+// @Produce json
+// @Param id path string true "Binary ID"
+// @Param upload path string true "Upload ID"
+// @Success 200 {object} models.BinaryUpload
+// @Failure 404 {string} string ""
+// @Failure 500 {string} string ""
+// @Router /api/bin/{id}/uploads/{upload} [get]
+func (h *Handler) BinaryUpload() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		upload, ok := h.binaryUpload(rw, r, user.UserID)
+		if !ok {
+			return
+		}
+		writeJSON(rw, upload)
+	}
+}
+
+// UploadChunks appends the chunks of the multipart body to the upload. Every part is one chunk,
+// all but the last chunk of the content are exactly 1 MiB. The chunks are saved as they are read,
+// so the chunks received before the connection is broken are kept
+// @Tags Add
+// @Summary Uploading the chunks of the binary content
+// @Description seq is the number of the first chunk of the body, it must be the chunks of the upload.
+// @Description The parts are named chunk. On the conflict the current state of the upload is returned.
+// @ID uploadChunks
+// @Accept mpfd
+// @Produce json
+// @Param id path string true "Binary ID"
+// @Param upload path string true "Upload ID"
+// @Param seq query int true "Number of the first chunk"
+// @Success 200 {object} models.BinaryUpload
+// @Failure 400 {string} string "invalid chunk: ..."
+// @Failure 404 {string} string ""
+// @Failure 409 {object} models.BinaryUpload
+// @Failure 500 {string} string ""
+// @Router /api/bin/{id}/uploads/{upload} [put]
+func (h *Handler) UploadChunks() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		upload, ok := h.binaryUpload(rw, r, user.UserID)
+		if !ok {
+			return
+		}
+		seq, err := strconv.ParseInt(r.URL.Query().Get("seq"), 10, 64)
+		if err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte("invalid seq"))
+			return
+		}
+		if seq != upload.Chunks {
+			h.writeChunkConflict(rw, r, user.UserID, upload.ID)
+			return
+		}
+		parts, err := r.MultipartReader()
+		if err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte("multipart body required"))
+			return
+		}
+
+		// One byte more than the chunk, so the longer part is rejected and not cut
+		buf := make([]byte, models.BinaryChunkSize+1)
+		for {
+			part, err := parts.NextPart()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				rw.WriteHeader(http.StatusBadRequest)
+				rw.Write([]byte("invalid multipart body"))
+				return
+			}
+			if part.FormName() != chunkPart {
+				rw.WriteHeader(http.StatusBadRequest)
+				rw.Write([]byte("unexpected part " + part.FormName()))
+				return
+			}
+			n, err := io.ReadFull(part, buf)
+			if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+				rw.WriteHeader(http.StatusBadRequest)
+				rw.Write([]byte("read data problem"))
+				return
+			}
+
+			chunk := models.BinaryChunk{Upload: upload.ID, Seq: upload.Chunks, Data: buf[:n]}
+			upload, err = h.store.AddChunk(r.Context(), user.UserID, chunk)
+			if errors.Is(err, storage.ErrChunkOrder) {
+				h.writeChunkConflict(rw, r, user.UserID, chunk.Upload)
+				return
+			}
+			if err != nil {
+				writeUploadError(rw, err)
+				return
+			}
+		}
+		writeJSON(rw, upload)
+	}
+}
+
+// CompleteUpload replaces the content of the binary with the upload if the version in the body is still the current one
+// @Tags Add
+// @Summary Completing the upload of the binary content
+// @Description The version of the body is required, it is the version of the record read by the client.
+// @Description The previous content is removed, the revisions of the record do not keep it.
+// @ID completeUpload
+// @Accept json
+// @Produce json
+// @Param id path string true "Binary ID"
+// @Param upload path string true "Upload ID"
+// @Param version body models.RecordVersion true "Version of the record"
+// @Success 200 {object} models.RecordVersion
+// @Failure 400 {string} string "invalid deserialization"
+// @Failure 404 {string} string ""
+// @Failure 409 {string} string "version conflict"
+// @Failure 500 {string} string ""
+// @Router /api/bin/{id}/uploads/{upload}/complete [post]
+func (h *Handler) CompleteUpload() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		binID := chi.URLParam(r, "id")
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		var version models.RecordVersion
+		if !readJSON(rw, r, &version) {
+			return
+		}
+		if version.Version <= 0 {
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte("version required"))
+			return
+		}
+
+		newVersion, err := h.store.CompleteUpload(r.Context(), user.UserID, binID, chi.URLParam(r, "upload"), version.Version)
+		writeUpdateResult(rw, models.RecordVersion{ID: binID, Version: newVersion}, err)
+	}
+}
+
+// binaryUpload reads the upload of the path, the upload of another binary is not found
+func (h *Handler) binaryUpload(rw http.ResponseWriter, r *http.Request, userID int64) (*models.BinaryUpload, bool) {
+	upload, err := h.store.BinaryUpload(r.Context(), userID, chi.URLParam(r, "upload"))
+	if err == nil && upload.BinaryID != chi.URLParam(r, "id") {
+		err = pgx.ErrNoRows
+	}
+	if err != nil {
+		writeUploadError(rw, err)
+		return nil, false
+	}
+	return upload, true
+}
+
+// writeChunkConflict writes the current state of the upload, the client resumes from it
+func (h *Handler) writeChunkConflict(rw http.ResponseWriter, r *http.Request, userID int64, uploadID string) {
+	upload, err := h.store.BinaryUpload(r.Context(), userID, uploadID)
+	if err != nil {
+		writeUploadError(rw, err)
+		return
+	}
+	data, err := json.Marshal(upload)
+	if err != nil {
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusConflict)
+	rw.Write(data)
+}
+
+// writeUploadError writes the error of the binary content requests
+func writeUploadError(rw http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		rw.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, models.ErrInvalidChunk):
+		rw.WriteHeader(http.StatusBadRequest)
+		rw.Write([]byte(err.Error()))
+	default:
+		rw.WriteHeader(http.StatusInternalServerError)
+	}
+}
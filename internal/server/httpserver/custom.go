@@ -0,0 +1,348 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v4"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/audit"
+	"github.com/ncyellow/GophKeeper/internal/server/auth"
+	"github.com/ncyellow/GophKeeper/internal/server/storage"
+)
+
+// RecordTypes returns the record types defined by the user
+// @Tags Read
+// @Summary Returns the list of the user record types
+// @Description output JSON list of the record types ordered by name
+// @ID listRecordTypes
+// @Produce json
+// @Success 200 {array} models.RecordType
+// @Failure 500 {string} string ""
+// @Router /api/types [get]
+func (h *Handler) RecordTypes() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		recordTypes, err := h.store.RecordTypes(r.Context(), user.UserID)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		writeJSON(rw, recordTypes)
+	}
+}
+
+// RecordType returns the schema of the record type
+// @Tags Read
+// @Summary Returns the user record type
+// @ID readRecordType
+// @Produce json
+// @Param name path string true "Record type name"
+// @Success 200 {object} models.RecordType
+// @Failure 404 {string} string ""
+// @Failure 500 {string} string ""
+// @Router /api/types/{name} [get]
+func (h *Handler) RecordType() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		recordType, err := h.store.RecordType(r.Context(), user.UserID, chi.URLParam(r, "name"))
+		if err != nil {
+			writeRecordTypeError(rw, err)
+			return
+		}
+		writeJSON(rw, recordType)
+	}
+}
+
+// AddRecordType creates the record type
+// @Tags Add
+// @Summary Creating a new record type
+// @Description The name of the type is unique for the user, the fields are named and typed:
+// @Description text, number, date, url or email. The values of the secret fields are encrypted.
+// @ID addRecordType
+// @Accept json
+// @Produce plain
+// @Param record_type body models.RecordType true "Record type with the fields"
+// @Success 200 {string} string "ok"
+// @Failure 400 {string} string "invalid deserialization"
+// @Failure 400 {string} string "invalid record type: ..."
+// @Failure 409 {string} string "name is already taken"
+// @Failure 500 {string} string ""
+// @Router /api/types [post]
+func (h *Handler) AddRecordType() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		var recordType models.RecordType
+		if !readRecordType(rw, r, &recordType) {
+			return
+		}
+
+		if err := h.store.AddRecordType(r.Context(), user.UserID, recordType); err != nil {
+			writeRecordTypeError(rw, err)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("ok"))
+	}
+}
+
+// UpdateRecordType replaces the fields of the record type, the records keep their values
+// @Tags Add
+// @Summary Updating the record type
+// @ID updateRecordType
+// @Accept json
+// @Produce plain
+// @Param name path string true "Record type name"
+// @Param record_type body models.RecordType true "Record type with the new fields"
+// @Success 200 {string} string "ok"
+// @Failure 400 {string} string "invalid deserialization"
+// @Failure 400 {string} string "invalid record type: ..."
+// @Failure 404 {string} string ""
+// @Failure 409 {string} string "record type is used by records"
+// @Failure 500 {string} string ""
+// @Router /api/types/{name} [put]
+func (h *Handler) UpdateRecordType() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		var recordType models.RecordType
+		if !readRecordType(rw, r, &recordType) {
+			return
+		}
+
+		if err := h.store.UpdateRecordType(r.Context(), user.UserID, recordType); err != nil {
+			writeRecordTypeError(rw, err)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("ok"))
+	}
+}
+
+// DeleteRecordType removes the record type
+// @Tags Delete
+// @Summary Deleting the record type
+// @Description The type can not be deleted while it has records, including the ones in the trash
+// @ID delRecordType
+// @Produce plain
+// @Param name path string true "Record type name"
+// @Success 200 {string} string "ok"
+// @Failure 404 {string} string ""
+// @Failure 409 {string} string "record type is used by records"
+// @Failure 500 {string} string ""
+// @Router /api/types/{name} [delete]
+func (h *Handler) DeleteRecordType() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		if err := h.store.DeleteRecordType(r.Context(), user.UserID, chi.URLParam(r, "name")); err != nil {
+			writeRecordTypeError(rw, err)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("ok"))
+	}
+}
+
+// CustomRecords returns the page of the custom records of all types
+// @Tags Read
+// @Summary Returns the custom records
+// @Description output JSON page of the records, the values are read one by one
+// @ID listCustomRecords
+// @Produce json
+// @Param after query string false "cursor of the page"
+// @Param limit query int false "size of the page, 50 by default, 200 at most"
+// @Param sort query string false "created (default) or id"
+// @Param order query string false "asc (default) or desc"
+// @Param tag query string false "only the records with the tag"
+// @Param field query string false "only the records with the custom field"
+// @Param value query string false "only the records with the value of the field"
+// @Param vault query int false "only the records of the vault"
+// @Param folder query int false "only the records of the folder of the vault, the root of the vault by default"
+// @Success 200 {object} models.RecordPage
+// @Failure 400 {string} string "invalid query"
+// @Failure 500 {string} string ""
+// @Router /api/custom [get]
+func (h *Handler) CustomRecords() http.HandlerFunc {
+	return h.records(models.KindCustom)
+}
+
+// CustomRecord returns the custom record
+// @Tags Read
+// @Summary Returns user custom record
+// @Description input rest URL, output JSON value with the public values and the secrets by the fields of the type
+// @ID readCustomRecord
+// @Produce json
+// @Param id path string true "Custom record ID"
+// @Success 200 {object} models.CustomRecord
+// @Failure 404 {string} string ""
+// @Failure 500 {string} string ""
+// @Router /api/custom/{id} [get]
+func (h *Handler) CustomRecord() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		record, err := h.store.CustomRecord(r.Context(), user.UserID, chi.URLParam(r, "id"))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				rw.WriteHeader(http.StatusNotFound)
+				return
+			}
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		writeJSON(rw, record)
+	}
+}
+
+// AddCustomRecord register a new custom record
+// @Tags Add
+// @Summary Registering a new custom record
+// @Description Registration is performed using a unique pair of User ID + record ID.
+// @Description The values are checked by the schema of the type, the secret ones are passed in secrets.
+// @ID addCustomRecord
+// @Accept json
+// @Produce plain
+// @Param record body models.CustomRecord true "Custom record object"
+// @Success 200 {string} string "ok"
+// @Failure 400 {string} string "invalid deserialization"
+// @Failure 400 {string} string "invalid record: ..."
+// @Failure 409 {string} string ""
+// @Failure 500 {string} string "read data problem"
+// @Router /api/custom [post]
+func (h *Handler) AddCustomRecord() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		var record models.CustomRecord
+		if !readJSON(rw, r, &record) {
+			return
+		}
+
+		err := h.store.AddCustomRecord(r.Context(), user.UserID, record)
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidRecord) || errors.Is(err, storage.ErrInvalidLocation) {
+				rw.WriteHeader(http.StatusBadRequest)
+				rw.Write([]byte(err.Error()))
+				return
+			}
+			rw.WriteHeader(http.StatusConflict)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("ok"))
+	}
+}
+
+// UpdateCustomRecord replaces the custom record if the version in the body is still the current one.
+// PUT replaces the whole record, PATCH only the fields present in the body
+// @Tags Add
+// @Summary Updating the custom record
+// @Description The version of the body is required, it is the version of the record read by the client.
+// @ID updateCustomRecord
+// @Accept json
+// @Produce json
+// @Param id path string true "Custom record ID"
+// @Param record body models.CustomRecord true "Custom record object with the version"
+// @Success 200 {object} models.RecordVersion
+// @Failure 400 {string} string "invalid deserialization"
+// @Failure 400 {string} string "invalid record: ..."
+// @Failure 404 {string} string ""
+// @Failure 409 {string} string "version conflict"
+// @Failure 500 {string} string ""
+// @Router /api/custom/{id} [put]
+// @Router /api/custom/{id} [patch]
+func (h *Handler) UpdateCustomRecord() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		recordID := chi.URLParam(r, "id")
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		var record models.CustomRecord
+		if r.Method == http.MethodPatch {
+			current, err := h.store.CustomRecord(audit.Internal(r.Context()), user.UserID, recordID)
+			if err != nil {
+				writeUpdateResult(rw, models.RecordVersion{}, err)
+				return
+			}
+			record = *current
+		}
+		if !readUpdate(rw, r, &record) {
+			return
+		}
+		record.ID = recordID
+
+		version, err := h.store.UpdateCustomRecord(r.Context(), user.UserID, record)
+		if errors.Is(err, models.ErrInvalidRecord) {
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte(err.Error()))
+			return
+		}
+		writeUpdateResult(rw, models.RecordVersion{ID: recordID, Version: version}, err)
+	}
+}
+
+// DeleteCustomRecord delete custom record by user and ID
+// @Tags Delete
+// @Summary Deleting custom record
+// @Description The record is moved to the trash. Deletion is performed using a unique pair of User ID + record ID.
+// @ID delCustomRecord
+// @Produce plain
+// @Param id path string true "Custom record ID"
+// @Success 200 {string} string "ok"
+// @Failure 404 {string} string ""
+// @Failure 500 {string} string ""
+// @Router /api/custom/{id} [delete]
+func (h *Handler) DeleteCustomRecord() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		err := h.store.DeleteCustomRecord(r.Context(), user.UserID, chi.URLParam(r, "id"))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				rw.WriteHeader(http.StatusNotFound)
+				return
+			}
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("ok"))
+	}
+}
+
+// readRecordType reads the body of the record type and checks its schema
+func readRecordType(rw http.ResponseWriter, r *http.Request, recordType *models.RecordType) bool {
+	if !readJSON(rw, r, recordType) {
+		return false
+	}
+	if name := chi.URLParam(r, "name"); name != "" {
+		recordType.Name = name
+	}
+	if err := recordType.Validate(); err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		rw.Write([]byte(err.Error()))
+		return false
+	}
+	return true
+}
+
+// writeRecordTypeError writes the error of the change of the record type
+func writeRecordTypeError(rw http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		rw.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, models.ErrInvalidRecordType):
+		rw.WriteHeader(http.StatusBadRequest)
+		rw.Write([]byte(err.Error()))
+	case errors.Is(err, storage.ErrNameTaken), errors.Is(err, storage.ErrRecordTypeInUse):
+		rw.WriteHeader(http.StatusConflict)
+		rw.Write([]byte(err.Error()))
+	default:
+		rw.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -2,21 +2,30 @@
 package httpserver
 
 import (
-	"crypto/sha1"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/jackc/pgx/v4"
 
 	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/audit"
 	"github.com/ncyellow/GophKeeper/internal/server/auth"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/certbind"
 	"github.com/ncyellow/GophKeeper/internal/server/auth/jwt"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/password"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/signing"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/throttle"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/twofactor"
 	"github.com/ncyellow/GophKeeper/internal/server/config"
+	"github.com/ncyellow/GophKeeper/internal/server/listing"
+	"github.com/ncyellow/GophKeeper/internal/server/paging"
 	"github.com/ncyellow/GophKeeper/internal/server/storage"
 )
 
@@ -35,58 +44,162 @@ import (
 // @Tag.name Delete
 // @Tag.description "Group of requests for deleting data"
 
+// @Tag.name Auth
+// @Tag.description "Group of requests for working with the sessions"
+
 // Handler structure implements chi.Mux for routing functionality
 type Handler struct {
 	*chi.Mux
 	store      storage.Storage
 	authorizer *jwt.Authorizer
+	bindings   *certbind.Bindings
 }
 
-// NewRouter constructor of our routing object
-func NewRouter(conf *config.Config, store storage.Storage, parser jwt.Parser) chi.Router {
+// NewRouter constructor of our routing object. bindings restrict the accounts to the client certificates, may be nil
+func NewRouter(conf *config.Config, store storage.Storage, keys *signing.Keystore, parser jwt.Parser, bindings *certbind.Bindings) chi.Router {
 	r := chi.NewRouter()
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Logger)
+	r.Use(audit.Middleware)
 
-	authorizer := &jwt.Authorizer{
-		Store:      store,
-		SigningKey: []byte(conf.SigningKey),
-	}
+	authorizer := jwt.NewAuthorizer(store, conf, keys)
 
 	handler := Handler{
 		Mux:        r,
 		store:      store,
 		authorizer: authorizer,
+		bindings:   bindings,
 	}
 
 	r.Group(func(r chi.Router) {
 		r.Post("/api/register", handler.Register())
 		r.Post("/api/signin", handler.SignIn())
+		r.Post("/api/token/refresh", handler.Refresh())
 	})
 
 	r.Group(func(r chi.Router) {
-		r.Use(auth.Auth(store, conf, parser))
+		r.Use(auth.Auth(store, parser, bindings))
 		// Here will be the handlers ^_^
 
+		// API for the sessions of the user
+		r.Post("/api/logout", handler.Logout())
+		r.Get("/api/sessions", handler.Sessions())
+		r.Delete("/api/sessions/{id}", handler.RevokeSession())
+
+		// API for the second factor of the sign-in
+		r.Post("/api/2fa/setup", handler.SetupTOTP())
+		r.Post("/api/2fa/confirm", handler.ConfirmTOTP())
+		r.Post("/api/2fa/disable", handler.DisableTOTP())
+		r.Get("/api/lockouts", handler.Lockouts())
+		r.Get("/api/audit", handler.AuditEvents())
+
+		// API for the account management
+		r.Put("/api/account/password", handler.ChangePassword())
+		r.Put("/api/account/login", handler.ChangeLogin())
+		r.Delete("/api/account", handler.DeleteAccount())
+
+		// API for the client side encryption parameters
+		r.Get("/api/vault", handler.Vault())
+		r.Put("/api/vault", handler.SetVaultCheck())
+
+		// API for the deleted records
+		r.Get("/api/trash", handler.Trash())
+		r.Post("/api/trash/{kind}/{id}/restore", handler.RestoreTrash())
+		r.Delete("/api/trash", handler.EmptyTrash())
+
+		// API for the named vaults and the folders of the records
+		r.Get("/api/vaults", handler.Vaults())
+		r.Post("/api/vaults", handler.AddVault())
+		r.Put("/api/vaults/{vault}", handler.RenameVault())
+		r.Delete("/api/vaults/{vault}", handler.DeleteVault())
+		r.Get("/api/vaults/{vault}/folders", handler.Folders())
+		r.Post("/api/vaults/{vault}/folders", handler.AddFolder())
+		r.Put("/api/folders/{folder}", handler.UpdateFolder())
+		r.Delete("/api/folders/{folder}", handler.DeleteFolder())
+
 		// API for working with bank cards
+		r.Get("/api/card", handler.Cards())
 		r.Get("/api/card/{id}", handler.Card())
 		r.Post("/api/card", handler.AddCard())
+		r.Put("/api/card/{id}", handler.UpdateCard())
+		r.Patch("/api/card/{id}", handler.UpdateCard())
 		r.Delete("/api/card/{id}", handler.DeleteCard())
+		r.Get("/api/card/{id}/revisions", handler.Revisions(models.KindCard))
+		r.Get("/api/card/{id}/revisions/{rev}", handler.Revision(models.KindCard))
+		r.Post("/api/card/{id}/revisions/{rev}/restore", handler.RestoreRevision(models.KindCard))
+		r.Put("/api/card/{id}/location", handler.MoveRecord(models.KindCard))
 
 		// API for working with logins
+		r.Get("/api/login", handler.Logins())
 		r.Get("/api/login/{id}", handler.Login())
 		r.Post("/api/login", handler.AddLogin())
+		r.Put("/api/login/{id}", handler.UpdateLogin())
+		r.Patch("/api/login/{id}", handler.UpdateLogin())
 		r.Delete("/api/login/{id}", handler.DeleteLogin())
+		r.Get("/api/login/{id}/revisions", handler.Revisions(models.KindLogin))
+		r.Get("/api/login/{id}/revisions/{rev}", handler.Revision(models.KindLogin))
+		r.Post("/api/login/{id}/revisions/{rev}/restore", handler.RestoreRevision(models.KindLogin))
+		r.Put("/api/login/{id}/location", handler.MoveRecord(models.KindLogin))
 
 		// API for working with text data
+		r.Get("/api/txt", handler.Texts())
 		r.Get("/api/txt/{id}", handler.Text())
 		r.Post("/api/txt", handler.AddText())
+		r.Put("/api/txt/{id}", handler.UpdateText())
+		r.Patch("/api/txt/{id}", handler.UpdateText())
 		r.Delete("/api/txt/{id}", handler.DeleteText())
+		r.Get("/api/txt/{id}/revisions", handler.Revisions(models.KindText))
+		r.Get("/api/txt/{id}/revisions/{rev}", handler.Revision(models.KindText))
+		r.Post("/api/txt/{id}/revisions/{rev}/restore", handler.RestoreRevision(models.KindText))
+		r.Put("/api/txt/{id}/location", handler.MoveRecord(models.KindText))
 
 		// API for working with binary data
+		r.Get("/api/bin", handler.Binaries())
 		r.Get("/api/bin/{id}", handler.Binary())
 		r.Post("/api/bin", handler.AddBinary())
+		r.Put("/api/bin/{id}", handler.UpdateBinary())
+		r.Patch("/api/bin/{id}", handler.UpdateBinary())
 		r.Delete("/api/bin/{id}", handler.DeleteBinary())
+		r.Get("/api/bin/{id}/revisions", handler.Revisions(models.KindBinary))
+		r.Get("/api/bin/{id}/revisions/{rev}", handler.Revision(models.KindBinary))
+		r.Post("/api/bin/{id}/revisions/{rev}/restore", handler.RestoreRevision(models.KindBinary))
+		r.Put("/api/bin/{id}/location", handler.MoveRecord(models.KindBinary))
+		r.Get("/api/bin/{id}/content", handler.BinaryContent())
+		r.Post("/api/bin/{id}/uploads", handler.StartUpload())
+		r.Get("/api/bin/{id}/uploads/{upload}", handler.BinaryUpload())
+		r.Put("/api/bin/{id}/uploads/{upload}", handler.UploadChunks())
+		r.Post("/api/bin/{id}/uploads/{upload}/complete", handler.CompleteUpload())
+
+		// API for working with ssh keys
+		r.Get("/api/ssh", handler.SSHKeys())
+		r.Get("/api/ssh/{id}", handler.SSHKey())
+		r.Post("/api/ssh", handler.AddSSHKey())
+		r.Put("/api/ssh/{id}", handler.UpdateSSHKey())
+		r.Patch("/api/ssh/{id}", handler.UpdateSSHKey())
+		r.Delete("/api/ssh/{id}", handler.DeleteSSHKey())
+		r.Get("/api/ssh/{id}/revisions", handler.Revisions(models.KindSSH))
+		r.Get("/api/ssh/{id}/revisions/{rev}", handler.Revision(models.KindSSH))
+		r.Post("/api/ssh/{id}/revisions/{rev}/restore", handler.RestoreRevision(models.KindSSH))
+		r.Put("/api/ssh/{id}/location", handler.MoveRecord(models.KindSSH))
+
+		// API for the record types defined by the user
+		r.Get("/api/types", handler.RecordTypes())
+		r.Get("/api/types/{name}", handler.RecordType())
+		r.Post("/api/types", handler.AddRecordType())
+		r.Put("/api/types/{name}", handler.UpdateRecordType())
+		r.Delete("/api/types/{name}", handler.DeleteRecordType())
+
+		// API for working with the records of the user types
+		r.Get("/api/custom", handler.CustomRecords())
+		r.Get("/api/custom/{id}", handler.CustomRecord())
+		r.Post("/api/custom", handler.AddCustomRecord())
+		r.Put("/api/custom/{id}", handler.UpdateCustomRecord())
+		r.Patch("/api/custom/{id}", handler.UpdateCustomRecord())
+		r.Delete("/api/custom/{id}", handler.DeleteCustomRecord())
+		r.Get("/api/custom/{id}/revisions", handler.Revisions(models.KindCustom))
+		r.Get("/api/custom/{id}/revisions/{rev}", handler.Revision(models.KindCustom))
+		r.Post("/api/custom/{id}/revisions/{rev}/restore", handler.RestoreRevision(models.KindCustom))
+		r.Put("/api/custom/{id}/location", handler.MoveRecord(models.KindCustom))
 	})
 	return handler
 }
@@ -117,32 +230,35 @@ func (h *Handler) Register() http.HandlerFunc {
 			return
 		}
 
-		originalPassword := user.Password
+		if err := h.bindings.Check(user.Login, r.TLS); err != nil {
+			rw.WriteHeader(http.StatusForbidden)
+			rw.Write([]byte("certificate is not bound to the account"))
+			return
+		}
 
-		pwd := sha1.New()
-		pwd.Write([]byte(user.Password))
-		hashPwd := fmt.Sprintf("%x", pwd.Sum(nil))
+		hashPwd, err := password.Hash(user.Password)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
 		user.Password = hashPwd
 
 		// Attempting registration
-		_, err = h.store.Register(r.Context(), user)
+		user.UserID, err = h.store.Register(r.Context(), user)
 		if err != nil {
 			rw.WriteHeader(http.StatusConflict)
 			rw.Write([]byte("already have"))
 			return
 		}
 
-		user.Password = originalPassword
-		// Generating token if registration is successful
-		jwtToken, err := h.authorizer.SignIn(r.Context(), &user)
+		// Creating the session if registration is successful
+		tokens, err := h.authorizer.CreateSession(r.Context(), &user, r.UserAgent())
 		if err != nil {
 			rw.WriteHeader(http.StatusInternalServerError)
 			rw.Write([]byte("invalid login"))
 			return
 		}
-		rw.Header().Set("Authorization", jwtToken)
-		rw.Header().Set("Content-Type", "text/html")
-		rw.WriteHeader(http.StatusOK)
+		writeTokens(rw, tokens)
 	}
 }
 
@@ -173,20 +289,991 @@ func (h *Handler) SignIn() http.HandlerFunc {
 			return
 		}
 
-		// Attempting authentication, if successful - generate token
-		jwtToken, err := h.authorizer.SignIn(r.Context(), &user)
-		// Either 200 or 401
+		// The bound account is not signed in without its certificate, so the password can not be guessed from elsewhere
+		if err := h.bindings.Check(user.Login, r.TLS); err != nil {
+			rw.WriteHeader(http.StatusForbidden)
+			rw.Write([]byte("certificate is not bound to the account"))
+			return
+		}
+
+		// Attempting authentication, if successful - generate tokens
+		tokens, err := h.authorizer.SignIn(r.Context(), &user, r.UserAgent(), remoteIP(r))
+		// Either 200, 428 if the password is correct but the second factor code is missing,
+		// 429 if there were too many failed attempts, or 401
 		if err != nil {
+			var locked *throttle.LockedError
+			if errors.As(err, &locked) {
+				rw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+				rw.WriteHeader(http.StatusTooManyRequests)
+				rw.Write([]byte("too many attempts"))
+				return
+			}
+			if errors.Is(err, twofactor.ErrCodeRequired) {
+				rw.WriteHeader(http.StatusPreconditionRequired)
+				rw.Write([]byte("otp required"))
+				return
+			}
 			rw.WriteHeader(http.StatusUnauthorized)
 			rw.Write([]byte("invalid login or password"))
 			return
 		}
-		rw.Header().Set("Authorization", jwtToken)
-		rw.Header().Set("Content-Type", "text/html")
+		writeTokens(rw, tokens)
+	}
+}
+
+// Refresh issues a new pair of tokens by the refresh token
+// @Tags Auth
+// @Summary Refreshes the access token
+// @Description input JSON with the refresh token, output new tokens in the Authorization and Refresh-Token headers
+// @ID refreshToken
+// @Accept  json
+// @Param refresh body models.Tokens true "refresh token"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Router /api/token/refresh [post]
+func (h *Handler) Refresh() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// check Content-Type
+		if r.Header.Get("Content-Type") != "application/json" {
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte("content type not support"))
+			return
+		}
+		reqBody, err := io.ReadAll(r.Body)
+		defer r.Body.Close()
+		if err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte("Read data problem"))
+			return
+		}
+
+		var request models.Tokens
+		err = json.Unmarshal(reqBody, &request)
+		if err != nil || request.RefreshToken == "" {
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte("invalid deserialization"))
+			return
+		}
+
+		tokens, err := h.authorizer.Refresh(r.Context(), request.RefreshToken)
+		if err != nil {
+			if errors.Is(err, jwt.ErrSessionExpired) || errors.Is(err, jwt.ErrInvalidToken) {
+				rw.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		writeTokens(rw, tokens)
+	}
+}
+
+// Logout revokes the current session, its tokens stop working
+// @Tags Auth
+// @Summary Logout
+// @ID logout
+// @Success 200
+// @Failure 500
+// @Router /api/logout [post]
+func (h *Handler) Logout() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+		sessionID := r.Context().Value(auth.SessionContextKey{}).(string)
+
+		err := h.store.RevokeSession(r.Context(), user.UserID, sessionID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("ok"))
+	}
+}
+
+// Sessions returns the active sessions of the user
+// @Tags Auth
+// @Summary Returns the active sessions of the user
+// @Description output JSON list, the current session is marked
+// @ID sessions
+// @Produce json
+// @Success 200 {object} []models.Session
+// @Failure 500
+// @Router /api/sessions [get]
+func (h *Handler) Sessions() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+		sessionID := r.Context().Value(auth.SessionContextKey{}).(string)
+
+		sessions, err := h.store.Sessions(r.Context(), user.UserID)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		for i := range sessions {
+			sessions[i].Current = sessions[i].ID == sessionID
+		}
+
+		data, err := json.Marshal(sessions)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(data)
+	}
+}
+
+// RevokeSession revokes the session of the user, e.g. of a lost device
+// @Tags Auth
+// @Summary Revokes the session
+// @ID revokeSession
+// @Param id path string true "session id"
+// @Success 200
+// @Failure 404
+// @Failure 500
+// @Router /api/sessions/{id} [delete]
+func (h *Handler) RevokeSession() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+		sessionID := chi.URLParam(r, "id")
+
+		err := h.authorizer.RevokeSession(r.Context(), user, remoteIP(r), sessionID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				rw.WriteHeader(http.StatusNotFound)
+				return
+			}
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("ok"))
+	}
+}
+
+// SetupTOTP generates a new secret of the second factor, it is enabled only after the confirmation
+// @Tags Auth
+// @Summary Sets up the two-factor authentication
+// @Description output JSON with the base32 secret and the otpauth uri for the authenticator app
+// @ID setupTOTP
+// @Produce json
+// @Success 200 {object} models.TOTPSetup
+// @Failure 409 {string} string ""
+// @Failure 500
+// @Router /api/2fa/setup [post]
+func (h *Handler) SetupTOTP() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		setup, err := h.authorizer.TwoFactor.Setup(r.Context(), user)
+		if err != nil {
+			if errors.Is(err, twofactor.ErrAlreadyEnabled) {
+				rw.WriteHeader(http.StatusConflict)
+				return
+			}
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		data, err := json.Marshal(setup)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(data)
+	}
+}
+
+// ConfirmTOTP enables the second factor by the code of the set up secret
+// @Tags Auth
+// @Summary Enables the two-factor authentication
+// @Description input JSON with the code from the authenticator app, output JSON with the one-time recovery codes
+// @ID confirmTOTP
+// @Accept json
+// @Produce json
+// @Param code body models.OTPCode true "code from the authenticator app"
+// @Success 200 {object} models.RecoveryCodes
+// @Failure 400 {string} string "invalid deserialization"
+// @Failure 403 {string} string "invalid code"
+// @Failure 409 {string} string ""
+// @Failure 500
+// @Router /api/2fa/confirm [post]
+func (h *Handler) ConfirmTOTP() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		code, ok := readOTPCode(rw, r)
+		if !ok {
+			return
+		}
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		codes, err := h.authorizer.EnableTOTP(r.Context(), user, remoteIP(r), code)
+		if err != nil {
+			switch {
+			case errors.Is(err, twofactor.ErrInvalidCode):
+				rw.WriteHeader(http.StatusForbidden)
+				rw.Write([]byte("invalid code"))
+			case errors.Is(err, twofactor.ErrAlreadyEnabled), errors.Is(err, twofactor.ErrNotSetUp):
+				rw.WriteHeader(http.StatusConflict)
+			default:
+				rw.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+
+		data, err := json.Marshal(models.RecoveryCodes{Codes: codes})
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(data)
+	}
+}
+
+// DisableTOTP disables the second factor, the current code or a recovery code is required
+// @Tags Auth
+// @Summary Disables the two-factor authentication
+// @ID disableTOTP
+// @Accept json
+// @Param code body models.OTPCode true "code from the authenticator app or a recovery code"
+// @Success 200 {string} string "ok"
+// @Failure 400 {string} string "invalid deserialization"
+// @Failure 403 {string} string "invalid code"
+// @Failure 409 {string} string ""
+// @Failure 500
+// @Router /api/2fa/disable [post]
+func (h *Handler) DisableTOTP() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		code, ok := readOTPCode(rw, r)
+		if !ok {
+			return
+		}
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		err := h.authorizer.DisableTOTP(r.Context(), user, remoteIP(r), code)
+		if err != nil {
+			switch {
+			case errors.Is(err, twofactor.ErrInvalidCode), errors.Is(err, twofactor.ErrCodeRequired):
+				rw.WriteHeader(http.StatusForbidden)
+				rw.Write([]byte("invalid code"))
+			case errors.Is(err, twofactor.ErrNotEnabled):
+				rw.WriteHeader(http.StatusConflict)
+			default:
+				rw.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("ok"))
+	}
+}
+
+// readOTPCode reads the code of the second factor from the request body, writes 400 if there is no code
+func readOTPCode(rw http.ResponseWriter, r *http.Request) (string, bool) {
+	reqBody, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		rw.WriteHeader(http.StatusInternalServerError)
+		rw.Write([]byte("read data problem"))
+		return "", false
+	}
+
+	var request models.OTPCode
+	err = json.Unmarshal(reqBody, &request)
+	if err != nil || request.Code == "" {
+		rw.WriteHeader(http.StatusBadRequest)
+		rw.Write([]byte("invalid deserialization"))
+		return "", false
+	}
+	return request.Code, true
+}
+
+// ChangePassword replaces the password of the user, the other sessions are revoked
+// @Tags Account
+// @Summary Changes the password
+// @ID changePassword
+// @Accept json
+// @Param change body models.PasswordChange true "current and new password"
+// @Success 200 {string} string "ok"
+// @Failure 400 {string} string "invalid deserialization"
+// @Failure 403 {string} string "invalid password"
+// @Failure 429 {string} string "too many attempts"
+// @Failure 500
+// @Router /api/account/password [put]
+func (h *Handler) ChangePassword() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		var change models.PasswordChange
+		if !readJSON(rw, r, &change) {
+			return
+		}
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+		sessionID := r.Context().Value(auth.SessionContextKey{}).(string)
+
+		err := h.authorizer.ChangePassword(r.Context(), user, sessionID, remoteIP(r), change)
+		writeAccountResult(rw, err)
+	}
+}
+
+// ChangeLogin renames the user, the current access token stops working and is refreshed by the client
+// @Tags Account
+// @Summary Changes the login
+// @ID changeLogin
+// @Accept json
+// @Param change body models.LoginChange true "password and new login"
+// @Success 200 {string} string "ok"
+// @Failure 400 {string} string "invalid deserialization"
+// @Failure 403 {string} string "invalid password"
+// @Failure 409 {string} string "already have"
+// @Failure 429 {string} string "too many attempts"
+// @Failure 500
+// @Router /api/account/login [put]
+func (h *Handler) ChangeLogin() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		var change models.LoginChange
+		if !readJSON(rw, r, &change) {
+			return
+		}
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		// The binding is kept by the login, so the bound account is not renamed and the new login is not bound
+		if err := h.bindings.CheckRename(user.Login, change.NewLogin, r.TLS); errors.Is(err, certbind.ErrBoundLogin) {
+			rw.WriteHeader(http.StatusForbidden)
+			rw.Write([]byte(err.Error()))
+			return
+		} else if err != nil {
+			rw.WriteHeader(http.StatusForbidden)
+			rw.Write([]byte("certificate is not bound to the account"))
+			return
+		}
+
+		err := h.authorizer.ChangeLogin(r.Context(), user, remoteIP(r), change)
+		writeAccountResult(rw, err)
+	}
+}
+
+// DeleteAccount removes the user with all the records and sessions
+// @Tags Account
+// @Summary Deletes the account
+// @ID deleteAccount
+// @Accept json
+// @Param deletion body models.AccountDeletion true "password"
+// @Success 200 {string} string "ok"
+// @Failure 400 {string} string "invalid deserialization"
+// @Failure 403 {string} string "invalid password"
+// @Failure 429 {string} string "too many attempts"
+// @Failure 500
+// @Router /api/account [delete]
+func (h *Handler) DeleteAccount() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		var deletion models.AccountDeletion
+		if !readJSON(rw, r, &deletion) {
+			return
+		}
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		err := h.authorizer.DeleteAccount(r.Context(), user, remoteIP(r), deletion)
+		writeAccountResult(rw, err)
+	}
+}
+
+// readJSON reads the request body into v, writes 400 if it is not a valid json
+func readJSON(rw http.ResponseWriter, r *http.Request, v interface{}) bool {
+	reqBody, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		rw.WriteHeader(http.StatusInternalServerError)
+		rw.Write([]byte("read data problem"))
+		return false
+	}
+
+	if err := json.Unmarshal(reqBody, v); err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		rw.Write([]byte("invalid deserialization"))
+		return false
+	}
+	return true
+}
+
+// writeAccountResult writes the response of the account management requests.
+// The wrong password is 403 and not 401, otherwise the client would refresh the token and repeat the request
+func writeAccountResult(rw http.ResponseWriter, err error) {
+	var locked *throttle.LockedError
+	switch {
+	case err == nil:
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("ok"))
+	case errors.As(err, &locked):
+		rw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+		rw.WriteHeader(http.StatusTooManyRequests)
+		rw.Write([]byte("too many attempts"))
+	case errors.Is(err, jwt.ErrEmptyValue):
+		rw.WriteHeader(http.StatusBadRequest)
+		rw.Write([]byte(err.Error()))
+	case errors.Is(err, jwt.ErrInvalidCredentials):
+		rw.WriteHeader(http.StatusForbidden)
+		rw.Write([]byte("invalid password"))
+	case errors.Is(err, storage.ErrLoginTaken):
+		rw.WriteHeader(http.StatusConflict)
+		rw.Write([]byte("already have"))
+	default:
+		rw.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// Lockouts returns the lockouts of the account after too many failed sign-in attempts
+// @Tags Auth
+// @Summary Returns the lockouts of the account
+// @Description output JSON list, the newest first
+// @ID lockouts
+// @Produce json
+// @Success 200 {object} []models.Lockout
+// @Failure 500
+// @Router /api/lockouts [get]
+func (h *Handler) Lockouts() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		lockouts, err := h.store.Lockouts(r.Context(), user.UserID)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		data, err := json.Marshal(lockouts)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(data)
+	}
+}
+
+// AuditEvents returns the page of the audit log of the user
+// @Tags Auth
+// @Summary Returns the audit log of the user
+// @Description output JSON page of the events, the newest first. The next page is requested with before = next
+// @ID audit
+// @Produce json
+// @Param before query int false "cursor of the page"
+// @Param limit query int false "size of the page, 50 by default, 200 at most"
+// @Success 200 {object} models.AuditPage
+// @Failure 400
+// @Failure 500
+// @Router /api/audit [get]
+func (h *Handler) AuditEvents() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		before, limit, err := pageQuery(r)
+		if err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte("invalid query"))
+			return
+		}
+		limit = paging.Limit(limit)
+
+		events, err := h.store.AuditEvents(r.Context(), user.UserID, before, limit)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		data, err := json.Marshal(audit.NewPage(events, limit))
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(data)
+	}
+}
+
+// pageQuery reads the cursor and the size of the page from the query, both are optional
+func pageQuery(r *http.Request) (int64, int, error) {
+	var before int64
+	var limit int
+	var err error
+	if value := r.URL.Query().Get("before"); value != "" {
+		if before, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return 0, 0, err
+		}
+	}
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			return 0, 0, err
+		}
+	}
+	return before, limit, nil
+}
+
+// Cards returns the page of the cards of the user without the secret fields
+// @Tags Read
+// @Summary Returns the list of the user cards
+// @Description output JSON page of the ids, the notes, the tags and the fields. The next page is requested with after = next
+// @ID listCards
+// @Produce json
+// @Param after query string false "cursor of the page"
+// @Param limit query int false "size of the page, 50 by default, 200 at most"
+// @Param sort query string false "created (default) or id"
+// @Param order query string false "asc (default) or desc"
+// @Param tag query string false "only the records with the tag"
+// @Param field query string false "only the records with the custom field"
+// @Param value query string false "only the records with the value of the field"
+// @Param vault query int false "only the records of the vault"
+// @Param folder query int false "only the records of the folder of the vault, the root of the vault by default"
+// @Success 200 {object} models.RecordPage
+// @Failure 400 {string} string "invalid query"
+// @Failure 500 {string} string ""
+// @Router /api/card [get]
+func (h *Handler) Cards() http.HandlerFunc {
+	return h.records(models.KindCard)
+}
+
+// Logins returns the page of the logins of the user without the secret fields
+// @Tags Read
+// @Summary Returns the list of the user logins
+// @Description output JSON page of the ids, the notes, the tags and the fields. The next page is requested with after = next
+// @ID listLogins
+// @Produce json
+// @Param after query string false "cursor of the page"
+// @Param limit query int false "size of the page, 50 by default, 200 at most"
+// @Param sort query string false "created (default) or id"
+// @Param order query string false "asc (default) or desc"
+// @Param tag query string false "only the records with the tag"
+// @Param field query string false "only the records with the custom field"
+// @Param value query string false "only the records with the value of the field"
+// @Param vault query int false "only the records of the vault"
+// @Param folder query int false "only the records of the folder of the vault, the root of the vault by default"
+// @Success 200 {object} models.RecordPage
+// @Failure 400 {string} string "invalid query"
+// @Failure 500 {string} string ""
+// @Router /api/login [get]
+func (h *Handler) Logins() http.HandlerFunc {
+	return h.records(models.KindLogin)
+}
+
+// Texts returns the page of the text data of the user without the secret fields
+// @Tags Read
+// @Summary Returns the list of the user text data
+// @Description output JSON page of the ids, the notes, the tags and the fields. The next page is requested with after = next
+// @ID listTexts
+// @Produce json
+// @Param after query string false "cursor of the page"
+// @Param limit query int false "size of the page, 50 by default, 200 at most"
+// @Param sort query string false "created (default) or id"
+// @Param order query string false "asc (default) or desc"
+// @Param tag query string false "only the records with the tag"
+// @Param field query string false "only the records with the custom field"
+// @Param value query string false "only the records with the value of the field"
+// @Param vault query int false "only the records of the vault"
+// @Param folder query int false "only the records of the folder of the vault, the root of the vault by default"
+// @Success 200 {object} models.RecordPage
+// @Failure 400 {string} string "invalid query"
+// @Failure 500 {string} string ""
+// @Router /api/txt [get]
+func (h *Handler) Texts() http.HandlerFunc {
+	return h.records(models.KindText)
+}
+
+// Binaries returns the page of the binary data of the user without the secret fields
+// @Tags Read
+// @Summary Returns the list of the user binary data
+// @Description output JSON page of the ids, the notes, the tags and the fields. The next page is requested with after = next
+// @ID listBinaries
+// @Produce json
+// @Param after query string false "cursor of the page"
+// @Param limit query int false "size of the page, 50 by default, 200 at most"
+// @Param sort query string false "created (default) or id"
+// @Param order query string false "asc (default) or desc"
+// @Param tag query string false "only the records with the tag"
+// @Param field query string false "only the records with the custom field"
+// @Param value query string false "only the records with the value of the field"
+// @Param vault query int false "only the records of the vault"
+// @Param folder query int false "only the records of the folder of the vault, the root of the vault by default"
+// @Success 200 {object} models.RecordPage
+// @Failure 400 {string} string "invalid query"
+// @Failure 500 {string} string ""
+// @Router /api/bin [get]
+func (h *Handler) Binaries() http.HandlerFunc {
+	return h.records(models.KindBinary)
+}
+
+// SSHKeys returns the page of the ssh keys of the user without the private keys
+// @Tags Read
+// @Summary Returns the list of the user ssh keys
+// @Description output JSON page of the ids, the notes, the tags and the fields. The next page is requested with after = next
+// @ID listSSHKeys
+// @Produce json
+// @Param after query string false "cursor of the page"
+// @Param limit query int false "size of the page, 50 by default, 200 at most"
+// @Param sort query string false "created (default) or id"
+// @Param order query string false "asc (default) or desc"
+// @Param tag query string false "only the records with the tag"
+// @Param field query string false "only the records with the custom field"
+// @Param value query string false "only the records with the value of the field"
+// @Param vault query int false "only the records of the vault"
+// @Param folder query int false "only the records of the folder of the vault, the root of the vault by default"
+// @Success 200 {object} models.RecordPage
+// @Failure 400 {string} string "invalid query"
+// @Failure 500 {string} string ""
+// @Router /api/ssh [get]
+func (h *Handler) SSHKeys() http.HandlerFunc {
+	return h.records(models.KindSSH)
+}
+
+// records returns the handler of the record list of the kind
+func (h *Handler) records(kind models.Kind) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		opts, err := listQuery(r)
+		if err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte("invalid query"))
+			return
+		}
+
+		records, err := h.store.Records(r.Context(), user.UserID, kind, opts)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		data, err := json.Marshal(listing.NewPage(records, opts.Limit))
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(data)
+	}
+}
+
+// Revisions returns the previous versions of the record without the values, the newest first
+// @Tags Read
+// @Summary Returns the history of the record
+// @Description output JSON list of the revisions, the record may be already deleted
+// @ID listRevisions
+// @Produce json
+// @Param id path string true "Record ID"
+// @Success 200 {array} models.Revision
+// @Failure 500 {string} string ""
+// @Router /api/card/{id}/revisions [get]
+// @Router /api/login/{id}/revisions [get]
+// @Router /api/txt/{id}/revisions [get]
+// @Router /api/bin/{id}/revisions [get]
+func (h *Handler) Revisions(kind models.Kind) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		recordID := chi.URLParam(r, "id")
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		revisions, err := h.store.Revisions(r.Context(), user.UserID, kind, recordID)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		data, err := json.Marshal(revisions)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(data)
+	}
+}
+
+// Revision returns the revision of the record with the values of the fields
+// @Tags Read
+// @Summary Returns the previous version of the record
+// @Description output JSON revision with the fields by their json names
+// @ID readRevision
+// @Produce json
+// @Param id path string true "Record ID"
+// @Param rev path int true "Revision ID"
+// @Success 200 {object} models.Revision
+// @Failure 400 {string} string "invalid revision"
+// @Failure 404 {string} string ""
+// @Failure 500 {string} string ""
+// @Router /api/card/{id}/revisions/{rev} [get]
+// @Router /api/login/{id}/revisions/{rev} [get]
+// @Router /api/txt/{id}/revisions/{rev} [get]
+// @Router /api/bin/{id}/revisions/{rev} [get]
+func (h *Handler) Revision(kind models.Kind) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		recordID := chi.URLParam(r, "id")
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		revisionID, err := strconv.ParseInt(chi.URLParam(r, "rev"), 10, 64)
+		if err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte("invalid revision"))
+			return
+		}
+
+		revision, err := h.store.Revision(r.Context(), user.UserID, kind, recordID, revisionID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				rw.WriteHeader(http.StatusNotFound)
+				return
+			}
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		data, err := json.Marshal(revision)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
 		rw.WriteHeader(http.StatusOK)
+		rw.Write(data)
 	}
 }
 
+// RestoreRevision makes the revision the current version of the record, the deleted record is added again
+// @Tags Add
+// @Summary Restoring the previous version of the record
+// @Description The restore is a new version of the record, the history is kept
+// @ID restoreRevision
+// @Produce json
+// @Param id path string true "Record ID"
+// @Param rev path int true "Revision ID"
+// @Success 200 {object} models.RecordVersion
+// @Failure 400 {string} string "invalid revision"
+// @Failure 404 {string} string ""
+// @Failure 410 {string} string "the content of the revision is removed"
+// @Failure 500 {string} string ""
+// @Router /api/card/{id}/revisions/{rev}/restore [post]
+// @Router /api/login/{id}/revisions/{rev}/restore [post]
+// @Router /api/txt/{id}/revisions/{rev}/restore [post]
+// @Router /api/bin/{id}/revisions/{rev}/restore [post]
+func (h *Handler) RestoreRevision(kind models.Kind) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		recordID := chi.URLParam(r, "id")
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		revisionID, err := strconv.ParseInt(chi.URLParam(r, "rev"), 10, 64)
+		if err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte("invalid revision"))
+			return
+		}
+
+		version, err := h.store.RestoreRevision(r.Context(), user.UserID, kind, recordID, revisionID)
+		writeUpdateResult(rw, models.RecordVersion{ID: recordID, Version: version}, err)
+	}
+}
+
+// Trash returns the deleted records of the user without the secret fields
+// @Tags Read
+// @Summary Returns the trash of the user
+// @Description output JSON list of the deleted records, the recently deleted first
+// @ID listTrash
+// @Produce json
+// @Success 200 {array} models.TrashItem
+// @Failure 500 {string} string ""
+// @Router /api/trash [get]
+func (h *Handler) Trash() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		items, err := h.store.Trash(r.Context(), user.UserID)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		data, err := json.Marshal(items)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(data)
+	}
+}
+
+// RestoreTrash moves the deleted record back from the trash
+// @Tags Add
+// @Summary Restoring the deleted record
+// @Description The record is restored with the values it had when it was deleted
+// @ID restoreTrash
+// @Produce plain
+// @Param kind path string true "card, login, text, bin or ssh"
+// @Param id path string true "Record ID"
+// @Success 200 {string} string "ok"
+// @Failure 400 {string} string "invalid kind"
+// @Failure 404 {string} string ""
+// @Failure 500 {string} string ""
+// @Router /api/trash/{kind}/{id}/restore [post]
+func (h *Handler) RestoreTrash() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		kind, err := models.ParseKind(chi.URLParam(r, "kind"))
+		if err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte("invalid kind"))
+			return
+		}
+
+		err = h.store.RestoreTrash(r.Context(), user.UserID, kind, chi.URLParam(r, "id"))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				rw.WriteHeader(http.StatusNotFound)
+				return
+			}
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("ok"))
+	}
+}
+
+// EmptyTrash removes the deleted records of the user for good
+// @Tags Delete
+// @Summary Emptying the trash
+// @Description The removed records can not be restored, their history is kept
+// @ID emptyTrash
+// @Produce json
+// @Success 200 {object} models.TrashEmptied
+// @Failure 500 {string} string ""
+// @Router /api/trash [delete]
+func (h *Handler) EmptyTrash() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		removed, err := h.store.EmptyTrash(r.Context(), user.UserID)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		data, err := json.Marshal(models.TrashEmptied{Removed: removed})
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(data)
+	}
+}
+
+// readUpdate reads the body of the update into the record, the fields missing in the body keep their values.
+// The version is required, without it the update would overwrite the changes of other clients
+func readUpdate(rw http.ResponseWriter, r *http.Request, record interface{}) bool {
+	reqBody, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		rw.WriteHeader(http.StatusInternalServerError)
+		rw.Write([]byte("read data problem"))
+		return false
+	}
+
+	var version models.RecordVersion
+	if err := json.Unmarshal(reqBody, &version); err != nil || json.Unmarshal(reqBody, record) != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		rw.Write([]byte("invalid deserialization"))
+		return false
+	}
+	if version.Version <= 0 {
+		rw.WriteHeader(http.StatusBadRequest)
+		rw.Write([]byte("version required"))
+		return false
+	}
+	return true
+}
+
+// writeUpdateResult writes the response of the record update
+func writeUpdateResult(rw http.ResponseWriter, version models.RecordVersion, err error) {
+	switch {
+	case err == nil:
+		data, err := json.Marshal(version)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(data)
+	case errors.Is(err, pgx.ErrNoRows):
+		rw.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, storage.ErrVersionConflict):
+		rw.WriteHeader(http.StatusConflict)
+		rw.Write([]byte("version conflict"))
+	case errors.Is(err, storage.ErrContentRemoved):
+		rw.WriteHeader(http.StatusGone)
+		rw.Write([]byte(err.Error()))
+	default:
+		rw.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// listQuery reads the options of the record list from the query, all are optional
+func listQuery(r *http.Request) (models.ListOptions, error) {
+	query := r.URL.Query()
+	var limit int
+	var vault, folder int64
+	for name, value := range map[string]*int64{"vault": &vault, "folder": &folder} {
+		if param := query.Get(name); param != "" {
+			var err error
+			if *value, err = strconv.ParseInt(param, 10, 64); err != nil {
+				return models.ListOptions{}, err
+			}
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil {
+			return models.ListOptions{}, err
+		}
+	}
+	opts, err := listing.Options(query.Get("sort"), query.Get("order"), query.Get("after"), limit)
+	if err != nil {
+		return models.ListOptions{}, err
+	}
+	if opts, err = listing.Filter(opts, query.Get("tag"), query.Get("field"), query.Get("value")); err != nil {
+		return models.ListOptions{}, err
+	}
+	return listing.Scope(opts, vault, folder)
+}
+
+// remoteIP returns the ip address of the client. Forwarded headers are not trusted, they are set by the client
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// writeTokens writes the tokens of the session to the response headers
+func writeTokens(rw http.ResponseWriter, tokens *models.Tokens) {
+	rw.Header().Set("Authorization", tokens.AccessToken)
+	rw.Header().Set("Refresh-Token", tokens.RefreshToken)
+	rw.Header().Set("Content-Type", "text/html")
+	rw.WriteHeader(http.StatusOK)
+}
+
 // Card return specific card data
 // @Tags Read
 // @Summary Returns user card data
@@ -231,12 +1318,14 @@ func (h *Handler) Card() http.HandlerFunc {
 // @Tags Add
 // @Summary Registering a new card
 // @Description Registration is performed using a unique pair of User ID + Card ID.
+// @Description The number must pass the Luhn check, the expiry is MM/YY, the CVV length depends on the brand.
 // @ID addCard
 // @Accept json
 // @Produce plain
 // @Param card_data body Card true "Card object"
 // @Success 200 {string} string "ok"
 // @Failure 400 {string} string "invalid deserialization"
+// @Failure 400 {string} string "invalid card: ..."
 // @Failure 409 {string} string ""
 // @Failure 500 {string} string "read data problem"
 // @Router /api/card [post]
@@ -258,11 +1347,21 @@ func (h *Handler) AddCard() http.HandlerFunc {
 			rw.Write([]byte("invalid deserialization"))
 			return
 		}
+		if err := cardData.Validate(); err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte(err.Error()))
+			return
+		}
 
 		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
 
 		err = h.store.AddCard(r.Context(), user.UserID, cardData)
 		if err != nil {
+			if errors.Is(err, storage.ErrInvalidLocation) {
+				rw.WriteHeader(http.StatusBadRequest)
+				rw.Write([]byte(err.Error()))
+				return
+			}
 			rw.WriteHeader(http.StatusConflict)
 			return
 		}
@@ -273,14 +1372,62 @@ func (h *Handler) AddCard() http.HandlerFunc {
 	}
 }
 
+// UpdateCard replaces the card if the version in the body is still the current one.
+// PUT replaces the whole record, PATCH only the fields present in the body
+// @Tags Add
+// @Summary Updating the card
+// @Description The version of the body is required, it is the version of the record read by the client.
+// @ID updateCard
+// @Accept json
+// @Produce json
+// @Param id path string true "Card ID"
+// @Param card_data body Card true "Card object with the version"
+// @Success 200 {object} models.RecordVersion
+// @Failure 400 {string} string "invalid deserialization"
+// @Failure 400 {string} string "invalid card: ..."
+// @Failure 404 {string} string ""
+// @Failure 409 {string} string "version conflict"
+// @Failure 500 {string} string ""
+// @Router /api/card/{id} [put]
+// @Router /api/card/{id} [patch]
+func (h *Handler) UpdateCard() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		cardID := chi.URLParam(r, "id")
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		var card models.Card
+		if r.Method == http.MethodPatch {
+			current, err := h.store.Card(audit.Internal(r.Context()), user.UserID, cardID)
+			if err != nil {
+				writeUpdateResult(rw, models.RecordVersion{}, err)
+				return
+			}
+			card = *current
+		}
+		if !readUpdate(rw, r, &card) {
+			return
+		}
+		card.ID = cardID
+		if err := card.Validate(); err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte(err.Error()))
+			return
+		}
+
+		version, err := h.store.UpdateCard(r.Context(), user.UserID, card)
+		writeUpdateResult(rw, models.RecordVersion{ID: cardID, Version: version}, err)
+	}
+}
+
 // DeleteCard delete a card by user and ID
 // @Tags Delete
 // @Summary Deleting a card
-// @Description Deletion is performed using a unique pair of User ID + Card ID.
+// @Description The record is moved to the trash. Deletion is performed using a unique pair of User ID + Card ID.
 // @ID delCard
 // @Produce plain
 // @Param id path string true "Card ID"
 // @Success 200 {string} string "ok"
+// @Failure 404 {string} string ""
 // @Failure 500 {string} string ""
 // @Router /api/card [delete]
 func (h *Handler) DeleteCard() http.HandlerFunc {
@@ -291,6 +1438,10 @@ func (h *Handler) DeleteCard() http.HandlerFunc {
 		// delete login
 		err := h.store.DeleteCard(r.Context(), user.UserID, cardID)
 		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				rw.WriteHeader(http.StatusNotFound)
+				return
+			}
 			rw.WriteHeader(http.StatusInternalServerError)
 			return
 		}
@@ -377,6 +1528,11 @@ func (h *Handler) AddLogin() http.HandlerFunc {
 
 		err = h.store.AddLogin(r.Context(), user.UserID, loginData)
 		if err != nil {
+			if errors.Is(err, storage.ErrInvalidLocation) {
+				rw.WriteHeader(http.StatusBadRequest)
+				rw.Write([]byte(err.Error()))
+				return
+			}
 			rw.WriteHeader(http.StatusConflict)
 			return
 		}
@@ -387,14 +1543,56 @@ func (h *Handler) AddLogin() http.HandlerFunc {
 	}
 }
 
+// UpdateLogin replaces the login if the version in the body is still the current one.
+// PUT replaces the whole record, PATCH only the fields present in the body
+// @Tags Add
+// @Summary Updating the login
+// @Description The version of the body is required, it is the version of the record read by the client.
+// @ID updateLogin
+// @Accept json
+// @Produce json
+// @Param id path string true "Login ID"
+// @Param login_data body Login true "Login object with the version"
+// @Success 200 {object} models.RecordVersion
+// @Failure 400 {string} string "invalid deserialization"
+// @Failure 404 {string} string ""
+// @Failure 409 {string} string "version conflict"
+// @Failure 500 {string} string ""
+// @Router /api/login/{id} [put]
+// @Router /api/login/{id} [patch]
+func (h *Handler) UpdateLogin() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		loginID := chi.URLParam(r, "id")
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		var login models.Login
+		if r.Method == http.MethodPatch {
+			current, err := h.store.Login(audit.Internal(r.Context()), user.UserID, loginID)
+			if err != nil {
+				writeUpdateResult(rw, models.RecordVersion{}, err)
+				return
+			}
+			login = *current
+		}
+		if !readUpdate(rw, r, &login) {
+			return
+		}
+		login.ID = loginID
+
+		version, err := h.store.UpdateLogin(r.Context(), user.UserID, login)
+		writeUpdateResult(rw, models.RecordVersion{ID: loginID, Version: version}, err)
+	}
+}
+
 // DeleteLogin delete a login by user and ID
 // @Tags Delete
 // @Summary Deleting a login
-// @Description Deletion is performed using a unique pair of User ID + Card ID.
+// @Description The record is moved to the trash. Deletion is performed using a unique pair of User ID + Card ID.
 // @ID delLogin
 // @Produce plain
 // @Param id path string true "Login ID"
 // @Success 200 {string} string "ok"
+// @Failure 404 {string} string ""
 // @Failure 500 {string} string ""
 // @Router /api/login [delete]
 func (h *Handler) DeleteLogin() http.HandlerFunc {
@@ -405,6 +1603,10 @@ func (h *Handler) DeleteLogin() http.HandlerFunc {
 		// delete login
 		err := h.store.DeleteLogin(r.Context(), user.UserID, loginID)
 		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				rw.WriteHeader(http.StatusNotFound)
+				return
+			}
 			rw.WriteHeader(http.StatusInternalServerError)
 			return
 		}
@@ -491,6 +1693,11 @@ func (h *Handler) AddText() http.HandlerFunc {
 
 		err = h.store.AddText(r.Context(), user.UserID, textData)
 		if err != nil {
+			if errors.Is(err, storage.ErrInvalidLocation) {
+				rw.WriteHeader(http.StatusBadRequest)
+				rw.Write([]byte(err.Error()))
+				return
+			}
 			rw.WriteHeader(http.StatusConflict)
 			return
 		}
@@ -501,14 +1708,56 @@ func (h *Handler) AddText() http.HandlerFunc {
 	}
 }
 
+// UpdateText replaces the text if the version in the body is still the current one.
+// PUT replaces the whole record, PATCH only the fields present in the body
+// @Tags Add
+// @Summary Updating the text
+// @Description The version of the body is required, it is the version of the record read by the client.
+// @ID updateText
+// @Accept json
+// @Produce json
+// @Param id path string true "Text ID"
+// @Param text_data body Text true "Text object with the version"
+// @Success 200 {object} models.RecordVersion
+// @Failure 400 {string} string "invalid deserialization"
+// @Failure 404 {string} string ""
+// @Failure 409 {string} string "version conflict"
+// @Failure 500 {string} string ""
+// @Router /api/txt/{id} [put]
+// @Router /api/txt/{id} [patch]
+func (h *Handler) UpdateText() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		textID := chi.URLParam(r, "id")
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		var text models.Text
+		if r.Method == http.MethodPatch {
+			current, err := h.store.Text(audit.Internal(r.Context()), user.UserID, textID)
+			if err != nil {
+				writeUpdateResult(rw, models.RecordVersion{}, err)
+				return
+			}
+			text = *current
+		}
+		if !readUpdate(rw, r, &text) {
+			return
+		}
+		text.ID = textID
+
+		version, err := h.store.UpdateText(r.Context(), user.UserID, text)
+		writeUpdateResult(rw, models.RecordVersion{ID: textID, Version: version}, err)
+	}
+}
+
 // DeleteText delete text by user and ID
 // @Tags Delete
 // @Summary Deleting text
-// @Description Deletion is performed using a unique pair of User ID + Card ID.
+// @Description The record is moved to the trash. Deletion is performed using a unique pair of User ID + Card ID.
 // @ID delText
 // @Produce plain
 // @Param id path string true "Text ID"
 // @Success 200 {string} string "ok"
+// @Failure 404 {string} string ""
 // @Failure 500 {string} string ""
 // @Router /api/text [delete]
 func (h *Handler) DeleteText() http.HandlerFunc {
@@ -519,6 +1768,10 @@ func (h *Handler) DeleteText() http.HandlerFunc {
 		// delete text
 		err := h.store.DeleteText(r.Context(), user.UserID, textID)
 		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				rw.WriteHeader(http.StatusNotFound)
+				return
+			}
 			rw.WriteHeader(http.StatusInternalServerError)
 			return
 		}
@@ -606,6 +1859,11 @@ func (h *Handler) AddBinary() http.HandlerFunc {
 		// Requesting binary data information
 		err = h.store.AddBinary(r.Context(), user.UserID, binData)
 		if err != nil {
+			if errors.Is(err, storage.ErrInvalidLocation) {
+				rw.WriteHeader(http.StatusBadRequest)
+				rw.Write([]byte(err.Error()))
+				return
+			}
 			rw.WriteHeader(http.StatusConflict)
 			return
 		}
@@ -616,14 +1874,56 @@ func (h *Handler) AddBinary() http.HandlerFunc {
 	}
 }
 
+// UpdateBinary replaces the binary if the version in the body is still the current one.
+// PUT replaces the whole record, PATCH only the fields present in the body
+// @Tags Add
+// @Summary Updating the binary
+// @Description The version of the body is required, it is the version of the record read by the client.
+// @ID updateBinary
+// @Accept json
+// @Produce json
+// @Param id path string true "Binary ID"
+// @Param binary_data body Binary true "Binary object with the version"
+// @Success 200 {object} models.RecordVersion
+// @Failure 400 {string} string "invalid deserialization"
+// @Failure 404 {string} string ""
+// @Failure 409 {string} string "version conflict"
+// @Failure 500 {string} string ""
+// @Router /api/bin/{id} [put]
+// @Router /api/bin/{id} [patch]
+func (h *Handler) UpdateBinary() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		binID := chi.URLParam(r, "id")
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		var binary models.Binary
+		if r.Method == http.MethodPatch {
+			current, err := h.store.Binary(audit.Internal(r.Context()), user.UserID, binID)
+			if err != nil {
+				writeUpdateResult(rw, models.RecordVersion{}, err)
+				return
+			}
+			binary = *current
+		}
+		if !readUpdate(rw, r, &binary) {
+			return
+		}
+		binary.ID = binID
+
+		version, err := h.store.UpdateBinary(r.Context(), user.UserID, binary)
+		writeUpdateResult(rw, models.RecordVersion{ID: binID, Version: version}, err)
+	}
+}
+
 // DeleteBinary delete binary data by user and ID
 // @Tags Delete
 // @Summary Deleting binary data
-// @Description Deletion is performed using a unique pair of User ID + Card ID.
+// @Description The record is moved to the trash. Deletion is performed using a unique pair of User ID + Card ID.
 // @ID delBinary
 // @Produce plain
 // @Param id path string true "Binary ID"
 // @Success 200 {string} string "ok"
+// @Failure 404 {string} string ""
 // @Failure 500 {string} string ""
 // @Router /api/text [delete]
 func (h *Handler) DeleteBinary() http.HandlerFunc {
@@ -634,6 +1934,267 @@ func (h *Handler) DeleteBinary() http.HandlerFunc {
 		// delete binary data
 		err := h.store.DeleteBinary(r.Context(), user.UserID, binID)
 		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				rw.WriteHeader(http.StatusNotFound)
+				return
+			}
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("ok"))
+	}
+}
+
+// SSHKey return specific ssh key
+// @Tags Read
+// @Summary Returns user ssh key
+// @Description input rest URL, output JSON value
+// @ID readSSHKey
+// @Produce json
+// @Param id path string true "SSH key ID"
+// @Success 200 {object} SSHKey
+// @Failure 404 {string} string ""
+// @Failure 500 {string} string ""
+// @Router /api/ssh/{id} [get]
+func (h *Handler) SSHKey() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		keyID := chi.URLParam(r, "id")
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		targetKey, err := h.store.SSHKey(r.Context(), user.UserID, keyID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				rw.WriteHeader(http.StatusNotFound)
+				return
+			}
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		result, err := json.Marshal(targetKey)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			rw.Write([]byte("invalid serialization"))
+			return
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(result)
+	}
+}
+
+// AddSSHKey register a new ssh key
+// @Tags Add
+// @Summary Registering a new ssh key
+// @Description Registration is performed using a unique pair of User ID + SSH key ID.
+// @Description The public key may be omitted, it is derived from the private key together with the fingerprint.
+// @ID addSSHKey
+// @Accept json
+// @Produce plain
+// @Param ssh_key body SSHKey true "SSH key object"
+// @Success 200 {string} string "ok"
+// @Failure 400 {string} string "invalid deserialization"
+// @Failure 400 {string} string "invalid ssh key: ..."
+// @Failure 409 {string} string ""
+// @Failure 500 {string} string "read data problem"
+// @Router /api/ssh [post]
+func (h *Handler) AddSSHKey() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		reqBody, err := io.ReadAll(r.Body)
+		defer r.Body.Close()
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			rw.Write([]byte("read data problem"))
+			return
+		}
+
+		var key models.SSHKey
+		err = json.Unmarshal(reqBody, &key)
+		if err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte("invalid deserialization"))
+			return
+		}
+		if err := key.Validate(); err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte(err.Error()))
+			return
+		}
+
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		err = h.store.AddSSHKey(r.Context(), user.UserID, key)
+		if err != nil {
+			if errors.Is(err, storage.ErrInvalidLocation) {
+				rw.WriteHeader(http.StatusBadRequest)
+				rw.Write([]byte(err.Error()))
+				return
+			}
+			rw.WriteHeader(http.StatusConflict)
+			return
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("ok"))
+	}
+}
+
+// UpdateSSHKey replaces the ssh key if the version in the body is still the current one.
+// PUT replaces the whole record, PATCH only the fields present in the body
+// @Tags Add
+// @Summary Updating the ssh key
+// @Description The version of the body is required, it is the version of the record read by the client.
+// @ID updateSSHKey
+// @Accept json
+// @Produce json
+// @Param id path string true "SSH key ID"
+// @Param ssh_key body SSHKey true "SSH key object with the version"
+// @Success 200 {object} models.RecordVersion
+// @Failure 400 {string} string "invalid deserialization"
+// @Failure 400 {string} string "invalid ssh key: ..."
+// @Failure 404 {string} string ""
+// @Failure 409 {string} string "version conflict"
+// @Failure 500 {string} string ""
+// @Router /api/ssh/{id} [put]
+// @Router /api/ssh/{id} [patch]
+func (h *Handler) UpdateSSHKey() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		keyID := chi.URLParam(r, "id")
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		var key models.SSHKey
+		if r.Method == http.MethodPatch {
+			current, err := h.store.SSHKey(audit.Internal(r.Context()), user.UserID, keyID)
+			if err != nil {
+				writeUpdateResult(rw, models.RecordVersion{}, err)
+				return
+			}
+			key = *current
+		}
+		if !readUpdate(rw, r, &key) {
+			return
+		}
+		key.ID = keyID
+		if err := key.Validate(); err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte(err.Error()))
+			return
+		}
+
+		version, err := h.store.UpdateSSHKey(r.Context(), user.UserID, key)
+		writeUpdateResult(rw, models.RecordVersion{ID: keyID, Version: version}, err)
+	}
+}
+
+// DeleteSSHKey delete ssh key by user and ID
+// @Tags Delete
+// @Summary Deleting ssh key
+// @Description The record is moved to the trash. Deletion is performed using a unique pair of User ID + SSH key ID.
+// @ID delSSHKey
+// @Produce plain
+// @Param id path string true "SSH key ID"
+// @Success 200 {string} string "ok"
+// @Failure 404 {string} string ""
+// @Failure 500 {string} string ""
+// @Router /api/ssh/{id} [delete]
+func (h *Handler) DeleteSSHKey() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		keyID := chi.URLParam(r, "id")
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		err := h.store.DeleteSSHKey(r.Context(), user.UserID, keyID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				rw.WriteHeader(http.StatusNotFound)
+				return
+			}
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("ok"))
+	}
+}
+
+// Vault return the parameters of the client side encryption
+// @Tags Read
+// @Summary Returns the salt and the master password check value
+// @Description The salt is generated on the first request. The check value is empty until the client sets it
+// @ID readVault
+// @Produce json
+// @Success 200 {object} VaultParams
+// @Failure 500 {string} string ""
+// @Router /api/vault [get]
+func (h *Handler) Vault() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		params, err := h.store.VaultParams(r.Context(), user.UserID)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		result, err := json.Marshal(params)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			rw.Write([]byte("invalid serialization"))
+			return
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(result)
+	}
+}
+
+// SetVaultCheck save the master password check value
+// @Tags Add
+// @Summary Saving the master password check value
+// @Description The value can be set only once, the server does not know the master password and can not validate it.
+// @ID addVaultCheck
+// @Accept json
+// @Produce plain
+// @Param vault_data body VaultParams true "Vault object, only check is used"
+// @Success 200 {string} string "ok"
+// @Failure 400 {string} string "invalid deserialization"
+// @Failure 409 {string} string ""
+// @Failure 500 {string} string "read data problem"
+// @Router /api/vault [put]
+func (h *Handler) SetVaultCheck() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		reqBody, err := io.ReadAll(r.Body)
+		defer r.Body.Close()
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			rw.Write([]byte("read data problem"))
+			return
+		}
+
+		// parse message
+		var params models.VaultParams
+		err = json.Unmarshal(reqBody, &params)
+		if err != nil || params.Check == "" {
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte("invalid deserialization"))
+			return
+		}
+
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		err = h.store.SetVaultCheck(r.Context(), user.UserID, params.Check)
+		if err != nil {
+			if errors.Is(err, storage.ErrVaultInitialized) {
+				rw.WriteHeader(http.StatusConflict)
+				return
+			}
 			rw.WriteHeader(http.StatusInternalServerError)
 			return
 		}
@@ -2,26 +2,48 @@ package httpserver
 
 import (
 	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/jackc/pgx/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/ssh"
 
 	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/audit"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/certbind"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/jwt"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/password"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/signing"
 	"github.com/ncyellow/GophKeeper/internal/server/config"
 	mockjwt "github.com/ncyellow/GophKeeper/internal/server/mocks/auth/jwt"
 	mockstorage "github.com/ncyellow/GophKeeper/internal/server/mocks/storage"
+	"github.com/ncyellow/GophKeeper/internal/server/storage"
+	"github.com/ncyellow/GophKeeper/internal/totp"
 )
 
+// otpSecret the secret of the second factor in tests
+const otpSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
 type want struct {
 	statusCode int
 	body       string
@@ -56,7 +78,10 @@ func (suite *HandlersSuite) SetupTest() {
 	parser := mockjwt.NewMockParser(ctrl)
 	suite.parser = parser
 
-	r := NewRouter(&conf, store, parser)
+	keys, err := signing.Open(signing.Options{})
+	suite.Require().NoError(err)
+
+	r := NewRouter(&conf, store, keys, parser, nil)
 	suite.ts = httptest.NewServer(r)
 }
 
@@ -101,6 +126,37 @@ func (suite *HandlersSuite) runTableTests(testList []tests) {
 	}
 }
 
+// sessionOf gomock matcher for models.Session of the user. Session ids are random
+type sessionOf int64
+
+func (m sessionOf) Matches(x interface{}) bool {
+	session, ok := x.(models.Session)
+	return ok && session.UserID == int64(m) && session.ID != ""
+}
+
+func (m sessionOf) String() string {
+	return fmt.Sprintf("session of user %d", int64(m))
+}
+
+// userWithPassword gomock matcher for models.User. Hashes have a random salt, so we verify the password instead of comparing
+type userWithPassword struct {
+	login    string
+	password string
+}
+
+func (m userWithPassword) Matches(x interface{}) bool {
+	user, ok := x.(models.User)
+	if !ok || user.Login != m.login {
+		return false
+	}
+	ok, _, err := password.Verify(m.password, user.Password)
+	return err == nil && ok
+}
+
+func (m userWithPassword) String() string {
+	return fmt.Sprintf("user %s with the password %s", m.login, m.password)
+}
+
 // TestRegisterHandler base registration tests
 func (suite *HandlersSuite) TestRegisterHandler() {
 	testData := []tests{
@@ -137,17 +193,13 @@ func (suite *HandlersSuite) TestRegisterHandler() {
 
 			mockExpected: func() {
 				// Registration successful, returned ID without errors
-				suite.store.EXPECT().Register(gomock.Any(), models.User{
-					Login:    "login",
-					Password: "5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8", // sha1
+				suite.store.EXPECT().Register(gomock.Any(), userWithPassword{
+					login:    "login",
+					password: "password",
 				}).Return(int64(1), nil)
 
-				// After registration - authentication
-				suite.store.EXPECT().User(gomock.Any(), "login",
-					"5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8").Return(&models.User{
-					Login:    "login",
-					Password: "5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8",
-				}, nil)
+				// After registration - the session of the new user
+				suite.store.EXPECT().CreateSession(gomock.Any(), sessionOf(1), gomock.Any()).Return(nil)
 			},
 			want: want{
 				statusCode: http.StatusOK,
@@ -162,10 +214,9 @@ func (suite *HandlersSuite) TestRegisterHandler() {
 			body:        []byte(`{"login": "login", "password": "password"}`),
 
 			mockExpected: func() {
-				// Registration successful, returned ID without errors
-				suite.store.EXPECT().Register(gomock.Any(), models.User{
-					Login:    "login",
-					Password: "5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8", // sha1
+				suite.store.EXPECT().Register(gomock.Any(), userWithPassword{
+					login:    "login",
+					password: "password",
 				}).Return(int64(0), errors.New("SomeError"))
 			},
 			want: want{
@@ -179,6 +230,22 @@ func (suite *HandlersSuite) TestRegisterHandler() {
 
 // TestRegisterHandler base authentication tests
 func (suite *HandlersSuite) TestSignIn() {
+	hash, err := password.Hash("password")
+	suite.Require().NoError(err)
+	code, err := totp.Code(otpSecret, time.Now())
+	suite.Require().NoError(err)
+
+	notLocked := func() {
+		suite.store.EXPECT().LoginRetryAfter(gomock.Any(), []string{"login:login", "ip:127.0.0.1"}).Return(time.Duration(0), nil)
+	}
+	failed := func(failures int) {
+		suite.store.EXPECT().AddLoginFailure(gomock.Any(), "login:login", gomock.Any()).Return(failures, nil)
+		suite.store.EXPECT().AddLoginFailure(gomock.Any(), "ip:127.0.0.1", gomock.Any()).Return(failures, nil)
+	}
+	audited := func(userID int64, action string) {
+		suite.store.EXPECT().AddAuditEvent(gomock.Any(), userID, models.AuditEvent{Actor: "login", Action: action, IP: "127.0.0.1"}).Return(nil)
+	}
+
 	testData := []tests{
 		{
 			name:         "signin incorrect user data",
@@ -200,17 +267,207 @@ func (suite *HandlersSuite) TestSignIn() {
 			body:        []byte(`{"login": "login", "password": "password"}`),
 
 			mockExpected: func() {
-				suite.store.EXPECT().User(gomock.Any(), "login",
-					"5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8").Return(&models.User{
+				notLocked()
+				suite.store.EXPECT().User(gomock.Any(), "login").Return(&models.User{
+					UserID:   1,
+					Login:    "login",
+					Password: hash,
+				}, nil)
+				suite.store.EXPECT().TOTP(gomock.Any(), int64(1)).Return(&models.TOTP{}, nil)
+				suite.store.EXPECT().ResetLoginFailures(gomock.Any(), "login:login").Return(nil)
+				suite.store.EXPECT().CreateSession(gomock.Any(), sessionOf(1), gomock.Any()).Return(nil)
+				audited(1, models.AuditSignIn)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       "",
+			},
+		},
+		{
+			name:        "signin with legacy sha1 hash upgrades it",
+			request:     "/api/signin",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        []byte(`{"login": "login", "password": "password"}`),
+
+			mockExpected: func() {
+				notLocked()
+				suite.store.EXPECT().User(gomock.Any(), "login").Return(&models.User{
+					UserID:   1,
+					Login:    "login",
+					Password: "5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8", // sha1
+				}, nil)
+				suite.store.EXPECT().TOTP(gomock.Any(), int64(1)).Return(&models.TOTP{}, nil)
+				suite.store.EXPECT().UpdatePassword(gomock.Any(), int64(1), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ int64, hash string) error {
+						ok, rehash, err := password.Verify("password", hash)
+						suite.NoError(err)
+						suite.True(ok)
+						suite.False(rehash)
+						return nil
+					})
+				suite.store.EXPECT().ResetLoginFailures(gomock.Any(), "login:login").Return(nil)
+				suite.store.EXPECT().CreateSession(gomock.Any(), sessionOf(1), gomock.Any()).Return(nil)
+				audited(1, models.AuditSignIn)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       "",
+			},
+		},
+		{
+			name:        "signin without the second factor code",
+			request:     "/api/signin",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        []byte(`{"login": "login", "password": "password"}`),
+
+			mockExpected: func() {
+				notLocked()
+				suite.store.EXPECT().User(gomock.Any(), "login").Return(&models.User{
+					UserID:   1,
+					Login:    "login",
+					Password: hash,
+				}, nil)
+				suite.store.EXPECT().TOTP(gomock.Any(), int64(1)).Return(&models.TOTP{Secret: otpSecret, Enabled: true}, nil)
+			},
+			want: want{
+				statusCode: http.StatusPreconditionRequired,
+				body:       "otp required",
+			},
+		},
+		{
+			name:        "signin with the second factor code",
+			request:     "/api/signin",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        []byte(fmt.Sprintf(`{"login": "login", "password": "password", "otp": "%s"}`, code)),
+
+			mockExpected: func() {
+				notLocked()
+				suite.store.EXPECT().User(gomock.Any(), "login").Return(&models.User{
+					UserID:   1,
+					Login:    "login",
+					Password: hash,
+				}, nil)
+				suite.store.EXPECT().TOTP(gomock.Any(), int64(1)).Return(&models.TOTP{Secret: otpSecret, Enabled: true}, nil)
+				suite.store.EXPECT().UseTOTPCounter(gomock.Any(), int64(1), gomock.Any()).Return(true, nil)
+				suite.store.EXPECT().ResetLoginFailures(gomock.Any(), "login:login").Return(nil)
+				suite.store.EXPECT().CreateSession(gomock.Any(), sessionOf(1), gomock.Any()).Return(nil)
+				audited(1, models.AuditSignIn)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       "",
+			},
+		},
+		{
+			name:        "signin with the already used second factor code",
+			request:     "/api/signin",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        []byte(fmt.Sprintf(`{"login": "login", "password": "password", "otp": "%s"}`, code)),
+
+			mockExpected: func() {
+				notLocked()
+				suite.store.EXPECT().User(gomock.Any(), "login").Return(&models.User{
+					UserID:   1,
+					Login:    "login",
+					Password: hash,
+				}, nil)
+				suite.store.EXPECT().TOTP(gomock.Any(), int64(1)).Return(&models.TOTP{Secret: otpSecret, Enabled: true}, nil)
+				suite.store.EXPECT().UseTOTPCounter(gomock.Any(), int64(1), gomock.Any()).Return(false, nil)
+				failed(1)
+				audited(1, models.AuditSignInFailed)
+			},
+			want: want{
+				statusCode: http.StatusUnauthorized,
+				body:       "invalid login or password",
+			},
+		},
+		{
+			name:        "signin with a recovery code",
+			request:     "/api/signin",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        []byte(`{"login": "login", "password": "password", "otp": "abcde-fghij"}`),
+
+			mockExpected: func() {
+				notLocked()
+				suite.store.EXPECT().User(gomock.Any(), "login").Return(&models.User{
+					UserID:   1,
 					Login:    "login",
-					Password: "5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8",
+					Password: hash,
 				}, nil)
+				suite.store.EXPECT().TOTP(gomock.Any(), int64(1)).Return(&models.TOTP{Secret: otpSecret, Enabled: true}, nil)
+				suite.store.EXPECT().UseRecoveryCode(gomock.Any(), int64(1), fmt.Sprintf("%x", sha256.Sum256([]byte("abcdefghij")))).Return(true, nil)
+				suite.store.EXPECT().ResetLoginFailures(gomock.Any(), "login:login").Return(nil)
+				suite.store.EXPECT().CreateSession(gomock.Any(), sessionOf(1), gomock.Any()).Return(nil)
+				audited(1, models.AuditSignIn)
 			},
 			want: want{
 				statusCode: http.StatusOK,
 				body:       "",
 			},
 		},
+		{
+			name:        "signin with wrong password",
+			request:     "/api/signin",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        []byte(`{"login": "login", "password": "wrong"}`),
+
+			mockExpected: func() {
+				notLocked()
+				suite.store.EXPECT().User(gomock.Any(), "login").Return(&models.User{
+					UserID:   1,
+					Login:    "login",
+					Password: hash,
+				}, nil)
+				failed(1)
+				audited(1, models.AuditSignInFailed)
+			},
+			want: want{
+				statusCode: http.StatusUnauthorized,
+				body:       "invalid login or password",
+			},
+		},
+		{
+			name:        "signin with unknown login",
+			request:     "/api/signin",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        []byte(`{"login": "login", "password": "password"}`),
+
+			mockExpected: func() {
+				notLocked()
+				suite.store.EXPECT().User(gomock.Any(), "login").Return(nil, pgx.ErrNoRows)
+				// The sixth failure is delayed, but the lockout event is saved only for existing users
+				failed(6)
+				suite.store.EXPECT().LockLogin(gomock.Any(), "login:login", time.Second).Return(nil)
+				audited(0, models.AuditSignInFailed)
+			},
+			want: want{
+				statusCode: http.StatusUnauthorized,
+				body:       "invalid login or password",
+			},
+		},
+		{
+			name:        "signin when locked",
+			request:     "/api/signin",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        []byte(`{"login": "login", "password": "password"}`),
+
+			mockExpected: func() {
+				suite.store.EXPECT().LoginRetryAfter(gomock.Any(), []string{"login:login", "ip:127.0.0.1"}).Return(1500*time.Millisecond, nil)
+				audited(0, models.AuditSignInFailed)
+			},
+			want: want{
+				statusCode: http.StatusTooManyRequests,
+				body:       "too many attempts",
+			},
+		},
 		{
 			name:        "signin with any error",
 			request:     "/api/signin",
@@ -219,8 +476,8 @@ func (suite *HandlersSuite) TestSignIn() {
 			body:        []byte(`{"login": "login", "password": "password"}`),
 
 			mockExpected: func() {
-				suite.store.EXPECT().User(gomock.Any(), "login",
-					"5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8").Return(nil, errors.New("any error"))
+				notLocked()
+				suite.store.EXPECT().User(gomock.Any(), "login").Return(nil, errors.New("any error"))
 			},
 			want: want{
 				statusCode: http.StatusUnauthorized,
@@ -231,69 +488,83 @@ func (suite *HandlersSuite) TestSignIn() {
 	suite.runTableTests(testData)
 }
 
-// TestCard сard reading tests
-func (suite *HandlersSuite) TestCard() {
-	userID := int64(1)
-	cardID := "testID"
-	url := fmt.Sprintf("/api/card/%s", cardID)
-	defaultCard := &models.Card{
-		UserID:   userID,
-		ID:       cardID,
-		FIO:      "fio",
-		Number:   "number",
-		Date:     "date",
-		CVV:      "cvv",
-		MetaInfo: "metainfo",
-	}
-	byteCard, _ := json.Marshal(defaultCard)
+// TestRefresh tests for refreshing the tokens
+func (suite *HandlersSuite) TestRefresh() {
+	url := "/api/token/refresh"
+	secretHash := fmt.Sprintf("%x", sha256.Sum256([]byte("secret")))
 
 	testData := []tests{
 		{
-			name:        "read card successfully",
+			name:         "refresh without token",
+			request:      url,
+			requestType:  "POST",
+			contentType:  "application/json",
+			body:         []byte(`{}`),
+			mockExpected: nil,
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "invalid deserialization",
+			},
+		},
+		{
+			name:        "refresh with success",
 			request:     url,
-			requestType: "GET",
-			contentType: "",
-			body:        nil,
+			requestType: "POST",
+			contentType: "application/json",
+			body:        []byte(`{"refresh_token": "session.secret"}`),
 			mockExpected: func() {
-				user := &models.User{
-					UserID: userID,
-					Login:  "login",
-				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
-				suite.store.EXPECT().Card(gomock.Any(), user.UserID, cardID).
-					Return(defaultCard, nil)
+				suite.store.EXPECT().RefreshSession(gomock.Any(), "session", secretHash, gomock.Any(), gomock.Any()).
+					Return(&models.User{UserID: 1, Login: "login"}, nil)
 			},
 			want: want{
 				statusCode: http.StatusOK,
-				body:       string(byteCard),
+				body:       "",
 			},
 		},
 		{
-			name:        "read card not found",
+			name:        "refresh with used token revokes the session",
 			request:     url,
-			requestType: "GET",
-			contentType: "",
-			body:        nil,
+			requestType: "POST",
+			contentType: "application/json",
+			body:        []byte(`{"refresh_token": "session.secret"}`),
 			mockExpected: func() {
-				user := &models.User{
-					UserID: userID,
-					Login:  "login",
-				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
-				suite.store.EXPECT().Card(gomock.Any(), user.UserID, cardID).
+				suite.store.EXPECT().RefreshSession(gomock.Any(), "session", secretHash, gomock.Any(), gomock.Any()).
 					Return(nil, pgx.ErrNoRows)
+				suite.store.EXPECT().RevokeReusedSession(gomock.Any(), "session", secretHash).Return(true, nil)
 			},
 			want: want{
-				statusCode: http.StatusNotFound,
+				statusCode: http.StatusUnauthorized,
 				body:       "",
 			},
 		},
 		{
-			name:        "read card internal error",
+			name:        "refresh with internal error",
 			request:     url,
-			requestType: "GET",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        []byte(`{"refresh_token": "session.secret"}`),
+			mockExpected: func() {
+				suite.store.EXPECT().RefreshSession(gomock.Any(), "session", secretHash, gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("some error"))
+			},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+				body:       "",
+			},
+		},
+	}
+	suite.runTableTests(testData)
+}
+
+// TestLogout tests for revoking the current session
+func (suite *HandlersSuite) TestLogout() {
+	userID := int64(1)
+
+	testData := []tests{
+		{
+			name:        "logout successfully",
+			request:     "/api/logout",
+			requestType: "POST",
 			contentType: "",
 			body:        nil,
 			mockExpected: func() {
@@ -301,13 +572,27 @@ func (suite *HandlersSuite) TestCard() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
-				suite.store.EXPECT().Card(gomock.Any(), user.UserID, cardID).
-					Return(nil, errors.New("some error"))
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+				suite.store.EXPECT().RevokeSession(gomock.Any(), user.UserID, "session").Return(nil)
 			},
 			want: want{
-				statusCode: http.StatusInternalServerError,
+				statusCode: http.StatusOK,
+				body:       "ok",
+			},
+		},
+		{
+			name:        "logout of the revoked session",
+			request:     "/api/logout",
+			requestType: "POST",
+			contentType: "",
+			body:        nil,
+			mockExpected: func() {
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: "login", SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(nil, pgx.ErrNoRows)
+			},
+			want: want{
+				statusCode: http.StatusUnauthorized,
 				body:       "",
 			},
 		},
@@ -315,25 +600,22 @@ func (suite *HandlersSuite) TestCard() {
 	suite.runTableTests(testData)
 }
 
-// TestLogin login request tests
-func (suite *HandlersSuite) TestLogin() {
+// TestSessions tests for reading the sessions of the user
+func (suite *HandlersSuite) TestSessions() {
 	userID := int64(1)
-	loginID := "testID"
-	url := fmt.Sprintf("/api/login/%s", loginID)
-
-	defaultLogin := &models.Login{
-		UserID:   userID,
-		ID:       loginID,
-		Login:    "login",
-		Password: "password",
-		MetaInfo: "metainfo",
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sessions := []models.Session{
+		{ID: "session", UserAgent: "client", CreatedAt: created, RefreshedAt: created, ExpiresAt: created},
+		{ID: "other", UserAgent: "client", CreatedAt: created, RefreshedAt: created, ExpiresAt: created},
 	}
-	byteLogin, _ := json.Marshal(defaultLogin)
+	expected := []models.Session{sessions[0], sessions[1]}
+	expected[0].Current = true
+	byteSessions, _ := json.Marshal(expected)
 
 	testData := []tests{
 		{
-			name:        "read login successfully",
-			request:     url,
+			name:        "read sessions successfully",
+			request:     "/api/sessions",
 			requestType: "GET",
 			contentType: "",
 			body:        nil,
@@ -342,20 +624,19 @@ func (suite *HandlersSuite) TestLogin() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
-				suite.store.EXPECT().Login(gomock.Any(), user.UserID, loginID).
-					Return(defaultLogin, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+				suite.store.EXPECT().Sessions(gomock.Any(), user.UserID).Return(sessions, nil)
 			},
 			want: want{
 				statusCode: http.StatusOK,
-				body:       string(byteLogin),
+				body:       string(byteSessions),
 			},
 		},
 		{
-			name:        "read login not found",
-			request:     url,
-			requestType: "GET",
+			name:        "revoke session successfully",
+			request:     "/api/sessions/other",
+			requestType: "DELETE",
 			contentType: "",
 			body:        nil,
 			mockExpected: func() {
@@ -363,20 +644,21 @@ func (suite *HandlersSuite) TestLogin() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
-				suite.store.EXPECT().Login(gomock.Any(), user.UserID, loginID).
-					Return(nil, pgx.ErrNoRows)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+				suite.store.EXPECT().RevokeSession(gomock.Any(), user.UserID, "other").Return(nil)
+				suite.store.EXPECT().AddAuditEvent(gomock.Any(), user.UserID,
+					models.AuditEvent{Actor: "login", Action: models.AuditRevokeSession, IP: "127.0.0.1"}).Return(nil)
 			},
 			want: want{
-				statusCode: http.StatusNotFound,
-				body:       "",
+				statusCode: http.StatusOK,
+				body:       "ok",
 			},
 		},
 		{
-			name:        "read login internal error",
-			request:     url,
-			requestType: "GET",
+			name:        "revoke unknown session",
+			request:     "/api/sessions/unknown",
+			requestType: "DELETE",
 			contentType: "",
 			body:        nil,
 			mockExpected: func() {
@@ -384,13 +666,12 @@ func (suite *HandlersSuite) TestLogin() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
-				suite.store.EXPECT().Login(gomock.Any(), user.UserID, loginID).
-					Return(nil, errors.New("some error"))
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+				suite.store.EXPECT().RevokeSession(gomock.Any(), user.UserID, "unknown").Return(pgx.ErrNoRows)
 			},
 			want: want{
-				statusCode: http.StatusInternalServerError,
+				statusCode: http.StatusNotFound,
 				body:       "",
 			},
 		},
@@ -398,17 +679,184 @@ func (suite *HandlersSuite) TestLogin() {
 	suite.runTableTests(testData)
 }
 
-// TestText text request tests
-func (suite *HandlersSuite) TestText() {
+// TestCard сard reading tests
+func (suite *HandlersSuite) TestCard() {
 	userID := int64(1)
-	textID := "testID"
-	url := fmt.Sprintf("/api/txt/%s", textID)
+	cardID := "testID"
+	url := fmt.Sprintf("/api/card/%s", cardID)
+	defaultCard := &models.Card{
+		UserID: userID,
+		ID:     cardID,
+		FIO:    "fio",
+		Number: "number",
+		Date:   "date",
+		CVV:    "cvv",
+		Note:   "note",
+	}
+	byteCard, _ := json.Marshal(defaultCard)
 
-	defaultText := &models.Text{
+	testData := []tests{
+		{
+			name:        "read card successfully",
+			request:     url,
+			requestType: "GET",
+			contentType: "",
+			body:        nil,
+			mockExpected: func() {
+				user := &models.User{
+					UserID: userID,
+					Login:  "login",
+				}
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+				suite.store.EXPECT().Card(gomock.Any(), user.UserID, cardID).
+					Return(defaultCard, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       string(byteCard),
+			},
+		},
+		{
+			name:        "read card not found",
+			request:     url,
+			requestType: "GET",
+			contentType: "",
+			body:        nil,
+			mockExpected: func() {
+				user := &models.User{
+					UserID: userID,
+					Login:  "login",
+				}
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+				suite.store.EXPECT().Card(gomock.Any(), user.UserID, cardID).
+					Return(nil, pgx.ErrNoRows)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+				body:       "",
+			},
+		},
+		{
+			name:        "read card internal error",
+			request:     url,
+			requestType: "GET",
+			contentType: "",
+			body:        nil,
+			mockExpected: func() {
+				user := &models.User{
+					UserID: userID,
+					Login:  "login",
+				}
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+				suite.store.EXPECT().Card(gomock.Any(), user.UserID, cardID).
+					Return(nil, errors.New("some error"))
+			},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+				body:       "",
+			},
+		},
+	}
+	suite.runTableTests(testData)
+}
+
+// TestLogin login request tests
+func (suite *HandlersSuite) TestLogin() {
+	userID := int64(1)
+	loginID := "testID"
+	url := fmt.Sprintf("/api/login/%s", loginID)
+
+	defaultLogin := &models.Login{
 		UserID:   userID,
-		ID:       textID,
-		Content:  "content",
-		MetaInfo: "metainfo",
+		ID:       loginID,
+		Login:    "login",
+		Password: "password",
+		Note:     "note",
+	}
+	byteLogin, _ := json.Marshal(defaultLogin)
+
+	testData := []tests{
+		{
+			name:        "read login successfully",
+			request:     url,
+			requestType: "GET",
+			contentType: "",
+			body:        nil,
+			mockExpected: func() {
+				user := &models.User{
+					UserID: userID,
+					Login:  "login",
+				}
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+				suite.store.EXPECT().Login(gomock.Any(), user.UserID, loginID).
+					Return(defaultLogin, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       string(byteLogin),
+			},
+		},
+		{
+			name:        "read login not found",
+			request:     url,
+			requestType: "GET",
+			contentType: "",
+			body:        nil,
+			mockExpected: func() {
+				user := &models.User{
+					UserID: userID,
+					Login:  "login",
+				}
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+				suite.store.EXPECT().Login(gomock.Any(), user.UserID, loginID).
+					Return(nil, pgx.ErrNoRows)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+				body:       "",
+			},
+		},
+		{
+			name:        "read login internal error",
+			request:     url,
+			requestType: "GET",
+			contentType: "",
+			body:        nil,
+			mockExpected: func() {
+				user := &models.User{
+					UserID: userID,
+					Login:  "login",
+				}
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+				suite.store.EXPECT().Login(gomock.Any(), user.UserID, loginID).
+					Return(nil, errors.New("some error"))
+			},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+				body:       "",
+			},
+		},
+	}
+	suite.runTableTests(testData)
+}
+
+// TestText text request tests
+func (suite *HandlersSuite) TestText() {
+	userID := int64(1)
+	textID := "testID"
+	url := fmt.Sprintf("/api/txt/%s", textID)
+
+	defaultText := &models.Text{
+		UserID:  userID,
+		ID:      textID,
+		Content: "content",
+		Note:    "note",
 	}
 	byteText, _ := json.Marshal(defaultText)
 
@@ -424,8 +872,8 @@ func (suite *HandlersSuite) TestText() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 				suite.store.EXPECT().Text(gomock.Any(), user.UserID, textID).
 					Return(defaultText, nil)
 			},
@@ -445,8 +893,8 @@ func (suite *HandlersSuite) TestText() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 				suite.store.EXPECT().Text(gomock.Any(), user.UserID, textID).
 					Return(nil, pgx.ErrNoRows)
 			},
@@ -466,8 +914,8 @@ func (suite *HandlersSuite) TestText() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 				suite.store.EXPECT().Text(gomock.Any(), user.UserID, textID).
 					Return(nil, errors.New("some error"))
 			},
@@ -487,10 +935,10 @@ func (suite *HandlersSuite) TestBin() {
 	url := fmt.Sprintf("/api/bin/%s", binID)
 
 	defaultBin := &models.Binary{
-		UserID:   userID,
-		ID:       binID,
-		Data:     []byte("data"),
-		MetaInfo: "metainfo",
+		UserID: userID,
+		ID:     binID,
+		Data:   []byte("data"),
+		Note:   "note",
 	}
 	byteBin, _ := json.Marshal(defaultBin)
 
@@ -506,8 +954,8 @@ func (suite *HandlersSuite) TestBin() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 				suite.store.EXPECT().Binary(gomock.Any(), user.UserID, binID).
 					Return(defaultBin, nil)
 			},
@@ -527,8 +975,8 @@ func (suite *HandlersSuite) TestBin() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 				suite.store.EXPECT().Binary(gomock.Any(), user.UserID, binID).
 					Return(nil, pgx.ErrNoRows)
 			},
@@ -548,8 +996,8 @@ func (suite *HandlersSuite) TestBin() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 				suite.store.EXPECT().Binary(gomock.Any(), user.UserID, binID).
 					Return(nil, errors.New("some error"))
 			},
@@ -569,12 +1017,12 @@ func (suite *HandlersSuite) TestAddCard() {
 	url := "/api/card"
 
 	defaultCard := &models.Card{
-		ID:       cardID,
-		FIO:      "fio",
-		Number:   "number",
-		Date:     "date",
-		CVV:      "cvv",
-		MetaInfo: "metainfo",
+		ID:     cardID,
+		FIO:    "fio",
+		Number: "4111111111111111",
+		Date:   "12/30",
+		CVV:    "123",
+		Note:   "note",
 	}
 
 	byteCard, _ := json.Marshal(defaultCard)
@@ -591,8 +1039,8 @@ func (suite *HandlersSuite) TestAddCard() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 				suite.store.EXPECT().AddCard(gomock.Any(), user.UserID, *defaultCard).
 					Return(nil)
 			},
@@ -612,8 +1060,8 @@ func (suite *HandlersSuite) TestAddCard() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 				suite.store.EXPECT().AddCard(gomock.Any(), user.UserID, *defaultCard).
 					Return(errors.New("some error"))
 			},
@@ -633,14 +1081,33 @@ func (suite *HandlersSuite) TestAddCard() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 			},
 			want: want{
 				statusCode: http.StatusBadRequest,
 				body:       "invalid deserialization",
 			},
 		},
+		{
+			name:        "add card with the number failing the checksum",
+			request:     url,
+			requestType: "POST",
+			contentType: "",
+			body:        []byte(`{"id": "visa", "number": "4111 1111 1111 1112", "date": "12/30", "cvv": "123"}`),
+			mockExpected: func() {
+				user := &models.User{
+					UserID: userID,
+					Login:  "login",
+				}
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "invalid card: the number fails the checksum, check it for typos",
+			},
+		},
 	}
 	suite.runTableTests(testData)
 }
@@ -663,8 +1130,8 @@ func (suite *HandlersSuite) TestDelCard() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 				suite.store.EXPECT().DeleteCard(gomock.Any(), user.UserID, cardID).
 					Return(nil)
 			},
@@ -684,8 +1151,8 @@ func (suite *HandlersSuite) TestDelCard() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 				suite.store.EXPECT().DeleteCard(gomock.Any(), user.UserID, cardID).
 					Return(errors.New("some errors"))
 			},
@@ -708,7 +1175,7 @@ func (suite *HandlersSuite) TestAddLogin() {
 		ID:       loginID,
 		Login:    "login",
 		Password: "password",
-		MetaInfo: "metainfo",
+		Note:     "note",
 	}
 	byteLogin, _ := json.Marshal(defaultLogin)
 
@@ -724,8 +1191,8 @@ func (suite *HandlersSuite) TestAddLogin() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 				suite.store.EXPECT().AddLogin(gomock.Any(), user.UserID, *defaultLogin).
 					Return(nil)
 			},
@@ -745,8 +1212,8 @@ func (suite *HandlersSuite) TestAddLogin() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 				suite.store.EXPECT().AddLogin(gomock.Any(), user.UserID, *defaultLogin).
 					Return(errors.New("some error"))
 			},
@@ -766,8 +1233,8 @@ func (suite *HandlersSuite) TestAddLogin() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 			},
 			want: want{
 				statusCode: http.StatusBadRequest,
@@ -796,8 +1263,8 @@ func (suite *HandlersSuite) TestDelLogin() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 				suite.store.EXPECT().DeleteLogin(gomock.Any(), user.UserID, loginID).
 					Return(nil)
 			},
@@ -817,8 +1284,8 @@ func (suite *HandlersSuite) TestDelLogin() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 				suite.store.EXPECT().DeleteLogin(gomock.Any(), user.UserID, loginID).
 					Return(errors.New("some errors"))
 			},
@@ -838,9 +1305,9 @@ func (suite *HandlersSuite) TestAddText() {
 	url := "/api/txt"
 
 	defaultText := &models.Text{
-		ID:       textID,
-		Content:  "content",
-		MetaInfo: "metainfo",
+		ID:      textID,
+		Content: "content",
+		Note:    "note",
 	}
 	byteText, _ := json.Marshal(defaultText)
 
@@ -856,8 +1323,8 @@ func (suite *HandlersSuite) TestAddText() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 				suite.store.EXPECT().AddText(gomock.Any(), user.UserID, *defaultText).
 					Return(nil)
 			},
@@ -877,8 +1344,8 @@ func (suite *HandlersSuite) TestAddText() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 				suite.store.EXPECT().AddText(gomock.Any(), user.UserID, *defaultText).
 					Return(errors.New("some error"))
 			},
@@ -898,8 +1365,8 @@ func (suite *HandlersSuite) TestAddText() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 			},
 			want: want{
 				statusCode: http.StatusBadRequest,
@@ -928,8 +1395,8 @@ func (suite *HandlersSuite) TestDelText() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 				suite.store.EXPECT().DeleteText(gomock.Any(), user.UserID, textID).
 					Return(nil)
 			},
@@ -949,8 +1416,8 @@ func (suite *HandlersSuite) TestDelText() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 				suite.store.EXPECT().DeleteText(gomock.Any(), user.UserID, textID).
 					Return(errors.New("some errors"))
 			},
@@ -970,9 +1437,9 @@ func (suite *HandlersSuite) TestAddBin() {
 	url := "/api/bin"
 
 	defaultBin := &models.Binary{
-		ID:       binID,
-		Data:     []byte("data"),
-		MetaInfo: "metainfo",
+		ID:   binID,
+		Data: []byte("data"),
+		Note: "note",
 	}
 	byteBin, _ := json.Marshal(defaultBin)
 
@@ -988,8 +1455,8 @@ func (suite *HandlersSuite) TestAddBin() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 				suite.store.EXPECT().AddBinary(gomock.Any(), user.UserID, *defaultBin).
 					Return(nil)
 			},
@@ -1009,8 +1476,8 @@ func (suite *HandlersSuite) TestAddBin() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 				suite.store.EXPECT().AddBinary(gomock.Any(), user.UserID, *defaultBin).
 					Return(errors.New("some error"))
 			},
@@ -1030,8 +1497,8 @@ func (suite *HandlersSuite) TestAddBin() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 			},
 			want: want{
 				statusCode: http.StatusBadRequest,
@@ -1042,6 +1509,150 @@ func (suite *HandlersSuite) TestAddBin() {
 	suite.runTableTests(testData)
 }
 
+// TestBinaryContent tests for the chunked upload and the download of the binary content
+func (suite *HandlersSuite) TestBinaryContent() {
+	user := &models.User{
+		UserID: 1,
+		Login:  "login",
+	}
+	signedIn := func() {
+		suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+		suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+	}
+	upload := &models.BinaryUpload{ID: "up", BinaryID: "photo"}
+	received := &models.BinaryUpload{ID: "up", BinaryID: "photo", Offset: 4, Chunks: 1}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("chunk", "chunk")
+	part.Write([]byte("data"))
+	form.Close()
+
+	testData := []tests{
+		{
+			name:        "start the upload",
+			request:     "/api/bin/photo/uploads",
+			requestType: "POST",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().StartUpload(gomock.Any(), int64(1), "photo").Return(upload, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"id":"up","bin_id":"photo","offset":0,"chunks":0}`,
+			},
+		},
+		{
+			name:        "upload of another binary",
+			request:     "/api/bin/video/uploads/up",
+			requestType: "GET",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().BinaryUpload(gomock.Any(), int64(1), "up").Return(upload, nil)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+				body:       "",
+			},
+		},
+		{
+			name:        "upload the chunk",
+			request:     "/api/bin/photo/uploads/up?seq=0",
+			requestType: "PUT",
+			contentType: form.FormDataContentType(),
+			body:        body.Bytes(),
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().BinaryUpload(gomock.Any(), int64(1), "up").Return(upload, nil)
+				suite.store.EXPECT().AddChunk(gomock.Any(), int64(1), models.BinaryChunk{Upload: "up", Seq: 0, Data: []byte("data")}).
+					Return(received, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"id":"up","bin_id":"photo","offset":4,"chunks":1}`,
+			},
+		},
+		{
+			name:        "the chunk is already received",
+			request:     "/api/bin/photo/uploads/up?seq=0",
+			requestType: "PUT",
+			contentType: form.FormDataContentType(),
+			body:        body.Bytes(),
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().BinaryUpload(gomock.Any(), int64(1), "up").Return(received, nil).Times(2)
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       `{"id":"up","bin_id":"photo","offset":4,"chunks":1}`,
+			},
+		},
+		{
+			name:        "complete the changed binary",
+			request:     "/api/bin/photo/uploads/up/complete",
+			requestType: "POST",
+			body:        []byte(`{"version":2}`),
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().CompleteUpload(gomock.Any(), int64(1), "photo", "up", int64(2)).
+					Return(int64(0), storage.ErrVersionConflict)
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       "version conflict",
+			},
+		},
+		{
+			name:        "complete the upload",
+			request:     "/api/bin/photo/uploads/up/complete",
+			requestType: "POST",
+			body:        []byte(`{"version":2}`),
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().CompleteUpload(gomock.Any(), int64(1), "photo", "up", int64(2)).Return(int64(3), nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"id":"photo","version":3}`,
+			},
+		},
+		{
+			name:        "download the content",
+			request:     "/api/bin/photo/content",
+			requestType: "GET",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().Binary(gomock.Any(), int64(1), "photo").
+					Return(&models.Binary{ID: "photo", ContentID: "up", Size: 4, Version: 3}, nil)
+				suite.store.EXPECT().BinaryChunk(gomock.Any(), int64(1), "up", int64(0)).Return([]byte("data"), nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       "data",
+			},
+		},
+	}
+	suite.runTableTests(testData)
+
+	// The download is resumed from the offset while the content is the same
+	signedIn()
+	suite.store.EXPECT().Binary(gomock.Any(), int64(1), "photo").
+		Return(&models.Binary{ID: "photo", ContentID: "up", Size: 4, Version: 3}, nil)
+	suite.store.EXPECT().BinaryChunk(gomock.Any(), int64(1), "up", int64(0)).Return([]byte("data"), nil)
+
+	req, err := http.NewRequest("GET", suite.ts.URL+"/api/bin/photo/content", nil)
+	require.NoError(suite.T(), err)
+	req.Header.Set("Range", "bytes=2-")
+	req.Header.Set("If-Range", `"up"`)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(suite.T(), err)
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusPartialContent, resp.StatusCode)
+	assert.Equal(suite.T(), "ta", string(content))
+}
+
 // TestDelBin binary delete tests.
 func (suite *HandlersSuite) TestDelBin() {
 	userID := int64(1)
@@ -1060,8 +1671,8 @@ func (suite *HandlersSuite) TestDelBin() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 				suite.store.EXPECT().DeleteBinary(gomock.Any(), user.UserID, binID).
 					Return(nil)
 			},
@@ -1081,8 +1692,8 @@ func (suite *HandlersSuite) TestDelBin() {
 					UserID: userID,
 					Login:  "login",
 				}
-				suite.parser.EXPECT().ParseToken(gomock.Any(), gomock.Any()).Return(user.Login, nil)
-				suite.store.EXPECT().UserByLogin(gomock.Any(), user.Login).Return(user, nil)
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
 				suite.store.EXPECT().DeleteBinary(gomock.Any(), user.UserID, binID).
 					Return(errors.New("some errors"))
 			},
@@ -1094,3 +1705,1441 @@ func (suite *HandlersSuite) TestDelBin() {
 	}
 	suite.runTableTests(testData)
 }
+
+// TestSSHKey tests for adding, reading and deleting the ssh keys
+func (suite *HandlersSuite) TestSSHKey() {
+	user := &models.User{
+		UserID: 1,
+		Login:  "login",
+	}
+	signedIn := func() {
+		suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+		suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+	}
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	suite.Require().NoError(err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	suite.Require().NoError(err)
+	privateKey := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	sshPublic, err := ssh.NewPublicKey(public)
+	suite.Require().NoError(err)
+	publicKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPublic)))
+
+	body, _ := json.Marshal(models.SSHKey{ID: "deploy", PrivateKey: privateKey, Comment: "ci"})
+	stored := models.SSHKey{ID: "deploy", PrivateKey: privateKey, PublicKey: publicKey, Comment: "ci",
+		Fingerprint: ssh.FingerprintSHA256(sshPublic)}
+	storedBody, _ := json.Marshal(stored)
+
+	testData := []tests{
+		{
+			name:        "add the ssh key, the public key and the fingerprint are derived",
+			request:     "/api/ssh",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        body,
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().AddSSHKey(gomock.Any(), user.UserID, stored).Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       "ok",
+			},
+		},
+		{
+			name:        "add the ssh key with a broken private key",
+			request:     "/api/ssh",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        []byte(`{"id": "deploy", "private_key": "not a key"}`),
+			mockExpected: func() {
+				signedIn()
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "invalid ssh key: the private key must be in the PEM or the OpenSSH format",
+			},
+		},
+		{
+			name:        "read the ssh key",
+			request:     "/api/ssh/deploy",
+			requestType: "GET",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().SSHKey(gomock.Any(), user.UserID, "deploy").Return(&stored, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       string(storedBody),
+			},
+		},
+		{
+			name:        "read the missing ssh key",
+			request:     "/api/ssh/missing",
+			requestType: "GET",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().SSHKey(gomock.Any(), user.UserID, "missing").Return(nil, pgx.ErrNoRows)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+				body:       "",
+			},
+		},
+		{
+			name:        "delete the ssh key",
+			request:     "/api/ssh/deploy",
+			requestType: "DELETE",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().DeleteSSHKey(gomock.Any(), user.UserID, "deploy").Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       "ok",
+			},
+		},
+	}
+	suite.runTableTests(testData)
+}
+
+func (suite *HandlersSuite) TestCustomRecords() {
+	user := &models.User{
+		UserID: 1,
+		Login:  "login",
+	}
+	signedIn := func() {
+		suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+		suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+	}
+
+	wifi := models.RecordType{Name: "Wi-Fi", Fields: []models.TypeField{
+		{Name: "ssid", Type: models.FieldText, Required: true},
+		{Name: "password", Type: models.FieldText, Secret: true},
+	}}
+	wifiBody, _ := json.Marshal(wifi)
+	record := models.CustomRecord{ID: "home", Type: "Wi-Fi", Values: map[string]string{"ssid": "home"},
+		Secrets: map[string]string{"password": "secret"}}
+	recordBody, _ := json.Marshal(record)
+
+	testData := []tests{
+		{
+			name:        "add the record type",
+			request:     "/api/types",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        wifiBody,
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().AddRecordType(gomock.Any(), user.UserID, wifi).Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       "ok",
+			},
+		},
+		{
+			name:        "add the record type with an unknown field type",
+			request:     "/api/types",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        []byte(`{"name": "Wi-Fi", "fields": [{"name": "ssid", "type": "color"}]}`),
+			mockExpected: func() {
+				signedIn()
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `invalid record type: unknown type "color" of the field ssid`,
+			},
+		},
+		{
+			name:        "read the record type",
+			request:     "/api/types/Wi-Fi",
+			requestType: "GET",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().RecordType(gomock.Any(), user.UserID, "Wi-Fi").Return(&wifi, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       string(wifiBody),
+			},
+		},
+		{
+			name:        "delete the record type that has records",
+			request:     "/api/types/Wi-Fi",
+			requestType: "DELETE",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().DeleteRecordType(gomock.Any(), user.UserID, "Wi-Fi").Return(storage.ErrRecordTypeInUse)
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       storage.ErrRecordTypeInUse.Error(),
+			},
+		},
+		{
+			name:        "add the custom record",
+			request:     "/api/custom",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        recordBody,
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().AddCustomRecord(gomock.Any(), user.UserID, record).Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       "ok",
+			},
+		},
+		{
+			name:        "add the custom record not matching the type",
+			request:     "/api/custom",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        []byte(`{"id": "home", "type": "Wi-Fi"}`),
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().AddCustomRecord(gomock.Any(), user.UserID, gomock.Any()).
+					Return(fmt.Errorf("%w: the field ssid is required", models.ErrInvalidRecord))
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "invalid record: the field ssid is required",
+			},
+		},
+		{
+			name:        "read the custom record",
+			request:     "/api/custom/home",
+			requestType: "GET",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().CustomRecord(gomock.Any(), user.UserID, "home").Return(&record, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       string(recordBody),
+			},
+		},
+	}
+	suite.runTableTests(testData)
+}
+
+// TestVault tests for reading the client side encryption parameters
+func (suite *HandlersSuite) TestVault() {
+	userID := int64(1)
+	url := "/api/vault"
+
+	params := &models.VaultParams{
+		Salt:  []byte("salt"),
+		Check: "$gk1$check",
+	}
+	byteParams, _ := json.Marshal(params)
+
+	testData := []tests{
+		{
+			name:        "read vault successfully",
+			request:     url,
+			requestType: "GET",
+			contentType: "",
+			body:        nil,
+			mockExpected: func() {
+				user := &models.User{
+					UserID: userID,
+					Login:  "login",
+				}
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+				suite.store.EXPECT().VaultParams(gomock.Any(), user.UserID).
+					Return(params, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       string(byteParams),
+			},
+		},
+		{
+			name:        "read vault internal error",
+			request:     url,
+			requestType: "GET",
+			contentType: "",
+			body:        nil,
+			mockExpected: func() {
+				user := &models.User{
+					UserID: userID,
+					Login:  "login",
+				}
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+				suite.store.EXPECT().VaultParams(gomock.Any(), user.UserID).
+					Return(nil, errors.New("some error"))
+			},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+				body:       "",
+			},
+		},
+	}
+	suite.runTableTests(testData)
+}
+
+// TestSetVaultCheck tests for saving the master password check value
+func (suite *HandlersSuite) TestSetVaultCheck() {
+	userID := int64(1)
+	url := "/api/vault"
+
+	testData := []tests{
+		{
+			name:        "set vault check successfully",
+			request:     url,
+			requestType: "PUT",
+			contentType: "application/json",
+			body:        []byte(`{"check": "$gk1$check"}`),
+			mockExpected: func() {
+				user := &models.User{
+					UserID: userID,
+					Login:  "login",
+				}
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+				suite.store.EXPECT().SetVaultCheck(gomock.Any(), user.UserID, "$gk1$check").
+					Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       "ok",
+			},
+		},
+		{
+			name:        "set vault check twice",
+			request:     url,
+			requestType: "PUT",
+			contentType: "application/json",
+			body:        []byte(`{"check": "$gk1$check"}`),
+			mockExpected: func() {
+				user := &models.User{
+					UserID: userID,
+					Login:  "login",
+				}
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+				suite.store.EXPECT().SetVaultCheck(gomock.Any(), user.UserID, "$gk1$check").
+					Return(storage.ErrVaultInitialized)
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       "",
+			},
+		},
+		{
+			name:        "set empty vault check",
+			request:     url,
+			requestType: "PUT",
+			contentType: "application/json",
+			body:        []byte(`{"salt": "c2FsdA=="}`),
+			mockExpected: func() {
+				user := &models.User{
+					UserID: userID,
+					Login:  "login",
+				}
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "invalid deserialization",
+			},
+		},
+	}
+	suite.runTableTests(testData)
+}
+
+// TestTwoFactor tests for enabling and disabling the second factor
+func (suite *HandlersSuite) TestTwoFactor() {
+	user := &models.User{
+		UserID: 1,
+		Login:  "login",
+	}
+	authorized := func() {
+		suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+		suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+	}
+	code, err := totp.Code(otpSecret, time.Now())
+	suite.Require().NoError(err)
+
+	testData := []tests{
+		{
+			name:        "setup when already enabled",
+			request:     "/api/2fa/setup",
+			requestType: "POST",
+			mockExpected: func() {
+				authorized()
+				suite.store.EXPECT().SetTOTPSecret(gomock.Any(), user.UserID, gomock.Any()).Return(storage.ErrTOTPEnabled)
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       "",
+			},
+		},
+		{
+			name:        "confirm without code",
+			request:     "/api/2fa/confirm",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        []byte(`{}`),
+			mockExpected: func() {
+				authorized()
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "invalid deserialization",
+			},
+		},
+		{
+			name:        "confirm with wrong code",
+			request:     "/api/2fa/confirm",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        []byte(`{"code": "12345"}`),
+			mockExpected: func() {
+				authorized()
+				suite.store.EXPECT().TOTP(gomock.Any(), user.UserID).Return(&models.TOTP{Secret: otpSecret}, nil)
+			},
+			want: want{
+				statusCode: http.StatusForbidden,
+				body:       "invalid code",
+			},
+		},
+		{
+			name:        "confirm without setup",
+			request:     "/api/2fa/confirm",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        []byte(fmt.Sprintf(`{"code": "%s"}`, code)),
+			mockExpected: func() {
+				authorized()
+				suite.store.EXPECT().TOTP(gomock.Any(), user.UserID).Return(&models.TOTP{}, nil)
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       "",
+			},
+		},
+		{
+			name:        "disable with wrong code",
+			request:     "/api/2fa/disable",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        []byte(`{"code": "abcde-fghij"}`),
+			mockExpected: func() {
+				authorized()
+				suite.store.EXPECT().TOTP(gomock.Any(), user.UserID).Return(&models.TOTP{Secret: otpSecret, Enabled: true}, nil)
+				suite.store.EXPECT().UseRecoveryCode(gomock.Any(), user.UserID, gomock.Any()).Return(false, nil)
+			},
+			want: want{
+				statusCode: http.StatusForbidden,
+				body:       "invalid code",
+			},
+		},
+		{
+			name:        "disable successfully",
+			request:     "/api/2fa/disable",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        []byte(fmt.Sprintf(`{"code": "%s"}`, code)),
+			mockExpected: func() {
+				authorized()
+				suite.store.EXPECT().TOTP(gomock.Any(), user.UserID).Return(&models.TOTP{Secret: otpSecret, Enabled: true}, nil)
+				suite.store.EXPECT().UseTOTPCounter(gomock.Any(), user.UserID, gomock.Any()).Return(true, nil)
+				suite.store.EXPECT().DisableTOTP(gomock.Any(), user.UserID).Return(nil)
+				suite.store.EXPECT().AddAuditEvent(gomock.Any(), user.UserID,
+					models.AuditEvent{Actor: "login", Action: models.AuditDisableTOTP, IP: "127.0.0.1"}).Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       "ok",
+			},
+		},
+	}
+	suite.runTableTests(testData)
+}
+
+// TestConfirmTOTP checks that the recovery codes are returned once and only their hashes are stored
+func (suite *HandlersSuite) TestConfirmTOTP() {
+	user := &models.User{
+		UserID: 1,
+		Login:  "login",
+	}
+	code, err := totp.Code(otpSecret, time.Now())
+	suite.Require().NoError(err)
+
+	suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+	suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+	suite.store.EXPECT().TOTP(gomock.Any(), user.UserID).Return(&models.TOTP{Secret: otpSecret}, nil)
+	var hashes []string
+	suite.store.EXPECT().EnableTOTP(gomock.Any(), user.UserID, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int64, _ int64, h []string) error {
+			hashes = h
+			return nil
+		})
+	suite.store.EXPECT().AddAuditEvent(gomock.Any(), user.UserID,
+		models.AuditEvent{Actor: "login", Action: models.AuditEnableTOTP, IP: "127.0.0.1"}).Return(nil)
+
+	resp, body := runTestRequest(suite.T(), suite.ts, "POST", "/api/2fa/confirm", "application/json",
+		[]byte(fmt.Sprintf(`{"code": "%s"}`, code)))
+	defer resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	var codes models.RecoveryCodes
+	suite.Require().NoError(json.Unmarshal([]byte(body), &codes))
+	suite.Len(codes.Codes, 10)
+	suite.Len(hashes, 10)
+	for _, hash := range hashes {
+		for _, code := range codes.Codes {
+			suite.NotContains(hash, strings.ReplaceAll(code, "-", ""))
+		}
+	}
+}
+
+// TestSignInRetryAfter checks that the client gets the time left of the lockout
+func (suite *HandlersSuite) TestSignInRetryAfter() {
+	suite.store.EXPECT().LoginRetryAfter(gomock.Any(), []string{"login:login", "ip:127.0.0.1"}).Return(1500*time.Millisecond, nil)
+	suite.store.EXPECT().AddAuditEvent(gomock.Any(), int64(0), gomock.Any()).Return(nil)
+
+	resp, _ := runTestRequest(suite.T(), suite.ts, "POST", "/api/signin", "application/json",
+		[]byte(`{"login": "login", "password": "password"}`))
+	defer resp.Body.Close()
+	suite.Equal(http.StatusTooManyRequests, resp.StatusCode)
+	suite.Equal("2", resp.Header.Get("Retry-After"))
+}
+
+// TestLockouts tests for reading the lockouts of the account
+func (suite *HandlersSuite) TestLockouts() {
+	user := &models.User{
+		UserID: 1,
+		Login:  "login",
+	}
+	lockouts := []models.Lockout{
+		{IP: "10.0.0.1", Failures: 15, CreatedAt: time.Unix(1700000000, 0).UTC(), LockedUntil: time.Unix(1700000900, 0).UTC()},
+	}
+	byteLockouts, _ := json.Marshal(lockouts)
+
+	testData := []tests{
+		{
+			name:        "read lockouts successfully",
+			request:     "/api/lockouts",
+			requestType: "GET",
+			mockExpected: func() {
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+				suite.store.EXPECT().Lockouts(gomock.Any(), user.UserID).Return(lockouts, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       string(byteLockouts),
+			},
+		},
+		{
+			name:        "read lockouts internal error",
+			request:     "/api/lockouts",
+			requestType: "GET",
+			mockExpected: func() {
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+				suite.store.EXPECT().Lockouts(gomock.Any(), user.UserID).Return(nil, errors.New("some error"))
+			},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+				body:       "",
+			},
+		},
+	}
+	suite.runTableTests(testData)
+}
+
+// TestRecords tests for the record lists
+func (suite *HandlersSuite) TestRecords() {
+	user := &models.User{
+		UserID: 1,
+		Login:  "login",
+	}
+	signedIn := func() {
+		suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+		suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+	}
+
+	testData := []tests{
+		{
+			name:        "full page of the cards",
+			request:     "/api/card?limit=2",
+			requestType: "GET",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().Records(gomock.Any(), int64(1), models.KindCard,
+					models.ListOptions{Sort: models.SortCreated, Limit: 2}).
+					Return([]models.RecordInfo{{ID: "visa", Note: "salary", Cursor: "3"}, {ID: "master", Cursor: "8"}}, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"records":[{"id":"visa","note":"salary"},{"id":"master","note":""}],"next":"8"}`,
+			},
+		},
+		{
+			name:        "last page of the logins by id",
+			request:     "/api/login?sort=id&order=desc&after=mail",
+			requestType: "GET",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().Records(gomock.Any(), int64(1), models.KindLogin,
+					models.ListOptions{Sort: models.SortID, Desc: true, After: "mail", Limit: 50}).
+					Return([]models.RecordInfo{{ID: "bank", Cursor: "bank"}}, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"records":[{"id":"bank","note":""}]}`,
+			},
+		},
+		{
+			name:        "empty list of the texts",
+			request:     "/api/txt",
+			requestType: "GET",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().Records(gomock.Any(), int64(1), models.KindText,
+					models.ListOptions{Sort: models.SortCreated, Limit: 50}).
+					Return([]models.RecordInfo{}, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"records":[]}`,
+			},
+		},
+		{
+			name:        "texts with the tag and the field",
+			request:     "/api/txt?tag=work&field=project&value=keeper",
+			requestType: "GET",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().Records(gomock.Any(), int64(1), models.KindText,
+					models.ListOptions{Sort: models.SortCreated, Limit: 50, Tag: "work", Field: "project", Value: "keeper"}).
+					Return([]models.RecordInfo{{ID: "todo", Cursor: "4", Tags: []string{"work"},
+						Fields: map[string]string{"project": "keeper"}}}, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"records":[{"id":"todo","note":"","tags":["work"],"fields":{"project":"keeper"}}]}`,
+			},
+		},
+		{
+			name:        "value of the field without the name",
+			request:     "/api/txt?value=keeper",
+			requestType: "GET",
+			mockExpected: func() {
+				signedIn()
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "invalid query",
+			},
+		},
+		{
+			name:        "unknown sorting of the binaries",
+			request:     "/api/bin?sort=content",
+			requestType: "GET",
+			mockExpected: func() {
+				signedIn()
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "invalid query",
+			},
+		},
+		{
+			name:        "list internal error",
+			request:     "/api/bin",
+			requestType: "GET",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().Records(gomock.Any(), int64(1), models.KindBinary, gomock.Any()).
+					Return(nil, errors.New("some error"))
+			},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+				body:       "",
+			},
+		},
+	}
+	suite.runTableTests(testData)
+}
+
+// TestUpdateRecords tests for the record updates
+func (suite *HandlersSuite) TestUpdateRecords() {
+	user := &models.User{
+		UserID: 1,
+		Login:  "login",
+	}
+	signedIn := func() {
+		suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+		suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+	}
+
+	testData := []tests{
+		{
+			name:        "replace the card",
+			request:     "/api/card/visa",
+			requestType: "PUT",
+			contentType: "application/json",
+			body:        []byte(`{"fio": "fio", "number": "4111 1111 1111 1111", "date": "12/2030", "cvv": "123", "version": 2}`),
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().UpdateCard(gomock.Any(), int64(1), models.Card{
+					ID: "visa", FIO: "fio", Number: "4111111111111111", Date: "12/30", CVV: "123", Version: 2,
+				}).Return(int64(3), nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"id":"visa","version":3}`,
+			},
+		},
+		{
+			name:        "replace the card with the wrong cvv length",
+			request:     "/api/card/amex",
+			requestType: "PUT",
+			contentType: "application/json",
+			body:        []byte(`{"number": "378282246310005", "date": "12/30", "cvv": "123", "version": 2}`),
+			mockExpected: func() {
+				signedIn()
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "invalid card: the American Express CVV must have 4 digits",
+			},
+		},
+		{
+			name:        "patch the password of the login",
+			request:     "/api/login/mail",
+			requestType: "PATCH",
+			contentType: "application/json",
+			body:        []byte(`{"password": "fixed", "version": 4}`),
+			mockExpected: func() {
+				signedIn()
+				// The current record is read by the server itself, it is not a read of the audit log
+				suite.store.EXPECT().Login(gomock.Any(), int64(1), "mail").
+					DoAndReturn(func(ctx context.Context, _ int64, _ string) (*models.Login, error) {
+						suite.True(audit.IsInternal(ctx))
+						return &models.Login{ID: "mail", Login: "user", Password: "typo", Note: "work", Version: 4}, nil
+					})
+				suite.store.EXPECT().UpdateLogin(gomock.Any(), int64(1), models.Login{
+					ID: "mail", Login: "user", Password: "fixed", Note: "work", Version: 4,
+				}).Return(int64(5), nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"id":"mail","version":5}`,
+			},
+		},
+		{
+			name:        "patch the missing card",
+			request:     "/api/card/visa",
+			requestType: "PATCH",
+			contentType: "application/json",
+			body:        []byte(`{"cvv": "321", "version": 1}`),
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().Card(gomock.Any(), int64(1), "visa").Return(nil, pgx.ErrNoRows)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+				body:       "",
+			},
+		},
+		{
+			name:        "replace the text of the stale version",
+			request:     "/api/txt/note",
+			requestType: "PUT",
+			contentType: "application/json",
+			body:        []byte(`{"content": "content", "version": 1}`),
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().UpdateText(gomock.Any(), int64(1), models.Text{ID: "note", Content: "content", Version: 1}).
+					Return(int64(0), storage.ErrVersionConflict)
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       "version conflict",
+			},
+		},
+		{
+			name:        "replace the binary without the version",
+			request:     "/api/bin/photo",
+			requestType: "PUT",
+			contentType: "application/json",
+			body:        []byte(`{"data": "ZGF0YQ=="}`),
+			mockExpected: func() {
+				signedIn()
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "version required",
+			},
+		},
+	}
+	suite.runTableTests(testData)
+}
+
+// TestRevisions tests for the history of the records
+func (suite *HandlersSuite) TestRevisions() {
+	user := &models.User{
+		UserID: 1,
+		Login:  "login",
+	}
+	signedIn := func() {
+		suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+		suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+	}
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	testData := []tests{
+		{
+			name:        "history of the card",
+			request:     "/api/card/visa/revisions",
+			requestType: "GET",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().Revisions(gomock.Any(), int64(1), models.KindCard, "visa").Return([]models.Revision{
+					{ID: 2, Kind: models.KindCard, RecordID: "visa", Version: 1, Action: models.RevisionAdd, CreatedAt: created},
+				}, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `[{"id":2,"kind":"card","record_id":"visa","version":1,"action":"add","created_at":"2024-01-02T03:04:05Z"}]`,
+			},
+		},
+		{
+			name:        "revision of the text",
+			request:     "/api/txt/note/revisions/5",
+			requestType: "GET",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().Revision(gomock.Any(), int64(1), models.KindText, "note", int64(5)).Return(&models.Revision{
+					ID: 5, Kind: models.KindText, RecordID: "note", Version: 2, Action: models.RevisionDelete, CreatedAt: created,
+					Fields: map[string]string{"content": "content", "note": ""},
+				}, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body: `{"id":5,"kind":"text","record_id":"note","version":2,"action":"delete","created_at":"2024-01-02T03:04:05Z",` +
+					`"fields":{"content":"content","note":""}}`,
+			},
+		},
+		{
+			name:        "revision of another record",
+			request:     "/api/login/mail/revisions/5",
+			requestType: "GET",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().Revision(gomock.Any(), int64(1), models.KindLogin, "mail", int64(5)).Return(nil, pgx.ErrNoRows)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+				body:       "",
+			},
+		},
+		{
+			name:        "invalid revision",
+			request:     "/api/login/mail/revisions/last",
+			requestType: "GET",
+			mockExpected: func() {
+				signedIn()
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "invalid revision",
+			},
+		},
+		{
+			name:        "restore the binary",
+			request:     "/api/bin/photo/revisions/3/restore",
+			requestType: "POST",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().RestoreRevision(gomock.Any(), int64(1), models.KindBinary, "photo", int64(3)).Return(int64(6), nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"id":"photo","version":6}`,
+			},
+		},
+	}
+	suite.runTableTests(testData)
+}
+
+// TestTrash tests for the deleted records
+func (suite *HandlersSuite) TestTrash() {
+	user := &models.User{
+		UserID: 1,
+		Login:  "login",
+	}
+	signedIn := func() {
+		suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+		suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+	}
+	deleted := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	testData := []tests{
+		{
+			name:        "list the trash",
+			request:     "/api/trash",
+			requestType: "GET",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().Trash(gomock.Any(), int64(1)).Return([]models.TrashItem{
+					{Kind: models.KindLogin, ID: "mail", Note: "work", DeletedAt: deleted},
+				}, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `[{"kind":"login","id":"mail","note":"work","deleted_at":"2024-01-02T03:04:05Z"}]`,
+			},
+		},
+		{
+			name:        "restore the login",
+			request:     "/api/trash/login/mail/restore",
+			requestType: "POST",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().RestoreTrash(gomock.Any(), int64(1), models.KindLogin, "mail").Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       "ok",
+			},
+		},
+		{
+			name:        "restore the record not in the trash",
+			request:     "/api/trash/text/note/restore",
+			requestType: "POST",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().RestoreTrash(gomock.Any(), int64(1), models.KindText, "note").Return(pgx.ErrNoRows)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+				body:       "",
+			},
+		},
+		{
+			name:        "restore the unknown kind",
+			request:     "/api/trash/photo/note/restore",
+			requestType: "POST",
+			mockExpected: func() {
+				signedIn()
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "invalid kind",
+			},
+		},
+		{
+			name:        "empty the trash",
+			request:     "/api/trash",
+			requestType: "DELETE",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().EmptyTrash(gomock.Any(), int64(1)).Return(int64(2), nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"removed":2}`,
+			},
+		},
+		{
+			name:        "delete the missing card",
+			request:     "/api/card/visa",
+			requestType: "DELETE",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().DeleteCard(gomock.Any(), int64(1), "visa").Return(pgx.ErrNoRows)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+				body:       "",
+			},
+		},
+	}
+	suite.runTableTests(testData)
+}
+
+// TestVaults tests for the vaults, the folders and the moves of the records
+func (suite *HandlersSuite) TestVaults() {
+	user := &models.User{
+		UserID: 1,
+		Login:  "login",
+	}
+	signedIn := func() {
+		suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+		suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+	}
+
+	testData := []tests{
+		{
+			name:        "list the vaults",
+			request:     "/api/vaults",
+			requestType: "GET",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().Vaults(gomock.Any(), int64(1)).Return([]models.Vault{{ID: 2, Name: "work"}}, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `[{"id":2,"name":"work"}]`,
+			},
+		},
+		{
+			name:        "add the vault",
+			request:     "/api/vaults",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        []byte(`{"name":"work"}`),
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().AddVault(gomock.Any(), int64(1), "work").Return(int64(2), nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"id":2,"name":"work"}`,
+			},
+		},
+		{
+			name:        "add the vault with the taken name",
+			request:     "/api/vaults",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        []byte(`{"name":"work"}`),
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().AddVault(gomock.Any(), int64(1), "work").Return(int64(0), storage.ErrNameTaken)
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       "name is already taken",
+			},
+		},
+		{
+			name:        "add the vault without the name",
+			request:     "/api/vaults",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        []byte(`{}`),
+			mockExpected: func() {
+				signedIn()
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "invalid deserialization",
+			},
+		},
+		{
+			name:        "delete the missing vault",
+			request:     "/api/vaults/7",
+			requestType: "DELETE",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().DeleteVault(gomock.Any(), int64(1), int64(7)).Return(pgx.ErrNoRows)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+				body:       "",
+			},
+		},
+		{
+			name:        "add the nested folder",
+			request:     "/api/vaults/2/folders",
+			requestType: "POST",
+			contentType: "application/json",
+			body:        []byte(`{"name":"cards","parent":3}`),
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().AddFolder(gomock.Any(), int64(1), models.Folder{Vault: 2, Parent: 3, Name: "cards"}).
+					Return(int64(5), nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"id":5,"vault":2,"parent":3,"name":"cards"}`,
+			},
+		},
+		{
+			name:        "folders of the invalid vault",
+			request:     "/api/vaults/work/folders",
+			requestType: "GET",
+			mockExpected: func() {
+				signedIn()
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "invalid vault",
+			},
+		},
+		{
+			name:        "move the folder into its nested one",
+			request:     "/api/folders/3",
+			requestType: "PUT",
+			contentType: "application/json",
+			body:        []byte(`{"name":"bank","parent":5}`),
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().UpdateFolder(gomock.Any(), int64(1), models.Folder{ID: 3, Parent: 5, Name: "bank"}).
+					Return(pgx.ErrNoRows)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+				body:       "",
+			},
+		},
+		{
+			name:        "move the login to the folder",
+			request:     "/api/login/mail/location",
+			requestType: "PUT",
+			contentType: "application/json",
+			body:        []byte(`{"vault":2,"folder":5}`),
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().MoveRecord(gomock.Any(), int64(1), models.KindLogin, "mail",
+					models.Location{Vault: 2, Folder: 5}).Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       "ok",
+			},
+		},
+		{
+			name:        "move the text to the folder of another user",
+			request:     "/api/txt/todo/location",
+			requestType: "PUT",
+			contentType: "application/json",
+			body:        []byte(`{"vault":9}`),
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().MoveRecord(gomock.Any(), int64(1), models.KindText, "todo",
+					models.Location{Vault: 9}).Return(storage.ErrInvalidLocation)
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "no such vault or folder",
+			},
+		},
+		{
+			name:        "list the cards of the folder",
+			request:     "/api/card?vault=2&folder=5",
+			requestType: "GET",
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().Records(gomock.Any(), int64(1), models.KindCard,
+					models.ListOptions{Sort: models.SortCreated, Limit: 50, Vault: 2, Folder: 5}).
+					Return([]models.RecordInfo{}, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"records":[]}`,
+			},
+		},
+		{
+			name:        "list the folder without the vault",
+			request:     "/api/card?folder=5",
+			requestType: "GET",
+			mockExpected: func() {
+				signedIn()
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "invalid query",
+			},
+		},
+	}
+	suite.runTableTests(testData)
+}
+
+// TestAccount tests for the account management
+func (suite *HandlersSuite) TestAccount() {
+	hash, err := password.Hash("password")
+	suite.Require().NoError(err)
+	user := &models.User{
+		UserID: 1,
+		Login:  "login",
+	}
+	signedIn := func() {
+		suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+		suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+	}
+	confirmed := func() {
+		suite.store.EXPECT().LoginRetryAfter(gomock.Any(), []string{"login:login", "ip:127.0.0.1"}).Return(time.Duration(0), nil)
+		suite.store.EXPECT().User(gomock.Any(), "login").Return(&models.User{UserID: 1, Login: "login", Password: hash}, nil)
+		suite.store.EXPECT().ResetLoginFailures(gomock.Any(), "login:login").Return(nil)
+	}
+
+	testData := []tests{
+		{
+			name:        "change password successfully",
+			request:     "/api/account/password",
+			requestType: "PUT",
+			contentType: "application/json",
+			body:        []byte(`{"password": "password", "new_password": "new"}`),
+			mockExpected: func() {
+				signedIn()
+				confirmed()
+				suite.store.EXPECT().UpdatePassword(gomock.Any(), int64(1), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ int64, hash string) error {
+						ok, _, err := password.Verify("new", hash)
+						suite.NoError(err)
+						suite.True(ok)
+						return nil
+					})
+				suite.store.EXPECT().AddAuditEvent(gomock.Any(), int64(1),
+					models.AuditEvent{Actor: "login", Action: models.AuditChangePassword, IP: "127.0.0.1"}).Return(nil)
+				suite.store.EXPECT().RevokeOtherSessions(gomock.Any(), int64(1), "session").Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       "ok",
+			},
+		},
+		{
+			name:        "change password with wrong current password",
+			request:     "/api/account/password",
+			requestType: "PUT",
+			contentType: "application/json",
+			body:        []byte(`{"password": "wrong", "new_password": "new"}`),
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().LoginRetryAfter(gomock.Any(), []string{"login:login", "ip:127.0.0.1"}).Return(time.Duration(0), nil)
+				suite.store.EXPECT().User(gomock.Any(), "login").Return(&models.User{UserID: 1, Login: "login", Password: hash}, nil)
+				suite.store.EXPECT().AddLoginFailure(gomock.Any(), "login:login", gomock.Any()).Return(1, nil)
+				suite.store.EXPECT().AddLoginFailure(gomock.Any(), "ip:127.0.0.1", gomock.Any()).Return(1, nil)
+			},
+			want: want{
+				statusCode: http.StatusForbidden,
+				body:       "invalid password",
+			},
+		},
+		{
+			name:        "change password to empty one",
+			request:     "/api/account/password",
+			requestType: "PUT",
+			contentType: "application/json",
+			body:        []byte(`{"password": "password"}`),
+			mockExpected: func() {
+				signedIn()
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "empty login or password",
+			},
+		},
+		{
+			name:        "change password when locked",
+			request:     "/api/account/password",
+			requestType: "PUT",
+			contentType: "application/json",
+			body:        []byte(`{"password": "password", "new_password": "new"}`),
+			mockExpected: func() {
+				signedIn()
+				suite.store.EXPECT().LoginRetryAfter(gomock.Any(), []string{"login:login", "ip:127.0.0.1"}).Return(time.Minute, nil)
+			},
+			want: want{
+				statusCode: http.StatusTooManyRequests,
+				body:       "too many attempts",
+			},
+		},
+		{
+			name:        "change login successfully",
+			request:     "/api/account/login",
+			requestType: "PUT",
+			contentType: "application/json",
+			body:        []byte(`{"password": "password", "new_login": "renamed"}`),
+			mockExpected: func() {
+				signedIn()
+				confirmed()
+				suite.store.EXPECT().ChangeLogin(gomock.Any(), int64(1), "renamed").Return(nil)
+				suite.store.EXPECT().AddAuditEvent(gomock.Any(), int64(1),
+					models.AuditEvent{Actor: "login", Action: models.AuditChangeLogin, IP: "127.0.0.1"}).Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       "ok",
+			},
+		},
+		{
+			name:        "change login to the taken one",
+			request:     "/api/account/login",
+			requestType: "PUT",
+			contentType: "application/json",
+			body:        []byte(`{"password": "password", "new_login": "taken"}`),
+			mockExpected: func() {
+				signedIn()
+				confirmed()
+				suite.store.EXPECT().ChangeLogin(gomock.Any(), int64(1), "taken").Return(storage.ErrLoginTaken)
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       "already have",
+			},
+		},
+		{
+			name:        "change login invalid request",
+			request:     "/api/account/login",
+			requestType: "PUT",
+			contentType: "application/json",
+			body:        []byte(`{"password": "password", new_login}`),
+			mockExpected: func() {
+				signedIn()
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "invalid deserialization",
+			},
+		},
+		{
+			name:        "delete account successfully",
+			request:     "/api/account",
+			requestType: "DELETE",
+			contentType: "application/json",
+			body:        []byte(`{"password": "password"}`),
+			mockExpected: func() {
+				signedIn()
+				confirmed()
+				suite.store.EXPECT().DeleteUser(gomock.Any(), int64(1)).Return(nil)
+				suite.store.EXPECT().AddAuditEvent(gomock.Any(), int64(0),
+					models.AuditEvent{Actor: "login", Action: models.AuditDeleteAccount, IP: "127.0.0.1"}).Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       "ok",
+			},
+		},
+		{
+			name:        "delete account internal error",
+			request:     "/api/account",
+			requestType: "DELETE",
+			contentType: "application/json",
+			body:        []byte(`{"password": "password"}`),
+			mockExpected: func() {
+				signedIn()
+				confirmed()
+				suite.store.EXPECT().DeleteUser(gomock.Any(), int64(1)).Return(errors.New("some error"))
+			},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+				body:       "",
+			},
+		},
+	}
+	suite.runTableTests(testData)
+}
+
+// TestAuditEvents tests for reading the audit log
+func (suite *HandlersSuite) TestAuditEvents() {
+	user := &models.User{
+		UserID: 1,
+		Login:  "login",
+	}
+	events := []models.AuditEvent{
+		{ID: 12, Actor: "login", Action: models.AuditRead, Kind: models.KindCard, RecordID: "card",
+			Transport: "http", IP: "10.0.0.1", CreatedAt: time.Unix(1700000000, 0).UTC()},
+		{ID: 10, Actor: "login", Action: models.AuditSignIn, Transport: "grpc", IP: "10.0.0.1", CreatedAt: time.Unix(1700000000, 0).UTC()},
+	}
+	fullPage, _ := json.Marshal(models.AuditPage{Events: events, Next: 10})
+	lastPage, _ := json.Marshal(models.AuditPage{Events: events})
+
+	testData := []tests{
+		{
+			name:        "read the full page of the audit log",
+			request:     "/api/audit?before=20&limit=2",
+			requestType: "GET",
+			mockExpected: func() {
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+				suite.store.EXPECT().AuditEvents(gomock.Any(), user.UserID, int64(20), 2).Return(events, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       string(fullPage),
+			},
+		},
+		{
+			name:        "read the last page of the audit log with the default limit",
+			request:     "/api/audit",
+			requestType: "GET",
+			mockExpected: func() {
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+				suite.store.EXPECT().AuditEvents(gomock.Any(), user.UserID, int64(0), 50).Return(events, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       string(lastPage),
+			},
+		},
+		{
+			name:        "read the audit log with invalid cursor",
+			request:     "/api/audit?before=last",
+			requestType: "GET",
+			mockExpected: func() {
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       "invalid query",
+			},
+		},
+		{
+			name:        "read the audit log internal error",
+			request:     "/api/audit",
+			requestType: "GET",
+			mockExpected: func() {
+				suite.parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+				suite.store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+				suite.store.EXPECT().AuditEvents(gomock.Any(), user.UserID, int64(0), 50).Return(nil, errors.New("some error"))
+			},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+				body:       "",
+			},
+		},
+	}
+	suite.runTableTests(testData)
+}
+
+// TestCertBindings checks that the bound account is not used without its client certificate
+func TestCertBindings(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	file := filepath.Join(t.TempDir(), "bindings")
+	require.NoError(t, os.WriteFile(file, []byte("login client\n"), 0o600))
+	bindings, err := certbind.Load(file)
+	require.NoError(t, err)
+
+	store := mockstorage.NewMockStorage(ctrl)
+	parser := mockjwt.NewMockParser(ctrl)
+	keys, err := signing.Open(signing.Options{})
+	require.NoError(t, err)
+	ts := httptest.NewServer(NewRouter(&config.Config{}, store, keys, parser, bindings))
+	defer ts.Close()
+
+	resp, body := runTestRequest(t, ts, "POST", "/api/signin", "application/json",
+		[]byte(`{"login": "login", "password": "password"}`))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, "certificate is not bound to the account", body)
+
+	// A valid token of the bound account is not accepted without the certificate either
+	user := &models.User{UserID: 1, Login: "login"}
+	parser.EXPECT().ParseToken(gomock.Any()).Return(&jwt.Claims{Username: user.Login, SessionID: "session"}, nil)
+	store.EXPECT().SessionUser(gomock.Any(), "session").Return(user, nil)
+	resp, _ = runTestRequest(t, ts, "GET", "/api/sessions", "", nil)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
@@ -11,9 +11,12 @@ import (
 
 	"github.com/rs/zerolog/log"
 
+	"github.com/ncyellow/GophKeeper/internal/server/auth/certbind"
 	"github.com/ncyellow/GophKeeper/internal/server/auth/jwt"
+	"github.com/ncyellow/GophKeeper/internal/server/auth/signing"
 	"github.com/ncyellow/GophKeeper/internal/server/config"
 	"github.com/ncyellow/GophKeeper/internal/server/storage"
+	"github.com/ncyellow/GophKeeper/internal/tlsconfig"
 )
 
 // HTTPServer structure of our HTTPS server. Implements the Server interface
@@ -23,16 +26,43 @@ type HTTPServer struct {
 
 // Run a blocking function for starting the server
 func (s *HTTPServer) Run() error {
-	store, err := storage.NewPgStorage(s.Conf)
+	store, err := storage.CreateStorage(s.Conf)
 	if err != nil {
 		return err
 	}
 
-	router := NewRouter(s.Conf, store, &jwt.DefaultParser{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	crl, err := tlsconfig.OpenCRL(ctx, s.Conf.CRLFile, s.Conf.CACertFile, s.Conf.CRLReloadInterval)
+	if err != nil {
+		return err
+	}
+	bindings, err := certbind.Load(s.Conf.CertBindingsFile)
+	if err != nil {
+		return err
+	}
+	keys, err := signing.Open(signing.NewOptions(s.Conf))
+	if err != nil {
+		return err
+	}
+	go keys.Watch(ctx)
+	go storage.PurgeRevisions(ctx, store, s.Conf.RevisionRetention)
+	go storage.PurgeTrash(ctx, store, s.Conf.TrashRetention)
+	go storage.PurgeUploads(ctx, store)
+	go storage.PurgeBlobs(ctx, store)
+
+	tlsConf, err := tlsconfig.Server(s.Conf.CryptoCrt, s.Conf.CryptoKey, s.Conf.CACertFile, crl)
+	if err != nil {
+		return err
+	}
+
+	router := NewRouter(s.Conf, store, keys, jwt.NewParser(keys), bindings)
 
 	srv := http.Server{
-		Addr:    s.Conf.Address,
-		Handler: router,
+		Addr:      s.Conf.Address,
+		Handler:   router,
+		TLSConfig: tlsConf,
 	}
 
 	idleConnsClosed := make(chan struct{})
@@ -53,7 +83,7 @@ func (s *HTTPServer) Run() error {
 	}()
 
 	go func() {
-		if err := srv.ListenAndServeTLS(s.Conf.CryptoCrt, s.Conf.CryptoKey); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		if err := srv.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Error().Msgf("listen: %s", err)
 		}
 	}()
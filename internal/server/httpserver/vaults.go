@@ -0,0 +1,367 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v4"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/auth"
+	"github.com/ncyellow/GophKeeper/internal/server/storage"
+)
+
+// Vaults returns the vaults of the user
+// @Tags Read
+// @Summary Returns the list of the user vaults
+// @Description output JSON list of the vaults ordered by name
+// @ID listVaults
+// @Produce json
+// @Success 200 {array} models.Vault
+// @Failure 500 {string} string ""
+// @Router /api/vaults [get]
+func (h *Handler) Vaults() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		vaults, err := h.store.Vaults(r.Context(), user.UserID)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		writeJSON(rw, vaults)
+	}
+}
+
+// AddVault creates the vault
+// @Tags Add
+// @Summary Creating a new vault
+// @Description The name of the vault is unique for the user
+// @ID addVault
+// @Accept json
+// @Produce json
+// @Param vault body models.Vault true "Vault with the name"
+// @Success 200 {object} models.Vault
+// @Failure 400 {string} string "invalid deserialization"
+// @Failure 409 {string} string "name is already taken"
+// @Failure 500 {string} string ""
+// @Router /api/vaults [post]
+func (h *Handler) AddVault() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		var vault models.Vault
+		if !readNamed(rw, r, &vault, &vault.Name) {
+			return
+		}
+
+		vaultID, err := h.store.AddVault(r.Context(), user.UserID, vault.Name)
+		if err != nil {
+			writeFolderError(rw, err)
+			return
+		}
+		vault.ID = vaultID
+		writeJSON(rw, vault)
+	}
+}
+
+// RenameVault renames the vault
+// @Tags Add
+// @Summary Renaming the vault
+// @ID renameVault
+// @Accept json
+// @Produce plain
+// @Param vault path int true "Vault ID"
+// @Param vault_data body models.Vault true "Vault with the new name"
+// @Success 200 {string} string "ok"
+// @Failure 400 {string} string "invalid deserialization"
+// @Failure 404 {string} string ""
+// @Failure 409 {string} string "name is already taken"
+// @Failure 500 {string} string ""
+// @Router /api/vaults/{vault} [put]
+func (h *Handler) RenameVault() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		vaultID, ok := pathID(rw, r, "vault")
+		if !ok {
+			return
+		}
+		var vault models.Vault
+		if !readNamed(rw, r, &vault, &vault.Name) {
+			return
+		}
+
+		if err := h.store.RenameVault(r.Context(), user.UserID, vaultID, vault.Name); err != nil {
+			writeFolderError(rw, err)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("ok"))
+	}
+}
+
+// DeleteVault removes the vault with its folders
+// @Tags Delete
+// @Summary Deleting the vault
+// @Description The records of the vault are not deleted, they are moved to the top level
+// @ID delVault
+// @Produce plain
+// @Param vault path int true "Vault ID"
+// @Success 200 {string} string "ok"
+// @Failure 404 {string} string ""
+// @Failure 500 {string} string ""
+// @Router /api/vaults/{vault} [delete]
+func (h *Handler) DeleteVault() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		vaultID, ok := pathID(rw, r, "vault")
+		if !ok {
+			return
+		}
+		if err := h.store.DeleteVault(r.Context(), user.UserID, vaultID); err != nil {
+			writeFolderError(rw, err)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("ok"))
+	}
+}
+
+// Folders returns all folders of the vault
+// @Tags Read
+// @Summary Returns the folders of the vault
+// @Description output JSON list of the folders ordered by name, the tree is built by the parents
+// @ID listFolders
+// @Produce json
+// @Param vault path int true "Vault ID"
+// @Success 200 {array} models.Folder
+// @Failure 500 {string} string ""
+// @Router /api/vaults/{vault}/folders [get]
+func (h *Handler) Folders() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		vaultID, ok := pathID(rw, r, "vault")
+		if !ok {
+			return
+		}
+		folders, err := h.store.Folders(r.Context(), user.UserID, vaultID)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		writeJSON(rw, folders)
+	}
+}
+
+// AddFolder creates the folder in the vault
+// @Tags Add
+// @Summary Creating a new folder
+// @Description The folder without the parent is created in the root of the vault
+// @ID addFolder
+// @Accept json
+// @Produce json
+// @Param vault path int true "Vault ID"
+// @Param folder body models.Folder true "Folder with the name and the parent"
+// @Success 200 {object} models.Folder
+// @Failure 400 {string} string "invalid deserialization"
+// @Failure 404 {string} string ""
+// @Failure 409 {string} string "name is already taken"
+// @Failure 500 {string} string ""
+// @Router /api/vaults/{vault}/folders [post]
+func (h *Handler) AddFolder() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		vaultID, ok := pathID(rw, r, "vault")
+		if !ok {
+			return
+		}
+		var folder models.Folder
+		if !readNamed(rw, r, &folder, &folder.Name) {
+			return
+		}
+		folder.Vault = vaultID
+
+		folderID, err := h.store.AddFolder(r.Context(), user.UserID, folder)
+		if err != nil {
+			writeFolderError(rw, err)
+			return
+		}
+		folder.ID = folderID
+		writeJSON(rw, folder)
+	}
+}
+
+// UpdateFolder renames the folder and moves it to the parent
+// @Tags Add
+// @Summary Renaming and moving the folder
+// @Description The zero parent is the root of the vault, the folder can not be moved to another vault
+// @ID updateFolder
+// @Accept json
+// @Produce plain
+// @Param folder path int true "Folder ID"
+// @Param folder_data body models.Folder true "Folder with the name and the parent"
+// @Success 200 {string} string "ok"
+// @Failure 400 {string} string "invalid deserialization"
+// @Failure 404 {string} string ""
+// @Failure 409 {string} string "name is already taken"
+// @Failure 500 {string} string ""
+// @Router /api/folders/{folder} [put]
+func (h *Handler) UpdateFolder() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		folderID, ok := pathID(rw, r, "folder")
+		if !ok {
+			return
+		}
+		var folder models.Folder
+		if !readNamed(rw, r, &folder, &folder.Name) {
+			return
+		}
+		folder.ID = folderID
+
+		if err := h.store.UpdateFolder(r.Context(), user.UserID, folder); err != nil {
+			writeFolderError(rw, err)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("ok"))
+	}
+}
+
+// DeleteFolder removes the folder with the nested ones
+// @Tags Delete
+// @Summary Deleting the folder
+// @Description The records of the folders are not deleted, they are moved to the root of the vault
+// @ID delFolder
+// @Produce plain
+// @Param folder path int true "Folder ID"
+// @Success 200 {string} string "ok"
+// @Failure 404 {string} string ""
+// @Failure 500 {string} string ""
+// @Router /api/folders/{folder} [delete]
+func (h *Handler) DeleteFolder() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		folderID, ok := pathID(rw, r, "folder")
+		if !ok {
+			return
+		}
+		if err := h.store.DeleteFolder(r.Context(), user.UserID, folderID); err != nil {
+			writeFolderError(rw, err)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("ok"))
+	}
+}
+
+// MoveRecord returns the handler moving the record of the kind to the vault and the folder
+// @Tags Add
+// @Summary Moving the record to the folder
+// @Description The zero vault is the top level, the zero folder is the root of the vault. The version is not changed
+// @ID moveRecord
+// @Accept json
+// @Produce plain
+// @Param id path string true "Record ID"
+// @Param location body models.Location true "Vault and folder"
+// @Success 200 {string} string "ok"
+// @Failure 400 {string} string "no such vault or folder"
+// @Failure 404 {string} string ""
+// @Failure 500 {string} string ""
+// @Router /api/card/{id}/location [put]
+// @Router /api/login/{id}/location [put]
+// @Router /api/txt/{id}/location [put]
+// @Router /api/bin/{id}/location [put]
+func (h *Handler) MoveRecord(kind models.Kind) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(auth.UserContextKey{}).(*models.User)
+
+		reqBody, err := io.ReadAll(r.Body)
+		defer r.Body.Close()
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			rw.Write([]byte("read data problem"))
+			return
+		}
+		var location models.Location
+		if err := json.Unmarshal(reqBody, &location); err != nil || (location.Vault == 0 && location.Folder != 0) {
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte("invalid deserialization"))
+			return
+		}
+
+		err = h.store.MoveRecord(r.Context(), user.UserID, kind, chi.URLParam(r, "id"), location)
+		if err != nil {
+			writeFolderError(rw, err)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("ok"))
+	}
+}
+
+// pathID reads the numeric id from the path, the invalid one is answered with 400
+func pathID(rw http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, name), 10, 64)
+	if err != nil || id <= 0 {
+		rw.WriteHeader(http.StatusBadRequest)
+		rw.Write([]byte("invalid " + name))
+		return 0, false
+	}
+	return id, true
+}
+
+// readNamed reads the body of the vault or the folder, the name is required
+func readNamed(rw http.ResponseWriter, r *http.Request, value interface{}, name *string) bool {
+	reqBody, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		rw.WriteHeader(http.StatusInternalServerError)
+		rw.Write([]byte("read data problem"))
+		return false
+	}
+	if err := json.Unmarshal(reqBody, value); err != nil || *name == "" {
+		rw.WriteHeader(http.StatusBadRequest)
+		rw.Write([]byte("invalid deserialization"))
+		return false
+	}
+	return true
+}
+
+// writeFolderError writes the error of the change of the vaults, the folders or the location of the record
+func writeFolderError(rw http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		rw.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, storage.ErrNameTaken):
+		rw.WriteHeader(http.StatusConflict)
+		rw.Write([]byte(err.Error()))
+	case errors.Is(err, storage.ErrInvalidLocation):
+		rw.WriteHeader(http.StatusBadRequest)
+		rw.Write([]byte(err.Error()))
+	default:
+		rw.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// writeJSON writes the value as the json response
+func writeJSON(rw http.ResponseWriter, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	rw.Write(data)
+}
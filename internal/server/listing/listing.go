@@ -0,0 +1,74 @@
+// Package listing checks the options of the record lists requested by the clients and builds the pages.
+// The lists use keyset pagination: the cursor is the position of the last record of the previous page,
+// so the records added or deleted in between do not shift the pages
+package listing
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/paging"
+)
+
+// Orders of the sorting
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// ErrInvalidOptions unknown sorting or order, or the cursor does not match the sorting
+var ErrInvalidOptions = errors.New("invalid list options")
+
+// Options returns the checked options of the page, the empty values are the defaults:
+// the sorting by creation in the ascending order from the first record
+func Options(sort string, order string, after string, limit int) (models.ListOptions, error) {
+	opts := models.ListOptions{Sort: sort, After: after, Limit: paging.Limit(limit)}
+	switch sort {
+	case "":
+		opts.Sort = models.SortCreated
+	case models.SortCreated, models.SortID:
+	default:
+		return models.ListOptions{}, ErrInvalidOptions
+	}
+	switch order {
+	case "", OrderAsc:
+	case OrderDesc:
+		opts.Desc = true
+	default:
+		return models.ListOptions{}, ErrInvalidOptions
+	}
+	// The creation cursor is the position of the row, the id cursor is any string
+	if opts.Sort == models.SortCreated && after != "" {
+		if position, err := strconv.ParseInt(after, 10, 64); err != nil || position <= 0 {
+			return models.ListOptions{}, ErrInvalidOptions
+		}
+	}
+	return opts, nil
+}
+
+// Filter adds the tag and the field filters to the options. The value of the field can't be set without
+// the name of the field
+func Filter(opts models.ListOptions, tag string, field string, value string) (models.ListOptions, error) {
+	if field == "" && value != "" {
+		return models.ListOptions{}, ErrInvalidOptions
+	}
+	opts.Tag, opts.Field, opts.Value = tag, field, value
+	return opts, nil
+}
+
+// Scope limits the records to the folder of the vault, the zero folder is the root of the vault.
+// The zero vault lists the records of all vaults, the folder can't be set without the vault
+func Scope(opts models.ListOptions, vault int64, folder int64) (models.ListOptions, error) {
+	if vault < 0 || folder < 0 || (vault == 0 && folder != 0) {
+		return models.ListOptions{}, ErrInvalidOptions
+	}
+	opts.Vault, opts.Folder = vault, folder
+	return opts, nil
+}
+
+// NewPage returns the page of the records read with the limit, a full page has the cursor of the next one
+func NewPage(records []models.RecordInfo, limit int) models.RecordPage {
+	next := paging.Next(records, limit, func(record models.RecordInfo) string { return record.Cursor })
+	return models.RecordPage{Records: records, Next: next}
+}
@@ -0,0 +1,94 @@
+package listing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/paging"
+)
+
+func TestOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		sort    string
+		order   string
+		after   string
+		limit   int
+		want    models.ListOptions
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			want: models.ListOptions{Sort: models.SortCreated, Limit: paging.DefaultLimit},
+		},
+		{
+			name:  "by id descending",
+			sort:  "id",
+			order: "desc",
+			after: "card-7",
+			limit: 1000,
+			want:  models.ListOptions{Sort: models.SortID, Desc: true, After: "card-7", Limit: paging.MaxLimit},
+		},
+		{
+			name:  "by creation after the position",
+			sort:  "created",
+			order: "asc",
+			after: "42",
+			limit: 10,
+			want:  models.ListOptions{Sort: models.SortCreated, After: "42", Limit: 10},
+		},
+		{
+			name:    "unknown sorting",
+			sort:    "cvv",
+			wantErr: true,
+		},
+		{
+			name:    "unknown order",
+			order:   "random",
+			wantErr: true,
+		},
+		{
+			name:    "id cursor with the creation sorting",
+			after:   "card-7",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := Options(tt.sort, tt.order, tt.after, tt.limit)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidOptions)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, opts)
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	opts, err := Filter(models.ListOptions{Limit: 10}, "work", "bank", "test")
+	assert.NoError(t, err)
+	assert.Equal(t, models.ListOptions{Limit: 10, Tag: "work", Field: "bank", Value: "test"}, opts)
+
+	_, err = Filter(models.ListOptions{}, "", "", "test")
+	assert.ErrorIs(t, err, ErrInvalidOptions)
+}
+
+func TestScope(t *testing.T) {
+	opts, err := Scope(models.ListOptions{Limit: 10}, 2, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ListOptions{Limit: 10, Vault: 2, Folder: 5}, opts)
+
+	_, err = Scope(models.ListOptions{}, 0, 5)
+	assert.ErrorIs(t, err, ErrInvalidOptions)
+}
+
+func TestNewPage(t *testing.T) {
+	records := []models.RecordInfo{{ID: "a", Cursor: "1"}, {ID: "b", Cursor: "3"}}
+	assert.Equal(t, "3", NewPage(records, 2).Next)
+	assert.Empty(t, NewPage(records, 3).Next)
+	assert.Empty(t, NewPage(nil, 2).Next)
+}
@@ -8,6 +8,7 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	jwt "github.com/ncyellow/GophKeeper/internal/server/auth/jwt"
 )
 
 // MockParser is a mock of Parser interface.
@@ -34,16 +35,16 @@ func (m *MockParser) EXPECT() *MockParserMockRecorder {
 }
 
 // ParseToken mocks base method.
-func (m *MockParser) ParseToken(accessToken string, signingKey []byte) (string, error) {
+func (m *MockParser) ParseToken(accessToken string) (*jwt.Claims, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ParseToken", accessToken, signingKey)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "ParseToken", accessToken)
+	ret0, _ := ret[0].(*jwt.Claims)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ParseToken indicates an expected call of ParseToken.
-func (mr *MockParserMockRecorder) ParseToken(accessToken, signingKey interface{}) *gomock.Call {
+func (mr *MockParserMockRecorder) ParseToken(accessToken interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseToken", reflect.TypeOf((*MockParser)(nil).ParseToken), accessToken, signingKey)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseToken", reflect.TypeOf((*MockParser)(nil).ParseToken), accessToken)
 }
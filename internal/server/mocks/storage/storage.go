@@ -7,6 +7,7 @@ package mock_storage
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	models "github.com/ncyellow/GophKeeper/internal/models"
@@ -35,6 +36,20 @@ func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
 	return m.recorder
 }
 
+// AddAuditEvent mocks base method.
+func (m *MockStorage) AddAuditEvent(ctx context.Context, userID int64, event models.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAuditEvent", ctx, userID, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAuditEvent indicates an expected call of AddAuditEvent.
+func (mr *MockStorageMockRecorder) AddAuditEvent(ctx, userID, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAuditEvent", reflect.TypeOf((*MockStorage)(nil).AddAuditEvent), ctx, userID, event)
+}
+
 // AddBinary mocks base method.
 func (m *MockStorage) AddBinary(ctx context.Context, userID int64, binData models.Binary) error {
 	m.ctrl.T.Helper()
@@ -63,6 +78,64 @@ func (mr *MockStorageMockRecorder) AddCard(ctx, userID, card interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCard", reflect.TypeOf((*MockStorage)(nil).AddCard), ctx, userID, card)
 }
 
+// AddChunk mocks base method.
+func (m *MockStorage) AddChunk(ctx context.Context, userID int64, chunk models.BinaryChunk) (*models.BinaryUpload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddChunk", ctx, userID, chunk)
+	ret0, _ := ret[0].(*models.BinaryUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddChunk indicates an expected call of AddChunk.
+func (mr *MockStorageMockRecorder) AddChunk(ctx, userID, chunk interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddChunk", reflect.TypeOf((*MockStorage)(nil).AddChunk), ctx, userID, chunk)
+}
+
+// AddCustomRecord mocks base method.
+func (m *MockStorage) AddCustomRecord(ctx context.Context, userID int64, record models.CustomRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCustomRecord", ctx, userID, record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddCustomRecord indicates an expected call of AddCustomRecord.
+func (mr *MockStorageMockRecorder) AddCustomRecord(ctx, userID, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCustomRecord", reflect.TypeOf((*MockStorage)(nil).AddCustomRecord), ctx, userID, record)
+}
+
+// AddFolder mocks base method.
+func (m *MockStorage) AddFolder(ctx context.Context, userID int64, folder models.Folder) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddFolder", ctx, userID, folder)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddFolder indicates an expected call of AddFolder.
+func (mr *MockStorageMockRecorder) AddFolder(ctx, userID, folder interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddFolder", reflect.TypeOf((*MockStorage)(nil).AddFolder), ctx, userID, folder)
+}
+
+// AddLockout mocks base method.
+func (m *MockStorage) AddLockout(ctx context.Context, userID int64, lockout models.Lockout, delay time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLockout", ctx, userID, lockout, delay)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddLockout indicates an expected call of AddLockout.
+func (mr *MockStorageMockRecorder) AddLockout(ctx, userID, lockout, delay interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLockout", reflect.TypeOf((*MockStorage)(nil).AddLockout), ctx, userID, lockout, delay)
+}
+
 // AddLogin mocks base method.
 func (m *MockStorage) AddLogin(ctx context.Context, userID int64, login models.Login) error {
 	m.ctrl.T.Helper()
@@ -77,6 +150,49 @@ func (mr *MockStorageMockRecorder) AddLogin(ctx, userID, login interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLogin", reflect.TypeOf((*MockStorage)(nil).AddLogin), ctx, userID, login)
 }
 
+// AddLoginFailure mocks base method.
+func (m *MockStorage) AddLoginFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLoginFailure", ctx, key, window)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddLoginFailure indicates an expected call of AddLoginFailure.
+func (mr *MockStorageMockRecorder) AddLoginFailure(ctx, key, window interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLoginFailure", reflect.TypeOf((*MockStorage)(nil).AddLoginFailure), ctx, key, window)
+}
+
+// AddRecordType mocks base method.
+func (m *MockStorage) AddRecordType(ctx context.Context, userID int64, recordType models.RecordType) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRecordType", ctx, userID, recordType)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddRecordType indicates an expected call of AddRecordType.
+func (mr *MockStorageMockRecorder) AddRecordType(ctx, userID, recordType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRecordType", reflect.TypeOf((*MockStorage)(nil).AddRecordType), ctx, userID, recordType)
+}
+
+// AddSSHKey mocks base method.
+func (m *MockStorage) AddSSHKey(ctx context.Context, userID int64, key models.SSHKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddSSHKey", ctx, userID, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddSSHKey indicates an expected call of AddSSHKey.
+func (mr *MockStorageMockRecorder) AddSSHKey(ctx, userID, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSSHKey", reflect.TypeOf((*MockStorage)(nil).AddSSHKey), ctx, userID, key)
+}
+
 // AddText mocks base method.
 func (m *MockStorage) AddText(ctx context.Context, userID int64, text models.Text) error {
 	m.ctrl.T.Helper()
@@ -91,6 +207,36 @@ func (mr *MockStorageMockRecorder) AddText(ctx, userID, text interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddText", reflect.TypeOf((*MockStorage)(nil).AddText), ctx, userID, text)
 }
 
+// AddVault mocks base method.
+func (m *MockStorage) AddVault(ctx context.Context, userID int64, name string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddVault", ctx, userID, name)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddVault indicates an expected call of AddVault.
+func (mr *MockStorageMockRecorder) AddVault(ctx, userID, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddVault", reflect.TypeOf((*MockStorage)(nil).AddVault), ctx, userID, name)
+}
+
+// AuditEvents mocks base method.
+func (m *MockStorage) AuditEvents(ctx context.Context, userID, before int64, limit int) ([]models.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuditEvents", ctx, userID, before, limit)
+	ret0, _ := ret[0].([]models.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuditEvents indicates an expected call of AuditEvents.
+func (mr *MockStorageMockRecorder) AuditEvents(ctx, userID, before, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuditEvents", reflect.TypeOf((*MockStorage)(nil).AuditEvents), ctx, userID, before, limit)
+}
+
 // Binary mocks base method.
 func (m *MockStorage) Binary(ctx context.Context, userID int64, binID string) (*models.Binary, error) {
 	m.ctrl.T.Helper()
@@ -106,6 +252,36 @@ func (mr *MockStorageMockRecorder) Binary(ctx, userID, binID interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Binary", reflect.TypeOf((*MockStorage)(nil).Binary), ctx, userID, binID)
 }
 
+// BinaryChunk mocks base method.
+func (m *MockStorage) BinaryChunk(ctx context.Context, userID int64, contentID string, seq int64) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BinaryChunk", ctx, userID, contentID, seq)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BinaryChunk indicates an expected call of BinaryChunk.
+func (mr *MockStorageMockRecorder) BinaryChunk(ctx, userID, contentID, seq interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BinaryChunk", reflect.TypeOf((*MockStorage)(nil).BinaryChunk), ctx, userID, contentID, seq)
+}
+
+// BinaryUpload mocks base method.
+func (m *MockStorage) BinaryUpload(ctx context.Context, userID int64, uploadID string) (*models.BinaryUpload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BinaryUpload", ctx, userID, uploadID)
+	ret0, _ := ret[0].(*models.BinaryUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BinaryUpload indicates an expected call of BinaryUpload.
+func (mr *MockStorageMockRecorder) BinaryUpload(ctx, userID, uploadID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BinaryUpload", reflect.TypeOf((*MockStorage)(nil).BinaryUpload), ctx, userID, uploadID)
+}
+
 // Card mocks base method.
 func (m *MockStorage) Card(ctx context.Context, userID int64, cardID string) (*models.Card, error) {
 	m.ctrl.T.Helper()
@@ -121,6 +297,20 @@ func (mr *MockStorageMockRecorder) Card(ctx, userID, cardID interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Card", reflect.TypeOf((*MockStorage)(nil).Card), ctx, userID, cardID)
 }
 
+// ChangeLogin mocks base method.
+func (m *MockStorage) ChangeLogin(ctx context.Context, userID int64, login string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeLogin", ctx, userID, login)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangeLogin indicates an expected call of ChangeLogin.
+func (mr *MockStorageMockRecorder) ChangeLogin(ctx, userID, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeLogin", reflect.TypeOf((*MockStorage)(nil).ChangeLogin), ctx, userID, login)
+}
+
 // Close mocks base method.
 func (m *MockStorage) Close() {
 	m.ctrl.T.Helper()
@@ -133,6 +323,50 @@ func (mr *MockStorageMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockStorage)(nil).Close))
 }
 
+// CompleteUpload mocks base method.
+func (m *MockStorage) CompleteUpload(ctx context.Context, userID int64, binID, uploadID string, version int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteUpload", ctx, userID, binID, uploadID, version)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteUpload indicates an expected call of CompleteUpload.
+func (mr *MockStorageMockRecorder) CompleteUpload(ctx, userID, binID, uploadID, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteUpload", reflect.TypeOf((*MockStorage)(nil).CompleteUpload), ctx, userID, binID, uploadID, version)
+}
+
+// CreateSession mocks base method.
+func (m *MockStorage) CreateSession(ctx context.Context, session models.Session, refreshHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", ctx, session, refreshHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockStorageMockRecorder) CreateSession(ctx, session, refreshHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockStorage)(nil).CreateSession), ctx, session, refreshHash)
+}
+
+// CustomRecord mocks base method.
+func (m *MockStorage) CustomRecord(ctx context.Context, userID int64, recordID string) (*models.CustomRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CustomRecord", ctx, userID, recordID)
+	ret0, _ := ret[0].(*models.CustomRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CustomRecord indicates an expected call of CustomRecord.
+func (mr *MockStorageMockRecorder) CustomRecord(ctx, userID, recordID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CustomRecord", reflect.TypeOf((*MockStorage)(nil).CustomRecord), ctx, userID, recordID)
+}
+
 // DeleteBinary mocks base method.
 func (m *MockStorage) DeleteBinary(ctx context.Context, userID int64, binID string) error {
 	m.ctrl.T.Helper()
@@ -161,6 +395,34 @@ func (mr *MockStorageMockRecorder) DeleteCard(ctx, userID, cardID interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCard", reflect.TypeOf((*MockStorage)(nil).DeleteCard), ctx, userID, cardID)
 }
 
+// DeleteCustomRecord mocks base method.
+func (m *MockStorage) DeleteCustomRecord(ctx context.Context, userID int64, recordID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCustomRecord", ctx, userID, recordID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCustomRecord indicates an expected call of DeleteCustomRecord.
+func (mr *MockStorageMockRecorder) DeleteCustomRecord(ctx, userID, recordID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCustomRecord", reflect.TypeOf((*MockStorage)(nil).DeleteCustomRecord), ctx, userID, recordID)
+}
+
+// DeleteFolder mocks base method.
+func (m *MockStorage) DeleteFolder(ctx context.Context, userID, folderID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFolder", ctx, userID, folderID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFolder indicates an expected call of DeleteFolder.
+func (mr *MockStorageMockRecorder) DeleteFolder(ctx, userID, folderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFolder", reflect.TypeOf((*MockStorage)(nil).DeleteFolder), ctx, userID, folderID)
+}
+
 // DeleteLogin mocks base method.
 func (m *MockStorage) DeleteLogin(ctx context.Context, userID int64, loginID string) error {
 	m.ctrl.T.Helper()
@@ -175,6 +437,34 @@ func (mr *MockStorageMockRecorder) DeleteLogin(ctx, userID, loginID interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLogin", reflect.TypeOf((*MockStorage)(nil).DeleteLogin), ctx, userID, loginID)
 }
 
+// DeleteRecordType mocks base method.
+func (m *MockStorage) DeleteRecordType(ctx context.Context, userID int64, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRecordType", ctx, userID, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRecordType indicates an expected call of DeleteRecordType.
+func (mr *MockStorageMockRecorder) DeleteRecordType(ctx, userID, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecordType", reflect.TypeOf((*MockStorage)(nil).DeleteRecordType), ctx, userID, name)
+}
+
+// DeleteSSHKey mocks base method.
+func (m *MockStorage) DeleteSSHKey(ctx context.Context, userID int64, keyID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSSHKey", ctx, userID, keyID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSSHKey indicates an expected call of DeleteSSHKey.
+func (mr *MockStorageMockRecorder) DeleteSSHKey(ctx, userID, keyID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSSHKey", reflect.TypeOf((*MockStorage)(nil).DeleteSSHKey), ctx, userID, keyID)
+}
+
 // DeleteText mocks base method.
 func (m *MockStorage) DeleteText(ctx context.Context, userID int64, textID string) error {
 	m.ctrl.T.Helper()
@@ -189,6 +479,121 @@ func (mr *MockStorageMockRecorder) DeleteText(ctx, userID, textID interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteText", reflect.TypeOf((*MockStorage)(nil).DeleteText), ctx, userID, textID)
 }
 
+// DeleteUser mocks base method.
+func (m *MockStorage) DeleteUser(ctx context.Context, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockStorageMockRecorder) DeleteUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockStorage)(nil).DeleteUser), ctx, userID)
+}
+
+// DeleteVault mocks base method.
+func (m *MockStorage) DeleteVault(ctx context.Context, userID, vaultID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteVault", ctx, userID, vaultID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteVault indicates an expected call of DeleteVault.
+func (mr *MockStorageMockRecorder) DeleteVault(ctx, userID, vaultID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVault", reflect.TypeOf((*MockStorage)(nil).DeleteVault), ctx, userID, vaultID)
+}
+
+// DisableTOTP mocks base method.
+func (m *MockStorage) DisableTOTP(ctx context.Context, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTOTP", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTOTP indicates an expected call of DisableTOTP.
+func (mr *MockStorageMockRecorder) DisableTOTP(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTOTP", reflect.TypeOf((*MockStorage)(nil).DisableTOTP), ctx, userID)
+}
+
+// EmptyTrash mocks base method.
+func (m *MockStorage) EmptyTrash(ctx context.Context, userID int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EmptyTrash", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EmptyTrash indicates an expected call of EmptyTrash.
+func (mr *MockStorageMockRecorder) EmptyTrash(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EmptyTrash", reflect.TypeOf((*MockStorage)(nil).EmptyTrash), ctx, userID)
+}
+
+// EnableTOTP mocks base method.
+func (m *MockStorage) EnableTOTP(ctx context.Context, userID, counter int64, recoveryHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTOTP", ctx, userID, counter, recoveryHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableTOTP indicates an expected call of EnableTOTP.
+func (mr *MockStorageMockRecorder) EnableTOTP(ctx, userID, counter, recoveryHashes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTOTP", reflect.TypeOf((*MockStorage)(nil).EnableTOTP), ctx, userID, counter, recoveryHashes)
+}
+
+// Folders mocks base method.
+func (m *MockStorage) Folders(ctx context.Context, userID, vaultID int64) ([]models.Folder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Folders", ctx, userID, vaultID)
+	ret0, _ := ret[0].([]models.Folder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Folders indicates an expected call of Folders.
+func (mr *MockStorageMockRecorder) Folders(ctx, userID, vaultID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Folders", reflect.TypeOf((*MockStorage)(nil).Folders), ctx, userID, vaultID)
+}
+
+// LockLogin mocks base method.
+func (m *MockStorage) LockLogin(ctx context.Context, key string, delay time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockLogin", ctx, key, delay)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockLogin indicates an expected call of LockLogin.
+func (mr *MockStorageMockRecorder) LockLogin(ctx, key, delay interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockLogin", reflect.TypeOf((*MockStorage)(nil).LockLogin), ctx, key, delay)
+}
+
+// Lockouts mocks base method.
+func (m *MockStorage) Lockouts(ctx context.Context, userID int64) ([]models.Lockout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lockouts", ctx, userID)
+	ret0, _ := ret[0].([]models.Lockout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lockouts indicates an expected call of Lockouts.
+func (mr *MockStorageMockRecorder) Lockouts(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lockouts", reflect.TypeOf((*MockStorage)(nil).Lockouts), ctx, userID)
+}
+
 // Login mocks base method.
 func (m *MockStorage) Login(ctx context.Context, userID int64, loginID string) (*models.Login, error) {
 	m.ctrl.T.Helper()
@@ -204,62 +609,651 @@ func (mr *MockStorageMockRecorder) Login(ctx, userID, loginID interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockStorage)(nil).Login), ctx, userID, loginID)
 }
 
-// Register mocks base method.
-func (m *MockStorage) Register(ctx context.Context, user models.User) (int64, error) {
+// LoginRetryAfter mocks base method.
+func (m *MockStorage) LoginRetryAfter(ctx context.Context, keys []string) (time.Duration, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Register", ctx, user)
-	ret0, _ := ret[0].(int64)
+	ret := m.ctrl.Call(m, "LoginRetryAfter", ctx, keys)
+	ret0, _ := ret[0].(time.Duration)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Register indicates an expected call of Register.
-func (mr *MockStorageMockRecorder) Register(ctx, user interface{}) *gomock.Call {
+// LoginRetryAfter indicates an expected call of LoginRetryAfter.
+func (mr *MockStorageMockRecorder) LoginRetryAfter(ctx, keys interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockStorage)(nil).Register), ctx, user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginRetryAfter", reflect.TypeOf((*MockStorage)(nil).LoginRetryAfter), ctx, keys)
 }
 
-// Text mocks base method.
-func (m *MockStorage) Text(ctx context.Context, userID int64, textID string) (*models.Text, error) {
+// MoveRecord mocks base method.
+func (m *MockStorage) MoveRecord(ctx context.Context, userID int64, kind models.Kind, id string, location models.Location) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Text", ctx, userID, textID)
-	ret0, _ := ret[0].(*models.Text)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "MoveRecord", ctx, userID, kind, id, location)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// Text indicates an expected call of Text.
-func (mr *MockStorageMockRecorder) Text(ctx, userID, textID interface{}) *gomock.Call {
+// MoveRecord indicates an expected call of MoveRecord.
+func (mr *MockStorageMockRecorder) MoveRecord(ctx, userID, kind, id, location interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Text", reflect.TypeOf((*MockStorage)(nil).Text), ctx, userID, textID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveRecord", reflect.TypeOf((*MockStorage)(nil).MoveRecord), ctx, userID, kind, id, location)
 }
 
-// User mocks base method.
-func (m *MockStorage) User(ctx context.Context, login, password string) (*models.User, error) {
+// PurgeBlobs mocks base method.
+func (m *MockStorage) PurgeBlobs(ctx context.Context, retention time.Duration) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "User", ctx, login, password)
-	ret0, _ := ret[0].(*models.User)
+	ret := m.ctrl.Call(m, "PurgeBlobs", ctx, retention)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// User indicates an expected call of User.
-func (mr *MockStorageMockRecorder) User(ctx, login, password interface{}) *gomock.Call {
+// PurgeBlobs indicates an expected call of PurgeBlobs.
+func (mr *MockStorageMockRecorder) PurgeBlobs(ctx, retention interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "User", reflect.TypeOf((*MockStorage)(nil).User), ctx, login, password)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeBlobs", reflect.TypeOf((*MockStorage)(nil).PurgeBlobs), ctx, retention)
 }
 
-// UserByLogin mocks base method.
-func (m *MockStorage) UserByLogin(ctx context.Context, login string) (*models.User, error) {
+// PurgeRevisions mocks base method.
+func (m *MockStorage) PurgeRevisions(ctx context.Context, retention time.Duration) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UserByLogin", ctx, login)
-	ret0, _ := ret[0].(*models.User)
+	ret := m.ctrl.Call(m, "PurgeRevisions", ctx, retention)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UserByLogin indicates an expected call of UserByLogin.
+// PurgeRevisions indicates an expected call of PurgeRevisions.
+func (mr *MockStorageMockRecorder) PurgeRevisions(ctx, retention interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeRevisions", reflect.TypeOf((*MockStorage)(nil).PurgeRevisions), ctx, retention)
+}
+
+// PurgeTrash mocks base method.
+func (m *MockStorage) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeTrash", ctx, retention)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeTrash indicates an expected call of PurgeTrash.
+func (mr *MockStorageMockRecorder) PurgeTrash(ctx, retention interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeTrash", reflect.TypeOf((*MockStorage)(nil).PurgeTrash), ctx, retention)
+}
+
+// PurgeUploads mocks base method.
+func (m *MockStorage) PurgeUploads(ctx context.Context, retention time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeUploads", ctx, retention)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeUploads indicates an expected call of PurgeUploads.
+func (mr *MockStorageMockRecorder) PurgeUploads(ctx, retention interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeUploads", reflect.TypeOf((*MockStorage)(nil).PurgeUploads), ctx, retention)
+}
+
+// RecordType mocks base method.
+func (m *MockStorage) RecordType(ctx context.Context, userID int64, name string) (*models.RecordType, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordType", ctx, userID, name)
+	ret0, _ := ret[0].(*models.RecordType)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordType indicates an expected call of RecordType.
+func (mr *MockStorageMockRecorder) RecordType(ctx, userID, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordType", reflect.TypeOf((*MockStorage)(nil).RecordType), ctx, userID, name)
+}
+
+// RecordTypes mocks base method.
+func (m *MockStorage) RecordTypes(ctx context.Context, userID int64) ([]models.RecordType, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordTypes", ctx, userID)
+	ret0, _ := ret[0].([]models.RecordType)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordTypes indicates an expected call of RecordTypes.
+func (mr *MockStorageMockRecorder) RecordTypes(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordTypes", reflect.TypeOf((*MockStorage)(nil).RecordTypes), ctx, userID)
+}
+
+// Records mocks base method.
+func (m *MockStorage) Records(ctx context.Context, userID int64, kind models.Kind, opts models.ListOptions) ([]models.RecordInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Records", ctx, userID, kind, opts)
+	ret0, _ := ret[0].([]models.RecordInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Records indicates an expected call of Records.
+func (mr *MockStorageMockRecorder) Records(ctx, userID, kind, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Records", reflect.TypeOf((*MockStorage)(nil).Records), ctx, userID, kind, opts)
+}
+
+// RefreshSession mocks base method.
+func (m *MockStorage) RefreshSession(ctx context.Context, sessionID, oldHash, newHash string, expiresAt time.Time) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshSession", ctx, sessionID, oldHash, newHash, expiresAt)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshSession indicates an expected call of RefreshSession.
+func (mr *MockStorageMockRecorder) RefreshSession(ctx, sessionID, oldHash, newHash, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshSession", reflect.TypeOf((*MockStorage)(nil).RefreshSession), ctx, sessionID, oldHash, newHash, expiresAt)
+}
+
+// Register mocks base method.
+func (m *MockStorage) Register(ctx context.Context, user models.User) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Register", ctx, user)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Register indicates an expected call of Register.
+func (mr *MockStorageMockRecorder) Register(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockStorage)(nil).Register), ctx, user)
+}
+
+// RenameVault mocks base method.
+func (m *MockStorage) RenameVault(ctx context.Context, userID, vaultID int64, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameVault", ctx, userID, vaultID, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RenameVault indicates an expected call of RenameVault.
+func (mr *MockStorageMockRecorder) RenameVault(ctx, userID, vaultID, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameVault", reflect.TypeOf((*MockStorage)(nil).RenameVault), ctx, userID, vaultID, name)
+}
+
+// ResetLoginFailures mocks base method.
+func (m *MockStorage) ResetLoginFailures(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetLoginFailures", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetLoginFailures indicates an expected call of ResetLoginFailures.
+func (mr *MockStorageMockRecorder) ResetLoginFailures(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetLoginFailures", reflect.TypeOf((*MockStorage)(nil).ResetLoginFailures), ctx, key)
+}
+
+// RestoreRevision mocks base method.
+func (m *MockStorage) RestoreRevision(ctx context.Context, userID int64, kind models.Kind, id string, revisionID int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreRevision", ctx, userID, kind, id, revisionID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreRevision indicates an expected call of RestoreRevision.
+func (mr *MockStorageMockRecorder) RestoreRevision(ctx, userID, kind, id, revisionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreRevision", reflect.TypeOf((*MockStorage)(nil).RestoreRevision), ctx, userID, kind, id, revisionID)
+}
+
+// RestoreTrash mocks base method.
+func (m *MockStorage) RestoreTrash(ctx context.Context, userID int64, kind models.Kind, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreTrash", ctx, userID, kind, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreTrash indicates an expected call of RestoreTrash.
+func (mr *MockStorageMockRecorder) RestoreTrash(ctx, userID, kind, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreTrash", reflect.TypeOf((*MockStorage)(nil).RestoreTrash), ctx, userID, kind, id)
+}
+
+// Revision mocks base method.
+func (m *MockStorage) Revision(ctx context.Context, userID int64, kind models.Kind, id string, revisionID int64) (*models.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revision", ctx, userID, kind, id, revisionID)
+	ret0, _ := ret[0].(*models.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revision indicates an expected call of Revision.
+func (mr *MockStorageMockRecorder) Revision(ctx, userID, kind, id, revisionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revision", reflect.TypeOf((*MockStorage)(nil).Revision), ctx, userID, kind, id, revisionID)
+}
+
+// Revisions mocks base method.
+func (m *MockStorage) Revisions(ctx context.Context, userID int64, kind models.Kind, id string) ([]models.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revisions", ctx, userID, kind, id)
+	ret0, _ := ret[0].([]models.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revisions indicates an expected call of Revisions.
+func (mr *MockStorageMockRecorder) Revisions(ctx, userID, kind, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revisions", reflect.TypeOf((*MockStorage)(nil).Revisions), ctx, userID, kind, id)
+}
+
+// RevokeOtherSessions mocks base method.
+func (m *MockStorage) RevokeOtherSessions(ctx context.Context, userID int64, sessionID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeOtherSessions", ctx, userID, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeOtherSessions indicates an expected call of RevokeOtherSessions.
+func (mr *MockStorageMockRecorder) RevokeOtherSessions(ctx, userID, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeOtherSessions", reflect.TypeOf((*MockStorage)(nil).RevokeOtherSessions), ctx, userID, sessionID)
+}
+
+// RevokeReusedSession mocks base method.
+func (m *MockStorage) RevokeReusedSession(ctx context.Context, sessionID, hash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeReusedSession", ctx, sessionID, hash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeReusedSession indicates an expected call of RevokeReusedSession.
+func (mr *MockStorageMockRecorder) RevokeReusedSession(ctx, sessionID, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeReusedSession", reflect.TypeOf((*MockStorage)(nil).RevokeReusedSession), ctx, sessionID, hash)
+}
+
+// RevokeSession mocks base method.
+func (m *MockStorage) RevokeSession(ctx context.Context, userID int64, sessionID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, userID, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockStorageMockRecorder) RevokeSession(ctx, userID, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockStorage)(nil).RevokeSession), ctx, userID, sessionID)
+}
+
+// SSHKey mocks base method.
+func (m *MockStorage) SSHKey(ctx context.Context, userID int64, keyID string) (*models.SSHKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SSHKey", ctx, userID, keyID)
+	ret0, _ := ret[0].(*models.SSHKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SSHKey indicates an expected call of SSHKey.
+func (mr *MockStorageMockRecorder) SSHKey(ctx, userID, keyID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SSHKey", reflect.TypeOf((*MockStorage)(nil).SSHKey), ctx, userID, keyID)
+}
+
+// SessionUser mocks base method.
+func (m *MockStorage) SessionUser(ctx context.Context, sessionID string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SessionUser", ctx, sessionID)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SessionUser indicates an expected call of SessionUser.
+func (mr *MockStorageMockRecorder) SessionUser(ctx, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SessionUser", reflect.TypeOf((*MockStorage)(nil).SessionUser), ctx, sessionID)
+}
+
+// Sessions mocks base method.
+func (m *MockStorage) Sessions(ctx context.Context, userID int64) ([]models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sessions", ctx, userID)
+	ret0, _ := ret[0].([]models.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Sessions indicates an expected call of Sessions.
+func (mr *MockStorageMockRecorder) Sessions(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sessions", reflect.TypeOf((*MockStorage)(nil).Sessions), ctx, userID)
+}
+
+// SetTOTPSecret mocks base method.
+func (m *MockStorage) SetTOTPSecret(ctx context.Context, userID int64, secret string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTOTPSecret", ctx, userID, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetTOTPSecret indicates an expected call of SetTOTPSecret.
+func (mr *MockStorageMockRecorder) SetTOTPSecret(ctx, userID, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTOTPSecret", reflect.TypeOf((*MockStorage)(nil).SetTOTPSecret), ctx, userID, secret)
+}
+
+// SetVaultCheck mocks base method.
+func (m *MockStorage) SetVaultCheck(ctx context.Context, userID int64, check string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetVaultCheck", ctx, userID, check)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetVaultCheck indicates an expected call of SetVaultCheck.
+func (mr *MockStorageMockRecorder) SetVaultCheck(ctx, userID, check interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVaultCheck", reflect.TypeOf((*MockStorage)(nil).SetVaultCheck), ctx, userID, check)
+}
+
+// StartUpload mocks base method.
+func (m *MockStorage) StartUpload(ctx context.Context, userID int64, binID string) (*models.BinaryUpload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartUpload", ctx, userID, binID)
+	ret0, _ := ret[0].(*models.BinaryUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartUpload indicates an expected call of StartUpload.
+func (mr *MockStorageMockRecorder) StartUpload(ctx, userID, binID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartUpload", reflect.TypeOf((*MockStorage)(nil).StartUpload), ctx, userID, binID)
+}
+
+// TOTP mocks base method.
+func (m *MockStorage) TOTP(ctx context.Context, userID int64) (*models.TOTP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TOTP", ctx, userID)
+	ret0, _ := ret[0].(*models.TOTP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TOTP indicates an expected call of TOTP.
+func (mr *MockStorageMockRecorder) TOTP(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TOTP", reflect.TypeOf((*MockStorage)(nil).TOTP), ctx, userID)
+}
+
+// Text mocks base method.
+func (m *MockStorage) Text(ctx context.Context, userID int64, textID string) (*models.Text, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Text", ctx, userID, textID)
+	ret0, _ := ret[0].(*models.Text)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Text indicates an expected call of Text.
+func (mr *MockStorageMockRecorder) Text(ctx, userID, textID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Text", reflect.TypeOf((*MockStorage)(nil).Text), ctx, userID, textID)
+}
+
+// Trash mocks base method.
+func (m *MockStorage) Trash(ctx context.Context, userID int64) ([]models.TrashItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Trash", ctx, userID)
+	ret0, _ := ret[0].([]models.TrashItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Trash indicates an expected call of Trash.
+func (mr *MockStorageMockRecorder) Trash(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trash", reflect.TypeOf((*MockStorage)(nil).Trash), ctx, userID)
+}
+
+// UpdateBinary mocks base method.
+func (m *MockStorage) UpdateBinary(ctx context.Context, userID int64, binData models.Binary) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBinary", ctx, userID, binData)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateBinary indicates an expected call of UpdateBinary.
+func (mr *MockStorageMockRecorder) UpdateBinary(ctx, userID, binData interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBinary", reflect.TypeOf((*MockStorage)(nil).UpdateBinary), ctx, userID, binData)
+}
+
+// UpdateCard mocks base method.
+func (m *MockStorage) UpdateCard(ctx context.Context, userID int64, card models.Card) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCard", ctx, userID, card)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateCard indicates an expected call of UpdateCard.
+func (mr *MockStorageMockRecorder) UpdateCard(ctx, userID, card interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCard", reflect.TypeOf((*MockStorage)(nil).UpdateCard), ctx, userID, card)
+}
+
+// UpdateCustomRecord mocks base method.
+func (m *MockStorage) UpdateCustomRecord(ctx context.Context, userID int64, record models.CustomRecord) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCustomRecord", ctx, userID, record)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateCustomRecord indicates an expected call of UpdateCustomRecord.
+func (mr *MockStorageMockRecorder) UpdateCustomRecord(ctx, userID, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCustomRecord", reflect.TypeOf((*MockStorage)(nil).UpdateCustomRecord), ctx, userID, record)
+}
+
+// UpdateFolder mocks base method.
+func (m *MockStorage) UpdateFolder(ctx context.Context, userID int64, folder models.Folder) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFolder", ctx, userID, folder)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateFolder indicates an expected call of UpdateFolder.
+func (mr *MockStorageMockRecorder) UpdateFolder(ctx, userID, folder interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFolder", reflect.TypeOf((*MockStorage)(nil).UpdateFolder), ctx, userID, folder)
+}
+
+// UpdateLogin mocks base method.
+func (m *MockStorage) UpdateLogin(ctx context.Context, userID int64, login models.Login) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLogin", ctx, userID, login)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateLogin indicates an expected call of UpdateLogin.
+func (mr *MockStorageMockRecorder) UpdateLogin(ctx, userID, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLogin", reflect.TypeOf((*MockStorage)(nil).UpdateLogin), ctx, userID, login)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockStorage) UpdatePassword(ctx context.Context, userID int64, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, userID, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockStorageMockRecorder) UpdatePassword(ctx, userID, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockStorage)(nil).UpdatePassword), ctx, userID, password)
+}
+
+// UpdateRecordType mocks base method.
+func (m *MockStorage) UpdateRecordType(ctx context.Context, userID int64, recordType models.RecordType) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRecordType", ctx, userID, recordType)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRecordType indicates an expected call of UpdateRecordType.
+func (mr *MockStorageMockRecorder) UpdateRecordType(ctx, userID, recordType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRecordType", reflect.TypeOf((*MockStorage)(nil).UpdateRecordType), ctx, userID, recordType)
+}
+
+// UpdateSSHKey mocks base method.
+func (m *MockStorage) UpdateSSHKey(ctx context.Context, userID int64, key models.SSHKey) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSSHKey", ctx, userID, key)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateSSHKey indicates an expected call of UpdateSSHKey.
+func (mr *MockStorageMockRecorder) UpdateSSHKey(ctx, userID, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSSHKey", reflect.TypeOf((*MockStorage)(nil).UpdateSSHKey), ctx, userID, key)
+}
+
+// UpdateText mocks base method.
+func (m *MockStorage) UpdateText(ctx context.Context, userID int64, text models.Text) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateText", ctx, userID, text)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateText indicates an expected call of UpdateText.
+func (mr *MockStorageMockRecorder) UpdateText(ctx, userID, text interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateText", reflect.TypeOf((*MockStorage)(nil).UpdateText), ctx, userID, text)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockStorage) UseRecoveryCode(ctx context.Context, userID int64, hash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, userID, hash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockStorageMockRecorder) UseRecoveryCode(ctx, userID, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockStorage)(nil).UseRecoveryCode), ctx, userID, hash)
+}
+
+// UseTOTPCounter mocks base method.
+func (m *MockStorage) UseTOTPCounter(ctx context.Context, userID, counter int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTOTPCounter", ctx, userID, counter)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseTOTPCounter indicates an expected call of UseTOTPCounter.
+func (mr *MockStorageMockRecorder) UseTOTPCounter(ctx, userID, counter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTOTPCounter", reflect.TypeOf((*MockStorage)(nil).UseTOTPCounter), ctx, userID, counter)
+}
+
+// User mocks base method.
+func (m *MockStorage) User(ctx context.Context, login string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "User", ctx, login)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// User indicates an expected call of User.
+func (mr *MockStorageMockRecorder) User(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "User", reflect.TypeOf((*MockStorage)(nil).User), ctx, login)
+}
+
+// UserByLogin mocks base method.
+func (m *MockStorage) UserByLogin(ctx context.Context, login string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserByLogin", ctx, login)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserByLogin indicates an expected call of UserByLogin.
 func (mr *MockStorageMockRecorder) UserByLogin(ctx, login interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserByLogin", reflect.TypeOf((*MockStorage)(nil).UserByLogin), ctx, login)
 }
+
+// VaultParams mocks base method.
+func (m *MockStorage) VaultParams(ctx context.Context, userID int64) (*models.VaultParams, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VaultParams", ctx, userID)
+	ret0, _ := ret[0].(*models.VaultParams)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VaultParams indicates an expected call of VaultParams.
+func (mr *MockStorageMockRecorder) VaultParams(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VaultParams", reflect.TypeOf((*MockStorage)(nil).VaultParams), ctx, userID)
+}
+
+// Vaults mocks base method.
+func (m *MockStorage) Vaults(ctx context.Context, userID int64) ([]models.Vault, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Vaults", ctx, userID)
+	ret0, _ := ret[0].([]models.Vault)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Vaults indicates an expected call of Vaults.
+func (mr *MockStorageMockRecorder) Vaults(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Vaults", reflect.TypeOf((*MockStorage)(nil).Vaults), ctx, userID)
+}
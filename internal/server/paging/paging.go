@@ -0,0 +1,30 @@
+// Package paging limits the pages of the lists requested by the clients and finds the cursors of the next pages.
+// It is shared by the record lists and the audit log
+package paging
+
+// Limits of the page
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+// Limit returns the size of the page requested by the client, DefaultLimit if it is not set
+func Limit(limit int) int {
+	if limit <= 0 {
+		return DefaultLimit
+	}
+	if limit > MaxLimit {
+		return MaxLimit
+	}
+	return limit
+}
+
+// Next returns the cursor of the page after the items read with the limit. Only a full page has the next one,
+// the zero cursor is returned for the last page
+func Next[T any, C any](items []T, limit int, cursor func(T) C) C {
+	var next C
+	if len(items) > 0 && len(items) == limit {
+		next = cursor(items[len(items)-1])
+	}
+	return next
+}
@@ -0,0 +1,21 @@
+package paging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimit(t *testing.T) {
+	assert.Equal(t, DefaultLimit, Limit(0))
+	assert.Equal(t, DefaultLimit, Limit(-1))
+	assert.Equal(t, 10, Limit(10))
+	assert.Equal(t, MaxLimit, Limit(1000))
+}
+
+func TestNext(t *testing.T) {
+	double := func(item int) int { return item * 2 }
+	assert.Equal(t, 6, Next([]int{1, 3}, 2, double))
+	assert.Zero(t, Next([]int{1, 3}, 3, double))
+	assert.Zero(t, Next(nil, 2, double))
+}
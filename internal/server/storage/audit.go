@@ -0,0 +1,302 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/audit"
+)
+
+// AuditStorage decorator over any Storage that appends the successful reads, additions, updates and deletions
+// of the records to the audit log. The transport and the ip address are taken from the audit.Source of the context,
+// the reads of the context marked by audit.Internal are not logged.
+// A failed write of the event is logged and does not fail the request, the record is already read or changed by then
+type AuditStorage struct {
+	Storage
+}
+
+// NewAuditStorage constructor
+func NewAuditStorage(store Storage) *AuditStorage {
+	return &AuditStorage{Storage: store}
+}
+
+// AddAuditEvent fills the source of the event from the context if it is not set
+func (a *AuditStorage) AddAuditEvent(ctx context.Context, userID int64, event models.AuditEvent) error {
+	source := audit.SourceFrom(ctx)
+	if event.Transport == "" {
+		event.Transport = source.Transport
+	}
+	if event.IP == "" {
+		event.IP = source.IP
+	}
+	return a.Storage.AddAuditEvent(ctx, userID, event)
+}
+
+func (a *AuditStorage) AddCard(ctx context.Context, userID int64, card models.Card) error {
+	if err := a.Storage.AddCard(ctx, userID, card); err != nil {
+		return err
+	}
+	a.record(ctx, userID, models.AuditAdd, models.KindCard, card.ID)
+	return nil
+}
+
+func (a *AuditStorage) Card(ctx context.Context, userID int64, cardID string) (*models.Card, error) {
+	card, err := a.Storage.Card(ctx, userID, cardID)
+	if err != nil {
+		return nil, err
+	}
+	a.record(ctx, userID, models.AuditRead, models.KindCard, cardID)
+	return card, nil
+}
+
+func (a *AuditStorage) UpdateCard(ctx context.Context, userID int64, card models.Card) (int64, error) {
+	version, err := a.Storage.UpdateCard(ctx, userID, card)
+	if err != nil {
+		return 0, err
+	}
+	a.record(ctx, userID, models.AuditUpdate, models.KindCard, card.ID)
+	return version, nil
+}
+
+func (a *AuditStorage) DeleteCard(ctx context.Context, userID int64, cardID string) error {
+	if err := a.Storage.DeleteCard(ctx, userID, cardID); err != nil {
+		return err
+	}
+	a.record(ctx, userID, models.AuditDelete, models.KindCard, cardID)
+	return nil
+}
+
+func (a *AuditStorage) AddLogin(ctx context.Context, userID int64, login models.Login) error {
+	if err := a.Storage.AddLogin(ctx, userID, login); err != nil {
+		return err
+	}
+	a.record(ctx, userID, models.AuditAdd, models.KindLogin, login.ID)
+	return nil
+}
+
+func (a *AuditStorage) Login(ctx context.Context, userID int64, loginID string) (*models.Login, error) {
+	login, err := a.Storage.Login(ctx, userID, loginID)
+	if err != nil {
+		return nil, err
+	}
+	a.record(ctx, userID, models.AuditRead, models.KindLogin, loginID)
+	return login, nil
+}
+
+func (a *AuditStorage) UpdateLogin(ctx context.Context, userID int64, login models.Login) (int64, error) {
+	version, err := a.Storage.UpdateLogin(ctx, userID, login)
+	if err != nil {
+		return 0, err
+	}
+	a.record(ctx, userID, models.AuditUpdate, models.KindLogin, login.ID)
+	return version, nil
+}
+
+func (a *AuditStorage) DeleteLogin(ctx context.Context, userID int64, loginID string) error {
+	if err := a.Storage.DeleteLogin(ctx, userID, loginID); err != nil {
+		return err
+	}
+	a.record(ctx, userID, models.AuditDelete, models.KindLogin, loginID)
+	return nil
+}
+
+func (a *AuditStorage) AddText(ctx context.Context, userID int64, text models.Text) error {
+	if err := a.Storage.AddText(ctx, userID, text); err != nil {
+		return err
+	}
+	a.record(ctx, userID, models.AuditAdd, models.KindText, text.ID)
+	return nil
+}
+
+func (a *AuditStorage) Text(ctx context.Context, userID int64, textID string) (*models.Text, error) {
+	text, err := a.Storage.Text(ctx, userID, textID)
+	if err != nil {
+		return nil, err
+	}
+	a.record(ctx, userID, models.AuditRead, models.KindText, textID)
+	return text, nil
+}
+
+func (a *AuditStorage) UpdateText(ctx context.Context, userID int64, text models.Text) (int64, error) {
+	version, err := a.Storage.UpdateText(ctx, userID, text)
+	if err != nil {
+		return 0, err
+	}
+	a.record(ctx, userID, models.AuditUpdate, models.KindText, text.ID)
+	return version, nil
+}
+
+func (a *AuditStorage) DeleteText(ctx context.Context, userID int64, textID string) error {
+	if err := a.Storage.DeleteText(ctx, userID, textID); err != nil {
+		return err
+	}
+	a.record(ctx, userID, models.AuditDelete, models.KindText, textID)
+	return nil
+}
+
+func (a *AuditStorage) AddBinary(ctx context.Context, userID int64, binData models.Binary) error {
+	if err := a.Storage.AddBinary(ctx, userID, binData); err != nil {
+		return err
+	}
+	a.record(ctx, userID, models.AuditAdd, models.KindBinary, binData.ID)
+	return nil
+}
+
+func (a *AuditStorage) Binary(ctx context.Context, userID int64, binID string) (*models.Binary, error) {
+	binData, err := a.Storage.Binary(ctx, userID, binID)
+	if err != nil {
+		return nil, err
+	}
+	a.record(ctx, userID, models.AuditRead, models.KindBinary, binID)
+	return binData, nil
+}
+
+func (a *AuditStorage) UpdateBinary(ctx context.Context, userID int64, binData models.Binary) (int64, error) {
+	version, err := a.Storage.UpdateBinary(ctx, userID, binData)
+	if err != nil {
+		return 0, err
+	}
+	a.record(ctx, userID, models.AuditUpdate, models.KindBinary, binData.ID)
+	return version, nil
+}
+
+func (a *AuditStorage) DeleteBinary(ctx context.Context, userID int64, binID string) error {
+	if err := a.Storage.DeleteBinary(ctx, userID, binID); err != nil {
+		return err
+	}
+	a.record(ctx, userID, models.AuditDelete, models.KindBinary, binID)
+	return nil
+}
+
+func (a *AuditStorage) CompleteUpload(ctx context.Context, userID int64, binID string, uploadID string, version int64) (int64, error) {
+	newVersion, err := a.Storage.CompleteUpload(ctx, userID, binID, uploadID, version)
+	if err != nil {
+		return 0, err
+	}
+	a.record(ctx, userID, models.AuditUpdate, models.KindBinary, binID)
+	return newVersion, nil
+}
+
+func (a *AuditStorage) AddSSHKey(ctx context.Context, userID int64, key models.SSHKey) error {
+	if err := a.Storage.AddSSHKey(ctx, userID, key); err != nil {
+		return err
+	}
+	a.record(ctx, userID, models.AuditAdd, models.KindSSH, key.ID)
+	return nil
+}
+
+func (a *AuditStorage) SSHKey(ctx context.Context, userID int64, keyID string) (*models.SSHKey, error) {
+	key, err := a.Storage.SSHKey(ctx, userID, keyID)
+	if err != nil {
+		return nil, err
+	}
+	a.record(ctx, userID, models.AuditRead, models.KindSSH, keyID)
+	return key, nil
+}
+
+func (a *AuditStorage) UpdateSSHKey(ctx context.Context, userID int64, key models.SSHKey) (int64, error) {
+	version, err := a.Storage.UpdateSSHKey(ctx, userID, key)
+	if err != nil {
+		return 0, err
+	}
+	a.record(ctx, userID, models.AuditUpdate, models.KindSSH, key.ID)
+	return version, nil
+}
+
+func (a *AuditStorage) DeleteSSHKey(ctx context.Context, userID int64, keyID string) error {
+	if err := a.Storage.DeleteSSHKey(ctx, userID, keyID); err != nil {
+		return err
+	}
+	a.record(ctx, userID, models.AuditDelete, models.KindSSH, keyID)
+	return nil
+}
+
+func (a *AuditStorage) AddCustomRecord(ctx context.Context, userID int64, record models.CustomRecord) error {
+	if err := a.Storage.AddCustomRecord(ctx, userID, record); err != nil {
+		return err
+	}
+	a.record(ctx, userID, models.AuditAdd, models.KindCustom, record.ID)
+	return nil
+}
+
+func (a *AuditStorage) CustomRecord(ctx context.Context, userID int64, recordID string) (*models.CustomRecord, error) {
+	record, err := a.Storage.CustomRecord(ctx, userID, recordID)
+	if err != nil {
+		return nil, err
+	}
+	a.record(ctx, userID, models.AuditRead, models.KindCustom, recordID)
+	return record, nil
+}
+
+func (a *AuditStorage) UpdateCustomRecord(ctx context.Context, userID int64, record models.CustomRecord) (int64, error) {
+	version, err := a.Storage.UpdateCustomRecord(ctx, userID, record)
+	if err != nil {
+		return 0, err
+	}
+	a.record(ctx, userID, models.AuditUpdate, models.KindCustom, record.ID)
+	return version, nil
+}
+
+func (a *AuditStorage) DeleteCustomRecord(ctx context.Context, userID int64, recordID string) error {
+	if err := a.Storage.DeleteCustomRecord(ctx, userID, recordID); err != nil {
+		return err
+	}
+	a.record(ctx, userID, models.AuditDelete, models.KindCustom, recordID)
+	return nil
+}
+
+func (a *AuditStorage) Revision(ctx context.Context, userID int64, kind models.Kind, id string, revisionID int64) (*models.Revision, error) {
+	revision, err := a.Storage.Revision(ctx, userID, kind, id, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	a.record(ctx, userID, models.AuditRead, kind, id)
+	return revision, nil
+}
+
+func (a *AuditStorage) RestoreRevision(ctx context.Context, userID int64, kind models.Kind, id string, revisionID int64) (int64, error) {
+	version, err := a.Storage.RestoreRevision(ctx, userID, kind, id, revisionID)
+	if err != nil {
+		return 0, err
+	}
+	a.record(ctx, userID, models.AuditRestore, kind, id)
+	return version, nil
+}
+
+func (a *AuditStorage) RestoreTrash(ctx context.Context, userID int64, kind models.Kind, id string) error {
+	if err := a.Storage.RestoreTrash(ctx, userID, kind, id); err != nil {
+		return err
+	}
+	a.record(ctx, userID, models.AuditRestore, kind, id)
+	return nil
+}
+
+func (a *AuditStorage) MoveRecord(ctx context.Context, userID int64, kind models.Kind, id string, location models.Location) error {
+	if err := a.Storage.MoveRecord(ctx, userID, kind, id, location); err != nil {
+		return err
+	}
+	a.record(ctx, userID, models.AuditMove, kind, id)
+	return nil
+}
+
+func (a *AuditStorage) EmptyTrash(ctx context.Context, userID int64) (int64, error) {
+	removed, err := a.Storage.EmptyTrash(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	a.record(ctx, userID, models.AuditEmptyTrash, "", "")
+	return removed, nil
+}
+
+// record appends the event of the record to the audit log
+func (a *AuditStorage) record(ctx context.Context, userID int64, action string, kind models.Kind, id string) {
+	if action == models.AuditRead && audit.IsInternal(ctx) {
+		return
+	}
+	err := a.AddAuditEvent(ctx, userID, models.AuditEvent{Action: action, Kind: kind, RecordID: id})
+	if err != nil {
+		log.Error().Err(err).Msgf("cant write audit event %s of %s %s of the user %d", action, kind, id, userID)
+	}
+}
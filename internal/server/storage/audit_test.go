@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/audit"
+	mockstorage "github.com/ncyellow/GophKeeper/internal/server/mocks/storage"
+)
+
+func TestAuditStorage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mockstorage.NewMockStorage(ctrl)
+	store := NewAuditStorage(mock)
+	ctx := audit.WithSource(context.Background(), audit.Source{Transport: audit.GRPC, IP: "10.0.0.1"})
+
+	// The successful read is recorded with the source of the request
+	card := &models.Card{ID: "card"}
+	mock.EXPECT().Card(ctx, int64(1), "card").Return(card, nil)
+	mock.EXPECT().AddAuditEvent(ctx, int64(1), models.AuditEvent{
+		Action: models.AuditRead, Kind: models.KindCard, RecordID: "card", Transport: "grpc", IP: "10.0.0.1",
+	}).Return(nil)
+	result, err := store.Card(ctx, 1, "card")
+	assert.NoError(t, err)
+	assert.Equal(t, card, result)
+
+	// The read made by the server itself is not recorded
+	internal := audit.Internal(ctx)
+	mock.EXPECT().Card(internal, int64(1), "card").Return(card, nil)
+	_, err = store.Card(internal, 1, "card")
+	assert.NoError(t, err)
+
+	// The record not found is not recorded
+	mock.EXPECT().Login(ctx, int64(1), "login").Return(nil, pgx.ErrNoRows)
+	_, err = store.Login(ctx, 1, "login")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	// The update is recorded, the stale version is not
+	mock.EXPECT().UpdateBinary(ctx, int64(1), models.Binary{ID: "bin", Version: 2}).Return(int64(3), nil)
+	mock.EXPECT().AddAuditEvent(ctx, int64(1), models.AuditEvent{
+		Action: models.AuditUpdate, Kind: models.KindBinary, RecordID: "bin", Transport: "grpc", IP: "10.0.0.1",
+	}).Return(nil)
+	version, err := store.UpdateBinary(ctx, 1, models.Binary{ID: "bin", Version: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), version)
+
+	mock.EXPECT().UpdateBinary(ctx, int64(1), models.Binary{ID: "bin", Version: 2}).Return(int64(0), ErrVersionConflict)
+	_, err = store.UpdateBinary(ctx, 1, models.Binary{ID: "bin", Version: 2})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	// The restore of a revision is recorded as well
+	mock.EXPECT().RestoreRevision(ctx, int64(1), models.KindText, "text", int64(4)).Return(int64(5), nil)
+	mock.EXPECT().AddAuditEvent(ctx, int64(1), models.AuditEvent{
+		Action: models.AuditRestore, Kind: models.KindText, RecordID: "text", Transport: "grpc", IP: "10.0.0.1",
+	}).Return(nil)
+	version, err = store.RestoreRevision(ctx, 1, models.KindText, "text", 4)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), version)
+
+	// Emptying the trash is recorded without a record
+	mock.EXPECT().EmptyTrash(ctx, int64(1)).Return(int64(2), nil)
+	mock.EXPECT().AddAuditEvent(ctx, int64(1), models.AuditEvent{
+		Action: models.AuditEmptyTrash, Transport: "grpc", IP: "10.0.0.1",
+	}).Return(nil)
+	removed, err := store.EmptyTrash(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), removed)
+
+	// The move of the record is recorded, the move to a missing folder is not
+	location := models.Location{Vault: 2, Folder: 3}
+	mock.EXPECT().MoveRecord(ctx, int64(1), models.KindLogin, "mail", location).Return(nil)
+	mock.EXPECT().AddAuditEvent(ctx, int64(1), models.AuditEvent{
+		Action: models.AuditMove, Kind: models.KindLogin, RecordID: "mail", Transport: "grpc", IP: "10.0.0.1",
+	}).Return(nil)
+	assert.NoError(t, store.MoveRecord(ctx, 1, models.KindLogin, "mail", location))
+
+	mock.EXPECT().MoveRecord(ctx, int64(1), models.KindLogin, "mail", location).Return(ErrInvalidLocation)
+	assert.ErrorIs(t, store.MoveRecord(ctx, 1, models.KindLogin, "mail", location), ErrInvalidLocation)
+
+	// The failed write of the event does not fail the deletion, it is already done
+	mock.EXPECT().DeleteText(ctx, int64(1), "text").Return(nil)
+	mock.EXPECT().AddAuditEvent(ctx, int64(1), gomock.Any()).Return(assert.AnError)
+	assert.NoError(t, store.DeleteText(ctx, 1, "text"))
+
+	// The source set by the caller is kept
+	event := models.AuditEvent{Actor: "login", Action: models.AuditSignInFailed, IP: "10.0.0.2"}
+	mock.EXPECT().AddAuditEvent(ctx, int64(0), models.AuditEvent{
+		Actor: "login", Action: models.AuditSignInFailed, Transport: "grpc", IP: "10.0.0.2",
+	}).Return(nil)
+	assert.NoError(t, store.AddAuditEvent(ctx, 0, event))
+}
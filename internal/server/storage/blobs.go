@@ -0,0 +1,217 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/blobstore"
+)
+
+// blobBatch how many rows are read at once during the migration of the blobs
+const blobBatch = 100
+
+// InlineBinary the binary record with the content kept in its row
+type InlineBinary struct {
+	RowID  int64
+	UserID int64
+	ID     string
+}
+
+// ChunkBlob the blob of the chunk of the uploaded content
+type ChunkBlob struct {
+	Upload string
+	Seq    int64
+	Sum    string
+	UserID int64
+}
+
+// BlobSource lists the content to be moved to the blob store, used by the migration of the blobs
+type BlobSource interface {
+	// InlineBinaries returns the binary records with the content in the rows ordered by RowID
+	InlineBinaries(ctx context.Context, afterRowID int64, limit int) ([]InlineBinary, error)
+	// ChunkBlobs returns the blobs of the chunks ordered by the upload and the number of the chunk
+	ChunkBlobs(ctx context.Context, after ChunkBlob, limit int) ([]ChunkBlob, error)
+}
+
+// MoveInlineBinaries uploads the content kept in the rows of the binary records to the blob store.
+// The content is read and uploaded through store like the client does it, so it is encrypted at rest
+// the same way as the uploaded content. The upload is completed only if the record was not changed concurrently.
+// Returns the number of moved records and the number of records skipped because of concurrent changes,
+// the skipped ones are handled by the next run
+func MoveInlineBinaries(ctx context.Context, store Storage, source BlobSource) (moved int, skipped int, err error) {
+	var after int64
+	for {
+		binaries, err := source.InlineBinaries(ctx, after, blobBatch)
+		if err != nil {
+			return moved, skipped, err
+		}
+		if len(binaries) == 0 {
+			return moved, skipped, nil
+		}
+		for _, inline := range binaries {
+			after = inline.RowID
+			ok, err := moveBinary(ctx, store, inline)
+			if err != nil {
+				return moved, skipped, err
+			}
+			if ok {
+				moved++
+			} else {
+				skipped++
+			}
+		}
+	}
+}
+
+// moveBinary uploads the content of one record, false if the record was changed or removed concurrently
+func moveBinary(ctx context.Context, store Storage, inline InlineBinary) (bool, error) {
+	binary, err := store.Binary(ctx, inline.UserID, inline.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if binary.ContentID != "" || len(binary.Data) == 0 {
+		return false, nil
+	}
+
+	upload, err := store.StartUpload(ctx, inline.UserID, binary.ID)
+	if err != nil {
+		return false, err
+	}
+	for seq := int64(0); seq*models.BinaryChunkSize < int64(len(binary.Data)); seq++ {
+		end := min((seq+1)*models.BinaryChunkSize, int64(len(binary.Data)))
+		chunk := models.BinaryChunk{Upload: upload.ID, Seq: seq, Data: binary.Data[seq*models.BinaryChunkSize : end]}
+		if _, err := store.AddChunk(ctx, inline.UserID, chunk); err != nil {
+			return false, err
+		}
+	}
+	// The upload of the changed record is abandoned and removed by PurgeUploads
+	_, err = store.CompleteUpload(ctx, inline.UserID, binary.ID, upload.ID, binary.Version)
+	if errors.Is(err, ErrVersionConflict) || errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// CopyBlobs copies the blobs of all the chunks from another backend to the configured one, e.g. after
+// the switch from the postgres backend to S3. The other backend uses the rest of the configuration.
+// The blobs are verified by the checksums before the copy.
+// Returns the number of copied blobs and the number of blobs missing or damaged in the source
+func (p *PgStorage) CopyBlobs(ctx context.Context, backend string) (copied int, missing int, err error) {
+	conf := *p.conf
+	conf.BlobStore = backend
+	from, err := blobstore.New(&conf, p.pool)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var after ChunkBlob
+	for {
+		blobs, err := p.ChunkBlobs(ctx, after, blobBatch)
+		if err != nil {
+			return copied, missing, err
+		}
+		if len(blobs) == 0 {
+			return copied, missing, nil
+		}
+		for _, blob := range blobs {
+			after = blob
+			dir := chunkDir(blob.Upload, blob.Seq)
+			data, err := blobstore.Read(ctx, from, dir, blob.Sum)
+			if errors.Is(err, blobstore.ErrNotFound) || errors.Is(err, blobstore.ErrChecksum) {
+				missing++
+				continue
+			}
+			if err != nil {
+				return copied, missing, err
+			}
+			if _, err := blobstore.Write(ctx, p.blobs, dir, data); err != nil {
+				return copied, missing, err
+			}
+			copied++
+		}
+	}
+}
+
+func (p *PgStorage) InlineBinaries(ctx context.Context, afterRowID int64, limit int) ([]InlineBinary, error) {
+	rows, err := p.pool.Query(ctx, `
+	SELECT "@bin", "user", "id"
+	FROM "bin_data"
+	WHERE "@bin" > $1 AND "blob" IS NULL AND "content" <> '' AND "deleted_at" IS NULL
+	ORDER BY "@bin"
+	LIMIT $2
+	`, afterRowID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []InlineBinary
+	for rows.Next() {
+		var binary InlineBinary
+		if err := rows.Scan(&binary.RowID, &binary.UserID, &binary.ID); err != nil {
+			return nil, err
+		}
+		result = append(result, binary)
+	}
+	return result, rows.Err()
+}
+
+func (p *PgStorage) ChunkBlobs(ctx context.Context, after ChunkBlob, limit int) ([]ChunkBlob, error) {
+	rows, err := p.pool.Query(ctx, `
+	SELECT c."upload", c."seq", c."sha256", u."user"
+	FROM "bin_chunks" c JOIN "bin_uploads" u ON u."id" = c."upload"
+	WHERE (c."upload", c."seq") > ($1, $2)
+	ORDER BY c."upload", c."seq"
+	LIMIT $3
+	`, after.Upload, after.Seq, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []ChunkBlob
+	for rows.Next() {
+		var blob ChunkBlob
+		if err := rows.Scan(&blob.Upload, &blob.Seq, &blob.Sum, &blob.UserID); err != nil {
+			return nil, err
+		}
+		result = append(result, blob)
+	}
+	return result, rows.Err()
+}
+
+func (p *PgStorage) ReadChunkBlob(ctx context.Context, blob ChunkBlob) ([]byte, error) {
+	return blobstore.Read(ctx, p.blobs, chunkDir(blob.Upload, blob.Seq), blob.Sum)
+}
+
+func (p *PgStorage) ReplaceChunkBlob(ctx context.Context, blob ChunkBlob, data []byte) (bool, error) {
+	dir := chunkDir(blob.Upload, blob.Seq)
+	sum, err := blobstore.Write(ctx, p.blobs, dir, data)
+	if err != nil {
+		return false, err
+	}
+	// The chunk is replaced only if it was not removed with its upload in the meantime, the new blob
+	// of the removed one is removed with the upload. The old blob is removed by PurgeBlobs later,
+	// so the downloads started before can finish
+	var replaced bool
+	err = p.pool.QueryRow(ctx, `
+	WITH "chunk" AS (
+		UPDATE "bin_chunks" SET "sha256" = $4
+		WHERE "upload" = $1 AND "seq" = $2 AND "sha256" = $3
+		RETURNING "upload"
+	), "deletion" AS (
+		INSERT INTO "blob_deletions"("prefix")
+		SELECT $5 FROM "chunk"
+		ON CONFLICT ("prefix") DO NOTHING
+	)
+	SELECT EXISTS (SELECT 1 FROM "chunk")
+	`, blob.Upload, blob.Seq, blob.Sum, sum, path.Join(dir, blob.Sum)).Scan(&replaced)
+	return replaced, err
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+	mockstorage "github.com/ncyellow/GophKeeper/internal/server/mocks/storage"
+)
+
+// inlineSource returns the binaries once, like the query after the last row
+type inlineSource []InlineBinary
+
+func (s inlineSource) InlineBinaries(_ context.Context, afterRowID int64, _ int) ([]InlineBinary, error) {
+	var result []InlineBinary
+	for _, binary := range s {
+		if binary.RowID > afterRowID {
+			result = append(result, binary)
+		}
+	}
+	return result, nil
+}
+
+func (s inlineSource) ChunkBlobs(context.Context, ChunkBlob, int) ([]ChunkBlob, error) {
+	return nil, nil
+}
+
+func TestMoveInlineBinaries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mockstorage.NewMockStorage(ctrl)
+	ctx := context.Background()
+	data := bytes.Repeat([]byte("x"), models.BinaryChunkSize+1)
+
+	// The content is split into the chunks and the upload is completed with the read version
+	mock.EXPECT().Binary(ctx, int64(1), "big").Return(&models.Binary{ID: "big", Data: data, Version: 3}, nil)
+	mock.EXPECT().StartUpload(ctx, int64(1), "big").Return(&models.BinaryUpload{ID: "up", BinaryID: "big"}, nil)
+	mock.EXPECT().AddChunk(ctx, int64(1), models.BinaryChunk{Upload: "up", Seq: 0, Data: data[:models.BinaryChunkSize]}).
+		Return(&models.BinaryUpload{}, nil)
+	mock.EXPECT().AddChunk(ctx, int64(1), models.BinaryChunk{Upload: "up", Seq: 1, Data: data[models.BinaryChunkSize:]}).
+		Return(&models.BinaryUpload{}, nil)
+	mock.EXPECT().CompleteUpload(ctx, int64(1), "big", "up", int64(3)).Return(int64(4), nil)
+
+	// The record changed after it was read is skipped
+	mock.EXPECT().Binary(ctx, int64(2), "changed").Return(&models.Binary{ID: "changed", Data: []byte("data"), Version: 1}, nil)
+	mock.EXPECT().StartUpload(ctx, int64(2), "changed").Return(&models.BinaryUpload{ID: "up2", BinaryID: "changed"}, nil)
+	mock.EXPECT().AddChunk(ctx, int64(2), gomock.Any()).Return(&models.BinaryUpload{}, nil)
+	mock.EXPECT().CompleteUpload(ctx, int64(2), "changed", "up2", int64(1)).Return(int64(0), ErrVersionConflict)
+
+	// So is the removed one
+	mock.EXPECT().Binary(ctx, int64(2), "removed").Return(nil, pgx.ErrNoRows)
+
+	moved, skipped, err := MoveInlineBinaries(ctx, mock, inlineSource{
+		{RowID: 1, UserID: 1, ID: "big"},
+		{RowID: 2, UserID: 2, ID: "changed"},
+		{RowID: 5, UserID: 2, ID: "removed"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, moved)
+	assert.Equal(t, 2, skipped)
+}
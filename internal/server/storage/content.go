@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+)
+
+// ContentReader reads the content of the binary. The uploaded content is read chunk by chunk,
+// so only one chunk is kept in memory whatever the size of the content is. The seek is free,
+// the chunk with the offset is found by its number
+type ContentReader struct {
+	ctx    context.Context
+	store  Storage
+	userID int64
+	binary *models.Binary
+	offset int64
+	seq    int64
+	chunk  []byte
+}
+
+// NewContentReader constructor, the binary is read by the caller
+func NewContentReader(ctx context.Context, store Storage, userID int64, binary *models.Binary) *ContentReader {
+	return &ContentReader{ctx: ctx, store: store, userID: userID, binary: binary}
+}
+
+// Size the length of the content
+func (c *ContentReader) Size() int64 {
+	if c.binary.ContentID == "" {
+		return int64(len(c.binary.Data))
+	}
+	return c.binary.Size
+}
+
+func (c *ContentReader) Read(p []byte) (int, error) {
+	if c.offset >= c.Size() {
+		return 0, io.EOF
+	}
+	if c.binary.ContentID == "" {
+		n := copy(p, c.binary.Data[c.offset:])
+		c.offset += int64(n)
+		return n, nil
+	}
+
+	seq := c.offset / models.BinaryChunkSize
+	if c.chunk == nil || c.seq != seq {
+		chunk, err := c.store.BinaryChunk(c.ctx, c.userID, c.binary.ContentID, seq)
+		if err != nil {
+			return 0, err
+		}
+		c.seq, c.chunk = seq, chunk
+	}
+	start := c.offset - seq*models.BinaryChunkSize
+	if start >= int64(len(c.chunk)) {
+		// The chunks do not match the size of the content
+		return 0, io.ErrUnexpectedEOF
+	}
+	n := copy(p, c.chunk[start:])
+	c.offset += int64(n)
+	return n, nil
+}
+
+func (c *ContentReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += c.offset
+	case io.SeekEnd:
+		offset += c.Size()
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	c.offset = offset
+	return offset, nil
+}
@@ -0,0 +1,448 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/storage/keyring"
+)
+
+// dataKeysTTL how long the unwrapped data keys are cached. After the data key rotation
+// other server instances start using the new key no later than after this time
+const dataKeysTTL = 5 * time.Minute
+
+// EncryptedStorage decorator over any Storage that encrypts the secret fields of the records at rest.
+// Every user has own data keys, they are stored in KeyStore wrapped by the master key.
+// Values saved before the encryption was enabled are read as is.
+type EncryptedStorage struct {
+	Storage
+	keys    KeyStore
+	keyring *keyring.Keyring
+
+	mu    sync.Mutex
+	cache map[int64]cachedKeys
+}
+
+// cachedKeys unwrapped data keys of the user
+type cachedKeys struct {
+	keys     *keyring.DataKeys
+	loadedAt time.Time
+}
+
+// NewEncryptedStorage constructor, keys stores the wrapped data keys, ring holds the master keys
+func NewEncryptedStorage(store Storage, keys KeyStore, ring *keyring.Keyring) *EncryptedStorage {
+	return &EncryptedStorage{
+		Storage: store,
+		keys:    keys,
+		keyring: ring,
+		cache:   make(map[int64]cachedKeys),
+	}
+}
+
+func (e *EncryptedStorage) AddCard(ctx context.Context, userID int64, card models.Card) error {
+	if err := e.seal(ctx, userID, models.KindCard, card.ID, cardColumns(&card)); err != nil {
+		return err
+	}
+	return e.Storage.AddCard(ctx, userID, card)
+}
+
+func (e *EncryptedStorage) Card(ctx context.Context, userID int64, cardID string) (*models.Card, error) {
+	card, err := e.Storage.Card(ctx, userID, cardID)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.open(ctx, userID, models.KindCard, card.ID, cardColumns(card)); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+func (e *EncryptedStorage) UpdateCard(ctx context.Context, userID int64, card models.Card) (int64, error) {
+	if err := e.seal(ctx, userID, models.KindCard, card.ID, cardColumns(&card)); err != nil {
+		return 0, err
+	}
+	return e.Storage.UpdateCard(ctx, userID, card)
+}
+
+func (e *EncryptedStorage) AddLogin(ctx context.Context, userID int64, login models.Login) error {
+	if err := e.seal(ctx, userID, models.KindLogin, login.ID, loginColumns(&login)); err != nil {
+		return err
+	}
+	return e.Storage.AddLogin(ctx, userID, login)
+}
+
+func (e *EncryptedStorage) Login(ctx context.Context, userID int64, loginID string) (*models.Login, error) {
+	login, err := e.Storage.Login(ctx, userID, loginID)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.open(ctx, userID, models.KindLogin, login.ID, loginColumns(login)); err != nil {
+		return nil, err
+	}
+	return login, nil
+}
+
+func (e *EncryptedStorage) UpdateLogin(ctx context.Context, userID int64, login models.Login) (int64, error) {
+	if err := e.seal(ctx, userID, models.KindLogin, login.ID, loginColumns(&login)); err != nil {
+		return 0, err
+	}
+	return e.Storage.UpdateLogin(ctx, userID, login)
+}
+
+func (e *EncryptedStorage) AddText(ctx context.Context, userID int64, text models.Text) error {
+	if err := e.seal(ctx, userID, models.KindText, text.ID, textColumns(&text)); err != nil {
+		return err
+	}
+	return e.Storage.AddText(ctx, userID, text)
+}
+
+func (e *EncryptedStorage) Text(ctx context.Context, userID int64, textID string) (*models.Text, error) {
+	text, err := e.Storage.Text(ctx, userID, textID)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.open(ctx, userID, models.KindText, text.ID, textColumns(text)); err != nil {
+		return nil, err
+	}
+	return text, nil
+}
+
+func (e *EncryptedStorage) UpdateText(ctx context.Context, userID int64, text models.Text) (int64, error) {
+	if err := e.seal(ctx, userID, models.KindText, text.ID, textColumns(&text)); err != nil {
+		return 0, err
+	}
+	return e.Storage.UpdateText(ctx, userID, text)
+}
+
+func (e *EncryptedStorage) AddBinary(ctx context.Context, userID int64, binData models.Binary) error {
+	content := string(binData.Data)
+	if err := e.seal(ctx, userID, models.KindBinary, binData.ID, binaryColumns(&binData, &content)); err != nil {
+		return err
+	}
+	binData.Data = []byte(content)
+	return e.Storage.AddBinary(ctx, userID, binData)
+}
+
+func (e *EncryptedStorage) Binary(ctx context.Context, userID int64, binID string) (*models.Binary, error) {
+	binary, err := e.Storage.Binary(ctx, userID, binID)
+	if err != nil {
+		return nil, err
+	}
+	content := string(binary.Data)
+	if err := e.open(ctx, userID, models.KindBinary, binary.ID, binaryColumns(binary, &content)); err != nil {
+		return nil, err
+	}
+	binary.Data = []byte(content)
+	return binary, nil
+}
+
+func (e *EncryptedStorage) UpdateBinary(ctx context.Context, userID int64, binData models.Binary) (int64, error) {
+	content := string(binData.Data)
+	if err := e.seal(ctx, userID, models.KindBinary, binData.ID, binaryColumns(&binData, &content)); err != nil {
+		return 0, err
+	}
+	binData.Data = []byte(content)
+	return e.Storage.UpdateBinary(ctx, userID, binData)
+}
+
+// AddChunk encrypts the chunk bound to its upload and position, the chunks can not be swapped or moved to another content
+func (e *EncryptedStorage) AddChunk(ctx context.Context, userID int64, chunk models.BinaryChunk) (*models.BinaryUpload, error) {
+	data := string(chunk.Data)
+	if err := e.seal(ctx, userID, models.KindBinary, chunk.Upload, chunkColumns(chunk.Seq, &data)); err != nil {
+		return nil, err
+	}
+	chunk.Data = []byte(data)
+	return e.Storage.AddChunk(ctx, userID, chunk)
+}
+
+func (e *EncryptedStorage) BinaryChunk(ctx context.Context, userID int64, contentID string, seq int64) ([]byte, error) {
+	chunk, err := e.Storage.BinaryChunk(ctx, userID, contentID, seq)
+	if err != nil {
+		return nil, err
+	}
+	data := string(chunk)
+	if err := e.open(ctx, userID, models.KindBinary, contentID, chunkColumns(seq, &data)); err != nil {
+		return nil, err
+	}
+	return []byte(data), nil
+}
+
+func (e *EncryptedStorage) AddSSHKey(ctx context.Context, userID int64, key models.SSHKey) error {
+	if err := e.seal(ctx, userID, models.KindSSH, key.ID, sshKeyColumns(&key)); err != nil {
+		return err
+	}
+	return e.Storage.AddSSHKey(ctx, userID, key)
+}
+
+func (e *EncryptedStorage) SSHKey(ctx context.Context, userID int64, keyID string) (*models.SSHKey, error) {
+	key, err := e.Storage.SSHKey(ctx, userID, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.open(ctx, userID, models.KindSSH, key.ID, sshKeyColumns(key)); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (e *EncryptedStorage) UpdateSSHKey(ctx context.Context, userID int64, key models.SSHKey) (int64, error) {
+	if err := e.seal(ctx, userID, models.KindSSH, key.ID, sshKeyColumns(&key)); err != nil {
+		return 0, err
+	}
+	return e.Storage.UpdateSSHKey(ctx, userID, key)
+}
+
+func (e *EncryptedStorage) AddCustomRecord(ctx context.Context, userID int64, record models.CustomRecord) error {
+	if err := e.sealSecrets(ctx, userID, &record); err != nil {
+		return err
+	}
+	return e.Storage.AddCustomRecord(ctx, userID, record)
+}
+
+func (e *EncryptedStorage) CustomRecord(ctx context.Context, userID int64, recordID string) (*models.CustomRecord, error) {
+	record, err := e.Storage.CustomRecord(ctx, userID, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if record.Sealed == "" {
+		return record, nil
+	}
+	secrets := record.Sealed
+	if err := e.open(ctx, userID, models.KindCustom, record.ID, map[string]*string{"secrets": &secrets}); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(secrets), &record.Secrets); err != nil {
+		return nil, err
+	}
+	record.Sealed = ""
+	return record, nil
+}
+
+func (e *EncryptedStorage) UpdateCustomRecord(ctx context.Context, userID int64, record models.CustomRecord) (int64, error) {
+	if err := e.sealSecrets(ctx, userID, &record); err != nil {
+		return 0, err
+	}
+	return e.Storage.UpdateCustomRecord(ctx, userID, record)
+}
+
+// sealSecrets encrypts the secrets of the custom record as one json object, the column can be re-encrypted
+// by the rotation like the columns of the other records
+func (e *EncryptedStorage) sealSecrets(ctx context.Context, userID int64, record *models.CustomRecord) error {
+	secrets := encodeValues(record.Secrets)
+	if err := e.seal(ctx, userID, models.KindCustom, record.ID, map[string]*string{"secrets": &secrets}); err != nil {
+		return err
+	}
+	record.Secrets, record.Sealed = nil, secrets
+	return nil
+}
+
+// Revision decrypts the values of the revision, they are kept encrypted as the record was stored
+func (e *EncryptedStorage) Revision(ctx context.Context, userID int64, kind models.Kind, id string, revisionID int64) (*models.Revision, error) {
+	revision, err := e.Storage.Revision(ctx, userID, kind, id, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]*string)
+	for _, column := range sealedTables[kind].columns {
+		if value, ok := revision.Fields[revisionField(kind, column)]; ok {
+			columns[column] = &value
+		}
+	}
+	if err := e.open(ctx, userID, kind, id, columns); err != nil {
+		return nil, err
+	}
+	for column, value := range columns {
+		revision.Fields[revisionField(kind, column)] = *value
+	}
+	return revision, nil
+}
+
+// totpKind, totpID and totpColumn the secret of the second factor is encrypted like a column of a record of the user
+const (
+	totpKind   models.Kind = "user"
+	totpID                 = "totp"
+	totpColumn             = "secret"
+)
+
+func (e *EncryptedStorage) TOTP(ctx context.Context, userID int64) (*models.TOTP, error) {
+	otp, err := e.Storage.TOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.open(ctx, userID, totpKind, totpID, map[string]*string{totpColumn: &otp.Secret}); err != nil {
+		return nil, err
+	}
+	return otp, nil
+}
+
+func (e *EncryptedStorage) SetTOTPSecret(ctx context.Context, userID int64, secret string) error {
+	if err := e.seal(ctx, userID, totpKind, totpID, map[string]*string{totpColumn: &secret}); err != nil {
+		return err
+	}
+	return e.Storage.SetTOTPSecret(ctx, userID, secret)
+}
+
+// seal encrypts the columns in place with the active data key of the user
+func (e *EncryptedStorage) seal(ctx context.Context, userID int64, kind models.Kind, id string, columns map[string]*string) error {
+	keys, err := e.dataKeys(ctx, userID, false)
+	if err != nil {
+		return err
+	}
+	for column, value := range columns {
+		sealed, err := keys.Seal(columnAD(userID, kind, id, column), *value)
+		if err != nil {
+			return err
+		}
+		*value = sealed
+	}
+	return nil
+}
+
+// open decrypts the columns in place. If a value is encrypted with a data key unknown to the cache,
+// the key was added by the rotation and the keys are reloaded
+func (e *EncryptedStorage) open(ctx context.Context, userID int64, kind models.Kind, id string, columns map[string]*string) error {
+	var keys *keyring.DataKeys
+	for column, value := range columns {
+		if !keyring.IsSealed(*value) {
+			continue
+		}
+		version, err := keyring.Version(*value)
+		if err != nil {
+			return err
+		}
+		if keys == nil {
+			keys, err = e.dataKeys(ctx, userID, false)
+			if err != nil {
+				return err
+			}
+		}
+		if !keys.Has(version) {
+			keys, err = e.dataKeys(ctx, userID, true)
+			if err != nil {
+				return err
+			}
+		}
+		plain, err := keys.Open(columnAD(userID, kind, id, column), *value)
+		if err != nil {
+			return err
+		}
+		*value = plain
+	}
+	return nil
+}
+
+// dataKeys returns the unwrapped data keys of the user. The first key is created on the first write
+func (e *EncryptedStorage) dataKeys(ctx context.Context, userID int64, reload bool) (*keyring.DataKeys, error) {
+	e.mu.Lock()
+	cached, ok := e.cache[userID]
+	e.mu.Unlock()
+	if ok && !reload && time.Since(cached.loadedAt) < dataKeysTTL {
+		return cached.keys, nil
+	}
+
+	userKeys, err := e.keys.UserKeys(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(userKeys) == 0 {
+		// Concurrent requests may create the first key at the same time, only one of them is saved
+		if _, err := e.addDataKey(ctx, userID, 1); err != nil {
+			return nil, err
+		}
+		userKeys, err = e.keys.UserKeys(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	unwrapped := make(map[int][]byte, len(userKeys))
+	for _, key := range userKeys {
+		plain, err := e.keyring.Unwrap(userID, key.Version, key.KEKVersion, key.Wrapped)
+		if err != nil {
+			return nil, fmt.Errorf("cant unwrap data key %d of user %d: %w", key.Version, userID, err)
+		}
+		unwrapped[key.Version] = plain
+	}
+	keys, err := keyring.NewDataKeys(unwrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	e.cache[userID] = cachedKeys{keys: keys, loadedAt: time.Now()}
+	e.mu.Unlock()
+	return keys, nil
+}
+
+// addDataKey generates and saves a new data key of the version
+func (e *EncryptedStorage) addDataKey(ctx context.Context, userID int64, version int) (bool, error) {
+	key, err := keyring.NewDataKey()
+	if err != nil {
+		return false, err
+	}
+	kekVersion, wrapped, err := e.keyring.Wrap(userID, version, key)
+	if err != nil {
+		return false, err
+	}
+	return e.keys.AddUserKey(ctx, UserKey{
+		UserID:     userID,
+		Version:    version,
+		KEKVersion: kekVersion,
+		Wrapped:    wrapped,
+	})
+}
+
+// columnAD associated data of the encrypted column, the value can not be moved to another user, record or column
+func columnAD(userID int64, kind models.Kind, id string, column string) string {
+	return fmt.Sprintf("%d/%s/%s/%s", userID, kind, id, column)
+}
+
+// cardColumns encrypted columns of the card
+func cardColumns(card *models.Card) map[string]*string {
+	return map[string]*string{
+		"fio":    &card.FIO,
+		"number": &card.Number,
+		"date":   &card.Date,
+		"cvv":    &card.CVV,
+	}
+}
+
+// loginColumns encrypted columns of the login
+func loginColumns(login *models.Login) map[string]*string {
+	return map[string]*string{
+		"login":    &login.Login,
+		"password": &login.Password,
+		"totp":     &login.TOTP,
+	}
+}
+
+// binaryColumns encrypted columns of the binary, the data is passed as a string
+func binaryColumns(binary *models.Binary, content *string) map[string]*string {
+	return map[string]*string{
+		"content":   content,
+		"file_name": &binary.FileName,
+	}
+}
+
+// chunkColumns the chunk of the uploaded content is encrypted like a column of the upload
+func chunkColumns(seq int64, data *string) map[string]*string {
+	return map[string]*string{fmt.Sprintf("chunk/%d", seq): data}
+}
+
+// sshKeyColumns encrypted columns of the ssh key, the public key is not a secret
+func sshKeyColumns(key *models.SSHKey) map[string]*string {
+	return map[string]*string{
+		"private_key": &key.PrivateKey,
+	}
+}
+
+// textColumns encrypted columns of the text
+func textColumns(text *models.Text) map[string]*string {
+	return map[string]*string{
+		"content": &text.Content,
+	}
+}
@@ -0,0 +1,386 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/blobstore"
+	mockstorage "github.com/ncyellow/GophKeeper/internal/server/mocks/storage"
+	"github.com/ncyellow/GophKeeper/internal/server/storage/keyring"
+)
+
+// memKeys in-memory KeyStore and SealedStore
+type memKeys struct {
+	keys []UserKey
+	rows map[models.Kind][]SealedRow
+	// changed emulates the server updating the column between SealedRows and ReplaceSealed
+	changed string
+	// chunks the blobs of the chunks by their checksums, the chunk without the blob is removed with its upload
+	chunks []ChunkBlob
+	blobs  map[string][]byte
+}
+
+func (m *memKeys) UserKeys(_ context.Context, userID int64) ([]UserKey, error) {
+	var result []UserKey
+	for _, key := range m.keys {
+		if key.UserID == userID {
+			result = append(result, key)
+		}
+	}
+	return result, nil
+}
+
+func (m *memKeys) AddUserKey(_ context.Context, key UserKey) (bool, error) {
+	for _, k := range m.keys {
+		if k.UserID == key.UserID && k.Version == key.Version {
+			return false, nil
+		}
+	}
+	key.KeyID = int64(len(m.keys) + 1)
+	m.keys = append(m.keys, key)
+	return true, nil
+}
+
+func (m *memKeys) StaleUserKeys(_ context.Context, kekVersion int, afterKeyID int64, limit int) ([]UserKey, error) {
+	var result []UserKey
+	for _, key := range m.keys {
+		if key.KEKVersion != kekVersion && key.KeyID > afterKeyID && len(result) < limit {
+			result = append(result, key)
+		}
+	}
+	return result, nil
+}
+
+func (m *memKeys) RewrapUserKey(_ context.Context, key UserKey, oldKEKVersion int) (bool, error) {
+	for i, k := range m.keys {
+		if k.KeyID == key.KeyID && k.KEKVersion == oldKEKVersion {
+			m.keys[i] = key
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memKeys) UserIDs(_ context.Context, afterUserID int64, limit int) ([]int64, error) {
+	if afterUserID < 1 {
+		return []int64{1}, nil
+	}
+	return nil, nil
+}
+
+func (m *memKeys) SealedRows(_ context.Context, kind models.Kind, afterRowID int64, limit int) ([]SealedRow, error) {
+	var result []SealedRow
+	for _, row := range m.rows[kind] {
+		if row.RowID > afterRowID && len(result) < limit {
+			values := make(map[string]string, len(row.Values))
+			for k, v := range row.Values {
+				values[k] = v
+			}
+			result = append(result, SealedRow{RowID: row.RowID, UserID: row.UserID, ID: row.ID, Values: values})
+		}
+	}
+	return result, nil
+}
+
+func (m *memKeys) ReplaceSealed(_ context.Context, kind models.Kind, rowID int64, column string, old string, value string) (bool, error) {
+	for _, row := range m.rows[kind] {
+		if row.RowID != rowID {
+			continue
+		}
+		if column == m.changed {
+			row.Values[column] = "changed"
+		}
+		if row.Values[column] != old {
+			return false, nil
+		}
+		row.Values[column] = value
+		return true, nil
+	}
+	return false, nil
+}
+
+func (m *memKeys) SealedTOTPSecrets(ctx context.Context, afterUserID int64, limit int) ([]SealedRow, error) {
+	return m.SealedRows(ctx, totpKind, afterUserID, limit)
+}
+
+func (m *memKeys) ReplaceTOTPSecret(ctx context.Context, userID int64, old string, value string) (bool, error) {
+	return m.ReplaceSealed(ctx, totpKind, userID, totpColumn, old, value)
+}
+
+func (m *memKeys) ChunkBlobs(_ context.Context, after ChunkBlob, limit int) ([]ChunkBlob, error) {
+	var result []ChunkBlob
+	for _, blob := range m.chunks {
+		if (blob.Upload > after.Upload || blob.Upload == after.Upload && blob.Seq > after.Seq) && len(result) < limit {
+			result = append(result, blob)
+		}
+	}
+	return result, nil
+}
+
+func (m *memKeys) ReadChunkBlob(_ context.Context, blob ChunkBlob) ([]byte, error) {
+	data, ok := m.blobs[blob.Sum]
+	if !ok {
+		return nil, blobstore.ErrNotFound
+	}
+	return data, nil
+}
+
+func (m *memKeys) ReplaceChunkBlob(_ context.Context, blob ChunkBlob, data []byte) (bool, error) {
+	for i, chunk := range m.chunks {
+		if chunk == blob {
+			delete(m.blobs, blob.Sum)
+			m.chunks[i].Sum = blobstore.Checksum(data)
+			m.blobs[m.chunks[i].Sum] = data
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func testRing(t *testing.T, versions ...int) *keyring.Keyring {
+	var lines []string
+	for _, version := range versions {
+		key := strings.Repeat(string(rune('a'+version)), keyring.KeyLen)
+		lines = append(lines, string(rune('0'+version))+":"+base64.StdEncoding.EncodeToString([]byte(key)))
+	}
+	ring, err := keyring.Parse(strings.Join(lines, "\n"))
+	require.NoError(t, err)
+	return ring
+}
+
+func TestEncryptedStorageCard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockstorage.NewMockStorage(ctrl)
+	keys := &memKeys{}
+	encrypted := NewEncryptedStorage(store, keys, testRing(t, 1))
+
+	card := models.Card{
+		ID:     "card",
+		FIO:    "Ivanov Ivan",
+		Number: "4111111111111111",
+		Date:   "12/30",
+		CVV:    "123",
+		Note:   "note",
+	}
+
+	var saved models.Card
+	store.EXPECT().AddCard(gomock.Any(), int64(1), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int64, c models.Card) error {
+			saved = c
+			return nil
+		})
+	require.NoError(t, encrypted.AddCard(context.Background(), 1, card))
+
+	// The secret fields reach the database only encrypted, the id and note are not encrypted
+	for _, value := range []string{saved.FIO, saved.Number, saved.Date, saved.CVV} {
+		assert.True(t, keyring.IsSealed(value), value)
+	}
+	assert.NotContains(t, saved.Number, card.Number)
+	assert.Equal(t, card.ID, saved.ID)
+	assert.Equal(t, card.Note, saved.Note)
+	assert.Len(t, keys.keys, 1)
+
+	// The read record is decrypted in place, keep a copy of the saved one
+	stolen := saved
+	store.EXPECT().Card(gomock.Any(), int64(1), "card").Return(&saved, nil)
+	result, err := encrypted.Card(context.Background(), 1, "card")
+	require.NoError(t, err)
+	assert.Equal(t, card, *result)
+
+	// The value of another user can not be read even if it is copied to the own record
+	store.EXPECT().Card(gomock.Any(), int64(2), "card").Return(&stolen, nil)
+	_, err = encrypted.Card(context.Background(), 2, "card")
+	assert.Error(t, err)
+
+	// The updated record is encrypted the same way, the version is passed as is
+	edited := card
+	edited.CVV = "456"
+	edited.Version = 1
+	store.EXPECT().UpdateCard(gomock.Any(), int64(1), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int64, c models.Card) (int64, error) {
+			assert.True(t, keyring.IsSealed(c.CVV))
+			assert.Equal(t, int64(1), c.Version)
+			return 2, nil
+		})
+	version, err := encrypted.UpdateCard(context.Background(), 1, edited)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), version)
+
+	// The revision keeps the values as they were stored and is decrypted with the same keys
+	store.EXPECT().Revision(gomock.Any(), int64(1), models.KindCard, "card", int64(1)).Return(&models.Revision{
+		ID: 1, Kind: models.KindCard, RecordID: "card", Version: 1, Action: models.RevisionAdd,
+		Fields: map[string]string{"fio": stolen.FIO, "number": stolen.Number, "date": stolen.Date, "cvv": stolen.CVV, "note": stolen.Note},
+	}, nil)
+	revision, err := encrypted.Revision(context.Background(), 1, models.KindCard, "card", 1)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"fio": card.FIO, "number": card.Number, "date": card.Date, "cvv": card.CVV, "note": card.Note,
+	}, revision.Fields)
+
+	// Records saved before the encryption was enabled are read as is
+	legacy := card
+	store.EXPECT().Card(gomock.Any(), int64(1), "card").Return(&legacy, nil)
+	result, err = encrypted.Card(context.Background(), 1, "card")
+	require.NoError(t, err)
+	assert.Equal(t, card, *result)
+}
+
+func TestEncryptedStorageBinary(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockstorage.NewMockStorage(ctrl)
+	encrypted := NewEncryptedStorage(store, &memKeys{}, testRing(t, 1))
+
+	binary := models.Binary{ID: "bin", Data: []byte{0, 1, 2, 255}}
+
+	var saved models.Binary
+	store.EXPECT().AddBinary(gomock.Any(), int64(1), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int64, b models.Binary) error {
+			saved = b
+			return nil
+		})
+	require.NoError(t, encrypted.AddBinary(context.Background(), 1, binary))
+	assert.True(t, keyring.IsSealed(string(saved.Data)))
+
+	store.EXPECT().Binary(gomock.Any(), int64(1), "bin").Return(&saved, nil)
+	result, err := encrypted.Binary(context.Background(), 1, "bin")
+	require.NoError(t, err)
+	assert.Equal(t, binary.Data, result.Data)
+}
+
+func TestEncryptedStorageCustomRecord(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockstorage.NewMockStorage(ctrl)
+	encrypted := NewEncryptedStorage(store, &memKeys{}, testRing(t, 1))
+
+	record := models.CustomRecord{ID: "home", Type: "Wi-Fi", Values: map[string]string{"ssid": "home"},
+		Secrets: map[string]string{"password": "secret"}}
+
+	// The secrets are sealed as one column, the public values are kept as is
+	var saved models.CustomRecord
+	store.EXPECT().AddCustomRecord(gomock.Any(), int64(1), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int64, r models.CustomRecord) error {
+			saved = r
+			return nil
+		})
+	require.NoError(t, encrypted.AddCustomRecord(context.Background(), 1, record))
+	assert.True(t, keyring.IsSealed(saved.Sealed))
+	assert.NotContains(t, saved.Sealed, "secret")
+	assert.Nil(t, saved.Secrets)
+	assert.Equal(t, record.Values, saved.Values)
+
+	store.EXPECT().CustomRecord(gomock.Any(), int64(1), "home").Return(&saved, nil)
+	result, err := encrypted.CustomRecord(context.Background(), 1, "home")
+	require.NoError(t, err)
+	assert.Equal(t, record, *result)
+}
+
+func TestRotation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockstorage.NewMockStorage(ctrl)
+	keys := &memKeys{rows: make(map[models.Kind][]SealedRow)}
+	old := NewEncryptedStorage(store, keys, testRing(t, 1))
+
+	var saved models.Login
+	store.EXPECT().AddLogin(gomock.Any(), int64(1), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int64, l models.Login) error {
+			saved = l
+			return nil
+		})
+	login := models.Login{ID: "login", Login: "user", Password: "secret"}
+	require.NoError(t, old.AddLogin(context.Background(), 1, login))
+
+	keys.rows[models.KindLogin] = []SealedRow{
+		{RowID: 1, UserID: 1, ID: "login", Values: map[string]string{"login": saved.Login, "password": saved.Password}},
+	}
+	keys.rows[models.KindText] = []SealedRow{
+		{RowID: 1, UserID: 1, ID: "text", Values: map[string]string{"content": "plain text"}},
+	}
+
+	// The secret of the second factor is sealed with the data key too
+	var secret string
+	store.EXPECT().SetTOTPSecret(gomock.Any(), int64(1), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int64, s string) error {
+			secret = s
+			return nil
+		})
+	require.NoError(t, old.SetTOTPSecret(context.Background(), 1, "JBSWY3DPEHPK3PXP"))
+	keys.rows[totpKind] = []SealedRow{{RowID: 1, UserID: 1, ID: totpID, Values: map[string]string{totpColumn: secret}}}
+
+	// The chunk of the uploaded content is re-encrypted too, the one of the removed upload is skipped
+	var chunk []byte
+	store.EXPECT().AddChunk(gomock.Any(), int64(1), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int64, c models.BinaryChunk) (*models.BinaryUpload, error) {
+			chunk = c.Data
+			return &models.BinaryUpload{}, nil
+		})
+	_, err := old.AddChunk(context.Background(), 1, models.BinaryChunk{Upload: "up", Seq: 1, Data: []byte("chunk")})
+	require.NoError(t, err)
+	keys.chunks = []ChunkBlob{
+		{Upload: "gone", Seq: 0, Sum: "removed", UserID: 1},
+		{Upload: "up", Seq: 1, Sum: blobstore.Checksum(chunk), UserID: 1},
+	}
+	keys.blobs = map[string][]byte{blobstore.Checksum(chunk): chunk}
+
+	// The new master key version is added, the data keys are re-wrapped and rotated
+	rotated := NewEncryptedStorage(store, keys, testRing(t, 1, 2))
+	count, err := rotated.RewrapKeys(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 2, keys.keys[0].KEKVersion)
+
+	count, err = rotated.RotateDataKeys(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Len(t, keys.keys, 2)
+
+	// The password is changed by the server during the re-encryption
+	keys.changed = "password"
+	updated, skipped, err := rotated.Reencrypt(context.Background(), keys)
+	require.NoError(t, err)
+	assert.Equal(t, 4, updated)
+	assert.Equal(t, 2, skipped)
+
+	version, err := keyring.Version(keys.rows[models.KindLogin][0].Values["login"])
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+	assert.Equal(t, "changed", keys.rows[models.KindLogin][0].Values["password"])
+	content := keys.rows[models.KindText][0].Values["content"]
+	assert.True(t, keyring.IsSealed(content))
+
+	// The old master key is not needed anymore
+	store.EXPECT().Text(gomock.Any(), int64(1), "text").Return(&models.Text{ID: "text", Content: content}, nil)
+	text, err := NewEncryptedStorage(store, keys, testRing(t, 2)).Text(context.Background(), 1, "text")
+	require.NoError(t, err)
+	assert.Equal(t, "plain text", text.Content)
+
+	secret = keys.rows[totpKind][0].Values[totpColumn]
+	store.EXPECT().TOTP(gomock.Any(), int64(1)).Return(&models.TOTP{Secret: secret}, nil)
+	otp, err := NewEncryptedStorage(store, keys, testRing(t, 2)).TOTP(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", otp.Secret)
+
+	// The chunk got a new blob sealed with the new data key
+	rechunked := keys.blobs[keys.chunks[1].Sum]
+	version, err = keyring.Version(string(rechunked))
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+	store.EXPECT().BinaryChunk(gomock.Any(), int64(1), "up", int64(1)).Return(rechunked, nil)
+	data, err := NewEncryptedStorage(store, keys, testRing(t, 2)).BinaryChunk(context.Background(), 1, "up", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("chunk"), data)
+}
@@ -0,0 +1,263 @@
+// Package keyring implements the envelope encryption of the records at rest.
+// Every user has own data keys (DEK), they encrypt the record fields. Data keys are stored in the database
+// wrapped by the master key (KEK), the master key is never stored in the database.
+// Both kinds of keys are versioned, so they can be rotated without making old records unreadable.
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Prefix of the values encrypted at rest, followed by the data key version: $gks1$<version>$<base64>
+const Prefix = "$gks1$"
+
+// KeyLen length of the master and data keys, AES-256
+const KeyLen = 32
+
+var (
+	ErrNoMasterKey     = errors.New("master key is not configured")
+	ErrUnknownKey      = errors.New("unknown key version")
+	ErrDecrypt         = errors.New("cant decrypt the value, the record is corrupted or was encrypted with another key")
+	ErrInvalidEnvelope = errors.New("invalid encrypted value")
+)
+
+// Keyring holds all configured versions of the master key. The highest version is used to wrap new data keys,
+// the older ones are kept only to unwrap keys that are not rotated yet
+type Keyring struct {
+	keks   map[int]cipher.AEAD
+	active int
+}
+
+// Load reads the master keys from the value of the env variable or from the file, the value takes priority
+func Load(value string, file string) (*Keyring, error) {
+	if value == "" && file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("cant read master key file: %w", err)
+		}
+		value = string(data)
+	}
+	return Parse(value)
+}
+
+// Parse parses the master keys in the format "<version>:<base64 key>", the keys are separated by commas or new lines.
+// Empty lines and lines starting with # are skipped
+func Parse(value string) (*Keyring, error) {
+	k := &Keyring{keks: make(map[int]cipher.AEAD)}
+	lines := strings.FieldsFunc(value, func(r rune) bool {
+		return r == '\n' || r == ','
+	})
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ver, encoded, found := strings.Cut(line, ":")
+		if !found {
+			return nil, errors.New("invalid master key, expected <version>:<base64 key>")
+		}
+		version, err := strconv.Atoi(strings.TrimSpace(ver))
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid master key version %q", ver)
+		}
+		if _, ok := k.keks[version]; ok {
+			return nil, fmt.Errorf("duplicate master key version %d", version)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil || len(key) != KeyLen {
+			return nil, fmt.Errorf("master key version %d must be %d bytes in base64", version, KeyLen)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		k.keks[version] = aead
+		if version > k.active {
+			k.active = version
+		}
+	}
+	if len(k.keks) == 0 {
+		return nil, ErrNoMasterKey
+	}
+	return k, nil
+}
+
+// ActiveVersion version of the master key that wraps new data keys
+func (k *Keyring) ActiveVersion() int {
+	return k.active
+}
+
+// Versions returns all configured master key versions in ascending order
+func (k *Keyring) Versions() []int {
+	versions := make([]int, 0, len(k.keks))
+	for version := range k.keks {
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// NewDataKey generates a random data key
+func NewDataKey() ([]byte, error) {
+	key := make([]byte, KeyLen)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("cant generate data key: %w", err)
+	}
+	return key, nil
+}
+
+// Wrap encrypts the data key with the active master key. The wrapped key is bound to the user and the data key version
+func (k *Keyring) Wrap(userID int64, version int, key []byte) (kekVersion int, wrapped []byte, err error) {
+	wrapped, err = seal(k.keks[k.active], wrapAD(userID, version), key)
+	if err != nil {
+		return 0, nil, err
+	}
+	return k.active, wrapped, nil
+}
+
+// Unwrap decrypts the data key with the master key it was wrapped with
+func (k *Keyring) Unwrap(userID int64, version int, kekVersion int, wrapped []byte) ([]byte, error) {
+	kek, ok := k.keks[kekVersion]
+	if !ok {
+		return nil, fmt.Errorf("%w: master key %d", ErrUnknownKey, kekVersion)
+	}
+	return open(kek, wrapAD(userID, version), wrapped)
+}
+
+// DataKeys all data keys of one user. New values are encrypted with the highest version
+type DataKeys struct {
+	aeads  map[int]cipher.AEAD
+	active int
+}
+
+// NewDataKeys builds the user data keys from the unwrapped keys by versions
+func NewDataKeys(keys map[int][]byte) (*DataKeys, error) {
+	d := &DataKeys{aeads: make(map[int]cipher.AEAD, len(keys))}
+	for version, key := range keys {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		d.aeads[version] = aead
+		if version > d.active {
+			d.active = version
+		}
+	}
+	if len(d.aeads) == 0 {
+		return nil, ErrUnknownKey
+	}
+	return d, nil
+}
+
+// ActiveVersion version of the data key used for new values
+func (d *DataKeys) ActiveVersion() int {
+	return d.active
+}
+
+// Has checks whether the data key of the version is known
+func (d *DataKeys) Has(version int) bool {
+	_, ok := d.aeads[version]
+	return ok
+}
+
+// Seal encrypts the value with the active data key. ad binds the ciphertext to the record field
+func (d *DataKeys) Seal(ad string, plaintext string) (string, error) {
+	sealed, err := seal(d.aeads[d.active], ad, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%d$%s", Prefix, d.active, base64.RawStdEncoding.EncodeToString(sealed)), nil
+}
+
+// Open decrypts the value with the data key of its version. Values without the prefix were saved
+// before the encryption was enabled and are returned as is
+func (d *DataKeys) Open(ad string, value string) (string, error) {
+	if !IsSealed(value) {
+		return value, nil
+	}
+	version, payload, err := split(value)
+	if err != nil {
+		return "", err
+	}
+	aead, ok := d.aeads[version]
+	if !ok {
+		return "", fmt.Errorf("%w: data key %d", ErrUnknownKey, version)
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", ErrInvalidEnvelope
+	}
+	plain, err := open(aead, ad, sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// IsSealed checks whether the value is encrypted at rest
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// Version returns the data key version of the encrypted value
+func Version(value string) (int, error) {
+	version, _, err := split(value)
+	return version, err
+}
+
+// split parses $gks1$<version>$<payload>
+func split(value string) (int, string, error) {
+	ver, payload, found := strings.Cut(strings.TrimPrefix(value, Prefix), "$")
+	if !IsSealed(value) || !found {
+		return 0, "", ErrInvalidEnvelope
+	}
+	version, err := strconv.Atoi(ver)
+	if err != nil {
+		return 0, "", ErrInvalidEnvelope
+	}
+	return version, payload, nil
+}
+
+// wrapAD associated data of the wrapped data key, the wrapped key can not be moved to another user or version
+func wrapAD(userID int64, version int) string {
+	return fmt.Sprintf("user/%d/dek/%d", userID, version)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal returns nonce + ciphertext
+func seal(aead cipher.AEAD, ad string, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("cant generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, []byte(ad)), nil
+}
+
+// open splits nonce + ciphertext and decrypts it
+func open(aead cipher.AEAD, ad string, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrInvalidEnvelope
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ciphertext, []byte(ad))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plain, nil
+}
@@ -0,0 +1,109 @@
+package keyring
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), KeyLen)))
+}
+
+func TestParse(t *testing.T) {
+	k, err := Parse("# master keys\n1:" + testKey('a') + "\n3:" + testKey('c') + ", 2:" + testKey('b'))
+	require.NoError(t, err)
+	assert.Equal(t, 3, k.ActiveVersion())
+	assert.Equal(t, []int{1, 2, 3}, k.Versions())
+
+	for _, value := range []string{
+		"",
+		testKey('a'),
+		"x:" + testKey('a'),
+		"0:" + testKey('a'),
+		"1:c2hvcnQ=",
+		"1:" + testKey('a') + ",1:" + testKey('b'),
+	} {
+		_, err := Parse(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestWrapUnwrap(t *testing.T) {
+	old, err := Parse("1:" + testKey('a'))
+	require.NoError(t, err)
+	dek, err := NewDataKey()
+	require.NoError(t, err)
+
+	kekVersion, wrapped, err := old.Wrap(1, 1, dek)
+	require.NoError(t, err)
+	assert.Equal(t, 1, kekVersion)
+
+	// After the master key rotation the old key is still readable, new keys are wrapped by the new version
+	k, err := Parse("1:" + testKey('a') + "\n2:" + testKey('b'))
+	require.NoError(t, err)
+	unwrapped, err := k.Unwrap(1, 1, kekVersion, wrapped)
+	require.NoError(t, err)
+	assert.Equal(t, dek, unwrapped)
+
+	kekVersion, rewrapped, err := k.Wrap(1, 1, unwrapped)
+	require.NoError(t, err)
+	assert.Equal(t, 2, kekVersion)
+	unwrapped, err = k.Unwrap(1, 1, kekVersion, rewrapped)
+	require.NoError(t, err)
+	assert.Equal(t, dek, unwrapped)
+
+	// The wrapped key can not be moved to another user or version
+	_, err = k.Unwrap(2, 1, kekVersion, rewrapped)
+	assert.ErrorIs(t, err, ErrDecrypt)
+	_, err = k.Unwrap(1, 2, kekVersion, rewrapped)
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = old.Unwrap(1, 1, 2, rewrapped)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestSealOpen(t *testing.T) {
+	first, err := NewDataKey()
+	require.NoError(t, err)
+	second, err := NewDataKey()
+	require.NoError(t, err)
+
+	old, err := NewDataKeys(map[int][]byte{1: first})
+	require.NoError(t, err)
+	sealed, err := old.Seal("1/card/id/cvv", "123")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(sealed, Prefix+"1$"))
+	assert.NotContains(t, sealed, "123")
+
+	keys, err := NewDataKeys(map[int][]byte{1: first, 2: second})
+	require.NoError(t, err)
+	assert.Equal(t, 2, keys.ActiveVersion())
+
+	plain, err := keys.Open("1/card/id/cvv", sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, "123", plain)
+
+	resealed, err := keys.Seal("1/card/id/cvv", plain)
+	require.NoError(t, err)
+	version, err := Version(resealed)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	_, err = old.Open("1/card/id/cvv", resealed)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	_, err = keys.Open("1/card/other/cvv", sealed)
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	// Values saved before the encryption are returned as is
+	plain, err = keys.Open("1/card/id/cvv", "legacy")
+	assert.NoError(t, err)
+	assert.Equal(t, "legacy", plain)
+
+	_, err = keys.Open("1/card/id/cvv", Prefix+"x$abc")
+	assert.ErrorIs(t, err, ErrInvalidEnvelope)
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+)
+
+// UserKey data key of the user wrapped by the master key
+type UserKey struct {
+	KeyID      int64
+	UserID     int64
+	Version    int
+	KEKVersion int
+	Wrapped    []byte
+}
+
+// KeyStore stores the wrapped data keys of the users, used by EncryptedStorage
+type KeyStore interface {
+	// UserKeys returns all data keys of the user ordered by version
+	UserKeys(ctx context.Context, userID int64) ([]UserKey, error)
+	// AddUserKey saves a new data key. Returns false if the user already has a key of this version
+	AddUserKey(ctx context.Context, key UserKey) (bool, error)
+	// StaleUserKeys returns the keys wrapped by a master key other than kekVersion, keys are ordered by KeyID
+	StaleUserKeys(ctx context.Context, kekVersion int, afterKeyID int64, limit int) ([]UserKey, error)
+	// RewrapUserKey replaces the wrapped key if it is still wrapped by oldKEKVersion
+	RewrapUserKey(ctx context.Context, key UserKey, oldKEKVersion int) (bool, error)
+	// UserIDs returns the user ids in ascending order
+	UserIDs(ctx context.Context, afterUserID int64, limit int) ([]int64, error)
+}
+
+// SealedRow raw values of the encrypted columns of one record
+type SealedRow struct {
+	RowID  int64
+	UserID int64
+	ID     string
+	Values map[string]string
+}
+
+// SealedStore gives access to the encrypted columns, secrets and chunks as is, used for the re-encryption of the records
+type SealedStore interface {
+	// SealedRows returns the records of the kind ordered by RowID
+	SealedRows(ctx context.Context, kind models.Kind, afterRowID int64, limit int) ([]SealedRow, error)
+	// ReplaceSealed replaces the column value if it was not changed since it was read
+	ReplaceSealed(ctx context.Context, kind models.Kind, rowID int64, column string, old string, value string) (bool, error)
+	// SealedTOTPSecrets returns the secrets of the second factor as the rows of one column ordered by the user id
+	SealedTOTPSecrets(ctx context.Context, afterUserID int64, limit int) ([]SealedRow, error)
+	// ReplaceTOTPSecret replaces the secret of the second factor if it was not changed since it was read
+	ReplaceTOTPSecret(ctx context.Context, userID int64, old string, value string) (bool, error)
+	// ChunkBlobs returns the blobs of the chunks ordered by the upload and the number of the chunk
+	ChunkBlobs(ctx context.Context, after ChunkBlob, limit int) ([]ChunkBlob, error)
+	// ReadChunkBlob returns the data of the chunk from the blob store verified by the checksum
+	ReadChunkBlob(ctx context.Context, blob ChunkBlob) ([]byte, error)
+	// ReplaceChunkBlob writes the data as the new blob of the chunk if the chunk was not removed since it was read
+	ReplaceChunkBlob(ctx context.Context, blob ChunkBlob, data []byte) (bool, error)
+}
+
+// sealedTable describes the table of the records and its columns encrypted at rest
+type sealedTable struct {
+	table   string
+	key     string
+	columns []string
+	// public the other columns of the record besides the note, they are not encrypted but are kept in the revisions
+	public []string
+}
+
+// sealedTables the encrypted columns of every record kind. Ids and note are not encrypted
+var sealedTables = map[models.Kind]sealedTable{
+	models.KindCard:   {table: "cards", key: "@cards", columns: []string{"fio", "number", "date", "cvv"}},
+	models.KindLogin:  {table: "logins", key: "@logins", columns: []string{"login", "password", "totp"}},
+	models.KindText:   {table: "text_data", key: "@text", columns: []string{"content"}},
+	models.KindBinary: {table: "bin_data", key: "@bin", columns: []string{"content", "file_name"}},
+	models.KindSSH: {table: "ssh_keys", key: "@ssh_keys", columns: []string{"private_key"},
+		public: []string{"public_key", "comment", "fingerprint"}},
+	models.KindCustom: {table: "custom_records", key: "@custom_records", columns: []string{"secrets"},
+		public: []string{"type", "values"}},
+}
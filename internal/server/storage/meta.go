@@ -0,0 +1,19 @@
+package storage
+
+import "sort"
+
+// metaArgs converts the tags and the fields of the record into the arrays of set_record_meta.
+// The arrays are never nil, the empty ones clear the stored meta
+func metaArgs(tags []string, fields map[string]string) ([]string, []string, []string) {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := make([]string, 0, len(names))
+	for _, name := range names {
+		values = append(values, fields[name])
+	}
+	return append([]string{}, tags...), names, values
+}
@@ -3,19 +3,35 @@ package storage
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/driftprogramming/pgxpoolmock"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/rs/zerolog/log"
 
 	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/blobstore"
 	"github.com/ncyellow/GophKeeper/internal/server/config"
+	"github.com/ncyellow/GophKeeper/internal/server/storage/keyring"
 )
 
+// vaultSaltLen length of the salt for the client side key derivation
+const vaultSaltLen = 16
+
 type PgStorage struct {
 	conf *config.Config
 	pool pgxpoolmock.PgxPool
+	// blobs keeps the payloads of the chunks, the chunks in the database keep their checksums
+	blobs blobstore.BlobStore
 }
 
 // NewPgStorage конструктор хранилища на основе postgresql, явно не используется, только через фабрику
@@ -25,9 +41,16 @@ func NewPgStorage(conf *config.Config) (*PgStorage, error) {
 		return nil, fmt.Errorf("cant connect to pgsql: %w", err)
 	}
 
+	blobs, err := blobstore.New(conf, pool)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+
 	store := PgStorage{
-		conf: conf,
-		pool: pool,
+		conf:  conf,
+		pool:  pool,
+		blobs: blobs,
 	}
 	return &store, nil
 }
@@ -63,12 +86,12 @@ func (p *PgStorage) UserByLogin(ctx context.Context, login string) (*models.User
 	return &user, nil
 }
 
-func (p *PgStorage) User(ctx context.Context, login string, password string) (*models.User, error) {
+func (p *PgStorage) User(ctx context.Context, login string) (*models.User, error) {
 	var user models.User
 	row := p.pool.QueryRow(ctx, `
-	SELECT "@users", "login", "password" FROM "users" WHERE "login" = $1 AND "password" = $2
+	SELECT "@users", "login", "password" FROM "users" WHERE "login" = $1
 	LIMIT 1
-	`, login, password)
+	`, login)
 
 	err := row.Scan(&user.UserID, &user.Login, &user.Password)
 	if err != nil {
@@ -77,156 +100,1770 @@ func (p *PgStorage) User(ctx context.Context, login string, password string) (*m
 	return &user, nil
 }
 
-func (p *PgStorage) AddCard(ctx context.Context, userID int64, card models.Card) error {
-	var lastInsertID int64
-	err := p.pool.QueryRow(ctx, `
-	INSERT INTO "cards"("id", "user", "fio", "number", "date", "cvv", "metainfo")
-	VALUES ($1, $2, $3, $4, $5, $6, $7)
-	returning "@cards"
-	`, card.ID, userID, card.FIO, card.Number, card.Date, card.CVV, card.MetaInfo).Scan(&lastInsertID)
-	// так как логин у нас уникален, то при попытке вставить второй одинаковый логин будет ошибка
+func (p *PgStorage) UpdatePassword(ctx context.Context, userID int64, password string) error {
+	_, err := p.pool.Exec(ctx, `
+	UPDATE "users" SET "password" = $2
+	WHERE "@users" = $1
+	`, userID, password)
+
+	return err
+}
+
+func (p *PgStorage) ChangeLogin(ctx context.Context, userID int64, login string) error {
+	result, err := p.pool.Exec(ctx, `
+	UPDATE "users" SET "login" = $2
+	WHERE "@users" = $1 AND NOT EXISTS (SELECT 1 FROM "users" WHERE "login" = $2 AND "@users" <> $1)
+	`, userID, login)
 	if err != nil {
+		// The concurrent rename to the same login is stopped by the unique index
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return ErrLoginTaken
+		}
 		return err
 	}
-	log.Info().Msgf("Новая карта с идентификатором - %s добавлена. @card - %d", card.ID, lastInsertID)
+	if result.RowsAffected() == 0 {
+		return ErrLoginTaken
+	}
 	return nil
 }
 
-func (p *PgStorage) Card(ctx context.Context, userID int64, cardID string) (*models.Card, error) {
-	var card models.Card
+func (p *PgStorage) DeleteUser(ctx context.Context, userID int64) error {
+	result, err := p.pool.Exec(ctx, `
+	DELETE FROM "users" WHERE "@users" = $1
+	`, userID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (p *PgStorage) VaultParams(ctx context.Context, userID int64) (*models.VaultParams, error) {
+	salt := make([]byte, vaultSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("cant generate salt: %w", err)
+	}
 
+	// The salt is generated once, all subsequent calls return the saved one
+	var params models.VaultParams
 	err := p.pool.QueryRow(ctx, `
-	SELECT "id", "user", "fio", "number", "date", "cvv", "metainfo"
-	FROM "cards"
-	WHERE "user" = $1 and "id" = $2
-	LIMIT 1
-	`, userID, cardID).Scan(&card.ID, &card.UserID, &card.FIO, &card.Number, &card.Date, &card.CVV, &card.MetaInfo)
+	UPDATE "users" SET "vault_salt" = COALESCE("vault_salt", $2)
+	WHERE "@users" = $1
+	returning "vault_salt", COALESCE("vault_check", '')
+	`, userID, salt).Scan(&params.Salt, &params.Check)
 	if err != nil {
 		return nil, err
 	}
-	return &card, nil
+	return &params, nil
 }
 
-func (p *PgStorage) DeleteCard(ctx context.Context, userID int64, cardID string) error {
+func (p *PgStorage) SetVaultCheck(ctx context.Context, userID int64, check string) error {
+	result, err := p.pool.Exec(ctx, `
+	UPDATE "users" SET "vault_check" = $2
+	WHERE "@users" = $1 AND "vault_check" IS NULL
+	`, userID, check)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrVaultInitialized
+	}
+	return nil
+}
+
+func (p *PgStorage) CreateSession(ctx context.Context, session models.Session, refreshHash string) error {
 	_, err := p.pool.Exec(ctx, `
-	DELETE FROM "cards"
-	WHERE "user" = $1 and "id" = $2
-	`, userID, cardID)
+	INSERT INTO "sessions"("id", "user", "refresh_hash", "user_agent", "expires_at")
+	VALUES ($1, $2, $3, $4, $5)
+	`, session.ID, session.UserID, refreshHash, session.UserAgent, session.ExpiresAt)
 
 	return err
 }
 
-func (p *PgStorage) AddLogin(ctx context.Context, userID int64, login models.Login) error {
-	var lastInsertID int64
+func (p *PgStorage) SessionUser(ctx context.Context, sessionID string) (*models.User, error) {
+	var user models.User
 	err := p.pool.QueryRow(ctx, `
-	INSERT INTO "logins"("id", "user", "login", "password", "metainfo")
-	VALUES ($1, $2, $3, $4, $5)
-	returning "@logins"
-	`, login.ID, userID, login.Login, login.Password, login.MetaInfo).Scan(&lastInsertID)
+	SELECT u."@users", u."login"
+	FROM "sessions" s JOIN "users" u ON u."@users" = s."user"
+	WHERE s."id" = $1 AND s."revoked_at" IS NULL AND s."expires_at" > now()
+	`, sessionID).Scan(&user.UserID, &user.Login)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (p *PgStorage) RefreshSession(ctx context.Context, sessionID string, oldHash string, newHash string, expiresAt time.Time) (*models.User, error) {
+	var user models.User
+	// The used hashes are kept to detect a second use of any of the previous refresh tokens
+	err := p.pool.QueryRow(ctx, `
+	WITH "refreshed" AS (
+		UPDATE "sessions" s SET "refresh_hash" = $3, "refreshed_at" = now(), "expires_at" = $4
+		FROM "users" u
+		WHERE s."id" = $1 AND s."refresh_hash" = $2 AND s."revoked_at" IS NULL AND s."expires_at" > now()
+			AND u."@users" = s."user"
+		returning u."@users", u."login"
+	), "used" AS (
+		INSERT INTO "session_used_hashes"("session", "hash")
+		SELECT $1, $2 FROM "refreshed"
+	)
+	SELECT "@users", "login" FROM "refreshed"
+	`, sessionID, oldHash, newHash, expiresAt).Scan(&user.UserID, &user.Login)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (p *PgStorage) RevokeReusedSession(ctx context.Context, sessionID string, hash string) (bool, error) {
+	result, err := p.pool.Exec(ctx, `
+	UPDATE "sessions" SET "revoked_at" = now()
+	WHERE "id" = $1 AND "revoked_at" IS NULL
+		AND EXISTS (SELECT 1 FROM "session_used_hashes" WHERE "session" = $1 AND "hash" = $2)
+	`, sessionID, hash)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+func (p *PgStorage) Sessions(ctx context.Context, userID int64) ([]models.Session, error) {
+	rows, err := p.pool.Query(ctx, `
+	SELECT "id", "user", "user_agent", "created_at", "refreshed_at", "expires_at"
+	FROM "sessions"
+	WHERE "user" = $1 AND "revoked_at" IS NULL AND "expires_at" > now()
+	ORDER BY "created_at"
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]models.Session, 0)
+	for rows.Next() {
+		var session models.Session
+		err := rows.Scan(&session.ID, &session.UserID, &session.UserAgent,
+			&session.CreatedAt, &session.RefreshedAt, &session.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+func (p *PgStorage) RevokeSession(ctx context.Context, userID int64, sessionID string) error {
+	result, err := p.pool.Exec(ctx, `
+	UPDATE "sessions" SET "revoked_at" = now()
+	WHERE "user" = $1 AND "id" = $2 AND "revoked_at" IS NULL
+	`, userID, sessionID)
 	if err != nil {
 		return err
 	}
-	log.Info().Msgf("Новые учетные данные с идентификатором - %s добавлены. @login - %d", login.ID, lastInsertID)
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
 	return nil
 }
 
-func (p *PgStorage) Login(ctx context.Context, userID int64, loginID string) (*models.Login, error) {
-	var login models.Login
+func (p *PgStorage) RevokeOtherSessions(ctx context.Context, userID int64, sessionID string) error {
+	_, err := p.pool.Exec(ctx, `
+	UPDATE "sessions" SET "revoked_at" = now()
+	WHERE "user" = $1 AND "id" <> $2 AND "revoked_at" IS NULL
+	`, userID, sessionID)
+	return err
+}
 
+func (p *PgStorage) TOTP(ctx context.Context, userID int64) (*models.TOTP, error) {
+	var otp models.TOTP
 	err := p.pool.QueryRow(ctx, `
-	SELECT "id", "user", "login", "password", "metainfo"
-	FROM "logins"
-	WHERE "user" = $1 and "id" = $2
-	LIMIT 1
-	`, userID, loginID).Scan(&login.ID, &login.UserID, &login.Login, &login.Password, &login.MetaInfo)
+	SELECT COALESCE("totp_secret", ''), "totp_enabled", "totp_last_counter"
+	FROM "users"
+	WHERE "@users" = $1
+	`, userID).Scan(&otp.Secret, &otp.Enabled, &otp.LastCounter)
 	if err != nil {
 		return nil, err
 	}
-	return &login, nil
+	return &otp, nil
 }
 
-func (p *PgStorage) DeleteLogin(ctx context.Context, userID int64, loginID string) error {
-	_, err := p.pool.Exec(ctx, `
-	DELETE FROM "logins"
-	WHERE "user" = $1 and "id" = $2
-	`, userID, loginID)
-
-	return err
+func (p *PgStorage) SetTOTPSecret(ctx context.Context, userID int64, secret string) error {
+	result, err := p.pool.Exec(ctx, `
+	UPDATE "users" SET "totp_secret" = $2, "totp_last_counter" = 0
+	WHERE "@users" = $1 AND NOT "totp_enabled"
+	`, userID, secret)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrTOTPEnabled
+	}
+	return nil
 }
 
-func (p *PgStorage) AddText(ctx context.Context, userID int64, text models.Text) error {
-	var lastInsertID int64
+func (p *PgStorage) EnableTOTP(ctx context.Context, userID int64, counter int64, recoveryHashes []string) error {
+	// Enabling and saving the recovery codes in one statement, so the codes of an old setup can not remain
+	var enabled int64
 	err := p.pool.QueryRow(ctx, `
-	INSERT INTO "text_data"("id", "user", "content", "metainfo")
-	VALUES ($1, $2, $3, $4)
-	returning "@text"
-	`, text.ID, userID, text.Content, text.MetaInfo).Scan(&lastInsertID)
+	WITH "enabled" AS (
+		UPDATE "users" SET "totp_enabled" = true, "totp_last_counter" = $2
+		WHERE "@users" = $1 AND NOT "totp_enabled" AND "totp_secret" IS NOT NULL
+		returning "@users"
+	), "deleted" AS (
+		DELETE FROM "recovery_codes" WHERE "user" IN (SELECT "@users" FROM "enabled")
+	), "inserted" AS (
+		INSERT INTO "recovery_codes"("user", "code_hash")
+		SELECT "@users", unnest($3::text[]) FROM "enabled"
+	)
+	SELECT count(*) FROM "enabled"
+	`, userID, counter, recoveryHashes).Scan(&enabled)
 	if err != nil {
 		return err
 	}
-	log.Info().Msgf("Новые текстовые данные с идентификатором - %s добавлены. @login - %d", text.ID, lastInsertID)
+	if enabled == 0 {
+		return ErrTOTPEnabled
+	}
 	return nil
 }
 
-func (p *PgStorage) Text(ctx context.Context, userID int64, textID string) (*models.Text, error) {
-	var text models.Text
+func (p *PgStorage) UseTOTPCounter(ctx context.Context, userID int64, counter int64) (bool, error) {
+	result, err := p.pool.Exec(ctx, `
+	UPDATE "users" SET "totp_last_counter" = $2
+	WHERE "@users" = $1 AND "totp_last_counter" < $2
+	`, userID, counter)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+func (p *PgStorage) UseRecoveryCode(ctx context.Context, userID int64, hash string) (bool, error) {
+	result, err := p.pool.Exec(ctx, `
+	UPDATE "recovery_codes" SET "used_at" = now()
+	WHERE "user" = $1 AND "code_hash" = $2 AND "used_at" IS NULL
+	`, userID, hash)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+func (p *PgStorage) DisableTOTP(ctx context.Context, userID int64) error {
+	_, err := p.pool.Exec(ctx, `
+	WITH "deleted" AS (
+		DELETE FROM "recovery_codes" WHERE "user" = $1
+	)
+	UPDATE "users" SET "totp_secret" = NULL, "totp_enabled" = false, "totp_last_counter" = 0
+	WHERE "@users" = $1
+	`, userID)
+
+	return err
+}
 
+func (p *PgStorage) LoginRetryAfter(ctx context.Context, keys []string) (time.Duration, error) {
+	var seconds float64
 	err := p.pool.QueryRow(ctx, `
-	SELECT "id", "user", "content", "metainfo"
-	FROM "text_data"
-	WHERE "user" = $1 and "id" = $2
-	LIMIT 1
-	`, userID, textID).Scan(&text.ID, &text.UserID, &text.Content, &text.MetaInfo)
+	SELECT COALESCE(EXTRACT(EPOCH FROM max("locked_until") - now()), 0)::float8
+	FROM "login_attempts"
+	WHERE "key" = ANY($1) AND "locked_until" > now()
+	`, keys).Scan(&seconds)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	return &text, nil
+	return time.Duration(seconds * float64(time.Second)), nil
 }
 
-func (p *PgStorage) DeleteText(ctx context.Context, userID int64, textID string) error {
+func (p *PgStorage) AddLoginFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	// One statement, so concurrent failures on several server instances are all counted
+	var failures int
+	err := p.pool.QueryRow(ctx, `
+	INSERT INTO "login_attempts"("key", "failures", "last_failure_at") VALUES ($1, 1, now())
+	ON CONFLICT ("key") DO UPDATE SET
+		"failures" = CASE WHEN "login_attempts"."last_failure_at" < now() - make_interval(secs => $2)
+			THEN 1 ELSE "login_attempts"."failures" + 1 END,
+		"last_failure_at" = now()
+	returning "failures"
+	`, key, window.Seconds()).Scan(&failures)
+	if err != nil {
+		return 0, err
+	}
+	return failures, nil
+}
+
+func (p *PgStorage) LockLogin(ctx context.Context, key string, delay time.Duration) error {
 	_, err := p.pool.Exec(ctx, `
-	DELETE FROM "text_data"
-	WHERE "user" = $1 and "id" = $2
-	`, userID, textID)
+	UPDATE "login_attempts" SET "locked_until" = GREATEST("locked_until", now() + make_interval(secs => $2))
+	WHERE "key" = $1
+	`, key, delay.Seconds())
 
 	return err
 }
 
-func (p *PgStorage) AddBinary(ctx context.Context, userID int64, binData models.Binary) error {
-	var lastInsertID int64
+func (p *PgStorage) ResetLoginFailures(ctx context.Context, key string) error {
+	_, err := p.pool.Exec(ctx, `
+	DELETE FROM "login_attempts" WHERE "key" = $1
+	`, key)
+
+	return err
+}
+
+func (p *PgStorage) AddLockout(ctx context.Context, userID int64, lockout models.Lockout, delay time.Duration) error {
+	_, err := p.pool.Exec(ctx, `
+	INSERT INTO "lockouts"("user", "ip", "failures", "locked_until") VALUES ($1, $2, $3, now() + make_interval(secs => $4))
+	`, userID, lockout.IP, lockout.Failures, delay.Seconds())
+
+	return err
+}
+
+func (p *PgStorage) Lockouts(ctx context.Context, userID int64) ([]models.Lockout, error) {
+	rows, err := p.pool.Query(ctx, `
+	SELECT "ip", "failures", "created_at", "locked_until"
+	FROM "lockouts"
+	WHERE "user" = $1
+	ORDER BY "created_at" DESC
+	LIMIT 100
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lockouts := make([]models.Lockout, 0)
+	for rows.Next() {
+		var lockout models.Lockout
+		err := rows.Scan(&lockout.IP, &lockout.Failures, &lockout.CreatedAt, &lockout.LockedUntil)
+		if err != nil {
+			return nil, err
+		}
+		lockouts = append(lockouts, lockout)
+	}
+	return lockouts, rows.Err()
+}
+
+func (p *PgStorage) AddAuditEvent(ctx context.Context, userID int64, event models.AuditEvent) error {
+	_, err := p.pool.Exec(ctx, `
+	INSERT INTO "audit_events"("user", "actor", "action", "kind", "record", "transport", "ip")
+	VALUES (NULLIF($1::bigint, 0), COALESCE(NULLIF($2, ''), (SELECT "login" FROM "users" WHERE "@users" = $1), ''), $3, $4, $5, $6, $7)
+	`, userID, event.Actor, event.Action, string(event.Kind), event.RecordID, event.Transport, event.IP)
+	return err
+}
+
+func (p *PgStorage) AuditEvents(ctx context.Context, userID int64, before int64, limit int) ([]models.AuditEvent, error) {
+	rows, err := p.pool.Query(ctx, `
+	SELECT "@audit_events", "actor", "action", "kind", "record", "transport", "ip", "created_at"
+	FROM "audit_events"
+	WHERE "user" = $1 AND ($2::bigint = 0 OR "@audit_events" < $2)
+	ORDER BY "@audit_events" DESC
+	LIMIT $3
+	`, userID, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]models.AuditEvent, 0)
+	for rows.Next() {
+		var event models.AuditEvent
+		var kind string
+		err := rows.Scan(&event.ID, &event.Actor, &event.Action, &kind, &event.RecordID, &event.Transport, &event.IP, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		event.Kind = models.Kind(kind)
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func (p *PgStorage) Records(ctx context.Context, userID int64, kind models.Kind, opts models.ListOptions) ([]models.RecordInfo, error) {
+	table, ok := sealedTables[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown record kind %q", kind)
+	}
+	compare, order := ">", "ASC"
+	if opts.Desc {
+		compare, order = "<", "DESC"
+	}
+
+	// Table and column names are taken from sealedTables, not from the input
+	filter := fmt.Sprintf(`
+	AND ($4 = '' OR EXISTS (
+		SELECT 1 FROM "record_tags" t
+		WHERE t."user" = $1 AND t."kind" = '%[1]s' AND t."id" = "%[2]s"."id" AND t."tag" = $4
+	))
+	AND ($5 = '' OR EXISTS (
+		SELECT 1 FROM "record_fields" f
+		WHERE f."user" = $1 AND f."kind" = '%[1]s' AND f."id" = "%[2]s"."id" AND f."name" = $5
+		AND ($6 = '' OR f."value" = $6)
+	))
+	AND ($7::bigint = 0 OR EXISTS (
+		SELECT 1 FROM "record_locations" l
+		WHERE l."user" = $1 AND l."kind" = '%[1]s' AND l."id" = "%[2]s"."id" AND l."vault" = $7 AND COALESCE(l."folder", 0) = $8
+	))`, kind, table.table)
+	meta := fmt.Sprintf(`"record_tags_of"("user", '%[1]s', "id"), "record_fields_of"("user", '%[1]s', "id")`, kind)
+	var query string
+	var after interface{}
+	if opts.Sort == models.SortID {
+		query = fmt.Sprintf(`
+	SELECT "id", COALESCE("note", ''), "id", %s
+	FROM "%s"
+	WHERE "user" = $1 AND "deleted_at" IS NULL AND ($2 = '' OR "id" %s $2)%s
+	ORDER BY "id" %s
+	LIMIT $3
+	`, meta, table.table, compare, filter, order)
+		after = opts.After
+	} else {
+		query = fmt.Sprintf(`
+	SELECT "id", COALESCE("note", ''), "%s"::text, %s
+	FROM "%s"
+	WHERE "user" = $1 AND "deleted_at" IS NULL AND ($2::bigint = 0 OR "%s" %s $2)%s
+	ORDER BY "%s" %s
+	LIMIT $3
+	`, table.key, meta, table.table, table.key, compare, filter, table.key, order)
+		position, _ := strconv.ParseInt(opts.After, 10, 64)
+		after = position
+	}
+
+	rows, err := p.pool.Query(ctx, query, userID, after, opts.Limit, opts.Tag, opts.Field, opts.Value,
+		opts.Vault, opts.Folder)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make([]models.RecordInfo, 0)
+	for rows.Next() {
+		var record models.RecordInfo
+		if err := rows.Scan(&record.ID, &record.Note, &record.Cursor, &record.Tags, &record.Fields); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+func (p *PgStorage) Revisions(ctx context.Context, userID int64, kind models.Kind, id string) ([]models.Revision, error) {
+	rows, err := p.pool.Query(ctx, `
+	SELECT "@record_revisions", "version", "action", "created_at"
+	FROM "record_revisions"
+	WHERE "user" = $1 AND "kind" = $2 AND "id" = $3
+	ORDER BY "@record_revisions" DESC
+	`, userID, string(kind), id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := make([]models.Revision, 0)
+	for rows.Next() {
+		revision := models.Revision{Kind: kind, RecordID: id}
+		if err := rows.Scan(&revision.ID, &revision.Version, &revision.Action, &revision.CreatedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+func (p *PgStorage) Revision(ctx context.Context, userID int64, kind models.Kind, id string, revisionID int64) (*models.Revision, error) {
+	if _, ok := sealedTables[kind]; !ok {
+		return nil, fmt.Errorf("unknown record kind %q", kind)
+	}
+	revision := models.Revision{Kind: kind, RecordID: id}
+	var data map[string]interface{}
 	err := p.pool.QueryRow(ctx, `
-	INSERT INTO "bin_data"("id", "user", "content", "metainfo")
-	VALUES ($1, $2, $3, $4)
-	returning "@bin"
-	`, binData.ID, userID, binData.Data, binData.MetaInfo).Scan(&lastInsertID)
+	SELECT "@record_revisions", "version", "action", "created_at", "data"
+	FROM "record_revisions"
+	WHERE "user" = $1 AND "kind" = $2 AND "id" = $3 AND "@record_revisions" = $4
+	`, userID, string(kind), id, revisionID).Scan(&revision.ID, &revision.Version, &revision.Action, &revision.CreatedAt, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	revision.Fields = make(map[string]string)
+	for _, column := range revisionColumns(kind) {
+		value, _ := data[column].(string)
+		revision.Fields[revisionField(kind, column)] = value
+	}
+	return &revision, nil
+}
+
+func (p *PgStorage) RestoreRevision(ctx context.Context, userID int64, kind models.Kind, id string, revisionID int64) (int64, error) {
+	table, ok := sealedTables[kind]
+	if !ok {
+		return 0, fmt.Errorf("unknown record kind %q", kind)
+	}
+	columns := revisionColumns(kind)
+	values := make([]string, 0, len(columns))
+	updates := make([]string, 0, len(columns))
+	for _, column := range columns {
+		values = append(values, fmt.Sprintf(`COALESCE("%s", '')`, column))
+		updates = append(updates, fmt.Sprintf(`"%s" = EXCLUDED."%s"`, column, column))
+	}
+	filter := ""
+	if kind == models.KindBinary {
+		// The content of the binary is in the row or in the upload, the revision restores the one it had
+		// with the metadata of the file. The removed upload can not be restored
+		for _, column := range binaryContentColumns {
+			columns = append(columns, column.name)
+			values = append(values, column.value)
+			updates = append(updates, fmt.Sprintf(`"%s" = EXCLUDED."%s"`, column.name, column.name))
+		}
+		filter = `
+	WHERE "blob" IS NULL OR "blob" IN (SELECT "id" FROM "bin_uploads" WHERE "user" = $1 AND "completed_at" IS NOT NULL)`
+	}
+
+	// Table and column names are taken from sealedTables, not from the input.
+	// The deleted record gets the version after the last revision, the existing one is updated and leaves the trash.
+	// The columns added after the revision was kept are restored empty
+	var version int64
+	err := p.pool.QueryRow(ctx, fmt.Sprintf(`
+	WITH "revision" AS (
+		SELECT (jsonb_populate_record(NULL::"%[1]s", "data")).*
+		FROM "record_revisions"
+		WHERE "user" = $1 AND "kind" = $2 AND "id" = $3 AND "@record_revisions" = $4
+	)
+	INSERT INTO "%[1]s"("id", "user", "%[2]s", "version")
+	SELECT $3, $1, %[4]s, (
+		SELECT COALESCE(MAX("version"), 0) + 1 FROM "record_revisions" WHERE "user" = $1 AND "kind" = $2 AND "id" = $3
+	)
+	FROM "revision"%[5]s
+	ON CONFLICT ("id", "user") DO UPDATE SET %[3]s, "version" = "%[1]s"."version" + 1, "deleted_at" = NULL
+	RETURNING "version"
+	`, table.table, strings.Join(columns, `", "`), strings.Join(updates, ", "), strings.Join(values, ", "), filter),
+		userID, string(kind), id, revisionID).Scan(&version)
+	if kind != models.KindBinary {
+		return version, err
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		if _, err := p.Revision(ctx, userID, kind, id, revisionID); err != nil {
+			return 0, err
+		}
+		return 0, ErrContentRemoved
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// The upload replaced by the restored content is removed like on the update
+	_, err = p.pool.Exec(ctx, `
+	DELETE FROM "bin_uploads" u USING "bin_data" b
+	WHERE b."user" = $1 AND b."id" = $2 AND u."@bin" = b."@bin" AND u."completed_at" IS NOT NULL
+		AND u."id" IS DISTINCT FROM b."blob"
+	`, userID, id)
+	return version, err
+}
+
+// binaryContentColumns the columns of the binary restored with its content besides revisionColumns,
+// they are not shown in the revision
+var binaryContentColumns = []struct {
+	name  string
+	value string
+}{
+	{name: "blob", value: `"blob"`},
+	{name: "size", value: `COALESCE("size", 0)`},
+	{name: "file_size", value: `COALESCE("file_size", 0)`},
+	{name: "mime_type", value: `COALESCE("mime_type", '')`},
+	{name: "mod_time", value: `"mod_time"`},
+}
+
+func (p *PgStorage) PurgeRevisions(ctx context.Context, retention time.Duration) (int64, error) {
+	result, err := p.pool.Exec(ctx, `
+	DELETE FROM "record_revisions" r
+	WHERE r."created_at" < now() - make_interval(secs => $1)
+		AND (r."action" = 'delete' OR EXISTS (
+			SELECT 1 FROM "record_revisions" n
+			WHERE n."user" = r."user" AND n."kind" = r."kind" AND n."id" = r."id"
+				AND n."@record_revisions" > r."@record_revisions"
+		))
+	`, retention.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+func (p *PgStorage) Trash(ctx context.Context, userID int64) ([]models.TrashItem, error) {
+	rows, err := p.pool.Query(ctx, `
+	SELECT 'card', "id", COALESCE("note", ''), "deleted_at" FROM "cards" WHERE "user" = $1 AND "deleted_at" IS NOT NULL
+	UNION ALL
+	SELECT 'login', "id", COALESCE("note", ''), "deleted_at" FROM "logins" WHERE "user" = $1 AND "deleted_at" IS NOT NULL
+	UNION ALL
+	SELECT 'text', "id", COALESCE("note", ''), "deleted_at" FROM "text_data" WHERE "user" = $1 AND "deleted_at" IS NOT NULL
+	UNION ALL
+	SELECT 'bin', "id", COALESCE("note", ''), "deleted_at" FROM "bin_data" WHERE "user" = $1 AND "deleted_at" IS NOT NULL
+	UNION ALL
+	SELECT 'ssh', "id", COALESCE("note", ''), "deleted_at" FROM "ssh_keys" WHERE "user" = $1 AND "deleted_at" IS NOT NULL
+	UNION ALL
+	SELECT 'custom', "id", COALESCE("note", ''), "deleted_at" FROM "custom_records" WHERE "user" = $1 AND "deleted_at" IS NOT NULL
+	ORDER BY 4 DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.TrashItem, 0)
+	for rows.Next() {
+		var item models.TrashItem
+		var kind string
+		if err := rows.Scan(&kind, &item.ID, &item.Note, &item.DeletedAt); err != nil {
+			return nil, err
+		}
+		item.Kind = models.Kind(kind)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (p *PgStorage) RestoreTrash(ctx context.Context, userID int64, kind models.Kind, id string) error {
+	table, ok := sealedTables[kind]
+	if !ok {
+		return fmt.Errorf("unknown record kind %q", kind)
+	}
+	// Table name is taken from sealedTables, not from the input
+	result, err := p.pool.Exec(ctx, fmt.Sprintf(`
+	UPDATE "%s" SET "deleted_at" = NULL
+	WHERE "user" = $1 AND "id" = $2 AND "deleted_at" IS NOT NULL
+	`, table.table), userID, id)
 	if err != nil {
 		return err
 	}
-	log.Info().Msgf("Новые бинарные данные с идентификатором - %s добавлены. @bin - %d",
-		binData.ID, lastInsertID)
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
 	return nil
 }
 
-func (p *PgStorage) Binary(ctx context.Context, userID int64, binID string) (*models.Binary, error) {
-	var binary models.Binary
+func (p *PgStorage) EmptyTrash(ctx context.Context, userID int64) (int64, error) {
+	return p.removeTrashed(ctx, `"user" = $1`, userID)
+}
 
+func (p *PgStorage) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	return p.removeTrashed(ctx, `"deleted_at" < now() - make_interval(secs => $1)`, retention.Seconds())
+}
+
+func (p *PgStorage) Vaults(ctx context.Context, userID int64) ([]models.Vault, error) {
+	rows, err := p.pool.Query(ctx, `
+	SELECT "@vaults", "name" FROM "vaults"
+	WHERE "user" = $1
+	ORDER BY "name"
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	vaults := make([]models.Vault, 0)
+	for rows.Next() {
+		var vault models.Vault
+		if err := rows.Scan(&vault.ID, &vault.Name); err != nil {
+			return nil, err
+		}
+		vaults = append(vaults, vault)
+	}
+	return vaults, rows.Err()
+}
+
+func (p *PgStorage) AddVault(ctx context.Context, userID int64, name string) (int64, error) {
+	var vaultID int64
 	err := p.pool.QueryRow(ctx, `
-	SELECT "id", "user", "content", "metainfo"
-	FROM "bin_data"
-	WHERE "user" = $1 and "id" = $2
-	LIMIT 1
-	`, userID, binID).Scan(&binary.ID, &binary.UserID, &binary.Data, &binary.MetaInfo)
+	INSERT INTO "vaults"("user", "name") VALUES ($1, $2)
+	returning "@vaults"
+	`, userID, name).Scan(&vaultID)
+	if err != nil {
+		return 0, nameError(err)
+	}
+	return vaultID, nil
+}
+
+func (p *PgStorage) RenameVault(ctx context.Context, userID int64, vaultID int64, name string) error {
+	result, err := p.pool.Exec(ctx, `
+	UPDATE "vaults" SET "name" = $3
+	WHERE "user" = $1 AND "@vaults" = $2
+	`, userID, vaultID, name)
+	if err != nil {
+		return nameError(err)
+	}
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (p *PgStorage) DeleteVault(ctx context.Context, userID int64, vaultID int64) error {
+	result, err := p.pool.Exec(ctx, `
+	DELETE FROM "vaults" WHERE "user" = $1 AND "@vaults" = $2
+	`, userID, vaultID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (p *PgStorage) Folders(ctx context.Context, userID int64, vaultID int64) ([]models.Folder, error) {
+	rows, err := p.pool.Query(ctx, `
+	SELECT "@folders", "vault", COALESCE("parent", 0), "name" FROM "folders"
+	WHERE "user" = $1 AND "vault" = $2
+	ORDER BY "name", "@folders"
+	`, userID, vaultID)
 	if err != nil {
 		return nil, err
 	}
-	return &binary, nil
+	defer rows.Close()
+
+	folders := make([]models.Folder, 0)
+	for rows.Next() {
+		var folder models.Folder
+		if err := rows.Scan(&folder.ID, &folder.Vault, &folder.Parent, &folder.Name); err != nil {
+			return nil, err
+		}
+		folders = append(folders, folder)
+	}
+	return folders, rows.Err()
 }
 
-func (p *PgStorage) DeleteBinary(ctx context.Context, userID int64, binID string) error {
-	_, err := p.pool.Exec(ctx, `
-	DELETE FROM "bin_data"
-	WHERE "user" = $1 and "id" = $2
-	`, userID, binID)
+func (p *PgStorage) AddFolder(ctx context.Context, userID int64, folder models.Folder) (int64, error) {
+	var folderID int64
+	err := p.pool.QueryRow(ctx, `
+	INSERT INTO "folders"("user", "vault", "parent", "name")
+	SELECT $1, $2, NULLIF($3::bigint, 0), $4
+	WHERE EXISTS (SELECT 1 FROM "vaults" WHERE "user" = $1 AND "@vaults" = $2)
+	AND ($3 = 0 OR EXISTS (SELECT 1 FROM "folders" WHERE "user" = $1 AND "vault" = $2 AND "@folders" = $3))
+	returning "@folders"
+	`, userID, folder.Vault, folder.Parent, folder.Name).Scan(&folderID)
+	if err != nil {
+		return 0, nameError(err)
+	}
+	return folderID, nil
+}
 
-	return err
+func (p *PgStorage) UpdateFolder(ctx context.Context, userID int64, folder models.Folder) error {
+	// The new parent is neither the folder itself nor one of its nested folders, otherwise they make a cycle.
+	// update_folder locks the folders of the user before the check, so two concurrent moves can not make it together
+	var updated bool
+	err := p.pool.QueryRow(ctx, `
+	SELECT "update_folder"($1, $2, $3, $4)
+	`, userID, folder.ID, folder.Name, folder.Parent).Scan(&updated)
+	if err != nil {
+		return nameError(err)
+	}
+	if !updated {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (p *PgStorage) DeleteFolder(ctx context.Context, userID int64, folderID int64) error {
+	result, err := p.pool.Exec(ctx, `
+	DELETE FROM "folders" WHERE "user" = $1 AND "@folders" = $2
+	`, userID, folderID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (p *PgStorage) MoveRecord(ctx context.Context, userID int64, kind models.Kind, id string, location models.Location) error {
+	table, ok := sealedTables[kind]
+	if !ok {
+		return fmt.Errorf("unknown record kind %q", kind)
+	}
+	// Table name is taken from sealedTables, not from the input
+	err := p.pool.QueryRow(ctx, fmt.Sprintf(`
+	SELECT "set_record_location"("user", '%s', "id", $3, $4) FROM "%s"
+	WHERE "user" = $1 AND "id" = $2 AND "deleted_at" IS NULL
+	`, kind, table.table), userID, id, location.Vault, location.Folder).Scan(nil)
+	return locationError(err)
+}
+
+// Codes of the postgresql errors
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// nameError converts the unique violation of the vault or folder name to ErrNameTaken
+func nameError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrNameTaken
+	}
+	return err
+}
+
+// locationError converts the error raised by set_record_location to ErrInvalidLocation
+func locationError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return ErrInvalidLocation
+	}
+	return err
+}
+
+// removeTrashed removes the trashed records of all kinds matching the condition in one statement,
+// returns the number of the removed records
+func (p *PgStorage) removeTrashed(ctx context.Context, condition string, args ...interface{}) (int64, error) {
+	deletes := make([]string, 0, len(models.Kinds))
+	counts := make([]string, 0, len(models.Kinds))
+	for _, kind := range models.Kinds {
+		deletes = append(deletes, fmt.Sprintf(`"%s" AS (DELETE FROM "%s" WHERE "deleted_at" IS NOT NULL AND %s RETURNING 1)`,
+			kind, sealedTables[kind].table, condition))
+		counts = append(counts, fmt.Sprintf(`(SELECT count(*) FROM "%s")`, kind))
+	}
+
+	var removed int64
+	err := p.pool.QueryRow(ctx, fmt.Sprintf(`
+	WITH %s
+	SELECT %s
+	`, strings.Join(deletes, ",\n\t"), strings.Join(counts, " + ")), args...).Scan(&removed)
+	return removed, err
+}
+
+func (p *PgStorage) AddCard(ctx context.Context, userID int64, card models.Card) error {
+	var lastInsertID int64
+	tags, names, values := metaArgs(card.Tags, card.Fields)
+	err := p.pool.QueryRow(ctx, `
+	WITH "record" AS (
+		INSERT INTO "cards"("id", "user", "fio", "number", "date", "cvv", "note")
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		returning "@cards"
+	)
+	SELECT "@cards", "set_record_meta"($2, 'card', $1, $8, $9, $10), "set_record_location"($2, 'card', $1, $11, $12)
+	FROM "record"
+	`, card.ID, userID, card.FIO, card.Number, card.Date, card.CVV, card.Note, tags, names, values,
+		card.Vault, card.Folder).Scan(&lastInsertID, nil, nil)
+	// так как логин у нас уникален, то при попытке вставить второй одинаковый логин будет ошибка
+	if err != nil {
+		return locationError(err)
+	}
+	log.Info().Msgf("Новая карта с идентификатором - %s добавлена. @card - %d", card.ID, lastInsertID)
+	return nil
+}
+
+func (p *PgStorage) Card(ctx context.Context, userID int64, cardID string) (*models.Card, error) {
+	var card models.Card
+
+	err := p.pool.QueryRow(ctx, `
+	SELECT "id", "user", "fio", "number", "date", "cvv", "note", "version",
+		"record_tags_of"("user", 'card', "id"), "record_fields_of"("user", 'card', "id"),
+		"record_vault_of"("user", 'card', "id"), "record_folder_of"("user", 'card', "id")
+	FROM "cards"
+	WHERE "user" = $1 and "id" = $2 AND "deleted_at" IS NULL
+	LIMIT 1
+	`, userID, cardID).Scan(&card.ID, &card.UserID, &card.FIO, &card.Number, &card.Date, &card.CVV, &card.Note, &card.Version,
+		&card.Tags, &card.Fields, &card.Vault, &card.Folder)
+	if err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+func (p *PgStorage) UpdateCard(ctx context.Context, userID int64, card models.Card) (int64, error) {
+	var version int64
+	tags, names, values := metaArgs(card.Tags, card.Fields)
+	err := p.pool.QueryRow(ctx, `
+	WITH "record" AS (
+		UPDATE "cards" SET "fio" = $4, "number" = $5, "date" = $6, "cvv" = $7, "note" = $8, "version" = "version" + 1
+		WHERE "user" = $1 AND "id" = $2 AND "version" = $3 AND "deleted_at" IS NULL
+		RETURNING "version"
+	)
+	SELECT "version", "set_record_meta"($1, 'card', $2, $9, $10, $11) FROM "record"
+	`, userID, card.ID, card.Version, card.FIO, card.Number, card.Date, card.CVV, card.Note, tags, names, values).Scan(&version, nil)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, p.versionConflict(ctx, models.KindCard, userID, card.ID)
+	}
+	return version, err
+}
+
+func (p *PgStorage) DeleteCard(ctx context.Context, userID int64, cardID string) error {
+	result, err := p.pool.Exec(ctx, `
+	UPDATE "cards" SET "deleted_at" = now()
+	WHERE "user" = $1 AND "id" = $2 AND "deleted_at" IS NULL
+	`, userID, cardID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (p *PgStorage) AddLogin(ctx context.Context, userID int64, login models.Login) error {
+	var lastInsertID int64
+	tags, names, values := metaArgs(login.Tags, login.Fields)
+	err := p.pool.QueryRow(ctx, `
+	WITH "record" AS (
+		INSERT INTO "logins"("id", "user", "login", "password", "totp", "note")
+		VALUES ($1, $2, $3, $4, $5, $6)
+		returning "@logins"
+	)
+	SELECT "@logins", "set_record_meta"($2, 'login', $1, $7, $8, $9), "set_record_location"($2, 'login', $1, $10, $11)
+	FROM "record"
+	`, login.ID, userID, login.Login, login.Password, login.TOTP, login.Note, tags, names, values,
+		login.Vault, login.Folder).Scan(&lastInsertID, nil, nil)
+	if err != nil {
+		return locationError(err)
+	}
+	log.Info().Msgf("Новые учетные данные с идентификатором - %s добавлены. @login - %d", login.ID, lastInsertID)
+	return nil
+}
+
+func (p *PgStorage) Login(ctx context.Context, userID int64, loginID string) (*models.Login, error) {
+	var login models.Login
+
+	err := p.pool.QueryRow(ctx, `
+	SELECT "id", "user", "login", "password", "totp", "note", "version",
+		"record_tags_of"("user", 'login', "id"), "record_fields_of"("user", 'login', "id"),
+		"record_vault_of"("user", 'login', "id"), "record_folder_of"("user", 'login', "id")
+	FROM "logins"
+	WHERE "user" = $1 and "id" = $2 AND "deleted_at" IS NULL
+	LIMIT 1
+	`, userID, loginID).Scan(&login.ID, &login.UserID, &login.Login, &login.Password, &login.TOTP, &login.Note, &login.Version,
+		&login.Tags, &login.Fields, &login.Vault, &login.Folder)
+	if err != nil {
+		return nil, err
+	}
+	return &login, nil
+}
+
+func (p *PgStorage) UpdateLogin(ctx context.Context, userID int64, login models.Login) (int64, error) {
+	var version int64
+	tags, names, values := metaArgs(login.Tags, login.Fields)
+	err := p.pool.QueryRow(ctx, `
+	WITH "record" AS (
+		UPDATE "logins" SET "login" = $4, "password" = $5, "totp" = $6, "note" = $7, "version" = "version" + 1
+		WHERE "user" = $1 AND "id" = $2 AND "version" = $3 AND "deleted_at" IS NULL
+		RETURNING "version"
+	)
+	SELECT "version", "set_record_meta"($1, 'login', $2, $8, $9, $10) FROM "record"
+	`, userID, login.ID, login.Version, login.Login, login.Password, login.TOTP, login.Note, tags, names, values).Scan(&version, nil)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, p.versionConflict(ctx, models.KindLogin, userID, login.ID)
+	}
+	return version, err
+}
+
+func (p *PgStorage) DeleteLogin(ctx context.Context, userID int64, loginID string) error {
+	result, err := p.pool.Exec(ctx, `
+	UPDATE "logins" SET "deleted_at" = now()
+	WHERE "user" = $1 AND "id" = $2 AND "deleted_at" IS NULL
+	`, userID, loginID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (p *PgStorage) AddText(ctx context.Context, userID int64, text models.Text) error {
+	var lastInsertID int64
+	tags, names, values := metaArgs(text.Tags, text.Fields)
+	err := p.pool.QueryRow(ctx, `
+	WITH "record" AS (
+		INSERT INTO "text_data"("id", "user", "content", "note")
+		VALUES ($1, $2, $3, $4)
+		returning "@text"
+	)
+	SELECT "@text", "set_record_meta"($2, 'text', $1, $5, $6, $7), "set_record_location"($2, 'text', $1, $8, $9)
+	FROM "record"
+	`, text.ID, userID, text.Content, text.Note, tags, names, values,
+		text.Vault, text.Folder).Scan(&lastInsertID, nil, nil)
+	if err != nil {
+		return locationError(err)
+	}
+	log.Info().Msgf("Новые текстовые данные с идентификатором - %s добавлены. @login - %d", text.ID, lastInsertID)
+	return nil
+}
+
+func (p *PgStorage) Text(ctx context.Context, userID int64, textID string) (*models.Text, error) {
+	var text models.Text
+
+	err := p.pool.QueryRow(ctx, `
+	SELECT "id", "user", "content", "note", "version",
+		"record_tags_of"("user", 'text', "id"), "record_fields_of"("user", 'text', "id"),
+		"record_vault_of"("user", 'text', "id"), "record_folder_of"("user", 'text', "id")
+	FROM "text_data"
+	WHERE "user" = $1 and "id" = $2 AND "deleted_at" IS NULL
+	LIMIT 1
+	`, userID, textID).Scan(&text.ID, &text.UserID, &text.Content, &text.Note, &text.Version,
+		&text.Tags, &text.Fields, &text.Vault, &text.Folder)
+	if err != nil {
+		return nil, err
+	}
+	return &text, nil
+}
+
+func (p *PgStorage) UpdateText(ctx context.Context, userID int64, text models.Text) (int64, error) {
+	var version int64
+	tags, names, values := metaArgs(text.Tags, text.Fields)
+	err := p.pool.QueryRow(ctx, `
+	WITH "record" AS (
+		UPDATE "text_data" SET "content" = $4, "note" = $5, "version" = "version" + 1
+		WHERE "user" = $1 AND "id" = $2 AND "version" = $3 AND "deleted_at" IS NULL
+		RETURNING "version"
+	)
+	SELECT "version", "set_record_meta"($1, 'text', $2, $6, $7, $8) FROM "record"
+	`, userID, text.ID, text.Version, text.Content, text.Note, tags, names, values).Scan(&version, nil)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, p.versionConflict(ctx, models.KindText, userID, text.ID)
+	}
+	return version, err
+}
+
+func (p *PgStorage) DeleteText(ctx context.Context, userID int64, textID string) error {
+	result, err := p.pool.Exec(ctx, `
+	UPDATE "text_data" SET "deleted_at" = now()
+	WHERE "user" = $1 AND "id" = $2 AND "deleted_at" IS NULL
+	`, userID, textID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (p *PgStorage) AddBinary(ctx context.Context, userID int64, binData models.Binary) error {
+	var lastInsertID int64
+	tags, names, values := metaArgs(binData.Tags, binData.Fields)
+	err := p.pool.QueryRow(ctx, `
+	WITH "record" AS (
+		INSERT INTO "bin_data"("id", "user", "content", "note", "file_name", "file_size", "mime_type", "mod_time")
+		VALUES ($1, $2, $3, $4, $10, $11, $12, $13)
+		returning "@bin"
+	)
+	SELECT "@bin", "set_record_meta"($2, 'bin', $1, $5, $6, $7), "set_record_location"($2, 'bin', $1, $8, $9)
+	FROM "record"
+	`, binData.ID, userID, binData.Data, binData.Note, tags, names, values,
+		binData.Vault, binData.Folder, binData.FileName, binData.FileSize, binData.MimeType, binData.ModTime).
+		Scan(&lastInsertID, nil, nil)
+	if err != nil {
+		return locationError(err)
+	}
+	log.Info().Msgf("Новые бинарные данные с идентификатором - %s добавлены. @bin - %d",
+		binData.ID, lastInsertID)
+	return nil
+}
+
+func (p *PgStorage) Binary(ctx context.Context, userID int64, binID string) (*models.Binary, error) {
+	var binary models.Binary
+
+	err := p.pool.QueryRow(ctx, `
+	SELECT "id", "user", "content", COALESCE("blob", ''), "size", "note", "version",
+		"record_tags_of"("user", 'bin', "id"), "record_fields_of"("user", 'bin', "id"),
+		"record_vault_of"("user", 'bin', "id"), "record_folder_of"("user", 'bin', "id"),
+		"file_name", "file_size", "mime_type", "mod_time"
+	FROM "bin_data"
+	WHERE "user" = $1 and "id" = $2 AND "deleted_at" IS NULL
+	LIMIT 1
+	`, userID, binID).Scan(&binary.ID, &binary.UserID, &binary.Data, &binary.ContentID, &binary.Size, &binary.Note,
+		&binary.Version, &binary.Tags, &binary.Fields, &binary.Vault, &binary.Folder,
+		&binary.FileName, &binary.FileSize, &binary.MimeType, &binary.ModTime)
+	if err != nil {
+		return nil, err
+	}
+	return &binary, nil
+}
+
+func (p *PgStorage) UpdateBinary(ctx context.Context, userID int64, binData models.Binary) (int64, error) {
+	var version int64
+	tags, names, values := metaArgs(binData.Tags, binData.Fields)
+	err := p.pool.QueryRow(ctx, `
+	WITH "record" AS (
+		UPDATE "bin_data" SET "content" = $4, "note" = $5, "version" = "version" + 1,
+			"blob" = CASE WHEN "blob" = $9 THEN "blob" END, "size" = CASE WHEN "blob" = $9 THEN "size" ELSE 0 END,
+			"file_name" = $10, "file_size" = $11, "mime_type" = $12, "mod_time" = $13
+		WHERE "user" = $1 AND "id" = $2 AND "version" = $3 AND "deleted_at" IS NULL
+		RETURNING "@bin", "version"
+	), "previous" AS (
+		DELETE FROM "bin_uploads"
+		WHERE "@bin" IN (SELECT "@bin" FROM "record") AND "completed_at" IS NOT NULL AND "id" <> $9
+	)
+	SELECT "version", "set_record_meta"($1, 'bin', $2, $6, $7, $8) FROM "record"
+	`, userID, binData.ID, binData.Version, binData.Data, binData.Note, tags, names, values,
+		binData.ContentID, binData.FileName, binData.FileSize, binData.MimeType, binData.ModTime).Scan(&version, nil)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, p.versionConflict(ctx, models.KindBinary, userID, binData.ID)
+	}
+	return version, err
+}
+
+func (p *PgStorage) DeleteBinary(ctx context.Context, userID int64, binID string) error {
+	result, err := p.pool.Exec(ctx, `
+	UPDATE "bin_data" SET "deleted_at" = now()
+	WHERE "user" = $1 AND "id" = $2 AND "deleted_at" IS NULL
+	`, userID, binID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (p *PgStorage) StartUpload(ctx context.Context, userID int64, binID string) (*models.BinaryUpload, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("cant generate upload id: %w", err)
+	}
+	upload := models.BinaryUpload{ID: hex.EncodeToString(id), BinaryID: binID}
+	_, err := p.pool.Exec(ctx, `
+	INSERT INTO "bin_uploads"("id", "user", "@bin")
+	SELECT $1, "user", "@bin" FROM "bin_data"
+	WHERE "user" = $2 AND "id" = $3 AND "deleted_at" IS NULL
+	`, upload.ID, userID, binID)
+	if err != nil {
+		return nil, err
+	}
+	return p.BinaryUpload(ctx, userID, upload.ID)
+}
+
+func (p *PgStorage) BinaryUpload(ctx context.Context, userID int64, uploadID string) (*models.BinaryUpload, error) {
+	var upload models.BinaryUpload
+	err := p.pool.QueryRow(ctx, `
+	SELECT u."id", b."id", u."size", u."chunks"
+	FROM "bin_uploads" u JOIN "bin_data" b ON b."@bin" = u."@bin"
+	WHERE u."user" = $1 AND u."id" = $2 AND u."completed_at" IS NULL AND b."deleted_at" IS NULL
+	`, userID, uploadID).Scan(&upload.ID, &upload.BinaryID, &upload.Offset, &upload.Chunks)
+	if err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+func (p *PgStorage) AddChunk(ctx context.Context, userID int64, chunk models.BinaryChunk) (*models.BinaryUpload, error) {
+	// The blob is written first, the chunk of another upload or out of order is never referenced
+	// and is removed with its upload
+	sum, err := blobstore.Write(ctx, p.blobs, chunkDir(chunk.Upload, chunk.Seq), chunk.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	var upload models.BinaryUpload
+	// Only the last chunk may be short, so the chunk is not appended after a short one
+	err = p.pool.QueryRow(ctx, `
+	WITH "upload" AS (
+		UPDATE "bin_uploads" SET "size" = "size" + $4, "chunks" = "chunks" + 1
+		WHERE "user" = $1 AND "id" = $2 AND "completed_at" IS NULL AND "chunks" = $3 AND "size" = "chunks" * $6
+		RETURNING "id", "@bin", "size", "chunks"
+	), "chunk" AS (
+		INSERT INTO "bin_chunks"("upload", "seq", "sha256")
+		SELECT "id", $3, $5 FROM "upload"
+	)
+	SELECT u."id", b."id", u."size", u."chunks"
+	FROM "upload" u JOIN "bin_data" b ON b."@bin" = u."@bin"
+	`, userID, chunk.Upload, chunk.Seq, chunk.Size, sum, int64(models.BinaryChunkSize)).
+		Scan(&upload.ID, &upload.BinaryID, &upload.Offset, &upload.Chunks)
+	if errors.Is(err, pgx.ErrNoRows) {
+		if _, err := p.BinaryUpload(ctx, userID, chunk.Upload); err != nil {
+			return nil, err
+		}
+		return nil, ErrChunkOrder
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+func (p *PgStorage) CompleteUpload(ctx context.Context, userID int64, binID string, uploadID string, version int64) (int64, error) {
+	upload, err := p.BinaryUpload(ctx, userID, uploadID)
+	if err != nil {
+		return 0, err
+	}
+	if upload.BinaryID != binID {
+		return 0, pgx.ErrNoRows
+	}
+	var newVersion int64
+	err = p.pool.QueryRow(ctx, `
+	WITH "upload" AS (
+		UPDATE "bin_uploads" u SET "completed_at" = now()
+		FROM "bin_data" b
+		WHERE u."user" = $1 AND u."id" = $2 AND u."completed_at" IS NULL
+			AND b."@bin" = u."@bin" AND b."version" = $3 AND b."deleted_at" IS NULL
+		RETURNING u."id", u."@bin", u."size", b."blob"
+	), "record" AS (
+		UPDATE "bin_data" b SET "blob" = u."id", "size" = u."size", "content" = '', "version" = b."version" + 1
+		FROM "upload" u
+		WHERE b."@bin" = u."@bin"
+		RETURNING b."version"
+	), "previous" AS (
+		DELETE FROM "bin_uploads" WHERE "id" IN (SELECT "blob" FROM "upload")
+	)
+	SELECT "version" FROM "record"
+	`, userID, uploadID, version).Scan(&newVersion)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, p.versionConflict(ctx, models.KindBinary, userID, binID)
+	}
+	return newVersion, err
+}
+
+func (p *PgStorage) BinaryChunk(ctx context.Context, userID int64, contentID string, seq int64) ([]byte, error) {
+	var sum string
+	err := p.pool.QueryRow(ctx, `
+	SELECT c."sha256"
+	FROM "bin_chunks" c JOIN "bin_uploads" u ON u."id" = c."upload"
+	WHERE u."user" = $1 AND u."id" = $2 AND u."completed_at" IS NOT NULL AND c."seq" = $3
+	`, userID, contentID, seq).Scan(&sum)
+	if err != nil {
+		return nil, err
+	}
+	return blobstore.Read(ctx, p.blobs, chunkDir(contentID, seq), sum)
+}
+
+// chunkDir the blobs of the chunk are kept under "<upload>/<seq>", all the blobs of the upload under "<upload>/"
+func chunkDir(uploadID string, seq int64) string {
+	return fmt.Sprintf("%s/%d", uploadID, seq)
+}
+
+func (p *PgStorage) PurgeUploads(ctx context.Context, retention time.Duration) (int64, error) {
+	result, err := p.pool.Exec(ctx, `
+	DELETE FROM "bin_uploads"
+	WHERE "completed_at" IS NULL AND "created_at" < now() - make_interval(secs => $1)
+	`, retention.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+// PurgeBlobs removes the blobs of the uploads removed earlier than retention ago and the blobs of the chunks
+// re-encrypted by the rotation, the downloads of the replaced content started before can finish
+func (p *PgStorage) PurgeBlobs(ctx context.Context, retention time.Duration) (int64, error) {
+	rows, err := p.pool.Query(ctx, `
+	SELECT "prefix" FROM "blob_deletions"
+	WHERE "created_at" < now() - make_interval(secs => $1)
+	ORDER BY "created_at"
+	LIMIT 1000
+	`, retention.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	prefixes := make([]string, 0)
+	for rows.Next() {
+		var prefix string
+		if err := rows.Scan(&prefix); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var removed int64
+	for _, prefix := range prefixes {
+		// The prefix of the removed upload ends with a slash, the replaced blob of the chunk is removed by its key
+		remove := p.blobs.Delete
+		if !strings.HasSuffix(prefix, "/") {
+			remove = p.blobs.Remove
+		}
+		if err := remove(ctx, prefix); err != nil {
+			return removed, err
+		}
+		if _, err := p.pool.Exec(ctx, `DELETE FROM "blob_deletions" WHERE "prefix" = $1`, prefix); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func (p *PgStorage) AddSSHKey(ctx context.Context, userID int64, key models.SSHKey) error {
+	var lastInsertID int64
+	tags, names, values := metaArgs(key.Tags, key.Fields)
+	err := p.pool.QueryRow(ctx, `
+	WITH "record" AS (
+		INSERT INTO "ssh_keys"("id", "user", "private_key", "public_key", "comment", "fingerprint", "note")
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		returning "@ssh_keys"
+	)
+	SELECT "@ssh_keys", "set_record_meta"($2, 'ssh', $1, $8, $9, $10), "set_record_location"($2, 'ssh', $1, $11, $12)
+	FROM "record"
+	`, key.ID, userID, key.PrivateKey, key.PublicKey, key.Comment, key.Fingerprint, key.Note, tags, names, values,
+		key.Vault, key.Folder).Scan(&lastInsertID, nil, nil)
+	if err != nil {
+		return locationError(err)
+	}
+	log.Info().Msgf("Новый ssh ключ с идентификатором - %s добавлен. @ssh_keys - %d", key.ID, lastInsertID)
+	return nil
+}
+
+func (p *PgStorage) SSHKey(ctx context.Context, userID int64, keyID string) (*models.SSHKey, error) {
+	var key models.SSHKey
+
+	err := p.pool.QueryRow(ctx, `
+	SELECT "id", "user", "private_key", "public_key", "comment", "fingerprint", "note", "version",
+		"record_tags_of"("user", 'ssh', "id"), "record_fields_of"("user", 'ssh', "id"),
+		"record_vault_of"("user", 'ssh', "id"), "record_folder_of"("user", 'ssh', "id")
+	FROM "ssh_keys"
+	WHERE "user" = $1 and "id" = $2 AND "deleted_at" IS NULL
+	LIMIT 1
+	`, userID, keyID).Scan(&key.ID, &key.UserID, &key.PrivateKey, &key.PublicKey, &key.Comment, &key.Fingerprint,
+		&key.Note, &key.Version, &key.Tags, &key.Fields, &key.Vault, &key.Folder)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (p *PgStorage) UpdateSSHKey(ctx context.Context, userID int64, key models.SSHKey) (int64, error) {
+	var version int64
+	tags, names, values := metaArgs(key.Tags, key.Fields)
+	err := p.pool.QueryRow(ctx, `
+	WITH "record" AS (
+		UPDATE "ssh_keys" SET "private_key" = $4, "public_key" = $5, "comment" = $6, "fingerprint" = $7, "note" = $8,
+			"version" = "version" + 1
+		WHERE "user" = $1 AND "id" = $2 AND "version" = $3 AND "deleted_at" IS NULL
+		RETURNING "version"
+	)
+	SELECT "version", "set_record_meta"($1, 'ssh', $2, $9, $10, $11) FROM "record"
+	`, userID, key.ID, key.Version, key.PrivateKey, key.PublicKey, key.Comment, key.Fingerprint, key.Note,
+		tags, names, values).Scan(&version, nil)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, p.versionConflict(ctx, models.KindSSH, userID, key.ID)
+	}
+	return version, err
+}
+
+func (p *PgStorage) DeleteSSHKey(ctx context.Context, userID int64, keyID string) error {
+	result, err := p.pool.Exec(ctx, `
+	UPDATE "ssh_keys" SET "deleted_at" = now()
+	WHERE "user" = $1 AND "id" = $2 AND "deleted_at" IS NULL
+	`, userID, keyID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (p *PgStorage) RecordTypes(ctx context.Context, userID int64) ([]models.RecordType, error) {
+	rows, err := p.pool.Query(ctx, `
+	SELECT "name", "fields"::text FROM "record_types"
+	WHERE "user" = $1
+	ORDER BY "name"
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recordTypes := make([]models.RecordType, 0)
+	for rows.Next() {
+		recordType := models.RecordType{UserID: userID}
+		var fields string
+		if err := rows.Scan(&recordType.Name, &fields); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(fields), &recordType.Fields); err != nil {
+			return nil, err
+		}
+		recordTypes = append(recordTypes, recordType)
+	}
+	return recordTypes, rows.Err()
+}
+
+func (p *PgStorage) RecordType(ctx context.Context, userID int64, name string) (*models.RecordType, error) {
+	recordType := models.RecordType{UserID: userID}
+	var fields string
+	err := p.pool.QueryRow(ctx, `
+	SELECT "name", "fields"::text FROM "record_types"
+	WHERE "user" = $1 AND "name" = $2
+	`, userID, name).Scan(&recordType.Name, &fields)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(fields), &recordType.Fields); err != nil {
+		return nil, err
+	}
+	return &recordType, nil
+}
+
+func (p *PgStorage) AddRecordType(ctx context.Context, userID int64, recordType models.RecordType) error {
+	fields, err := json.Marshal(recordType.Fields)
+	if err != nil {
+		return err
+	}
+	_, err = p.pool.Exec(ctx, `
+	INSERT INTO "record_types"("user", "name", "fields") VALUES ($1, $2, $3::jsonb)
+	`, userID, recordType.Name, string(fields))
+	return nameError(err)
+}
+
+func (p *PgStorage) UpdateRecordType(ctx context.Context, userID int64, recordType models.RecordType) error {
+	fields, err := json.Marshal(recordType.Fields)
+	if err != nil {
+		return err
+	}
+	// The values of the records stay in the columns of their secrecy, so while the type has records
+	// the fields can not be removed or change it. The fields are added, reordered and retyped freely
+	var locked bool
+	err = p.pool.QueryRow(ctx, `
+	WITH "type" AS (
+		SELECT EXISTS (
+			SELECT 1 FROM "custom_records" WHERE "user" = $1 AND "type" = $2
+		) AND EXISTS (
+			SELECT 1 FROM jsonb_array_elements("fields") "old"
+			WHERE NOT EXISTS (
+				SELECT 1 FROM jsonb_array_elements($3::jsonb) "new"
+				WHERE "new"->>'name' = "old"->>'name'
+					AND coalesce(("new"->>'secret')::boolean, false) = coalesce(("old"->>'secret')::boolean, false)
+			)
+		) AS "locked"
+		FROM "record_types"
+		WHERE "user" = $1 AND "name" = $2
+		FOR UPDATE
+	), "updated" AS (
+		UPDATE "record_types" SET "fields" = $3::jsonb
+		WHERE "user" = $1 AND "name" = $2 AND NOT (SELECT "locked" FROM "type")
+	)
+	SELECT "locked" FROM "type"
+	`, userID, recordType.Name, string(fields)).Scan(&locked)
+	if err != nil {
+		return err
+	}
+	if locked {
+		return ErrRecordTypeInUse
+	}
+	return nil
+}
+
+func (p *PgStorage) DeleteRecordType(ctx context.Context, userID int64, name string) error {
+	result, err := p.pool.Exec(ctx, `
+	DELETE FROM "record_types" WHERE "user" = $1 AND "name" = $2
+	`, userID, name)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return ErrRecordTypeInUse
+	}
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (p *PgStorage) AddCustomRecord(ctx context.Context, userID int64, record models.CustomRecord) error {
+	var lastInsertID int64
+	tags, names, values := metaArgs(record.Tags, record.Fields)
+	err := p.pool.QueryRow(ctx, `
+	WITH "record" AS (
+		INSERT INTO "custom_records"("id", "user", "type", "values", "secrets", "note")
+		VALUES ($1, $2, $3, $4, $5, $6)
+		returning "@custom_records"
+	)
+	SELECT "@custom_records", "set_record_meta"($2, 'custom', $1, $7, $8, $9),
+		"set_record_location"($2, 'custom', $1, $10, $11)
+	FROM "record"
+	`, record.ID, userID, record.Type, encodeValues(record.Values), secretsColumn(record), record.Note,
+		tags, names, values, record.Vault, record.Folder).Scan(&lastInsertID, nil, nil)
+	if err != nil {
+		return locationError(err)
+	}
+	log.Info().Msgf("Новая запись типа %s с идентификатором - %s добавлена. @custom_records - %d",
+		record.Type, record.ID, lastInsertID)
+	return nil
+}
+
+func (p *PgStorage) CustomRecord(ctx context.Context, userID int64, recordID string) (*models.CustomRecord, error) {
+	var record models.CustomRecord
+	var values, secrets string
+
+	err := p.pool.QueryRow(ctx, `
+	SELECT "id", "user", "type", "values", "secrets", "note", "version",
+		"record_tags_of"("user", 'custom', "id"), "record_fields_of"("user", 'custom', "id"),
+		"record_vault_of"("user", 'custom', "id"), "record_folder_of"("user", 'custom', "id")
+	FROM "custom_records"
+	WHERE "user" = $1 and "id" = $2 AND "deleted_at" IS NULL
+	LIMIT 1
+	`, userID, recordID).Scan(&record.ID, &record.UserID, &record.Type, &values, &secrets, &record.Note,
+		&record.Version, &record.Tags, &record.Fields, &record.Vault, &record.Folder)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(values), &record.Values); err != nil {
+		return nil, err
+	}
+	// The secrets encrypted at rest are opened by EncryptedStorage
+	if keyring.IsSealed(secrets) {
+		record.Sealed = secrets
+	} else if err := json.Unmarshal([]byte(secrets), &record.Secrets); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (p *PgStorage) UpdateCustomRecord(ctx context.Context, userID int64, record models.CustomRecord) (int64, error) {
+	var version int64
+	tags, names, values := metaArgs(record.Tags, record.Fields)
+	err := p.pool.QueryRow(ctx, `
+	WITH "record" AS (
+		UPDATE "custom_records" SET "type" = $4, "values" = $5, "secrets" = $6, "note" = $7, "version" = "version" + 1
+		WHERE "user" = $1 AND "id" = $2 AND "version" = $3 AND "deleted_at" IS NULL
+		RETURNING "version"
+	)
+	SELECT "version", "set_record_meta"($1, 'custom', $2, $8, $9, $10) FROM "record"
+	`, userID, record.ID, record.Version, record.Type, encodeValues(record.Values), secretsColumn(record), record.Note,
+		tags, names, values).Scan(&version, nil)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, p.versionConflict(ctx, models.KindCustom, userID, record.ID)
+	}
+	return version, err
+}
+
+func (p *PgStorage) DeleteCustomRecord(ctx context.Context, userID int64, recordID string) error {
+	result, err := p.pool.Exec(ctx, `
+	UPDATE "custom_records" SET "deleted_at" = now()
+	WHERE "user" = $1 AND "id" = $2 AND "deleted_at" IS NULL
+	`, userID, recordID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// encodeValues the values of the custom record as the json object, the empty object for no values
+func encodeValues(values map[string]string) string {
+	if len(values) == 0 {
+		return "{}"
+	}
+	data, _ := json.Marshal(values)
+	return string(data)
+}
+
+// secretsColumn the value of the secrets column: the secrets sealed by EncryptedStorage or the json object of them
+func secretsColumn(record models.CustomRecord) string {
+	if record.Sealed != "" {
+		return record.Sealed
+	}
+	return encodeValues(record.Secrets)
+}
+
+func (p *PgStorage) UserKeys(ctx context.Context, userID int64) ([]UserKey, error) {
+	rows, err := p.pool.Query(ctx, `
+	SELECT "@user_keys", "user", "version", "kek_version", "wrapped_key"
+	FROM "user_keys"
+	WHERE "user" = $1
+	ORDER BY "version"
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanUserKeys(rows)
+}
+
+func (p *PgStorage) AddUserKey(ctx context.Context, key UserKey) (bool, error) {
+	result, err := p.pool.Exec(ctx, `
+	INSERT INTO "user_keys"("user", "version", "kek_version", "wrapped_key")
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT ("user", "version") DO NOTHING
+	`, key.UserID, key.Version, key.KEKVersion, key.Wrapped)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+func (p *PgStorage) StaleUserKeys(ctx context.Context, kekVersion int, afterKeyID int64, limit int) ([]UserKey, error) {
+	rows, err := p.pool.Query(ctx, `
+	SELECT "@user_keys", "user", "version", "kek_version", "wrapped_key"
+	FROM "user_keys"
+	WHERE "kek_version" <> $1 AND "@user_keys" > $2
+	ORDER BY "@user_keys"
+	LIMIT $3
+	`, kekVersion, afterKeyID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanUserKeys(rows)
+}
+
+func (p *PgStorage) RewrapUserKey(ctx context.Context, key UserKey, oldKEKVersion int) (bool, error) {
+	result, err := p.pool.Exec(ctx, `
+	UPDATE "user_keys" SET "kek_version" = $2, "wrapped_key" = $3
+	WHERE "@user_keys" = $1 AND "kek_version" = $4
+	`, key.KeyID, key.KEKVersion, key.Wrapped, oldKEKVersion)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+func (p *PgStorage) UserIDs(ctx context.Context, afterUserID int64, limit int) ([]int64, error) {
+	rows, err := p.pool.Query(ctx, `
+	SELECT "@users" FROM "users"
+	WHERE "@users" > $1
+	ORDER BY "@users"
+	LIMIT $2
+	`, afterUserID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (p *PgStorage) SealedRows(ctx context.Context, kind models.Kind, afterRowID int64, limit int) ([]SealedRow, error) {
+	table, ok := sealedTables[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown record kind %q", kind)
+	}
+	// Table and column names are taken from sealedTables, not from the input
+	rows, err := p.pool.Query(ctx, fmt.Sprintf(`
+	SELECT "%s", "user", "id", "%s"
+	FROM "%s"
+	WHERE "%s" > $1
+	ORDER BY "%s"
+	LIMIT $2
+	`, table.key, strings.Join(table.columns, `", "`), table.table, table.key, table.key), afterRowID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []SealedRow
+	for rows.Next() {
+		row := SealedRow{Values: make(map[string]string, len(table.columns))}
+		values := make([]string, len(table.columns))
+		dest := []interface{}{&row.RowID, &row.UserID, &row.ID}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		for i, column := range table.columns {
+			row.Values[column] = values[i]
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+func (p *PgStorage) ReplaceSealed(ctx context.Context, kind models.Kind, rowID int64, column string, old string, value string) (bool, error) {
+	table, ok := sealedTables[kind]
+	if !ok || !contains(table.columns, column) {
+		return false, fmt.Errorf("unknown encrypted column %s.%s", kind, column)
+	}
+	// The row is updated only if the value was not changed by the server in the meantime
+	result, err := p.pool.Exec(ctx, fmt.Sprintf(`
+	UPDATE "%s" SET "%s" = $3
+	WHERE "%s" = $1 AND "%s" = $2
+	`, table.table, column, table.key, column), rowID, old, value)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+func (p *PgStorage) SealedTOTPSecrets(ctx context.Context, afterUserID int64, limit int) ([]SealedRow, error) {
+	rows, err := p.pool.Query(ctx, `
+	SELECT "@users", "totp_secret"
+	FROM "users"
+	WHERE "@users" > $1 AND "totp_secret" IS NOT NULL
+	ORDER BY "@users"
+	LIMIT $2
+	`, afterUserID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []SealedRow
+	for rows.Next() {
+		var secret string
+		row := SealedRow{ID: totpID}
+		if err := rows.Scan(&row.UserID, &secret); err != nil {
+			return nil, err
+		}
+		row.RowID = row.UserID
+		row.Values = map[string]string{totpColumn: secret}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+func (p *PgStorage) ReplaceTOTPSecret(ctx context.Context, userID int64, old string, value string) (bool, error) {
+	// The secret is updated only if the user did not set up the second factor again in the meantime
+	result, err := p.pool.Exec(ctx, `
+	UPDATE "users" SET "totp_secret" = $3
+	WHERE "@users" = $1 AND "totp_secret" = $2
+	`, userID, old, value)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// versionConflict tells the stale version from the missing record after the update did not match any row.
+// Returns ErrVersionConflict if the record exists, pgx.ErrNoRows otherwise
+func (p *PgStorage) versionConflict(ctx context.Context, kind models.Kind, userID int64, id string) error {
+	table := sealedTables[kind]
+	var version int64
+	err := p.pool.QueryRow(ctx, fmt.Sprintf(`
+	SELECT "version" FROM "%s"
+	WHERE "user" = $1 AND "id" = $2 AND "deleted_at" IS NULL
+	`, table.table), userID, id).Scan(&version)
+	if err != nil {
+		return err
+	}
+	return ErrVersionConflict
+}
+
+// scanUserKeys reads all rows of the user_keys query
+func scanUserKeys(rows pgx.Rows) ([]UserKey, error) {
+	defer rows.Close()
+
+	var keys []UserKey
+	for rows.Next() {
+		var key UserKey
+		if err := rows.Scan(&key.KeyID, &key.UserID, &key.Version, &key.KEKVersion, &key.Wrapped); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
@@ -3,15 +3,19 @@ package storage
 import (
 	"context"
 	"errors"
+	"io"
 	"testing"
+	"time"
 
 	"github.com/driftprogramming/pgxpoolmock"
 	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/blobstore"
 )
 
 // PgStorageSuite - tests for database operations
@@ -19,6 +23,7 @@ import (
 type PgStorageSuite struct {
 	suite.Suite
 	mockPool *pgxpoolmock.MockPgxPool
+	blobs    blobstore.BlobStore
 	store    Storage
 }
 
@@ -34,7 +39,10 @@ func (suite *PgStorageSuite) SetupTest() {
 
 	mockPool := pgxpoolmock.NewMockPgxPool(ctrl)
 	suite.mockPool = mockPool
-	suite.store = &PgStorage{pool: mockPool}
+	blobs, err := blobstore.NewFS(suite.T().TempDir())
+	suite.Require().NoError(err)
+	suite.blobs = blobs
+	suite.store = &PgStorage{pool: mockPool, blobs: blobs}
 }
 
 func (suite *PgStorageSuite) TestRegister() {
@@ -91,11 +99,11 @@ func (suite *PgStorageSuite) TestUser() {
 	pgxRows.Next()
 
 	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
-	SELECT "@users", "login", "password" FROM "users" WHERE "login" = $1 AND "password" = $2
+	SELECT "@users", "login", "password" FROM "users" WHERE "login" = $1
 	LIMIT 1
-	`, user.Login, user.Password).Return(pgxRows)
+	`, user.Login).Return(pgxRows)
 
-	resultUser, err := suite.store.User(context.Background(), user.Login, user.Password)
+	resultUser, err := suite.store.User(context.Background(), user.Login)
 	assert.Equal(suite.T(), *resultUser, user)
 	assert.NoError(suite.T(), err)
 
@@ -108,15 +116,171 @@ func (suite *PgStorageSuite) TestUser() {
 	pgxRows.Next()
 
 	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
-	SELECT "@users", "login", "password" FROM "users" WHERE "login" = $1 AND "password" = $2
+	SELECT "@users", "login", "password" FROM "users" WHERE "login" = $1
 	LIMIT 1
-	`, user.Login, user.Password).Return(pgxRows)
+	`, user.Login).Return(pgxRows)
 
-	resultUser, err = suite.store.User(context.Background(), user.Login, user.Password)
+	resultUser, err = suite.store.User(context.Background(), user.Login)
 	assert.Nil(suite.T(), resultUser)
 	assert.Error(suite.T(), err, pgx.ErrNoRows)
 }
 
+func (suite *PgStorageSuite) TestUpdatePassword() {
+	userID := int64(1)
+	hash := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$a2V5"
+
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	UPDATE "users" SET "password" = $2
+	WHERE "@users" = $1
+	`, userID, hash).Return([]byte("UPDATE 1"), nil)
+
+	err := suite.store.UpdatePassword(context.Background(), userID, hash)
+	assert.NoError(suite.T(), err)
+
+	// Test for SQL errors
+	targetErr := errors.New("some error")
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	UPDATE "users" SET "password" = $2
+	WHERE "@users" = $1
+	`, userID, hash).Return(nil, targetErr)
+
+	err = suite.store.UpdatePassword(context.Background(), userID, hash)
+	assert.ErrorIs(suite.T(), err, targetErr)
+}
+
+func (suite *PgStorageSuite) TestChangeLogin() {
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	UPDATE "users" SET "login" = $2
+	WHERE "@users" = $1 AND NOT EXISTS (SELECT 1 FROM "users" WHERE "login" = $2 AND "@users" <> $1)
+	`, int64(1), "renamed").Return([]byte("UPDATE 1"), nil)
+
+	err := suite.store.ChangeLogin(context.Background(), 1, "renamed")
+	assert.NoError(suite.T(), err)
+
+	// The login of another user
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	UPDATE "users" SET "login" = $2
+	WHERE "@users" = $1 AND NOT EXISTS (SELECT 1 FROM "users" WHERE "login" = $2 AND "@users" <> $1)
+	`, int64(1), "taken").Return([]byte("UPDATE 0"), nil)
+
+	err = suite.store.ChangeLogin(context.Background(), 1, "taken")
+	assert.ErrorIs(suite.T(), err, ErrLoginTaken)
+
+	// The concurrent rename to the same login
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	UPDATE "users" SET "login" = $2
+	WHERE "@users" = $1 AND NOT EXISTS (SELECT 1 FROM "users" WHERE "login" = $2 AND "@users" <> $1)
+	`, int64(1), "raced").Return(nil, &pgconn.PgError{Code: "23505"})
+
+	err = suite.store.ChangeLogin(context.Background(), 1, "raced")
+	assert.ErrorIs(suite.T(), err, ErrLoginTaken)
+}
+
+func (suite *PgStorageSuite) TestDeleteUser() {
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	DELETE FROM "users" WHERE "@users" = $1
+	`, int64(1)).Return([]byte("DELETE 1"), nil)
+
+	err := suite.store.DeleteUser(context.Background(), 1)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *PgStorageSuite) TestRevokeOtherSessions() {
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	UPDATE "sessions" SET "revoked_at" = now()
+	WHERE "user" = $1 AND "id" <> $2 AND "revoked_at" IS NULL
+	`, int64(1), "session").Return([]byte("UPDATE 2"), nil)
+
+	err := suite.store.RevokeOtherSessions(context.Background(), 1, "session")
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *PgStorageSuite) TestRefreshSession() {
+	expiresAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	// The replaced hash is added to the used ones of the session
+	pgxRows := pgxpoolmock.NewRows([]string{"@users", "login"}).AddRow(int64(1), "login").ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	WITH "refreshed" AS (
+		UPDATE "sessions" s SET "refresh_hash" = $3, "refreshed_at" = now(), "expires_at" = $4
+		FROM "users" u
+		WHERE s."id" = $1 AND s."refresh_hash" = $2 AND s."revoked_at" IS NULL AND s."expires_at" > now()
+			AND u."@users" = s."user"
+		returning u."@users", u."login"
+	), "used" AS (
+		INSERT INTO "session_used_hashes"("session", "hash")
+		SELECT $1, $2 FROM "refreshed"
+	)
+	SELECT "@users", "login" FROM "refreshed"
+	`, "session", "old", "new", expiresAt).Return(pgxRows)
+
+	user, err := suite.store.RefreshSession(context.Background(), "session", "old", "new", expiresAt)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), &models.User{UserID: 1, Login: "login"}, user)
+}
+
+func (suite *PgStorageSuite) TestRevokeReusedSession() {
+	query := `
+	UPDATE "sessions" SET "revoked_at" = now()
+	WHERE "id" = $1 AND "revoked_at" IS NULL
+		AND EXISTS (SELECT 1 FROM "session_used_hashes" WHERE "session" = $1 AND "hash" = $2)
+	`
+	// Any of the used hashes revokes the session, not only the last one
+	suite.mockPool.EXPECT().Exec(gomock.Any(), query, "session", "first").Return([]byte("UPDATE 1"), nil)
+	revoked, err := suite.store.RevokeReusedSession(context.Background(), "session", "first")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), revoked)
+
+	suite.mockPool.EXPECT().Exec(gomock.Any(), query, "session", "unknown").Return([]byte("UPDATE 0"), nil)
+	revoked, err = suite.store.RevokeReusedSession(context.Background(), "session", "unknown")
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), revoked)
+}
+
+func (suite *PgStorageSuite) TestVaultParams() {
+	userID := int64(1)
+	params := models.VaultParams{
+		Salt:  []byte("0123456789abcdef"),
+		Check: "$gk1$check",
+	}
+
+	columns := []string{"vault_salt", "vault_check"}
+	pgxRows := pgxpoolmock.NewRows(columns).AddRow(params.Salt, params.Check).ToPgxRows()
+	pgxRows.Next()
+
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	UPDATE "users" SET "vault_salt" = COALESCE("vault_salt", $2)
+	WHERE "@users" = $1
+	returning "vault_salt", COALESCE("vault_check", '')
+	`, userID, gomock.Any()).Return(pgxRows)
+
+	result, err := suite.store.VaultParams(context.Background(), userID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), params, *result)
+}
+
+func (suite *PgStorageSuite) TestSetVaultCheck() {
+	userID := int64(1)
+	check := "$gk1$check"
+
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	UPDATE "users" SET "vault_check" = $2
+	WHERE "@users" = $1 AND "vault_check" IS NULL
+	`, userID, check).Return([]byte("UPDATE 1"), nil)
+
+	err := suite.store.SetVaultCheck(context.Background(), userID, check)
+	assert.NoError(suite.T(), err)
+
+	// The check value is already set
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	UPDATE "users" SET "vault_check" = $2
+	WHERE "@users" = $1 AND "vault_check" IS NULL
+	`, userID, check).Return([]byte("UPDATE 0"), nil)
+
+	err = suite.store.SetVaultCheck(context.Background(), userID, check)
+	assert.ErrorIs(suite.T(), err, ErrVaultInitialized)
+}
+
 func (suite *PgStorageSuite) TestUserByLogin() {
 	userID := int64(1)
 	user := models.User{
@@ -159,24 +323,31 @@ func (suite *PgStorageSuite) TestUserByLogin() {
 func (suite *PgStorageSuite) TestAddCard() {
 	userID := int64(1)
 	card := models.Card{
-		ID:       "testID",
-		FIO:      "fio",
-		Number:   "number",
-		Date:     "date",
-		CVV:      "cvv",
-		MetaInfo: "metainfo",
+		ID:     "testID",
+		FIO:    "fio",
+		Number: "number",
+		Date:   "date",
+		CVV:    "cvv",
+		Note:   "note",
+		Tags:   []string{"work"},
+		Fields: map[string]string{"bank": "test"},
 	}
 
-	columns := []string{"id"}
+	columns := []string{"id", "meta", "location"}
 	pgxRows := pgxpoolmock.NewRows(columns).
-		AddRow(int64(1)).ToPgxRows()
+		AddRow(int64(1), nil, nil).ToPgxRows()
 	pgxRows.Next()
 
 	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
-	INSERT INTO "cards"("id", "user", "fio", "number", "date", "cvv", "metainfo")
-	VALUES ($1, $2, $3, $4, $5, $6, $7)
-	returning "@cards"
-	`, card.ID, userID, card.FIO, card.Number, card.Date, card.CVV, card.MetaInfo).Return(pgxRows)
+	WITH "record" AS (
+		INSERT INTO "cards"("id", "user", "fio", "number", "date", "cvv", "note")
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		returning "@cards"
+	)
+	SELECT "@cards", "set_record_meta"($2, 'card', $1, $8, $9, $10), "set_record_location"($2, 'card', $1, $11, $12)
+	FROM "record"
+	`, card.ID, userID, card.FIO, card.Number, card.Date, card.CVV, card.Note,
+		[]string{"work"}, []string{"bank"}, []string{"test"}, card.Vault, card.Folder).Return(pgxRows)
 
 	err := suite.store.AddCard(context.Background(), userID, card)
 	assert.NoError(suite.T(), err)
@@ -184,16 +355,21 @@ func (suite *PgStorageSuite) TestAddCard() {
 	// Test for SQL errors
 	targetErr := errors.New("some error")
 	pgxRows = pgxpoolmock.NewRows(columns).
-		AddRow(int64(1)).
+		AddRow(int64(1), nil, nil).
 		RowError(0, targetErr).
 		ToPgxRows()
 	pgxRows.Next()
 
 	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
-	INSERT INTO "cards"("id", "user", "fio", "number", "date", "cvv", "metainfo")
-	VALUES ($1, $2, $3, $4, $5, $6, $7)
-	returning "@cards"
-	`, card.ID, userID, card.FIO, card.Number, card.Date, card.CVV, card.MetaInfo).Return(pgxRows)
+	WITH "record" AS (
+		INSERT INTO "cards"("id", "user", "fio", "number", "date", "cvv", "note")
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		returning "@cards"
+	)
+	SELECT "@cards", "set_record_meta"($2, 'card', $1, $8, $9, $10), "set_record_location"($2, 'card', $1, $11, $12)
+	FROM "record"
+	`, card.ID, userID, card.FIO, card.Number, card.Date, card.CVV, card.Note,
+		[]string{"work"}, []string{"bank"}, []string{"test"}, card.Vault, card.Folder).Return(pgxRows)
 
 	err = suite.store.AddCard(context.Background(), userID, card)
 	assert.Error(suite.T(), err, targetErr)
@@ -202,24 +378,31 @@ func (suite *PgStorageSuite) TestAddCard() {
 func (suite *PgStorageSuite) TestCard() {
 	userID := int64(1)
 	card := models.Card{
-		ID:       "testID",
-		FIO:      "fio",
-		Number:   "number",
-		Date:     "date",
-		CVV:      "cvv",
-		MetaInfo: "metainfo",
-		UserID:   userID,
+		ID:      "testID",
+		FIO:     "fio",
+		Number:  "number",
+		Date:    "date",
+		CVV:     "cvv",
+		Note:    "note",
+		Version: 3,
+		UserID:  userID,
+		Tags:    []string{"work"},
+		Fields:  map[string]string{"bank": "test"},
+		Vault:   2,
+		Folder:  5,
 	}
 
-	columns := []string{"id", "user", "fio", "number", "date", "cvv", "metainfo"}
+	columns := []string{"id", "user", "fio", "number", "date", "cvv", "note", "version", "tags", "fields", "vault", "folder"}
 	pgxRows := pgxpoolmock.NewRows(columns).
-		AddRow(card.ID, userID, card.FIO, card.Number, card.Date, card.CVV, card.MetaInfo).ToPgxRows()
+		AddRow(card.ID, userID, card.FIO, card.Number, card.Date, card.CVV, card.Note, card.Version, card.Tags, card.Fields, card.Vault, card.Folder).ToPgxRows()
 	pgxRows.Next()
 
 	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
-	SELECT "id", "user", "fio", "number", "date", "cvv", "metainfo"
+	SELECT "id", "user", "fio", "number", "date", "cvv", "note", "version",
+		"record_tags_of"("user", 'card', "id"), "record_fields_of"("user", 'card', "id"),
+		"record_vault_of"("user", 'card', "id"), "record_folder_of"("user", 'card', "id")
 	FROM "cards"
-	WHERE "user" = $1 and "id" = $2
+	WHERE "user" = $1 and "id" = $2 AND "deleted_at" IS NULL
 	LIMIT 1
 	`, userID, card.ID).Return(pgxRows)
 
@@ -230,15 +413,17 @@ func (suite *PgStorageSuite) TestCard() {
 	// Test for SQL errors
 	targetErr := errors.New("some error")
 	pgxRows = pgxpoolmock.NewRows(columns).
-		AddRow(card.ID, userID, card.FIO, card.Number, card.Date, card.CVV, card.MetaInfo).
+		AddRow(card.ID, userID, card.FIO, card.Number, card.Date, card.CVV, card.Note, card.Version, card.Tags, card.Fields, card.Vault, card.Folder).
 		RowError(0, targetErr).
 		ToPgxRows()
 	pgxRows.Next()
 
 	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
-	SELECT "id", "user", "fio", "number", "date", "cvv", "metainfo"
+	SELECT "id", "user", "fio", "number", "date", "cvv", "note", "version",
+		"record_tags_of"("user", 'card', "id"), "record_fields_of"("user", 'card', "id"),
+		"record_vault_of"("user", 'card', "id"), "record_folder_of"("user", 'card', "id")
 	FROM "cards"
-	WHERE "user" = $1 and "id" = $2
+	WHERE "user" = $1 and "id" = $2 AND "deleted_at" IS NULL
 	LIMIT 1
 	`, userID, card.ID).Return(pgxRows)
 
@@ -252,12 +437,21 @@ func (suite *PgStorageSuite) TestDeleteCard() {
 	cardID := "testID"
 
 	suite.mockPool.EXPECT().Exec(gomock.Any(), `
-	DELETE FROM "cards"
-	WHERE "user" = $1 and "id" = $2
-	`, userID, cardID).Return([]byte("DELETE"), nil)
+	UPDATE "cards" SET "deleted_at" = now()
+	WHERE "user" = $1 AND "id" = $2 AND "deleted_at" IS NULL
+	`, userID, cardID).Return([]byte("UPDATE 1"), nil)
 
 	err := suite.store.DeleteCard(context.Background(), userID, cardID)
 	assert.NoError(suite.T(), err)
+
+	// The record is already in the trash or there is no such record
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	UPDATE "cards" SET "deleted_at" = now()
+	WHERE "user" = $1 AND "id" = $2 AND "deleted_at" IS NULL
+	`, userID, cardID).Return([]byte("UPDATE 0"), nil)
+
+	err = suite.store.DeleteCard(context.Background(), userID, cardID)
+	assert.ErrorIs(suite.T(), err, pgx.ErrNoRows)
 }
 
 func (suite *PgStorageSuite) TestAddLogin() {
@@ -267,19 +461,23 @@ func (suite *PgStorageSuite) TestAddLogin() {
 		ID:       "testID",
 		Login:    "login",
 		Password: "password",
-		MetaInfo: "metainfo",
+		Note:     "note",
 	}
 
-	columns := []string{"id"}
+	columns := []string{"id", "meta", "location"}
 	pgxRows := pgxpoolmock.NewRows(columns).
-		AddRow(int64(1)).ToPgxRows()
+		AddRow(int64(1), nil, nil).ToPgxRows()
 	pgxRows.Next()
 
 	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
-	INSERT INTO "logins"("id", "user", "login", "password", "metainfo")
-	VALUES ($1, $2, $3, $4, $5)
-	returning "@logins"
-	`, login.ID, userID, login.Login, login.Password, login.MetaInfo).Return(pgxRows)
+	WITH "record" AS (
+		INSERT INTO "logins"("id", "user", "login", "password", "totp", "note")
+		VALUES ($1, $2, $3, $4, $5, $6)
+		returning "@logins"
+	)
+	SELECT "@logins", "set_record_meta"($2, 'login', $1, $7, $8, $9), "set_record_location"($2, 'login', $1, $10, $11)
+	FROM "record"
+	`, login.ID, userID, login.Login, login.Password, login.TOTP, login.Note, []string{}, []string{}, []string{}, login.Vault, login.Folder).Return(pgxRows)
 
 	err := suite.store.AddLogin(context.Background(), userID, login)
 	assert.NoError(suite.T(), err)
@@ -287,16 +485,20 @@ func (suite *PgStorageSuite) TestAddLogin() {
 	// Test for SQL errors
 	targetErr := errors.New("some error")
 	pgxRows = pgxpoolmock.NewRows(columns).
-		AddRow(int64(1)).
+		AddRow(int64(1), nil, nil).
 		RowError(0, targetErr).
 		ToPgxRows()
 	pgxRows.Next()
 
 	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
-	INSERT INTO "logins"("id", "user", "login", "password", "metainfo")
-	VALUES ($1, $2, $3, $4, $5)
-	returning "@logins"
-	`, login.ID, userID, login.Login, login.Password, login.MetaInfo).Return(pgxRows)
+	WITH "record" AS (
+		INSERT INTO "logins"("id", "user", "login", "password", "totp", "note")
+		VALUES ($1, $2, $3, $4, $5, $6)
+		returning "@logins"
+	)
+	SELECT "@logins", "set_record_meta"($2, 'login', $1, $7, $8, $9), "set_record_location"($2, 'login', $1, $10, $11)
+	FROM "record"
+	`, login.ID, userID, login.Login, login.Password, login.TOTP, login.Note, []string{}, []string{}, []string{}, login.Vault, login.Folder).Return(pgxRows)
 
 	err = suite.store.AddLogin(context.Background(), userID, login)
 	assert.Error(suite.T(), err, targetErr)
@@ -309,18 +511,22 @@ func (suite *PgStorageSuite) TestLogin() {
 		ID:       "testID",
 		Login:    "login",
 		Password: "password",
-		MetaInfo: "metainfo",
+		TOTP:     "otpauth://totp/mail?secret=JBSWY3DPEHPK3PXP",
+		Note:     "note",
+		Version:  3,
 	}
 
-	columns := []string{"id", "user", "login", "password", "metainfo"}
+	columns := []string{"id", "user", "login", "password", "totp", "note", "version", "tags", "fields", "vault", "folder"}
 	pgxRows := pgxpoolmock.NewRows(columns).
-		AddRow(login.ID, userID, login.Login, login.Password, login.MetaInfo).ToPgxRows()
+		AddRow(login.ID, userID, login.Login, login.Password, login.TOTP, login.Note, login.Version, login.Tags, login.Fields, login.Vault, login.Folder).ToPgxRows()
 	pgxRows.Next()
 
 	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
-	SELECT "id", "user", "login", "password", "metainfo"
+	SELECT "id", "user", "login", "password", "totp", "note", "version",
+		"record_tags_of"("user", 'login', "id"), "record_fields_of"("user", 'login', "id"),
+		"record_vault_of"("user", 'login', "id"), "record_folder_of"("user", 'login', "id")
 	FROM "logins"
-	WHERE "user" = $1 and "id" = $2
+	WHERE "user" = $1 and "id" = $2 AND "deleted_at" IS NULL
 	LIMIT 1
 	`, userID, login.ID).Return(pgxRows)
 
@@ -331,15 +537,17 @@ func (suite *PgStorageSuite) TestLogin() {
 	// Test for SQL errors
 	targetErr := errors.New("some error")
 	pgxRows = pgxpoolmock.NewRows(columns).
-		AddRow(login.ID, userID, login.Login, login.Password, login.MetaInfo).
+		AddRow(login.ID, userID, login.Login, login.Password, login.TOTP, login.Note, login.Version, login.Tags, login.Fields, login.Vault, login.Folder).
 		RowError(0, targetErr).
 		ToPgxRows()
 	pgxRows.Next()
 
 	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
-	SELECT "id", "user", "login", "password", "metainfo"
+	SELECT "id", "user", "login", "password", "totp", "note", "version",
+		"record_tags_of"("user", 'login', "id"), "record_fields_of"("user", 'login', "id"),
+		"record_vault_of"("user", 'login', "id"), "record_folder_of"("user", 'login', "id")
 	FROM "logins"
-	WHERE "user" = $1 and "id" = $2
+	WHERE "user" = $1 and "id" = $2 AND "deleted_at" IS NULL
 	LIMIT 1
 	`, userID, login.ID).Return(pgxRows)
 
@@ -353,9 +561,9 @@ func (suite *PgStorageSuite) TestDeleteLogin() {
 	loginID := "testID"
 
 	suite.mockPool.EXPECT().Exec(gomock.Any(), `
-	DELETE FROM "logins"
-	WHERE "user" = $1 and "id" = $2
-	`, userID, loginID).Return([]byte("DELETE"), nil)
+	UPDATE "logins" SET "deleted_at" = now()
+	WHERE "user" = $1 AND "id" = $2 AND "deleted_at" IS NULL
+	`, userID, loginID).Return([]byte("UPDATE 1"), nil)
 
 	err := suite.store.DeleteLogin(context.Background(), userID, loginID)
 	assert.NoError(suite.T(), err)
@@ -364,22 +572,26 @@ func (suite *PgStorageSuite) TestDeleteLogin() {
 func (suite *PgStorageSuite) TestAddText() {
 	userID := int64(1)
 	text := models.Text{
-		UserID:   userID,
-		ID:       "testID",
-		Content:  "content",
-		MetaInfo: "metainfo",
+		UserID:  userID,
+		ID:      "testID",
+		Content: "content",
+		Note:    "note",
 	}
 
-	columns := []string{"id"}
+	columns := []string{"id", "meta", "location"}
 	pgxRows := pgxpoolmock.NewRows(columns).
-		AddRow(int64(1)).ToPgxRows()
+		AddRow(int64(1), nil, nil).ToPgxRows()
 	pgxRows.Next()
 
 	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
-	INSERT INTO "text_data"("id", "user", "content", "metainfo")
-	VALUES ($1, $2, $3, $4)
-	returning "@text"
-	`, text.ID, userID, text.Content, text.MetaInfo).Return(pgxRows)
+	WITH "record" AS (
+		INSERT INTO "text_data"("id", "user", "content", "note")
+		VALUES ($1, $2, $3, $4)
+		returning "@text"
+	)
+	SELECT "@text", "set_record_meta"($2, 'text', $1, $5, $6, $7), "set_record_location"($2, 'text', $1, $8, $9)
+	FROM "record"
+	`, text.ID, userID, text.Content, text.Note, []string{}, []string{}, []string{}, text.Vault, text.Folder).Return(pgxRows)
 
 	err := suite.store.AddText(context.Background(), userID, text)
 	assert.NoError(suite.T(), err)
@@ -387,16 +599,20 @@ func (suite *PgStorageSuite) TestAddText() {
 	// Test for SQL errors
 	targetErr := errors.New("some error")
 	pgxRows = pgxpoolmock.NewRows(columns).
-		AddRow(int64(1)).
+		AddRow(int64(1), nil, nil).
 		RowError(0, targetErr).
 		ToPgxRows()
 	pgxRows.Next()
 
 	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
-	INSERT INTO "text_data"("id", "user", "content", "metainfo")
-	VALUES ($1, $2, $3, $4)
-	returning "@text"
-	`, text.ID, userID, text.Content, text.MetaInfo).Return(pgxRows)
+	WITH "record" AS (
+		INSERT INTO "text_data"("id", "user", "content", "note")
+		VALUES ($1, $2, $3, $4)
+		returning "@text"
+	)
+	SELECT "@text", "set_record_meta"($2, 'text', $1, $5, $6, $7), "set_record_location"($2, 'text', $1, $8, $9)
+	FROM "record"
+	`, text.ID, userID, text.Content, text.Note, []string{}, []string{}, []string{}, text.Vault, text.Folder).Return(pgxRows)
 
 	err = suite.store.AddText(context.Background(), userID, text)
 	assert.Error(suite.T(), err, targetErr)
@@ -405,21 +621,24 @@ func (suite *PgStorageSuite) TestAddText() {
 func (suite *PgStorageSuite) TestText() {
 	userID := int64(1)
 	text := models.Text{
-		UserID:   userID,
-		ID:       "testID",
-		Content:  "content",
-		MetaInfo: "metainfo",
+		UserID:  userID,
+		ID:      "testID",
+		Content: "content",
+		Note:    "note",
+		Version: 3,
 	}
 
-	columns := []string{"id", "user", "content", "metainfo"}
+	columns := []string{"id", "user", "content", "note", "version", "tags", "fields", "vault", "folder"}
 	pgxRows := pgxpoolmock.NewRows(columns).
-		AddRow(text.ID, userID, text.Content, text.MetaInfo).ToPgxRows()
+		AddRow(text.ID, userID, text.Content, text.Note, text.Version, text.Tags, text.Fields, text.Vault, text.Folder).ToPgxRows()
 	pgxRows.Next()
 
 	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
-	SELECT "id", "user", "content", "metainfo"
+	SELECT "id", "user", "content", "note", "version",
+		"record_tags_of"("user", 'text', "id"), "record_fields_of"("user", 'text', "id"),
+		"record_vault_of"("user", 'text', "id"), "record_folder_of"("user", 'text', "id")
 	FROM "text_data"
-	WHERE "user" = $1 and "id" = $2
+	WHERE "user" = $1 and "id" = $2 AND "deleted_at" IS NULL
 	LIMIT 1
 	`, userID, text.ID).Return(pgxRows)
 
@@ -430,15 +649,17 @@ func (suite *PgStorageSuite) TestText() {
 	// Test for SQL errors
 	targetErr := errors.New("some error")
 	pgxRows = pgxpoolmock.NewRows(columns).
-		AddRow(text.ID, userID, text.Content, text.MetaInfo).
+		AddRow(text.ID, userID, text.Content, text.Note, text.Version, text.Tags, text.Fields, text.Vault, text.Folder).
 		RowError(0, targetErr).
 		ToPgxRows()
 	pgxRows.Next()
 
 	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
-	SELECT "id", "user", "content", "metainfo"
+	SELECT "id", "user", "content", "note", "version",
+		"record_tags_of"("user", 'text', "id"), "record_fields_of"("user", 'text', "id"),
+		"record_vault_of"("user", 'text', "id"), "record_folder_of"("user", 'text', "id")
 	FROM "text_data"
-	WHERE "user" = $1 and "id" = $2
+	WHERE "user" = $1 and "id" = $2 AND "deleted_at" IS NULL
 	LIMIT 1
 	`, userID, text.ID).Return(pgxRows)
 
@@ -452,9 +673,9 @@ func (suite *PgStorageSuite) TestDeleteText() {
 	textID := "testID"
 
 	suite.mockPool.EXPECT().Exec(gomock.Any(), `
-	DELETE FROM "text_data"
-	WHERE "user" = $1 and "id" = $2
-	`, userID, textID).Return([]byte("DELETE"), nil)
+	UPDATE "text_data" SET "deleted_at" = now()
+	WHERE "user" = $1 AND "id" = $2 AND "deleted_at" IS NULL
+	`, userID, textID).Return([]byte("UPDATE 1"), nil)
 
 	err := suite.store.DeleteText(context.Background(), userID, textID)
 	assert.NoError(suite.T(), err)
@@ -462,23 +683,33 @@ func (suite *PgStorageSuite) TestDeleteText() {
 
 func (suite *PgStorageSuite) TestAddBinary() {
 	userID := int64(1)
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
 	bin := models.Binary{
 		UserID:   userID,
 		ID:       "testID",
 		Data:     []byte("data"),
-		MetaInfo: "metainfo",
+		FileName: "photo.png",
+		FileSize: 4,
+		MimeType: "image/png",
+		ModTime:  &modTime,
+		Note:     "note",
 	}
 
-	columns := []string{"id"}
+	columns := []string{"id", "meta", "location"}
 	pgxRows := pgxpoolmock.NewRows(columns).
-		AddRow(int64(1)).ToPgxRows()
+		AddRow(int64(1), nil, nil).ToPgxRows()
 	pgxRows.Next()
 
 	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
-	INSERT INTO "bin_data"("id", "user", "content", "metainfo")
-	VALUES ($1, $2, $3, $4)
-	returning "@bin"
-	`, bin.ID, userID, bin.Data, bin.MetaInfo).Return(pgxRows)
+	WITH "record" AS (
+		INSERT INTO "bin_data"("id", "user", "content", "note", "file_name", "file_size", "mime_type", "mod_time")
+		VALUES ($1, $2, $3, $4, $10, $11, $12, $13)
+		returning "@bin"
+	)
+	SELECT "@bin", "set_record_meta"($2, 'bin', $1, $5, $6, $7), "set_record_location"($2, 'bin', $1, $8, $9)
+	FROM "record"
+	`, bin.ID, userID, bin.Data, bin.Note, []string{}, []string{}, []string{}, bin.Vault, bin.Folder,
+		bin.FileName, bin.FileSize, bin.MimeType, bin.ModTime).Return(pgxRows)
 
 	err := suite.store.AddBinary(context.Background(), userID, bin)
 	assert.NoError(suite.T(), err)
@@ -486,16 +717,21 @@ func (suite *PgStorageSuite) TestAddBinary() {
 	// Test for SQL errors
 	targetErr := errors.New("some error")
 	pgxRows = pgxpoolmock.NewRows(columns).
-		AddRow(int64(1)).
+		AddRow(int64(1), nil, nil).
 		RowError(0, targetErr).
 		ToPgxRows()
 	pgxRows.Next()
 
 	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
-	INSERT INTO "bin_data"("id", "user", "content", "metainfo")
-	VALUES ($1, $2, $3, $4)
-	returning "@bin"
-	`, bin.ID, userID, bin.Data, bin.MetaInfo).Return(pgxRows)
+	WITH "record" AS (
+		INSERT INTO "bin_data"("id", "user", "content", "note", "file_name", "file_size", "mime_type", "mod_time")
+		VALUES ($1, $2, $3, $4, $10, $11, $12, $13)
+		returning "@bin"
+	)
+	SELECT "@bin", "set_record_meta"($2, 'bin', $1, $5, $6, $7), "set_record_location"($2, 'bin', $1, $8, $9)
+	FROM "record"
+	`, bin.ID, userID, bin.Data, bin.Note, []string{}, []string{}, []string{}, bin.Vault, bin.Folder,
+		bin.FileName, bin.FileSize, bin.MimeType, bin.ModTime).Return(pgxRows)
 
 	err = suite.store.AddBinary(context.Background(), userID, bin)
 	assert.Error(suite.T(), err, targetErr)
@@ -503,22 +739,33 @@ func (suite *PgStorageSuite) TestAddBinary() {
 
 func (suite *PgStorageSuite) TestBinary() {
 	userID := int64(1)
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
 	bin := models.Binary{
 		UserID:   userID,
 		ID:       "testID",
 		Data:     []byte("data"),
-		MetaInfo: "metainfo",
+		FileName: "photo.png",
+		FileSize: 4,
+		MimeType: "image/png",
+		ModTime:  &modTime,
+		Note:     "note",
+		Version:  3,
 	}
 
-	columns := []string{"id", "user", "content", "metainfo"}
+	columns := []string{"id", "user", "content", "blob", "size", "note", "version", "tags", "fields", "vault", "folder",
+		"file_name", "file_size", "mime_type", "mod_time"}
 	pgxRows := pgxpoolmock.NewRows(columns).
-		AddRow(bin.ID, userID, bin.Data, bin.MetaInfo).ToPgxRows()
+		AddRow(bin.ID, userID, bin.Data, bin.ContentID, bin.Size, bin.Note, bin.Version, bin.Tags, bin.Fields, bin.Vault,
+			bin.Folder, bin.FileName, bin.FileSize, bin.MimeType, bin.ModTime).ToPgxRows()
 	pgxRows.Next()
 
 	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
-	SELECT "id", "user", "content", "metainfo"
+	SELECT "id", "user", "content", COALESCE("blob", ''), "size", "note", "version",
+		"record_tags_of"("user", 'bin', "id"), "record_fields_of"("user", 'bin', "id"),
+		"record_vault_of"("user", 'bin', "id"), "record_folder_of"("user", 'bin', "id"),
+		"file_name", "file_size", "mime_type", "mod_time"
 	FROM "bin_data"
-	WHERE "user" = $1 and "id" = $2
+	WHERE "user" = $1 and "id" = $2 AND "deleted_at" IS NULL
 	LIMIT 1
 	`, userID, bin.ID).Return(pgxRows)
 
@@ -529,15 +776,19 @@ func (suite *PgStorageSuite) TestBinary() {
 	// Test for SQL errors
 	targetErr := errors.New("some error")
 	pgxRows = pgxpoolmock.NewRows(columns).
-		AddRow(bin.ID, userID, bin.Data, bin.MetaInfo).
+		AddRow(bin.ID, userID, bin.Data, bin.ContentID, bin.Size, bin.Note, bin.Version, bin.Tags, bin.Fields, bin.Vault,
+			bin.Folder, bin.FileName, bin.FileSize, bin.MimeType, bin.ModTime).
 		RowError(0, targetErr).
 		ToPgxRows()
 	pgxRows.Next()
 
 	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
-	SELECT "id", "user", "content", "metainfo"
+	SELECT "id", "user", "content", COALESCE("blob", ''), "size", "note", "version",
+		"record_tags_of"("user", 'bin', "id"), "record_fields_of"("user", 'bin', "id"),
+		"record_vault_of"("user", 'bin', "id"), "record_folder_of"("user", 'bin', "id"),
+		"file_name", "file_size", "mime_type", "mod_time"
 	FROM "bin_data"
-	WHERE "user" = $1 and "id" = $2
+	WHERE "user" = $1 and "id" = $2 AND "deleted_at" IS NULL
 	LIMIT 1
 	`, userID, bin.ID).Return(pgxRows)
 
@@ -551,10 +802,1068 @@ func (suite *PgStorageSuite) TestDeleteBinary() {
 	binID := "testID"
 
 	suite.mockPool.EXPECT().Exec(gomock.Any(), `
-	DELETE FROM "bin_data"
-	WHERE "user" = $1 and "id" = $2
-	`, userID, binID).Return([]byte("DELETE"), nil)
+	UPDATE "bin_data" SET "deleted_at" = now()
+	WHERE "user" = $1 AND "id" = $2 AND "deleted_at" IS NULL
+	`, userID, binID).Return([]byte("UPDATE 1"), nil)
 
 	err := suite.store.DeleteBinary(context.Background(), userID, binID)
 	assert.NoError(suite.T(), err)
 }
+
+func (suite *PgStorageSuite) TestAddSSHKey() {
+	key := models.SSHKey{
+		ID:          "deploy",
+		PrivateKey:  "private",
+		PublicKey:   "ssh-ed25519 AAAA",
+		Comment:     "ci@example",
+		Fingerprint: "SHA256:abc",
+		Note:        "note",
+		Vault:       2,
+	}
+
+	pgxRows := pgxpoolmock.NewRows([]string{"id", "meta", "location"}).AddRow(int64(1), nil, nil).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	WITH "record" AS (
+		INSERT INTO "ssh_keys"("id", "user", "private_key", "public_key", "comment", "fingerprint", "note")
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		returning "@ssh_keys"
+	)
+	SELECT "@ssh_keys", "set_record_meta"($2, 'ssh', $1, $8, $9, $10), "set_record_location"($2, 'ssh', $1, $11, $12)
+	FROM "record"
+	`, key.ID, int64(1), key.PrivateKey, key.PublicKey, key.Comment, key.Fingerprint, key.Note,
+		[]string{}, []string{}, []string{}, key.Vault, key.Folder).Return(pgxRows)
+
+	err := suite.store.AddSSHKey(context.Background(), 1, key)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *PgStorageSuite) TestSSHKey() {
+	key := models.SSHKey{
+		UserID:      1,
+		ID:          "deploy",
+		PrivateKey:  "private",
+		PublicKey:   "ssh-ed25519 AAAA",
+		Comment:     "ci@example",
+		Fingerprint: "SHA256:abc",
+		Note:        "note",
+		Version:     2,
+	}
+
+	columns := []string{"id", "user", "private_key", "public_key", "comment", "fingerprint", "note", "version",
+		"tags", "fields", "vault", "folder"}
+	pgxRows := pgxpoolmock.NewRows(columns).
+		AddRow(key.ID, key.UserID, key.PrivateKey, key.PublicKey, key.Comment, key.Fingerprint, key.Note, key.Version,
+			key.Tags, key.Fields, key.Vault, key.Folder).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	SELECT "id", "user", "private_key", "public_key", "comment", "fingerprint", "note", "version",
+		"record_tags_of"("user", 'ssh', "id"), "record_fields_of"("user", 'ssh', "id"),
+		"record_vault_of"("user", 'ssh', "id"), "record_folder_of"("user", 'ssh', "id")
+	FROM "ssh_keys"
+	WHERE "user" = $1 and "id" = $2 AND "deleted_at" IS NULL
+	LIMIT 1
+	`, int64(1), key.ID).Return(pgxRows)
+
+	target, err := suite.store.SSHKey(context.Background(), 1, key.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), key, *target)
+}
+
+func (suite *PgStorageSuite) TestDeleteSSHKey() {
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	UPDATE "ssh_keys" SET "deleted_at" = now()
+	WHERE "user" = $1 AND "id" = $2 AND "deleted_at" IS NULL
+	`, int64(1), "deploy").Return([]byte("UPDATE 0"), nil)
+
+	err := suite.store.DeleteSSHKey(context.Background(), 1, "deploy")
+	assert.ErrorIs(suite.T(), err, pgx.ErrNoRows)
+}
+
+func (suite *PgStorageSuite) TestRecordType() {
+	pgxRows := pgxpoolmock.NewRows([]string{"name", "fields"}).
+		AddRow("Wi-Fi", `[{"name": "ssid", "type": "text", "required": true}, {"name": "password", "type": "text", "secret": true}]`).
+		ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	SELECT "name", "fields"::text FROM "record_types"
+	WHERE "user" = $1 AND "name" = $2
+	`, int64(1), "Wi-Fi").Return(pgxRows)
+
+	recordType, err := suite.store.RecordType(context.Background(), 1, "Wi-Fi")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), &models.RecordType{UserID: 1, Name: "Wi-Fi", Fields: []models.TypeField{
+		{Name: "ssid", Type: models.FieldText, Required: true},
+		{Name: "password", Type: models.FieldText, Secret: true},
+	}}, recordType)
+}
+
+func (suite *PgStorageSuite) TestAddRecordType() {
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	INSERT INTO "record_types"("user", "name", "fields") VALUES ($1, $2, $3::jsonb)
+	`, int64(1), "Wi-Fi", `[{"name":"ssid","type":"text"}]`).Return(nil, &pgconn.PgError{Code: "23505"})
+
+	err := suite.store.AddRecordType(context.Background(), 1, models.RecordType{Name: "Wi-Fi",
+		Fields: []models.TypeField{{Name: "ssid", Type: models.FieldText}}})
+	assert.ErrorIs(suite.T(), err, ErrNameTaken)
+}
+
+func (suite *PgStorageSuite) TestUpdateRecordType() {
+	query := `
+	WITH "type" AS (
+		SELECT EXISTS (
+			SELECT 1 FROM "custom_records" WHERE "user" = $1 AND "type" = $2
+		) AND EXISTS (
+			SELECT 1 FROM jsonb_array_elements("fields") "old"
+			WHERE NOT EXISTS (
+				SELECT 1 FROM jsonb_array_elements($3::jsonb) "new"
+				WHERE "new"->>'name' = "old"->>'name'
+					AND coalesce(("new"->>'secret')::boolean, false) = coalesce(("old"->>'secret')::boolean, false)
+			)
+		) AS "locked"
+		FROM "record_types"
+		WHERE "user" = $1 AND "name" = $2
+		FOR UPDATE
+	), "updated" AS (
+		UPDATE "record_types" SET "fields" = $3::jsonb
+		WHERE "user" = $1 AND "name" = $2 AND NOT (SELECT "locked" FROM "type")
+	)
+	SELECT "locked" FROM "type"
+	`
+	recordType := models.RecordType{Name: "Wi-Fi", Fields: []models.TypeField{{Name: "ssid", Type: models.FieldText, Secret: true}}}
+	fields := `[{"name":"ssid","type":"text","secret":true}]`
+
+	for _, locked := range []bool{false, true} {
+		pgxRows := pgxpoolmock.NewRows([]string{"locked"}).AddRow(locked).ToPgxRows()
+		pgxRows.Next()
+		suite.mockPool.EXPECT().QueryRow(gomock.Any(), query, int64(1), "Wi-Fi", fields).Return(pgxRows)
+	}
+	assert.NoError(suite.T(), suite.store.UpdateRecordType(context.Background(), 1, recordType))
+	// The values of the records would stay in the column of the old secrecy
+	assert.ErrorIs(suite.T(), suite.store.UpdateRecordType(context.Background(), 1, recordType), ErrRecordTypeInUse)
+
+	pgxRows := pgxpoolmock.NewRows([]string{"locked"}).AddRow(nil).RowError(0, pgx.ErrNoRows).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), query, int64(1), "Wi-Fi", fields).Return(pgxRows)
+	assert.ErrorIs(suite.T(), suite.store.UpdateRecordType(context.Background(), 1, recordType), pgx.ErrNoRows)
+}
+
+func (suite *PgStorageSuite) TestDeleteRecordType() {
+	// The records of the type are kept, even in the trash
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	DELETE FROM "record_types" WHERE "user" = $1 AND "name" = $2
+	`, int64(1), "Wi-Fi").Return(nil, &pgconn.PgError{Code: "23503"})
+
+	err := suite.store.DeleteRecordType(context.Background(), 1, "Wi-Fi")
+	assert.ErrorIs(suite.T(), err, ErrRecordTypeInUse)
+}
+
+func (suite *PgStorageSuite) TestAddCustomRecord() {
+	record := models.CustomRecord{
+		ID:      "home",
+		Type:    "Wi-Fi",
+		Values:  map[string]string{"ssid": "home"},
+		Secrets: map[string]string{"password": "secret"},
+		Note:    "note",
+	}
+
+	pgxRows := pgxpoolmock.NewRows([]string{"id", "meta", "location"}).AddRow(int64(1), nil, nil).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	WITH "record" AS (
+		INSERT INTO "custom_records"("id", "user", "type", "values", "secrets", "note")
+		VALUES ($1, $2, $3, $4, $5, $6)
+		returning "@custom_records"
+	)
+	SELECT "@custom_records", "set_record_meta"($2, 'custom', $1, $7, $8, $9),
+		"set_record_location"($2, 'custom', $1, $10, $11)
+	FROM "record"
+	`, record.ID, int64(1), record.Type, `{"ssid":"home"}`, `{"password":"secret"}`, record.Note,
+		[]string{}, []string{}, []string{}, record.Vault, record.Folder).Return(pgxRows)
+
+	err := suite.store.AddCustomRecord(context.Background(), 1, record)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *PgStorageSuite) TestCustomRecord() {
+	record := models.CustomRecord{
+		UserID:  1,
+		ID:      "home",
+		Type:    "Wi-Fi",
+		Values:  map[string]string{"ssid": "home"},
+		Secrets: map[string]string{"password": "secret"},
+		Version: 2,
+	}
+
+	columns := []string{"id", "user", "type", "values", "secrets", "note", "version",
+		"tags", "fields", "vault", "folder"}
+	pgxRows := pgxpoolmock.NewRows(columns).
+		AddRow(record.ID, record.UserID, record.Type, `{"ssid": "home"}`, `{"password": "secret"}`, record.Note,
+			record.Version, record.Tags, record.Fields, record.Vault, record.Folder).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	SELECT "id", "user", "type", "values", "secrets", "note", "version",
+		"record_tags_of"("user", 'custom', "id"), "record_fields_of"("user", 'custom', "id"),
+		"record_vault_of"("user", 'custom', "id"), "record_folder_of"("user", 'custom', "id")
+	FROM "custom_records"
+	WHERE "user" = $1 and "id" = $2 AND "deleted_at" IS NULL
+	LIMIT 1
+	`, int64(1), record.ID).Return(pgxRows)
+
+	target, err := suite.store.CustomRecord(context.Background(), 1, record.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), record, *target)
+}
+
+func (suite *PgStorageSuite) TestUserKeys() {
+	userID := int64(1)
+	key := UserKey{KeyID: 1, UserID: userID, Version: 2, KEKVersion: 1, Wrapped: []byte("wrapped")}
+
+	columns := []string{"@user_keys", "user", "version", "kek_version", "wrapped_key"}
+	pgxRows := pgxpoolmock.NewRows(columns).
+		AddRow(key.KeyID, key.UserID, key.Version, key.KEKVersion, key.Wrapped).ToPgxRows()
+
+	suite.mockPool.EXPECT().Query(gomock.Any(), `
+	SELECT "@user_keys", "user", "version", "kek_version", "wrapped_key"
+	FROM "user_keys"
+	WHERE "user" = $1
+	ORDER BY "version"
+	`, userID).Return(pgxRows, nil)
+
+	keys, err := suite.store.(*PgStorage).UserKeys(context.Background(), userID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []UserKey{key}, keys)
+}
+
+func (suite *PgStorageSuite) TestAddUserKey() {
+	key := UserKey{UserID: 1, Version: 1, KEKVersion: 1, Wrapped: []byte("wrapped")}
+
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	INSERT INTO "user_keys"("user", "version", "kek_version", "wrapped_key")
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT ("user", "version") DO NOTHING
+	`, key.UserID, key.Version, key.KEKVersion, key.Wrapped).Return([]byte("INSERT 0 1"), nil)
+
+	ok, err := suite.store.(*PgStorage).AddUserKey(context.Background(), key)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), ok)
+
+	// The key of this version is already created by another request
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	INSERT INTO "user_keys"("user", "version", "kek_version", "wrapped_key")
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT ("user", "version") DO NOTHING
+	`, key.UserID, key.Version, key.KEKVersion, key.Wrapped).Return([]byte("INSERT 0 0"), nil)
+
+	ok, err = suite.store.(*PgStorage).AddUserKey(context.Background(), key)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), ok)
+}
+
+func (suite *PgStorageSuite) TestReplaceSealed() {
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	UPDATE "cards" SET "cvv" = $3
+	WHERE "@cards" = $1 AND "cvv" = $2
+	`, int64(1), "old", "new").Return([]byte("UPDATE 1"), nil)
+
+	ok, err := suite.store.(*PgStorage).ReplaceSealed(context.Background(), models.KindCard, 1, "cvv", "old", "new")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), ok)
+
+	// Only the encrypted columns can be replaced
+	_, err = suite.store.(*PgStorage).ReplaceSealed(context.Background(), models.KindCard, 1, "note", "old", "new")
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PgStorageSuite) TestReplaceTOTPSecret() {
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	UPDATE "users" SET "totp_secret" = $3
+	WHERE "@users" = $1 AND "totp_secret" = $2
+	`, int64(1), "old", "new").Return([]byte("UPDATE 0"), nil)
+
+	// The secret set up again in the meantime is not replaced
+	ok, err := suite.store.(*PgStorage).ReplaceTOTPSecret(context.Background(), 1, "old", "new")
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), ok)
+}
+
+func (suite *PgStorageSuite) TestSessionUser() {
+	user := models.User{UserID: 1, Login: "login"}
+
+	columns := []string{"@users", "login"}
+	pgxRows := pgxpoolmock.NewRows(columns).AddRow(user.UserID, user.Login).ToPgxRows()
+	pgxRows.Next()
+
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	SELECT u."@users", u."login"
+	FROM "sessions" s JOIN "users" u ON u."@users" = s."user"
+	WHERE s."id" = $1 AND s."revoked_at" IS NULL AND s."expires_at" > now()
+	`, "session").Return(pgxRows)
+
+	result, err := suite.store.SessionUser(context.Background(), "session")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), user, *result)
+}
+
+func (suite *PgStorageSuite) TestRevokeSession() {
+	userID := int64(1)
+
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	UPDATE "sessions" SET "revoked_at" = now()
+	WHERE "user" = $1 AND "id" = $2 AND "revoked_at" IS NULL
+	`, userID, "session").Return([]byte("UPDATE 1"), nil)
+
+	err := suite.store.RevokeSession(context.Background(), userID, "session")
+	assert.NoError(suite.T(), err)
+
+	// The session of another user or already revoked one
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	UPDATE "sessions" SET "revoked_at" = now()
+	WHERE "user" = $1 AND "id" = $2 AND "revoked_at" IS NULL
+	`, userID, "session").Return([]byte("UPDATE 0"), nil)
+
+	err = suite.store.RevokeSession(context.Background(), userID, "session")
+	assert.ErrorIs(suite.T(), err, pgx.ErrNoRows)
+}
+
+func (suite *PgStorageSuite) TestSetTOTPSecret() {
+	userID := int64(1)
+
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	UPDATE "users" SET "totp_secret" = $2, "totp_last_counter" = 0
+	WHERE "@users" = $1 AND NOT "totp_enabled"
+	`, userID, "secret").Return([]byte("UPDATE 1"), nil)
+
+	err := suite.store.SetTOTPSecret(context.Background(), userID, "secret")
+	assert.NoError(suite.T(), err)
+
+	// The enabled second factor can not be replaced without disabling
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	UPDATE "users" SET "totp_secret" = $2, "totp_last_counter" = 0
+	WHERE "@users" = $1 AND NOT "totp_enabled"
+	`, userID, "secret").Return([]byte("UPDATE 0"), nil)
+
+	err = suite.store.SetTOTPSecret(context.Background(), userID, "secret")
+	assert.ErrorIs(suite.T(), err, ErrTOTPEnabled)
+}
+
+func (suite *PgStorageSuite) TestUseTOTPCounter() {
+	userID := int64(1)
+
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	UPDATE "users" SET "totp_last_counter" = $2
+	WHERE "@users" = $1 AND "totp_last_counter" < $2
+	`, userID, int64(100)).Return([]byte("UPDATE 1"), nil)
+
+	used, err := suite.store.UseTOTPCounter(context.Background(), userID, 100)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), used)
+
+	// The same code again
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	UPDATE "users" SET "totp_last_counter" = $2
+	WHERE "@users" = $1 AND "totp_last_counter" < $2
+	`, userID, int64(100)).Return([]byte("UPDATE 0"), nil)
+
+	used, err = suite.store.UseTOTPCounter(context.Background(), userID, 100)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), used)
+}
+
+func (suite *PgStorageSuite) TestUseRecoveryCode() {
+	userID := int64(1)
+
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	UPDATE "recovery_codes" SET "used_at" = now()
+	WHERE "user" = $1 AND "code_hash" = $2 AND "used_at" IS NULL
+	`, userID, "hash").Return([]byte("UPDATE 1"), nil)
+
+	used, err := suite.store.UseRecoveryCode(context.Background(), userID, "hash")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), used)
+}
+
+func (suite *PgStorageSuite) TestAddLoginFailure() {
+	pgxRows := pgxpoolmock.NewRows([]string{"failures"}).AddRow(3).ToPgxRows()
+	pgxRows.Next()
+
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	INSERT INTO "login_attempts"("key", "failures", "last_failure_at") VALUES ($1, 1, now())
+	ON CONFLICT ("key") DO UPDATE SET
+		"failures" = CASE WHEN "login_attempts"."last_failure_at" < now() - make_interval(secs => $2)
+			THEN 1 ELSE "login_attempts"."failures" + 1 END,
+		"last_failure_at" = now()
+	returning "failures"
+	`, "login:user", float64(3600)).Return(pgxRows)
+
+	failures, err := suite.store.AddLoginFailure(context.Background(), "login:user", time.Hour)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 3, failures)
+}
+
+func (suite *PgStorageSuite) TestLoginRetryAfter() {
+	pgxRows := pgxpoolmock.NewRows([]string{"seconds"}).AddRow(1.5).ToPgxRows()
+	pgxRows.Next()
+
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	SELECT COALESCE(EXTRACT(EPOCH FROM max("locked_until") - now()), 0)::float8
+	FROM "login_attempts"
+	WHERE "key" = ANY($1) AND "locked_until" > now()
+	`, []string{"login:user", "ip:10.0.0.1"}).Return(pgxRows)
+
+	retryAfter, err := suite.store.LoginRetryAfter(context.Background(), []string{"login:user", "ip:10.0.0.1"})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1500*time.Millisecond, retryAfter)
+}
+
+func (suite *PgStorageSuite) TestAddAuditEvent() {
+	event := models.AuditEvent{Action: models.AuditRead, Kind: models.KindCard, RecordID: "card", Transport: "http", IP: "10.0.0.1"}
+
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	INSERT INTO "audit_events"("user", "actor", "action", "kind", "record", "transport", "ip")
+	VALUES (NULLIF($1::bigint, 0), COALESCE(NULLIF($2, ''), (SELECT "login" FROM "users" WHERE "@users" = $1), ''), $3, $4, $5, $6, $7)
+	`, int64(1), "", "read", "card", "card", "http", "10.0.0.1").Return([]byte("INSERT 0 1"), nil)
+
+	err := suite.store.AddAuditEvent(context.Background(), 1, event)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *PgStorageSuite) TestAuditEvents() {
+	createdAt := time.Unix(1700000000, 0).UTC()
+	columns := []string{"@audit_events", "actor", "action", "kind", "record", "transport", "ip", "created_at"}
+	pgxRows := pgxpoolmock.NewRows(columns).
+		AddRow(int64(12), "login", "read", "card", "card", "http", "10.0.0.1", createdAt).
+		AddRow(int64(10), "login", "signin", "", "", "grpc", "10.0.0.1", createdAt).
+		ToPgxRows()
+
+	suite.mockPool.EXPECT().Query(gomock.Any(), `
+	SELECT "@audit_events", "actor", "action", "kind", "record", "transport", "ip", "created_at"
+	FROM "audit_events"
+	WHERE "user" = $1 AND ($2::bigint = 0 OR "@audit_events" < $2)
+	ORDER BY "@audit_events" DESC
+	LIMIT $3
+	`, int64(1), int64(20), 2).Return(pgxRows, nil)
+
+	events, err := suite.store.AuditEvents(context.Background(), 1, 20, 2)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []models.AuditEvent{
+		{ID: 12, Actor: "login", Action: "read", Kind: models.KindCard, RecordID: "card", Transport: "http", IP: "10.0.0.1", CreatedAt: createdAt},
+		{ID: 10, Actor: "login", Action: "signin", Transport: "grpc", IP: "10.0.0.1", CreatedAt: createdAt},
+	}, events)
+}
+
+func (suite *PgStorageSuite) TestRecords() {
+	columns := []string{"id", "note", "cursor", "tags", "fields"}
+	noTags, noFields := []string{}, map[string]string{}
+	pgxRows := pgxpoolmock.NewRows(columns).
+		AddRow("visa", "salary card", "7", []string{"work"}, map[string]string{"bank": "test"}).
+		AddRow("master", "", "9", noTags, noFields).
+		ToPgxRows()
+
+	suite.mockPool.EXPECT().Query(gomock.Any(), `
+	SELECT "id", COALESCE("note", ''), "@cards"::text, "record_tags_of"("user", 'card', "id"), "record_fields_of"("user", 'card', "id")
+	FROM "cards"
+	WHERE "user" = $1 AND "deleted_at" IS NULL AND ($2::bigint = 0 OR "@cards" > $2)
+	AND ($4 = '' OR EXISTS (
+		SELECT 1 FROM "record_tags" t
+		WHERE t."user" = $1 AND t."kind" = 'card' AND t."id" = "cards"."id" AND t."tag" = $4
+	))
+	AND ($5 = '' OR EXISTS (
+		SELECT 1 FROM "record_fields" f
+		WHERE f."user" = $1 AND f."kind" = 'card' AND f."id" = "cards"."id" AND f."name" = $5
+		AND ($6 = '' OR f."value" = $6)
+	))
+	AND ($7::bigint = 0 OR EXISTS (
+		SELECT 1 FROM "record_locations" l
+		WHERE l."user" = $1 AND l."kind" = 'card' AND l."id" = "cards"."id" AND l."vault" = $7 AND COALESCE(l."folder", 0) = $8
+	))
+	ORDER BY "@cards" ASC
+	LIMIT $3
+	`, int64(1), int64(5), 2, "", "", "", int64(0), int64(0)).Return(pgxRows, nil)
+
+	records, err := suite.store.Records(context.Background(), 1, models.KindCard,
+		models.ListOptions{Sort: models.SortCreated, After: "5", Limit: 2})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []models.RecordInfo{
+		{ID: "visa", Note: "salary card", Cursor: "7", Tags: []string{"work"}, Fields: map[string]string{"bank": "test"}},
+		{ID: "master", Cursor: "9", Tags: noTags, Fields: noFields},
+	}, records)
+
+	// Sorting by id in the descending order, filtered by the tag, the field and the folder
+	pgxRows = pgxpoolmock.NewRows(columns).AddRow("mail", "", "mail", []string{"work"}, map[string]string{"site": "mail.ru"}).ToPgxRows()
+	suite.mockPool.EXPECT().Query(gomock.Any(), `
+	SELECT "id", COALESCE("note", ''), "id", "record_tags_of"("user", 'login', "id"), "record_fields_of"("user", 'login', "id")
+	FROM "logins"
+	WHERE "user" = $1 AND "deleted_at" IS NULL AND ($2 = '' OR "id" < $2)
+	AND ($4 = '' OR EXISTS (
+		SELECT 1 FROM "record_tags" t
+		WHERE t."user" = $1 AND t."kind" = 'login' AND t."id" = "logins"."id" AND t."tag" = $4
+	))
+	AND ($5 = '' OR EXISTS (
+		SELECT 1 FROM "record_fields" f
+		WHERE f."user" = $1 AND f."kind" = 'login' AND f."id" = "logins"."id" AND f."name" = $5
+		AND ($6 = '' OR f."value" = $6)
+	))
+	AND ($7::bigint = 0 OR EXISTS (
+		SELECT 1 FROM "record_locations" l
+		WHERE l."user" = $1 AND l."kind" = 'login' AND l."id" = "logins"."id" AND l."vault" = $7 AND COALESCE(l."folder", 0) = $8
+	))
+	ORDER BY "id" DESC
+	LIMIT $3
+	`, int64(1), "work", 50, "work", "site", "", int64(2), int64(7)).Return(pgxRows, nil)
+
+	records, err = suite.store.Records(context.Background(), 1, models.KindLogin,
+		models.ListOptions{Sort: models.SortID, Desc: true, After: "work", Limit: 50, Tag: "work", Field: "site",
+			Vault: 2, Folder: 7})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []models.RecordInfo{{ID: "mail", Cursor: "mail", Tags: []string{"work"},
+		Fields: map[string]string{"site": "mail.ru"}}}, records)
+
+	_, err = suite.store.Records(context.Background(), 1, models.Kind("unknown"), models.ListOptions{})
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PgStorageSuite) TestUpdateCard() {
+	card := models.Card{ID: "visa", FIO: "fio", Number: "number", Date: "date", CVV: "cvv", Note: "salary", Version: 2,
+		Fields: map[string]string{"pin": "", "bank": "test"}}
+
+	pgxRows := pgxpoolmock.NewRows([]string{"version", "meta"}).AddRow(int64(3), nil).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	WITH "record" AS (
+		UPDATE "cards" SET "fio" = $4, "number" = $5, "date" = $6, "cvv" = $7, "note" = $8, "version" = "version" + 1
+		WHERE "user" = $1 AND "id" = $2 AND "version" = $3 AND "deleted_at" IS NULL
+		RETURNING "version"
+	)
+	SELECT "version", "set_record_meta"($1, 'card', $2, $9, $10, $11) FROM "record"
+	`, int64(1), "visa", int64(2), "fio", "number", "date", "cvv", "salary",
+		[]string{}, []string{"bank", "pin"}, []string{"test", ""}).Return(pgxRows)
+
+	version, err := suite.store.UpdateCard(context.Background(), 1, card)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(3), version)
+}
+
+func (suite *PgStorageSuite) TestUpdateLogin() {
+	login := models.Login{ID: "mail", Login: "login", Password: "password", Version: 2}
+
+	// The version is stale, the record is updated by another client
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	WITH "record" AS (
+		UPDATE "logins" SET "login" = $4, "password" = $5, "totp" = $6, "note" = $7, "version" = "version" + 1
+		WHERE "user" = $1 AND "id" = $2 AND "version" = $3 AND "deleted_at" IS NULL
+		RETURNING "version"
+	)
+	SELECT "version", "set_record_meta"($1, 'login', $2, $8, $9, $10) FROM "record"
+	`, int64(1), "mail", int64(2), "login", "password", "", "", []string{}, []string{}, []string{}).Return(noRows("version", "meta"))
+	pgxRows := pgxpoolmock.NewRows([]string{"version"}).AddRow(int64(5)).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	SELECT "version" FROM "logins"
+	WHERE "user" = $1 AND "id" = $2 AND "deleted_at" IS NULL
+	`, int64(1), "mail").Return(pgxRows)
+
+	_, err := suite.store.UpdateLogin(context.Background(), 1, login)
+	assert.ErrorIs(suite.T(), err, ErrVersionConflict)
+}
+
+func (suite *PgStorageSuite) TestUpdateText() {
+	text := models.Text{ID: "note", Content: "content", Version: 1}
+
+	// There is no such record
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	WITH "record" AS (
+		UPDATE "text_data" SET "content" = $4, "note" = $5, "version" = "version" + 1
+		WHERE "user" = $1 AND "id" = $2 AND "version" = $3 AND "deleted_at" IS NULL
+		RETURNING "version"
+	)
+	SELECT "version", "set_record_meta"($1, 'text', $2, $6, $7, $8) FROM "record"
+	`, int64(1), "note", int64(1), "content", "", []string{}, []string{}, []string{}).Return(noRows("version", "meta"))
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	SELECT "version" FROM "text_data"
+	WHERE "user" = $1 AND "id" = $2 AND "deleted_at" IS NULL
+	`, int64(1), "note").Return(noRows())
+
+	_, err := suite.store.UpdateText(context.Background(), 1, text)
+	assert.ErrorIs(suite.T(), err, pgx.ErrNoRows)
+}
+
+func (suite *PgStorageSuite) TestUpdateBinary() {
+	bin := models.Binary{ID: "photo", Data: []byte("data"), FileName: "photo.png", FileSize: 4, MimeType: "image/png",
+		Note: "png", Version: 4}
+
+	pgxRows := pgxpoolmock.NewRows([]string{"version", "meta"}).AddRow(int64(5), nil).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	WITH "record" AS (
+		UPDATE "bin_data" SET "content" = $4, "note" = $5, "version" = "version" + 1,
+			"blob" = CASE WHEN "blob" = $9 THEN "blob" END, "size" = CASE WHEN "blob" = $9 THEN "size" ELSE 0 END,
+			"file_name" = $10, "file_size" = $11, "mime_type" = $12, "mod_time" = $13
+		WHERE "user" = $1 AND "id" = $2 AND "version" = $3 AND "deleted_at" IS NULL
+		RETURNING "@bin", "version"
+	), "previous" AS (
+		DELETE FROM "bin_uploads"
+		WHERE "@bin" IN (SELECT "@bin" FROM "record") AND "completed_at" IS NOT NULL AND "id" <> $9
+	)
+	SELECT "version", "set_record_meta"($1, 'bin', $2, $6, $7, $8) FROM "record"
+	`, int64(1), "photo", int64(4), []byte("data"), "png", []string{}, []string{}, []string{}, "",
+		"photo.png", int64(4), "image/png", (*time.Time)(nil)).Return(pgxRows)
+
+	version, err := suite.store.UpdateBinary(context.Background(), 1, bin)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(5), version)
+}
+
+func (suite *PgStorageSuite) TestAddChunk() {
+	query := `
+	WITH "upload" AS (
+		UPDATE "bin_uploads" SET "size" = "size" + $4, "chunks" = "chunks" + 1
+		WHERE "user" = $1 AND "id" = $2 AND "completed_at" IS NULL AND "chunks" = $3 AND "size" = "chunks" * $6
+		RETURNING "id", "@bin", "size", "chunks"
+	), "chunk" AS (
+		INSERT INTO "bin_chunks"("upload", "seq", "sha256")
+		SELECT "id", $3, $5 FROM "upload"
+	)
+	SELECT u."id", b."id", u."size", u."chunks"
+	FROM "upload" u JOIN "bin_data" b ON b."@bin" = u."@bin"
+	`
+	chunk := models.BinaryChunk{Upload: "up", Seq: 1, Size: 4, Data: []byte("data")}
+	columns := []string{"id", "bin", "size", "chunks"}
+
+	pgxRows := pgxpoolmock.NewRows(columns).AddRow("up", "photo", int64(models.BinaryChunkSize+4), int64(2)).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), query, int64(1), "up", int64(1), int64(4), blobstore.Checksum([]byte("data")),
+		int64(models.BinaryChunkSize)).Return(pgxRows)
+
+	upload, err := suite.store.AddChunk(context.Background(), 1, chunk)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), models.BinaryUpload{ID: "up", BinaryID: "photo", Offset: models.BinaryChunkSize + 4, Chunks: 2}, *upload)
+
+	// The chunk is not the next one of the upload
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), query, int64(1), "up", int64(1), int64(4), blobstore.Checksum([]byte("data")),
+		int64(models.BinaryChunkSize)).Return(noRows(columns...))
+	pgxRows = pgxpoolmock.NewRows(columns).AddRow("up", "photo", int64(models.BinaryChunkSize*2), int64(2)).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	SELECT u."id", b."id", u."size", u."chunks"
+	FROM "bin_uploads" u JOIN "bin_data" b ON b."@bin" = u."@bin"
+	WHERE u."user" = $1 AND u."id" = $2 AND u."completed_at" IS NULL AND b."deleted_at" IS NULL
+	`, int64(1), "up").Return(pgxRows)
+
+	_, err = suite.store.AddChunk(context.Background(), 1, chunk)
+	assert.ErrorIs(suite.T(), err, ErrChunkOrder)
+}
+
+func (suite *PgStorageSuite) TestBinaryChunk() {
+	query := `
+	SELECT c."sha256"
+	FROM "bin_chunks" c JOIN "bin_uploads" u ON u."id" = c."upload"
+	WHERE u."user" = $1 AND u."id" = $2 AND u."completed_at" IS NOT NULL AND c."seq" = $3
+	`
+	sum, err := blobstore.Write(context.Background(), suite.blobs, "up/0", []byte("data"))
+	suite.Require().NoError(err)
+
+	pgxRows := pgxpoolmock.NewRows([]string{"sha256"}).AddRow(sum).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), query, int64(1), "up", int64(0)).Return(pgxRows)
+
+	data, err := suite.store.BinaryChunk(context.Background(), 1, "up", 0)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []byte("data"), data)
+
+	// The blob replaced behind the storage is not returned
+	suite.Require().NoError(suite.blobs.Put(context.Background(), "up/0/"+sum, []byte("date")))
+	pgxRows = pgxpoolmock.NewRows([]string{"sha256"}).AddRow(sum).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), query, int64(1), "up", int64(0)).Return(pgxRows)
+
+	_, err = suite.store.BinaryChunk(context.Background(), 1, "up", 0)
+	assert.ErrorIs(suite.T(), err, blobstore.ErrChecksum)
+}
+
+func (suite *PgStorageSuite) TestUpdateSSHKey() {
+	key := models.SSHKey{ID: "deploy", PrivateKey: "private", PublicKey: "ssh-ed25519 AAAA", Fingerprint: "SHA256:abc", Version: 1}
+
+	pgxRows := pgxpoolmock.NewRows([]string{"version", "meta"}).AddRow(int64(2), nil).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	WITH "record" AS (
+		UPDATE "ssh_keys" SET "private_key" = $4, "public_key" = $5, "comment" = $6, "fingerprint" = $7, "note" = $8,
+			"version" = "version" + 1
+		WHERE "user" = $1 AND "id" = $2 AND "version" = $3 AND "deleted_at" IS NULL
+		RETURNING "version"
+	)
+	SELECT "version", "set_record_meta"($1, 'ssh', $2, $9, $10, $11) FROM "record"
+	`, int64(1), "deploy", int64(1), "private", "ssh-ed25519 AAAA", "", "SHA256:abc", "",
+		[]string{}, []string{}, []string{}).Return(pgxRows)
+
+	version, err := suite.store.UpdateSSHKey(context.Background(), 1, key)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(2), version)
+}
+
+func (suite *PgStorageSuite) TestRevisions() {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	pgxRows := pgxpoolmock.NewRows([]string{"@record_revisions", "version", "action", "created_at"}).
+		AddRow(int64(7), int64(2), models.RevisionUpdate, created).
+		AddRow(int64(3), int64(1), models.RevisionAdd, created).
+		ToPgxRows()
+	suite.mockPool.EXPECT().Query(gomock.Any(), `
+	SELECT "@record_revisions", "version", "action", "created_at"
+	FROM "record_revisions"
+	WHERE "user" = $1 AND "kind" = $2 AND "id" = $3
+	ORDER BY "@record_revisions" DESC
+	`, int64(1), "card", "visa").Return(pgxRows, nil)
+
+	revisions, err := suite.store.Revisions(context.Background(), 1, models.KindCard, "visa")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []models.Revision{
+		{ID: 7, Kind: models.KindCard, RecordID: "visa", Version: 2, Action: models.RevisionUpdate, CreatedAt: created},
+		{ID: 3, Kind: models.KindCard, RecordID: "visa", Version: 1, Action: models.RevisionAdd, CreatedAt: created},
+	}, revisions)
+}
+
+func (suite *PgStorageSuite) TestRevision() {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	data := map[string]interface{}{"id": "photo", "content": "data", "note": "png", "version": float64(3)}
+	pgxRows := pgxpoolmock.NewRows([]string{"@record_revisions", "version", "action", "created_at", "data"}).
+		AddRow(int64(9), int64(3), models.RevisionDelete, created, data).
+		ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	SELECT "@record_revisions", "version", "action", "created_at", "data"
+	FROM "record_revisions"
+	WHERE "user" = $1 AND "kind" = $2 AND "id" = $3 AND "@record_revisions" = $4
+	`, int64(1), "bin", "photo", int64(9)).Return(pgxRows)
+
+	revision, err := suite.store.Revision(context.Background(), 1, models.KindBinary, "photo", 9)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), &models.Revision{
+		ID: 9, Kind: models.KindBinary, RecordID: "photo", Version: 3, Action: models.RevisionDelete, CreatedAt: created,
+		Fields: map[string]string{"data": "data", "file_name": "", "note": "png"},
+	}, revision)
+
+	_, err = suite.store.Revision(context.Background(), 1, models.Kind("unknown"), "photo", 9)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PgStorageSuite) TestRestoreRevision() {
+	pgxRows := pgxpoolmock.NewRows([]string{"version"}).AddRow(int64(4)).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	WITH "revision" AS (
+		SELECT (jsonb_populate_record(NULL::"logins", "data")).*
+		FROM "record_revisions"
+		WHERE "user" = $1 AND "kind" = $2 AND "id" = $3 AND "@record_revisions" = $4
+	)
+	INSERT INTO "logins"("id", "user", "login", "password", "totp", "note", "version")
+	SELECT $3, $1, COALESCE("login", ''), COALESCE("password", ''), COALESCE("totp", ''), COALESCE("note", ''), (
+		SELECT COALESCE(MAX("version"), 0) + 1 FROM "record_revisions" WHERE "user" = $1 AND "kind" = $2 AND "id" = $3
+	)
+	FROM "revision"
+	ON CONFLICT ("id", "user") DO UPDATE SET "login" = EXCLUDED."login", "password" = EXCLUDED."password", "totp" = EXCLUDED."totp", "note" = EXCLUDED."note", "version" = "logins"."version" + 1, "deleted_at" = NULL
+	RETURNING "version"
+	`, int64(1), "login", "mail", int64(5)).Return(pgxRows)
+
+	version, err := suite.store.RestoreRevision(context.Background(), 1, models.KindLogin, "mail", 5)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(4), version)
+}
+
+func (suite *PgStorageSuite) TestRestoreBinaryRevision() {
+	ctx := context.Background()
+	binaryQuery := `
+	SELECT "id", "user", "content", COALESCE("blob", ''), "size", "note", "version",
+		"record_tags_of"("user", 'bin', "id"), "record_fields_of"("user", 'bin', "id"),
+		"record_vault_of"("user", 'bin', "id"), "record_folder_of"("user", 'bin', "id"),
+		"file_name", "file_size", "mime_type", "mod_time"
+	FROM "bin_data"
+	WHERE "user" = $1 and "id" = $2 AND "deleted_at" IS NULL
+	LIMIT 1
+	`
+	binaryColumns := []string{"id", "user", "content", "blob", "size", "note", "version", "tags", "fields", "vault", "folder",
+		"file_name", "file_size", "mime_type", "mod_time"}
+	restoreQuery := `
+	WITH "revision" AS (
+		SELECT (jsonb_populate_record(NULL::"bin_data", "data")).*
+		FROM "record_revisions"
+		WHERE "user" = $1 AND "kind" = $2 AND "id" = $3 AND "@record_revisions" = $4
+	)
+	INSERT INTO "bin_data"("id", "user", "content", "file_name", "note", "blob", "size", "file_size", "mime_type", "mod_time", "version")
+	SELECT $3, $1, COALESCE("content", ''), COALESCE("file_name", ''), COALESCE("note", ''), "blob", COALESCE("size", 0), COALESCE("file_size", 0), COALESCE("mime_type", ''), "mod_time", (
+		SELECT COALESCE(MAX("version"), 0) + 1 FROM "record_revisions" WHERE "user" = $1 AND "kind" = $2 AND "id" = $3
+	)
+	FROM "revision"
+	WHERE "blob" IS NULL OR "blob" IN (SELECT "id" FROM "bin_uploads" WHERE "user" = $1 AND "completed_at" IS NOT NULL)
+	ON CONFLICT ("id", "user") DO UPDATE SET "content" = EXCLUDED."content", "file_name" = EXCLUDED."file_name", "note" = EXCLUDED."note", "blob" = EXCLUDED."blob", "size" = EXCLUDED."size", "file_size" = EXCLUDED."file_size", "mime_type" = EXCLUDED."mime_type", "mod_time" = EXCLUDED."mod_time", "version" = "bin_data"."version" + 1, "deleted_at" = NULL
+	RETURNING "version"
+	`
+
+	// The content uploaded in chunks is read from the blob store
+	sum, err := blobstore.Write(ctx, suite.blobs, "up/0", []byte("uploaded"))
+	suite.Require().NoError(err)
+	pgxRows := pgxpoolmock.NewRows(binaryColumns).
+		AddRow("photo", int64(1), []byte(nil), "up", int64(8), "", int64(3), []string(nil), map[string]string(nil),
+			int64(0), int64(0), "new.png", int64(8), "image/png", (*time.Time)(nil)).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), binaryQuery, int64(1), "photo").Return(pgxRows)
+	pgxRows = pgxpoolmock.NewRows([]string{"sha256"}).AddRow(sum).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	SELECT c."sha256"
+	FROM "bin_chunks" c JOIN "bin_uploads" u ON u."id" = c."upload"
+	WHERE u."user" = $1 AND u."id" = $2 AND u."completed_at" IS NOT NULL AND c."seq" = $3
+	`, int64(1), "up", int64(0)).Return(pgxRows)
+
+	binary, err := suite.store.Binary(ctx, 1, "photo")
+	suite.Require().NoError(err)
+	data, err := io.ReadAll(NewContentReader(ctx, suite.store, 1, binary))
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), []byte("uploaded"), data)
+
+	// The earlier revision with the content in the row drops the upload, the content is read from the row again
+	pgxRows = pgxpoolmock.NewRows([]string{"version"}).AddRow(int64(4)).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), restoreQuery, int64(1), "bin", "photo", int64(2)).Return(pgxRows)
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	DELETE FROM "bin_uploads" u USING "bin_data" b
+	WHERE b."user" = $1 AND b."id" = $2 AND u."@bin" = b."@bin" AND u."completed_at" IS NOT NULL
+		AND u."id" IS DISTINCT FROM b."blob"
+	`, int64(1), "photo").Return([]byte("DELETE 1"), nil)
+
+	version, err := suite.store.RestoreRevision(ctx, 1, models.KindBinary, "photo", 2)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(4), version)
+
+	pgxRows = pgxpoolmock.NewRows(binaryColumns).
+		AddRow("photo", int64(1), []byte("inline"), "", int64(0), "", int64(4), []string(nil), map[string]string(nil),
+			int64(0), int64(0), "old.txt", int64(6), "text/plain", (*time.Time)(nil)).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), binaryQuery, int64(1), "photo").Return(pgxRows)
+
+	binary, err = suite.store.Binary(ctx, 1, "photo")
+	suite.Require().NoError(err)
+	data, err = io.ReadAll(NewContentReader(ctx, suite.store, 1, binary))
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), []byte("inline"), data)
+	assert.Equal(suite.T(), "old.txt", binary.FileName)
+
+	// The revision of the upload replaced since can not be restored
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), restoreQuery, int64(1), "bin", "photo", int64(1)).Return(noRows())
+	pgxRows = pgxpoolmock.NewRows([]string{"@record_revisions", "version", "action", "created_at", "data"}).
+		AddRow(int64(1), int64(1), models.RevisionAdd, time.Now(), map[string]interface{}{"blob": "old"}).
+		ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	SELECT "@record_revisions", "version", "action", "created_at", "data"
+	FROM "record_revisions"
+	WHERE "user" = $1 AND "kind" = $2 AND "id" = $3 AND "@record_revisions" = $4
+	`, int64(1), "bin", "photo", int64(1)).Return(pgxRows)
+
+	_, err = suite.store.RestoreRevision(ctx, 1, models.KindBinary, "photo", 1)
+	assert.ErrorIs(suite.T(), err, ErrContentRemoved)
+}
+
+func (suite *PgStorageSuite) TestPurgeRevisions() {
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	DELETE FROM "record_revisions" r
+	WHERE r."created_at" < now() - make_interval(secs => $1)
+		AND (r."action" = 'delete' OR EXISTS (
+			SELECT 1 FROM "record_revisions" n
+			WHERE n."user" = r."user" AND n."kind" = r."kind" AND n."id" = r."id"
+				AND n."@record_revisions" > r."@record_revisions"
+		))
+	`, float64(3600)).Return([]byte("DELETE 2"), nil)
+
+	removed, err := suite.store.PurgeRevisions(context.Background(), time.Hour)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(2), removed)
+}
+
+func (suite *PgStorageSuite) TestTrash() {
+	deleted := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	pgxRows := pgxpoolmock.NewRows([]string{"kind", "id", "note", "deleted_at"}).
+		AddRow("login", "mail", "work", deleted).
+		AddRow("bin", "photo", "", deleted).
+		ToPgxRows()
+	suite.mockPool.EXPECT().Query(gomock.Any(), `
+	SELECT 'card', "id", COALESCE("note", ''), "deleted_at" FROM "cards" WHERE "user" = $1 AND "deleted_at" IS NOT NULL
+	UNION ALL
+	SELECT 'login', "id", COALESCE("note", ''), "deleted_at" FROM "logins" WHERE "user" = $1 AND "deleted_at" IS NOT NULL
+	UNION ALL
+	SELECT 'text', "id", COALESCE("note", ''), "deleted_at" FROM "text_data" WHERE "user" = $1 AND "deleted_at" IS NOT NULL
+	UNION ALL
+	SELECT 'bin', "id", COALESCE("note", ''), "deleted_at" FROM "bin_data" WHERE "user" = $1 AND "deleted_at" IS NOT NULL
+	UNION ALL
+	SELECT 'ssh', "id", COALESCE("note", ''), "deleted_at" FROM "ssh_keys" WHERE "user" = $1 AND "deleted_at" IS NOT NULL
+	UNION ALL
+	SELECT 'custom', "id", COALESCE("note", ''), "deleted_at" FROM "custom_records" WHERE "user" = $1 AND "deleted_at" IS NOT NULL
+	ORDER BY 4 DESC
+	`, int64(1)).Return(pgxRows, nil)
+
+	items, err := suite.store.Trash(context.Background(), 1)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []models.TrashItem{
+		{Kind: models.KindLogin, ID: "mail", Note: "work", DeletedAt: deleted},
+		{Kind: models.KindBinary, ID: "photo", DeletedAt: deleted},
+	}, items)
+}
+
+func (suite *PgStorageSuite) TestRestoreTrash() {
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	UPDATE "text_data" SET "deleted_at" = NULL
+	WHERE "user" = $1 AND "id" = $2 AND "deleted_at" IS NOT NULL
+	`, int64(1), "note").Return([]byte("UPDATE 1"), nil)
+	assert.NoError(suite.T(), suite.store.RestoreTrash(context.Background(), 1, models.KindText, "note"))
+
+	suite.mockPool.EXPECT().Exec(gomock.Any(), `
+	UPDATE "text_data" SET "deleted_at" = NULL
+	WHERE "user" = $1 AND "id" = $2 AND "deleted_at" IS NOT NULL
+	`, int64(1), "note").Return([]byte("UPDATE 0"), nil)
+	assert.ErrorIs(suite.T(), suite.store.RestoreTrash(context.Background(), 1, models.KindText, "note"), pgx.ErrNoRows)
+}
+
+func (suite *PgStorageSuite) TestEmptyTrash() {
+	pgxRows := pgxpoolmock.NewRows([]string{"count"}).AddRow(int64(3)).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	WITH "card" AS (DELETE FROM "cards" WHERE "deleted_at" IS NOT NULL AND "user" = $1 RETURNING 1),
+	"login" AS (DELETE FROM "logins" WHERE "deleted_at" IS NOT NULL AND "user" = $1 RETURNING 1),
+	"text" AS (DELETE FROM "text_data" WHERE "deleted_at" IS NOT NULL AND "user" = $1 RETURNING 1),
+	"bin" AS (DELETE FROM "bin_data" WHERE "deleted_at" IS NOT NULL AND "user" = $1 RETURNING 1),
+	"ssh" AS (DELETE FROM "ssh_keys" WHERE "deleted_at" IS NOT NULL AND "user" = $1 RETURNING 1),
+	"custom" AS (DELETE FROM "custom_records" WHERE "deleted_at" IS NOT NULL AND "user" = $1 RETURNING 1)
+	SELECT (SELECT count(*) FROM "card") + (SELECT count(*) FROM "login") + (SELECT count(*) FROM "text") + (SELECT count(*) FROM "bin") + (SELECT count(*) FROM "ssh") + (SELECT count(*) FROM "custom")
+	`, int64(1)).Return(pgxRows)
+
+	removed, err := suite.store.EmptyTrash(context.Background(), 1)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(3), removed)
+}
+
+func (suite *PgStorageSuite) TestPurgeTrash() {
+	pgxRows := pgxpoolmock.NewRows([]string{"count"}).AddRow(int64(0)).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	WITH "card" AS (DELETE FROM "cards" WHERE "deleted_at" IS NOT NULL AND "deleted_at" < now() - make_interval(secs => $1) RETURNING 1),
+	"login" AS (DELETE FROM "logins" WHERE "deleted_at" IS NOT NULL AND "deleted_at" < now() - make_interval(secs => $1) RETURNING 1),
+	"text" AS (DELETE FROM "text_data" WHERE "deleted_at" IS NOT NULL AND "deleted_at" < now() - make_interval(secs => $1) RETURNING 1),
+	"bin" AS (DELETE FROM "bin_data" WHERE "deleted_at" IS NOT NULL AND "deleted_at" < now() - make_interval(secs => $1) RETURNING 1),
+	"ssh" AS (DELETE FROM "ssh_keys" WHERE "deleted_at" IS NOT NULL AND "deleted_at" < now() - make_interval(secs => $1) RETURNING 1),
+	"custom" AS (DELETE FROM "custom_records" WHERE "deleted_at" IS NOT NULL AND "deleted_at" < now() - make_interval(secs => $1) RETURNING 1)
+	SELECT (SELECT count(*) FROM "card") + (SELECT count(*) FROM "login") + (SELECT count(*) FROM "text") + (SELECT count(*) FROM "bin") + (SELECT count(*) FROM "ssh") + (SELECT count(*) FROM "custom")
+	`, float64(86400)).Return(pgxRows)
+
+	removed, err := suite.store.PurgeTrash(context.Background(), 24*time.Hour)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(0), removed)
+}
+
+func (suite *PgStorageSuite) TestAddVault() {
+	pgxRows := pgxpoolmock.NewRows([]string{"id"}).AddRow(int64(4)).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	INSERT INTO "vaults"("user", "name") VALUES ($1, $2)
+	returning "@vaults"
+	`, int64(1), "work").Return(pgxRows)
+
+	vaultID, err := suite.store.AddVault(context.Background(), 1, "work")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(4), vaultID)
+
+	// The user already has the vault with the name
+	pgxRows = pgxpoolmock.NewRows([]string{"id"}).AddRow(int64(0)).
+		RowError(0, &pgconn.PgError{Code: "23505"}).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), `
+	INSERT INTO "vaults"("user", "name") VALUES ($1, $2)
+	returning "@vaults"
+	`, int64(1), "work").Return(pgxRows)
+
+	_, err = suite.store.AddVault(context.Background(), 1, "work")
+	assert.ErrorIs(suite.T(), err, ErrNameTaken)
+}
+
+func (suite *PgStorageSuite) TestFolders() {
+	pgxRows := pgxpoolmock.NewRows([]string{"id", "vault", "parent", "name"}).
+		AddRow(int64(3), int64(2), int64(0), "bank").
+		AddRow(int64(5), int64(2), int64(3), "cards").
+		ToPgxRows()
+	suite.mockPool.EXPECT().Query(gomock.Any(), `
+	SELECT "@folders", "vault", COALESCE("parent", 0), "name" FROM "folders"
+	WHERE "user" = $1 AND "vault" = $2
+	ORDER BY "name", "@folders"
+	`, int64(1), int64(2)).Return(pgxRows, nil)
+
+	folders, err := suite.store.Folders(context.Background(), 1, 2)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []models.Folder{
+		{ID: 3, Vault: 2, Name: "bank"},
+		{ID: 5, Vault: 2, Parent: 3, Name: "cards"},
+	}, folders)
+}
+
+func (suite *PgStorageSuite) TestUpdateFolder() {
+	query := `
+	SELECT "update_folder"($1, $2, $3, $4)
+	`
+	pgxRows := pgxpoolmock.NewRows([]string{"updated"}).AddRow(true).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), query, int64(1), int64(5), "cards", int64(3)).Return(pgxRows)
+	err := suite.store.UpdateFolder(context.Background(), 1, models.Folder{ID: 5, Parent: 3, Name: "cards"})
+	assert.NoError(suite.T(), err)
+
+	// The parent is the nested folder or there is no such folder
+	pgxRows = pgxpoolmock.NewRows([]string{"updated"}).AddRow(false).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), query, int64(1), int64(3), "bank", int64(5)).Return(pgxRows)
+	err = suite.store.UpdateFolder(context.Background(), 1, models.Folder{ID: 3, Parent: 5, Name: "bank"})
+	assert.ErrorIs(suite.T(), err, pgx.ErrNoRows)
+
+	// The name is taken by another folder of the parent
+	pgxRows = pgxpoolmock.NewRows([]string{"updated"}).AddRow(nil).RowError(0, &pgconn.PgError{Code: "23505"}).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), query, int64(1), int64(5), "bank", int64(3)).Return(pgxRows)
+	err = suite.store.UpdateFolder(context.Background(), 1, models.Folder{ID: 5, Parent: 3, Name: "bank"})
+	assert.ErrorIs(suite.T(), err, ErrNameTaken)
+}
+
+func (suite *PgStorageSuite) TestMoveRecord() {
+	query := `
+	SELECT "set_record_location"("user", 'text', "id", $3, $4) FROM "text_data"
+	WHERE "user" = $1 AND "id" = $2 AND "deleted_at" IS NULL
+	`
+	pgxRows := pgxpoolmock.NewRows([]string{"location"}).AddRow(nil).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), query, int64(1), "todo", int64(2), int64(5)).Return(pgxRows)
+	err := suite.store.MoveRecord(context.Background(), 1, models.KindText, "todo", models.Location{Vault: 2, Folder: 5})
+	assert.NoError(suite.T(), err)
+
+	// The folder is not in the vault of the user
+	pgxRows = pgxpoolmock.NewRows([]string{"location"}).AddRow(nil).
+		RowError(0, &pgconn.PgError{Code: "23503"}).ToPgxRows()
+	pgxRows.Next()
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), query, int64(1), "todo", int64(2), int64(9)).Return(pgxRows)
+	err = suite.store.MoveRecord(context.Background(), 1, models.KindText, "todo", models.Location{Vault: 2, Folder: 9})
+	assert.ErrorIs(suite.T(), err, ErrInvalidLocation)
+
+	// There is no such record
+	suite.mockPool.EXPECT().QueryRow(gomock.Any(), query, int64(1), "lost", int64(0), int64(0)).Return(noRows("location"))
+	err = suite.store.MoveRecord(context.Background(), 1, models.KindText, "lost", models.Location{})
+	assert.ErrorIs(suite.T(), err, pgx.ErrNoRows)
+}
+
+// noRows returns the result of the query that did not match any row, the updates of the records
+// return the version and the meta
+func noRows(columns ...string) pgx.Rows {
+	if len(columns) == 0 {
+		columns = []string{"version"}
+	}
+	values := make([]interface{}, len(columns))
+	rows := pgxpoolmock.NewRows(columns).
+		AddRow(values...).
+		RowError(0, pgx.ErrNoRows).
+		ToPgxRows()
+	rows.Next()
+	return rows
+}
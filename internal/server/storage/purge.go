@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// purgeInterval how often the expired revisions and trash items are removed
+const purgeInterval = time.Hour
+
+// uploadRetention how long the not completed upload of the binary content can be resumed
+const uploadRetention = 24 * time.Hour
+
+// blobRetention how long the chunks of the removed upload are kept, the downloads of the replaced content can finish
+const blobRetention = time.Hour
+
+// PurgeRevisions removes the revisions older than retention every hour until the context is canceled.
+// Zero retention keeps the revisions forever
+func PurgeRevisions(ctx context.Context, store Storage, retention time.Duration) {
+	purge(ctx, "revisions", retention, store.PurgeRevisions)
+}
+
+// PurgeTrash removes the records deleted earlier than retention ago every hour until the context is canceled.
+// Zero retention keeps the trash until the user empties it
+func PurgeTrash(ctx context.Context, store Storage, retention time.Duration) {
+	purge(ctx, "trash items", retention, store.PurgeTrash)
+}
+
+// PurgeUploads removes the abandoned uploads of the binary content every hour until the context is canceled
+func PurgeUploads(ctx context.Context, store Storage) {
+	purge(ctx, "uploads", uploadRetention, store.PurgeUploads)
+}
+
+// PurgeBlobs removes the chunks of the removed uploads from the blob store every hour until the context is canceled
+func PurgeBlobs(ctx context.Context, store Storage) {
+	purge(ctx, "blobs", blobRetention, store.PurgeBlobs)
+}
+
+// purge calls remove every purgeInterval, the first time at once
+func purge(ctx context.Context, name string, retention time.Duration, remove func(context.Context, time.Duration) (int64, error)) {
+	if retention <= 0 {
+		return
+	}
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	for {
+		removed, err := remove(ctx, retention)
+		if err != nil {
+			log.Error().Err(err).Msgf("cant remove expired %s", name)
+		} else if removed > 0 {
+			log.Info().Msgf("%d expired %s removed", removed, name)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package storage
+
+import "github.com/ncyellow/GophKeeper/internal/models"
+
+// revisionColumns the columns of the record kind kept in the revisions, the secret ones, the public ones and the note
+func revisionColumns(kind models.Kind) []string {
+	table := sealedTables[kind]
+	return append(append(append([]string{}, table.columns...), table.public...), "note")
+}
+
+// revisionField returns the json name of the field of the record stored in the column.
+// Only the binary data is stored under another name
+func revisionField(kind models.Kind, column string) string {
+	if kind == models.KindBinary && column == "content" {
+		return "data"
+	}
+	return column
+}
@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/blobstore"
+	"github.com/ncyellow/GophKeeper/internal/server/storage/keyring"
+)
+
+// rotationBatch how many rows are read at once during the rotation
+const rotationBatch = 100
+
+// RewrapKeys re-wraps the data keys wrapped by old master keys with the active master key.
+// After that the old master keys can be removed from the configuration. Returns the number of re-wrapped keys
+func (e *EncryptedStorage) RewrapKeys(ctx context.Context) (int, error) {
+	active := e.keyring.ActiveVersion()
+	count := 0
+	var after int64
+	for {
+		keys, err := e.keys.StaleUserKeys(ctx, active, after, rotationBatch)
+		if err != nil {
+			return count, err
+		}
+		if len(keys) == 0 {
+			return count, nil
+		}
+		for _, key := range keys {
+			after = key.KeyID
+			plain, err := e.keyring.Unwrap(key.UserID, key.Version, key.KEKVersion, key.Wrapped)
+			if err != nil {
+				return count, err
+			}
+			oldKEKVersion := key.KEKVersion
+			key.KEKVersion, key.Wrapped, err = e.keyring.Wrap(key.UserID, key.Version, plain)
+			if err != nil {
+				return count, err
+			}
+			ok, err := e.keys.RewrapUserKey(ctx, key, oldKEKVersion)
+			if err != nil {
+				return count, err
+			}
+			if ok {
+				count++
+			}
+		}
+	}
+}
+
+// RotateDataKeys adds a new data key version to every user that already has data keys,
+// new values are encrypted with it. Old data keys are kept to read the records until Reencrypt is done.
+// Returns the number of created keys
+func (e *EncryptedStorage) RotateDataKeys(ctx context.Context) (int, error) {
+	count := 0
+	var after int64
+	for {
+		userIDs, err := e.keys.UserIDs(ctx, after, rotationBatch)
+		if err != nil {
+			return count, err
+		}
+		if len(userIDs) == 0 {
+			return count, nil
+		}
+		for _, userID := range userIDs {
+			after = userID
+			keys, err := e.keys.UserKeys(ctx, userID)
+			if err != nil {
+				return count, err
+			}
+			if len(keys) == 0 {
+				// The user has no encrypted records yet, the first key is created on the first write
+				continue
+			}
+			ok, err := e.addDataKey(ctx, userID, keys[len(keys)-1].Version+1)
+			if err != nil {
+				return count, err
+			}
+			if ok {
+				count++
+			}
+			e.forget(userID)
+		}
+	}
+}
+
+// Reencrypt re-encrypts all records, the secrets of the second factor and the chunks of the uploaded content
+// with the active data keys of their users, values saved before the encryption was enabled are encrypted too.
+// The rows are updated one column at a time and only if the column was not changed concurrently, so the server
+// keeps working during the rotation. Returns the number of updated columns and chunks and the number of the ones
+// skipped because of concurrent changes, the skipped ones are handled by the next run
+func (e *EncryptedStorage) Reencrypt(ctx context.Context, store SealedStore) (updated int, skipped int, err error) {
+	for _, kind := range models.Kinds {
+		var after int64
+		for {
+			rows, err := store.SealedRows(ctx, kind, after, rotationBatch)
+			if err != nil {
+				return updated, skipped, err
+			}
+			if len(rows) == 0 {
+				break
+			}
+			for _, row := range rows {
+				after = row.RowID
+				err := e.reencryptRow(ctx, kind, row, &updated, &skipped, func(column, old, value string) (bool, error) {
+					return store.ReplaceSealed(ctx, kind, row.RowID, column, old, value)
+				})
+				if err != nil {
+					return updated, skipped, err
+				}
+			}
+		}
+	}
+
+	// The secrets of the second factor are sealed with the data keys like the records
+	var afterUserID int64
+	for {
+		rows, err := store.SealedTOTPSecrets(ctx, afterUserID, rotationBatch)
+		if err != nil {
+			return updated, skipped, err
+		}
+		if len(rows) == 0 {
+			break
+		}
+		for _, row := range rows {
+			afterUserID = row.UserID
+			err := e.reencryptRow(ctx, totpKind, row, &updated, &skipped, func(_, old, value string) (bool, error) {
+				return store.ReplaceTOTPSecret(ctx, row.UserID, old, value)
+			})
+			if err != nil {
+				return updated, skipped, err
+			}
+		}
+	}
+
+	chunksUpdated, chunksSkipped, err := e.reencryptChunks(ctx, store)
+	return updated + chunksUpdated, skipped + chunksSkipped, err
+}
+
+// reencryptRow re-encrypts the columns of the row not sealed with the active data key and saves them by replace,
+// which returns false if the column was changed concurrently
+func (e *EncryptedStorage) reencryptRow(ctx context.Context, kind models.Kind, row SealedRow, updated *int, skipped *int,
+	replace func(column string, old string, value string) (bool, error)) error {
+	keys, err := e.dataKeys(ctx, row.UserID, false)
+	if err != nil {
+		return err
+	}
+	for column, old := range row.Values {
+		if keyring.IsSealed(old) {
+			version, err := keyring.Version(old)
+			if err != nil {
+				return err
+			}
+			if version == keys.ActiveVersion() {
+				continue
+			}
+		}
+
+		value := old
+		columns := map[string]*string{column: &value}
+		if err := e.open(ctx, row.UserID, kind, row.ID, columns); err != nil {
+			return err
+		}
+		if err := e.seal(ctx, row.UserID, kind, row.ID, columns); err != nil {
+			return err
+		}
+		ok, err := replace(column, old, value)
+		if err != nil {
+			return err
+		}
+		if ok {
+			*updated++
+		} else {
+			*skipped++
+		}
+	}
+	return nil
+}
+
+// reencryptChunks re-encrypts the chunks of the uploaded content, every chunk gets a new blob.
+// The chunk removed with its upload in the meantime is skipped
+func (e *EncryptedStorage) reencryptChunks(ctx context.Context, store SealedStore) (updated int, skipped int, err error) {
+	var after ChunkBlob
+	for {
+		blobs, err := store.ChunkBlobs(ctx, after, rotationBatch)
+		if err != nil {
+			return updated, skipped, err
+		}
+		if len(blobs) == 0 {
+			return updated, skipped, nil
+		}
+		for _, blob := range blobs {
+			after = blob
+			data, err := store.ReadChunkBlob(ctx, blob)
+			if errors.Is(err, blobstore.ErrNotFound) {
+				skipped++
+				continue
+			}
+			if err != nil {
+				return updated, skipped, err
+			}
+			value := string(data)
+			if keyring.IsSealed(value) {
+				keys, err := e.dataKeys(ctx, blob.UserID, false)
+				if err != nil {
+					return updated, skipped, err
+				}
+				version, err := keyring.Version(value)
+				if err != nil {
+					return updated, skipped, err
+				}
+				if version == keys.ActiveVersion() {
+					continue
+				}
+			}
+
+			columns := chunkColumns(blob.Seq, &value)
+			if err := e.open(ctx, blob.UserID, models.KindBinary, blob.Upload, columns); err != nil {
+				return updated, skipped, err
+			}
+			if err := e.seal(ctx, blob.UserID, models.KindBinary, blob.Upload, columns); err != nil {
+				return updated, skipped, err
+			}
+			ok, err := store.ReplaceChunkBlob(ctx, blob, []byte(value))
+			if err != nil {
+				return updated, skipped, err
+			}
+			if ok {
+				updated++
+			} else {
+				skipped++
+			}
+		}
+	}
+}
+
+// forget drops the cached data keys of the user
+func (e *EncryptedStorage) forget(userID int64) {
+	e.mu.Lock()
+	delete(e.cache, userID)
+	e.mu.Unlock()
+}
@@ -2,31 +2,248 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
 
 	"github.com/ncyellow/GophKeeper/internal/models"
+	"github.com/ncyellow/GophKeeper/internal/server/config"
+	"github.com/ncyellow/GophKeeper/internal/server/storage/keyring"
 )
 
+// ErrTOTPEnabled the second factor can not be set up again while it is enabled
+var ErrTOTPEnabled = errors.New("two-factor authentication is already enabled")
+
+// ErrLoginTaken the login belongs to another user
+var ErrLoginTaken = errors.New("login is already taken")
+
+// ErrVersionConflict the record was updated since the client read it
+var ErrVersionConflict = errors.New("record version conflict")
+
+// ErrNameTaken the user already has a vault with the name, or the parent already has a folder with the name
+var ErrNameTaken = errors.New("name is already taken")
+
+// ErrInvalidLocation the vault or the folder of the record does not exist
+var ErrInvalidLocation = errors.New("no such vault or folder")
+
+// ErrRecordTypeInUse the record type can not be removed while it has records, the deleted ones too
+var ErrRecordTypeInUse = errors.New("record type is used by records")
+
+// ErrChunkOrder the chunk is not the next one of the upload, the upload is resumed from the received chunks
+var ErrChunkOrder = errors.New("unexpected chunk")
+
+// ErrContentRemoved the revision of the binary refers to the uploaded content that was replaced and removed since
+var ErrContentRemoved = errors.New("the content of the revision is removed")
+
+// ErrVaultInitialized the vault check value can be set only once, otherwise old records become unreadable
+var ErrVaultInitialized = errors.New("vault is already initialized")
+
 // Storage describes the interface for writing and reading to the database, similar to the HTTP API
 type Storage interface {
 	Register(ctx context.Context, user models.User) (int64, error)
 	UserByLogin(ctx context.Context, login string) (*models.User, error)
-	User(ctx context.Context, login string, password string) (*models.User, error)
+	// User returns the user together with the password hash, the password is checked by the caller
+	User(ctx context.Context, login string) (*models.User, error)
+	// UpdatePassword replaces the password hash of the user
+	UpdatePassword(ctx context.Context, userID int64, password string) error
+	// ChangeLogin renames the user. Returns ErrLoginTaken if the login belongs to another user
+	ChangeLogin(ctx context.Context, userID int64, login string) error
+	// DeleteUser removes the user, the records, sessions and keys are removed by the ON DELETE CASCADE foreign keys
+	DeleteUser(ctx context.Context, userID int64) error
+
+	// VaultParams returns the parameters of the client side encryption, the salt is generated on the first call
+	VaultParams(ctx context.Context, userID int64) (*models.VaultParams, error)
+	// SetVaultCheck saves the master password check value. Returns ErrVaultInitialized if it is already set
+	SetVaultCheck(ctx context.Context, userID int64, check string) error
+
+	// CreateSession saves a new session together with the hash of its refresh token
+	CreateSession(ctx context.Context, session models.Session, refreshHash string) error
+	// SessionUser returns the owner of the session, pgx.ErrNoRows if the session is revoked or expired
+	SessionUser(ctx context.Context, sessionID string) (*models.User, error)
+	// RefreshSession replaces the refresh token hash if oldHash is the current one and prolongs the session.
+	// Returns the owner of the session, pgx.ErrNoRows if the token is not the current one or the session is not active
+	RefreshSession(ctx context.Context, sessionID string, oldHash string, newHash string, expiresAt time.Time) (*models.User, error)
+	// RevokeReusedSession revokes the session if hash is the hash of any of its previous refresh tokens,
+	// that is an already used refresh token was presented again
+	RevokeReusedSession(ctx context.Context, sessionID string, hash string) (bool, error)
+	// Sessions returns the active sessions of the user
+	Sessions(ctx context.Context, userID int64) ([]models.Session, error)
+	// RevokeSession revokes the session of the user, pgx.ErrNoRows if the user has no such active session
+	RevokeSession(ctx context.Context, userID int64, sessionID string) error
+	// RevokeOtherSessions revokes all active sessions of the user except the current one
+	RevokeOtherSessions(ctx context.Context, userID int64, sessionID string) error
+
+	// TOTP returns the second factor of the user, the secret is empty if it was never set up
+	TOTP(ctx context.Context, userID int64) (*models.TOTP, error)
+	// SetTOTPSecret saves a not confirmed secret. Returns ErrTOTPEnabled if the second factor is already enabled
+	SetTOTPSecret(ctx context.Context, userID int64, secret string) error
+	// EnableTOTP enables the second factor and saves the hashes of the recovery codes.
+	// Returns ErrTOTPEnabled if it is already enabled or there is no secret
+	EnableTOTP(ctx context.Context, userID int64, counter int64, recoveryHashes []string) error
+	// UseTOTPCounter remembers the time step of the accepted code. Returns false if the same or a newer code was already used
+	UseTOTPCounter(ctx context.Context, userID int64, counter int64) (bool, error)
+	// UseRecoveryCode marks the recovery code as used. Returns false if there is no such unused code
+	UseRecoveryCode(ctx context.Context, userID int64, hash string) (bool, error)
+	// DisableTOTP removes the second factor and the recovery codes
+	DisableTOTP(ctx context.Context, userID int64) error
+
+	// LoginRetryAfter returns how long the sign-in is blocked by any of the attempt counters, zero if it is not blocked.
+	// The time of the database is used, so all server instances agree on it
+	LoginRetryAfter(ctx context.Context, keys []string) (time.Duration, error)
+	// AddLoginFailure increments the failed attempts counter and returns its value.
+	// Failures older than window are forgotten and the counter starts from one
+	AddLoginFailure(ctx context.Context, key string, window time.Duration) (int, error)
+	// LockLogin blocks the sign-in by the counter for the delay
+	LockLogin(ctx context.Context, key string, delay time.Duration) error
+	// ResetLoginFailures forgets the failed attempts of the counter
+	ResetLoginFailures(ctx context.Context, key string) error
+	// AddLockout saves the lockout event of the account, it is shown to the owner
+	AddLockout(ctx context.Context, userID int64, lockout models.Lockout, delay time.Duration) error
+	// Lockouts returns the lockout events of the account, the newest first
+	Lockouts(ctx context.Context, userID int64) ([]models.Lockout, error)
+
+	// AddAuditEvent appends the event to the audit log of the user. The zero userID is the failed sign-in
+	// with an unknown login, the event is saved but nobody sees it. An empty actor is the login of the user
+	AddAuditEvent(ctx context.Context, userID int64, event models.AuditEvent) error
+	// AuditEvents returns up to limit events of the user older than the event before, the newest first.
+	// The zero before means from the newest event
+	AuditEvents(ctx context.Context, userID int64, before int64, limit int) ([]models.AuditEvent, error)
+
+	// Records returns a page of the records of the kind without the secret fields, see models.ListOptions
+	Records(ctx context.Context, userID int64, kind models.Kind, opts models.ListOptions) ([]models.RecordInfo, error)
+
+	// Revisions returns the revisions of the record without the values of the fields, the newest first
+	Revisions(ctx context.Context, userID int64, kind models.Kind, id string) ([]models.Revision, error)
+	// Revision returns the revision of the record with the values of the fields, pgx.ErrNoRows if there is no such revision
+	Revision(ctx context.Context, userID int64, kind models.Kind, id string, revisionID int64) (*models.Revision, error)
+	// RestoreRevision replaces the record with the values of the revision, the deleted record is added again.
+	// Returns the new version of the record, pgx.ErrNoRows if there is no such revision,
+	// ErrContentRemoved if the uploaded content of the binary revision is removed
+	RestoreRevision(ctx context.Context, userID int64, kind models.Kind, id string, revisionID int64) (int64, error)
+	// PurgeRevisions removes the revisions of all users older than retention, returns the number of the removed ones.
+	// The last revision of the record is kept unless it is the delete one, so the next update does not lose the value
+	PurgeRevisions(ctx context.Context, retention time.Duration) (int64, error)
+
+	// Trash returns the deleted records of the user, the recently deleted first
+	Trash(ctx context.Context, userID int64) ([]models.TrashItem, error)
+	// RestoreTrash moves the record back from the trash, pgx.ErrNoRows if it is not in the trash
+	RestoreTrash(ctx context.Context, userID int64, kind models.Kind, id string) error
+	// EmptyTrash removes the deleted records of the user for good, returns the number of the removed ones
+	EmptyTrash(ctx context.Context, userID int64) (int64, error)
+	// PurgeTrash removes the records of all users deleted earlier than retention ago
+	PurgeTrash(ctx context.Context, retention time.Duration) (int64, error)
+
+	// Vaults returns the vaults of the user ordered by name
+	Vaults(ctx context.Context, userID int64) ([]models.Vault, error)
+	// AddVault creates the vault and returns its id, ErrNameTaken if the user has a vault with the name
+	AddVault(ctx context.Context, userID int64, name string) (int64, error)
+	// RenameVault returns pgx.ErrNoRows if there is no such vault, ErrNameTaken if the name is used
+	RenameVault(ctx context.Context, userID int64, vaultID int64, name string) error
+	// DeleteVault removes the vault with its folders, the records of the vault are moved to the top level
+	DeleteVault(ctx context.Context, userID int64, vaultID int64) error
+	// Folders returns all folders of the vault ordered by name, the client builds the tree by the parents
+	Folders(ctx context.Context, userID int64, vaultID int64) ([]models.Folder, error)
+	// AddFolder creates the folder and returns its id. Returns pgx.ErrNoRows if there is no such vault
+	// or parent folder in it, ErrNameTaken if the parent already has a folder with the name
+	AddFolder(ctx context.Context, userID int64, folder models.Folder) (int64, error)
+	// UpdateFolder renames the folder and moves it to the parent. Returns pgx.ErrNoRows if there is no such folder,
+	// or the parent is not a folder of the same vault outside of the moved one
+	UpdateFolder(ctx context.Context, userID int64, folder models.Folder) error
+	// DeleteFolder removes the folder with the nested ones, their records are moved to the root of the vault
+	DeleteFolder(ctx context.Context, userID int64, folderID int64) error
+	// MoveRecord moves the record to the location. Returns pgx.ErrNoRows if there is no such record,
+	// ErrInvalidLocation if there is no such vault or folder
+	MoveRecord(ctx context.Context, userID int64, kind models.Kind, id string, location models.Location) error
 
 	AddCard(ctx context.Context, userID int64, card models.Card) error
 	Card(ctx context.Context, userID int64, cardID string) (*models.Card, error)
+	// UpdateCard and the other updates replace the record if its version is still card.Version and return the new version.
+	// Return ErrVersionConflict if the version is stale, pgx.ErrNoRows if there is no such record
+	UpdateCard(ctx context.Context, userID int64, card models.Card) (int64, error)
+	// DeleteCard and the other deletes move the record to the trash, pgx.ErrNoRows if there is no such record
 	DeleteCard(ctx context.Context, userID int64, cardID string) error
 
 	AddLogin(ctx context.Context, userID int64, login models.Login) error
 	Login(ctx context.Context, userID int64, loginID string) (*models.Login, error)
+	UpdateLogin(ctx context.Context, userID int64, login models.Login) (int64, error)
 	DeleteLogin(ctx context.Context, userID int64, loginID string) error
 
 	AddText(ctx context.Context, userID int64, text models.Text) error
 	Text(ctx context.Context, userID int64, textID string) (*models.Text, error)
+	UpdateText(ctx context.Context, userID int64, text models.Text) (int64, error)
 	DeleteText(ctx context.Context, userID int64, textID string) error
 
 	AddBinary(ctx context.Context, userID int64, binData models.Binary) error
 	Binary(ctx context.Context, userID int64, binID string) (*models.Binary, error)
+	UpdateBinary(ctx context.Context, userID int64, binData models.Binary) (int64, error)
 	DeleteBinary(ctx context.Context, userID int64, binID string) error
+	// StartUpload creates the upload of the content of the binary, pgx.ErrNoRows if there is no such binary
+	StartUpload(ctx context.Context, userID int64, binID string) (*models.BinaryUpload, error)
+	// BinaryUpload returns the not completed upload, pgx.ErrNoRows if there is no such upload
+	BinaryUpload(ctx context.Context, userID int64, uploadID string) (*models.BinaryUpload, error)
+	// AddChunk appends the chunk to the upload and returns its new state. Returns ErrChunkOrder if the chunk
+	// is not the next one or the previous chunk was short, pgx.ErrNoRows if there is no such upload
+	AddChunk(ctx context.Context, userID int64, chunk models.BinaryChunk) (*models.BinaryUpload, error)
+	// CompleteUpload makes the upload the content of the binary if its version is still version,
+	// the previous content is removed. Returns the new version, ErrVersionConflict if the version is stale,
+	// pgx.ErrNoRows if there is no such upload of the binary
+	CompleteUpload(ctx context.Context, userID int64, binID string, uploadID string, version int64) (int64, error)
+	// BinaryChunk returns the chunk of the content, see Binary.ContentID. pgx.ErrNoRows if there is no such chunk,
+	// blobstore.ErrChecksum if the payload of the chunk is damaged
+	BinaryChunk(ctx context.Context, userID int64, contentID string, seq int64) ([]byte, error)
+	// PurgeUploads removes the uploads of all users not completed in retention
+	PurgeUploads(ctx context.Context, retention time.Duration) (int64, error)
+	// PurgeBlobs removes from the blob store the chunks of the uploads removed earlier than retention ago.
+	// The blob store may be another service, so the chunks are removed after the uploads
+	PurgeBlobs(ctx context.Context, retention time.Duration) (int64, error)
+
+	// AddSSHKey and UpdateSSHKey save the key as is, the public key and the fingerprint are set by SSHKey.Validate
+	AddSSHKey(ctx context.Context, userID int64, key models.SSHKey) error
+	SSHKey(ctx context.Context, userID int64, keyID string) (*models.SSHKey, error)
+	UpdateSSHKey(ctx context.Context, userID int64, key models.SSHKey) (int64, error)
+	DeleteSSHKey(ctx context.Context, userID int64, keyID string) error
+
+	// RecordTypes returns the record types of the user ordered by name
+	RecordTypes(ctx context.Context, userID int64) ([]models.RecordType, error)
+	// RecordType returns the record type by name, pgx.ErrNoRows if there is no such type
+	RecordType(ctx context.Context, userID int64, name string) (*models.RecordType, error)
+	// AddRecordType returns ErrNameTaken if the user has a type with the name
+	AddRecordType(ctx context.Context, userID int64, recordType models.RecordType) error
+	// UpdateRecordType replaces the fields of the type, the records are checked by the new schema on the next update.
+	// Returns ErrRecordTypeInUse if a field is removed or changes its secrecy while the type has records,
+	// pgx.ErrNoRows if there is no such type
+	UpdateRecordType(ctx context.Context, userID int64, recordType models.RecordType) error
+	// DeleteRecordType returns ErrRecordTypeInUse if the type has records, pgx.ErrNoRows if there is no such type
+	DeleteRecordType(ctx context.Context, userID int64, name string) error
+
+	// AddCustomRecord and UpdateCustomRecord save the record as is, it is checked by CustomRecord.Validate
+	// with its type
+	AddCustomRecord(ctx context.Context, userID int64, record models.CustomRecord) error
+	CustomRecord(ctx context.Context, userID int64, recordID string) (*models.CustomRecord, error)
+	UpdateCustomRecord(ctx context.Context, userID int64, record models.CustomRecord) (int64, error)
+	DeleteCustomRecord(ctx context.Context, userID int64, recordID string) error
 
 	Close()
 }
+
+// CreateStorage factory function of the storage. If the master key is configured,
+// the records are encrypted at rest with EncryptedStorage. The values are checked by ValidatedStorage
+// before the encryption. The access to the records is recorded by AuditStorage
+func CreateStorage(conf *config.Config) (Storage, error) {
+	pg, err := NewPgStorage(conf)
+	if err != nil {
+		return nil, err
+	}
+	if conf.MasterKey == "" && conf.MasterKeyFile == "" {
+		log.Warn().Msg("master key is not configured, records are stored without encryption at rest")
+		return NewAuditStorage(NewValidatedStorage(pg)), nil
+	}
+
+	ring, err := keyring.Load(conf.MasterKey, conf.MasterKeyFile)
+	if err != nil {
+		pg.Close()
+		return nil, err
+	}
+	return NewAuditStorage(NewValidatedStorage(NewEncryptedStorage(pg, pg, ring))), nil
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+)
+
+// ValidatedStorage decorator over any Storage that rejects the records with invalid values before they are saved,
+// the values are brought to the canonical form. The transports check the records too, it protects the other callers
+type ValidatedStorage struct {
+	Storage
+}
+
+// NewValidatedStorage constructor
+func NewValidatedStorage(store Storage) *ValidatedStorage {
+	return &ValidatedStorage{Storage: store}
+}
+
+func (v *ValidatedStorage) AddCard(ctx context.Context, userID int64, card models.Card) error {
+	if err := card.Validate(); err != nil {
+		return err
+	}
+	return v.Storage.AddCard(ctx, userID, card)
+}
+
+func (v *ValidatedStorage) UpdateCard(ctx context.Context, userID int64, card models.Card) (int64, error) {
+	if err := card.Validate(); err != nil {
+		return 0, err
+	}
+	return v.Storage.UpdateCard(ctx, userID, card)
+}
+
+func (v *ValidatedStorage) AddSSHKey(ctx context.Context, userID int64, key models.SSHKey) error {
+	if err := key.Validate(); err != nil {
+		return err
+	}
+	return v.Storage.AddSSHKey(ctx, userID, key)
+}
+
+func (v *ValidatedStorage) UpdateSSHKey(ctx context.Context, userID int64, key models.SSHKey) (int64, error) {
+	if err := key.Validate(); err != nil {
+		return 0, err
+	}
+	return v.Storage.UpdateSSHKey(ctx, userID, key)
+}
+
+func (v *ValidatedStorage) AddChunk(ctx context.Context, userID int64, chunk models.BinaryChunk) (*models.BinaryUpload, error) {
+	if err := chunk.Validate(); err != nil {
+		return nil, err
+	}
+	return v.Storage.AddChunk(ctx, userID, chunk)
+}
+
+func (v *ValidatedStorage) AddRecordType(ctx context.Context, userID int64, recordType models.RecordType) error {
+	if err := recordType.Validate(); err != nil {
+		return err
+	}
+	return v.Storage.AddRecordType(ctx, userID, recordType)
+}
+
+func (v *ValidatedStorage) UpdateRecordType(ctx context.Context, userID int64, recordType models.RecordType) error {
+	if err := recordType.Validate(); err != nil {
+		return err
+	}
+	return v.Storage.UpdateRecordType(ctx, userID, recordType)
+}
+
+func (v *ValidatedStorage) AddCustomRecord(ctx context.Context, userID int64, record models.CustomRecord) error {
+	if err := v.validateRecord(ctx, userID, &record); err != nil {
+		return err
+	}
+	return v.Storage.AddCustomRecord(ctx, userID, record)
+}
+
+func (v *ValidatedStorage) UpdateCustomRecord(ctx context.Context, userID int64, record models.CustomRecord) (int64, error) {
+	if err := v.validateRecord(ctx, userID, &record); err != nil {
+		return 0, err
+	}
+	return v.Storage.UpdateCustomRecord(ctx, userID, record)
+}
+
+// validateRecord checks the custom record by the schema of its type, the unknown type makes the record invalid
+func (v *ValidatedStorage) validateRecord(ctx context.Context, userID int64, record *models.CustomRecord) error {
+	recordType, err := v.Storage.RecordType(ctx, userID, record.Type)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: unknown record type %q", models.ErrInvalidRecord, record.Type)
+	}
+	if err != nil {
+		return err
+	}
+	return record.Validate(recordType)
+}
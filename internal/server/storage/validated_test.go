@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ncyellow/GophKeeper/internal/models"
+	mockstorage "github.com/ncyellow/GophKeeper/internal/server/mocks/storage"
+)
+
+func TestValidatedStorage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mockstorage.NewMockStorage(ctrl)
+	store := NewValidatedStorage(mock)
+	ctx := context.Background()
+
+	// The valid card is saved in the canonical form
+	mock.EXPECT().AddCard(ctx, int64(1), models.Card{ID: "visa", Number: "4111111111111111", Date: "12/30", CVV: "123"}).
+		Return(nil)
+	err := store.AddCard(ctx, 1, models.Card{ID: "visa", Number: "4111 1111 1111 1111", Date: "12/2030", CVV: "123"})
+	assert.NoError(t, err)
+
+	// The invalid one does not reach the storage
+	_, err = store.UpdateCard(ctx, 1, models.Card{ID: "visa", Number: "4111111111111112", Date: "12/30", Version: 1})
+	assert.ErrorIs(t, err, models.ErrInvalidCard)
+
+	// The custom record is checked by the schema of its type
+	mock.EXPECT().RecordType(ctx, int64(1), "Wi-Fi").Return(&models.RecordType{Name: "Wi-Fi",
+		Fields: []models.TypeField{{Name: "ssid", Type: models.FieldText, Required: true}}}, nil)
+	err = store.AddCustomRecord(ctx, 1, models.CustomRecord{ID: "home", Type: "Wi-Fi"})
+	assert.ErrorIs(t, err, models.ErrInvalidRecord)
+
+	mock.EXPECT().RecordType(ctx, int64(1), "Router").Return(nil, pgx.ErrNoRows)
+	err = store.AddCustomRecord(ctx, 1, models.CustomRecord{ID: "home", Type: "Router"})
+	assert.ErrorIs(t, err, models.ErrInvalidRecord)
+}
@@ -0,0 +1,168 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrRevoked    = errors.New("certificate is revoked")
+	ErrInvalidCRL = errors.New("crl is not signed by the ca")
+	ErrCRLNoCA    = errors.New("crl requires the ca of the client certificates")
+)
+
+// CRL the revoked client certificates. The list is reloaded from the file periodically,
+// so a certificate can be revoked without restarting the server
+type CRL struct {
+	file string
+	cas  []*x509.Certificate
+
+	mu      sync.RWMutex
+	revoked map[string]bool
+}
+
+// LoadCRL reads the pem or der crl file, it has to be signed by one of the certificates of caFile
+func LoadCRL(file string, caFile string) (*CRL, error) {
+	if caFile == "" {
+		return nil, ErrCRLNoCA
+	}
+	cas, err := loadCerts(caFile)
+	if err != nil {
+		return nil, err
+	}
+	crl := &CRL{file: file, cas: cas}
+	if err := crl.Reload(); err != nil {
+		return nil, err
+	}
+	return crl, nil
+}
+
+// OpenCRL loads the crl like LoadCRL and reloads it every interval until the context is done.
+// Returns nil if the crl file is not set
+func OpenCRL(ctx context.Context, file string, caFile string, interval time.Duration) (*CRL, error) {
+	if file == "" {
+		return nil, nil
+	}
+	crl, err := LoadCRL(file, caFile)
+	if err != nil {
+		return nil, err
+	}
+	if interval > 0 {
+		go crl.Watch(ctx, interval)
+	}
+	return crl, nil
+}
+
+// Reload reads the file again. On error the previous list stays in use
+func (c *CRL) Reload() error {
+	data, err := os.ReadFile(c.file)
+	if err != nil {
+		return fmt.Errorf("cant read crl: %w", err)
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	list, err := x509.ParseRevocationList(data)
+	if err != nil {
+		return fmt.Errorf("cant parse crl: %w", err)
+	}
+
+	var issuer *x509.Certificate
+	for _, ca := range c.cas {
+		if list.CheckSignatureFrom(ca) == nil {
+			issuer = ca
+			break
+		}
+	}
+	if issuer == nil {
+		return ErrInvalidCRL
+	}
+	if !list.NextUpdate.IsZero() && list.NextUpdate.Before(time.Now()) {
+		log.Warn().Msgf("crl %s is outdated since %s", c.file, list.NextUpdate)
+	}
+
+	revoked := make(map[string]bool, len(list.RevokedCertificateEntries))
+	for _, entry := range list.RevokedCertificateEntries {
+		revoked[revokedKey(issuer.RawSubject, entry.SerialNumber.String())] = true
+	}
+
+	c.mu.Lock()
+	c.revoked = revoked
+	c.mu.Unlock()
+	return nil
+}
+
+// Watch reloads the list every interval until the context is done
+func (c *CRL) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Reload(); err != nil {
+				log.Error().Err(err).Msgf("cant reload crl %s, the previous one is used", c.file)
+			}
+		}
+	}
+}
+
+// Revoked checks the certificate by its issuer and serial number
+func (c *CRL) Revoked(cert *x509.Certificate) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.revoked[revokedKey(cert.RawIssuer, cert.SerialNumber.String())]
+}
+
+// verify tls.Config.VerifyPeerCertificate callback, rejects the chains with a revoked certificate
+func (c *CRL) verify(_ [][]byte, chains [][]*x509.Certificate) error {
+	for _, chain := range chains {
+		for _, cert := range chain {
+			if c.Revoked(cert) {
+				return fmt.Errorf("%w: serial %s", ErrRevoked, cert.SerialNumber)
+			}
+		}
+	}
+	return nil
+}
+
+func revokedKey(issuer []byte, serial string) string {
+	return string(issuer) + "/" + serial
+}
+
+// loadCerts reads all pem certificates of the file
+func loadCerts(file string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("cant read ca certificate: %w", err)
+	}
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("cant parse ca certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, ErrInvalidCA
+	}
+	return certs, nil
+}
@@ -0,0 +1,85 @@
+// Package tlsconfig builds the TLS configuration shared by the https and grpc transports,
+// so both of them give the same transport security
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var (
+	ErrNoCertificate = errors.New("tls certificate and key are required")
+	ErrInvalidCA     = errors.New("no certificates in the ca file")
+)
+
+// Server returns the configuration of the listener. If caFile is set, the clients have to present
+// a certificate signed by it (mutual TLS), otherwise client certificates are not requested.
+// Client certificates listed in crl are rejected, crl may be nil
+func Server(certFile string, keyFile string, caFile string, crl *CRL) (*tls.Config, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, ErrNoCertificate
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("cant load tls certificate: %w", err)
+	}
+
+	conf := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if caFile != "" {
+		pool, err := loadPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		conf.ClientCAs = pool
+		conf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	if crl != nil {
+		if caFile == "" {
+			return nil, ErrCRLNoCA
+		}
+		conf.VerifyPeerCertificate = crl.verify
+	}
+	return conf, nil
+}
+
+// Client returns the configuration of the connection to the server. The server certificate is verified
+// by caFile or by the system roots if it is not set. The client certificate is presented if it is set
+func Client(certFile string, keyFile string, caFile string) (*tls.Config, error) {
+	conf := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cant load tls certificate: %w", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+	if caFile != "" {
+		pool, err := loadPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		conf.RootCAs = pool
+	}
+	return conf, nil
+}
+
+// loadPool reads the pem certificates of the ca
+func loadPool(caFile string) (*x509.CertPool, error) {
+	caCert, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("cant read ca certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, ErrInvalidCA
+	}
+	return pool, nil
+}
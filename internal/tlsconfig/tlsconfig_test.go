@@ -0,0 +1,193 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPKI the ca with the server and client certificates in a temporary directory
+type testPKI struct {
+	dir    string
+	ca     *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	serial int64
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "TestCA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	p := &testPKI{dir: t.TempDir(), ca: ca, caKey: key, serial: 1}
+	p.write(t, "ca.crt", "CERTIFICATE", der)
+	return p
+}
+
+// issue creates the certificate signed by the ca, returns the paths of the certificate and the key
+func (p *testPKI) issue(t *testing.T, name string, usage x509.ExtKeyUsage) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	p.serial++
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(p.serial),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, p.ca, &key.PublicKey, p.caKey)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return p.write(t, name+".crt", "CERTIFICATE", der), p.write(t, name+".key", "EC PRIVATE KEY", keyDer)
+}
+
+// writeCRL writes the crl of the ca revoking the serial numbers
+func (p *testPKI) writeCRL(t *testing.T, name string, serials ...int64) string {
+	var entries []x509.RevocationListEntry
+	for _, serial := range serials {
+		entries = append(entries, x509.RevocationListEntry{SerialNumber: big.NewInt(serial), RevocationTime: time.Now()})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(time.Now().UnixNano()),
+		ThisUpdate:                time.Now().Add(-time.Minute),
+		NextUpdate:                time.Now().Add(time.Hour),
+		RevokedCertificateEntries: entries,
+	}, p.ca, p.caKey)
+	require.NoError(t, err)
+	return p.write(t, name, "X509 CRL", der)
+}
+
+func (p *testPKI) write(t *testing.T, name string, blockType string, der []byte) string {
+	path := filepath.Join(p.dir, name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return path
+}
+
+// handshake connects the client to the server over a pipe, returns the error of the server side
+func handshake(t *testing.T, server *tls.Config, client *tls.Config) error {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	client = client.Clone()
+	client.ServerName = "localhost"
+	go func() {
+		conn := tls.Client(clientConn, client)
+		conn.Handshake()
+		// the server verifies the client certificate after the client handshake is done, wait for its verdict
+		conn.Read(make([]byte, 1))
+		conn.Close()
+	}()
+	conn := tls.Server(serverConn, server)
+	return conn.Handshake()
+}
+
+func TestMutualTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	caFile := filepath.Join(pki.dir, "ca.crt")
+	serverCrt, serverKey := pki.issue(t, "localhost", x509.ExtKeyUsageServerAuth)
+	clientCrt, clientKey := pki.issue(t, "client", x509.ExtKeyUsageClientAuth)
+
+	// TLS without client certificates
+	server, err := Server(serverCrt, serverKey, "", nil)
+	require.NoError(t, err)
+	client, err := Client("", "", caFile)
+	require.NoError(t, err)
+	assert.NoError(t, handshake(t, server, client))
+
+	// Mutual TLS, the client without a certificate is rejected
+	server, err = Server(serverCrt, serverKey, caFile, nil)
+	require.NoError(t, err)
+	assert.Error(t, handshake(t, server, client))
+
+	client, err = Client(clientCrt, clientKey, caFile)
+	require.NoError(t, err)
+	assert.NoError(t, handshake(t, server, client))
+
+	// The certificate of another ca is rejected
+	other := newTestPKI(t)
+	otherCrt, otherKey := other.issue(t, "client", x509.ExtKeyUsageClientAuth)
+	client, err = Client(otherCrt, otherKey, caFile)
+	require.NoError(t, err)
+	assert.Error(t, handshake(t, server, client))
+}
+
+func TestServerRequiresCertificate(t *testing.T) {
+	_, err := Server("", "", "", nil)
+	assert.ErrorIs(t, err, ErrNoCertificate)
+
+	_, err = Client("", "", filepath.Join(t.TempDir(), "missing.crt"))
+	assert.Error(t, err)
+
+	empty := filepath.Join(t.TempDir(), "empty.crt")
+	require.NoError(t, os.WriteFile(empty, []byte("no pem"), 0o600))
+	_, err = Client("", "", empty)
+	assert.ErrorIs(t, err, ErrInvalidCA)
+}
+
+func TestCRL(t *testing.T) {
+	pki := newTestPKI(t)
+	caFile := filepath.Join(pki.dir, "ca.crt")
+	serverCrt, serverKey := pki.issue(t, "localhost", x509.ExtKeyUsageServerAuth)
+	clientCrt, clientKey := pki.issue(t, "client", x509.ExtKeyUsageClientAuth)
+	revokedSerial := pki.serial
+	client, err := Client(clientCrt, clientKey, caFile)
+	require.NoError(t, err)
+
+	crlFile := pki.writeCRL(t, "ca.crl", revokedSerial)
+	crl, err := LoadCRL(crlFile, caFile)
+	require.NoError(t, err)
+	server, err := Server(serverCrt, serverKey, caFile, crl)
+	require.NoError(t, err)
+	assert.ErrorIs(t, handshake(t, server, client), ErrRevoked)
+
+	// The reloaded list applies to the running server
+	pki.writeCRL(t, "ca.crl")
+	require.NoError(t, crl.Reload())
+	assert.NoError(t, handshake(t, server, client))
+
+	// The crl of another ca is not accepted, the previous list stays in use
+	other := newTestPKI(t)
+	other.writeCRL(t, "ca.crl", revokedSerial)
+	require.NoError(t, os.Rename(filepath.Join(other.dir, "ca.crl"), crlFile))
+	assert.ErrorIs(t, crl.Reload(), ErrInvalidCRL)
+	assert.NoError(t, handshake(t, server, client))
+
+	_, err = LoadCRL(crlFile, "")
+	assert.ErrorIs(t, err, ErrCRLNoCA)
+
+	// The crl is optional
+	crl, err = OpenCRL(context.Background(), "", caFile, time.Minute)
+	assert.NoError(t, err)
+	assert.Nil(t, crl)
+}
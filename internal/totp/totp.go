@@ -0,0 +1,245 @@
+// Package totp implements time-based one-time passwords (RFC 6238) compatible with authenticator apps.
+// The second factor of the account sign-in uses HMAC-SHA1, 6 digits and 30 seconds step, the codes of the stored
+// logins are computed by Key with the parameters of their otpauth:// URI.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Digits length of the code
+	Digits = 6
+	// Period lifetime of the code
+	Period = 30 * time.Second
+	// secretLen length of the generated secret in bytes, recommended by RFC 4226
+	secretLen = 20
+)
+
+var (
+	ErrInvalidSecret = errors.New("invalid totp secret, expected base32")
+	ErrInvalidURI    = errors.New("invalid otpauth uri")
+)
+
+// encoding base32 without padding, the way authenticator apps show the secret
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a new random secret in base32
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretLen)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("cant generate totp secret: %w", err)
+	}
+	return encoding.EncodeToString(secret), nil
+}
+
+// URI returns the otpauth:// uri of the secret, authenticator apps import it from a QR code
+func URI(issuer string, account string, secret string) string {
+	values := url.Values{}
+	values.Set("secret", secret)
+	values.Set("issuer", issuer)
+	values.Set("algorithm", "SHA1")
+	values.Set("digits", fmt.Sprint(Digits))
+	values.Set("period", fmt.Sprint(int(Period.Seconds())))
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + values.Encode()
+}
+
+// Counter returns the number of the time step of t
+func Counter(t time.Time) int64 {
+	return t.Unix() / int64(Period.Seconds())
+}
+
+// Code returns the code of the secret at the time t
+func Code(secret string, t time.Time) (string, error) {
+	key, err := decode(secret)
+	if err != nil {
+		return "", err
+	}
+	return code(sha1.New, key, Counter(t), Digits), nil
+}
+
+// Validate checks the code allowing the clock drift of skew steps in both directions.
+// Returns the counter of the matched step, the caller must not accept codes with the same or an older counter again
+func Validate(secret string, value string, t time.Time, skew int64) (int64, bool, error) {
+	key, err := decode(secret)
+	if err != nil {
+		return 0, false, err
+	}
+	value = strings.ReplaceAll(value, " ", "")
+	if len(value) != Digits {
+		return 0, false, nil
+	}
+	current := Counter(t)
+	for counter := current - skew; counter <= current+skew; counter++ {
+		if subtle.ConstantTimeCompare([]byte(code(sha1.New, key, counter, Digits)), []byte(value)) == 1 {
+			return counter, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// decode parses the base32 secret, spaces and lower case are allowed as authenticator apps show them
+func decode(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	secret = strings.TrimRight(secret, "=")
+	key, err := encoding.DecodeString(secret)
+	if err != nil || len(key) == 0 {
+		return nil, ErrInvalidSecret
+	}
+	return key, nil
+}
+
+// code HOTP value of the counter, RFC 4226, with the hash of RFC 6238
+func code(hash func() hash.Hash, key []byte, counter int64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+	mac := hmac.New(hash, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	modulo := uint32(1)
+	for i := 0; i < digits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%modulo)
+}
+
+// Algorithm the hash of the codes
+type Algorithm string
+
+const (
+	SHA1   Algorithm = "SHA1"
+	SHA256 Algorithm = "SHA256"
+	SHA512 Algorithm = "SHA512"
+)
+
+// hashes the constructors of the supported algorithms
+var hashes = map[Algorithm]func() hash.Hash{
+	SHA1:   sha1.New,
+	SHA256: sha256.New,
+	SHA512: sha512.New,
+}
+
+// Key the secret of the second factor of an account with the parameters of its codes,
+// as the otpauth:// URI of the service describes it
+type Key struct {
+	Issuer    string
+	Account   string
+	Secret    string
+	Algorithm Algorithm
+	Digits    int
+	Period    time.Duration
+}
+
+// ParseKey reads the otpauth://totp/ URI or the bare base32 secret, the missing parameters are the defaults
+// of authenticator apps: SHA1, 6 digits, 30 seconds. Only 6 or 8 digits are supported
+func ParseKey(value string) (Key, error) {
+	value = strings.TrimSpace(value)
+	key := Key{Algorithm: SHA1, Digits: Digits, Period: Period}
+	if !strings.HasPrefix(strings.ToLower(value), "otpauth:") {
+		key.Secret = value
+		return key, key.check()
+	}
+
+	uri, err := url.Parse(value)
+	if err != nil || !strings.EqualFold(uri.Scheme, "otpauth") || !strings.EqualFold(uri.Host, "totp") {
+		return Key{}, fmt.Errorf("%w: only otpauth://totp/ is supported", ErrInvalidURI)
+	}
+	label := strings.TrimPrefix(uri.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		key.Issuer, key.Account = issuer, strings.TrimSpace(account)
+	} else {
+		key.Account = label
+	}
+
+	query := uri.Query()
+	key.Secret = query.Get("secret")
+	if issuer := query.Get("issuer"); issuer != "" {
+		key.Issuer = issuer
+	}
+	if algorithm := query.Get("algorithm"); algorithm != "" {
+		key.Algorithm = Algorithm(strings.ToUpper(algorithm))
+	}
+	if digits := query.Get("digits"); digits != "" {
+		if key.Digits, err = strconv.Atoi(digits); err != nil {
+			return Key{}, fmt.Errorf("%w: invalid digits %q", ErrInvalidURI, digits)
+		}
+	}
+	if period := query.Get("period"); period != "" {
+		seconds, err := strconv.Atoi(period)
+		if err != nil {
+			return Key{}, fmt.Errorf("%w: invalid period %q", ErrInvalidURI, period)
+		}
+		key.Period = time.Duration(seconds) * time.Second
+	}
+	return key, key.check()
+}
+
+// check validates the secret and the parameters of the key
+func (k Key) check() error {
+	if _, err := decode(k.Secret); err != nil {
+		return err
+	}
+	if _, ok := hashes[k.Algorithm]; !ok {
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidURI, k.Algorithm)
+	}
+	if k.Digits != 6 && k.Digits != 8 {
+		return fmt.Errorf("%w: digits must be 6 or 8", ErrInvalidURI)
+	}
+	if k.Period < time.Second || k.Period%time.Second != 0 {
+		return fmt.Errorf("%w: period must be a positive number of seconds", ErrInvalidURI)
+	}
+	return nil
+}
+
+// URI returns the otpauth:// URI of the key with all the parameters, ParseKey reads it back
+func (k Key) URI() string {
+	values := url.Values{}
+	values.Set("secret", strings.ToUpper(strings.ReplaceAll(k.Secret, " ", "")))
+	if k.Issuer != "" {
+		values.Set("issuer", k.Issuer)
+	}
+	values.Set("algorithm", string(k.Algorithm))
+	values.Set("digits", strconv.Itoa(k.Digits))
+	values.Set("period", strconv.Itoa(int(k.Period.Seconds())))
+	label := k.Account
+	if k.Issuer != "" {
+		label = k.Issuer + ":" + k.Account
+	}
+	return "otpauth://totp/" + url.PathEscape(label) + "?" + values.Encode()
+}
+
+// Code returns the code of the key at the time t
+func (k Key) Code(t time.Time) (string, error) {
+	if err := k.check(); err != nil {
+		return "", err
+	}
+	secret, _ := decode(k.Secret)
+	return code(hashes[k.Algorithm], secret, t.Unix()/int64(k.Period.Seconds()), k.Digits), nil
+}
+
+// Remaining returns how long the code of the time t stays valid
+func (k Key) Remaining(t time.Time) time.Duration {
+	period := int64(k.Period.Seconds())
+	if period <= 0 {
+		return 0
+	}
+	return time.Duration(period-t.Unix()%period) * time.Second
+}
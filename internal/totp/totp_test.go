@@ -0,0 +1,139 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret the secret "12345678901234567890" of the RFC 6238 test vectors in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// The last 6 digits of the SHA1 test vectors from RFC 6238 appendix B
+	for unix, expected := range map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	} {
+		code, err := Code(rfcSecret, time.Unix(unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, expected, code, unix)
+	}
+
+	// Authenticator apps show the secret in groups and in lower case
+	code, err := Code(strings.ToLower("GEZD GNBV GY3T QOJQ GEZD GNBV GY3T QOJQ"), time.Unix(59, 0))
+	require.NoError(t, err)
+	assert.Equal(t, "287082", code)
+
+	_, err = Code("not base32!", time.Now())
+	assert.ErrorIs(t, err, ErrInvalidSecret)
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	previous, err := Code(rfcSecret, now.Add(-Period))
+	require.NoError(t, err)
+
+	counter, ok, err := Validate(rfcSecret, "050471", now, 1)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, Counter(now), counter)
+
+	// The code of the previous step is accepted because of the clock drift
+	counter, ok, err = Validate(rfcSecret, previous, now, 1)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, Counter(now)-1, counter)
+
+	_, ok, err = Validate(rfcSecret, previous, now, 0)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = Validate(rfcSecret, "000000", now, 1)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	other, err := GenerateSecret()
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+
+	uri := URI("GophKeeper", "user@example.com", secret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/GophKeeper:user@example.com?"), uri)
+	assert.Contains(t, uri, "secret="+secret)
+	assert.Contains(t, uri, "issuer=GophKeeper")
+}
+
+func TestKeyCode(t *testing.T) {
+	// The 8 digit test vectors from RFC 6238 appendix B, every hash has the own secret
+	secrets := map[Algorithm]string{
+		SHA1:   encoding.EncodeToString([]byte("12345678901234567890")),
+		SHA256: encoding.EncodeToString([]byte("12345678901234567890123456789012")),
+		SHA512: encoding.EncodeToString([]byte("1234567890123456789012345678901234567890123456789012345678901234")),
+	}
+	vectors := map[Algorithm]map[int64]string{
+		SHA1:   {59: "94287082", 1111111109: "07081804", 20000000000: "65353130"},
+		SHA256: {59: "46119246", 1111111109: "68084774", 20000000000: "77737706"},
+		SHA512: {59: "90693936", 1111111109: "25091201", 20000000000: "47863826"},
+	}
+	for algorithm, codes := range vectors {
+		key := Key{Secret: secrets[algorithm], Algorithm: algorithm, Digits: 8, Period: Period}
+		for unix, expected := range codes {
+			code, err := key.Code(time.Unix(unix, 0))
+			require.NoError(t, err)
+			assert.Equal(t, expected, code, "%s %d", algorithm, unix)
+		}
+	}
+
+	key := Key{Secret: rfcSecret, Algorithm: SHA1, Digits: 6, Period: 60 * time.Second}
+	code, err := key.Code(time.Unix(119, 0))
+	require.NoError(t, err)
+	expected, err := Code(rfcSecret, time.Unix(59, 0))
+	require.NoError(t, err)
+	assert.Equal(t, expected, code, "the 60 seconds step 1 is the 30 seconds step 1")
+	assert.Equal(t, time.Second, key.Remaining(time.Unix(119, 0)))
+	assert.Equal(t, 60*time.Second, key.Remaining(time.Unix(120, 0)))
+}
+
+func TestParseKey(t *testing.T) {
+	key, err := ParseKey("otpauth://totp/ACME%20Co:john@example.com?secret=" + rfcSecret +
+		"&issuer=ACME%20Co&algorithm=sha256&digits=8&period=60")
+	require.NoError(t, err)
+	assert.Equal(t, Key{Issuer: "ACME Co", Account: "john@example.com", Secret: rfcSecret,
+		Algorithm: SHA256, Digits: 8, Period: time.Minute}, key)
+
+	// The URI is read back as is
+	again, err := ParseKey(key.URI())
+	require.NoError(t, err)
+	assert.Equal(t, key, again)
+
+	// The bare secret has the defaults
+	key, err = ParseKey(" gezd gnbv gy3t qojq gezd gnbv gy3t qojq ")
+	require.NoError(t, err)
+	assert.Equal(t, SHA1, key.Algorithm)
+	assert.Equal(t, Digits, key.Digits)
+	assert.Equal(t, Period, key.Period)
+
+	for _, value := range []string{
+		"otpauth://hotp/acme?secret=" + rfcSecret + "&counter=1",
+		"otpauth://totp/acme?secret=" + rfcSecret + "&algorithm=MD5",
+		"otpauth://totp/acme?secret=" + rfcSecret + "&digits=7",
+		"otpauth://totp/acme?secret=" + rfcSecret + "&period=0",
+		"otpauth://totp/acme",
+		"not base32!",
+	} {
+		_, err := ParseKey(value)
+		assert.Error(t, err, value)
+	}
+}